@@ -0,0 +1,319 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midiio"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midireader"
+)
+
+const (
+	magic   = "gmCP"
+	version = 1
+
+	// MaxLen is the maximal length of the data of a record
+	MaxLen = 1 << 20
+)
+
+// Header is the header of a capture
+type Header struct {
+	// Start is the wall time of the start of the capture
+	Start time.Time
+
+	// Resolution is the resolution of the clock that measured the arrival times
+	Resolution time.Duration
+}
+
+// Record are the bytes that arrived at Time after the start of the capture
+type Record struct {
+	Time time.Duration
+	Data []byte
+}
+
+// WriterOption is an option for the Writer
+type WriterOption func(*Writer)
+
+// Resolution sets the resolution of the clock that is written to the header. Default is 1µs.
+func Resolution(d time.Duration) WriterOption {
+	return func(w *Writer) {
+		w.header.Resolution = d
+	}
+}
+
+// Writer appends records to a capture. It is safe for concurrent use.
+type Writer struct {
+	mx     sync.Mutex
+	dst    io.Writer
+	clock  midiio.Clock
+	header Header
+	start  time.Time
+	last   time.Duration
+}
+
+// NewWriter writes the header of a capture to dst and returns a Writer for its records.
+// The arrival times are measured with the given clock (SystemClock, if nil) from the call of NewWriter.
+func NewWriter(dst io.Writer, clock midiio.Clock, options ...WriterOption) (*Writer, error) {
+	if clock == nil {
+		clock = midiio.SystemClock
+	}
+
+	w := &Writer{dst: dst, clock: clock, header: Header{Resolution: time.Microsecond}}
+
+	for _, opt := range options {
+		opt(w)
+	}
+
+	w.start = clock.Now()
+	w.header.Start = w.start
+
+	header := []byte(magic)
+	header = append(header, version)
+	header = binary.AppendUvarint(header, uint64(w.header.Resolution))
+	header = binary.BigEndian.AppendUint64(header, uint64(w.start.UnixNano()))
+
+	if _, err := dst.Write(header); err != nil {
+		return nil, fmt.Errorf("can't write header: %v", err)
+	}
+
+	return w, nil
+}
+
+// Header returns the header that has been written
+func (w *Writer) Header() Header {
+	return w.header
+}
+
+// Write records the given bytes with the current time as arrival time. It implements io.Writer,
+// e.g. for an io.TeeReader of a MIDI port.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	if err := w.write(Record{Time: w.clock.Now().Sub(w.start), Data: p}); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteRecord appends the given record, e.g. with an arrival time that has been measured by the driver.
+// The times of the records must not decrease.
+func (w *Writer) WriteRecord(rec Record) error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	return w.write(rec)
+}
+
+// write appends a record as a single write
+func (w *Writer) write(rec Record) error {
+	if rec.Time < w.last {
+		return fmt.Errorf("can't write record: time %v before %v", rec.Time, w.last)
+	}
+
+	if len(rec.Data) > MaxLen {
+		return fmt.Errorf("can't write record: invalid length %v", len(rec.Data))
+	}
+
+	bf := make([]byte, 0, 2*binary.MaxVarintLen64+len(rec.Data))
+	bf = binary.AppendUvarint(bf, uint64(rec.Time/time.Microsecond))
+	bf = binary.AppendUvarint(bf, uint64(len(rec.Data)))
+	bf = append(bf, rec.Data...)
+
+	if _, err := w.dst.Write(bf); err != nil {
+		return err
+	}
+
+	w.last = rec.Time
+	return nil
+}
+
+// ReaderOption is an option for the Reader
+type ReaderOption func(*Reader)
+
+// Pace lets the Reader return the bytes of each record not before its arrival time, measured with the given clock
+// (SystemClock, if nil) from the first call of Read. Without it, the bytes are returned as fast as possible.
+func Pace(clock midiio.Clock) ReaderOption {
+	return func(r *Reader) {
+		if clock == nil {
+			clock = midiio.SystemClock
+		}
+		r.clock = clock
+	}
+}
+
+// Reader reads a capture. Its Read method replays the recorded bytes (see Pace), while Next returns the records.
+// Both must not be mixed.
+type Reader struct {
+	src    *bufio.Reader
+	header Header
+	clock  midiio.Clock
+	start  time.Time
+
+	// pending are the unread bytes of the current record, time is its arrival time
+	pending []byte
+	time    time.Duration
+}
+
+// NewReader reads the header of the capture from src and returns a Reader for its records.
+func NewReader(src io.Reader, options ...ReaderOption) (*Reader, error) {
+	r := &Reader{src: bufio.NewReader(src)}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	header := make([]byte, len(magic)+1)
+
+	if _, err := io.ReadFull(r.src, header); err != nil || string(header[:len(magic)]) != magic {
+		return nil, fmt.Errorf("invalid capture: missing header")
+	}
+
+	if v := header[len(magic)]; v != version {
+		return nil, fmt.Errorf("invalid capture: unsupported version %v", v)
+	}
+
+	res, err := binary.ReadUvarint(r.src)
+
+	if err != nil || res > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid capture: invalid resolution")
+	}
+
+	var start [8]byte
+
+	if _, err := io.ReadFull(r.src, start[:]); err != nil {
+		return nil, fmt.Errorf("invalid capture: missing start time")
+	}
+
+	r.header = Header{Start: time.Unix(0, int64(binary.BigEndian.Uint64(start[:]))), Resolution: time.Duration(res)}
+	return r, nil
+}
+
+// Header returns the header of the capture
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next record. io.EOF is returned at the end of the capture,
+// io.ErrUnexpectedEOF for a record that is cut off.
+func (r *Reader) Next() (rec Record, err error) {
+	micros, err := binary.ReadUvarint(r.src)
+
+	if err == io.EOF {
+		return rec, io.EOF
+	}
+
+	if err != nil {
+		return rec, unexpected(err)
+	}
+
+	n, err := binary.ReadUvarint(r.src)
+
+	if err != nil {
+		return rec, unexpected(err)
+	}
+
+	if micros > math.MaxInt64/uint64(time.Microsecond) || n > MaxLen {
+		return rec, fmt.Errorf("invalid capture: invalid record")
+	}
+
+	rec.Time = time.Duration(micros) * time.Microsecond
+	rec.Data = make([]byte, n)
+
+	if _, err := io.ReadFull(r.src, rec.Data); err != nil {
+		return rec, unexpected(err)
+	}
+
+	return rec, nil
+}
+
+// unexpected turns io.EOF within a record into io.ErrUnexpectedEOF
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Read reads the recorded bytes. A single Read does not return the bytes of more than one record, so that Time
+// is the arrival time of all returned bytes.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		rec, err := r.Next()
+
+		if err != nil {
+			return 0, err
+		}
+
+		r.pending, r.time = rec.Data, rec.Time
+		r.wait()
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// wait waits for the arrival time of the current record, if the Reader is paced
+func (r *Reader) wait() {
+	if r.clock == nil {
+		return
+	}
+
+	if r.start.IsZero() {
+		r.start = r.clock.Now()
+	}
+
+	for {
+		d := r.start.Add(r.time).Sub(r.clock.Now())
+
+		if d <= 0 {
+			return
+		}
+
+		r.clock.Sleep(d)
+	}
+}
+
+// Time returns the arrival time of the bytes of the last Read
+func (r *Reader) Time() time.Duration {
+	return r.time
+}
+
+// Message is a message of a capture with the arrival time of its last byte
+type Message struct {
+	Time    time.Duration
+	Message midi.Message
+}
+
+// Messages reads the remaining bytes of the capture with a midireader (with the given options) and returns the
+// parsed messages with their arrival times, including the realtime messages, in the order of their arrival.
+// The messages that have been read before an error are returned together with the error.
+func (r *Reader) Messages(options ...midireader.Option) (msgs []Message, err error) {
+	rd := midireader.New(r, func(m realtime.Message) {
+		msgs = append(msgs, Message{Time: r.time, Message: m})
+	}, options...)
+
+	for {
+		msg, err := rd.Read()
+
+		if errors.Is(err, io.EOF) {
+			return msgs, nil
+		}
+
+		if err != nil {
+			return msgs, err
+		}
+
+		msgs = append(msgs, Message{Time: r.time, Message: msg})
+	}
+}
@@ -0,0 +1,222 @@
+package capture
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/smf"
+)
+
+// fakeClock only advances when sleeping
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+// synthetic returns a capture of 25 timing clock messages at 125 BPM (20ms apart), where the odd ones
+// arrive 1ms late, and of a note that arrives in pieces, interrupted by a timing clock message
+func synthetic(t *testing.T) []byte {
+	var bf bytes.Buffer
+	w, err := NewWriter(&bf, &fakeClock{now: time.Unix(100, 0)})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	recs := []Record{
+		{Time: 5 * time.Millisecond, Data: []byte{0x90, 0x3C}},
+		{Time: 7 * time.Millisecond, Data: []byte{0x64}},
+		{Time: 20 * time.Millisecond, Data: []byte{0x80}},
+		// the second timing clock interrupts the note off
+		{Time: 21 * time.Millisecond, Data: []byte{0xF8, 0x3C, 0x00}},
+	}
+
+	for i := 0; i < 25; i++ {
+		if i == 1 {
+			continue
+		}
+
+		at := time.Duration(i) * 20 * time.Millisecond
+
+		if i%2 == 1 {
+			at += time.Millisecond
+		}
+
+		recs = append(recs, Record{Time: at, Data: []byte{0xF8}})
+	}
+
+	sort.SliceStable(recs, func(a, b int) bool { return recs[a].Time < recs[b].Time })
+
+	for _, rec := range recs {
+		if err := w.WriteRecord(rec); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+
+	return bf.Bytes()
+}
+
+func TestMessages(t *testing.T) {
+	rd, err := NewReader(bytes.NewReader(synthetic(t)))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := rd.Header(), (Header{Start: time.Unix(100, 0), Resolution: time.Microsecond}); got != want {
+		t.Errorf("Header() = %+v; want %+v", got, want)
+	}
+
+	msgs, err := rd.Messages()
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var bf bytes.Buffer
+
+	for _, m := range msgs[:6] {
+		fmt.Fprintf(&bf, "%v %s\n", m.Time, m.Message)
+	}
+
+	expected := `0s TimingClock
+7ms channel.NoteOn channel 1 key 60 velocity 100
+21ms TimingClock
+21ms channel.NoteOff channel 1 key 60
+40ms TimingClock
+61ms TimingClock
+`
+
+	if got, want := bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if got, want := len(msgs), 27; got != want {
+		t.Errorf("len(msgs) = %v; want %v", got, want)
+	}
+}
+
+func TestClockJitter(t *testing.T) {
+	rd, _ := NewReader(bytes.NewReader(synthetic(t)))
+	msgs, _ := rd.Messages()
+	j, err := ClockJitter(msgs)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if j.Clocks != 25 || j.Period != 20*time.Millisecond || j.BPM() != 125 {
+		t.Errorf("Clocks = %v, Period = %v, BPM = %v; want 25, 20ms, 125", j.Clocks, j.Period, j.BPM())
+	}
+
+	if j.RMS != time.Millisecond || j.Max != time.Millisecond {
+		t.Errorf("RMS = %v, Max = %v; want 1ms, 1ms", j.RMS, j.Max)
+	}
+
+	for i, dev := range j.Deviations {
+		want := time.Millisecond
+
+		if i%2 == 1 {
+			want = -want
+		}
+
+		if dev != want {
+			t.Errorf("Deviations[%v] = %v; want %v", i, dev, want)
+		}
+	}
+
+	if _, err := ClockJitter(msgs[:2]); err == nil {
+		t.Errorf("expected error for a single timing clock")
+	}
+}
+
+func TestToSMF(t *testing.T) {
+	rd, _ := NewReader(bytes.NewReader(synthetic(t)))
+	msgs, _ := rd.Messages()
+
+	// 1ms per tick
+	s := ToSMF(msgs, smf.MetricTicks(500), 120)
+	var bf bytes.Buffer
+
+	for _, ev := range s.Tracks()[0].Events() {
+		fmt.Fprintf(&bf, "%v %s\n", ev.AbsTicks, ev.Message)
+	}
+
+	fmt.Fprintf(&bf, "%v end\n", s.Tracks()[0].End())
+
+	expected := `0 meta.Tempo BPM: 120.00
+7 channel.NoteOn channel 1 key 60 velocity 100
+21 channel.NoteOff channel 1 key 60
+480 end
+`
+
+	if got, want := bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestPace(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	rd, _ := NewReader(bytes.NewReader(synthetic(t)), Pace(clock))
+	start := clock.now
+	var p [1]byte
+
+	for i, want := range []time.Duration{0, 5 * time.Millisecond, 5 * time.Millisecond, 7 * time.Millisecond, 20 * time.Millisecond} {
+		if _, err := rd.Read(p[:]); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		if got := clock.now.Sub(start); got != want || rd.Time() != want {
+			t.Errorf("[%v] read at %v (Time %v); want %v", i, got, rd.Time(), want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	var bf bytes.Buffer
+	w, _ := NewWriter(&bf, clock, Resolution(time.Millisecond))
+
+	clock.Sleep(1500 * time.Microsecond)
+	io.Copy(w, bytes.NewReader([]byte{0x90, 0x3C, 0x64}))
+
+	if err := w.WriteRecord(Record{Time: time.Millisecond}); err == nil {
+		t.Errorf("expected error for a record before the last one")
+	}
+
+	rd, err := NewReader(bytes.NewReader(bf.Bytes()))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := rd.Header().Resolution, time.Millisecond; got != want {
+		t.Errorf("Resolution = %v; want %v", got, want)
+	}
+
+	rec, err := rd.Next()
+
+	if err != nil || rec.Time != 1500*time.Microsecond || !bytes.Equal(rec.Data, []byte{0x90, 0x3C, 0x64}) {
+		t.Errorf("Next() = %v, % X, %v; want 1.5ms, 90 3C 64, <nil>", rec.Time, rec.Data, err)
+	}
+
+	if _, err := rd.Next(); err != io.EOF {
+		t.Errorf("Next() returned %v at the end; want io.EOF", err)
+	}
+
+	rd, _ = NewReader(bytes.NewReader(bf.Bytes()[:bf.Len()-1]))
+
+	if _, err := rd.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Next() returned %v for a truncated record; want io.ErrUnexpectedEOF", err)
+	}
+
+	if _, err := NewReader(bytes.NewReader([]byte("gmCP\x02"))); err == nil {
+		t.Errorf("expected error for an unknown version")
+	}
+}
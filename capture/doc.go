@@ -0,0 +1,25 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package capture records the raw bytes that arrive from a MIDI port together with their arrival times, e.g. for
+debugging timing problems.
+
+A capture starts with a header (the resolution of the clock and the wall time of the start), followed by records
+of the arrival time in microseconds since the start and the raw bytes that arrived. Records are only appended,
+so a capture can be written while the port is read:
+
+	w, err := capture.NewWriter(file, nil)
+
+	if err != nil {
+		panic(err)
+	}
+
+	rd := midireader.New(io.TeeReader(port, w), nil)
+
+A Reader replays the bytes of a capture, as fast as possible or with their original timing (see Pace), and parses
+them to messages with their arrival times (see Reader.Messages). The messages can be converted to a SMF (see ToSMF)
+and the jitter of the timing clock messages can be measured (see ClockJitter).
+*/
+package capture
@@ -0,0 +1,84 @@
+package capture
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/realtime"
+)
+
+// Jitter are the statistics of the times between the timing clock messages of a capture (see ClockJitter)
+type Jitter struct {
+	// Clocks is the number of timing clock messages
+	Clocks int
+
+	// Period is the mean time between consecutive timing clock messages
+	Period time.Duration
+
+	// Deviations are the differences of the times between consecutive timing clock messages from the Period,
+	// in the order of the messages
+	Deviations []time.Duration
+
+	// RMS is the root mean square and Max the maximum of the absolute Deviations
+	RMS, Max time.Duration
+}
+
+// BPM returns the tempo of the Period, based on 24 timing clock messages per quarter note
+func (j Jitter) BPM() float64 {
+	if j.Period <= 0 {
+		return 0
+	}
+	return float64(time.Minute) / float64(24*j.Period)
+}
+
+// ClockJitter returns the jitter of the arrival times of the timing clock messages within the given messages.
+// The time from the timing clock before a stop message to the one after the next start or continue message
+// is not taken into account. An error is returned, if there are no consecutive timing clock messages.
+func ClockJitter(msgs []Message) (j Jitter, err error) {
+	var deltas []time.Duration
+	var last time.Duration
+	var running bool
+	var sum time.Duration
+
+	for _, m := range msgs {
+		switch m.Message {
+		case realtime.TimingClock:
+			j.Clocks++
+
+			if running {
+				deltas = append(deltas, m.Time-last)
+				sum += m.Time - last
+			}
+
+			last, running = m.Time, true
+		case realtime.Stop:
+			running = false
+		}
+	}
+
+	if len(deltas) == 0 {
+		return j, fmt.Errorf("no consecutive timing clock messages")
+	}
+
+	j.Period = sum / time.Duration(len(deltas))
+	j.Deviations = make([]time.Duration, len(deltas))
+	var squares float64
+
+	for i, d := range deltas {
+		dev := d - j.Period
+		j.Deviations[i] = dev
+		squares += float64(dev) * float64(dev)
+
+		if dev < 0 {
+			dev = -dev
+		}
+
+		if dev > j.Max {
+			j.Max = dev
+		}
+	}
+
+	j.RMS = time.Duration(math.Sqrt(squares / float64(len(deltas))))
+	return j, nil
+}
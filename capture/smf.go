@@ -0,0 +1,35 @@
+package capture
+
+import (
+	"time"
+
+	"github.com/gomidi/midi/midiio"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// ToSMF returns a SMF of format 0 with the given resolution and tempo that contains the channel and sysex messages
+// at the positions of their arrival times (see midiio.Recorder). Other messages are skipped.
+// The track ends at the arrival time of the last message.
+func ToSMF(msgs []Message, ticks smf.MetricTicks, bpm float64) *smftrack.SMF {
+	clock := &replayClock{}
+	rec := midiio.NewRecorder(ticks, meta.FractionalBPM(bpm), clock)
+
+	for _, m := range msgs {
+		clock.now = time.Time{}.Add(m.Time)
+		rec.Write(m.Message)
+	}
+
+	s := smftrack.New(smf.SMF0, ticks)
+	s.AddTrack(rec.Stop())
+	return s
+}
+
+// replayClock is a midiio.Clock that returns the arrival time of the current message
+type replayClock struct {
+	now time.Time
+}
+
+func (c *replayClock) Now() time.Time        { return c.now }
+func (c *replayClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
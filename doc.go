@@ -24,17 +24,29 @@ The underlying core implementations can be found here:
 
   github.com/gomidi/midi/midireader (live reading)
   github.com/gomidi/midi/midiwriter (live writing)
+  github.com/gomidi/midi/wire       (framed message streams, e.g. for pipes)
+  github.com/gomidi/midi/route      (rules for translating live messages)
+  github.com/gomidi/midi/capture    (timestamped captures of live input)
+  github.com/gomidi/midi/trigger    (patterns of live messages that trigger actions)
+  github.com/gomidi/midi/live       (clips looped in realtime for live looping)
   github.com/gomidi/midi/smf/smfreader   (SMF reading)
   github.com/gomidi/midi/smf/smfwriter   (SMF writing)
   github.com/gomidi/midi/smf/smftrack    (SMF modification)
+  github.com/gomidi/midi/smf/importer    (SMF from step sequencer grids)
+  github.com/gomidi/midi/smf/stepinput   (step entry of notes into SMF tracks)
 
 The core of the MIDI messages that can be written or analyzed can be found here:
 
   github.com/gomidi/midi/midimessage/channel    (Channel Messages)
   github.com/gomidi/midi/midimessage/meta       (Meta Messages)
   github.com/gomidi/midi/midimessage/realtime   (System Realtime Messages)
+  github.com/gomidi/midi/midimessage/status     (Status Byte Classification)
   github.com/gomidi/midi/midimessage/syscommon  (System Common messages)
   github.com/gomidi/midi/midimessage/sysex      (System Exclusive messages)
+  github.com/gomidi/midi/midimessage/sysex/roland (Roland DT1/RQ1 messages and checksums)
+  github.com/gomidi/midi/midimessage/sysex/yamaha (Yamaha bulk dumps and checksums)
+  github.com/gomidi/midi/midimessage/sysex/msc    (MIDI Show Control messages)
+  github.com/gomidi/midi/midibinary             (big-endian fields of SMF and meta messages)
 
 Please keep in mind that that not all kinds of MIDI messages can be used in both scenarios.
 
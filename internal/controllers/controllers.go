@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// Stream returns the stream a continuous controller message belongs to and its value scaled to 7bit.
+// Streams are distinguished by the type of message, channel, controller and key.
+func Stream(msg midi.Message) (stream [4]uint8, value int, ok bool) {
+	switch v := msg.(type) {
+	case channel.ControlChange:
+		return [4]uint8{0, v.Channel(), v.Controller()}, int(v.Value()), true
+	case channel.Pitchbend:
+		return [4]uint8{1, v.Channel()}, int(v.Value()) / 128, true
+	case channel.Aftertouch:
+		return [4]uint8{2, v.Channel()}, int(v.Pressure()), true
+	case channel.PolyAftertouch:
+		return [4]uint8{3, v.Channel(), v.Key()}, int(v.Pressure()), true
+	}
+	return
+}
@@ -13,97 +13,64 @@ in the github.com/afandian/go-midi package of Joe Wass.
 See the file midi_functions.go for the original functions.
 */
 
-// ReadUint16 reads a 2-byte 16 bit integer from a Reader.
-// It returns the 16-bit value and an error.
-// This is a slightly modified variant of the parseUint16 function
+// ReadVarLength reads a variable length value from a Reader.
+// It returns the [up to] 32-bit value and an error: io.EOF, if the reader ends before the value and
+// midi.ErrUnexpectedEOF, if it ends within the value.
+// This is a slightly modified variant of the parseVarLength function
 // from Joe Wass. See the file midi_functions.go for the original.
-func ReadUint16(rd io.Reader) (uint16, error) {
-	b, err := ReadNBytes(2, rd)
-
-	if err != nil {
-		return 0, err
+func ReadVarLength(reader io.Reader) (uint32, error) {
+	if sl, is := reader.(Slicer); is {
+		return readVarLengthSlicer(sl)
 	}
 
-	var val uint16 = 0x00
-	val |= uint16(b[1]) << 0
-	val |= uint16(b[0]) << 8
-
-	return val, nil
-}
-
-// ReadUint24 parse a 3-byte 24 bit integer from a Reader.
-// It returns the 32-bit value and an error.
-// This is a slightly modified variant of the parseUint24 function
-// from Joe Wass. See the file midi_functions.go for the original.
-func ReadUint24(rd io.Reader) (uint32, error) {
-	b, err := ReadNBytes(3, rd)
-
-	if err != nil {
-		return 0, err
-	}
+	// Result value
+	var result uint32 = 0x00
 
-	var val uint32 = 0x00
-	val |= uint32(b[2]) << 0
-	val |= uint32(b[1]) << 8
-	val |= uint32(b[0]) << 16
+	// RTFM.
+	for first := true; ; first = false {
+		b, err := ReadByte(reader)
 
-	return val, nil
-}
+		switch {
+		case err == io.EOF && !first:
+			return result, midi.ErrUnexpectedEOF
+		case err != nil:
+			return result, err
+		}
 
-// ReadUint32 parse a 4-byte 32 bit integer from a Reader.
-// It returns the 32-bit value and an error.
-// This is a slightly modified variant of the parseUint32 function
-// from Joe Wass. See the file midi_functions.go for the original.
-func ReadUint32(rd io.Reader) (uint32, error) {
-	b, err := ReadNBytes(4, rd)
+		result = result<<7 | (uint32(b) & 0x7f)
 
-	if err != nil {
-		return 0, err
+		if b&0x80 == 0 {
+			return result, nil
+		}
 	}
-
-	var val uint32 = 0x00
-	val |= uint32(b[3]) << 0
-	val |= uint32(b[2]) << 8
-	val |= uint32(b[1]) << 16
-	val |= uint32(b[0]) << 24
-
-	return val, nil
 }
 
-// ReadVarLength reads a variable length value from a Reader.
-// It returns the [up to] 32-bit value and an error.
-// This is a slightly modified variant of the parseVarLength function
-// from Joe Wass. See the file midi_functions.go for the original.
-func ReadVarLength(reader io.Reader) (uint32, error) {
+// readVarLengthSlicer reads a variable length quantity from a Slicer without allocations
+func readVarLengthSlicer(sl Slicer) (uint32, error) {
+	var result uint32
 
-	// Single byte buffer to read byte by byte.
-	var buffer []byte = make([]uint8, 1)
-
-	// The number of bytes returned.
-	// Should always be 1 unless we reach the EOF
-	var num int = 1
-
-	// Result value
-	var result uint32 = 0x00
+	for first := true; ; first = false {
+		b, err := sl.Slice(1)
 
-	// RTFM.
-	var first = true
-	for (first || (buffer[0]&0x80 == 0x80)) && (num > 0) {
-		result = result << 7
+		switch {
+		case err == io.EOF && first:
+			return result, io.EOF
+		case err != nil:
+			return result, midi.ErrUnexpectedEOF
+		}
 
-		num, _ = reader.Read(buffer)
-		result |= (uint32(buffer[0]) & 0x7f)
-		first = false
-	}
+		result = result<<7 | uint32(b[0]&0x7f)
 
-	if num == 0 && !first {
-		return result, midi.ErrUnexpectedEOF
+		if b[0]&0x80 == 0 {
+			return result, nil
+		}
 	}
-
-	return result, nil
 }
 
-// ReadVarLengthData reads data that is prefixed by a varLength that tells the length of the data
+// ReadVarLengthData reads data that is prefixed by a varLength that tells the length of the data.
+// If the reader is a Slicer, the data is a sub-slice of its data.
+// It returns io.EOF, if the reader ends before the length and midi.ErrUnexpectedEOF, if it ends within the length
+// or the data.
 //
 // This is a slightly modified variant of the parseText function
 // from Joe Wass. See the file midi_functions.go for the original.
@@ -114,12 +81,10 @@ func ReadVarLengthData(reader io.Reader) ([]byte, error) {
 		return []byte{}, err
 	}
 
-	var buffer []byte = make([]byte, length)
-
-	num, err := reader.Read(buffer)
+	b, err := ReadNBytes(int(length), reader)
 
 	// If we couldn't read the entire expected-length buffer, that's a problem.
-	if num != int(length) {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
 		return []byte{}, midi.ErrUnexpectedEOF
 	}
 
@@ -128,21 +93,9 @@ func ReadVarLengthData(reader io.Reader) ([]byte, error) {
 		return []byte{}, err
 	}
 
-	return buffer, nil
+	return b, nil
 }
 
-// ParseStatus parses the status byte and returns type and channel
-//
-// This is a slightly modified variant of the readStatusByte function
-// from Joe Wass. See the file midi_functions.go for the original.
-func ParseStatus(b byte) (messageType uint8, messageChannel uint8) {
-	messageType = (b & 0xF0) >> 4
-	messageChannel = b & 0x0F
-	return
-}
-
-// -----------------------------------------
-
 // ParseUint7 parses a 7-bit bit integer from a byte, ignoring the high bit.
 //
 // This is a slightly modified variant of the parseUint7 function
@@ -187,7 +140,7 @@ const (
 // This is a slightly modified variant of the keySignatureFromSharpsOrFlats function
 // from Joe Wass. See the file music.go for the original.
 func KeyFromSharpsOrFlats(sharpsOrFlats int8, mode uint8) uint8 {
-	tmp := int(sharpsOrFlats * 7)
+	tmp := int(sharpsOrFlats) * 7
 
 	// Relative Minor.
 	if mode == minorMode {
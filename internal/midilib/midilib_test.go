@@ -3,8 +3,11 @@ package midilib
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"testing"
+	"testing/iotest"
 
 	"github.com/gomidi/midi/internal/vlq"
 )
@@ -30,32 +33,6 @@ func TestKeyFromSharpsOrFlats(t *testing.T) {
 	}
 }
 
-func TestParseStatus(t *testing.T) {
-	var tests = []struct {
-		byte           byte
-		messageType    uint8
-		messageChannel uint8
-	}{
-		{0xF0, 15, 0},  /* sysex */
-		{0xF7, 15, 7},  /* sysex */
-		{0xFF, 15, 15}, /* meta */
-		{0xF8, 15, 8},  /* reatime MTC */
-		{0xC0, 12, 0},  /* prog change chan 0 */
-		{0x92, 9, 2},   /* note on chan2 */
-		{0x81, 8, 1},   /* note off chan 1 */
-
-	}
-
-	for _, test := range tests {
-		typ, ch := ParseStatus(test.byte)
-
-		if typ != test.messageType || ch != test.messageChannel {
-			t.Errorf("ParseStatus(%X) = %v,%v; want %v,%v", test.byte, typ, ch, test.messageType, test.messageChannel)
-		}
-	}
-
-}
-
 func TestParsePitchWheelVals(t *testing.T) {
 	var tests = []struct {
 		byte0    byte
@@ -133,6 +110,46 @@ func TestVLQ(t *testing.T) {
 
 }
 
+func TestReadTruncated(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []byte
+		read  func(io.Reader) error
+	}{
+		{"ReadVarLength", []byte{0x81, 0x80, 0x00}, func(rd io.Reader) error { _, err := ReadVarLength(rd); return err }},
+		{"ReadVarLengthData", []byte{0x03, 0x61, 0x62, 0x63}, func(rd io.Reader) error { _, err := ReadVarLengthData(rd); return err }},
+		{"ReadNBytes", []byte{0x01, 0x02, 0x03}, func(rd io.Reader) error { _, err := ReadNBytes(3, rd); return err }},
+		{"ReadByte", []byte{0x01}, func(rd io.Reader) error { _, err := ReadByte(rd); return err }},
+	}
+
+	var readers = map[string]func([]byte) io.Reader{
+		"bytes.Reader": func(b []byte) io.Reader { return bytes.NewReader(b) },
+		"SliceReader":  func(b []byte) io.Reader { return NewSliceReader(b) },
+		"OneByteReader": func(b []byte) io.Reader {
+			return iotest.OneByteReader(bytes.NewReader(b))
+		},
+	}
+
+	for _, test := range tests {
+		for rdName, newReader := range readers {
+			if err := test.read(newReader(test.input)); err != nil {
+				t.Errorf("%s(% X) with %s returned error: %v", test.name, test.input, rdName, err)
+			}
+
+			for n := 0; n < len(test.input); n++ {
+				err := test.read(newReader(test.input[:n]))
+
+				switch {
+				case n == 0 && err != io.EOF:
+					t.Errorf("%s(% X) with %s = %v; want io.EOF", test.name, test.input[:n], rdName, err)
+				case n > 0 && !errors.Is(err, io.ErrUnexpectedEOF):
+					t.Errorf("%s(% X) with %s = %v; want io.ErrUnexpectedEOF", test.name, test.input[:n], rdName, err)
+				}
+			}
+		}
+	}
+}
+
 func TestLibBits(t *testing.T) {
 
 	tests := []struct {
@@ -146,8 +163,6 @@ func TestLibBits(t *testing.T) {
 		{ClearBitU8(50, 4), "ClearBitU8(50, 4)", "100010"},
 		{hasBitU8(50, 4), "hasBitU8(50, 4)", "%!b(bool=true)"},
 		{hasBitU8(50, 3), "hasBitU8(50, 3)", "%!b(bool=false)"},
-		{IsStatusByte(50), "IsStatusByte(50)", "%!b(bool=false)"},
-		{IsStatusByte(128), "IsStatusByte(128)", "%!b(bool=true)"},
 		{IsChannelMessage(128), "IsChannelMessage(128)", "%!b(bool=true)"},
 		{IsChannelMessage(121), "IsChannelMessage(121)", "%!b(bool=false)"},
 	}
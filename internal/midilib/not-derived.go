@@ -34,11 +34,10 @@ func MsbLsbSigned(n int16) uint16 {
 	return msbLsbUnsigned(uint16(n + 8192))
 }
 
-// takes a 14bit uint and pads it to 16 bit like in the specs for e.g. pitchbend
+// takes a 14bit uint and pads it to 16 bit like in the specs for e.g. pitchbend.
+// Higher bits are cut.
 func msbLsbUnsigned(n uint16) uint16 {
-	if n > 16383 {
-		panic("n must not overflow 14bits (max 16383)")
-	}
+	n &= 0x3FFF
 
 	lsb := n << 8
 	lsb = clearBitU16(lsb, 15)
@@ -59,25 +58,21 @@ func IsChannelMessage(b uint8) bool {
 	return !hasBitU8(b, 6)
 }
 
-// IsStatusByte returns if the given byte is a status byte
-func IsStatusByte(b uint8) bool {
-	return hasBitU8(b, 7)
-}
-
-// ReadNBytes reads n bytes from the reader
+// ReadNBytes reads n bytes from the reader.
+// If the reader is a Slicer, the bytes are a sub-slice of its data.
+// Like io.ReadFull, it returns io.EOF, if no byte could be read and io.ErrUnexpectedEOF, if the reader ends
+// after some of the bytes.
 func ReadNBytes(n int, rd io.Reader) ([]byte, error) {
-	var b []byte = make([]byte, n)
-	num, err := rd.Read(b)
-
-	// if num is correct, we are not interested in io.EOF errors
-	if num == n {
-		err = nil
+	if sl, is := rd.(Slicer); is {
+		return sl.Slice(n)
 	}
 
-	return b, err
+	var b []byte = make([]byte, n)
+	num, err := io.ReadFull(rd, b)
+	return b[:num], err
 }
 
-// ReadByte reads a byte from the reader
+// ReadByte reads a byte from the reader. It returns io.EOF, if the reader ends.
 func ReadByte(rd io.Reader) (byte, error) {
 	b, err := ReadNBytes(1, rd)
 
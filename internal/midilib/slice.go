@@ -0,0 +1,60 @@
+package midilib
+
+import (
+	"io"
+)
+
+// Slicer is implemented by readers of data in memory that can return the next bytes as a sub-slice
+// of their data instead of copying them.
+type Slicer interface {
+	// Slice returns the next n bytes as a sub-slice of the data and advances the reader.
+	// If there are less than n bytes, the remaining bytes are returned together with io.ErrUnexpectedEOF
+	// (io.EOF, if there are no bytes left).
+	Slice(n int) ([]byte, error)
+}
+
+// SliceReader is a reader of a byte slice that implements Slicer
+type SliceReader struct {
+	data []byte
+	pos  int
+}
+
+// NewSliceReader returns a SliceReader of the given data
+func NewSliceReader(data []byte) *SliceReader {
+	return &SliceReader{data: data}
+}
+
+// Read reads the next bytes into p
+func (r *SliceReader) Read(p []byte) (n int, err error) {
+	if r.pos >= len(r.data) {
+		return 0, io.EOF
+	}
+
+	n = copy(p, r.data[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+// Slice returns the next n bytes as a sub-slice of the data (see Slicer)
+func (r *SliceReader) Slice(n int) ([]byte, error) {
+	rest := len(r.data) - r.pos
+
+	switch {
+	case rest == 0 && n > 0:
+		return nil, io.EOF
+	case rest < n:
+		b := r.data[r.pos:]
+		r.pos = len(r.data)
+		return b, io.ErrUnexpectedEOF
+	}
+
+	// the capacity is limited, so that appending to the slice does not overwrite the data
+	b := r.data[r.pos : r.pos+n : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// Len returns the number of bytes that have not been read yet
+func (r *SliceReader) Len() int {
+	return len(r.data) - r.pos
+}
@@ -3,6 +3,7 @@ package runningstatus
 import (
 	"github.com/gomidi/midi"
 	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/midimessage/status"
 
 	"io"
 )
@@ -18,10 +19,10 @@ type reader struct {
 	status byte
 }
 
-func (r *reader) read(canary byte) (status byte, changed bool) {
+func (r *reader) read(canary byte) (st byte, changed bool) {
 
 	// channel/Voice Category Status
-	if canary >= 0x80 && canary <= 0xEF {
+	if status.IsChannel(canary) {
 		r.status = canary
 		changed = true
 	}
@@ -46,10 +47,10 @@ type livereader struct {
 
 // Read reads the status byte from the given canary, while respecting
 // running status and returns whether the status has changed
-func (r *livereader) Read(canary byte) (status byte, changed bool) {
+func (r *livereader) Read(canary byte) (st byte, changed bool) {
 
 	// here we clear for System Common Category messages
-	if canary >= 0xF0 && canary <= 0xF7 {
+	if status.IsSysEx(canary) || status.IsSystemCommon(canary) {
 		r.status = 0
 		return r.status, true
 	}
@@ -63,7 +64,7 @@ type smfreader struct {
 
 // Read reads the status byte from the given canary, while respecting
 // running status and returns whether the status has changed
-func (r *smfreader) Read(canary byte) (status byte, changed bool) {
+func (r *smfreader) Read(canary byte) (st byte, changed bool) {
 
 	// here we clear for meta messages
 	if canary == 0xFF || canary == 0xF0 || canary == 0xF7 {
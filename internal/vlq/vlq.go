@@ -42,6 +42,31 @@ func Encode(n uint32) (out []byte) {
 	return
 }
 
+// Append appends the variable length quantity of the given value to dst and returns the extended slice
+func Append(dst []byte, n uint32) []byte {
+	l := Len(n)
+
+	for i := l - 1; i >= 0; i-- {
+		b := byte(n>>(7*uint(i))) & vlqMask
+
+		if i > 0 {
+			b |= vlqContinue
+		}
+
+		dst = append(dst, b)
+	}
+
+	return dst
+}
+
+// Len returns the number of bytes of the variable length quantity of the given value, without encoding it
+func Len(n uint32) (l int) {
+	for l = 1; n >= vlqContinue; l++ {
+		n /= vlqContinue
+	}
+	return
+}
+
 // Decode decodes a variable length quantity
 func Decode(source []byte) (num uint32) {
 
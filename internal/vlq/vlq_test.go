@@ -44,3 +44,21 @@ func TestDecode(t *testing.T) {
 	}
 
 }
+
+func TestLen(t *testing.T) {
+	for _, test := range tests {
+		if got, want := Len(test.num), len(test.bytes); got != want {
+			t.Errorf("Len(%#v) = %d; want %d", test.num, got, want)
+		}
+	}
+}
+
+func TestAppend(t *testing.T) {
+	for _, test := range tests {
+		var b = Append([]byte{0xFF}, test.num)
+
+		if got, want := fmt.Sprintf("%X", b), fmt.Sprintf("FF%X", test.bytes); got != want {
+			t.Errorf("Append(%#v) = %#v; want %#v", test.num, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package live
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// Clip is a pattern of MIDI messages that a Session plays in a loop of a number of bars
+type Clip struct {
+	timeFormat smf.TimeFormat
+	bars       uint64
+	events     []clipEvent
+}
+
+// clipEvent is a message of a clip with its ticks from the start of the clip
+type clipEvent struct {
+	tick uint64
+	msg  midi.Message
+}
+
+type clipConfig struct {
+	channel int
+}
+
+// ClipOption is an option for NewClip
+type ClipOption func(*clipConfig)
+
+// OutputChannel moves the channel messages of the clip to the given channel (0-15), e.g. to layer the clips of
+// different instruments onto one channel, or to play the same region on different channels.
+func OutputChannel(ch uint8) ClipOption {
+	return func(c *clipConfig) {
+		c.channel = int(ch)
+	}
+}
+
+// NewClip returns a clip of the messages of the given region that loops every given number of bars.
+// The messages of the tracks of the region are merged. Meta messages are dropped, since they can't be used live,
+// and the messages after the given number of bars are not played.
+//
+// The bars are measured with the MeterMap of the Session from the start of the bar in which the clip is launched,
+// so that the loop follows the changes of the time signature.
+// An error is returned, if the number of bars is 0 or the output channel is not valid.
+func NewClip(r smftrack.Region, bars uint64, options ...ClipOption) (*Clip, error) {
+	c := clipConfig{channel: -1}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	if bars == 0 {
+		return nil, fmt.Errorf("can't create clip of 0 bars")
+	}
+
+	if c.channel > 15 {
+		return nil, fmt.Errorf("can't create clip: invalid output channel %v", c.channel)
+	}
+
+	s := smftrack.New(smf.SMF1, r.TimeFormat())
+
+	for _, tr := range r.Tracks() {
+		s.AddTrack(tr)
+	}
+
+	clip := &Clip{timeFormat: r.TimeFormat(), bars: bars}
+
+	for _, ev := range s.Merged() {
+		msg := ev.Message
+
+		switch v := msg.(type) {
+		case meta.Message:
+			continue
+		case channel.Message:
+			if c.channel >= 0 {
+				msg = channel.SetChannel(v, uint8(c.channel))
+			}
+		}
+
+		clip.events = append(clip.events, clipEvent{tick: ev.AbsTicks, msg: msg})
+	}
+
+	return clip, nil
+}
+
+// Bars returns the number of bars of the loop of the clip
+func (c *Clip) Bars() uint64 {
+	return c.bars
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package live plays clips of MIDI messages in loops, e.g. for live looping.
+
+A Clip is made of a region of a SMF (see smftrack.CopyRegion) and loops every given number of bars.
+A Session plays the launched clips in realtime to a midi.Writer. The launches are quantized to the next bar or
+beat, based on the tempo and time signatures of a conductor SMF:
+
+	clip, err := live.NewClip(smftrack.CopyRegion(song, 0, 3840), 2)
+
+	if err != nil {
+		panic(err)
+	}
+
+	session, err := live.NewSession(song, out)
+
+	if err != nil {
+		panic(err)
+	}
+
+	go session.Run()
+
+	session.Launch(clip, live.QuantizeBar)
+	// ...
+	session.Stop(clip, live.StopAtBarEnd)
+
+Multiple clips are layered, each clip may be moved to another channel (see OutputChannel). The notes of a clip that
+are sounding, when it loops or stops, are ended. If another clip holds the same key on the same channel, the note is
+ended together with the note of that clip.
+*/
+package live
@@ -0,0 +1,511 @@
+package live
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midiio"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// maxSleep is the maximal duration of a single sleep of Run with a Clock that is not a WakeClock, so that Close,
+// Launch and Stop are noticed in time
+const maxSleep = 20 * time.Millisecond
+
+// maxIdle is the maximal duration of a single sleep of Run with a WakeClock, while nothing is scheduled
+const maxIdle = time.Second
+
+// Quantization is the grid to which Launch quantizes the start of a clip
+type Quantization int
+
+const (
+	// QuantizeBar starts the clip at the start of the next bar
+	QuantizeBar Quantization = iota
+
+	// QuantizeBeat starts the clip at the next beat, i.e. the next note value of the denominator of the time
+	// signature (e.g. the next eighth note in 6/8)
+	QuantizeBeat
+
+	// QuantizeNone starts the clip immediately
+	QuantizeNone
+)
+
+// StopMode is the way Stop stops a clip
+type StopMode int
+
+const (
+	// StopImmediately stops the clip at the current position
+	StopImmediately StopMode = iota
+
+	// StopAtBarEnd stops the clip at the end of the current bar
+	StopAtBarEnd
+)
+
+// SessionOption is an option for NewSession
+type SessionOption func(*Session)
+
+// UseClock sets the Clock of the Session, e.g. the Clock of a Player. Default is midiio.SystemClock.
+func UseClock(c midiio.Clock) SessionOption {
+	return func(s *Session) {
+		s.clock = c
+	}
+}
+
+// Session plays launched clips in realtime to a midi.Writer. The position of the Session is measured in ticks
+// since its creation, with the tempo and the time signatures of a conductor SMF.
+//
+// Launch and Stop may be called from other goroutines, while Run is playing.
+type Session struct {
+	mu    sync.Mutex
+	clock midiio.Clock
+	out   midi.Writer
+	start time.Time
+
+	timeFormat smf.TimeFormat
+	ticks4th   uint64
+	tempo      *smftrack.TempoMap
+	meter      *smftrack.MeterMap
+
+	// launches are the clips that are launched at a later tick, playing the clips that are playing in the order
+	// of their launches
+	launches []launch
+	playing  []*playback
+
+	// notes are the notes of all playing clips that are sounding and deferred counts the note off messages that are
+	// written, when the key is not held by any clip anymore (see endNotes)
+	notes    channel.NoteTracker
+	deferred [16][128]uint8
+
+	closed atomic.Bool
+
+	// wake interrupts the sleep of a WakeClock, when something has been launched or stopped
+	wake chan struct{}
+}
+
+// launch is a clip that is launched at a tick
+type launch struct {
+	clip *Clip
+	tick uint64
+}
+
+// playback is a clip that is playing
+type playback struct {
+	clip *Clip
+
+	// loopStart is the tick of the start of the current loop, loopEnd the tick of its end and next the index of
+	// the next event of the clip
+	loopStart uint64
+	loopEnd   uint64
+	next      int
+
+	// stopAt is the tick at which the clip stops, if stop is true
+	stop   bool
+	stopAt uint64
+
+	// notes are the notes of the clip that are sounding
+	notes channel.NoteTracker
+}
+
+// NewSession returns a Session that plays to the given writer. The tempo and the time signatures are taken from
+// the given conductor SMF (see smftrack.SMF.TempoMap and smftrack.SMF.MeterMap), which must have a metric time
+// format. The position 0 of the Session is the time of its creation.
+func NewSession(conductor *smftrack.SMF, out midi.Writer, options ...SessionOption) (*Session, error) {
+	ti, isMetric := conductor.TimeFormat().(smf.MetricTicks)
+
+	if !isMetric || ti == 0 {
+		return nil, fmt.Errorf("only metric timeformat supported, sorry")
+	}
+
+	s := &Session{
+		clock:      midiio.SystemClock,
+		out:        out,
+		timeFormat: ti,
+		ticks4th:   uint64(ti.Ticks4th()),
+		tempo:      conductor.TempoMap(),
+		meter:      conductor.MeterMap(),
+		wake:       make(chan struct{}, 1),
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	s.start = s.clock.Now()
+	return s, nil
+}
+
+// Position returns the current position of the Session in ticks
+func (s *Session) Position() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now()
+}
+
+// now returns the last tick that has been reached
+func (s *Session) now() uint64 {
+	elapsed := s.clock.Now().Sub(s.start)
+	tick := s.tempo.Ticks(elapsed)
+
+	// Ticks returns the nearest tick
+	if tick > 0 && s.tempo.Time(tick) > elapsed {
+		tick--
+	}
+
+	return tick
+}
+
+// quantize returns the tick of the given quantization at or after the given tick
+func (s *Session) quantize(tick uint64, q Quantization) uint64 {
+	bar, start := s.meter.Bar(tick)
+
+	switch q {
+	case QuantizeNone:
+		return tick
+	case QuantizeBeat:
+		beat := 4 * s.ticks4th / uint64(s.meter.MeterAt(tick).Denominator)
+
+		if beat == 0 {
+			return tick
+		}
+
+		return start + (tick-start+beat-1)/beat*beat
+	default:
+		if start == tick {
+			return tick
+		}
+
+		return s.meter.BarStart(bar + 1)
+	}
+}
+
+// Launch launches the given clip at the tick of the given quantization and returns the tick. If the clip is playing,
+// it restarts at that tick; a launch of the clip that is pending is replaced.
+// An error is returned, if the time format of the clip differs from the time format of the Session.
+func (s *Session) Launch(c *Clip, q Quantization) (uint64, error) {
+	if c.timeFormat != s.timeFormat {
+		return 0, fmt.Errorf("can't launch clip with time format %s in session with time format %s", c.timeFormat, s.timeFormat)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tick := s.quantize(s.now(), q)
+	s.cancelLaunch(c)
+	s.launches = append(s.launches, launch{clip: c, tick: tick})
+	s.wakeUp()
+	return tick, nil
+}
+
+// Stop stops the given clip and returns the tick at which it stops. The notes of the clip that are sounding at
+// that tick are ended. A launch of the clip that is pending is cancelled.
+func (s *Session) Stop(c *Clip, mode StopMode) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tick := s.now()
+
+	if mode == StopAtBarEnd {
+		bar, _ := s.meter.Bar(tick)
+		tick = s.meter.BarStart(bar + 1)
+	}
+
+	s.cancelLaunch(c)
+
+	for _, pb := range s.playing {
+		if pb.clip == c && (!pb.stop || tick < pb.stopAt) {
+			pb.stop, pb.stopAt = true, tick
+		}
+	}
+
+	s.wakeUp()
+	return tick
+}
+
+// cancelLaunch removes the pending launch of the given clip
+func (s *Session) cancelLaunch(c *Clip) {
+	launches := s.launches[:0]
+
+	for _, l := range s.launches {
+		if l.clip != c {
+			launches = append(launches, l)
+		}
+	}
+
+	s.launches = launches
+}
+
+// wakeUp interrupts the sleep of Run
+func (s *Session) wakeUp() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Update writes the messages of the playing clips up to the current position, and processes the launches and
+// stops until then. Run calls it in time; it may be called instead of Run, e.g. from the loop of an application
+// that has its own timing. The first error of the writer is returned.
+func (s *Session) Update() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.process(s.now())
+}
+
+// process processes everything up to the given tick, tick by tick
+func (s *Session) process(until uint64) error {
+	for {
+		tick, has := s.nextTick()
+
+		if !has || tick > until {
+			return nil
+		}
+
+		if err := s.step(tick); err != nil {
+			return err
+		}
+	}
+}
+
+// nextTick returns the next tick at which something has to be done
+func (s *Session) nextTick() (tick uint64, has bool) {
+	for _, l := range s.launches {
+		if !has || l.tick < tick {
+			tick, has = l.tick, true
+		}
+	}
+
+	for _, pb := range s.playing {
+		if t := pb.nextTick(); !has || t < tick {
+			tick, has = t, true
+		}
+	}
+
+	return
+}
+
+// step processes the given tick: first the clips that stop or loop end their notes, then the launched clips start
+// and finally the messages of the playing clips at the tick are written
+func (s *Session) step(tick uint64) error {
+	playing := s.playing[:0]
+
+	for _, pb := range s.playing {
+		if pb.stop && pb.stopAt <= tick {
+			if err := s.endNotes(pb); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if pb.loopEnd <= tick {
+			if err := s.endNotes(pb); err != nil {
+				return err
+			}
+
+			s.loop(pb, tick)
+		}
+
+		playing = append(playing, pb)
+	}
+
+	s.playing = playing
+	launches := s.launches[:0]
+
+	for _, l := range s.launches {
+		if l.tick > tick {
+			launches = append(launches, l)
+			continue
+		}
+
+		// a playing clip restarts
+		playing := s.playing[:0]
+
+		for _, pb := range s.playing {
+			if pb.clip != l.clip {
+				playing = append(playing, pb)
+				continue
+			}
+
+			if err := s.endNotes(pb); err != nil {
+				return err
+			}
+		}
+
+		pb := &playback{clip: l.clip}
+		s.loop(pb, tick)
+		s.playing = append(playing, pb)
+	}
+
+	s.launches = launches
+
+	for _, pb := range s.playing {
+		for ; pb.next < len(pb.clip.events); pb.next++ {
+			ev := pb.clip.events[pb.next]
+
+			if pb.loopStart+ev.tick > tick || pb.loopStart+ev.tick >= pb.loopEnd {
+				break
+			}
+
+			cm, isChannel := ev.msg.(channel.Message)
+
+			if isChannel {
+				pb.notes.Track(cm)
+				s.notes.Track(cm)
+			}
+
+			if err := s.out.Write(ev.msg); err != nil {
+				return err
+			}
+
+			if isChannel {
+				if err := s.releaseDeferred(cm); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// loop starts a loop of the given playback at the given tick. The loop lasts the bars of the clip, measured from
+// the start of the bar of the tick.
+func (s *Session) loop(pb *playback, tick uint64) {
+	bar, start := s.meter.Bar(tick)
+	pb.loopStart = tick
+	pb.loopEnd = tick + s.meter.BarStart(bar+pb.clip.bars) - start
+	pb.next = 0
+}
+
+// nextTick returns the tick of the next event, the end of the loop or the stop of the playback, whichever
+// comes first
+func (pb *playback) nextTick() uint64 {
+	tick := pb.loopEnd
+
+	if pb.next < len(pb.clip.events) {
+		if t := pb.loopStart + pb.clip.events[pb.next].tick; t < tick {
+			tick = t
+		}
+	}
+
+	if pb.stop && pb.stopAt < tick {
+		tick = pb.stopAt
+	}
+
+	return tick
+}
+
+// endNotes ends the sounding notes of the given playback. If another playing clip holds the same key on the same
+// channel, the note off messages are deferred until that clip ends the key, so that its note is not cut.
+func (s *Session) endNotes(pb *playback) error {
+	for ch := uint8(0); ch < 16; ch++ {
+		for _, key := range pb.notes.Active(ch) {
+			off := channel.Channel(ch).NoteOff(key)
+
+			for n := pb.notes.Count(ch, key); n > 0; n-- {
+				s.notes.Track(off)
+
+				if s.deferred[ch][key] < 255 {
+					s.deferred[ch][key]++
+				}
+			}
+
+			if err := s.writeDeferred(ch, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	pb.notes.Reset()
+	return nil
+}
+
+// releaseDeferred writes the deferred note off messages for the key of the given message, if it is a note message
+func (s *Session) releaseDeferred(msg channel.Message) error {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		return s.writeDeferred(v.Channel(), v.Key())
+	case channel.NoteOff:
+		return s.writeDeferred(v.Channel(), v.Key())
+	case channel.NoteOffVelocity:
+		return s.writeDeferred(v.Channel(), v.Key())
+	}
+	return nil
+}
+
+// writeDeferred writes the deferred note off messages for the given key, if no playing clip holds it anymore
+func (s *Session) writeDeferred(ch, key uint8) error {
+	if ch > 15 || key > 127 || s.notes.IsActive(ch, key) {
+		return nil
+	}
+
+	for ; s.deferred[ch][key] > 0; s.deferred[ch][key]-- {
+		if err := s.out.Write(channel.Channel(ch).NoteOff(key)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run plays the launched clips in realtime until Close is called. It sleeps until the next message is due,
+// respectively until a clip is launched or stopped (with a midiio.WakeClock, e.g. midiio.SystemClock), otherwise
+// it sleeps at most 20ms at once. Before Run returns, the sounding notes of the playing clips are ended.
+// The first error of the writer is returned.
+func (s *Session) Run() error {
+	wc, wakeable := s.clock.(midiio.WakeClock)
+
+	for !s.closed.Load() {
+		s.mu.Lock()
+		err := s.process(s.now())
+		d := maxIdle
+
+		if tick, has := s.nextTick(); has {
+			d = s.start.Add(s.tempo.Time(tick)).Sub(s.clock.Now())
+		}
+
+		s.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+
+		if d <= 0 || s.closed.Load() {
+			continue
+		}
+
+		if wakeable {
+			wc.SleepWake(d, s.wake)
+			continue
+		}
+
+		if d > maxSleep {
+			d = maxSleep
+		}
+
+		s.clock.Sleep(d)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, pb := range s.playing {
+		if err := s.endNotes(pb); err != nil {
+			return err
+		}
+	}
+
+	s.playing = nil
+	s.launches = nil
+	return nil
+}
+
+// Close lets Run return. It may be called from another goroutine or from the writer of the Session.
+func (s *Session) Close() {
+	s.closed.Store(true)
+	s.wakeUp()
+}
@@ -0,0 +1,332 @@
+package live
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// fakeClock only advances when sleeping
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+// sink records the written messages with the time since start
+type sink struct {
+	clock *fakeClock
+	start time.Time
+	bf    bytes.Buffer
+
+	// written is called after each message, if it is not nil
+	written func(n int)
+	n       int
+}
+
+func (s *sink) Write(msg midi.Message) error {
+	fmt.Fprintf(&s.bf, "%v %s\n", s.clock.now.Sub(s.start), msg)
+	s.n++
+
+	if s.written != nil {
+		s.written(s.n)
+	}
+
+	return nil
+}
+
+// ticks is the resolution of the tests: at 120 BPM a tick lasts 5ms and a bar of 4/4 2s
+var ticks = smf.MetricTicks(100)
+
+// conductor returns a SMF with 120 BPM and the given time signature changes
+func conductor(meters ...smftrack.Event) *smftrack.SMF {
+	var tr smftrack.Track
+	tr.Add(0, meta.BPM(120))
+
+	for _, m := range meters {
+		tr.Add(m.AbsTicks, m.Message)
+	}
+
+	s := smftrack.New(smf.SMF0, ticks)
+	s.AddTrack(&tr)
+	return s
+}
+
+// newClip returns a clip of a single track with the given events
+func newClip(t *testing.T, bars uint64, length uint64, evts []smftrack.Event, options ...ClipOption) *Clip {
+	var tr smftrack.Track
+
+	for _, ev := range evts {
+		tr.Add(ev.AbsTicks, ev.Message)
+	}
+
+	s := smftrack.New(smf.SMF0, ticks)
+	s.AddTrack(&tr)
+
+	c, err := NewClip(smftrack.CopyRegion(s, 0, length), bars, options...)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	return c
+}
+
+func TestSession(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	out := &sink{clock: clock, start: clock.now}
+
+	s, err := NewSession(conductor(), out, UseClock(clock))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the second note lasts beyond the loop of a bar
+	a := newClip(t, 1, 800, []smftrack.Event{
+		{AbsTicks: 0, Message: channel.Channel0.NoteOn(60, 100)},
+		{AbsTicks: 50, Message: channel.Channel0.NoteOff(60)},
+		{AbsTicks: 200, Message: channel.Channel0.NoteOn(64, 100)},
+		{AbsTicks: 450, Message: channel.Channel0.NoteOff(64)},
+		{AbsTicks: 450, Message: meta.Text("dropped")},
+	})
+
+	b := newClip(t, 1, 400, []smftrack.Event{
+		{AbsTicks: 0, Message: channel.Channel0.NoteOn(72, 100)},
+		{AbsTicks: 300, Message: channel.Channel0.NoteOff(72)},
+	}, OutputChannel(2))
+
+	// the actions at the given times since the start
+	actions := []struct {
+		at     time.Duration
+		action func() uint64
+		tick   uint64
+	}{
+		{300 * time.Millisecond, func() uint64 { tick, _ := s.Launch(a, QuantizeBar); return tick }, 400},
+		{2600 * time.Millisecond, func() uint64 { tick, _ := s.Launch(b, QuantizeBeat); return tick }, 600},
+		{5200 * time.Millisecond, func() uint64 { return s.Stop(b, StopImmediately) }, 1040},
+		{5200 * time.Millisecond, func() uint64 { return s.Stop(a, StopAtBarEnd) }, 1200},
+		{6500 * time.Millisecond, func() uint64 { tick, _ := s.Launch(a, QuantizeNone); return tick }, 1300},
+	}
+
+	for now := time.Duration(0); now <= 7*time.Second; now += 50 * time.Millisecond {
+		clock.now = out.start.Add(now)
+
+		for _, a := range actions {
+			if a.at == now {
+				if got := a.action(); got != a.tick {
+					t.Errorf("action at %v at tick %v; want %v", now, got, a.tick)
+				}
+			}
+		}
+
+		if err := s.Update(); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+
+	expected := `2s channel.NoteOn channel 1 key 60 velocity 100
+2.25s channel.NoteOff channel 1 key 60
+3s channel.NoteOn channel 1 key 64 velocity 100
+3s channel.NoteOn channel 3 key 72 velocity 100
+4s channel.NoteOff channel 1 key 64
+4s channel.NoteOn channel 1 key 60 velocity 100
+4.25s channel.NoteOff channel 1 key 60
+4.5s channel.NoteOff channel 3 key 72
+5s channel.NoteOn channel 1 key 64 velocity 100
+5s channel.NoteOn channel 3 key 72 velocity 100
+5.2s channel.NoteOff channel 3 key 72
+6s channel.NoteOff channel 1 key 64
+6.5s channel.NoteOn channel 1 key 60 velocity 100
+6.75s channel.NoteOff channel 1 key 60
+`
+
+	if got, want := out.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestSessionLayeredNotes(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	out := &sink{clock: clock, start: clock.now}
+
+	s, err := NewSession(conductor(), out, UseClock(clock))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	a := newClip(t, 1, 400, []smftrack.Event{
+		{AbsTicks: 0, Message: channel.Channel0.NoteOn(60, 100)},
+		{AbsTicks: 0, Message: channel.Channel0.NoteOn(64, 100)},
+		{AbsTicks: 300, Message: channel.Channel0.NoteOff(60)},
+		{AbsTicks: 300, Message: channel.Channel0.NoteOff(64)},
+	})
+
+	b := newClip(t, 1, 400, []smftrack.Event{
+		{AbsTicks: 0, Message: channel.Channel0.NoteOn(60, 90)},
+		{AbsTicks: 350, Message: channel.Channel0.NoteOff(60)},
+	})
+
+	s.Launch(a, QuantizeNone)
+	s.Launch(b, QuantizeNone)
+
+	for now := time.Duration(0); now < 2*time.Second; now += 50 * time.Millisecond {
+		clock.now = out.start.Add(now)
+
+		if now == 500*time.Millisecond {
+			s.Stop(a, StopImmediately)
+		}
+
+		if err := s.Update(); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+
+	// the note of a that b holds is ended with the note of b
+	expected := `0s channel.NoteOn channel 1 key 60 velocity 100
+0s channel.NoteOn channel 1 key 64 velocity 100
+0s channel.NoteOn channel 1 key 60 velocity 90
+500ms channel.NoteOff channel 1 key 64
+1.75s channel.NoteOff channel 1 key 60
+1.75s channel.NoteOff channel 1 key 60
+`
+
+	if got, want := out.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestSessionMeter(t *testing.T) {
+	// 4/4 in bar 0, 3/4 in bars 1 and 2, 6/8 from bar 3 on
+	c := conductor(
+		smftrack.Event{AbsTicks: 400, Message: meta.TimeSig{Numerator: 3, Denominator: 4}},
+		smftrack.Event{AbsTicks: 1000, Message: meta.TimeSig{Numerator: 6, Denominator: 8}},
+	)
+
+	tests := []struct {
+		tick     uint64
+		q        Quantization
+		expected uint64
+	}{
+		{0, QuantizeBar, 0},
+		{10, QuantizeBar, 400},
+		{10, QuantizeBeat, 100},
+		{410, QuantizeBar, 700},
+		{410, QuantizeBeat, 500},
+		{500, QuantizeBeat, 500},
+		{1010, QuantizeBeat, 1050},
+		{1010, QuantizeBar, 1300},
+		{1010, QuantizeNone, 1010},
+	}
+
+	clip := newClip(t, 1, 400, []smftrack.Event{{AbsTicks: 0, Message: channel.Channel0.NoteOn(60, 100)}})
+
+	for _, test := range tests {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		s, _ := NewSession(c, &sink{clock: clock}, UseClock(clock))
+		clock.Sleep(time.Duration(test.tick) * 5 * time.Millisecond)
+
+		if got, _ := s.Launch(clip, test.q); got != test.expected {
+			t.Errorf("Launch() at tick %v with quantization %v = %v; want %v", test.tick, test.q, got, test.expected)
+		}
+	}
+
+	// the loop of a bar follows the time signature changes
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	out := &sink{clock: clock, start: clock.now}
+	s, _ := NewSession(c, out, UseClock(clock))
+	clip = newClip(t, 1, 400, []smftrack.Event{
+		{AbsTicks: 0, Message: channel.Channel0.NoteOn(60, 100)},
+		{AbsTicks: 10, Message: channel.Channel0.NoteOff(60)},
+	})
+
+	clock.Sleep(time.Second)
+	s.Launch(clip, QuantizeBar)
+
+	for i := 0; i < 130; i++ {
+		clock.Sleep(50 * time.Millisecond)
+		s.Update()
+	}
+
+	expected := `2s channel.NoteOn channel 1 key 60 velocity 100
+2.05s channel.NoteOff channel 1 key 60
+3.5s channel.NoteOn channel 1 key 60 velocity 100
+3.55s channel.NoteOff channel 1 key 60
+5s channel.NoteOn channel 1 key 60 velocity 100
+5.05s channel.NoteOff channel 1 key 60
+6.5s channel.NoteOn channel 1 key 60 velocity 100
+6.55s channel.NoteOff channel 1 key 60
+`
+
+	if got, want := out.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestSessionRun(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	out := &sink{clock: clock, start: clock.now}
+	s, _ := NewSession(conductor(), out, UseClock(clock))
+
+	clip := newClip(t, 1, 400, []smftrack.Event{
+		{AbsTicks: 0, Message: channel.Channel0.NoteOn(60, 100)},
+		{AbsTicks: 50, Message: channel.Channel0.NoteOff(60)},
+		{AbsTicks: 200, Message: channel.Channel0.NoteOn(64, 100)},
+		{AbsTicks: 300, Message: channel.Channel0.NoteOff(64)},
+	})
+
+	out.written = func(n int) {
+		if n == 3 {
+			s.Close()
+		}
+	}
+
+	s.Launch(clip, QuantizeNone)
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the sounding note is ended, when Run returns
+	expected := `0s channel.NoteOn channel 1 key 60 velocity 100
+250ms channel.NoteOff channel 1 key 60
+1s channel.NoteOn channel 1 key 64 velocity 100
+1s channel.NoteOff channel 1 key 64
+`
+
+	if got, want := out.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	if _, err := NewSession(smftrack.New(smf.SMF0, smf.SMPTE25(40)), nil); err == nil {
+		t.Errorf("NewSession() with time code returned no error")
+	}
+
+	if _, err := NewClip(smftrack.Region{}, 0); err == nil {
+		t.Errorf("NewClip() of 0 bars returned no error")
+	}
+
+	if _, err := NewClip(smftrack.Region{}, 1, OutputChannel(16)); err == nil {
+		t.Errorf("NewClip() with output channel 16 returned no error")
+	}
+
+	s, _ := NewSession(conductor(), &sink{clock: &fakeClock{}}, UseClock(&fakeClock{}))
+	s2 := smftrack.New(smf.SMF0, smf.MetricTicks(960))
+	s2.AddTrack(&smftrack.Track{})
+	clip, _ := NewClip(smftrack.CopyRegion(s2, 0, 960), 1)
+
+	if _, err := s.Launch(clip, QuantizeBar); err == nil {
+		t.Errorf("Launch() of clip with other time format returned no error")
+	}
+}
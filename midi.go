@@ -2,9 +2,15 @@ package midi
 
 import (
 	"errors"
+	"fmt"
+	"io"
 )
 
 // Message is a MIDI message
+//
+// Every message of the midimessage packages can always be serialized: Raw never panics, whatever the values of the
+// message are. Values that can't be encoded are clamped or cut by Raw and reported by the Validate method of the
+// message, so that the writers return an error instead of writing corrupt data (see Validate).
 type Message interface {
 	// String inspects the MIDI message in an informative way
 	String() string
@@ -13,6 +19,47 @@ type Message interface {
 	Raw() []byte
 }
 
+// EncodedLen returns the number of bytes of the given message on the wire, i.e. len(msg.Raw()).
+// Messages that provide an EncodedLen method (as all messages of the midimessage packages do) are not encoded.
+func EncodedLen(msg Message) int {
+	if l, ok := msg.(interface{ EncodedLen() int }); ok {
+		return l.EncodedLen()
+	}
+	return len(msg.Raw())
+}
+
+// ErrInvalidMessage is wrapped by the errors of the Validate methods of the messages of the midimessage packages.
+// Such a message has values that can't be encoded, so that its raw bytes would corrupt the stream,
+// e.g. a data byte above 127.
+var ErrInvalidMessage = errors.New("invalid MIDI message")
+
+// Validate returns the error of the Validate method of the given message, if it has one. Other messages (i.e. of
+// other packages) are checked by encoding them: an error is returned, if their Raw method panics or returns no bytes.
+// The errors wrap ErrInvalidMessage.
+// The writers of the midiwriter and smfwriter packages validate the messages before writing them.
+func Validate(msg Message) error {
+	if v, ok := msg.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+
+	return validateRaw(msg)
+}
+
+// validateRaw returns an error, if the Raw method of the given message panics or returns no bytes
+func validateRaw(msg Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w %T: Raw panics: %v", ErrInvalidMessage, msg, r)
+		}
+	}()
+
+	if len(msg.Raw()) == 0 {
+		return fmt.Errorf("%w %T: no raw bytes", ErrInvalidMessage, msg)
+	}
+
+	return nil
+}
+
 // Writer writes MIDI messages
 type Writer interface {
 	// Write writes the given MIDI message and returns any error
@@ -37,8 +84,19 @@ type ReadCloser interface {
 	Close() error
 }
 
-// ErrUnexpectedEOF is returned, when an unexspected end of file is reached.
-var ErrUnexpectedEOF = errors.New("Unexpected End of File found.")
+// ErrUnexpectedEOF is returned, when an unexspected end of file is reached, i.e. the data ends within a message.
+// It wraps io.ErrUnexpectedEOF, so that errors.Is(err, io.ErrUnexpectedEOF) is true for it.
+//
+// The readers of this module return io.EOF, if the data ends cleanly between messages, and an error that wraps
+// io.ErrUnexpectedEOF (this one or io.ErrUnexpectedEOF itself), if it ends within a message.
+var ErrUnexpectedEOF error = unexpectedEOF{}
+
+type unexpectedEOF struct{}
+
+func (unexpectedEOF) Error() string { return "Unexpected End of File found." }
+
+// Unwrap returns io.ErrUnexpectedEOF
+func (unexpectedEOF) Unwrap() error { return io.ErrUnexpectedEOF }
 
 /*
    A MIDI message is made up of an eight-bit status byte which is generally followed by one or two data bytes.
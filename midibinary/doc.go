@@ -0,0 +1,17 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package midibinary provides the big-endian 16, 24 and 32 bit fields that are used within SMF files and meta
+messages (e.g. the sequence number, the tempo and the lengths of chunks), so that custom payloads, e.g. of
+sequencer specific meta messages, can be read and written the same way.
+
+A read either returns the complete value or an error: io.EOF, if no byte could be read, and io.ErrUnexpectedEOF,
+if the data ends within the field.
+
+	var bf bytes.Buffer
+	midibinary.WriteUint24(&bf, 500000)
+	tempo, err := midibinary.ReadUint24(&bf)
+*/
+package midibinary
@@ -0,0 +1,86 @@
+package midibinary
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxUint24 is the largest value of a 24 bit field
+const MaxUint24 = 1<<24 - 1
+
+// read reads exactly len(b) bytes. It returns io.EOF, if no byte could be read and io.ErrUnexpectedEOF, if the
+// data ends within b.
+func read(rd io.Reader, b []byte) error {
+	_, err := io.ReadFull(rd, b)
+	return err
+}
+
+// ReadUint16 reads a big-endian 16 bit value
+func ReadUint16(rd io.Reader) (uint16, error) {
+	var b [2]byte
+
+	if err := read(rd, b[:]); err != nil {
+		return 0, err
+	}
+
+	return uint16(b[0])<<8 | uint16(b[1]), nil
+}
+
+// ReadUint24 reads a big-endian 24 bit value
+func ReadUint24(rd io.Reader) (uint32, error) {
+	var b [3]byte
+
+	if err := read(rd, b[:]); err != nil {
+		return 0, err
+	}
+
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2]), nil
+}
+
+// ReadUint32 reads a big-endian 32 bit value
+func ReadUint32(rd io.Reader) (uint32, error) {
+	var b [4]byte
+
+	if err := read(rd, b[:]); err != nil {
+		return 0, err
+	}
+
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// AppendUint16 appends the big-endian 16 bit value to b
+func AppendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+// AppendUint24 appends the big-endian 24 bit value to b. The bits above the 24th bit are dropped, see WriteUint24.
+func AppendUint24(b []byte, v uint32) []byte {
+	return append(b, byte(v>>16), byte(v>>8), byte(v))
+}
+
+// AppendUint32 appends the big-endian 32 bit value to b
+func AppendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// WriteUint16 writes a big-endian 16 bit value
+func WriteUint16(wr io.Writer, v uint16) error {
+	_, err := wr.Write(AppendUint16(nil, v))
+	return err
+}
+
+// WriteUint24 writes a big-endian 24 bit value. A value above MaxUint24 returns an error and nothing is written.
+func WriteUint24(wr io.Writer, v uint32) error {
+	if v > MaxUint24 {
+		return fmt.Errorf("value %v exceeds 24 bits", v)
+	}
+
+	_, err := wr.Write(AppendUint24(nil, v))
+	return err
+}
+
+// WriteUint32 writes a big-endian 32 bit value
+func WriteUint32(wr io.Writer, v uint32) error {
+	_, err := wr.Write(AppendUint32(nil, v))
+	return err
+}
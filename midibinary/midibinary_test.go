@@ -0,0 +1,78 @@
+package midibinary
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRead(t *testing.T) {
+	data := []byte{0x12, 0x34, 0x56, 0x78}
+
+	tests := []struct {
+		size     int
+		read     func(io.Reader) (uint32, error)
+		expected uint32
+	}{
+		{2, func(rd io.Reader) (uint32, error) { v, err := ReadUint16(rd); return uint32(v), err }, 0x1234},
+		{3, ReadUint24, 0x123456},
+		{4, ReadUint32, 0x12345678},
+	}
+
+	for _, test := range tests {
+		// one byte per Read must not return a partial value
+		for _, rd := range []io.Reader{bytes.NewReader(data), iotest.OneByteReader(bytes.NewReader(data))} {
+			if got, err := test.read(rd); err != nil || got != test.expected {
+				t.Errorf("read %v bytes = %X, %v; want %X", test.size, got, err, test.expected)
+			}
+		}
+
+		if got, err := test.read(bytes.NewReader(nil)); err != io.EOF || got != 0 {
+			t.Errorf("read %v bytes of no data = %X, %v; want 0, %v", test.size, got, err, io.EOF)
+		}
+
+		for n := 1; n < test.size; n++ {
+			if got, err := test.read(iotest.OneByteReader(bytes.NewReader(data[:n]))); err != io.ErrUnexpectedEOF || got != 0 {
+				t.Errorf("read %v bytes of %v bytes = %X, %v; want 0, %v", test.size, n, got, err, io.ErrUnexpectedEOF)
+			}
+		}
+
+		failing := errors.New("failing")
+
+		if _, err := test.read(iotest.ErrReader(failing)); err != failing {
+			t.Errorf("read %v bytes with failing reader = %v; want %v", test.size, err, failing)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var bf bytes.Buffer
+
+	WriteUint16(&bf, 0x1234)
+	WriteUint24(&bf, 0x56789A)
+	WriteUint32(&bf, 0xBCDEF012)
+
+	if got, want := bf.Bytes(), []byte{0x12, 0x34, 0x56, 0x78, 0x9A, 0xBC, 0xDE, 0xF0, 0x12}; !bytes.Equal(got, want) {
+		t.Errorf("got % X; want % X", got, want)
+	}
+
+	if err := WriteUint24(&bf, MaxUint24+1); err == nil || bf.Len() != 9 {
+		t.Errorf("WriteUint24(%X) = %v, wrote %v bytes; want error and nothing written", MaxUint24+1, err, bf.Len()-9)
+	}
+
+	if got, want := AppendUint24([]byte{1}, MaxUint24), []byte{1, 0xFF, 0xFF, 0xFF}; !bytes.Equal(got, want) {
+		t.Errorf("AppendUint24() = % X; want % X", got, want)
+	}
+
+	// round trip
+	for _, v := range []uint32{0, 1, 0xFF, 0x100, 0xFFFF, 0x10000, MaxUint24} {
+		bf.Reset()
+		WriteUint24(&bf, v)
+
+		if got, err := ReadUint24(&bf); err != nil || got != v {
+			t.Errorf("ReadUint24() of %X = %X, %v", v, got, err)
+		}
+	}
+}
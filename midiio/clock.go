@@ -0,0 +1,54 @@
+package midiio
+
+import "time"
+
+// Clock is the source of time for the Player.
+// It allows to replace the system clock, e.g. for testing.
+type Clock interface {
+	// Now returns the current time. The times must be monotonic.
+	Now() time.Time
+
+	// Sleep pauses for at least the given duration.
+	Sleep(d time.Duration)
+}
+
+// WakeClock is a Clock whose sleep can be interrupted. The Player sleeps with it until the next deadline,
+// while it sleeps at most 20ms at once with other clocks, so that Stop is noticed in time.
+type WakeClock interface {
+	Clock
+
+	// SleepWake pauses for at least the given duration or until wake receives a value.
+	SleepWake(d time.Duration, wake <-chan struct{})
+}
+
+// TimerClock is a Clock that can call a function after a duration, like time.AfterFunc. The Watchdog uses it for
+// its timer.
+type TimerClock interface {
+	Clock
+
+	// AfterFunc calls f in its own goroutine after the given duration. The returned function stops the timer; it
+	// returns false, if the timer has already fired or been stopped.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// SystemClock is the Clock that is based on the time package. It is a WakeClock and a TimerClock.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+func (systemClock) SleepWake(d time.Duration, wake <-chan struct{}) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-wake:
+	}
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) (stop func() bool) {
+	return time.AfterFunc(d, f).Stop
+}
@@ -5,5 +5,9 @@
 /*
 Package midiio provides helpers for connecting io.Readers and io.Writers to midi.Readers and midi.Writers.
 
+The Player plays a SMF in realtime to a midi.Writer, the Recorder records MIDI messages into a track.
+The Throttle limits the rate of the bytes written to a midi.Writer.
+The PacedSender sends system exclusive bulk dumps with pauses to the byte stream of a MIDI port.
+
 */
 package midiio
@@ -0,0 +1,143 @@
+package midiio
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+// PacedOption is an option for the PacedSender
+type PacedOption func(*PacedSender)
+
+// PacedClock sets the Clock of the PacedSender. Default is SystemClock.
+func PacedClock(c Clock) PacedOption {
+	return func(p *PacedSender) {
+		p.clock = c
+	}
+}
+
+// PacedChunkSize sets the number of bytes of a message that are written at once, if there is a per byte delay.
+// The pause after a chunk is the per byte delay times its size. Default is 1, i.e. each byte is written on its own.
+func PacedChunkSize(n int) PacedOption {
+	return func(p *PacedSender) {
+		if n > 0 {
+			p.chunkSize = n
+		}
+	}
+}
+
+// PacedProgress sets a function that is called after each written chunk with the number of bytes that have been
+// sent and the total number of bytes of the messages (including the 0xF0 and 0xF7 bytes).
+func PacedProgress(fn func(sent, total int)) PacedOption {
+	return func(p *PacedSender) {
+		p.progress = fn
+	}
+}
+
+// PacedSender sends system exclusive messages, e.g. the bulk dump of a patch bank, to the byte stream of a
+// MIDI port with pauses, since many devices can't keep up with back-to-back messages.
+//
+// If there is a per byte delay, the bytes of a message are written in chunks (see PacedChunkSize), each followed
+// by a pause of the per byte delay times the size of the chunk. Otherwise a message is written at once.
+// Between the messages, there is an additional pause of the inter message delay.
+type PacedSender struct {
+	out          io.Writer
+	interMessage time.Duration
+	perByte      time.Duration
+	chunkSize    int
+	clock        Clock
+	progress     func(sent, total int)
+}
+
+// NewPacedSender returns a PacedSender that writes the raw bytes of the messages to out.
+func NewPacedSender(out io.Writer, interMessageDelay, perByteDelay time.Duration, options ...PacedOption) *PacedSender {
+	p := &PacedSender{
+		out:          out,
+		interMessage: interMessageDelay,
+		perByte:      perByteDelay,
+		chunkSize:    1,
+		clock:        SystemClock,
+	}
+
+	for _, opt := range options {
+		opt(p)
+	}
+
+	return p
+}
+
+// Send sends the given messages and returns when the last byte has been written.
+//
+// When the context is canceled, sending stops before the next write. If a message has been started, it is
+// terminated by an 0xF7 byte, so that the receiver leaves the system exclusive mode, and the error of the context
+// is returned. The first error returned by the writer is returned immediately.
+func (p *PacedSender) Send(ctx context.Context, msgs ...sysex.SysEx) error {
+	var sent, total int
+
+	for _, msg := range msgs {
+		total += msg.EncodedLen()
+	}
+
+	// wait is the pause before the next write
+	var wait time.Duration
+
+	for _, msg := range msgs {
+		raw := msg.Raw()
+		chunk := len(raw)
+
+		if p.perByte > 0 {
+			chunk = p.chunkSize
+		}
+
+		for start := 0; start < len(raw); start += chunk {
+			if !p.pause(ctx, wait) {
+				// terminate the started message
+				if start > 0 {
+					if _, err := p.out.Write([]byte{0xF7}); err != nil {
+						return err
+					}
+				}
+				return ctx.Err()
+			}
+
+			end := min(start+chunk, len(raw))
+
+			if _, err := p.out.Write(raw[start:end]); err != nil {
+				return err
+			}
+
+			sent += end - start
+
+			if p.progress != nil {
+				p.progress(sent, total)
+			}
+
+			wait = time.Duration(end-start) * p.perByte
+		}
+
+		wait += p.interMessage
+	}
+
+	return nil
+}
+
+// pause waits for the given duration. It returns false, if the context has been canceled in the meantime.
+func (p *PacedSender) pause(ctx context.Context, d time.Duration) bool {
+	deadline := p.clock.Now().Add(d)
+
+	for {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		d := deadline.Sub(p.clock.Now())
+
+		if d <= 0 {
+			return true
+		}
+
+		p.clock.Sleep(min(d, maxSleep))
+	}
+}
@@ -0,0 +1,127 @@
+package midiio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+// timedBytes records the written bytes together with the time of writing
+type timedBytes struct {
+	clock Clock
+	start time.Time
+	bf    bytes.Buffer
+}
+
+func (w *timedBytes) Write(p []byte) (int, error) {
+	fmt.Fprintf(&w.bf, "%v % X\n", w.clock.Now().Sub(w.start), p)
+	return len(p), nil
+}
+
+func TestPacedSender(t *testing.T) {
+	msgs := []sysex.SysEx{{0x41, 0x10, 0x42}, {0x41, 0x11}}
+
+	tests := []struct {
+		interMessage, perByte time.Duration
+		options               []PacedOption
+		expected              string
+	}{
+		{10 * time.Millisecond, 0, nil, `0s F0 41 10 42 F7
+10ms F0 41 11 F7
+`},
+		{10 * time.Millisecond, time.Millisecond, []PacedOption{PacedChunkSize(2)}, `0s F0 41
+2ms 10 42
+4ms F7
+15ms F0 41
+17ms 11 F7
+`},
+		{0, time.Millisecond, nil, `0s F0
+1ms 41
+2ms 10
+3ms 42
+4ms F7
+5ms F0
+6ms 41
+7ms 11
+8ms F7
+`},
+	}
+
+	for i, test := range tests {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		out := &timedBytes{clock: clock, start: clock.now}
+		var progress []int
+
+		options := append([]PacedOption{PacedClock(clock), PacedProgress(func(sent, total int) {
+			if total != 9 {
+				t.Errorf("[%v] total = %v; want 9", i, total)
+			}
+			progress = append(progress, sent)
+		})}, test.options...)
+
+		ps := NewPacedSender(out, test.interMessage, test.perByte, options...)
+
+		if err := ps.Send(context.Background(), msgs...); err != nil {
+			t.Fatalf("[%v] Error: %v", i, err)
+		}
+
+		if got := out.bf.String(); got != test.expected {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, test.expected)
+		}
+
+		if len(progress) == 0 || progress[len(progress)-1] != 9 {
+			t.Errorf("[%v] progress = %v; want it to end with 9", i, progress)
+		}
+	}
+}
+
+func TestPacedSenderCancel(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	out := &timedBytes{clock: clock, start: clock.now}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// cancel in the middle of the second message
+	ps := NewPacedSender(out, 10*time.Millisecond, time.Millisecond, PacedClock(clock), PacedChunkSize(2),
+		PacedProgress(func(sent, total int) {
+			if sent >= 9 {
+				cancel()
+			}
+		}))
+
+	err := ps.Send(ctx, sysex.SysEx{0x41, 0x10, 0x42}, sysex.SysEx{0x41, 0x11, 0x12, 0x13})
+
+	if err != context.Canceled {
+		t.Errorf("Send() error = %v; want %v", err, context.Canceled)
+	}
+
+	expected := `0s F0 41
+2ms 10 42
+4ms F7
+15ms F0 41
+17ms 11 12
+17ms F7
+`
+
+	if got := out.bf.String(); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	// canceled between the messages
+	out.bf.Reset()
+	out.start = clock.now
+	ctx, cancel = context.WithCancel(context.Background())
+
+	ps = NewPacedSender(out, 10*time.Millisecond, 0, PacedClock(clock), PacedProgress(func(sent, total int) { cancel() }))
+
+	if err := ps.Send(ctx, sysex.SysEx{0x41}, sysex.SysEx{0x42}); err != context.Canceled {
+		t.Errorf("Send() error = %v; want %v", err, context.Canceled)
+	}
+
+	if got, want := out.bf.String(), "0s F0 41 F7\n"; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
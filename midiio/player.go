@@ -1,107 +1,636 @@
 package midiio
 
-/*
 import (
-	"bytes"
 	"fmt"
+	"math/bits"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
 	"github.com/gomidi/midi"
-	"github.com/gomidi/midi/live/midiwriter"
-	"github.com/gomidi/midi/messages/channel"
-	"github.com/gomidi/midi/messages/meta"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
 	"github.com/gomidi/midi/smf"
-	"io"
-	"time"
+	"github.com/gomidi/midi/smf/smftrack"
 )
 
-// NewPlayer plays from a single track to reader
-// src is expected to be of format SMF0. Use smftrack to convert from SMF1 to SMF0.
-func NewPlayer(src smf.Reader) (io.Reader, error) {
-	err := src.ReadHeader()
+/*
+scheduling strategy:
+
+The times of all events are calculated in advance as offsets from the start of the playback.
+Each event has an absolute deadline (start + offset) that is compared against the monotonic clock,
+so that the errors of single waits do not accumulate.
+
+Waiting for a deadline is done with a coarse sleep until the deadline minus the busy wait threshold,
+followed by a busy wait for the rest. The busy wait compensates for the oversleeping of the operating system.
+With a WakeClock (e.g. SystemClock), the coarse sleep lasts until the deadline and is interrupted by Stop,
+so that long rests cost a single wakeup. Other clocks sleep at most maxSleep at once and poll for Stop.
 
-	if err != nil {
-		return nil, err
+All events whose deadline falls within the scheduling quantum after the current time are dispatched together,
+in the order of the file.
+
+The per event timing errors of this strategy can be compared to a naive time.Sleep per event with
+go test -bench Timing ./midiio (BenchmarkPlayerTiming and BenchmarkNaiveTiming).
+*/
+
+// maxSleep is the maximal duration of a single coarse sleep with a Clock that is not a WakeClock,
+// so that Stop is noticed in time.
+const maxSleep = 20 * time.Millisecond
+
+// PlayerOption is an option for the Player
+type PlayerOption func(*Player)
+
+// BusyWait sets the duration before a deadline, during which the Player busy waits instead of sleeping.
+// Higher values are more precise, but consume more CPU. Default is 1ms. 0 disables busy waiting.
+func BusyWait(threshold time.Duration) PlayerOption {
+	return func(p *Player) {
+		p.busyWait = threshold
+	}
+}
+
+// Quantum sets the scheduling quantum: events whose deadlines are within the quantum after the current time
+// are written at once. Default is 250µs.
+func Quantum(q time.Duration) PlayerOption {
+	return func(p *Player) {
+		p.quantum = q
+	}
+}
+
+// UseClock sets the Clock of the Player. Default is SystemClock.
+func UseClock(c Clock) PlayerOption {
+	return func(p *Player) {
+		p.clock = c
+	}
+}
+
+// SeekIndex sets the Index of the SMF that is used by Seek to find the state at the position, e.g. an Index that has
+// been restored from a sidecar file by smftrack.Thaw. Without it, Seek applies all events from the start of the SMF.
+// The Index must belong to the SMF that is passed to NewPlayer.
+func SeekIndex(idx *smftrack.Index) PlayerOption {
+	return func(p *Player) {
+		p.index = idx
+	}
+}
+
+// ThrottleOutput limits the rate of the bytes written by the Player with a Throttle (see NewThrottle).
+// Delayed messages are written while the Player waits for the next event.
+func ThrottleOutput(bytesPerSecond, burst int, options ...ThrottleOption) PlayerOption {
+	return func(p *Player) {
+		p.throttle = func(out midi.Writer, clock Clock) *Throttle {
+			return NewThrottle(out, bytesPerSecond, burst, clock, options...)
+		}
 	}
+}
 
-	if src.Header().Format != smf.SMF0 {
-		return nil, fmt.Errorf("only SMF0 files supported, sorry, please convert your file first")
+// BarPositions lets CurrentPosition show the position as bar and beat (see smftrack.Position) instead of the
+// ticks. The positions are based on the MeterMap of the SMF and the given options.
+func BarPositions(options ...smftrack.PositionOption) PlayerOption {
+	return func(p *Player) {
+		p.bars = true
+		p.positions = options
 	}
+}
+
+// Player plays the events of a SMF in realtime to a midi.Writer.
+// Meta messages are not written, but tempo changes are respected.
+// The tracks may be routed to different outputs by their ports (see SetPortResolver).
+type Player struct {
+	clock    Clock
+	busyWait time.Duration
+	quantum  time.Duration
+	events   []scheduledEvent
+	tpq      uint64
+	stopped  atomic.Bool
+
+	// wake interrupts the sleep of a WakeClock, when the Player is stopped
+	wake chan struct{}
+
+	// wakeups and elapsed (in nanoseconds) are the counters of Stats
+	wakeups atomic.Uint64
+	elapsed atomic.Int64
+
+	// tick is the tick of the last written event, respectively of the position set by Seek
+	tick atomic.Uint64
+
+	// bars, meters and positions are used by CurrentPosition (see BarPositions)
+	bars      bool
+	meters    *smftrack.MeterMap
+	positions []smftrack.PositionOption
+
+	// throttle creates the Throttle (see ThrottleOutput), throttled is the created Throttle
+	throttle  func(out midi.Writer, clock Clock) *Throttle
+	throttled *Throttle
+
+	// outs are the outputs, the first one is the default output
+	outs []*output
+
+	// channelOuts are the changes of the outputs that the events of each channel are written to, so that the
+	// messages of Seek are written to the output of the channel at the position; none means the default output
+	channelOuts [16][]channelOut
+
+	// sounding are the indices of the outputs that the sounding notes have been started on, in the order of
+	// their note on messages, so that a note is ended on its output, even if the port of its track changes
+	sounding map[soundingNote][]int
+
+	// smf and index are used by Seek to find the state at the position (see SeekIndex)
+	smf   *smftrack.SMF
+	index *smftrack.Index
+
+	// tempos are the tempo changes, with the offsets from the start of the playback
+	tempos []tempoChange
+
+	// from is the index of the first event to play, fromOffset its offset and chase are the messages that
+	// reestablish the state at the position (see Seek)
+	from       int
+	fromOffset time.Duration
+	chase      []midi.Message
+}
+
+// tempoChange is a tempo change at a tick (tempo in microseconds per quarter note)
+type tempoChange struct {
+	tick   uint64
+	offset time.Duration
+	tempo  uint64
+}
+
+// output is an output of the Player
+type output struct {
+	out midi.Writer
+
+	// notes are the notes that have been written and not yet ended
+	notes channel.NoteTracker
+}
+
+// channelOut is the index of the output that the events of a channel are written to, from the event with the
+// given index on
+type channelOut struct {
+	from int
+	out  int
+}
+
+// soundingNote identifies a sounding note by its track, channel and key
+type soundingNote struct {
+	track   int
+	channel uint8
+	key     uint8
+}
+
+type scheduledEvent struct {
+	// offset is the time from the start of the playback
+	offset time.Duration
+	tick   uint64
+	msg    midi.Message
+	track  int
 
-	ti, isMetric := src.Header().TimeFormat.(smf.MetricTicks)
+	// port is the name of the port of the track at the event (empty, if there is none)
+	port string
+
+	// out is the index of the output
+	out int
+}
+
+// PortResolver returns the output for the port with the given name (see SetPortResolver)
+type PortResolver func(name string) (midi.Writer, error)
+
+// NewPlayer returns a Player that plays the given SMF to the given writer.
+// The SMF must be of format 0 or 1 and must have a metric time format.
+func NewPlayer(s *smftrack.SMF, out midi.Writer, options ...PlayerOption) (*Player, error) {
+	if s.Format() == smf.SMF2 {
+		return nil, fmt.Errorf("SMF2 files are not supported, sorry")
+	}
+
+	ti, isMetric := s.TimeFormat().(smf.MetricTicks)
 	if !isMetric {
 		return nil, fmt.Errorf("only metric timeformat supported, sorry")
 	}
 
-	p := &smfPlayer{}
-	p.rd = src
-	p.metricTicks = ti
-	p.tempo = 120 // default
-	p.to = midiwriter.New(&p.bf)
+	p := &Player{
+		clock:    SystemClock,
+		busyWait: time.Millisecond,
+		quantum:  250 * time.Microsecond,
+		wake:     make(chan struct{}, 1),
+	}
+
+	for _, opt := range options {
+		opt(p)
+	}
+
+	if p.throttle != nil {
+		p.throttled = p.throttle(out, p.clock)
+		out = p.throttled
+	}
+
+	p.outs = []*output{{out: out}}
+	p.smf = s
+	p.tpq = uint64(ti.Number())
+	p.schedule(s.Merged(), p.tpq)
+
+	if p.bars {
+		p.meters = s.MeterMap()
+	}
+
 	return p, nil
 }
 
-// it is suggested to make the data buffer at least 3 bytes long
-// sysex are skipped
-func (p *smfPlayer) Read(data []byte) (n int, err error) {
-	if len(data) < 3 {
-		return 0, fmt.Errorf("data buffer too small must be 3 bytes or more")
+// schedule calculates the offsets of the events
+func (p *Player) schedule(evts []smftrack.TrackEvent, tpq uint64) {
+	var tempo = uint64(meta.BPM(120).MuSecPerQN())
+
+	// the offsets are calculated from the last tempo change, so that rounding errors do not accumulate
+	var tempoTick uint64
+	var tempoOffset time.Duration
+
+	// ports are the current port names of the tracks
+	var ports = map[int]string{}
+
+	p.tempos = []tempoChange{{tempo: tempo}}
+
+	for _, ev := range evts {
+		offset := tempoOffset + ticksDuration(ev.AbsTicks-tempoTick, tempo, tpq)
+
+		switch v := ev.Message.(type) {
+		case meta.Tempo:
+			tempo = uint64(v.MuSecPerQN())
+			tempoTick, tempoOffset = ev.AbsTicks, offset
+			p.tempos = append(p.tempos, tempoChange{tick: tempoTick, offset: tempoOffset, tempo: tempo})
+		case meta.Device:
+			ports[ev.Track] = v.Text()
+		case meta.Port:
+			ports[ev.Track] = strconv.Itoa(int(v.Number()))
+		case meta.Message:
+			// meta messages can't be used live
+		default:
+			p.events = append(p.events, scheduledEvent{offset: offset, tick: ev.AbsTicks, msg: ev.Message, track: ev.Track, port: ports[ev.Track]})
+		}
 	}
+}
+
+// offsetAt returns the offset of the given tick from the start of the playback
+func (p *Player) offsetAt(tick uint64) time.Duration {
+	i := sort.Search(len(p.tempos), func(i int) bool {
+		return p.tempos[i].tick > tick
+	}) - 1
+
+	tc := p.tempos[i]
+	return tc.offset + ticksDuration(tick-tc.tick, tc.tempo, p.tpq)
+}
 
-	msg, err := p.rd.Read()
+// Seek sets the position from which Play starts to the given tick. Before the events at the position are played,
+// the messages of the state after the events before the position (programs, controllers, pitch bend and aftertouch,
+// see smftrack.State.Messages) are written to the output of their channel at the position (see SetPortResolver). Notes that started before the position are not played.
+//
+// With an Index (see SeekIndex), the state is found in constant time, otherwise the events from the start of the
+// SMF are applied. The position is kept for further calls of Play, until Seek is called again; Seek(0) plays the
+// SMF from the beginning. Seek must not be called while playing. It has no effect on SyncExternal.
+func (p *Player) Seek(tick uint64) {
+	p.from = sort.Search(len(p.events), func(i int) bool {
+		return p.events[i].tick >= tick
+	})
 
-	if err != nil {
+	p.fromOffset = p.offsetAt(tick)
+	p.chase = nil
+	p.tick.Store(tick)
+
+	if tick == 0 {
 		return
 	}
 
-	delta := p.rd.Delta()
+	var st smftrack.State
+
+	if p.index != nil {
+		st = p.index.StateAt(tick - 1)
+	} else {
+		st = smftrack.StateAt(p.smf, tick-1)
+	}
+
+	p.chase = st.Messages()
+}
+
+// SetPortResolver routes the events of each track to the output that the given resolver returns for the port of the
+// track. The port of a track is set by a device (port) name message (FF 09) or by an obsolete MIDI port message
+// (FF 21) that is named by its number (e.g. "1", see smftrack.Ports). A port message in the middle of a track
+// routes the subsequent events of the track.
+//
+// Events of tracks without a port, as well as events of ports for which the resolver returns a nil writer,
+// are written to the default output that has been passed to NewPlayer. A nil resolver restores that for all events.
+// Notes are ended on the output that they have been started on, even if the port of their track has changed since.
+//
+// The resolver is called once for each port, before SetPortResolver returns. Its first error is returned and
+// leaves the routing unchanged. SetPortResolver must not be called while playing. ThrottleOutput only applies to
+// the default output.
+func (p *Player) SetPortResolver(resolve PortResolver) error {
+	var res = []*output{p.outs[0]}
+	var outs = map[string]int{"": 0}
+	var indices = make([]int, len(p.events))
 
-	time.Sleep(p.metricTicks.TempoDuration(p.tempo, delta))
+	for i := range p.events {
+		port := p.events[i].port
+		idx, has := outs[port]
+
+		if !has && resolve != nil {
+			out, err := resolve(port)
+
+			if err != nil {
+				return fmt.Errorf("can't resolve port %q: %v", port, err)
+			}
+
+			if out != nil {
+				idx = len(res)
+				res = append(res, &output{out: out})
+			}
 
-	// track ended
-	if msg == meta.EndOfTrack {
-		return 0, io.EOF
+			outs[port] = idx
+		}
 
+		indices[i] = idx
 	}
 
-	switch v := msg.(type) {
+	p.outs = res
+	p.channelOuts = [16][]channelOut{}
 
-	case meta.Tempo:
-		p.tempo = v.BPM()
+	for i, idx := range indices {
+		p.events[i].out = idx
 
-	default:
-		if cm, ok := msg.(channel.Message); ok {
-			_, err = p.to.Write(cm)
+		if cm, is := p.events[i].msg.(channel.Message); is && cm.Channel() < 16 {
+			changes := p.channelOuts[cm.Channel()]
 
-			if err != nil {
-				return
+			if len(changes) == 0 || changes[len(changes)-1].out != idx {
+				p.channelOuts[cm.Channel()] = append(changes, channelOut{from: i, out: idx})
 			}
 		}
+	}
+
+	return nil
+}
+
+// chaseOutput returns the output for the given message of Seek: the output of the last event of its channel
+// before the position, respectively of its first event, if there is none.
+func (p *Player) chaseOutput(msg midi.Message) *output {
+	cm, is := msg.(channel.Message)
+
+	if !is || cm.Channel() > 15 || len(p.channelOuts[cm.Channel()]) == 0 {
+		return p.outs[0]
+	}
+
+	changes := p.channelOuts[cm.Channel()]
+
+	i := sort.Search(len(changes), func(i int) bool {
+		return changes[i].from >= p.from
+	})
 
-		// skip sysex messages, since they are too long
-		// skip meta messages, since they can't be used live
+	if i > 0 {
+		i--
 	}
 
-	return p.bf.Read(data)
+	return p.outs[changes[i].out]
 }
-*/
 
-/*
-// roundFloat rounds the given float by the given decimals after the dot
-func roundFloat(x float64, decimals int) float64 {
-	// return roundFloat(x, numDig(x)+decimals)
-	frep := strconv.FormatFloat(x, 'f', decimals, 64)
-	f, _ := strconv.ParseFloat(frep, 64)
-	return f
+// ticksDuration returns the duration of the given ticks at the given tempo (microseconds per quarter note)
+// and resolution (ticks per quarter note).
+func ticksDuration(ticks, tempo, tpq uint64) time.Duration {
+	hi, lo := bits.Mul64(ticks, tempo*uint64(time.Microsecond))
+
+	if hi >= tpq {
+		return time.Duration(1<<63 - 1)
+	}
+
+	q, _ := bits.Div64(hi, lo, tpq)
+
+	if q > 1<<63-1 {
+		return time.Duration(1<<63 - 1)
+	}
+
+	return time.Duration(q)
 }
-*/
 
-/*
- */
-/*
-type smfPlayer struct {
-	metricTicks smf.MetricTicks
-	tempo       uint32
-	bf          bytes.Buffer
-	to          midi.Writer
-	rd          smf.Reader
+// Play plays the SMF from the beginning, respectively from the position that has been set by Seek.
+// It blocks until all events have been written, or until Stop is called. The first error returned by the writer
+// is returned.
+func (p *Player) Play() error {
+	p.stopped.Store(false)
+	p.resetNotes()
+	p.resetStats()
+
+	for _, msg := range p.chase {
+		if err := p.chaseOutput(msg).out.Write(msg); err != nil {
+			return err
+		}
+	}
+
+	start := p.clock.Now()
+
+	for i := p.from; i < len(p.events); {
+		// the offsets are relative to the position
+		if !p.waitUntil(start, start.Add(p.events[i].offset-p.fromOffset)) {
+			return p.panic()
+		}
+
+		// write all events within the quantum in order
+		limit := p.clock.Now().Sub(start) + p.fromOffset + p.quantum
+
+		for ; i < len(p.events) && p.events[i].offset <= limit; i++ {
+			if err := p.write(p.events[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	p.elapsed.Store(int64(p.clock.Now().Sub(start)))
+	return p.flush()
+}
+
+// Stop stops the playing (see Play and SyncExternal). It may be called from another goroutine.
+// Before Play or SyncExternal return, the messages of channel.Panic are written to each output, including note off
+// messages for the notes that are still sounding.
+func (p *Player) Stop() {
+	p.stopped.Store(true)
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// PlayerStats are the counters of the last playing of a Player (see Stats)
+type PlayerStats struct {
+	// Wakeups is the number of coarse sleeps of the Player that have ended, i.e. the times the Player woke up
+	// while waiting for an event (without the busy waiting)
+	Wakeups uint64
+
+	// Elapsed is the time from the start of the playing to its end, respectively to the last wakeup while playing
+	Elapsed time.Duration
+}
+
+// WakeupsPerSecond returns the mean number of wakeups per second of the playing
+func (s PlayerStats) WakeupsPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Wakeups) / s.Elapsed.Seconds()
+}
+
+// Stats returns the counters of the current or the last call of Play. It may be called from another goroutine.
+func (p *Player) Stats() PlayerStats {
+	return PlayerStats{Wakeups: p.wakeups.Load(), Elapsed: time.Duration(p.elapsed.Load())}
+}
+
+// resetStats resets the counters of Stats and a pending wake up by Stop
+func (p *Player) resetStats() {
+	p.wakeups.Store(0)
+	p.elapsed.Store(0)
+
+	select {
+	case <-p.wake:
+	default:
+	}
+}
+
+// CurrentTick returns the tick of the last event that has been written, respectively the position that has been
+// set by Seek, if no event has been written since. It may be called from another goroutine.
+func (p *Player) CurrentTick() uint64 {
+	return p.tick.Load()
+}
+
+// CurrentPosition returns the current tick (see CurrentTick) as text, e.g. "tick 7680", respectively as bar and
+// beat with the BarPositions option, e.g. "bar 5 beat 1.00". It may be called from another goroutine.
+func (p *Player) CurrentPosition() string {
+	if p.meters == nil {
+		return fmt.Sprintf("tick %v", p.CurrentTick())
+	}
+	return p.meters.Position(p.CurrentTick(), p.positions...).String()
+}
+
+func (p *Player) write(ev scheduledEvent) error {
+	out := ev.out
+	p.tick.Store(ev.tick)
+
+	if cm, is := ev.msg.(channel.Message); is {
+		out = p.noteOutput(ev.track, cm, out)
+		p.outs[out].notes.Track(cm)
+	}
+	return p.outs[out].out.Write(ev.msg)
+}
+
+// noteOutput returns the index of the output for the given channel message of the given track: a note off message
+// is written to the output that its note has been started on, all other messages to the given output.
+func (p *Player) noteOutput(track int, msg channel.Message, out int) int {
+	if len(p.outs) == 1 {
+		return out
+	}
+
+	var key uint8
+	var on bool
+
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		key, on = v.Key(), v.Velocity() > 0
+	case channel.NoteOff:
+		key = v.Key()
+	case channel.NoteOffVelocity:
+		key = v.Key()
+	default:
+		return out
+	}
+
+	n := soundingNote{track: track, channel: msg.Channel(), key: key}
+
+	if on {
+		p.sounding[n] = append(p.sounding[n], out)
+		return out
+	}
+
+	started := p.sounding[n]
+
+	// the note has been started before the position of Seek
+	if len(started) == 0 {
+		return out
+	}
+
+	if len(started) == 1 {
+		delete(p.sounding, n)
+	} else {
+		p.sounding[n] = started[1:]
+	}
+
+	return started[0]
+}
+
+// resetNotes forgets the notes that have been written to the outputs
+func (p *Player) resetNotes() {
+	for _, o := range p.outs {
+		o.notes.Reset()
+	}
+
+	p.sounding = map[soundingNote][]int{}
+}
+
+// panic writes the messages that silence all channels of all outputs
+func (p *Player) panic() error {
+	for _, o := range p.outs {
+		for _, msg := range channel.Panic(channel.PanicNoteOffs(&o.notes)) {
+			if err := o.out.Write(msg); err != nil {
+				return err
+			}
+		}
+	}
+
+	p.resetNotes()
+	return p.flush()
+}
+
+// flush waits until the messages that are delayed by the Throttle are written
+func (p *Player) flush() error {
+	if p.throttled == nil {
+		return nil
+	}
+	return p.throttled.Flush()
+}
+
+// waitUntil waits until the given deadline. It returns false, if the Player has been stopped in the meantime.
+// start is the start of the playing.
+func (p *Player) waitUntil(start, deadline time.Time) bool {
+	wc, wakeable := p.clock.(WakeClock)
+
+	for {
+		if p.stopped.Load() {
+			return false
+		}
+
+		now := p.clock.Now()
+		p.elapsed.Store(int64(now.Sub(start)))
+		d := deadline.Sub(now)
+
+		if d <= 0 {
+			return true
+		}
+
+		if d > p.busyWait {
+			d -= p.busyWait
+			if !wakeable && d > maxSleep {
+				d = maxSleep
+			}
+
+			// write the delayed messages in time (errors are returned by the next write)
+			if p.throttled != nil {
+				if err := p.throttled.Pump(); err == nil {
+					if w := p.throttled.Wait(); w > 0 && w < d {
+						d = w
+					}
+				}
+			}
+
+			if wakeable {
+				wc.SleepWake(d, p.wake)
+			} else {
+				p.clock.Sleep(d)
+			}
+
+			p.wakeups.Add(1)
+			continue
+		}
+
+		runtime.Gosched()
+	}
 }
-*/
@@ -0,0 +1,461 @@
+package midiio
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// fakeClock only advances when sleeping
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+// wakeClock is a fakeClock that is a WakeClock
+type wakeClock struct {
+	fakeClock
+}
+
+func (c *wakeClock) SleepWake(d time.Duration, wake <-chan struct{}) { c.Sleep(d) }
+
+// timedSink records the messages together with the time of writing
+type timedSink struct {
+	clock Clock
+	start time.Time
+	bf    bytes.Buffer
+	times []time.Time
+}
+
+func (s *timedSink) Write(msg midi.Message) error {
+	now := s.clock.Now()
+	s.times = append(s.times, now)
+	fmt.Fprintf(&s.bf, "%v %s\n", now.Sub(s.start), msg)
+	return nil
+}
+
+func TestPlayer(t *testing.T) {
+	var tr smftrack.Track
+	ch := channel.Channel0
+	tr.Add(0, meta.BPM(120), ch.NoteOn(60, 100))
+	tr.Add(48, ch.NoteOff(60))
+	tr.Add(96, meta.BPM(60), ch.NoteOn(62, 100))
+	// within the quantum of the note before
+	tr.Add(97, ch.NoteOn(64, 100))
+	tr.Add(192, ch.NoteOff(62), ch.NoteOff(64))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+
+	p, err := NewPlayer(s, sink, UseClock(clock), BusyWait(0), Quantum(15*time.Millisecond))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if err := p.Play(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0s channel.NoteOn channel 1 key 60 velocity 100
+250ms channel.NoteOff channel 1 key 60
+500ms channel.NoteOn channel 1 key 62 velocity 100
+500ms channel.NoteOn channel 1 key 64 velocity 100
+1.5s channel.NoteOff channel 1 key 62
+1.5s channel.NoteOff channel 1 key 64
+`
+
+	if got, want := sink.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestPlayerSeek(t *testing.T) {
+	var tr smftrack.Track
+	ch := channel.Channel0
+	tr.Add(0, meta.BPM(120), ch.ProgramChange(5), ch.NoteOn(60, 100))
+	tr.Add(48, ch.NoteOff(60), ch.ControlChange(7, 80))
+	tr.Add(96, meta.BPM(60), ch.Pitchbend(100), ch.NoteOn(62, 100))
+	tr.Add(192, ch.NoteOff(62))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	expected := `0s channel.ProgramChange channel 1 program 5
+0s channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 80
+0s channel.Pitchbend channel 1 value 100 absValue 0
+0s channel.NoteOn channel 1 key 62 velocity 100
+1s channel.NoteOff channel 1 key 62
+`
+
+	for _, options := range [][]PlayerOption{nil, {SeekIndex(smftrack.NewIndex(s))}} {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		sink := &timedSink{clock: clock, start: clock.now}
+		p, err := NewPlayer(s, sink, append(options, UseClock(clock), BusyWait(0))...)
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		p.Seek(96)
+
+		if err := p.Play(); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		if got, want := sink.bf.String(), expected; got != want {
+			t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+		}
+	}
+}
+
+func TestPlayerPosition(t *testing.T) {
+	var tr smftrack.Track
+	ch := channel.Channel0
+	ts := func(num, denom uint8) meta.TimeSig {
+		return meta.TimeSig{Numerator: num, Denominator: denom, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8}
+	}
+
+	// two bars of 4/4, two bars of 3/4, then 7/8
+	tr.Add(0, ts(4, 4), ch.NoteOn(60, 100))
+	tr.Add(480, ch.NoteOff(60))
+	tr.Add(768, ts(3, 4))
+	tr.Add(1152, ch.NoteOn(62, 100))
+	tr.Add(1344, ts(7, 8))
+	tr.Add(1392, ch.NoteOff(62))
+	tr.Add(1632, ch.NoteOn(64, 100))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	tests := []struct {
+		options []PlayerOption
+		seek    uint64
+		golden  string
+	}{
+		{
+			nil,
+			0,
+			`tick 0
+tick 0 channel.NoteOn channel 1 key 60 velocity 100
+tick 480 channel.NoteOff channel 1 key 60
+tick 1152 channel.NoteOn channel 1 key 62 velocity 100
+tick 1392 channel.NoteOff channel 1 key 62
+tick 1632 channel.NoteOn channel 1 key 64 velocity 100
+`,
+		},
+		{
+			[]PlayerOption{BarPositions()},
+			0,
+			`bar 1 beat 1.00
+bar 1 beat 1.00 channel.NoteOn channel 1 key 60 velocity 100
+bar 2 beat 2.00 channel.NoteOff channel 1 key 60
+bar 4 beat 2.00 channel.NoteOn channel 1 key 62 velocity 100
+bar 5 beat 2.00 channel.NoteOff channel 1 key 62
+bar 5 beat 7.00 channel.NoteOn channel 1 key 64 velocity 100
+`,
+		},
+		{
+			[]PlayerOption{BarPositions(smftrack.FirstBar(0))},
+			1344,
+			`bar 4 beat 1.00
+bar 4 beat 2.00 channel.NoteOff channel 1 key 62
+bar 4 beat 7.00 channel.NoteOn channel 1 key 64 velocity 100
+`,
+		},
+	}
+
+	for i, test := range tests {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		var bf bytes.Buffer
+		var p *Player
+
+		sink := writerFunc(func(msg midi.Message) error {
+			// the chased messages of Seek are left out
+			if strings.HasPrefix(msg.String(), "channel.Note") {
+				fmt.Fprintf(&bf, "%s %s\n", p.CurrentPosition(), msg)
+			}
+			return nil
+		})
+
+		p, _ = NewPlayer(s, sink, append(test.options, UseClock(clock), BusyWait(0))...)
+		p.Seek(test.seek)
+		fmt.Fprintf(&bf, "%s\n", p.CurrentPosition())
+
+		if err := p.Play(); err != nil {
+			t.Fatalf("[%v] Error: %v", i, err)
+		}
+
+		if got, want := bf.String(), test.golden; got != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+	}
+}
+
+func TestPlayerStop(t *testing.T) {
+	var tr smftrack.Track
+	tr.Add(0, channel.Channel0.NoteOn(60, 100))
+	tr.Add(96, channel.Channel0.NoteOff(60))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+	var p *Player
+
+	// stop the player while it is waiting for the note off
+	stopper := writerFunc(func(msg midi.Message) error {
+		p.Stop()
+		return sink.Write(msg)
+	})
+
+	p, _ = NewPlayer(s, stopper, UseClock(clock), BusyWait(0))
+
+	if err := p.Play(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the note on, the panic messages for 16 channels and the note off for the sounding note
+	if got, want := len(sink.times), 1+16*3+1; got != want {
+		t.Errorf("number of written messages = %v; want %v", got, want)
+	}
+
+	lines := strings.Split(strings.TrimSpace(sink.bf.String()), "\n")
+
+	// after the control changes of channel 0
+	if got, want := lines[4], "0s channel.NoteOff channel 1 key 60"; got != want {
+		t.Errorf("message no 4 = %#v; want %#v", got, want)
+	}
+}
+
+func TestPlayerWakeups(t *testing.T) {
+	// two short notes with a rest of 10 minutes in between
+	var tr smftrack.Track
+	ch := channel.Channel0
+	tr.Add(0, meta.BPM(60), ch.NoteOn(60, 100))
+	tr.Add(96, ch.NoteOff(60))
+	tr.Add(96+600*96, ch.NoteOn(62, 100))
+	tr.Add(2*96+600*96, ch.NoteOff(62))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	// the wakeups grow with the number of events
+	clock := &wakeClock{fakeClock{now: time.Unix(0, 0)}}
+	p, _ := NewPlayer(s, writerFunc(func(midi.Message) error { return nil }), UseClock(clock), BusyWait(0))
+
+	if err := p.Play(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	stats := p.Stats()
+
+	if got, want := stats.Wakeups, uint64(3); got != want {
+		t.Errorf("wakeups = %v; want %v", got, want)
+	}
+
+	if got, want := stats.Elapsed, 602*time.Second; got != want {
+		t.Errorf("elapsed = %v; want %v", got, want)
+	}
+
+	if got := stats.WakeupsPerSecond(); got > 0.01 {
+		t.Errorf("wakeups per second = %v; want <= 0.01", got)
+	}
+
+	// clocks that are not a WakeClock sleep at most maxSleep at once
+	p, _ = NewPlayer(s, writerFunc(func(midi.Message) error { return nil }), UseClock(&fakeClock{now: time.Unix(0, 0)}), BusyWait(0))
+
+	if err := p.Play(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := p.Stats().Wakeups, uint64(602*time.Second/maxSleep); got != want {
+		t.Errorf("wakeups = %v; want %v", got, want)
+	}
+}
+
+func TestPlayerStopWhileSleeping(t *testing.T) {
+	var tr smftrack.Track
+	tr.Add(0, channel.Channel0.NoteOn(60, 100))
+	tr.Add(96*3600, channel.Channel0.NoteOff(60))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	p, _ := NewPlayer(s, writerFunc(func(midi.Message) error { return nil }))
+	done := make(chan error)
+
+	go func() {
+		done <- p.Play()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	p.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Play did not return after Stop")
+	}
+
+	if got := p.Stats().Wakeups; got != 1 {
+		t.Errorf("wakeups = %v; want 1", got)
+	}
+}
+
+func TestNewPlayerErrors(t *testing.T) {
+	if _, err := NewPlayer(smftrack.New(smf.SMF2, nil), nil); err == nil {
+		t.Errorf("expected error for SMF2")
+	}
+
+	if _, err := NewPlayer(smftrack.New(smf.SMF0, smf.SMPTE25(40)), nil); err == nil {
+		t.Errorf("expected error for SMPTE time format")
+	}
+}
+
+type writerFunc func(midi.Message) error
+
+func (w writerFunc) Write(msg midi.Message) error { return w(msg) }
+
+// arpeggio returns a SMF with n notes that are 5ms apart
+func arpeggio(n int) *smftrack.SMF {
+	var tr smftrack.Track
+	tr.Add(0, meta.BPM(60))
+
+	for i := 0; i < n; i++ {
+		tr.Add(uint64(i), channel.Channel0.NoteOn(uint8(60+i%12), 100))
+	}
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(200))
+	s.AddTrack(&tr)
+	return s
+}
+
+// playNaive is the naive scheduler for comparison: a sleep for each delta
+func playNaive(s *smftrack.SMF, out midi.Writer) {
+	var last uint64
+
+	for _, ev := range s.Merged() {
+		time.Sleep(time.Duration(ev.AbsTicks-last) * 5 * time.Millisecond)
+		last = ev.AbsTicks
+
+		if _, isMeta := ev.Message.(meta.Message); !isMeta {
+			out.Write(ev.Message)
+		}
+	}
+}
+
+// reportTimingErrors reports the percentiles of the timing errors of the events that should be 5ms apart
+func reportTimingErrors(b *testing.B, times []time.Time) {
+	var errs []time.Duration
+
+	for i, tm := range times {
+		d := tm.Sub(times[0]) - time.Duration(i)*5*time.Millisecond
+		if d < 0 {
+			d = -d
+		}
+		errs = append(errs, d)
+	}
+
+	sort.Slice(errs, func(a, b int) bool { return errs[a] < errs[b] })
+
+	b.ReportMetric(float64(errs[len(errs)/2].Microseconds()), "p50-µs")
+	b.ReportMetric(float64(errs[len(errs)*99/100].Microseconds()), "p99-µs")
+}
+
+func BenchmarkPlayerTiming(b *testing.B) {
+	s := arpeggio(200)
+	var times []time.Time
+
+	for i := 0; i < b.N; i++ {
+		sink := &timedSink{clock: SystemClock}
+		p, _ := NewPlayer(s, sink)
+		p.Play()
+		times = sink.times
+	}
+
+	reportTimingErrors(b, times)
+}
+
+func BenchmarkNaiveTiming(b *testing.B) {
+	s := arpeggio(200)
+	var times []time.Time
+
+	for i := 0; i < b.N; i++ {
+		sink := &timedSink{clock: SystemClock}
+		playNaive(s, sink)
+		times = sink.times
+	}
+
+	reportTimingErrors(b, times)
+}
+
+// BenchmarkPlayerSeek measures Seek to random ticks of a file with 1M events, with an Index that is restored from a
+// sidecar (see smftrack.Thaw) and without
+func BenchmarkPlayerSeek(b *testing.B) {
+	var tr smftrack.Track
+	r := rand.New(rand.NewSource(1))
+	evts := make([]smftrack.Event, 0, 1000000)
+
+	for i := 0; len(evts) < 1000000; i++ {
+		tick := uint64(i) * 10
+		ch := channel.Channel(r.Intn(16))
+
+		switch i % 3 {
+		case 0:
+			evts = append(evts, smftrack.Event{AbsTicks: tick, Message: ch.NoteOn(uint8(r.Intn(128)), 100)})
+		case 1:
+			evts = append(evts, smftrack.Event{AbsTicks: tick, Message: ch.NoteOff(uint8(r.Intn(128)))})
+		case 2:
+			evts = append(evts, smftrack.Event{AbsTicks: tick, Message: ch.ControlChange(uint8(r.Intn(128)), uint8(r.Intn(128)))})
+		}
+	}
+
+	tr.SetEvents(evts)
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(960))
+	s.AddTrack(&tr)
+
+	idx, err := smftrack.Thaw(s, smftrack.FreezeIndex(s, 960))
+
+	if err != nil {
+		b.Fatalf("Error: %v", err)
+	}
+
+	out := writerFunc(func(midi.Message) error { return nil })
+
+	for _, bench := range []struct {
+		name    string
+		options []PlayerOption
+	}{
+		{"sidecar", []PlayerOption{SeekIndex(idx)}},
+		{"scan", nil},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			p, _ := NewPlayer(s, out, bench.options...)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				p.Seek(uint64(r.Int63n(10000000)))
+			}
+		})
+	}
+}
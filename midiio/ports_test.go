@@ -0,0 +1,280 @@
+package midiio
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// portsSMF returns a SMF with a track on port "A", a track on the obsolete port 1, a track without port
+// and a track that switches from port "A" to port "B" in the middle.
+func portsSMF() *smftrack.SMF {
+	var conductor, a, legacy, none, switching smftrack.Track
+	conductor.Add(0, meta.BPM(120))
+
+	a.Add(0, meta.Device("A"), channel.Channel0.NoteOn(60, 100))
+	a.Add(96, channel.Channel0.NoteOff(60))
+
+	legacy.Add(0, meta.Port(1), channel.Channel1.NoteOn(62, 100))
+	legacy.Add(96, channel.Channel1.NoteOff(62))
+
+	none.Add(0, channel.Channel2.NoteOn(64, 100))
+	none.Add(96, channel.Channel2.NoteOff(64))
+
+	switching.Add(0, meta.Device("A"), channel.Channel3.ProgramChange(1))
+	switching.Add(48, meta.Device("B"), channel.Channel3.ProgramChange(2))
+
+	s := smftrack.New(smf.SMF1, smf.MetricTicks(96))
+	s.AddTrack(&conductor)
+	s.AddTrack(&a)
+	s.AddTrack(&legacy)
+	s.AddTrack(&none)
+	s.AddTrack(&switching)
+	return s
+}
+
+func TestPlayerPorts(t *testing.T) {
+	s := portsSMF()
+
+	if got, want := smftrack.Ports(s), []string{"A", "1", "B"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Ports() = %v; want %v", got, want)
+	}
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	def := &timedSink{clock: clock, start: clock.now}
+	outA := &timedSink{clock: clock, start: clock.now}
+	out1 := &timedSink{clock: clock, start: clock.now}
+
+	p, err := NewPlayer(s, def, UseClock(clock), BusyWait(0))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var resolved []string
+
+	err = p.SetPortResolver(func(name string) (midi.Writer, error) {
+		resolved = append(resolved, name)
+
+		switch name {
+		case "A":
+			return outA, nil
+		case "1":
+			return out1, nil
+		}
+
+		// "B" falls back to the default output
+		return nil, nil
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := resolved, []string{"A", "1", "B"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolved ports = %v; want %v", got, want)
+	}
+
+	if err := p.Play(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := []struct {
+		name string
+		sink *timedSink
+		want string
+	}{
+		{"A", outA, `0s channel.NoteOn channel 1 key 60 velocity 100
+0s channel.ProgramChange channel 4 program 1
+500ms channel.NoteOff channel 1 key 60
+`},
+		{"1", out1, `0s channel.NoteOn channel 2 key 62 velocity 100
+500ms channel.NoteOff channel 2 key 62
+`},
+		{"default", def, `0s channel.NoteOn channel 3 key 64 velocity 100
+250ms channel.ProgramChange channel 4 program 2
+500ms channel.NoteOff channel 3 key 64
+`},
+	}
+
+	for _, e := range expected {
+		if got := e.sink.bf.String(); got != e.want {
+			t.Errorf("[%s] got:\n%s\n\nwanted:\n%s\n\n", e.name, got, e.want)
+		}
+	}
+}
+
+func TestPlayerPortsError(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	def := &timedSink{clock: clock, start: clock.now}
+	outA := &timedSink{clock: clock, start: clock.now}
+
+	p, _ := NewPlayer(portsSMF(), def, UseClock(clock), BusyWait(0))
+
+	p.SetPortResolver(func(name string) (midi.Writer, error) {
+		if name == "A" {
+			return outA, nil
+		}
+		return nil, nil
+	})
+
+	// port "1" can't be resolved after port "A" has been
+	err := p.SetPortResolver(func(name string) (midi.Writer, error) {
+		if name == "A" {
+			return writerFunc(func(midi.Message) error { return nil }), nil
+		}
+		return nil, fmt.Errorf("unknown port")
+	})
+
+	if err == nil {
+		t.Errorf("expected error for unresolvable port")
+	}
+
+	if err := p.Play(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the routing of the first resolver is kept
+	if got, want := len(outA.times), 3; got != want {
+		t.Errorf("number of messages on port A = %v; want %v", got, want)
+	}
+}
+
+func TestPlayerPortsStop(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	def := &timedSink{clock: clock, start: clock.now}
+	outA := &timedSink{clock: clock, start: clock.now}
+	var p *Player
+
+	// stop the player with the first message on port "A"
+	stopper := writerFunc(func(msg midi.Message) error {
+		p.Stop()
+		return outA.Write(msg)
+	})
+
+	p, _ = NewPlayer(portsSMF(), def, UseClock(clock), BusyWait(0))
+	p.SetPortResolver(func(name string) (midi.Writer, error) {
+		if name == "A" {
+			return stopper, nil
+		}
+		return nil, nil
+	})
+
+	if err := p.Play(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// each output gets the panic messages, port "A" also the note off of its sounding note
+	if got, want := len(outA.times), 2+16*3+1; got != want {
+		t.Errorf("number of messages on port A = %v; want %v", got, want)
+	}
+
+	if got, want := len(def.times), 2+16*3+2; got != want {
+		t.Errorf("number of messages on the default output = %v; want %v", got, want)
+	}
+}
+
+func TestPlayerPortsSwitchNote(t *testing.T) {
+	var tr smftrack.Track
+	tr.Add(0, meta.Device("A"), channel.Channel0.NoteOn(60, 100))
+	// the note is ended on port "A", where it has been started
+	tr.Add(48, meta.Device("B"), channel.Channel0.NoteOn(62, 100))
+	tr.Add(96, channel.Channel0.NoteOff(60), channel.Channel0.NoteOff(62))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	def := &timedSink{clock: clock, start: clock.now}
+	outA := &timedSink{clock: clock, start: clock.now}
+	outB := &timedSink{clock: clock, start: clock.now}
+
+	p, _ := NewPlayer(s, def, UseClock(clock), BusyWait(0))
+
+	err := p.SetPortResolver(func(name string) (midi.Writer, error) {
+		if name == "A" {
+			return outA, nil
+		}
+		return outB, nil
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if err := p.Play(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := []struct {
+		name string
+		sink *timedSink
+		want string
+	}{
+		{"A", outA, `0s channel.NoteOn channel 1 key 60 velocity 100
+500ms channel.NoteOff channel 1 key 60
+`},
+		{"B", outB, `250ms channel.NoteOn channel 1 key 62 velocity 100
+500ms channel.NoteOff channel 1 key 62
+`},
+	}
+
+	for _, e := range expected {
+		if got := e.sink.bf.String(); got != e.want {
+			t.Errorf("[%s] got:\n%s\n\nwanted:\n%s\n\n", e.name, got, e.want)
+		}
+	}
+}
+
+func TestPlayerPortsSeek(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	def := &timedSink{clock: clock, start: clock.now}
+	outA := &timedSink{clock: clock, start: clock.now}
+
+	s := portsSMF()
+	s.Track(1).Add(0, channel.Channel0.ControlChange(7, 90))
+
+	p, _ := NewPlayer(s, def, UseClock(clock), BusyWait(0))
+
+	p.SetPortResolver(func(name string) (midi.Writer, error) {
+		if name == "A" {
+			return outA, nil
+		}
+		return nil, nil
+	})
+
+	p.Seek(72)
+
+	if err := p.Play(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// channel 1 plays on port "A", channel 4 has switched from port "A" to the default output (port "B")
+	// before the position
+	expected := []struct {
+		name string
+		sink *timedSink
+		want string
+	}{
+		{"A", outA, `0s channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 90
+125ms channel.NoteOff channel 1 key 60
+`},
+		{"default", def, `0s channel.ProgramChange channel 4 program 2
+125ms channel.NoteOff channel 2 key 62
+125ms channel.NoteOff channel 3 key 64
+`},
+	}
+
+	for _, e := range expected {
+		if got := e.sink.bf.String(); got != e.want {
+			t.Errorf("[%s] got:\n%s\n\nwanted:\n%s\n\n", e.name, got, e.want)
+		}
+	}
+}
@@ -1,82 +1,121 @@
 package midiio
 
-/*
 import (
-	"bytes"
 	"fmt"
-	"github.com/gomidi/midi"
-	"github.com/gomidi/midi/live/midireader"
-	"github.com/gomidi/midi/messages/meta"
-	"io"
+	"sync"
 	"time"
 
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
 	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
 )
 
-// NewRecorder records into a single track on target, it needs the tempo to calculate the ticks from seconds
-// src is expected to be of format SMF0. Use smftrack to convert it to SMF1 (one track per MIDI channel) after the recording.
-// it makes sense to have a metronome playing alongside with the same tempo.
-// Also tempo should not change while recording.
-func NewRecorder(target smf.Writer, tempo uint) (io.Writer, error) {
-	_, err := target.WriteHeader()
+// Recorder records the MIDI messages that are written to it into a single track.
+// The position of the messages is calculated from the time that has passed since the creation
+// of the Recorder, based on the tempo (which does not change while recording).
+// It makes sense to have a metronome playing alongside with the same tempo.
+//
+// Use smftrack to put the track into a SMF.
+type Recorder struct {
+	mx      sync.Mutex
+	clock   Clock
+	ticks   smf.MetricTicks
+	tempo   meta.Tempo
+	start   time.Time
+	track   smftrack.Track
+	notes   channel.NoteTracker
+	used    [16]bool
+	stopped bool
+}
 
-	if err != nil {
-		return nil, err
+// NewRecorder starts a recording with the given resolution and tempo. If clock is nil, SystemClock is used.
+// The tempo is recorded as the first message.
+func NewRecorder(ticks smf.MetricTicks, tempo meta.Tempo, clock Clock) *Recorder {
+	if clock == nil {
+		clock = SystemClock
 	}
 
-	if target.Header().Format != smf.SMF0 {
-		return nil, fmt.Errorf("only SMF0 files supported, sorry, please convert your file")
-	}
+	r := &Recorder{clock: clock, ticks: ticks, tempo: tempo}
+	r.track.Add(0, tempo)
+	r.start = clock.Now()
+	return r
+}
+
+// Write records the given message at the current position. Only channel messages and sysex messages
+// are recorded, other messages are ignored. Writing after Stop or Abort returns an error.
+func (r *Recorder) Write(msg midi.Message) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
 
-	ti, isMetric := target.Header().TimeFormat.(smf.MetricTicks)
-	if !isMetric {
-		return nil, fmt.Errorf("only metric timeformat supported, sorry")
+	if r.stopped {
+		return fmt.Errorf("recording has been stopped")
 	}
 
-	_, err = target.Write(meta.Tempo(tempo))
-	if err != nil {
-		return nil, err
+	switch v := msg.(type) {
+	case channel.Message:
+		r.notes.Track(v)
+		if v.Channel() < 16 {
+			r.used[v.Channel()] = true
+		}
+	case sysex.SysEx:
+	default:
+		return nil
 	}
 
-	p := &recorder{}
-	p.metricTicks = ti
-	p.to = target
-	p.from = midireader.New(&p.bf, nil)
-	p.start = time.Now()
-	return p, nil
+	r.track.Add(r.now(), msg)
+	return nil
 }
 
-// each write does in fact write to the midi.Writer passed to new
-func (p *recorder) Write(data []byte) (n int, err error) {
+// now returns the current position in ticks
+func (r *Recorder) now() uint64 {
+	return uint64(r.ticks.FractionalTicks(r.tempo.FractionalBPM(), r.clock.Now().Sub(r.start)))
+}
 
-	_, err = p.bf.Write(data)
-	if err != nil {
-		return
-	}
+// Stop stops the recording and returns the recorded track, that ends at the current position.
+func (r *Recorder) Stop() *smftrack.Track {
+	r.mx.Lock()
+	defer r.mx.Unlock()
 
-	var msg midi.Message
-	msg, err = p.from.Read()
+	r.finish()
+	return &r.track
+}
 
-	if err != nil {
-		return
+// Abort stops the recording and returns the recorded track, that ends at the current position.
+// In contrast to Stop, the messages of channel.Panic are recorded for all channels that have been
+// used, so that there are no hanging notes within the recorded track.
+func (r *Recorder) Abort() *smftrack.Track {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if !r.stopped {
+		var channels []uint8
+
+		for ch, used := range r.used {
+			if used {
+				channels = append(channels, uint8(ch))
+			}
+		}
+
+		if len(channels) > 0 {
+			now := r.now()
+			for _, msg := range channel.Panic(channel.PanicChannels(channels...), channel.PanicNoteOffs(&r.notes)) {
+				r.track.Add(now, msg)
+			}
+		}
 	}
 
-	p.bf.Reset()
-
-	now := time.Now()
-	d := now.Sub(p.start)
-	p.start = now
-	p.to.SetDelta(p.metricTicks.TempoTicks(p.tempo, d))
-
-	return p.to.Write(msg)
+	r.finish()
+	return &r.track
 }
 
-type recorder struct {
-	metricTicks smf.MetricTicks
-	bf          bytes.Buffer
-	from        midi.Reader
-	tempo       uint32
-	to          smf.Writer
-	start       time.Time
+func (r *Recorder) finish() {
+	if r.stopped {
+		return
+	}
+
+	r.stopped = true
+	r.track.SetEnd(r.now())
 }
-*/
@@ -0,0 +1,80 @@
+package midiio
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+func recordedString(tr *smftrack.Track) string {
+	var bf bytes.Buffer
+
+	for _, ev := range tr.Events() {
+		fmt.Fprintf(&bf, "%v %s\n", ev.AbsTicks, ev.Message)
+	}
+
+	fmt.Fprintf(&bf, "%v end\n", tr.End())
+	return bf.String()
+}
+
+func TestRecorder(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	r := NewRecorder(smf.MetricTicks(96), meta.BPM(120), clock)
+	ch := channel.Channel1
+
+	r.Write(ch.NoteOn(60, 100))
+	clock.Sleep(250 * time.Millisecond)
+	r.Write(ch.NoteOn(64, 100))
+	r.Write(realtime.TimingClock)
+	clock.Sleep(250 * time.Millisecond)
+	r.Write(ch.NoteOff(60))
+	clock.Sleep(500 * time.Millisecond)
+
+	tr := r.Stop()
+
+	expected := `0 meta.Tempo BPM: 120.00
+0 channel.NoteOn channel 2 key 60 velocity 100
+48 channel.NoteOn channel 2 key 64 velocity 100
+96 channel.NoteOff channel 2 key 60
+192 end
+`
+
+	if got, want := recordedString(tr), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if err := r.Write(ch.NoteOff(64)); err == nil {
+		t.Errorf("expected error when writing after Stop")
+	}
+}
+
+func TestRecorderAbort(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	r := NewRecorder(smf.MetricTicks(96), meta.BPM(120), clock)
+	ch := channel.Channel1
+
+	r.Write(ch.NoteOn(60, 100))
+	clock.Sleep(500 * time.Millisecond)
+
+	tr := r.Abort()
+
+	expected := `0 meta.Tempo BPM: 120.00
+0 channel.NoteOn channel 2 key 60 velocity 100
+96 channel.ControlChange channel 2 controller 123 ("All Notes Off") value 0
+96 channel.ControlChange channel 2 controller 120 ("All Sound Off") value 0
+96 channel.ControlChange channel 2 controller 64 ("Hold Pedal (on/off)") value 0 (off)
+96 channel.NoteOff channel 2 key 60
+96 end
+`
+
+	if got, want := recordedString(tr), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+package midiio
+
+import (
+	"io"
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/syscommon"
+)
+
+// clocksPerQuarter is the number of MIDI timing clocks per quarter note
+const clocksPerQuarter = 24
+
+// SyncExternal plays the SMF as a slave of an external MIDI clock that is read from the given reader.
+// The reader must return the realtime messages (e.g. from the realtime handler of a midireader).
+//
+// Each timing clock advances the playback position by 1/24 of a quarter note. Start starts the playback
+// from the beginning, Stop stops it and Continue continues from the current position.
+// A song position pointer (SPP) moves the position to the given number of 16th notes. All other messages
+// are ignored, as well as the tempo messages of the SMF.
+//
+// Following the MIDI specification, the playback begins with the first timing clock after Start or Continue:
+// Each timing clock writes the events up to (and excluding) the position of the next timing clock.
+//
+// SyncExternal returns, when the reader returns io.EOF (nil is returned), when Stop is called
+// (after writing the panic messages, see Stop) or when the reader or the writer returns an error (the error is returned).
+//
+// The reader is read by another goroutine, so that Stop does not have to wait for the next message of a silent
+// input. The Read that is pending when Stop is called is not interrupted though: it ends with the next message
+// (which is dropped), or when the caller closes the input.
+func (p *Player) SyncExternal(in midi.Reader) error {
+	p.stopped.Store(false)
+	p.resetNotes()
+	p.resetStats()
+
+	// the next message is only read on request, so that the reader is never ahead of the playback
+	next := make(chan struct{})
+	read := make(chan readResult, 1)
+	defer close(next)
+
+	go func() {
+		for range next {
+			msg, err := in.Read()
+			read <- readResult{msg, err}
+		}
+	}()
+
+	// clocks is the position in MIDI clocks
+	var clocks uint64
+	var running bool
+	var i int
+
+	for !p.stopped.Load() {
+		next <- struct{}{}
+
+		var r readResult
+
+		select {
+		case r = <-read:
+		case <-p.wake:
+			// only Stop wakes the Player
+			return p.panic()
+		}
+
+		msg, err := r.msg, r.err
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch v := msg.(type) {
+		case syscommon.SPP:
+			// the position is only changed while not running
+			if running {
+				continue
+			}
+			clocks = uint64(v.Number()) * clocksPerQuarter / 4
+			i = p.firstEventAt(clocks)
+		default:
+			switch msg {
+			case realtime.Start:
+				running = true
+				clocks = 0
+				i = 0
+			case realtime.Continue:
+				running = true
+			case realtime.Stop:
+				running = false
+			case realtime.TimingClock:
+				if !running {
+					continue
+				}
+
+				clocks++
+
+				for ; i < len(p.events) && p.events[i].tick*clocksPerQuarter < clocks*p.tpq; i++ {
+					if err := p.write(p.events[i]); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return p.panic()
+}
+
+// readResult is the result of a Read
+type readResult struct {
+	msg midi.Message
+	err error
+}
+
+// firstEventAt returns the index of the first event at or after the given position in MIDI clocks
+func (p *Player) firstEventAt(clocks uint64) int {
+	return sort.Search(len(p.events), func(i int) bool {
+		return p.events[i].tick*clocksPerQuarter >= clocks*p.tpq
+	})
+}
@@ -0,0 +1,181 @@
+package midiio
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/syscommon"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// clockStream returns the messages one after another, advancing the clock by the given intervals before each message
+type clockStream struct {
+	clock     *fakeClock
+	msgs      []midi.Message
+	intervals []time.Duration
+}
+
+func (c *clockStream) Read() (midi.Message, error) {
+	if len(c.msgs) == 0 {
+		return nil, io.EOF
+	}
+	msg := c.msgs[0]
+	c.msgs = c.msgs[1:]
+
+	if len(c.intervals) > 0 {
+		c.clock.Sleep(c.intervals[0])
+		c.intervals = c.intervals[1:]
+	}
+
+	return msg, nil
+}
+
+func (c *clockStream) add(interval time.Duration, msgs ...midi.Message) {
+	for _, msg := range msgs {
+		c.msgs = append(c.msgs, msg)
+		c.intervals = append(c.intervals, interval)
+	}
+}
+
+func syncFile() *smftrack.SMF {
+	var tr smftrack.Track
+	ch := channel.Channel0
+	// the tempo is ignored
+	tr.Add(0, meta.BPM(30))
+
+	// 16th notes
+	for i := uint64(0); i < 4; i++ {
+		tr.Add(i*24, ch.NoteOn(uint8(60+i), 100))
+	}
+
+	tr.Add(192, ch.NoteOn(72, 100))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+	return s
+}
+
+func TestSyncExternalTempoRamp(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+	in := &clockStream{clock: clock}
+
+	in.add(0, realtime.Start)
+
+	// accelerating clock
+	for k := 0; k < 20; k++ {
+		in.add(20*time.Millisecond-time.Duration(k)*500*time.Microsecond, realtime.TimingClock)
+	}
+
+	p, _ := NewPlayer(syncFile(), sink, UseClock(clock))
+
+	if err := p.SyncExternal(in); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `20ms channel.NoteOn channel 1 key 60 velocity 100
+129.5ms channel.NoteOn channel 1 key 61 velocity 100
+221ms channel.NoteOn channel 1 key 62 velocity 100
+294.5ms channel.NoteOn channel 1 key 63 velocity 100
+`
+
+	if got, want := sink.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestSyncExternalRelocate(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+	in := &clockStream{clock: clock}
+	ms := time.Millisecond
+
+	in.add(0, realtime.Start)
+
+	for k := 0; k < 7; k++ {
+		in.add(10*ms, realtime.TimingClock)
+	}
+
+	// ignored while running
+	in.add(0, syscommon.SPP(2))
+	in.add(0, realtime.Stop)
+	// ignored while stopped
+	in.add(10*ms, realtime.TimingClock)
+	// move to the second half note (8 16ths)
+	in.add(0, syscommon.SPP(8))
+	in.add(0, realtime.Continue)
+	in.add(10*ms, realtime.TimingClock)
+	in.add(10*ms, realtime.TimingClock)
+
+	p, _ := NewPlayer(syncFile(), sink, UseClock(clock))
+
+	if err := p.SyncExternal(in); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `10ms channel.NoteOn channel 1 key 60 velocity 100
+70ms channel.NoteOn channel 1 key 61 velocity 100
+90ms channel.NoteOn channel 1 key 72 velocity 100
+`
+
+	if got, want := sink.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+// silentInput returns the messages and then blocks until it is closed
+type silentInput struct {
+	msgs    []midi.Message
+	blocked chan struct{}
+	closed  chan struct{}
+}
+
+func (s *silentInput) Read() (midi.Message, error) {
+	if len(s.msgs) > 0 {
+		msg := s.msgs[0]
+		s.msgs = s.msgs[1:]
+		return msg, nil
+	}
+
+	close(s.blocked)
+	<-s.closed
+	return nil, io.EOF
+}
+
+func TestSyncExternalStopSilent(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+	in := &silentInput{msgs: []midi.Message{realtime.Start, realtime.TimingClock}, blocked: make(chan struct{}), closed: make(chan struct{})}
+	defer close(in.closed)
+
+	p, _ := NewPlayer(syncFile(), sink, UseClock(clock))
+	done := make(chan error)
+
+	go func() {
+		done <- p.SyncExternal(in)
+	}()
+
+	<-in.blocked
+	p.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("SyncExternal has not returned after Stop")
+	}
+
+	// the sounding note is ended
+	if got, want := sink.bf.String(), "0s channel.NoteOff channel 1 key 60\n"; !strings.Contains(got, want) {
+		t.Errorf("got:\n%s\n\nwanted to contain:\n%s\n\n", got, want)
+	}
+}
@@ -0,0 +1,322 @@
+package midiio
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/internal/controllers"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+type thinState struct {
+	lastTime  time.Time
+	lastValue int
+	pending   midi.Message
+}
+
+// ThinningWriter is a midi.Writer that thins out dense control change, pitch bend, aftertouch and polyphonic
+// aftertouch streams before writing them to another midi.Writer. It is the live variant of smftrack.ThinControllers.
+//
+// A message is held back, if the time since the last written message of the same controller (on the same channel)
+// is below the minimal interval and the change of the value is below the minimal delta.
+// The last held back message of a controller (the final value of a ramp) is written by Flush, if the minimal interval
+// has passed, or before the next note on message on the same channel. Flush should be called regularly
+// (e.g. every minimal interval).
+type ThinningWriter struct {
+	mx       sync.Mutex
+	out      midi.Writer
+	clock    Clock
+	interval time.Duration
+	delta    int
+	streams  map[[4]uint8]*thinState
+}
+
+// NewThinningWriter returns a ThinningWriter that writes to out. If clock is nil, SystemClock is used.
+func NewThinningWriter(out midi.Writer, minInterval time.Duration, minDelta uint8, clock Clock) *ThinningWriter {
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	return &ThinningWriter{
+		out:      out,
+		clock:    clock,
+		interval: minInterval,
+		delta:    int(minDelta),
+		streams:  map[[4]uint8]*thinState{},
+	}
+}
+
+// Write writes the given message or holds it back (see ThinningWriter).
+func (w *ThinningWriter) Write(msg midi.Message) error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	if on, is := msg.(channel.NoteOn); is && on.Velocity() > 0 {
+		// establish the final values of the channel before the note starts
+		if err := w.flush(func(stream [4]uint8, st *thinState) bool { return stream[1] == on.Channel() }); err != nil {
+			return err
+		}
+	}
+
+	stream, value, ok := controllers.Stream(msg)
+
+	if !ok {
+		return w.out.Write(msg)
+	}
+
+	now := w.clock.Now()
+	st := w.streams[stream]
+
+	if st != nil {
+		diff := value - st.lastValue
+		if diff < 0 {
+			diff = -diff
+		}
+
+		if now.Sub(st.lastTime) < w.interval && diff < w.delta {
+			st.pending = msg
+			return nil
+		}
+	} else {
+		st = &thinState{}
+		w.streams[stream] = st
+	}
+
+	st.lastTime, st.lastValue, st.pending = now, value, nil
+	return w.out.Write(msg)
+}
+
+// Flush writes the held back messages of the controllers, whose last written message is at least
+// the minimal interval ago.
+func (w *ThinningWriter) Flush() error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	now := w.clock.Now()
+	return w.flush(func(stream [4]uint8, st *thinState) bool { return now.Sub(st.lastTime) >= w.interval })
+}
+
+// flush writes the held back messages of the streams that match, in the order of the streams
+func (w *ThinningWriter) flush(match func(stream [4]uint8, st *thinState) bool) error {
+	var streams [][4]uint8
+
+	for stream, st := range w.streams {
+		if st.pending != nil && match(stream, st) {
+			streams = append(streams, stream)
+		}
+	}
+
+	sort.Slice(streams, func(a, b int) bool {
+		for i := range streams[a] {
+			if streams[a][i] != streams[b][i] {
+				return streams[a][i] < streams[b][i]
+			}
+		}
+		return false
+	})
+
+	now := w.clock.Now()
+
+	for _, stream := range streams {
+		st := w.streams[stream]
+		_, value, _ := controllers.Stream(st.pending)
+
+		if err := w.out.Write(st.pending); err != nil {
+			return err
+		}
+
+		st.lastTime, st.lastValue, st.pending = now, value, nil
+	}
+
+	return nil
+}
+
+// pressureState is the state of the pressure of a channel or key
+type pressureState struct {
+	thinState
+
+	// fresh is true, if no message has been written since the last note on
+	fresh bool
+}
+
+// PressureThinningWriter is a midi.Writer that thins out dense aftertouch and polyphonic aftertouch streams
+// before writing them to another midi.Writer. It is the live variant of smftrack.ThinPressure.
+//
+// As with ThinningWriter, a pressure message is held back, if the time since the last written message of the same
+// channel (respectively key for polyphonic aftertouch) is below the minimal interval and the change of the pressure is
+// below the minimal delta. Flush writes the held back final values. The first pressure message after a note on message
+// on the channel (respectively of the key) is always written.
+//
+// If gated, pressure messages are dropped while no note is sounding on the channel (respectively while the key is not
+// sounding), and a pressure of 0 is written before the note off message that ends the last sounding note, if the last
+// written pressure is not 0.
+type PressureThinningWriter struct {
+	mx       sync.Mutex
+	out      midi.Writer
+	clock    Clock
+	interval time.Duration
+	delta    int
+	gate     bool
+	notes    channel.NoteTracker
+	streams  map[[2]uint8]*pressureState
+}
+
+// NewPressureThinningWriter returns a PressureThinningWriter that writes to out. If clock is nil, SystemClock is used.
+func NewPressureThinningWriter(out midi.Writer, minInterval time.Duration, minDelta uint8, gateToNotes bool, clock Clock) *PressureThinningWriter {
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	return &PressureThinningWriter{
+		out:      out,
+		clock:    clock,
+		interval: minInterval,
+		delta:    int(minDelta),
+		gate:     gateToNotes,
+		streams:  map[[2]uint8]*pressureState{},
+	}
+}
+
+// stream returns the state of the given stream
+func (w *PressureThinningWriter) stream(stream [2]uint8) *pressureState {
+	st := w.streams[stream]
+	if st == nil {
+		st = &pressureState{fresh: true}
+		w.streams[stream] = st
+	}
+	return st
+}
+
+// Write writes the given message, holds it back or drops it (see PressureThinningWriter).
+func (w *PressureThinningWriter) Write(msg midi.Message) error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		if v.Velocity() > 0 {
+			w.notes.Track(v)
+			w.stream([2]uint8{v.Channel(), 128}).fresh = true
+			w.stream([2]uint8{v.Channel(), v.Key()}).fresh = true
+			return w.out.Write(msg)
+		}
+		return w.release(v, v.Key())
+	case channel.NoteOff:
+		return w.release(v, v.Key())
+	case channel.NoteOffVelocity:
+		return w.release(v, v.Key())
+	case channel.Aftertouch:
+		if w.gate && len(w.notes.Active(v.Channel())) == 0 {
+			return nil
+		}
+		return w.write([2]uint8{v.Channel(), 128}, v.Pressure(), msg)
+	case channel.PolyAftertouch:
+		if w.gate && !w.notes.IsActive(v.Channel(), v.Key()) {
+			return nil
+		}
+		return w.write([2]uint8{v.Channel(), v.Key()}, v.Pressure(), msg)
+	}
+
+	return w.out.Write(msg)
+}
+
+// write writes the given pressure message of the given stream or holds it back
+func (w *PressureThinningWriter) write(stream [2]uint8, pressure uint8, msg midi.Message) error {
+	now := w.clock.Now()
+	st := w.stream(stream)
+
+	if !st.fresh {
+		diff := int(pressure) - st.lastValue
+		if diff < 0 {
+			diff = -diff
+		}
+
+		if now.Sub(st.lastTime) < w.interval && diff < w.delta {
+			st.pending = msg
+			return nil
+		}
+	}
+
+	st.lastTime, st.lastValue, st.pending, st.fresh = now, int(pressure), nil, false
+	return w.out.Write(msg)
+}
+
+// release writes the given note off message of the given key, preceded by the zero pressures of the key and channel
+// that are released by it, if gated
+func (w *PressureThinningWriter) release(msg channel.Message, key uint8) error {
+	ch := msg.Channel()
+	w.notes.Track(msg)
+
+	if w.gate {
+		released := []struct {
+			stream [2]uint8
+			zero   midi.Message
+			ended  bool
+		}{
+			{[2]uint8{ch, key}, channel.Channel(ch).PolyAftertouch(key, 0), !w.notes.IsActive(ch, key)},
+			{[2]uint8{ch, 128}, channel.Channel(ch).Aftertouch(0), len(w.notes.Active(ch)) == 0},
+		}
+
+		for _, r := range released {
+			st := w.streams[r.stream]
+
+			if !r.ended || st == nil {
+				continue
+			}
+
+			delete(w.streams, r.stream)
+
+			if !st.fresh && st.lastValue > 0 {
+				if err := w.out.Write(r.zero); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return w.out.Write(msg)
+}
+
+// Flush writes the held back pressure messages of the channels and keys, whose last written message is at least
+// the minimal interval ago.
+func (w *PressureThinningWriter) Flush() error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	now := w.clock.Now()
+	var streams [][2]uint8
+
+	for stream, st := range w.streams {
+		if st.pending != nil && now.Sub(st.lastTime) >= w.interval {
+			streams = append(streams, stream)
+		}
+	}
+
+	sort.Slice(streams, func(a, b int) bool {
+		if streams[a][0] != streams[b][0] {
+			return streams[a][0] < streams[b][0]
+		}
+		return streams[a][1] < streams[b][1]
+	})
+
+	for _, stream := range streams {
+		st := w.streams[stream]
+
+		if err := w.out.Write(st.pending); err != nil {
+			return err
+		}
+
+		switch v := st.pending.(type) {
+		case channel.Aftertouch:
+			st.lastValue = int(v.Pressure())
+		case channel.PolyAftertouch:
+			st.lastValue = int(v.Pressure())
+		}
+
+		st.lastTime, st.pending = now, nil
+	}
+
+	return nil
+}
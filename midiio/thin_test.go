@@ -0,0 +1,100 @@
+package midiio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+func TestThinningWriter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+	w := NewThinningWriter(sink, 10*time.Millisecond, 8, clock)
+	ch := channel.Channel0
+
+	// a volume ramp every millisecond
+	for i := 0; i < 100; i++ {
+		w.Write(ch.ControlChange(7, uint8(i)))
+		clock.Sleep(time.Millisecond)
+	}
+
+	// too early for the final value
+	w.Flush()
+	clock.Sleep(10 * time.Millisecond)
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// a bend ramp that is interrupted by a note on
+	w.Write(ch.Pitchbend(0))
+	w.Write(ch.Pitchbend(100))
+	w.Write(ch.NoteOn(60, 100))
+
+	expected := `0s channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 0
+8ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 8
+16ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 16
+24ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 24
+32ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 32
+40ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 40
+48ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 48
+56ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 56
+64ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 64
+72ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 72
+80ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 80
+88ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 88
+96ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 96
+110ms channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 99
+110ms channel.Pitchbend channel 1 value 0 absValue 0
+110ms channel.Pitchbend channel 1 value 100 absValue 0
+110ms channel.NoteOn channel 1 key 60 velocity 100
+`
+
+	if got, want := sink.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestPressureThinningWriter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+	w := NewPressureThinningWriter(sink, 10*time.Millisecond, 8, true, clock)
+	ch := channel.Channel0
+
+	// pressure in silence
+	w.Write(ch.Aftertouch(20))
+	clock.Sleep(time.Millisecond)
+
+	// a pressure ramp every millisecond while the note sounds
+	w.Write(ch.NoteOn(60, 100))
+
+	for i := 0; i < 30; i++ {
+		w.Write(ch.Aftertouch(uint8(10 + i)))
+		w.Write(ch.PolyAftertouch(64, 50))
+		clock.Sleep(time.Millisecond)
+	}
+
+	clock.Sleep(10 * time.Millisecond)
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	w.Write(ch.NoteOff(60))
+	w.Write(ch.Aftertouch(5))
+
+	expected := `1ms channel.NoteOn channel 1 key 60 velocity 100
+1ms channel.Aftertouch channel 1 pressure 10
+9ms channel.Aftertouch channel 1 pressure 18
+17ms channel.Aftertouch channel 1 pressure 26
+25ms channel.Aftertouch channel 1 pressure 34
+41ms channel.Aftertouch channel 1 pressure 39
+41ms channel.Aftertouch channel 1 pressure 0
+41ms channel.NoteOff channel 1 key 60
+`
+
+	if got, want := sink.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
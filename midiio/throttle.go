@@ -0,0 +1,318 @@
+package midiio
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/realtime"
+)
+
+// MessageClass is the class of a message that determines its priority and policy within a Throttle
+type MessageClass int
+
+// message classes in the order of their priority
+const (
+	// ClassRealtime are system realtime messages, e.g. the timing clock
+	ClassRealtime MessageClass = iota
+
+	// ClassNoteOff are the messages that end notes: note off, sustain off and channel mode messages (CC 120 - 127)
+	ClassNoteOff
+
+	// ClassNoteOn are note on messages
+	ClassNoteOn
+
+	// ClassController are control change messages
+	ClassController
+
+	// ClassStream are the continuous streams of pitch bend, aftertouch and polyphonic aftertouch messages
+	ClassStream
+
+	// ClassOther are all other messages, e.g. program change and sysex
+	ClassOther
+
+	numClasses
+)
+
+// Classify returns the class of the given message
+func Classify(msg midi.Message) MessageClass {
+	switch v := msg.(type) {
+	case realtime.Message:
+		return ClassRealtime
+	case channel.NoteOn:
+		if v.Velocity() == 0 {
+			return ClassNoteOff
+		}
+		return ClassNoteOn
+	case channel.NoteOff, channel.NoteOffVelocity:
+		return ClassNoteOff
+	case channel.ControlChange:
+		if v.Controller() >= 120 || (v.Controller() == 64 && v.Value() < 64) {
+			return ClassNoteOff
+		}
+		return ClassController
+	case channel.Pitchbend, channel.Aftertouch, channel.PolyAftertouch:
+		return ClassStream
+	default:
+		return ClassOther
+	}
+}
+
+// ThrottlePolicy determines what happens to a message, if it can't be written immediately
+type ThrottlePolicy int
+
+const (
+	// Delay queues the message until it can be written
+	Delay ThrottlePolicy = iota
+
+	// Drop drops the message
+	Drop
+)
+
+// ThrottleStats are the counters of a Throttle per MessageClass
+type ThrottleStats struct {
+	Written [numClasses]uint64
+	Delayed [numClasses]uint64
+	Dropped [numClasses]uint64
+}
+
+// ThrottleOption is an option for the Throttle
+type ThrottleOption func(*Throttle)
+
+// ThrottleClass sets the policy for the given message class. By default, the continuous streams (ClassStream) are
+// dropped and all other messages are delayed, since they change the state of the receiver.
+func ThrottleClass(class MessageClass, policy ThrottlePolicy) ThrottleOption {
+	return func(t *Throttle) {
+		if class >= 0 && class < numClasses {
+			t.policies[class] = policy
+		}
+	}
+}
+
+// Throttle is a midi.Writer that limits the rate of the bytes written to another midi.Writer,
+// e.g. for cheap USB-DIN interfaces that drop bytes when they are flooded.
+//
+// The rate is limited by a token bucket: Each byte of a message (without running status) consumes a token, the bucket
+// holds up to burst tokens and is refilled with bytesPerSecond tokens per second. A message that is larger than the
+// bucket is written when the bucket is full.
+//
+// A message that can't be written immediately is dropped or delayed, depending on the policy of its class
+// (see ThrottleClass). Delayed messages are queued by priority (see MessageClass) and written by later calls of
+// Write, Pump or Flush. Apart from realtime messages and the messages that end notes, a message never overtakes a
+// delayed message of the same channel or a delayed system exclusive message, so that e.g. notes are not played
+// before the program change that precedes them. Pump should be called regularly (e.g. every millisecond) while
+// messages are pending.
+type Throttle struct {
+	mx       sync.Mutex
+	out      midi.Writer
+	clock    Clock
+	rate     float64
+	burst    float64
+	tokens   float64
+	last     time.Time
+	policies [numClasses]ThrottlePolicy
+	queue    []midi.Message
+	stats    ThrottleStats
+}
+
+// NewThrottle returns a Throttle that writes to out. If clock is nil, SystemClock is used.
+// If bytesPerSecond is not positive, the rate is not limited.
+func NewThrottle(out midi.Writer, bytesPerSecond, burst int, clock Clock, options ...ThrottleOption) *Throttle {
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	t := &Throttle{
+		out:    out,
+		clock:  clock,
+		rate:   float64(bytesPerSecond),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+
+	t.policies[ClassStream] = Drop
+
+	for _, opt := range options {
+		opt(t)
+	}
+
+	return t
+}
+
+// Write writes the given message, if the rate allows it. Otherwise the message is delayed or dropped (see Throttle).
+func (t *Throttle) Write(msg midi.Message) error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if err := t.pump(); err != nil {
+		return err
+	}
+
+	class := Classify(msg)
+
+	if len(t.queue) == 0 && t.fits(msg) {
+		return t.write(msg)
+	}
+
+	if t.policies[class] == Drop {
+		t.stats.Dropped[class]++
+		return nil
+	}
+
+	t.stats.Delayed[class]++
+
+	// insert after the messages of the same or higher priority and the messages that it must not overtake
+	i := len(t.queue)
+	for i > 0 && Classify(t.queue[i-1]) > class && !blocks(t.queue[i-1], msg, class) {
+		i--
+	}
+
+	t.queue = append(t.queue[:i], append([]midi.Message{msg}, t.queue[i:]...)...)
+	return nil
+}
+
+// blocks returns true, if the given message of the given class must not overtake the queued message:
+// a note message of the same channel and key, so that a note off can't overtake its note on, and unless the message
+// is a realtime message or ends notes, a message of the same channel or a message without channel.
+func blocks(queued, msg midi.Message, class MessageClass) bool {
+	if qk, qIsNote := noteKey(queued); qIsNote {
+		if k, isNote := noteKey(msg); isNote && qk == k {
+			return true
+		}
+	}
+
+	if class == ClassRealtime || class == ClassNoteOff {
+		return false
+	}
+
+	qcm, qIsChannel := queued.(channel.Message)
+
+	if !qIsChannel {
+		return true
+	}
+
+	cm, isChannel := msg.(channel.Message)
+	return !isChannel || cm.Channel() == qcm.Channel()
+}
+
+// noteKey returns the channel and key of a note message
+func noteKey(msg midi.Message) (k [2]uint8, isNote bool) {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		return [2]uint8{v.Channel(), v.Key()}, true
+	case channel.NoteOff:
+		return [2]uint8{v.Channel(), v.Key()}, true
+	case channel.NoteOffVelocity:
+		return [2]uint8{v.Channel(), v.Key()}, true
+	}
+	return k, false
+}
+
+// Pump writes the delayed messages that the rate allows now.
+func (t *Throttle) Pump() error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return t.pump()
+}
+
+// Flush waits until all delayed messages have been written.
+func (t *Throttle) Flush() error {
+	for {
+		t.mx.Lock()
+		err := t.pump()
+		wait := t.wait()
+		t.mx.Unlock()
+
+		if err != nil || wait == 0 {
+			return err
+		}
+
+		t.clock.Sleep(wait)
+	}
+}
+
+// Wait returns the duration until the next delayed message can be written, or 0 if there are none.
+func (t *Throttle) Wait() time.Duration {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.refill()
+	return t.wait()
+}
+
+// Pending returns the number of delayed messages
+func (t *Throttle) Pending() int {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return len(t.queue)
+}
+
+// Stats returns the counters
+func (t *Throttle) Stats() ThrottleStats {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return t.stats
+}
+
+// refill adds the tokens for the time since the last refill
+func (t *Throttle) refill() {
+	now := t.clock.Now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	t.last = now
+
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+}
+
+// fits returns true, if the message can be written now
+func (t *Throttle) fits(msg midi.Message) bool {
+	if t.rate <= 0 {
+		return true
+	}
+
+	t.refill()
+	n := float64(midi.EncodedLen(msg))
+	return t.tokens >= n || t.tokens >= t.burst
+}
+
+func (t *Throttle) write(msg midi.Message) error {
+	t.tokens -= float64(midi.EncodedLen(msg))
+	t.stats.Written[Classify(msg)]++
+	return t.out.Write(msg)
+}
+
+func (t *Throttle) pump() error {
+	for len(t.queue) > 0 && t.fits(t.queue[0]) {
+		msg := t.queue[0]
+		t.queue = t.queue[1:]
+
+		if err := t.write(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wait returns the duration until the first message of the queue fits
+func (t *Throttle) wait() time.Duration {
+	if len(t.queue) == 0 {
+		return 0
+	}
+
+	need := float64(midi.EncodedLen(t.queue[0]))
+	if need > t.burst {
+		need = t.burst
+	}
+
+	if t.tokens >= need {
+		return time.Nanosecond
+	}
+
+	d := time.Duration((need - t.tokens) / t.rate * float64(time.Second))
+	if d <= 0 {
+		d = time.Nanosecond
+	}
+	return d
+}
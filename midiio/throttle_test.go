@@ -0,0 +1,194 @@
+package midiio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+func TestThrottle(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+	ch := channel.Channel0
+
+	th := NewThrottle(sink, 1000, 6, clock)
+
+	th.Write(ch.NoteOn(60, 100))
+	th.Write(ch.ControlChange(1, 10))
+	// the bucket is empty
+	th.Write(ch.ControlChange(1, 11))
+	th.Write(ch.Pitchbend(100))
+	th.Write(ch.NoteOn(62, 100))
+	th.Write(ch.NoteOff(60))
+	th.Write(realtime.TimingClock)
+
+	if got, want := th.Pending(), 4; got != want {
+		t.Errorf("Pending() = %v; want %v", got, want)
+	}
+
+	if err := th.Flush(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0s channel.NoteOn channel 1 key 60 velocity 100
+0s channel.ControlChange channel 1 controller 1 ("Modulation Wheel (MSB)") value 10
+1ms TimingClock
+4ms channel.NoteOff channel 1 key 60
+7ms channel.ControlChange channel 1 controller 1 ("Modulation Wheel (MSB)") value 11
+10ms channel.NoteOn channel 1 key 62 velocity 100
+`
+
+	if got, want := sink.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	stats := th.Stats()
+
+	if got, want := stats.Dropped[ClassStream], uint64(1); got != want {
+		t.Errorf("Dropped[ClassStream] = %v; want %v", got, want)
+	}
+
+	if got, want := stats.Delayed, [numClasses]uint64{1, 1, 1, 1, 0, 0}; got != want {
+		t.Errorf("Delayed = %v; want %v", got, want)
+	}
+
+	if got, want := stats.Written, [numClasses]uint64{1, 1, 2, 2, 0, 0}; got != want {
+		t.Errorf("Written = %v; want %v", got, want)
+	}
+}
+
+func TestThrottleClass(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+	ch := channel.Channel0
+
+	th := NewThrottle(sink, 1000, 3, clock, ThrottleClass(ClassController, Delay), ThrottleClass(ClassNoteOn, Drop))
+
+	th.Write(ch.ControlChange(1, 10))
+	th.Write(ch.ControlChange(1, 11))
+	th.Write(ch.NoteOn(60, 100))
+
+	// sustain off and all notes off end notes
+	th.Write(ch.ControlChange(64, 0))
+	th.Write(ch.ControlChange(123, 0))
+
+	clock.Sleep(3 * time.Millisecond)
+	th.Pump()
+
+	expected := `0s channel.ControlChange channel 1 controller 1 ("Modulation Wheel (MSB)") value 10
+3ms channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 0 (off)
+`
+
+	if got, want := sink.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if got, want := th.Wait(), 3*time.Millisecond; got != want {
+		t.Errorf("Wait() = %v; want %v", got, want)
+	}
+
+	if got, want := th.Stats().Dropped[ClassNoteOn], uint64(1); got != want {
+		t.Errorf("Dropped[ClassNoteOn] = %v; want %v", got, want)
+	}
+}
+
+func TestThrottleNoteOrder(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+	ch := channel.Channel0
+
+	th := NewThrottle(sink, 1000, 3, clock)
+
+	th.Write(ch.NoteOn(61, 100))
+	// the bucket is empty
+	th.Write(ch.NoteOn(62, 100))
+	th.Write(ch.NoteOn(60, 100))
+	// must not overtake the note on of the same key
+	th.Write(ch.NoteOff(60))
+
+	if err := th.Flush(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0s channel.NoteOn channel 1 key 61 velocity 100
+3ms channel.NoteOn channel 1 key 62 velocity 100
+6ms channel.NoteOn channel 1 key 60 velocity 100
+9ms channel.NoteOff channel 1 key 60
+`
+
+	if got, want := sink.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestThrottleChannelOrder(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+
+	th := NewThrottle(sink, 1000, 3, clock)
+
+	th.Write(channel.Channel0.NoteOn(61, 100))
+	// the bucket is empty
+	th.Write(channel.Channel0.ProgramChange(5))
+	// may overtake the program change of another channel
+	th.Write(channel.Channel1.NoteOn(60, 100))
+	// must not overtake the program change of the same channel
+	th.Write(channel.Channel0.NoteOn(60, 100))
+
+	if err := th.Flush(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0s channel.NoteOn channel 1 key 61 velocity 100
+3ms channel.NoteOn channel 2 key 60 velocity 100
+5ms channel.ProgramChange channel 1 program 5
+8ms channel.NoteOn channel 1 key 60 velocity 100
+`
+
+	if got, want := sink.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestPlayerThrottle(t *testing.T) {
+	var tr smftrack.Track
+	tr.Add(0, meta.BPM(120))
+
+	for i := 0; i < 4; i++ {
+		tr.Add(0, channel.Channel0.NoteOn(uint8(60+i), 100))
+	}
+
+	tr.Add(96, channel.Channel0.NoteOff(60))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	sink := &timedSink{clock: clock, start: clock.now}
+
+	p, err := NewPlayer(s, sink, UseClock(clock), BusyWait(0), ThrottleOutput(3000, 3))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if err := p.Play(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0s channel.NoteOn channel 1 key 60 velocity 100
+1ms channel.NoteOn channel 1 key 61 velocity 100
+2ms channel.NoteOn channel 1 key 62 velocity 100
+3ms channel.NoteOn channel 1 key 63 velocity 100
+500ms channel.NoteOff channel 1 key 60
+`
+
+	if got, want := sink.bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
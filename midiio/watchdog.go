@@ -0,0 +1,245 @@
+package midiio
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// WatchdogStats are the counters of a Watchdog
+type WatchdogStats struct {
+	// NoteOns is the number of tracked note on messages
+	NoteOns uint64
+
+	// NoteOffs is the number of tracked note off messages that ended an active note
+	NoteOffs uint64
+
+	// Unmatched is the number of tracked note off messages without an active note, e.g. the late
+	// note off of a note that was already ended by the Watchdog
+	Unmatched uint64
+
+	// Stuck is the number of notes that were ended by the Watchdog
+	Stuck uint64
+
+	// Active is the number of currently active notes
+	Active int
+}
+
+// Watchdog detects stuck notes in a live stream, e.g. due to note off messages that were lost over a flaky cable.
+// A note is considered stuck, if it is held for the maximal duration. Stuck notes are ended by synthetic
+// note off messages that are passed to a callback (typically writing them to the output).
+//
+// Like the channel.NoteTracker, multiple note on messages for the same key are counted. Each note off message ends
+// the longest held note of the key. The maximal duration has to be chosen longer than the longest legitimate note
+// (e.g. a pad).
+//
+// The Watchdog has a timer for the earliest deadline of the active notes, so that stuck notes are ended in time,
+// even if nothing is received anymore. The callback is called from the goroutine of the timer.
+type Watchdog struct {
+	mx        sync.Mutex
+	max       time.Duration
+	onStuck   func(channel.NoteOff)
+	clock     TimerClock
+	started   [16][128][]time.Time
+	stats     WatchdogStats
+	stopTimer func() bool
+	stopped   bool
+}
+
+// NewWatchdog returns a Watchdog that passes a note off message for each note that is held for maxDuration
+// to onStuck. If clock is nil, SystemClock is used.
+func NewWatchdog(maxDuration time.Duration, onStuck func(channel.NoteOff), clock TimerClock) *Watchdog {
+	if clock == nil {
+		clock = SystemClock.(TimerClock)
+	}
+
+	return &Watchdog{
+		max:     maxDuration,
+		onStuck: onStuck,
+		clock:   clock,
+	}
+}
+
+// Track passes the given message to the Watchdog. Note on messages start the timer of the note,
+// note off messages (and note on messages with velocity 0) stop it. Other messages are ignored.
+func (w *Watchdog) Track(msg channel.Message) {
+	ch, key, on := msg.Channel(), uint8(0), false
+
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		key, on = v.Key(), v.Velocity() > 0
+	case channel.NoteOff:
+		key = v.Key()
+	case channel.NoteOffVelocity:
+		key = v.Key()
+	default:
+		return
+	}
+
+	if ch > 15 || key > 127 {
+		return
+	}
+
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	started := w.started[ch][key]
+
+	if on {
+		w.started[ch][key] = append(started, w.clock.Now())
+		w.stats.NoteOns++
+		w.stats.Active++
+
+		// the deadline of the new note is not earlier than the deadline of the timer
+		if w.stopTimer == nil {
+			w.arm()
+		}
+		return
+	}
+
+	if len(started) == 0 {
+		w.stats.Unmatched++
+		return
+	}
+
+	w.started[ch][key] = started[1:]
+	w.stats.NoteOffs++
+	w.stats.Active--
+	w.disarm()
+	w.arm()
+}
+
+// Check ends the notes that are held for the maximal duration and passes their note off messages
+// to the callback. The note off messages are also returned. The timer of the Watchdog calls it at the deadline
+// of the earliest note, so it only needs to be called to check at other times.
+func (w *Watchdog) Check() (offs []channel.NoteOff) {
+	w.mx.Lock()
+
+	now := w.clock.Now()
+
+	for ch := range w.started {
+		for key, started := range w.started[ch] {
+			var n int
+
+			for n < len(started) && !now.Before(started[n].Add(w.max)) {
+				offs = append(offs, channel.Channel(ch).NoteOff(uint8(key)))
+				n++
+			}
+
+			if n > 0 {
+				w.started[ch][key] = started[n:]
+				w.stats.Stuck += uint64(n)
+				w.stats.Active -= n
+			}
+		}
+	}
+
+	w.disarm()
+	w.arm()
+	w.mx.Unlock()
+
+	// the callback is called without holding the lock, so that it may track messages
+	if w.onStuck != nil {
+		for _, off := range offs {
+			w.onStuck(off)
+		}
+	}
+
+	return
+}
+
+// arm starts the timer for the earliest deadline of the active notes, if there are any
+func (w *Watchdog) arm() {
+	if w.stopped || w.stats.Active == 0 {
+		return
+	}
+
+	var earliest time.Time
+	var has bool
+
+	for ch := range w.started {
+		for _, started := range w.started[ch] {
+			if len(started) > 0 && (!has || started[0].Before(earliest)) {
+				earliest, has = started[0], true
+			}
+		}
+	}
+
+	if !has {
+		return
+	}
+
+	d := earliest.Add(w.max).Sub(w.clock.Now())
+
+	if d < 0 {
+		d = 0
+	}
+
+	w.stopTimer = w.clock.AfterFunc(d, w.fire)
+}
+
+// fire is called by the timer
+func (w *Watchdog) fire() {
+	w.mx.Lock()
+	stopped := w.stopped
+	w.mx.Unlock()
+
+	if !stopped {
+		w.Check()
+	}
+}
+
+// disarm stops the timer
+func (w *Watchdog) disarm() {
+	if w.stopTimer != nil {
+		w.stopTimer()
+		w.stopTimer = nil
+	}
+}
+
+// Stats returns the current counters
+func (w *Watchdog) Stats() WatchdogStats {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	return w.stats
+}
+
+// Reset stops the timers of all notes (e.g. after a panic). The counters are kept.
+func (w *Watchdog) Reset() {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	w.started = [16][128][]time.Time{}
+	w.stats.Active = 0
+	w.disarm()
+}
+
+// Stop stops the timer of the Watchdog for good, so that the callback is not called anymore.
+func (w *Watchdog) Stop() {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	w.stopped = true
+	w.disarm()
+}
+
+// Reader returns a midi.Reader that passes the channel messages read from rd to the Watchdog.
+func (w *Watchdog) Reader(rd midi.Reader) midi.Reader {
+	return &watchdogReader{rd, w}
+}
+
+type watchdogReader struct {
+	midi.Reader
+	watchdog *Watchdog
+}
+
+// Read reads the next message and passes it to the Watchdog
+func (r *watchdogReader) Read() (midi.Message, error) {
+	msg, err := r.Reader.Read()
+
+	if m, ok := msg.(channel.Message); ok && err == nil {
+		r.watchdog.Track(m)
+	}
+
+	return msg, err
+}
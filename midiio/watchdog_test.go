@@ -0,0 +1,197 @@
+package midiio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// timerClock is a TimerClock that only advances by advance, which fires the timers that are due
+type timerClock struct {
+	mx     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func (c *timerClock) Now() time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.now
+}
+
+func (c *timerClock) Sleep(d time.Duration) { c.advance(d) }
+
+func (c *timerClock) AfterFunc(d time.Duration, f func()) (stop func() bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	t := &fakeTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+
+	return func() bool {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+		stopped := t.stopped
+		t.stopped = true
+		return !stopped
+	}
+}
+
+// pending returns the number of timers that have neither fired nor been stopped
+func (c *timerClock) pending() (n int) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	for _, t := range c.timers {
+		if !t.stopped {
+			n++
+		}
+	}
+
+	return
+}
+
+func (c *timerClock) advance(d time.Duration) {
+	c.mx.Lock()
+	c.now = c.now.Add(d)
+	c.mx.Unlock()
+
+	for {
+		var due *fakeTimer
+
+		c.mx.Lock()
+		for _, t := range c.timers {
+			if !t.stopped && !t.at.After(c.now) {
+				due = t
+				break
+			}
+		}
+		if due != nil {
+			due.stopped = true
+		}
+		c.mx.Unlock()
+
+		if due == nil {
+			return
+		}
+
+		due.f()
+	}
+}
+
+func offsString(offs []channel.NoteOff) string {
+	var bf strings.Builder
+
+	for _, off := range offs {
+		fmt.Fprintf(&bf, "% X\n", off.Raw())
+	}
+
+	return bf.String()
+}
+
+func TestWatchdog(t *testing.T) {
+	clock := &timerClock{now: time.Unix(0, 0)}
+
+	var stuck []channel.NoteOff
+	w := NewWatchdog(10*time.Second, func(off channel.NoteOff) { stuck = append(stuck, off) }, clock)
+
+	w.Track(channel.Channel1.NoteOn(60, 100))
+	w.Track(channel.Channel1.NoteOn(64, 100))
+	clock.advance(5 * time.Second)
+	w.Track(channel.Channel1.NoteOn(60, 100))
+	w.Track(channel.Channel1.NoteOff(64))
+	w.Track(channel.Channel1.ControlChange(7, 100))
+
+	// the maximal duration is not reached
+	clock.advance(4 * time.Second)
+
+	if len(stuck) != 0 {
+		t.Errorf("stuck = %v; want none", stuck)
+	}
+
+	// the timer ends the first note on key 60 without any further message, the second one is not stuck yet
+	clock.advance(time.Second)
+
+	if got, want := offsString(stuck), "91 3C 00\n"; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	// the second note is ended properly, the late note off is unmatched
+	w.Track(channel.Channel1.NoteOn(60, 0))
+	w.Track(channel.Channel1.NoteOffVelocity(60, 20))
+
+	// no timer is left, when no note is active
+	if got := clock.pending(); got != 0 {
+		t.Errorf("%v timers pending; want none", got)
+	}
+
+	clock.advance(time.Hour)
+
+	if got, want := len(stuck), 1; got != want {
+		t.Errorf("%v stuck notes; want %v", got, want)
+	}
+
+	expected := WatchdogStats{NoteOns: 3, NoteOffs: 2, Unmatched: 1, Stuck: 1, Active: 0}
+
+	if got, want := w.Stats(), expected; got != want {
+		t.Errorf("Stats() = %+v; want %+v", got, want)
+	}
+
+	// after Stop, the timer does not fire anymore
+	w.Track(channel.Channel1.NoteOn(62, 100))
+	w.Stop()
+	clock.advance(time.Hour)
+
+	if got, want := len(stuck), 1; got != want {
+		t.Errorf("%v stuck notes after Stop; want %v", got, want)
+	}
+}
+
+type messages []midi.Message
+
+func (m *messages) Read() (midi.Message, error) {
+	if len(*m) == 0 {
+		return nil, io.EOF
+	}
+	msg := (*m)[0]
+	*m = (*m)[1:]
+	return msg, nil
+}
+
+func TestWatchdogReader(t *testing.T) {
+	clock := &timerClock{now: time.Unix(0, 0)}
+
+	var stuck []channel.NoteOff
+	w := NewWatchdog(time.Second, func(off channel.NoteOff) { stuck = append(stuck, off) }, clock)
+
+	rd := w.Reader(&messages{channel.Channel0.NoteOn(60, 100), channel.Channel0.NoteOn(62, 100), channel.Channel0.NoteOff(62)})
+
+	rd.Read()
+	clock.advance(2 * time.Second)
+	rd.Read()
+	rd.Read()
+
+	if got, want := offsString(stuck), "90 3C 00\n"; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if _, err := rd.Read(); err != io.EOF {
+		t.Errorf("Read() error = %v; want %v", err, io.EOF)
+	}
+
+	if got, want := w.Stats().Active, 0; got != want {
+		t.Errorf("Stats().Active = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package midimessage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/status"
+	"github.com/gomidi/midi/midimessage/syscommon"
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+// UnmarshalMessage parses the raw bytes of a single MIDI message, as returned by its Raw method, i.e. it is the
+// inverse of Raw. The message type is dispatched by the status byte, meta messages by the type following 0xFF.
+// A single 0xFF is the realtime reset message.
+//
+// See channel.Unmarshal and sysex.Unmarshal for the messages that share their raw bytes with other messages.
+// Like the meta.Reader, a meta message with a value that is out of range is returned with the clamped value
+// together with a *meta.InvalidValueError.
+func UnmarshalMessage(data []byte) (midi.Message, error) {
+	if len(data) == 0 {
+		return nil, errors.New("can't unmarshal MIDI message: no data")
+	}
+
+	var msg midi.Message
+	var err error
+
+	switch b := data[0]; {
+	case !status.IsStatus(b):
+		err = fmt.Errorf("can't unmarshal MIDI message % X: missing status byte", data)
+	case status.IsChannel(b):
+		msg, err = channel.Unmarshal(data)
+	case b == 0xFF && len(data) > 1:
+		msg, err = meta.Unmarshal(data)
+	case status.IsRealtime(b):
+		msg, err = realtime.Unmarshal(data)
+	case b == 0xF0 || b == 0xF7:
+		msg, err = sysex.Unmarshal(data)
+	default:
+		msg, err = syscommon.Unmarshal(data)
+	}
+
+	return msg, err
+}
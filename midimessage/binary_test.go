@@ -0,0 +1,143 @@
+package midimessage
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for _, msg := range encodedLenCorpus() {
+		m, ok := msg.(encoding.BinaryMarshaler)
+
+		if !ok {
+			t.Errorf("%T is no encoding.BinaryMarshaler", msg)
+			continue
+		}
+
+		raw, err := m.MarshalBinary()
+
+		if err != nil || !bytes.Equal(raw, msg.Raw()) {
+			t.Errorf("MarshalBinary() of %v = % X, %v; want % X, nil", msg, raw, err, msg.Raw())
+			continue
+		}
+
+		// the type specific unmarshaler
+		ptr := reflect.New(reflect.TypeOf(msg))
+		u, ok := ptr.Interface().(encoding.BinaryUnmarshaler)
+
+		if !ok {
+			t.Errorf("*%T is no encoding.BinaryUnmarshaler", msg)
+			continue
+		}
+
+		err = u.UnmarshalBinary(raw)
+		var invalid *meta.InvalidValueError
+
+		if err != nil && !errors.As(err, &invalid) {
+			t.Errorf("UnmarshalBinary(% X) into %T returned error: %v", raw, msg, err)
+			continue
+		}
+
+		// constructors may leave fields unset that are derived when parsing, so the raw bytes are compared
+		if got := ptr.Elem().Interface().(interface{ Raw() []byte }); !bytes.Equal(got.Raw(), raw) {
+			t.Errorf("UnmarshalBinary(% X) = %v (% X); want %v", raw, got, got.Raw(), msg)
+		}
+
+		// the generic unmarshaler
+		got, err := UnmarshalMessage(raw)
+
+		if err != nil && !errors.As(err, &invalid) {
+			t.Errorf("UnmarshalMessage(% X) returned error: %v", raw, err)
+			continue
+		}
+
+		if !bytes.Equal(got.Raw(), raw) {
+			t.Errorf("UnmarshalMessage(% X) = %v (% X); want %v", raw, got, got.Raw(), msg)
+		}
+	}
+}
+
+func TestUnmarshalMessage(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected interface{}
+	}{
+		{[]byte{0x92, 0x3C, 0x40}, channel.Channel2.NoteOn(60, 64)},
+		{[]byte{0x92, 0x3C, 0x00}, channel.Channel2.NoteOff(60)},
+		{[]byte{0x82, 0x3C, 0x00}, channel.Channel2.NoteOffVelocity(60, 0)},
+		{[]byte{0xFF}, nil},
+		{[]byte{0xFF, 0x2F, 0x00}, meta.EndOfTrack},
+		{[]byte{0xF0, 0x01, 0x02, 0xF7}, sysex.SysEx{0x01, 0x02}},
+		{[]byte{0xF0, 0x01, 0x02}, sysex.Start{0x01, 0x02}},
+		{[]byte{0xF7, 0x01, 0x02, 0xF7}, sysex.End{0x01, 0x02}},
+		{[]byte{0xF7, 0x01, 0x02}, sysex.Continue{0x01, 0x02}},
+	}
+
+	for i, test := range tests {
+		got, err := UnmarshalMessage(test.input)
+
+		if err != nil {
+			t.Errorf("[%v] UnmarshalMessage(% X) returned error: %v", i, test.input, err)
+			continue
+		}
+
+		if test.expected == nil {
+			test.expected = got
+		}
+
+		if !reflect.DeepEqual(got, test.expected) || !bytes.Equal(got.Raw(), test.input) {
+			t.Errorf("[%v] UnmarshalMessage(% X) = %#v; want %#v", i, test.input, got, test.expected)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	invalid := [][]byte{
+		nil,
+		{0x3C, 0x40},
+		{0x92, 0x3C},
+		{0x92, 0x3C, 0x40, 0x00},
+		{0x92, 0x3C, 0x80},
+		{0xC2},
+		{0xFF, 0x51, 0x03, 0x07},
+		{0xFF, 0x2F, 0x00, 0x00},
+		{0xF2, 0x01},
+		{0xF4},
+		{0xF8, 0xF8},
+	}
+
+	for _, input := range invalid {
+		if msg, err := UnmarshalMessage(input); err == nil || msg != nil {
+			t.Errorf("UnmarshalMessage(% X) = %v, %v; want error", input, msg, err)
+		}
+	}
+
+	// the type specific unmarshalers reject other types
+	var on channel.NoteOn
+	var off channel.NoteOff
+	var text meta.Text
+	var sx sysex.SysEx
+
+	for _, err := range []error{
+		on.UnmarshalBinary([]byte{0x82, 0x3C, 0x40}),
+		off.UnmarshalBinary([]byte{0x92, 0x3C, 0x40}),
+		text.UnmarshalBinary(meta.Marker("a").Raw()),
+		sx.UnmarshalBinary([]byte{0xF0, 0x01}),
+	} {
+		if err == nil {
+			t.Errorf("UnmarshalBinary() of other type returned no error")
+		}
+	}
+
+	// note off messages of type 8 are accepted by NoteOff
+	if err := off.UnmarshalBinary([]byte{0x82, 0x3C, 0x40}); err != nil || off != channel.Channel2.NoteOff(60) {
+		t.Errorf("NoteOff.UnmarshalBinary() = %v, %v; want %v", off, err, channel.Channel2.NoteOff(60))
+	}
+}
@@ -17,19 +17,34 @@ func (a Aftertouch) Pressure() uint8 {
 	return a.pressure
 }
 
-// Channel returns the channel of the aftertouch message.
+// Channel returns the channel of the aftertouch message on the wire (0-15)
 func (a Aftertouch) Channel() uint8 {
 	return a.channel
 }
 
+// DisplayChannel returns the channel of the aftertouch message as it is displayed to users (1-16)
+func (a Aftertouch) DisplayChannel() uint8 {
+	return a.channel + 1
+}
+
 // Raw returns the raw bytes of the aftertouch message.
 func (a Aftertouch) Raw() []byte {
 	return channelMessage1(a.channel, 13, a.pressure)
 }
 
+// EncodedLen returns the number of bytes of the message including the status byte (see Raw), without encoding it
+func (a Aftertouch) EncodedLen() int {
+	return 2
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the channel is beyond 15 or a data byte is beyond 127
+func (a Aftertouch) Validate() error {
+	return validate(a, a.channel, a.pressure)
+}
+
 // String returns human readable information about the aftertouch message.
 func (a Aftertouch) String() string {
-	return fmt.Sprintf("%T channel %v pressure %v", a, a.Channel(), a.Pressure())
+	return fmt.Sprintf("%T channel %v pressure %v", a, a.DisplayChannel(), a.Pressure())
 }
 
 // set returns a new aftertouch message that is set to the parsed arguments
@@ -0,0 +1,214 @@
+package channel
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi/midimessage/status"
+)
+
+// Unmarshal parses the raw bytes of a single channel message (status byte and data bytes, as returned by Raw).
+// A note on message with velocity of 0 is returned as NoteOff and a note off message (type 8) is returned as
+// NoteOffVelocity, so that the raw bytes of the returned message are the given bytes.
+func Unmarshal(data []byte) (Message, error) {
+	if len(data) == 0 || !status.IsChannel(data[0]) {
+		return nil, fmt.Errorf("invalid channel message % X: missing status byte", data)
+	}
+
+	typ, _ := status.Split(data[0])
+
+	switch typ {
+	case byteProgramChange:
+		return unmarshal1(data, typ, ProgramChange{})
+	case byteChannelPressure:
+		return unmarshal1(data, typ, Aftertouch{})
+	case byteNoteOff:
+		return unmarshal2(data, typ, NoteOffVelocity{})
+	case byteNoteOn:
+		msg, err := unmarshal2(data, typ, NoteOn{})
+		if on, is := msg.(NoteOn); is && on.velocity == 0 {
+			msg = NoteOff{channel: on.channel, key: on.key}
+		}
+		return msg, err
+	case bytePolyphonicKeyPressure:
+		return unmarshal2(data, typ, PolyAftertouch{})
+	case byteControlChange:
+		return unmarshal2(data, typ, ControlChange{})
+	default:
+		return unmarshal2(data, typ, Pitchbend{})
+	}
+}
+
+// parse checks the given raw bytes of a channel message of the given type and returns the channel and
+// the data bytes
+func parse(data []byte, typ uint8, dataLen int) (channel uint8, args [2]uint8, err error) {
+	if len(data) == 0 || !status.IsChannel(data[0]) {
+		return 0, args, fmt.Errorf("invalid channel message % X: missing status byte", data)
+	}
+
+	t, channel := status.Split(data[0])
+
+	if t != typ {
+		return 0, args, fmt.Errorf("invalid channel message % X: unexpected type %X, expected %X", data, t, typ)
+	}
+
+	if len(data) != dataLen+1 {
+		return 0, args, fmt.Errorf("invalid channel message % X: expected %v data bytes", data, dataLen)
+	}
+
+	for i, b := range data[1:] {
+		if status.IsStatus(b) {
+			return 0, args, fmt.Errorf("invalid channel message % X: % X is no data byte", data, b)
+		}
+		args[i] = b
+	}
+
+	return channel, args, nil
+}
+
+func unmarshal1(data []byte, typ uint8, m setter1) (setter1, error) {
+	channel, args, err := parse(data, typ, 1)
+	if err != nil {
+		return nil, err
+	}
+	return m.set(channel, args[0]), nil
+}
+
+func unmarshal2(data []byte, typ uint8, m setter2) (setter2, error) {
+	channel, args, err := parse(data, typ, 2)
+	if err != nil {
+		return nil, err
+	}
+	return m.set(channel, args[0], args[1]), nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (n NoteOn) MarshalBinary() ([]byte, error) {
+	return n.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (n *NoteOn) UnmarshalBinary(data []byte) error {
+	msg, err := unmarshal2(data, byteNoteOn, NoteOn{})
+	if err != nil {
+		return err
+	}
+	*n = msg.(NoteOn)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (n NoteOff) MarshalBinary() ([]byte, error) {
+	return n.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes, that may be a note on message with velocity of 0
+// or a note off message. It implements encoding.BinaryUnmarshaler.
+func (n *NoteOff) UnmarshalBinary(data []byte) error {
+	typ := uint8(byteNoteOn)
+
+	if len(data) > 0 && data[0]>>4 == byteNoteOff {
+		typ = byteNoteOff
+	}
+
+	msg, err := unmarshal2(data, typ, NoteOff{})
+	if err != nil {
+		return err
+	}
+
+	if typ == byteNoteOn && data[2] != 0 {
+		return fmt.Errorf("invalid note off message % X: velocity must be 0", data)
+	}
+
+	*n = msg.(NoteOff)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (n NoteOffVelocity) MarshalBinary() ([]byte, error) {
+	return n.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (n *NoteOffVelocity) UnmarshalBinary(data []byte) error {
+	msg, err := unmarshal2(data, byteNoteOff, NoteOffVelocity{})
+	if err != nil {
+		return err
+	}
+	*n = msg.(NoteOffVelocity)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (p PolyAftertouch) MarshalBinary() ([]byte, error) {
+	return p.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (p *PolyAftertouch) UnmarshalBinary(data []byte) error {
+	msg, err := unmarshal2(data, bytePolyphonicKeyPressure, PolyAftertouch{})
+	if err != nil {
+		return err
+	}
+	*p = msg.(PolyAftertouch)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (c ControlChange) MarshalBinary() ([]byte, error) {
+	return c.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (c *ControlChange) UnmarshalBinary(data []byte) error {
+	msg, err := unmarshal2(data, byteControlChange, ControlChange{})
+	if err != nil {
+		return err
+	}
+	*c = msg.(ControlChange)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (p ProgramChange) MarshalBinary() ([]byte, error) {
+	return p.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (p *ProgramChange) UnmarshalBinary(data []byte) error {
+	msg, err := unmarshal1(data, byteProgramChange, ProgramChange{})
+	if err != nil {
+		return err
+	}
+	*p = msg.(ProgramChange)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (a Aftertouch) MarshalBinary() ([]byte, error) {
+	return a.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (a *Aftertouch) UnmarshalBinary(data []byte) error {
+	msg, err := unmarshal1(data, byteChannelPressure, Aftertouch{})
+	if err != nil {
+		return err
+	}
+	*a = msg.(Aftertouch)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (p Pitchbend) MarshalBinary() ([]byte, error) {
+	return p.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (p *Pitchbend) UnmarshalBinary(data []byte) error {
+	msg, err := unmarshal2(data, bytePitchWheel, Pitchbend{})
+	if err != nil {
+		return err
+	}
+	*p = msg.(Pitchbend)
+	return nil
+}
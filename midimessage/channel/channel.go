@@ -89,6 +89,11 @@ func (c Channel) Channel() uint8 {
 	return uint8(c)
 }
 
+// DisplayChannel returns the number of the MIDI channel as it is displayed to users (1-16)
+func (c Channel) DisplayChannel() uint8 {
+	return uint8(c) + 1
+}
+
 // NoteOff creates a note-off message on the channel for the given key
 // The note-off message is "faked" by a note-on message of velocity 0.
 // This allows saving bandwidth by using running status.
@@ -144,6 +149,38 @@ func (c Channel) ControlChange(controller uint8, value uint8) ControlChange {
 	return ControlChange{channel: c.Channel(), controller: controller, value: value}
 }
 
+// SustainOn creates a control change message on the channel that presses the sustain pedal (controller 64)
+func (c Channel) SustainOn() ControlChange {
+	return c.ControlChange(64, 127)
+}
+
+// SustainOff creates a control change message on the channel that releases the sustain pedal (controller 64)
+func (c Channel) SustainOff() ControlChange {
+	return c.ControlChange(64, 0)
+}
+
+// Pan creates a pan message (controller 10) on the channel for the given position between -64 (left)
+// and 63 (right), where 0 is the center. Positions beyond are clamped.
+func (c Channel) Pan(pos int8) ControlChange {
+	return c.ControlChange(10, panValue(pos))
+}
+
+// Balance creates a balance message (controller 8) on the channel for the given position between -64 (left)
+// and 63 (right), where 0 is the center. Positions beyond are clamped.
+func (c Channel) Balance(pos int8) ControlChange {
+	return c.ControlChange(8, panValue(pos))
+}
+
+func panValue(pos int8) uint8 {
+	if pos < -64 {
+		pos = -64
+	}
+	if pos > 63 {
+		pos = 63
+	}
+	return uint8(int(pos) + 64)
+}
+
 // ProgramChange creates a program change message on the channel
 func (c Channel) ProgramChange(program uint8) ProgramChange {
 	if program > 127 {
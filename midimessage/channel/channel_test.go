@@ -14,69 +14,69 @@ func TestMessagesString(t *testing.T) {
 	}{
 		{
 			Channel1.Aftertouch(120),
-			"channel.Aftertouch channel 1 pressure 120",
+			"channel.Aftertouch channel 2 pressure 120",
 		},
 		{
 			Channel8.ControlChange(7, 110),
-			"channel.ControlChange channel 8 controller 7 (\"Volume (MSB)\") value 110",
+			"channel.ControlChange channel 9 controller 7 (\"Volume (MSB)\") value 110",
 		},
 		{
 			Channel2.NoteOn(100, 80),
-			"channel.NoteOn channel 2 key 100 velocity 80",
+			"channel.NoteOn channel 3 key 100 velocity 80",
 		},
 		{
 			Channel3.NoteOff(80),
-			"channel.NoteOff channel 3 key 80",
+			"channel.NoteOff channel 4 key 80",
 		},
 		{
 			Channel4.NoteOffVelocity(80, 20),
-			"channel.NoteOffVelocity channel 4 key 80 velocity 20",
+			"channel.NoteOffVelocity channel 5 key 80 velocity 20",
 		},
 		{
 			Channel4.Pitchbend(300),
-			"channel.Pitchbend channel 4 value 300 absValue 0",
+			"channel.Pitchbend channel 5 value 300 absValue 0",
 		},
 		{
 			Channel4.PolyAftertouch(86, 109),
-			"channel.PolyAftertouch channel 4 key 86 pressure 109",
+			"channel.PolyAftertouch channel 5 key 86 pressure 109",
 		},
 		{
 			Channel4.ProgramChange(83),
-			"channel.ProgramChange channel 4 program 83",
+			"channel.ProgramChange channel 5 program 83",
 		},
 
 		// too high values
 		{
 			Channel1.Aftertouch(130),
-			"channel.Aftertouch channel 1 pressure 127",
+			"channel.Aftertouch channel 2 pressure 127",
 		},
 		{
 			Channel8.ControlChange(137, 130),
-			"channel.ControlChange channel 8 controller 127 (\"Poly Operation\") value 127",
+			"channel.ControlChange channel 9 controller 127 (\"Poly Operation\") value 127",
 		},
 		{
 			Channel2.NoteOn(130, 130),
-			"channel.NoteOn channel 2 key 127 velocity 127",
+			"channel.NoteOn channel 3 key 127 velocity 127",
 		},
 		{
 			Channel3.NoteOff(180),
-			"channel.NoteOff channel 3 key 127",
+			"channel.NoteOff channel 4 key 127",
 		},
 		{
 			Channel4.NoteOffVelocity(180, 220),
-			"channel.NoteOffVelocity channel 4 key 127 velocity 127",
+			"channel.NoteOffVelocity channel 5 key 127 velocity 127",
 		},
 		{
 			Channel4.Pitchbend(12300),
-			"channel.Pitchbend channel 4 value 8191 absValue 0",
+			"channel.Pitchbend channel 5 value 8191 absValue 0",
 		},
 		{
 			Channel4.PolyAftertouch(186, 190),
-			"channel.PolyAftertouch channel 4 key 127 pressure 127",
+			"channel.PolyAftertouch channel 5 key 127 pressure 127",
 		},
 		{
 			Channel4.ProgramChange(183),
-			"channel.ProgramChange channel 4 program 127",
+			"channel.ProgramChange channel 5 program 127",
 		},
 	}
 
@@ -93,6 +93,67 @@ func TestMessagesString(t *testing.T) {
 
 }
 
+// TestDisplayChannel checks the numbering policy for all message types at the lowest and the highest channel:
+// Channel is 0-based, DisplayChannel and String are 1-based.
+func TestDisplayChannel(t *testing.T) {
+	tests := []struct {
+		channel  Channel
+		expected []string
+	}{
+		{Channel0, []string{
+			"channel.NoteOn channel 1 key 60 velocity 100",
+			"channel.NoteOff channel 1 key 60",
+			"channel.NoteOffVelocity channel 1 key 60 velocity 64",
+			"channel.PolyAftertouch channel 1 key 60 pressure 30",
+			"channel.ControlChange channel 1 controller 7 (\"Volume (MSB)\") value 100",
+			"channel.ControlChange channel 1 controller 3 value 100",
+			"channel.ProgramChange channel 1 program 3",
+			"channel.Aftertouch channel 1 pressure 40",
+			"channel.Pitchbend channel 1 value -8192 absValue 0",
+		}},
+		{Channel15, []string{
+			"channel.NoteOn channel 16 key 60 velocity 100",
+			"channel.NoteOff channel 16 key 60",
+			"channel.NoteOffVelocity channel 16 key 60 velocity 64",
+			"channel.PolyAftertouch channel 16 key 60 pressure 30",
+			"channel.ControlChange channel 16 controller 7 (\"Volume (MSB)\") value 100",
+			"channel.ControlChange channel 16 controller 3 value 100",
+			"channel.ProgramChange channel 16 program 3",
+			"channel.Aftertouch channel 16 pressure 40",
+			"channel.Pitchbend channel 16 value -8192 absValue 0",
+		}},
+	}
+
+	for _, test := range tests {
+		ch := test.channel
+		msgs := []Message{
+			ch.NoteOn(60, 100),
+			ch.NoteOff(60),
+			ch.NoteOffVelocity(60, 64),
+			ch.PolyAftertouch(60, 30),
+			ch.ControlChange(7, 100),
+			ch.ControlChange(3, 100),
+			ch.ProgramChange(3),
+			ch.Aftertouch(40),
+			ch.Pitchbend(-8192),
+		}
+
+		if ch.DisplayChannel() != ch.Channel()+1 {
+			t.Errorf("Channel(%v).DisplayChannel() = %v; want %v", ch.Channel(), ch.DisplayChannel(), ch.Channel()+1)
+		}
+
+		for i, msg := range msgs {
+			if msg.Channel() != uint8(ch) || msg.DisplayChannel() != uint8(ch)+1 {
+				t.Errorf("%T: Channel() = %v, DisplayChannel() = %v; want %v, %v", msg, msg.Channel(), msg.DisplayChannel(), uint8(ch), uint8(ch)+1)
+			}
+
+			if got, want := msg.String(), test.expected[i]; got != want {
+				t.Errorf("got: %#v; wanted %#v", got, want)
+			}
+		}
+	}
+}
+
 func TestMessagesRaw(t *testing.T) {
 
 	tests := []struct {
@@ -156,42 +217,42 @@ func TestSetChannel(t *testing.T) {
 		{
 			Channel1.Aftertouch(120),
 			5,
-			"channel.Aftertouch channel 5 pressure 120",
+			"channel.Aftertouch channel 6 pressure 120",
 		},
 		{
 			Channel8.ControlChange(7, 110),
 			9,
-			"channel.ControlChange channel 9 controller 7 (\"Volume (MSB)\") value 110",
+			"channel.ControlChange channel 10 controller 7 (\"Volume (MSB)\") value 110",
 		},
 		{
 			Channel2.NoteOn(100, 80),
 			0,
-			"channel.NoteOn channel 0 key 100 velocity 80",
+			"channel.NoteOn channel 1 key 100 velocity 80",
 		},
 		{
 			Channel3.NoteOff(80),
 			2,
-			"channel.NoteOff channel 2 key 80",
+			"channel.NoteOff channel 3 key 80",
 		},
 		{
 			Channel4.NoteOffVelocity(80, 20),
 			11,
-			"channel.NoteOffVelocity channel 11 key 80 velocity 20",
+			"channel.NoteOffVelocity channel 12 key 80 velocity 20",
 		},
 		{
 			Channel4.Pitchbend(300),
 			14,
-			"channel.Pitchbend channel 14 value 300 absValue 0",
+			"channel.Pitchbend channel 15 value 300 absValue 0",
 		},
 		{
 			Channel4.PolyAftertouch(86, 109),
 			2,
-			"channel.PolyAftertouch channel 2 key 86 pressure 109",
+			"channel.PolyAftertouch channel 3 key 86 pressure 109",
 		},
 		{
 			Channel4.ProgramChange(83),
 			0,
-			"channel.ProgramChange channel 0 program 83",
+			"channel.ProgramChange channel 1 program 83",
 		},
 	}
 
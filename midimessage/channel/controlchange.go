@@ -22,16 +22,69 @@ func (c ControlChange) Value() uint8 {
 	return c.value
 }
 
-// Channel returns the MIDI channel of the control change message
+// Channel returns the channel of the control change message on the wire (0-15)
 func (c ControlChange) Channel() uint8 {
 	return c.channel
 }
 
+// DisplayChannel returns the channel of the control change message as it is displayed to users (1-16)
+func (c ControlChange) DisplayChannel() uint8 {
+	return c.channel + 1
+}
+
+// IsOn interprets the value of a switch controller (e.g. the sustain pedal): values of 64 and above are on.
+// isSwitch is false, if the controller is no switch controller (see IsSwitchController).
+func (c ControlChange) IsOn() (on bool, isSwitch bool) {
+	return c.value >= 64, IsSwitchController(c.controller)
+}
+
+// PanPosition returns the position of a pan (controller 10) or balance (controller 8) message
+// between -64 (left) and 63 (right), where 0 is the center. For other controllers, 0 is returned.
+func (c ControlChange) PanPosition() int8 {
+	if c.controller != 10 && c.controller != 8 {
+		return 0
+	}
+	return int8(c.value) - 64
+}
+
+// Balance returns the position of a balance message (controller 8) between -64 (left) and 63 (right),
+// where 0 is the center. For other controllers, 0 is returned.
+func (c ControlChange) Balance() int8 {
+	if c.controller != 8 {
+		return 0
+	}
+	return c.PanPosition()
+}
+
+// IsSwitchController returns true, if the given controller is a switch (on/off) controller,
+// i.e. the pedals (64-69), the general purpose buttons (80-83) and local control (122).
+func IsSwitchController(controller uint8) bool {
+	switch {
+	case controller >= 64 && controller <= 69:
+		return true
+	case controller >= 80 && controller <= 83:
+		return true
+	case controller == 122:
+		return true
+	}
+	return false
+}
+
 // Raw returns the raw bytes of the control change message.
 func (c ControlChange) Raw() []byte {
 	return channelMessage2(c.channel, 11, c.controller, c.value)
 }
 
+// EncodedLen returns the number of bytes of the message including the status byte (see Raw), without encoding it
+func (c ControlChange) EncodedLen() int {
+	return 3
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the channel is beyond 15 or a data byte is beyond 127
+func (c ControlChange) Validate() error {
+	return validate(c, c.channel, c.controller, c.value)
+}
+
 // set returns a new control change message that is set to the parsed arguments
 func (ControlChange) set(channel uint8, firstArg, secondArg uint8) setter2 {
 	var m ControlChange
@@ -42,12 +95,22 @@ func (ControlChange) set(channel uint8, firstArg, secondArg uint8) setter2 {
 }
 
 // String returns human readable information about the control change message.
+// For switch controllers, the interpretation of the value (on or off) is added.
 func (c ControlChange) String() string {
+	var value = fmt.Sprint(c.Value())
+
+	if on, isSwitch := c.IsOn(); isSwitch {
+		if on {
+			value += " (on)"
+		} else {
+			value += " (off)"
+		}
+	}
 
 	if name, has := ccControllers[c.controller]; has {
-		return fmt.Sprintf("%T channel %v controller %v (%#v) value %v", c, c.Channel(), c.Controller(), name, c.Value())
+		return fmt.Sprintf("%T channel %v controller %v (%#v) value %v", c, c.DisplayChannel(), c.Controller(), name, value)
 	}
-	return fmt.Sprintf("%T channel %v controller %v value %v", c, c.Channel(), c.Controller(), c.Value())
+	return fmt.Sprintf("%T channel %v controller %v value %v", c, c.DisplayChannel(), c.Controller(), value)
 
 }
 
@@ -0,0 +1,61 @@
+package channel
+
+import (
+	"testing"
+)
+
+func TestControlChangeIsOn(t *testing.T) {
+	for controller := 0; controller < 128; controller++ {
+		_, has := ccControllers[uint8(controller)]
+		name := ccControllers[uint8(controller)]
+
+		// the switch controllers are named "(on/off)"
+		expectSwitch := has && len(name) > 8 && name[len(name)-8:] == "(on/off)"
+
+		for _, value := range []uint8{0, 63, 64, 127} {
+			on, isSwitch := Channel0.ControlChange(uint8(controller), value).IsOn()
+
+			if isSwitch != expectSwitch {
+				t.Errorf("ControlChange(%v, %v).IsOn() isSwitch = %v; want %v", controller, value, isSwitch, expectSwitch)
+			}
+
+			if got, want := on, value >= 64; got != want {
+				t.Errorf("ControlChange(%v, %v).IsOn() on = %v; want %v", controller, value, got, want)
+			}
+		}
+	}
+}
+
+func TestControlChangePan(t *testing.T) {
+	tests := []struct {
+		input    ControlChange
+		pan      int8
+		balance  int8
+		expected string
+	}{
+		{Channel1.Pan(0), 0, 0, "channel.ControlChange channel 2 controller 10 (\"Pan position (MSB)\") value 64"},
+		{Channel1.Pan(-64), -64, 0, "channel.ControlChange channel 2 controller 10 (\"Pan position (MSB)\") value 0"},
+		{Channel1.Pan(63), 63, 0, "channel.ControlChange channel 2 controller 10 (\"Pan position (MSB)\") value 127"},
+		{Channel1.Pan(100), 63, 0, "channel.ControlChange channel 2 controller 10 (\"Pan position (MSB)\") value 127"},
+		{Channel1.Pan(-100), -64, 0, "channel.ControlChange channel 2 controller 10 (\"Pan position (MSB)\") value 0"},
+		{Channel1.Balance(-20), -20, -20, "channel.ControlChange channel 2 controller 8 (\"Balance (MSB)\") value 44"},
+		{Channel1.ControlChange(7, 100), 0, 0, "channel.ControlChange channel 2 controller 7 (\"Volume (MSB)\") value 100"},
+		{Channel2.SustainOn(), 0, 0, "channel.ControlChange channel 3 controller 64 (\"Hold Pedal (on/off)\") value 127 (on)"},
+		{Channel2.SustainOff(), 0, 0, "channel.ControlChange channel 3 controller 64 (\"Hold Pedal (on/off)\") value 0 (off)"},
+		{Channel2.ControlChange(67, 64), 0, 0, "channel.ControlChange channel 3 controller 67 (\"Soft Pedal (on/off)\") value 64 (on)"},
+	}
+
+	for _, test := range tests {
+		if got, want := test.input.PanPosition(), test.pan; got != want {
+			t.Errorf("%s PanPosition() = %v; want %v", test.input, got, want)
+		}
+
+		if got, want := test.input.Balance(), test.balance; got != want {
+			t.Errorf("%s Balance() = %v; want %v", test.input, got, want)
+		}
+
+		if got, want := test.input.String(), test.expected; got != want {
+			t.Errorf("String() = %q; want %q", got, want)
+		}
+	}
+}
@@ -2,5 +2,13 @@
 // Use of this source code is governed by a MIT
 // license that can be found in the LICENSE file.
 
-// Package channel provides MIDI Channel Messages
+/*
+Package channel provides MIDI Channel Messages
+
+Channels are numbered from 0 to 15, as they are on the wire, by all functions and methods that take or return
+a channel, e.g. Channel0 to Channel15 and the Channel methods of the messages.
+Since most instruments and sequencers display the channels from 1 to 16, the String methods of the messages
+show the 1-based number, as returned by DisplayChannel: Channel9.NoteOn(36, 100).String() is
+"channel.NoteOn channel 10 key 36 velocity 100".
+*/
 package channel
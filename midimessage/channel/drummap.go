@@ -0,0 +1,121 @@
+package channel
+
+import (
+	"sort"
+)
+
+// DrumMap maps the keys of the drum notes of one drum kit to the keys of another one.
+// Keys that are not part of the map are unmapped.
+type DrumMap map[uint8]uint8
+
+// Map returns the key that the given key is mapped to and whether the key is mapped at all
+func (m DrumMap) Map(key uint8) (mapped uint8, ok bool) {
+	mapped, ok = m[key]
+	return
+}
+
+// Remap returns the given note on, note off or polyphonic aftertouch message with the key mapped.
+// Other messages are returned unchanged. ok is false, if the key of the message is not mapped.
+func (m DrumMap) Remap(msg Message) (res Message, ok bool) {
+	ch := Channel(msg.Channel())
+
+	switch v := msg.(type) {
+	case NoteOn:
+		if key, has := m.Map(v.Key()); has {
+			return ch.NoteOn(key, v.Velocity()), true
+		}
+	case NoteOffVelocity:
+		if key, has := m.Map(v.Key()); has {
+			return ch.NoteOffVelocity(key, v.Velocity()), true
+		}
+	case NoteOff:
+		if key, has := m.Map(v.Key()); has {
+			return ch.NoteOff(key), true
+		}
+	case PolyAftertouch:
+		if key, has := m.Map(v.Key()); has {
+			return ch.PolyAftertouch(key, v.Pressure()), true
+		}
+	default:
+		return msg, true
+	}
+
+	return msg, false
+}
+
+// gmDrumFirst and gmDrumLast are the first and the last key of the General MIDI percussion map
+// (35 Acoustic Bass Drum to 81 Open Triangle)
+const (
+	gmDrumFirst = 35
+	gmDrumLast  = 81
+)
+
+// identity returns a DrumMap that maps the General MIDI percussion keys to themselves
+func identity() DrumMap {
+	m := DrumMap{}
+	for key := uint8(gmDrumFirst); key <= gmDrumLast; key++ {
+		m[key] = key
+	}
+	return m
+}
+
+// drumMapPresets are the changes of the presets against the identity of the General MIDI percussion keys
+var drumMapPresets = map[string]map[uint8]uint8{
+	"GM": {},
+
+	// Roland TD-series kits: the rim zones of the toms and the edge zones of the cymbals and the hi-hat
+	// have their own keys, that are mapped to the head, bow or the cymbal
+	"TD": {
+		22: 42, // hi-hat closed (edge) -> closed hi-hat
+		26: 46, // hi-hat open (edge) -> open hi-hat
+		39: 41, // tom 4 (rim) -> low floor tom
+		40: 38, // snare (rim) -> acoustic snare
+		47: 45, // tom 2 (rim) -> low tom
+		50: 48, // tom 1 (rim) -> hi-mid tom
+		52: 57, // crash 2 (edge) -> crash cymbal 2
+		55: 49, // crash 1 (edge) -> crash cymbal 1
+		58: 43, // tom 3 (rim) -> high floor tom
+		59: 51, // ride (edge) -> ride cymbal 1
+	},
+
+	// Alesis kits: like the TD-series, but the snare rim and the cymbal edges are separate sounds
+	"Alesis": {
+		22: 42, // hi-hat closed (edge) -> closed hi-hat
+		26: 46, // hi-hat open (edge) -> open hi-hat
+		39: 41, // tom 4 (rim) -> low floor tom
+		47: 45, // tom 2 (rim) -> low tom
+		50: 48, // tom 1 (rim) -> hi-mid tom
+		58: 43, // tom 3 (rim) -> high floor tom
+	},
+}
+
+// DrumMapPreset returns a new DrumMap for the preset with the given name that maps the keys of a drum kit
+// to the General MIDI percussion keys. It returns nil, if there is no such preset (see DrumMapPresets).
+//
+// The presets are "GM" (General MIDI, maps the percussion keys 35-81 to themselves), "TD" (Roland TD-series)
+// and "Alesis".
+func DrumMapPreset(name string) DrumMap {
+	changes, has := drumMapPresets[name]
+
+	if !has {
+		return nil
+	}
+
+	m := identity()
+
+	for from, to := range changes {
+		m[from] = to
+	}
+
+	return m
+}
+
+// DrumMapPresets returns the sorted names of the DrumMap presets
+func DrumMapPresets() []string {
+	var names []string
+	for name := range drumMapPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,97 @@
+package channel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDrumMapPreset(t *testing.T) {
+	tests := []struct {
+		preset   string
+		key      uint8
+		expected uint8
+		mapped   bool
+	}{
+		{"GM", 36, 36, true},
+		{"GM", 81, 81, true},
+		{"GM", 22, 0, false},
+		{"GM", 82, 0, false},
+		{"TD", 22, 42, true},
+		{"TD", 26, 46, true},
+		{"TD", 40, 38, true},
+		{"TD", 50, 48, true},
+		{"TD", 59, 51, true},
+		{"TD", 36, 36, true},
+		{"Alesis", 40, 40, true},
+		{"Alesis", 58, 43, true},
+	}
+
+	for _, test := range tests {
+		key, mapped := DrumMapPreset(test.preset).Map(test.key)
+
+		if key != test.expected || mapped != test.mapped {
+			t.Errorf("DrumMapPreset(%q).Map(%v) = %v, %v; want %v, %v", test.preset, test.key, key, mapped, test.expected, test.mapped)
+		}
+	}
+
+	if DrumMapPreset("unknown") != nil {
+		t.Errorf("DrumMapPreset(%q) must be nil", "unknown")
+	}
+
+	if got, want := DrumMapPresets(), []string{"Alesis", "GM", "TD"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DrumMapPresets() = %v; want %v", got, want)
+	}
+
+	// the presets are copies
+	DrumMapPreset("GM")[36] = 0
+
+	if got, want := DrumMapPreset("GM")[36], uint8(36); got != want {
+		t.Errorf("DrumMapPreset(%q)[36] = %v; want %v", "GM", got, want)
+	}
+}
+
+func TestDrumMapRemap(t *testing.T) {
+	m := DrumMap{22: 42}
+
+	tests := []struct {
+		input    Message
+		expected Message
+		ok       bool
+	}{
+		{Channel9.NoteOn(22, 100), Channel9.NoteOn(42, 100), true},
+		{Channel9.NoteOff(22), Channel9.NoteOff(42), true},
+		{Channel9.NoteOffVelocity(22, 30), Channel9.NoteOffVelocity(42, 30), true},
+		{Channel9.PolyAftertouch(22, 30), Channel9.PolyAftertouch(42, 30), true},
+		{Channel9.NoteOn(23, 100), Channel9.NoteOn(23, 100), false},
+		{Channel9.ProgramChange(3), Channel9.ProgramChange(3), true},
+	}
+
+	for _, test := range tests {
+		got, ok := m.Remap(test.input)
+
+		if got != test.expected || ok != test.ok {
+			t.Errorf("Remap(%s) = %s, %v; want %s, %v", test.input, got, ok, test.expected, test.ok)
+		}
+	}
+}
+
+func TestDrumName(t *testing.T) {
+	tests := []struct {
+		key      uint8
+		expected string
+	}{
+		{34, ""},
+		{35, "Acoustic Bass Drum"},
+		{38, "Acoustic Snare"},
+		{42, "Closed Hi-Hat"},
+		{49, "Crash Cymbal 1"},
+		{81, "Open Triangle"},
+		{82, ""},
+	}
+
+	for _, test := range tests {
+		if got := DrumName(test.key); got != test.expected {
+			t.Errorf("DrumName(%v) = %q; want %q", test.key, got, test.expected)
+		}
+	}
+}
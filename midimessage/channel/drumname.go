@@ -0,0 +1,62 @@
+package channel
+
+// gmDrumNames are the names of the General MIDI percussion keys, starting with gmDrumFirst
+var gmDrumNames = [gmDrumLast - gmDrumFirst + 1]string{
+	"Acoustic Bass Drum",
+	"Bass Drum 1",
+	"Side Stick",
+	"Acoustic Snare",
+	"Hand Clap",
+	"Electric Snare",
+	"Low Floor Tom",
+	"Closed Hi-Hat",
+	"High Floor Tom",
+	"Pedal Hi-Hat",
+	"Low Tom",
+	"Open Hi-Hat",
+	"Low-Mid Tom",
+	"Hi-Mid Tom",
+	"Crash Cymbal 1",
+	"High Tom",
+	"Ride Cymbal 1",
+	"Chinese Cymbal",
+	"Ride Bell",
+	"Tambourine",
+	"Splash Cymbal",
+	"Cowbell",
+	"Crash Cymbal 2",
+	"Vibraslap",
+	"Ride Cymbal 2",
+	"Hi Bongo",
+	"Low Bongo",
+	"Mute Hi Conga",
+	"Open Hi Conga",
+	"Low Conga",
+	"High Timbale",
+	"Low Timbale",
+	"High Agogo",
+	"Low Agogo",
+	"Cabasa",
+	"Maracas",
+	"Short Whistle",
+	"Long Whistle",
+	"Short Guiro",
+	"Long Guiro",
+	"Claves",
+	"Hi Wood Block",
+	"Low Wood Block",
+	"Mute Cuica",
+	"Open Cuica",
+	"Mute Triangle",
+	"Open Triangle",
+}
+
+// DrumName returns the General MIDI name of the percussion sound of the given key on the drum channel
+// (channel 9, channel 10 in GM), e.g. "Acoustic Snare" for key 38.
+// It returns an empty string for keys outside of the General MIDI percussion map (35-81).
+func DrumName(key uint8) string {
+	if key < gmDrumFirst || key > gmDrumLast {
+		return ""
+	}
+	return gmDrumNames[key-gmDrumFirst]
+}
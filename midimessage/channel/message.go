@@ -4,7 +4,31 @@ package channel
 type Message interface {
 	String() string
 	Raw() []byte
+
+	// Channel returns the channel on the wire (0-15)
 	Channel() uint8
+
+	// DisplayChannel returns the channel as it is displayed to users (1-16)
+	DisplayChannel() uint8
+}
+
+// EncodedLen returns the number of bytes of the given message without encoding it.
+// If runningStatus is true, the status byte is not counted, since it is omitted when it equals the status byte
+// of the previous message.
+func EncodedLen(msg Message, runningStatus bool) int {
+	var n int
+
+	if l, ok := msg.(interface{ EncodedLen() int }); ok {
+		n = l.EncodedLen()
+	} else {
+		n = len(msg.Raw())
+	}
+
+	if runningStatus && n > 0 {
+		n--
+	}
+
+	return n
 }
 
 var (
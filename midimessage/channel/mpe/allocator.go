@@ -0,0 +1,296 @@
+package mpe
+
+import (
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// StealPolicy is the handling of a new note, when all member channels are in use
+type StealPolicy int
+
+const (
+	// StealOldest ends the note that has been started first and gives its channel to the new note (default)
+	StealOldest StealPolicy = iota
+
+	// StealLast ends the note that has been started last and gives its channel to the new note
+	StealLast
+
+	// StealNone drops the new note
+	StealNone
+)
+
+// AllocatorOption is an option for the Allocator
+type AllocatorOption func(*Allocator)
+
+// Steal sets the policy for new notes, when all member channels are in use. Default is StealOldest.
+func Steal(p StealPolicy) AllocatorOption {
+	return func(a *Allocator) {
+		a.steal = p
+	}
+}
+
+// note is a sounding note
+type note struct {
+	key uint8
+	ch  uint8
+}
+
+// Allocator assigns notes to the member channels of a zone. The channels are assigned round-robin: a new note gets
+// the next free member channel after the channel of the previous note, so that the release phase of a note is not
+// affected by the expression of the following notes. When all member channels are in use, a sounding note is
+// stolen (see Steal).
+//
+// The expression of a note (pitch bend, pressure and timbre) is sent on the channel of the note. Before a note is
+// started on a channel, the pitch bend and the pressure of the channel are reset, if they have been changed.
+// Notes are identified by their keys: if the same key is sounding more than once, the messages for the key
+// affect the note that has been started first.
+type Allocator struct {
+	zone     Zone
+	channels []uint8
+	steal    StealPolicy
+
+	// next is the index of the channel where the search for a free channel starts
+	next int
+
+	// notes are the sounding notes in the order of their start
+	notes []note
+
+	// swallow is the number of note off messages per key that belong to stolen or dropped notes
+	swallow [128]int
+
+	bend     [16]int16
+	pressure [16]uint8
+}
+
+// NewAllocator returns an Allocator for the given zone
+func NewAllocator(zone Zone, options ...AllocatorOption) *Allocator {
+	a := &Allocator{zone: zone, channels: zone.MemberChannels()}
+
+	for _, opt := range options {
+		opt(a)
+	}
+
+	return a
+}
+
+// Channel returns the channel of the first sounding note of the given key. ok is false, if the key is not sounding.
+func (a *Allocator) Channel(key uint8) (ch uint8, ok bool) {
+	if i := a.find(key); i >= 0 {
+		return a.notes[i].ch, true
+	}
+	return 0, false
+}
+
+// find returns the index of the first sounding note of the given key or -1
+func (a *Allocator) find(key uint8) int {
+	for i, n := range a.notes {
+		if n.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// busy returns true, if there is a sounding note on the given channel
+func (a *Allocator) busy(ch uint8) bool {
+	for _, n := range a.notes {
+		if n.ch == ch {
+			return true
+		}
+	}
+	return false
+}
+
+// NoteOn assigns a channel to a new note and returns the messages that start it.
+// If a note has to be stolen, the messages begin with its note off message.
+// If the note is dropped (see StealNone) or the zone has no member channels, no messages are returned.
+func (a *Allocator) NoteOn(key, velocity uint8) (msgs []midi.Message) {
+	if key > 127 {
+		return nil
+	}
+
+	if velocity == 0 {
+		return a.NoteOff(key)
+	}
+
+	if len(a.channels) == 0 {
+		a.swallow[key]++
+		return nil
+	}
+
+	ch, found := uint8(0), false
+
+	for i := 0; i < len(a.channels); i++ {
+		idx := (a.next + i) % len(a.channels)
+
+		if !a.busy(a.channels[idx]) {
+			ch, found = a.channels[idx], true
+			a.next = idx + 1
+			break
+		}
+	}
+
+	if !found {
+		var victim int
+
+		switch a.steal {
+		case StealNone:
+			a.swallow[key]++
+			return nil
+		case StealLast:
+			victim = len(a.notes) - 1
+		default:
+			victim = 0
+		}
+
+		stolen := a.notes[victim]
+		a.notes = append(a.notes[:victim], a.notes[victim+1:]...)
+		a.swallow[stolen.key]++
+		ch = stolen.ch
+
+		for i, c := range a.channels {
+			if c == ch {
+				a.next = i + 1
+			}
+		}
+
+		msgs = append(msgs, channel.Channel(ch).NoteOff(stolen.key))
+	}
+
+	c := channel.Channel(ch)
+
+	if a.bend[ch] != 0 {
+		a.bend[ch] = 0
+		msgs = append(msgs, c.Pitchbend(0))
+	}
+
+	if a.pressure[ch] != 0 {
+		a.pressure[ch] = 0
+		msgs = append(msgs, c.Aftertouch(0))
+	}
+
+	a.notes = append(a.notes, note{key: key, ch: ch})
+	return append(msgs, c.NoteOn(key, velocity))
+}
+
+// NoteOff returns the note off message of the first sounding note of the given key and frees its channel.
+// The note off of a note that has been stolen or dropped returns no messages.
+func (a *Allocator) NoteOff(key uint8) []midi.Message {
+	return a.noteOff(key, func(c channel.Channel) midi.Message {
+		return c.NoteOff(key)
+	})
+}
+
+// NoteOffVelocity is like NoteOff, but returns a note off message with the given velocity
+func (a *Allocator) NoteOffVelocity(key, velocity uint8) []midi.Message {
+	return a.noteOff(key, func(c channel.Channel) midi.Message {
+		return c.NoteOffVelocity(key, velocity)
+	})
+}
+
+func (a *Allocator) noteOff(key uint8, off func(channel.Channel) midi.Message) []midi.Message {
+	if key > 127 {
+		return nil
+	}
+
+	if a.swallow[key] > 0 {
+		a.swallow[key]--
+		return nil
+	}
+
+	i := a.find(key)
+
+	if i < 0 {
+		return nil
+	}
+
+	ch := a.notes[i].ch
+	a.notes = append(a.notes[:i], a.notes[i+1:]...)
+	return []midi.Message{off(channel.Channel(ch))}
+}
+
+// Bend returns the pitch bend message for the first sounding note of the given key or nil, if the key is not sounding
+func (a *Allocator) Bend(key uint8, value int16) []midi.Message {
+	ch, ok := a.Channel(key)
+
+	if !ok {
+		return nil
+	}
+
+	a.bend[ch] = value
+	return []midi.Message{channel.Channel(ch).Pitchbend(value)}
+}
+
+// Pressure returns the channel aftertouch message for the first sounding note of the given key or nil,
+// if the key is not sounding
+func (a *Allocator) Pressure(key, pressure uint8) []midi.Message {
+	ch, ok := a.Channel(key)
+
+	if !ok {
+		return nil
+	}
+
+	a.pressure[ch] = pressure
+	return []midi.Message{channel.Channel(ch).Aftertouch(pressure)}
+}
+
+// Timbre returns the timbre control change message (CC 74) for the first sounding note of the given key or nil,
+// if the key is not sounding
+func (a *Allocator) Timbre(key, value uint8) []midi.Message {
+	ch, ok := a.Channel(key)
+
+	if !ok {
+		return nil
+	}
+
+	return []midi.Message{channel.Channel(ch).ControlChange(74, value)}
+}
+
+// Convert returns the MPE messages for the given message of a single channel stream (e.g. from a controller):
+// Note messages are passed to NoteOn and NoteOff, polyphonic aftertouch messages to Pressure.
+// Other channel messages are moved to the manager channel, so that they affect the whole zone.
+// Messages that are no channel messages are returned as they are.
+func (a *Allocator) Convert(msg midi.Message) []midi.Message {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		return a.NoteOn(v.Key(), v.Velocity())
+	case channel.NoteOff:
+		return a.NoteOff(v.Key())
+	case channel.NoteOffVelocity:
+		return a.NoteOffVelocity(v.Key(), v.Velocity())
+	case channel.PolyAftertouch:
+		return a.Pressure(v.Key(), v.Pressure())
+	case channel.Message:
+		return []midi.Message{channel.SetChannel(v, a.zone.Manager())}
+	default:
+		return []midi.Message{msg}
+	}
+}
+
+// Reader returns a midi.Reader that converts the messages read from rd (see Convert)
+func (a *Allocator) Reader(rd midi.Reader) midi.Reader {
+	return &convertReader{Reader: rd, convert: a.Convert}
+}
+
+type convertReader struct {
+	midi.Reader
+	convert func(midi.Message) []midi.Message
+	pending []midi.Message
+}
+
+// Read returns the next converted message
+func (r *convertReader) Read() (midi.Message, error) {
+	for len(r.pending) == 0 {
+		msg, err := r.Reader.Read()
+
+		if err != nil {
+			return msg, err
+		}
+
+		r.pending = r.convert(msg)
+	}
+
+	msg := r.pending[0]
+	r.pending = r.pending[1:]
+	return msg, nil
+}
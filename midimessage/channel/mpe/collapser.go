@@ -0,0 +1,130 @@
+package mpe
+
+import (
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// Collapser folds the messages of a MPE zone into a single channel, for receivers that don't understand MPE:
+//
+//   - the note messages of the member channels are moved to the target channel; if the same key is sounding on
+//     several member channels, the note off is passed on with the release of the last of them
+//   - the channel aftertouch of a member channel becomes polyphonic aftertouch for the keys sounding on it
+//   - the pitch bend of a member channel is passed on (to the target channel), if the member channel has the most
+//     recently started note that is still sounding, otherwise it is dropped
+//   - the other messages of the member channels (e.g. the timbre controller) are dropped
+//   - the messages of the manager channel are moved to the target channel
+//
+// Messages of other channels and messages that are no channel messages are returned as they are.
+// The Collapser keeps track of the sounding notes and must therefore be passed all messages of the stream.
+type Collapser struct {
+	zone   Zone
+	target channel.Channel
+	notes  channel.NoteTracker
+
+	// started are the channels of the sounding notes in the order of their start
+	started []uint8
+
+	// sounding is the number of sounding notes of each key on the target channel
+	sounding [128]int
+}
+
+// NewCollapser returns a Collapser that folds the given zone into the given target channel
+func NewCollapser(zone Zone, target uint8) *Collapser {
+	return &Collapser{zone: zone, target: channel.Channel(target)}
+}
+
+// Convert returns the messages that replace the given message
+func (c *Collapser) Convert(msg midi.Message) []midi.Message {
+	m, ok := msg.(channel.Message)
+
+	if !ok {
+		return []midi.Message{msg}
+	}
+
+	ch := m.Channel()
+
+	if ch == c.zone.Manager() && c.zone.Members > 0 {
+		return []midi.Message{channel.SetChannel(m, uint8(c.target))}
+	}
+
+	if !c.zone.IsMember(ch) {
+		return []midi.Message{msg}
+	}
+
+	switch v := m.(type) {
+	case channel.NoteOn:
+		if v.Key() > 127 {
+			return nil
+		}
+		if v.Velocity() == 0 {
+			return c.noteOff(ch, v.Key(), c.target.NoteOff(v.Key()))
+		}
+		c.notes.Track(v)
+		c.started = append(c.started, ch)
+		c.sounding[v.Key()]++
+		return []midi.Message{c.target.NoteOn(v.Key(), v.Velocity())}
+	case channel.NoteOff:
+		return c.noteOff(ch, v.Key(), c.target.NoteOff(v.Key()))
+	case channel.NoteOffVelocity:
+		return c.noteOff(ch, v.Key(), c.target.NoteOffVelocity(v.Key(), v.Velocity()))
+	case channel.Aftertouch:
+		var res []midi.Message
+
+		for _, key := range c.notes.Active(ch) {
+			res = append(res, c.target.PolyAftertouch(key, v.Pressure()))
+		}
+
+		return res
+	case channel.Pitchbend:
+		if len(c.started) == 0 || c.started[len(c.started)-1] != ch {
+			return nil
+		}
+		return []midi.Message{c.target.Pitchbend(v.Value())}
+	default:
+		return nil
+	}
+}
+
+// noteOff ends the note of the given key on the given member channel and returns the given note off message, if
+// the key is not sounding on another member channel.
+// Notes that are not sounding are ended anyway, since they might have been started before the Collapser was used.
+func (c *Collapser) noteOff(ch, key uint8, off midi.Message) []midi.Message {
+	if key > 127 {
+		return nil
+	}
+
+	if c.notes.IsActive(ch, key) {
+		c.notes.Track(channel.Channel(ch).NoteOff(key))
+		c.sounding[key]--
+
+		for i := len(c.started) - 1; i >= 0; i-- {
+			if c.started[i] == ch {
+				c.started = append(c.started[:i], c.started[i+1:]...)
+				break
+			}
+		}
+
+		if c.sounding[key] > 0 {
+			return nil
+		}
+	}
+
+	return []midi.Message{off}
+}
+
+// Reader returns a midi.Reader that converts the messages read from rd (see Convert)
+func (c *Collapser) Reader(rd midi.Reader) midi.Reader {
+	return &convertReader{Reader: rd, convert: c.Convert}
+}
+
+// Collapse returns the given messages folded from the given zone into the given target channel (see Collapser)
+func Collapse(msgs []midi.Message, zone Zone, target uint8) (res []midi.Message) {
+	c := NewCollapser(zone, target)
+
+	for _, msg := range msgs {
+		res = append(res, c.Convert(msg)...)
+	}
+
+	return
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2018 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package mpe provides helpers for MIDI Polyphonic Expression (MPE).
+
+In MPE each sounding note gets a MIDI channel of its own (a member channel of a zone), so that pitch bend,
+channel aftertouch and the timbre controller (CC 74) of the channel affect only this note. Messages for all notes
+of the zone are sent on the manager channel of the zone.
+
+Example
+
+	zone := mpe.LowerZone(15)
+	alloc := mpe.NewAllocator(zone)
+
+	// configure the receiver
+	for _, cc := range zone.Configuration() {
+		wr.Write(cc)
+	}
+
+	// play a note and bend it
+	for _, msg := range alloc.NoteOn(60, 100) {
+		wr.Write(msg)
+	}
+
+	for _, msg := range alloc.Bend(60, 2048) {
+		wr.Write(msg)
+	}
+
+The Allocator distributes the notes to the member channels, while the Collapser folds a MPE stream back to a
+single channel for receivers that don't understand MPE.
+*/
+package mpe
@@ -0,0 +1,394 @@
+package mpe
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/realtime"
+)
+
+func messagesString(msgs []midi.Message) string {
+	var s []string
+	for _, msg := range msgs {
+		s = append(s, msg.String())
+	}
+	return strings.Join(s, "\n")
+}
+
+func TestConfiguration(t *testing.T) {
+	tests := []struct {
+		zone     Zone
+		expected string
+	}{
+		{LowerZone(15), "B0 65 00 B0 64 06 B0 06 0F B0 65 7F B0 64 7F"},
+		{UpperZone(3), "BF 65 00 BF 64 06 BF 06 03 BF 65 7F BF 64 7F"},
+		{LowerZone(0), "B0 65 00 B0 64 06 B0 06 00 B0 65 7F B0 64 7F"},
+		{LowerZone(20), "B0 65 00 B0 64 06 B0 06 0F B0 65 7F B0 64 7F"},
+	}
+
+	for i, test := range tests {
+		var bt []string
+		for _, cc := range test.zone.Configuration() {
+			bt = append(bt, fmt.Sprintf("% X", cc.Raw()))
+		}
+
+		if got := strings.Join(bt, " "); got != test.expected {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, test.expected)
+		}
+	}
+}
+
+func TestZone(t *testing.T) {
+	tests := []struct {
+		zone     Zone
+		manager  uint8
+		members  string
+		isMember string
+	}{
+		{LowerZone(3), 0, "[1 2 3]", "1 2 3"},
+		{UpperZone(3), 15, "[14 13 12]", "12 13 14"},
+		{LowerZone(0), 0, "[]", ""},
+		{UpperZone(15), 15, "[14 13 12 11 10 9 8 7 6 5 4 3 2 1 0]", "0 1 2 3 4 5 6 7 8 9 10 11 12 13 14"},
+	}
+
+	for i, test := range tests {
+		var isMember []string
+		for ch := uint8(0); ch < 16; ch++ {
+			if test.zone.IsMember(ch) {
+				isMember = append(isMember, fmt.Sprint(ch))
+			}
+		}
+
+		got := fmt.Sprintf("%v %v %v", test.zone.Manager(), test.zone.MemberChannels(), strings.Join(isMember, " "))
+		expected := fmt.Sprintf("%v %v %v", test.manager, test.members, test.isMember)
+
+		if got != expected {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, expected)
+		}
+	}
+}
+
+func TestPitchBendSensitivity(t *testing.T) {
+	var bt []string
+	for _, cc := range PitchBendSensitivity(1, 48) {
+		bt = append(bt, fmt.Sprintf("% X", cc.Raw()))
+	}
+
+	expected := "B1 65 00 B1 64 00 B1 06 30 B1 65 7F B1 64 7F"
+
+	if got := strings.Join(bt, " "); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+// play calls the Allocator for each of the given steps ("on 60", "off 60", "bend 60 100", "press 60 30",
+// "timbre 60 20") and returns the resulting messages
+func play(a *Allocator, steps ...string) []midi.Message {
+	var res []midi.Message
+
+	for _, step := range steps {
+		var cmd string
+		var key, val int
+		fmt.Sscan(step, &cmd, &key, &val)
+
+		switch cmd {
+		case "on":
+			res = append(res, a.NoteOn(uint8(key), 100)...)
+		case "off":
+			res = append(res, a.NoteOff(uint8(key))...)
+		case "bend":
+			res = append(res, a.Bend(uint8(key), int16(val))...)
+		case "press":
+			res = append(res, a.Pressure(uint8(key), uint8(val))...)
+		case "timbre":
+			res = append(res, a.Timbre(uint8(key), uint8(val))...)
+		}
+	}
+
+	return res
+}
+
+func TestAllocatorRoundRobin(t *testing.T) {
+	a := NewAllocator(LowerZone(3))
+
+	got := messagesString(play(a, "on 60", "on 62", "off 60", "on 64", "on 65", "off 62", "off 65", "on 67"))
+
+	// released channels are not reused before the other free channels
+	expected := `channel.NoteOn channel 2 key 60 velocity 100
+channel.NoteOn channel 3 key 62 velocity 100
+channel.NoteOff channel 2 key 60
+channel.NoteOn channel 4 key 64 velocity 100
+channel.NoteOn channel 2 key 65 velocity 100
+channel.NoteOff channel 3 key 62
+channel.NoteOff channel 2 key 65
+channel.NoteOn channel 3 key 67 velocity 100`
+
+	if got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	if ch, ok := a.Channel(64); !ok || ch != 3 {
+		t.Errorf("Channel(64) = %v, %v; wanted 3, true", ch, ok)
+	}
+
+	if _, ok := a.Channel(60); ok {
+		t.Errorf("Channel(60) must not be sounding")
+	}
+}
+
+func TestAllocatorUpperZone(t *testing.T) {
+	a := NewAllocator(UpperZone(2))
+
+	got := messagesString(play(a, "on 60", "on 62", "off 60", "on 64"))
+
+	expected := `channel.NoteOn channel 15 key 60 velocity 100
+channel.NoteOn channel 14 key 62 velocity 100
+channel.NoteOff channel 15 key 60
+channel.NoteOn channel 15 key 64 velocity 100`
+
+	if got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+func TestAllocatorExhaustion(t *testing.T) {
+	// the fourth note does not fit into the 3 member channels; the note off messages of the stolen or dropped
+	// notes must not end other notes
+	steps := []string{"on 60", "on 62", "on 64", "on 65", "off 60", "off 62", "off 64", "off 65", "on 67"}
+
+	tests := []struct {
+		steal    StealPolicy
+		expected string
+	}{
+		{StealOldest, `channel.NoteOn channel 2 key 60 velocity 100
+channel.NoteOn channel 3 key 62 velocity 100
+channel.NoteOn channel 4 key 64 velocity 100
+channel.NoteOff channel 2 key 60
+channel.NoteOn channel 2 key 65 velocity 100
+channel.NoteOff channel 3 key 62
+channel.NoteOff channel 4 key 64
+channel.NoteOff channel 2 key 65
+channel.NoteOn channel 3 key 67 velocity 100`},
+		{StealLast, `channel.NoteOn channel 2 key 60 velocity 100
+channel.NoteOn channel 3 key 62 velocity 100
+channel.NoteOn channel 4 key 64 velocity 100
+channel.NoteOff channel 4 key 64
+channel.NoteOn channel 4 key 65 velocity 100
+channel.NoteOff channel 2 key 60
+channel.NoteOff channel 3 key 62
+channel.NoteOff channel 4 key 65
+channel.NoteOn channel 2 key 67 velocity 100`},
+		{StealNone, `channel.NoteOn channel 2 key 60 velocity 100
+channel.NoteOn channel 3 key 62 velocity 100
+channel.NoteOn channel 4 key 64 velocity 100
+channel.NoteOff channel 2 key 60
+channel.NoteOff channel 3 key 62
+channel.NoteOff channel 4 key 64
+channel.NoteOn channel 2 key 67 velocity 100`},
+	}
+
+	for i, test := range tests {
+		got := messagesString(play(NewAllocator(LowerZone(3), Steal(test.steal)), steps...))
+
+		if got != test.expected {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, test.expected)
+		}
+	}
+}
+
+func TestAllocatorStealSameKey(t *testing.T) {
+	// the stolen note has the same key as a later note: the note off of the stolen note must be swallowed
+	// and the following one must end the later note
+	a := NewAllocator(LowerZone(1))
+
+	got := messagesString(play(a, "on 60", "on 62", "on 60", "off 60", "off 62", "off 60"))
+
+	expected := `channel.NoteOn channel 2 key 60 velocity 100
+channel.NoteOff channel 2 key 60
+channel.NoteOn channel 2 key 62 velocity 100
+channel.NoteOff channel 2 key 62
+channel.NoteOn channel 2 key 60 velocity 100
+channel.NoteOff channel 2 key 60`
+
+	if got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	if len(a.notes) != 0 {
+		t.Errorf("notes still sounding: %v", a.notes)
+	}
+
+	// a zone without members drops all notes
+	if got := play(NewAllocator(LowerZone(0)), "on 60", "off 60"); len(got) != 0 {
+		t.Errorf("got:\n%s\n\nwanted no messages", messagesString(got))
+	}
+}
+
+func TestAllocatorExpression(t *testing.T) {
+	a := NewAllocator(LowerZone(2))
+
+	got := messagesString(play(a,
+		"on 60", "on 64",
+		"bend 60 1000", "press 64 50", "timbre 60 20",
+		"bend 67 100", // not sounding
+		"off 60", "off 64",
+		"on 62", // channel 1 had a pitch bend
+		"on 65", // channel 2 had a pressure
+	))
+
+	expected := `channel.NoteOn channel 2 key 60 velocity 100
+channel.NoteOn channel 3 key 64 velocity 100
+channel.Pitchbend channel 2 value 1000 absValue 0
+channel.Aftertouch channel 3 pressure 50
+channel.ControlChange channel 2 controller 74 ("Sound Brightness") value 20
+channel.NoteOff channel 2 key 60
+channel.NoteOff channel 3 key 64
+channel.Pitchbend channel 2 value 0 absValue 0
+channel.NoteOn channel 2 key 62 velocity 100
+channel.Aftertouch channel 3 pressure 0
+channel.NoteOn channel 3 key 65 velocity 100`
+
+	if got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+// sliceReader reads the given messages
+type sliceReader []midi.Message
+
+func (r *sliceReader) Read() (midi.Message, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	msg := (*r)[0]
+	*r = (*r)[1:]
+	return msg, nil
+}
+
+func readAll(t *testing.T, rd midi.Reader) []midi.Message {
+	var res []midi.Message
+
+	for {
+		msg, err := rd.Read()
+
+		if err != nil {
+			if err != io.EOF {
+				t.Fatalf("Error: %v", err)
+			}
+			return res
+		}
+
+		res = append(res, msg)
+	}
+}
+
+func TestAllocatorReader(t *testing.T) {
+	ch := channel.Channel0
+
+	src := sliceReader{
+		ch.ControlChange(7, 100),
+		ch.NoteOn(60, 100),
+		ch.NoteOn(64, 90),
+		ch.PolyAftertouch(64, 30),
+		ch.Pitchbend(200),
+		realtime.Start,
+		ch.NoteOn(60, 0),
+		ch.NoteOffVelocity(64, 20),
+	}
+
+	got := messagesString(readAll(t, NewAllocator(UpperZone(15)).Reader(&src)))
+
+	expected := `channel.ControlChange channel 16 controller 7 ("Volume (MSB)") value 100
+channel.NoteOn channel 15 key 60 velocity 100
+channel.NoteOn channel 14 key 64 velocity 90
+channel.Aftertouch channel 14 pressure 30
+channel.Pitchbend channel 16 value 200 absValue 0
+Start
+channel.NoteOff channel 15 key 60
+channel.NoteOffVelocity channel 14 key 64 velocity 20`
+
+	if got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	zone := LowerZone(15)
+
+	msgs := []midi.Message{
+		channel.Channel0.ControlChange(7, 100), // manager
+		channel.Channel1.NoteOn(60, 100),
+		channel.Channel2.NoteOn(64, 90),
+		channel.Channel1.Pitchbend(500),  // not the latest note
+		channel.Channel2.Pitchbend(-300), // latest note
+		channel.Channel2.ControlChange(74, 30),
+		channel.Channel1.Aftertouch(40),
+		channel.Channel3.Aftertouch(50), // no sounding notes
+		realtime.Start,
+		channel.Channel2.NoteOff(64),
+		channel.Channel1.Pitchbend(100), // now the latest note
+		channel.Channel3.NoteOn(60, 80), // same key as on channel 1
+		channel.Channel1.NoteOff(60),    // still sounding on channel 3
+		channel.Channel3.NoteOff(60),
+		channel.Channel5.NoteOff(62), // started before
+	}
+
+	expected := `channel.ControlChange channel 10 controller 7 ("Volume (MSB)") value 100
+channel.NoteOn channel 10 key 60 velocity 100
+channel.NoteOn channel 10 key 64 velocity 90
+channel.Pitchbend channel 10 value -300 absValue 0
+channel.PolyAftertouch channel 10 key 60 pressure 40
+Start
+channel.NoteOff channel 10 key 64
+channel.Pitchbend channel 10 value 100 absValue 0
+channel.NoteOn channel 10 key 60 velocity 80
+channel.NoteOff channel 10 key 60
+channel.NoteOff channel 10 key 62`
+
+	if got := messagesString(Collapse(msgs, zone, 9)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	// channels outside of the zone are not touched
+	src := sliceReader{channel.Channel9.NoteOn(60, 100), channel.Channel3.NoteOn(62, 100), channel.Channel0.ProgramChange(3)}
+
+	expected = `channel.NoteOn channel 10 key 60 velocity 100
+channel.NoteOn channel 4 key 62 velocity 100
+channel.ProgramChange channel 1 program 3`
+
+	if got := messagesString(readAll(t, NewCollapser(UpperZone(5), 0).Reader(&src))); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	// a single channel stream with poly aftertouch survives the allocation and the collapsing
+	ch := channel.Channel0
+
+	msgs := []midi.Message{
+		ch.NoteOn(60, 100),
+		ch.NoteOn(64, 90),
+		ch.PolyAftertouch(64, 30),
+		ch.NoteOff(60),
+		ch.NoteOff(64),
+	}
+
+	a := NewAllocator(LowerZone(4))
+	c := NewCollapser(LowerZone(4), 0)
+
+	var res []midi.Message
+
+	for _, msg := range msgs {
+		for _, m := range a.Convert(msg) {
+			res = append(res, c.Convert(m)...)
+		}
+	}
+
+	if got, expected := messagesString(res), messagesString(msgs); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
@@ -0,0 +1,97 @@
+package mpe
+
+import (
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// Zone is a MPE zone: a manager channel and a number of member channels next to it.
+type Zone struct {
+	// Upper is true for the upper zone with the manager channel 15, false for the lower zone with the manager channel 0
+	Upper bool
+
+	// Members is the number of member channels (up to 15). A zone without members is disabled.
+	Members uint8
+}
+
+// LowerZone returns the lower zone with the given number of member channels, i.e. the manager channel 0
+// and the member channels 1 to members
+func LowerZone(members uint8) Zone {
+	return Zone{Members: clampMembers(members)}
+}
+
+// UpperZone returns the upper zone with the given number of member channels, i.e. the manager channel 15
+// and the member channels 14 down to 15-members
+func UpperZone(members uint8) Zone {
+	return Zone{Upper: true, Members: clampMembers(members)}
+}
+
+func clampMembers(members uint8) uint8 {
+	if members > 15 {
+		return 15
+	}
+	return members
+}
+
+// Manager returns the manager channel of the zone
+func (z Zone) Manager() uint8 {
+	if z.Upper {
+		return 15
+	}
+	return 0
+}
+
+// MemberChannels returns the member channels of the zone, starting next to the manager channel
+func (z Zone) MemberChannels() []uint8 {
+	var res []uint8
+	members := clampMembers(z.Members)
+
+	for i := uint8(1); i <= members; i++ {
+		if z.Upper {
+			res = append(res, 15-i)
+		} else {
+			res = append(res, i)
+		}
+	}
+
+	return res
+}
+
+// IsMember returns true, if the given channel is a member channel of the zone
+func (z Zone) IsMember(ch uint8) bool {
+	members := clampMembers(z.Members)
+
+	if z.Upper {
+		return ch < 15 && ch >= 15-members
+	}
+
+	return ch > 0 && ch <= members
+}
+
+// rpn returns the control change messages that set the given registered parameter on the given channel,
+// followed by the null RPN, so that following data entries have no effect
+func rpn(ch, msb, lsb, dataMSB uint8) []channel.ControlChange {
+	c := channel.Channel(ch)
+
+	return []channel.ControlChange{
+		c.ControlChange(101, msb),
+		c.ControlChange(100, lsb),
+		c.ControlChange(6, dataMSB),
+		c.ControlChange(101, 127),
+		c.ControlChange(100, 127),
+	}
+}
+
+// Configuration returns the MPE configuration message (RPN 6) of the zone, to be sent on the manager channel.
+// A receiver sets the pitch bend sensitivity of the member channels to 48 semitones and of the manager channel
+// to 2 semitones, when it receives the message (see PitchBendSensitivity).
+// The configuration of a zone without members disables the zone.
+func (z Zone) Configuration() []channel.ControlChange {
+	return rpn(z.Manager(), 0, 6, clampMembers(z.Members))
+}
+
+// PitchBendSensitivity returns the messages that set the pitch bend sensitivity (RPN 0) of the given channel to
+// the given number of semitones. For a manager channel it applies to the manager channel, for a member channel
+// to all member channels of the zone.
+func PitchBendSensitivity(ch, semitones uint8) []channel.ControlChange {
+	return rpn(ch, 0, 0, semitones)
+}
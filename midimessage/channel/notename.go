@@ -0,0 +1,56 @@
+package channel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var noteSteps = map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+
+var noteNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// ParseNoteNumber returns the key number of the given note name.
+// A note name consists of the letter (C, D, E, F, G, A or B), an optional accidental (# or b)
+// and the octave. Middle C is C4 (key 60), the lowest key is C-1 (key 0) and the highest is G9 (key 127).
+func ParseNoteNumber(name string) (uint8, error) {
+	if len(name) < 2 {
+		return 0, fmt.Errorf("invalid note name %#v", name)
+	}
+
+	step, ok := noteSteps[strings.ToUpper(name[:1])[0]]
+
+	if !ok {
+		return 0, fmt.Errorf("invalid note name %#v", name)
+	}
+
+	rest := name[1:]
+
+	switch rest[0] {
+	case '#':
+		step++
+		rest = rest[1:]
+	case 'b':
+		step--
+		rest = rest[1:]
+	}
+
+	octave, err := strconv.Atoi(rest)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid octave in note name %#v", name)
+	}
+
+	key := (octave+1)*12 + step
+
+	if key < 0 || key > 127 {
+		return 0, fmt.Errorf("note %#v is out of range", name)
+	}
+
+	return uint8(key), nil
+}
+
+// NoteName returns the name of the given key number with sharps, e.g. "C4" for 60 (see ParseNoteNumber).
+func NoteName(key uint8) string {
+	return fmt.Sprintf("%s%d", noteNames[key%12], int(key)/12-1)
+}
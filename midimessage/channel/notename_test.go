@@ -0,0 +1,52 @@
+package channel
+
+import (
+	"testing"
+)
+
+func TestParseNoteNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected uint8
+		err      bool
+	}{
+		{"C4", 60, false},
+		{"c4", 60, false},
+		{"A4", 69, false},
+		{"C#4", 61, false},
+		{"Db4", 61, false},
+		{"Cb4", 59, false},
+		{"B#3", 60, false},
+		{"C-1", 0, false},
+		{"G9", 127, false},
+		{"G#9", 0, true},
+		{"Cb-1", 0, true},
+		{"H4", 0, true},
+		{"C", 0, true},
+		{"Cx4", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseNoteNumber(test.name)
+
+		if test.err {
+			if err == nil {
+				t.Errorf("ParseNoteNumber(%#v) expected error", test.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseNoteNumber(%#v) Error: %v", test.name, err)
+			continue
+		}
+
+		if got != test.expected {
+			t.Errorf("ParseNoteNumber(%#v) = %v; want %v", test.name, got, test.expected)
+		}
+
+		if back, _ := ParseNoteNumber(NoteName(got)); back != got {
+			t.Errorf("ParseNoteNumber(NoteName(%v)) = %v; want %v", got, back, got)
+		}
+	}
+}
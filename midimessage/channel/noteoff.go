@@ -32,9 +32,19 @@ func (n NoteOffVelocity) Raw() []byte {
 	return channelMessage2(n.channel, 8, n.key, n.velocity)
 }
 
+// EncodedLen returns the number of bytes of the message including the status byte (see Raw), without encoding it
+func (n NoteOffVelocity) EncodedLen() int {
+	return 3
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the channel is beyond 15 or a data byte is beyond 127
+func (n NoteOffVelocity) Validate() error {
+	return validate(n, n.channel, n.key, n.velocity)
+}
+
 // String returns human readable information about the note-off message that includes velocity.
 func (n NoteOffVelocity) String() string {
-	return fmt.Sprintf("%T channel %v key %v velocity %v", n, n.Channel(), n.Key(), n.Velocity())
+	return fmt.Sprintf("%T channel %v key %v velocity %v", n, n.DisplayChannel(), n.Key(), n.Velocity())
 }
 
 // NoteOff represents a note-off message by a note-on message with velocity of 0 (helps for running status).
@@ -56,14 +66,29 @@ func (n NoteOff) Raw() []byte {
 	return channelMessage2(n.channel, 9, n.key, 0)
 }
 
-// Channel returns the channel of the note-off message
+// EncodedLen returns the number of bytes of the message including the status byte (see Raw), without encoding it
+func (n NoteOff) EncodedLen() int {
+	return 3
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the channel is beyond 15 or a data byte is beyond 127
+func (n NoteOff) Validate() error {
+	return validate(n, n.channel, n.key)
+}
+
+// Channel returns the channel of the note-off message on the wire (0-15)
 func (n NoteOff) Channel() uint8 {
 	return n.channel
 }
 
+// DisplayChannel returns the channel of the note-off message as it is displayed to users (1-16)
+func (n NoteOff) DisplayChannel() uint8 {
+	return n.channel + 1
+}
+
 // String returns human readable information about the note-off message.
 func (n NoteOff) String() string {
-	return fmt.Sprintf("%T channel %v key %v", n, n.Channel(), n.Key())
+	return fmt.Sprintf("%T channel %v key %v", n, n.DisplayChannel(), n.Key())
 }
 
 // set returns a new note-off message that is set to the parsed arguments
@@ -22,19 +22,34 @@ func (n NoteOn) Velocity() uint8 {
 	return n.velocity
 }
 
-// Channel returns the channel of the note-on message
+// Channel returns the channel of the note-on message on the wire (0-15)
 func (n NoteOn) Channel() uint8 {
 	return n.channel
 }
 
+// DisplayChannel returns the channel of the note-on message as it is displayed to users (1-16)
+func (n NoteOn) DisplayChannel() uint8 {
+	return n.channel + 1
+}
+
 // Raw returns the bytes for the noteon message.
 func (n NoteOn) Raw() []byte {
 	return channelMessage2(n.channel, 9, n.key, n.velocity)
 }
 
+// EncodedLen returns the number of bytes of the message including the status byte (see Raw), without encoding it
+func (n NoteOn) EncodedLen() int {
+	return 3
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the channel is beyond 15 or a data byte is beyond 127
+func (n NoteOn) Validate() error {
+	return validate(n, n.channel, n.key, n.velocity)
+}
+
 // String returns human readable information about the note-on message.
 func (n NoteOn) String() string {
-	return fmt.Sprintf("%T channel %v key %v velocity %v", n, n.Channel(), n.Key(), n.Velocity())
+	return fmt.Sprintf("%T channel %v key %v velocity %v", n, n.DisplayChannel(), n.Key(), n.Velocity())
 }
 
 // set returns a new note-on message that is set to the parsed arguments
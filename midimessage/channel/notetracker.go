@@ -0,0 +1,79 @@
+package channel
+
+// NoteTracker keeps track of the notes that are currently sounding on each MIDI channel.
+// Multiple note on messages for the same key are counted, so that the key is active until
+// the same number of note off messages have been tracked.
+// The zero value is ready to use.
+type NoteTracker struct {
+	active [16][128]uint8
+}
+
+// Track passes the given message to the NoteTracker. Note on messages activate the key,
+// note off messages (and note on messages with velocity 0) deactivate it. Other messages are ignored.
+func (t *NoteTracker) Track(msg Message) {
+	ch, key := msg.Channel(), uint8(0)
+
+	switch v := msg.(type) {
+	case NoteOn:
+		key = v.Key()
+		if ch > 15 || key > 127 {
+			return
+		}
+		if v.Velocity() > 0 {
+			if t.active[ch][key] < 255 {
+				t.active[ch][key]++
+			}
+			return
+		}
+	case NoteOff:
+		key = v.Key()
+	case NoteOffVelocity:
+		key = v.Key()
+	default:
+		return
+	}
+
+	if ch > 15 || key > 127 {
+		return
+	}
+
+	if t.active[ch][key] > 0 {
+		t.active[ch][key]--
+	}
+}
+
+// IsActive returns true, if the given key is active on the given channel
+func (t *NoteTracker) IsActive(ch, key uint8) bool {
+	if ch > 15 || key > 127 {
+		return false
+	}
+	return t.active[ch][key] > 0
+}
+
+// Count returns the number of note on messages of the given key on the given channel that have not been ended
+func (t *NoteTracker) Count(ch, key uint8) uint8 {
+	if ch > 15 || key > 127 {
+		return 0
+	}
+	return t.active[ch][key]
+}
+
+// Active returns the active keys of the given channel in ascending order
+func (t *NoteTracker) Active(ch uint8) (keys []uint8) {
+	if ch > 15 {
+		return nil
+	}
+
+	for key, n := range t.active[ch] {
+		if n > 0 {
+			keys = append(keys, uint8(key))
+		}
+	}
+
+	return
+}
+
+// Reset deactivates all keys on all channels
+func (t *NoteTracker) Reset() {
+	t.active = [16][128]uint8{}
+}
@@ -0,0 +1,88 @@
+package channel
+
+// controllers used by Panic
+const (
+	ccSustain     = 64
+	ccAllSoundOff = 120
+	ccAllNotesOff = 123
+)
+
+type panicConfig struct {
+	channels       []uint8
+	resetPitchbend bool
+	tracker        *NoteTracker
+}
+
+// PanicOption is an option for Panic
+type PanicOption func(*panicConfig)
+
+// PanicChannels restricts the panic messages to the given channels (0-15). By default all 16 channels are included.
+func PanicChannels(channels ...uint8) PanicOption {
+	return func(c *panicConfig) {
+		c.channels = channels
+	}
+}
+
+// PanicResetPitchbend adds a pitch bend message with the center value for each channel.
+func PanicResetPitchbend() PanicOption {
+	return func(c *panicConfig) {
+		c.resetPitchbend = true
+	}
+}
+
+// PanicNoteOffs adds a note off message for each key that is active on the channel according to the given NoteTracker.
+// This is useful for instruments that ignore the all notes off message.
+func PanicNoteOffs(tracker *NoteTracker) PanicOption {
+	return func(c *panicConfig) {
+		c.tracker = tracker
+	}
+}
+
+// Panic returns the messages that silence the channels. For each channel, the following messages are returned
+// in this order:
+//
+//	control change 123 (all notes off)
+//	control change 120 (all sound off)
+//	control change 64 with value 0 (sustain off)
+//	pitch bend 0 (only with PanicResetPitchbend)
+//	note off messages for the active keys (only with PanicNoteOffs)
+func Panic(options ...PanicOption) []Message {
+	var c panicConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	if c.channels == nil {
+		for ch := uint8(0); ch < 16; ch++ {
+			c.channels = append(c.channels, ch)
+		}
+	}
+
+	var msgs []Message
+
+	for _, ch := range c.channels {
+		if ch > 15 {
+			continue
+		}
+
+		cha := Channel(ch)
+		msgs = append(msgs,
+			cha.ControlChange(ccAllNotesOff, 0),
+			cha.ControlChange(ccAllSoundOff, 0),
+			cha.ControlChange(ccSustain, 0),
+		)
+
+		if c.resetPitchbend {
+			msgs = append(msgs, cha.Pitchbend(0))
+		}
+
+		if c.tracker != nil {
+			for _, key := range c.tracker.Active(ch) {
+				msgs = append(msgs, cha.NoteOff(key))
+			}
+		}
+	}
+
+	return msgs
+}
@@ -0,0 +1,84 @@
+package channel
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func panicString(msgs []Message) string {
+	var bf bytes.Buffer
+
+	for _, msg := range msgs {
+		fmt.Fprintf(&bf, "% X\n", msg.Raw())
+	}
+
+	return bf.String()
+}
+
+func TestPanic(t *testing.T) {
+	msgs := Panic()
+
+	if got, want := len(msgs), 16*3; got != want {
+		t.Fatalf("len(Panic()) = %v; want %v", got, want)
+	}
+
+	if got, want := panicString(msgs[:6]), "B0 7B 00\nB0 78 00\nB0 40 00\nB1 7B 00\nB1 78 00\nB1 40 00\n"; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestPanicOptions(t *testing.T) {
+	var tr NoteTracker
+	tr.Track(Channel2.NoteOn(64, 100))
+	tr.Track(Channel2.NoteOn(60, 100))
+	tr.Track(Channel2.NoteOn(60, 100))
+	tr.Track(Channel2.NoteOn(62, 100))
+	tr.Track(Channel2.NoteOff(62))
+	tr.Track(Channel2.NoteOn(60, 0))
+	tr.Track(Channel3.NoteOn(50, 100))
+	tr.Track(Channel3.NoteOffVelocity(50, 20))
+	tr.Track(Channel5.NoteOn(70, 100))
+
+	msgs := Panic(PanicChannels(2, 3), PanicResetPitchbend(), PanicNoteOffs(&tr))
+
+	expected := `B2 7B 00
+B2 78 00
+B2 40 00
+E2 00 40
+92 3C 00
+92 40 00
+B3 7B 00
+B3 78 00
+B3 40 00
+E3 00 40
+`
+
+	if got, want := panicString(msgs), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestNoteTracker(t *testing.T) {
+	var tr NoteTracker
+	tr.Track(Channel0.NoteOn(60, 100))
+	tr.Track(Channel0.NoteOn(60, 100))
+	tr.Track(Channel0.NoteOff(60))
+
+	if !tr.IsActive(0, 60) {
+		t.Errorf("IsActive(0, 60) = false; want true")
+	}
+
+	tr.Track(Channel0.NoteOff(60))
+
+	if tr.IsActive(0, 60) {
+		t.Errorf("IsActive(0, 60) = true; want false")
+	}
+
+	tr.Track(Channel1.NoteOn(61, 100))
+	tr.Reset()
+
+	if got := tr.Active(1); len(got) != 0 {
+		t.Errorf("Active(1) = %v; want []", got)
+	}
+}
@@ -51,14 +51,29 @@ func (p Pitchbend) AbsValue() uint16 {
 	return p.absValue
 }
 
-// Channel returns the MIDI channel (starting with 0)
+// Channel returns the channel of the pitchbend message on the wire (0-15)
 func (p Pitchbend) Channel() uint8 {
 	return p.channel
 }
 
-// Raw returns the raw bytes for the message
+// DisplayChannel returns the channel of the pitchbend message as it is displayed to users (1-16)
+func (p Pitchbend) DisplayChannel() uint8 {
+	return p.channel + 1
+}
+
+// Raw returns the raw bytes for the message.
+// Values beyond the range of PitchLowest to PitchHighest are clamped to it.
 func (p Pitchbend) Raw() []byte {
-	r := midilib.MsbLsbSigned(p.value)
+	v := p.value
+
+	switch {
+	case v < PitchLowest:
+		v = PitchLowest
+	case v > PitchHighest:
+		v = PitchHighest
+	}
+
+	r := midilib.MsbLsbSigned(v)
 
 	var b = make([]byte, 2)
 
@@ -66,9 +81,23 @@ func (p Pitchbend) Raw() []byte {
 	return channelMessage2(p.channel, 14, b[0], b[1])
 }
 
+// EncodedLen returns the number of bytes of the message including the status byte (see Raw), without encoding it
+func (p Pitchbend) EncodedLen() int {
+	return 3
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the channel is beyond 15 or the value is beyond
+// the range of PitchLowest to PitchHighest
+func (p Pitchbend) Validate() error {
+	if p.value < PitchLowest || p.value > PitchHighest {
+		return invalid(p, "pitch bend value %v out of range %v to %v", p.value, PitchLowest, PitchHighest)
+	}
+	return validate(p, p.channel)
+}
+
 // String represents the MIDI pitch bend message as a string (for debugging)
 func (p Pitchbend) String() string {
-	return fmt.Sprintf("%T channel %v value %v absValue %v", p, p.Channel(), p.Value(), p.AbsValue())
+	return fmt.Sprintf("%T channel %v value %v absValue %v", p, p.DisplayChannel(), p.Value(), p.AbsValue())
 }
 
 func (Pitchbend) set(channel uint8, firstArg, secondArg uint8) setter2 {
@@ -23,14 +23,19 @@ func (p PolyAftertouch) Pressure() uint8 {
 	return p.pressure
 }
 
-// Channel returns the channel of the polyphonic aftertouch message
+// Channel returns the channel of the polyphonic aftertouch message on the wire (0-15)
 func (p PolyAftertouch) Channel() uint8 {
 	return p.channel
 }
 
+// DisplayChannel returns the channel of the polyphonic aftertouch message as it is displayed to users (1-16)
+func (p PolyAftertouch) DisplayChannel() uint8 {
+	return p.channel + 1
+}
+
 // String returns human readable information about the polyphonic aftertouch message.
 func (p PolyAftertouch) String() string {
-	return fmt.Sprintf("%T channel %v key %v pressure %v", p, p.Channel(), p.Key(), p.Pressure())
+	return fmt.Sprintf("%T channel %v key %v pressure %v", p, p.DisplayChannel(), p.Key(), p.Pressure())
 }
 
 // Raw returns the raw bytes of the polyphonic aftertouch message.
@@ -38,6 +43,16 @@ func (p PolyAftertouch) Raw() []byte {
 	return channelMessage2(p.channel, 10, p.key, p.pressure)
 }
 
+// EncodedLen returns the number of bytes of the message including the status byte (see Raw), without encoding it
+func (p PolyAftertouch) EncodedLen() int {
+	return 3
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the channel is beyond 15 or a data byte is beyond 127
+func (p PolyAftertouch) Validate() error {
+	return validate(p, p.channel, p.key, p.pressure)
+}
+
 // set returns a new polyphonic aftertouch message that is set to the parsed arguments
 func (PolyAftertouch) set(channel uint8, arg1, arg2 uint8) setter2 {
 	var m PolyAftertouch
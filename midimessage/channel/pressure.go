@@ -0,0 +1,201 @@
+package channel
+
+import (
+	"github.com/gomidi/midi"
+)
+
+// Reduce is the way a PressureConverter combines the polyphonic aftertouch of the sounding keys of a channel
+// into a channel aftertouch
+type Reduce int
+
+const (
+	// ReduceMax takes the highest pressure (default)
+	ReduceMax Reduce = iota
+
+	// ReduceMean takes the rounded mean of the pressures
+	ReduceMean
+
+	// ReduceLatest takes the pressure of the key that has been pressed most recently
+	ReduceLatest
+)
+
+// PressureConverter converts polyphonic aftertouch messages to channel aftertouch messages or vice versa,
+// message by message, so that it can be used for live streams (see Reader) as well as for the messages of files.
+// It keeps track of the sounding notes and must therefore be passed all messages of the stream.
+type PressureConverter struct {
+	toChannel bool
+	reduce    Reduce
+	notes     *NoteTracker
+
+	// pressure is the current polyphonic aftertouch of each key, 0 if none
+	pressure [16][128]uint8
+
+	// pressed is the number of the polyphonic aftertouch message that has set the pressure
+	pressed [16][128]uint64
+	counter uint64
+
+	// sent is the last channel aftertouch of each channel that has been passed on
+	sent [16]uint8
+}
+
+// NewPolyToChannelPressure returns a PressureConverter that replaces the polyphonic aftertouch messages by channel
+// aftertouch messages, for receivers that only understand the latter.
+// The pressures of the sounding keys of a channel are combined according to reduce, and a channel aftertouch
+// message is passed on, whenever the combined pressure changes, including the release of a pressed key.
+// Polyphonic aftertouch messages of keys that are not sounding are dropped.
+func NewPolyToChannelPressure(reduce Reduce) *PressureConverter {
+	return &PressureConverter{toChannel: true, reduce: reduce, notes: &NoteTracker{}}
+}
+
+// NewChannelToPolyPressure returns a PressureConverter that replaces the channel aftertouch messages by polyphonic
+// aftertouch messages of the same pressure for each key that is sounding on the channel, according to the given
+// NoteTracker. Channel aftertouch messages of channels without sounding keys are dropped.
+// The note messages are passed to the NoteTracker by Convert. If notes is nil, a new NoteTracker is used.
+func NewChannelToPolyPressure(notes *NoteTracker) *PressureConverter {
+	if notes == nil {
+		notes = &NoteTracker{}
+	}
+	return &PressureConverter{notes: notes}
+}
+
+// Convert returns the messages that replace the given message. Messages that are not converted are returned as they are.
+func (c *PressureConverter) Convert(msg midi.Message) []midi.Message {
+	m, ok := msg.(Message)
+
+	if !ok || m.Channel() > 15 {
+		return []midi.Message{msg}
+	}
+
+	ch := m.Channel()
+	c.notes.Track(m)
+
+	if !c.toChannel {
+		at, is := m.(Aftertouch)
+		if !is {
+			return []midi.Message{msg}
+		}
+
+		var res []midi.Message
+
+		for _, key := range c.notes.Active(ch) {
+			res = append(res, Channel(ch).PolyAftertouch(key, at.Pressure()))
+		}
+
+		return res
+	}
+
+	res := []midi.Message{msg}
+
+	switch v := m.(type) {
+	case PolyAftertouch:
+		res = nil
+
+		if v.Key() > 127 || !c.notes.IsActive(ch, v.Key()) {
+			return nil
+		}
+
+		c.counter++
+		c.pressure[ch][v.Key()] = v.Pressure()
+		c.pressed[ch][v.Key()] = c.counter
+	case NoteOn, NoteOff, NoteOffVelocity:
+		// the pressure of a key ends with its last note
+		for key := range c.pressure[ch] {
+			if c.pressure[ch][key] > 0 && !c.notes.IsActive(ch, uint8(key)) {
+				c.pressure[ch][key] = 0
+			}
+		}
+	default:
+		return res
+	}
+
+	if p := c.reduced(ch); p != c.sent[ch] {
+		c.sent[ch] = p
+		res = append(res, Channel(ch).Aftertouch(p))
+	}
+
+	return res
+}
+
+// reduced returns the combined pressure of the keys of the given channel
+func (c *PressureConverter) reduced(ch uint8) uint8 {
+	var max, sum, n, latest uint64
+	var latestPressure uint8
+
+	for key, p := range c.pressure[ch] {
+		if p == 0 {
+			continue
+		}
+
+		n++
+		sum += uint64(p)
+
+		if uint64(p) > max {
+			max = uint64(p)
+		}
+
+		if c.pressed[ch][key] > latest {
+			latest = c.pressed[ch][key]
+			latestPressure = p
+		}
+	}
+
+	switch {
+	case n == 0:
+		return 0
+	case c.reduce == ReduceMean:
+		return uint8((sum + n/2) / n)
+	case c.reduce == ReduceLatest:
+		return latestPressure
+	default:
+		return uint8(max)
+	}
+}
+
+// Reader returns a midi.Reader that converts the messages read from rd
+func (c *PressureConverter) Reader(rd midi.Reader) midi.Reader {
+	return &pressureReader{Reader: rd, converter: c}
+}
+
+type pressureReader struct {
+	midi.Reader
+	converter *PressureConverter
+	pending   []midi.Message
+}
+
+// Read returns the next converted message
+func (r *pressureReader) Read() (midi.Message, error) {
+	for len(r.pending) == 0 {
+		msg, err := r.Reader.Read()
+
+		if err != nil {
+			return msg, err
+		}
+
+		r.pending = r.converter.Convert(msg)
+	}
+
+	msg := r.pending[0]
+	r.pending = r.pending[1:]
+	return msg, nil
+}
+
+// PolyToChannelPressure returns the given messages with the polyphonic aftertouch messages replaced by channel
+// aftertouch messages (see NewPolyToChannelPressure).
+func PolyToChannelPressure(msgs []midi.Message, reduce Reduce) []midi.Message {
+	return convertAll(NewPolyToChannelPressure(reduce), msgs)
+}
+
+// ChannelToPolyPressure returns the given messages with the channel aftertouch messages replaced by polyphonic
+// aftertouch messages for the sounding keys (see NewChannelToPolyPressure).
+// The note messages are passed to the given NoteTracker that may contain the notes that are sounding before the
+// messages. If active is nil, no notes are sounding before the messages.
+func ChannelToPolyPressure(msgs []midi.Message, active *NoteTracker) []midi.Message {
+	return convertAll(NewChannelToPolyPressure(active), msgs)
+}
+
+func convertAll(c *PressureConverter, msgs []midi.Message) (res []midi.Message) {
+	for _, msg := range msgs {
+		res = append(res, c.Convert(msg)...)
+	}
+	return
+}
@@ -0,0 +1,152 @@
+package channel
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/realtime"
+)
+
+func messagesString(msgs []midi.Message) string {
+	var s []string
+	for _, msg := range msgs {
+		s = append(s, msg.String())
+	}
+	return strings.Join(s, "\n")
+}
+
+func TestPolyToChannelPressure(t *testing.T) {
+	ch := Channel0
+
+	msgs := []midi.Message{
+		ch.NoteOn(60, 100),
+		ch.NoteOn(64, 100),
+		ch.PolyAftertouch(60, 40),
+		ch.PolyAftertouch(64, 80),
+		ch.PolyAftertouch(60, 50),
+		ch.NoteOff(64),
+		ch.PolyAftertouch(67, 90), // silent key
+		realtime.Start,
+		Channel1.PolyAftertouch(60, 30), // silent channel
+		ch.NoteOff(60),
+	}
+
+	tests := []struct {
+		reduce   Reduce
+		expected string
+	}{
+		{ReduceMax, `channel.NoteOn channel 1 key 60 velocity 100
+channel.NoteOn channel 1 key 64 velocity 100
+channel.Aftertouch channel 1 pressure 40
+channel.Aftertouch channel 1 pressure 80
+channel.NoteOff channel 1 key 64
+channel.Aftertouch channel 1 pressure 50
+Start
+channel.NoteOff channel 1 key 60
+channel.Aftertouch channel 1 pressure 0`},
+		{ReduceMean, `channel.NoteOn channel 1 key 60 velocity 100
+channel.NoteOn channel 1 key 64 velocity 100
+channel.Aftertouch channel 1 pressure 40
+channel.Aftertouch channel 1 pressure 60
+channel.Aftertouch channel 1 pressure 65
+channel.NoteOff channel 1 key 64
+channel.Aftertouch channel 1 pressure 50
+Start
+channel.NoteOff channel 1 key 60
+channel.Aftertouch channel 1 pressure 0`},
+		{ReduceLatest, `channel.NoteOn channel 1 key 60 velocity 100
+channel.NoteOn channel 1 key 64 velocity 100
+channel.Aftertouch channel 1 pressure 40
+channel.Aftertouch channel 1 pressure 80
+channel.Aftertouch channel 1 pressure 50
+channel.NoteOff channel 1 key 64
+Start
+channel.NoteOff channel 1 key 60
+channel.Aftertouch channel 1 pressure 0`},
+	}
+
+	for i, test := range tests {
+		if got := messagesString(PolyToChannelPressure(msgs, test.reduce)); got != test.expected {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, test.expected)
+		}
+	}
+}
+
+func TestChannelToPolyPressure(t *testing.T) {
+	ch := Channel0
+
+	msgs := []midi.Message{
+		ch.Aftertouch(10), // no sounding keys yet
+		ch.NoteOn(64, 100),
+		ch.NoteOn(60, 100),
+		Channel1.Aftertouch(20), // silent channel
+		ch.Aftertouch(30),
+		ch.NoteOff(64),
+		ch.Aftertouch(40),
+	}
+
+	expected := `channel.NoteOn channel 1 key 64 velocity 100
+channel.NoteOn channel 1 key 60 velocity 100
+channel.PolyAftertouch channel 1 key 60 pressure 30
+channel.PolyAftertouch channel 1 key 64 pressure 30
+channel.NoteOff channel 1 key 64
+channel.PolyAftertouch channel 1 key 60 pressure 40`
+
+	if got := messagesString(ChannelToPolyPressure(msgs, nil)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	// notes that are sounding before the messages
+	var active NoteTracker
+	active.Track(Channel1.NoteOn(48, 100))
+
+	expected = `channel.PolyAftertouch channel 2 key 48 pressure 20`
+
+	if got := messagesString(ChannelToPolyPressure([]midi.Message{Channel1.Aftertouch(20)}, &active)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+// sliceReader reads the given messages
+type sliceReader []midi.Message
+
+func (r *sliceReader) Read() (midi.Message, error) {
+	if len(*r) == 0 {
+		return nil, io.EOF
+	}
+	msg := (*r)[0]
+	*r = (*r)[1:]
+	return msg, nil
+}
+
+func TestPressureConverterReader(t *testing.T) {
+	src := sliceReader{Channel0.NoteOn(60, 100), Channel0.NoteOn(64, 100), Channel0.PolyAftertouch(60, 30), Channel0.Aftertouch(50)}
+	rd := NewChannelToPolyPressure(nil).Reader(&src)
+
+	var got []midi.Message
+
+	for {
+		msg, err := rd.Read()
+
+		if err != nil {
+			if err != io.EOF {
+				t.Fatalf("Error: %v", err)
+			}
+			break
+		}
+
+		got = append(got, msg)
+	}
+
+	expected := `channel.NoteOn channel 1 key 60 velocity 100
+channel.NoteOn channel 1 key 64 velocity 100
+channel.PolyAftertouch channel 1 key 60 pressure 30
+channel.PolyAftertouch channel 1 key 60 pressure 50
+channel.PolyAftertouch channel 1 key 64 pressure 50`
+
+	if got := messagesString(got); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
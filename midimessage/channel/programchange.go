@@ -16,19 +16,34 @@ func (p ProgramChange) Program() uint8 {
 	return p.program
 }
 
-// Channel returns the channel of the program change message.
+// Channel returns the channel of the program change message on the wire (0-15)
 func (p ProgramChange) Channel() uint8 {
 	return p.channel
 }
 
+// DisplayChannel returns the channel of the program change message as it is displayed to users (1-16)
+func (p ProgramChange) DisplayChannel() uint8 {
+	return p.channel + 1
+}
+
 // Raw returns the raw bytes of the program change message.
 func (p ProgramChange) Raw() []byte {
 	return channelMessage1(p.channel, 12, p.program)
 }
 
+// EncodedLen returns the number of bytes of the message including the status byte (see Raw), without encoding it
+func (p ProgramChange) EncodedLen() int {
+	return 2
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the channel is beyond 15 or a data byte is beyond 127
+func (p ProgramChange) Validate() error {
+	return validate(p, p.channel, p.program)
+}
+
 // String returns human readable information about the program change message.
 func (p ProgramChange) String() string {
-	return fmt.Sprintf("%T channel %v program %v", p, p.Channel(), p.Program())
+	return fmt.Sprintf("%T channel %v program %v", p, p.DisplayChannel(), p.Program())
 }
 
 // set returns a new program change message that is set to the parsed arguments
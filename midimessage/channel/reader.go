@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/gomidi/midi"
 	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/midimessage/status"
 )
 
 const (
@@ -21,6 +23,7 @@ const (
 type Reader interface {
 	// Read reads a single channel message.
 	// It may just be called once per Reader. A second call returns io.EOF
+	// If the input ends before the second argument, midi.ErrUnexpectedEOF is returned.
 	Read(status, arg1 byte) (Message, error)
 }
 
@@ -57,8 +60,8 @@ type reader struct {
 }
 
 // Read reads a channel message
-func (r *reader) Read(status byte, arg1 byte) (msg Message, err error) {
-	typ, channel := midilib.ParseStatus(status)
+func (r *reader) Read(statusByte byte, arg1 byte) (msg Message, err error) {
+	typ, channel := status.Split(statusByte)
 
 	// fmt.Printf("typ: %v channel: %v\n", typ, channel)
 
@@ -79,6 +82,11 @@ func (r *reader) Read(status byte, arg1 byte) (msg Message, err error) {
 		var arg2 byte
 		arg2, err = midilib.ReadByte(r.input)
 
+		// the status and the first argument have been read, so the message is cut off
+		if err == io.EOF {
+			err = midi.ErrUnexpectedEOF
+		}
+
 		if err != nil {
 			return
 		}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gomidi/midi/internal/midilib"
 	// "fmt"
+	"errors"
 	"io"
 	"testing"
 
@@ -48,9 +49,9 @@ func mkTest(event midi.Message, expected string) *readTest {
 func TestReadNormalNoteOff(t *testing.T) {
 
 	tests := []*readTest{
-		mkTest(channel.Channel1.NoteOn(65, 100), "channel.NoteOn channel 1 key 65 velocity 100"),
-		mkTest(channel.Channel9.NoteOff(100), "channel.NoteOff channel 9 key 100"),
-		mkTest(channel.Channel9.NoteOffVelocity(120, 64), "channel.NoteOff channel 9 key 120"),
+		mkTest(channel.Channel1.NoteOn(65, 100), "channel.NoteOn channel 2 key 65 velocity 100"),
+		mkTest(channel.Channel9.NoteOff(100), "channel.NoteOff channel 10 key 100"),
+		mkTest(channel.Channel9.NoteOffVelocity(120, 64), "channel.NoteOff channel 10 key 120"),
 	}
 
 	for n, test := range tests {
@@ -84,14 +85,14 @@ func TestReadNormalNoteOff(t *testing.T) {
 func TestRead(t *testing.T) {
 
 	tests := []*readTest{
-		mkTest(channel.Channel1.NoteOn(65, 100), "channel.NoteOn channel 1 key 65 velocity 100"),
-		mkTest(channel.Channel9.NoteOff(100), "channel.NoteOff channel 9 key 100"),
-		mkTest(channel.Channel9.NoteOffVelocity(120, 64), "channel.NoteOffVelocity channel 9 key 120 velocity 64"),
-		mkTest(channel.Channel8.ProgramChange(3), "channel.ProgramChange channel 8 program 3"),
-		mkTest(channel.Channel8.Aftertouch(30), "channel.Aftertouch channel 8 pressure 30"),
-		mkTest(channel.Channel3.ControlChange(23, 25), "channel.ControlChange channel 3 controller 23 value 25"),
-		mkTest(channel.Channel0.Pitchbend(123), "channel.Pitchbend channel 0 value 123 absValue 8315"),
-		mkTest(channel.Channel15.PolyAftertouch(120, 106), "channel.PolyAftertouch channel 15 key 120 pressure 106"),
+		mkTest(channel.Channel1.NoteOn(65, 100), "channel.NoteOn channel 2 key 65 velocity 100"),
+		mkTest(channel.Channel9.NoteOff(100), "channel.NoteOff channel 10 key 100"),
+		mkTest(channel.Channel9.NoteOffVelocity(120, 64), "channel.NoteOffVelocity channel 10 key 120 velocity 64"),
+		mkTest(channel.Channel8.ProgramChange(3), "channel.ProgramChange channel 9 program 3"),
+		mkTest(channel.Channel8.Aftertouch(30), "channel.Aftertouch channel 9 pressure 30"),
+		mkTest(channel.Channel3.ControlChange(23, 25), "channel.ControlChange channel 4 controller 23 value 25"),
+		mkTest(channel.Channel0.Pitchbend(123), "channel.Pitchbend channel 1 value 123 absValue 8315"),
+		mkTest(channel.Channel15.PolyAftertouch(120, 106), "channel.PolyAftertouch channel 16 key 120 pressure 106"),
 	}
 
 	for n, test := range tests {
@@ -121,3 +122,14 @@ func TestRead(t *testing.T) {
 	}
 
 }
+
+func TestReadTruncated(t *testing.T) {
+	// the messages with two data bytes, cut after the first one
+	for _, status := range []byte{0x81, 0x92, 0xA3, 0xB4, 0xE5} {
+		_, err := channel.NewReader(bytes.NewReader(nil)).Read(status, 0x40)
+
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Read(% X, 40) without second data byte = %v; want io.ErrUnexpectedEOF", status, err)
+		}
+	}
+}
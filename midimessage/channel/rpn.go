@@ -0,0 +1,89 @@
+package channel
+
+// controllers that are involved in the selection and data entry of (non) registered parameters
+const (
+	ccDataEntryMSB = 6
+	ccDataEntryLSB = 38
+	ccNRPNLSB      = 98
+	ccNRPNMSB      = 99
+	ccRPNLSB       = 100
+	ccRPNMSB       = 101
+)
+
+// RPN is the data entry for a registered parameter number (RPN).
+type RPN struct {
+	// Channel is the MIDI channel of the data entry
+	Channel uint8
+
+	// MSB and LSB are the parameter number (CC 101 and CC 100).
+	// E.g. the pitch bend sensitivity has MSB 0 and LSB 0.
+	MSB, LSB uint8
+
+	// DataMSB and DataLSB are the values of the data entry (CC 6 and CC 38).
+	// For the pitch bend sensitivity DataMSB is the number of semitones and DataLSB the number of cents.
+	DataMSB, DataLSB uint8
+}
+
+// IsPitchBendSensitivity returns true, if the RPN is the pitch bend sensitivity (RPN 0,0)
+func (r RPN) IsPitchBendSensitivity() bool {
+	return r.MSB == 0 && r.LSB == 0
+}
+
+// Semitones returns the data entry as semitones, where DataMSB are the semitones and DataLSB the cents.
+func (r RPN) Semitones() float64 {
+	return float64(r.DataMSB) + float64(r.DataLSB)/100
+}
+
+type rpnState struct {
+	msb, lsb         uint8
+	dataMSB, dataLSB uint8
+	// selected is false, if no RPN is selected, a NRPN is selected or the RPN was reset via the null RPN (127,127)
+	selected bool
+}
+
+// RPNReader keeps track of the registered parameter numbers (RPN) that are selected
+// via control change messages on each MIDI channel and returns the corresponding data entries.
+// The zero value is ready to use.
+type RPNReader struct {
+	channels [16]rpnState
+}
+
+// Read passes the given message to the RPNReader. If the message is a data entry
+// for a registered parameter, it is returned and ok is true.
+// Data entries for non registered parameters (NRPN) and data entries after the
+// null RPN (127,127) are ignored.
+func (r *RPNReader) Read(msg Message) (rpn RPN, ok bool) {
+	cc, is := msg.(ControlChange)
+
+	if !is || cc.channel > 15 {
+		return
+	}
+
+	st := &r.channels[cc.channel]
+
+	switch cc.controller {
+	case ccRPNMSB:
+		st.msb = cc.value
+		st.selected = !(st.msb == 127 && st.lsb == 127)
+	case ccRPNLSB:
+		st.lsb = cc.value
+		st.selected = !(st.msb == 127 && st.lsb == 127)
+	case ccNRPNMSB, ccNRPNLSB:
+		st.selected = false
+	case ccDataEntryMSB:
+		if !st.selected {
+			return
+		}
+		st.dataMSB = cc.value
+		st.dataLSB = 0
+		return RPN{Channel: cc.channel, MSB: st.msb, LSB: st.lsb, DataMSB: st.dataMSB}, true
+	case ccDataEntryLSB:
+		if !st.selected {
+			return
+		}
+		st.dataLSB = cc.value
+		return RPN{Channel: cc.channel, MSB: st.msb, LSB: st.lsb, DataMSB: st.dataMSB, DataLSB: st.dataLSB}, true
+	}
+
+	return
+}
@@ -0,0 +1,98 @@
+package channel
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRPNReader(t *testing.T) {
+	tests := []struct {
+		input    []Message
+		expected string
+	}{
+		{
+			[]Message{
+				Channel1.ControlChange(101, 0),
+				Channel1.ControlChange(100, 0),
+				Channel1.ControlChange(6, 12),
+			},
+			"[{1 0 0 12 0}]",
+		},
+		{
+			[]Message{
+				Channel1.ControlChange(101, 0),
+				Channel1.ControlChange(100, 0),
+				Channel1.ControlChange(6, 1),
+				Channel1.ControlChange(38, 50),
+			},
+			"[{1 0 0 1 0} {1 0 0 1 50}]",
+		},
+		{
+			// other channel has no selection
+			[]Message{
+				Channel1.ControlChange(101, 0),
+				Channel1.ControlChange(100, 0),
+				Channel2.ControlChange(6, 1),
+			},
+			"[]",
+		},
+		{
+			// NRPN deselects
+			[]Message{
+				Channel1.ControlChange(101, 0),
+				Channel1.ControlChange(100, 0),
+				Channel1.ControlChange(99, 3),
+				Channel1.ControlChange(6, 1),
+			},
+			"[]",
+		},
+		{
+			// null RPN deselects
+			[]Message{
+				Channel1.ControlChange(101, 0),
+				Channel1.ControlChange(100, 0),
+				Channel1.ControlChange(101, 127),
+				Channel1.ControlChange(100, 127),
+				Channel1.ControlChange(6, 1),
+			},
+			"[]",
+		},
+		{
+			// non control change messages are ignored
+			[]Message{
+				Channel1.ControlChange(101, 0),
+				Channel1.NoteOn(60, 100),
+				Channel1.ControlChange(100, 2),
+				Channel1.ControlChange(6, 64),
+			},
+			"[{1 0 2 64 0}]",
+		},
+	}
+
+	for i, test := range tests {
+		var rd RPNReader
+		var res = []RPN{}
+
+		for _, msg := range test.input {
+			if rpn, ok := rd.Read(msg); ok {
+				res = append(res, rpn)
+			}
+		}
+
+		if got, want := fmt.Sprintf("%v", res), test.expected; got != want {
+			t.Errorf("[%v] RPNReader.Read() = %s; want %s", i, got, want)
+		}
+	}
+}
+
+func TestRPNSemitones(t *testing.T) {
+	rpn := RPN{DataMSB: 12, DataLSB: 50}
+
+	if got, want := rpn.Semitones(), 12.5; got != want {
+		t.Errorf("Semitones() = %v; want %v", got, want)
+	}
+
+	if !rpn.IsPitchBendSensitivity() {
+		t.Errorf("IsPitchBendSensitivity() = false; want true")
+	}
+}
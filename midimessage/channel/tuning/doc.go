@@ -0,0 +1,26 @@
+// Copyright (c) 2018 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package tuning plays alternative tunings on ordinary synths by detuning each note with the pitch bend of its channel.
+
+A Tuning is the deviation of each key from twelve-tone equal temperament in cents. It is built from the offsets of
+the pitch classes or from a Scale, e.g. of a Scala file:
+
+	scale, err := tuning.ParseScala(file)
+	t := scale.Tuning(60) // key 60 plays the first degree of the scale
+
+Since the pitch bend affects all notes of a channel, the Retuner distributes the notes that are detuned differently
+to the channels of a pool:
+
+	r := tuning.NewRetuner(t, 2, tuning.Pool(0, 0, 1, 2, 3, 4, 5))
+
+	for _, msg := range r.Convert(msg) {
+		wr.Write(msg)
+	}
+
+The pitch bend range of the receiving channels must be set to the given number of semitones (see
+mpe.PitchBendSensitivity).
+*/
+package tuning
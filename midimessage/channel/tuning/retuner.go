@@ -0,0 +1,333 @@
+package tuning
+
+import (
+	"math"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// Option is an option for the Retuner
+type Option func(*Retuner)
+
+// Pool sets the channels that the notes of the given source channel are distributed to. The source channel is
+// only used, if it is part of the pool. Default is the source channel alone.
+func Pool(source uint8, channels ...uint8) Option {
+	return func(r *Retuner) {
+		if source > 15 {
+			return
+		}
+
+		r.pools[source] = nil
+
+		for _, ch := range channels {
+			if ch < 16 {
+				r.pools[source] = append(r.pools[source], ch)
+			}
+		}
+	}
+}
+
+// SkipChannels sets the channels whose messages are passed unchanged. Default is channel 9 (channel 10 in GM),
+// since drums are not tuned.
+func SkipChannels(channels ...uint8) Option {
+	return func(r *Retuner) {
+		r.skip = [16]bool{}
+
+		for _, ch := range channels {
+			if ch < 16 {
+				r.skip[ch] = true
+			}
+		}
+	}
+}
+
+// voice is a sounding note
+type voice struct {
+	// source and key are the channel and key of the note on message that has been converted
+	source, key uint8
+
+	// ch and outKey are the channel and key of the note on message that has been returned
+	ch, outKey uint8
+
+	// bend is the pitch bend value of the tuning, without the pitch bend of the source channel
+	bend int16
+}
+
+// Retuner converts the note messages of a stream, so that the notes sound in a Tuning: a note is played by the key
+// that is nearest to its pitch in the Tuning, and the pitch bend of its channel is set to the remaining deviation
+// before the note on message. After the last note of a channel has ended, its pitch bend is reset.
+//
+// Notes with different pitch bends are played on different channels of the pool of the source channel (see Pool):
+// A note is added to a sounding channel that has the same pitch bend, otherwise it gets the next free channel in
+// round-robin order. If all channels of the pool are in use, the channel of the oldest note is stolen, i.e. its notes
+// are ended. The other channel messages of the source channel are sent to all channels of the pool (polyphonic
+// aftertouch to the channel and key of the note), pitch bend messages are added to the pitch bend of the tuning.
+type Retuner struct {
+	tuning Tuning
+
+	// bendRange is the pitch bend range of the receiver in cents
+	bendRange float64
+
+	pools [16][]uint8
+	skip  [16]bool
+
+	// next is the index of the pool channel of each source channel where the search for a free channel starts
+	next [16]int
+
+	// voices are the sounding notes in the order of their start
+	voices []voice
+
+	// swallow is the number of note off messages per source channel and key that belong to stolen notes
+	swallow [16][128]int
+
+	// bend is the pitch bend that has been sent on each channel, sourceBend the pitch bend of each source channel
+	bend       [16]int16
+	sourceBend [16]int16
+}
+
+// NewRetuner returns a Retuner for the given Tuning and a pitch bend range of the receiver of bendRange semitones
+func NewRetuner(t Tuning, bendRange float64, options ...Option) *Retuner {
+	r := &Retuner{tuning: t, bendRange: bendRange * 100}
+	r.skip[9] = true
+
+	for ch := range r.pools {
+		r.pools[ch] = []uint8{uint8(ch)}
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// target returns the key and the pitch bend value that play the given key in the Tuning
+func (r *Retuner) target(key uint8) (outKey uint8, bend int16) {
+	pitch := r.tuning.Pitch(key)
+	k := math.Round(pitch / 100)
+
+	if k < 0 {
+		k = 0
+	}
+
+	if k > 127 {
+		k = 127
+	}
+
+	if r.bendRange <= 0 {
+		return uint8(k), 0
+	}
+
+	return uint8(k), clampBend(int(math.Round((pitch - k*100) / r.bendRange * 8192)))
+}
+
+func clampBend(v int) int16 {
+	if v < -8192 {
+		return -8192
+	}
+
+	if v > 8191 {
+		return 8191
+	}
+
+	return int16(v)
+}
+
+// busy returns the index of the first voice on the given channel or -1
+func (r *Retuner) busy(ch uint8) int {
+	for i, v := range r.voices {
+		if v.ch == ch {
+			return i
+		}
+	}
+	return -1
+}
+
+// setBend returns the pitch bend message that sets the given channel to the given value, nil if it is already set
+func (r *Retuner) setBend(ch uint8, value int16) []midi.Message {
+	if r.bend[ch] == value {
+		return nil
+	}
+
+	r.bend[ch] = value
+	return []midi.Message{channel.Channel(ch).Pitchbend(value)}
+}
+
+// noteOn returns the messages that start the given note
+func (r *Retuner) noteOn(source, key, velocity uint8) (msgs []midi.Message) {
+	pool := r.pools[source]
+
+	if key > 127 {
+		return nil
+	}
+
+	if len(pool) == 0 {
+		r.swallow[source][key]++
+		return nil
+	}
+
+	outKey, bend := r.target(key)
+	ch, found := uint8(0), false
+
+	// a sounding channel with the same bend that doesn't play the key yet
+	for _, c := range pool {
+		if i := r.busy(c); i >= 0 && r.voices[i].bend == bend && r.sounding(c, outKey) < 0 {
+			ch, found = c, true
+			break
+		}
+	}
+
+	// the next free channel
+	for i := 0; !found && i < len(pool); i++ {
+		idx := (r.next[source] + i) % len(pool)
+
+		if r.busy(pool[idx]) < 0 {
+			ch, found = pool[idx], true
+			r.next[source] = idx + 1
+		}
+	}
+
+	// the channel of the oldest note
+	if !found {
+		for _, v := range r.voices {
+			if r.inPool(source, v.ch) {
+				ch = v.ch
+				break
+			}
+		}
+
+		for i := r.busy(ch); i >= 0; i = r.busy(ch) {
+			v := r.voices[i]
+			r.voices = append(r.voices[:i], r.voices[i+1:]...)
+			r.swallow[v.source][v.key]++
+			msgs = append(msgs, channel.Channel(ch).NoteOff(v.outKey))
+		}
+	}
+
+	r.voices = append(r.voices, voice{source: source, key: key, ch: ch, outKey: outKey, bend: bend})
+	msgs = append(msgs, r.setBend(ch, clampBend(int(bend)+int(r.sourceBend[source])))...)
+	return append(msgs, channel.Channel(ch).NoteOn(outKey, velocity))
+}
+
+// sounding returns the index of the first voice that plays the given key on the given channel or -1
+func (r *Retuner) sounding(ch, outKey uint8) int {
+	for i, v := range r.voices {
+		if v.ch == ch && v.outKey == outKey {
+			return i
+		}
+	}
+	return -1
+}
+
+// inPool returns true, if the given channel is part of the pool of the given source channel
+func (r *Retuner) inPool(source, ch uint8) bool {
+	for _, c := range r.pools[source] {
+		if c == ch {
+			return true
+		}
+	}
+	return false
+}
+
+// voice returns the index of the first voice of the given source channel and key or -1
+func (r *Retuner) voice(source, key uint8) int {
+	for i, v := range r.voices {
+		if v.source == source && v.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// noteOff returns the messages that end the given note, followed by the reset of the pitch bend, if it has been
+// the last note of its channel
+func (r *Retuner) noteOff(source, key uint8, off func(ch channel.Channel, outKey uint8) midi.Message) []midi.Message {
+	if key > 127 {
+		return nil
+	}
+
+	if r.swallow[source][key] > 0 {
+		r.swallow[source][key]--
+		return nil
+	}
+
+	i := r.voice(source, key)
+
+	if i < 0 {
+		return nil
+	}
+
+	v := r.voices[i]
+	r.voices = append(r.voices[:i], r.voices[i+1:]...)
+	msgs := []midi.Message{off(channel.Channel(v.ch), v.outKey)}
+
+	if r.busy(v.ch) < 0 {
+		msgs = append(msgs, r.setBend(v.ch, r.sourceBend[source])...)
+	}
+
+	return msgs
+}
+
+// pitchbend sets the pitch bend of the given source channel and returns the pitch bend messages of the channels
+// of its pool
+func (r *Retuner) pitchbend(source uint8, value int16) (msgs []midi.Message) {
+	r.sourceBend[source] = value
+
+	for _, ch := range r.pools[source] {
+		var bend int16
+
+		if i := r.busy(ch); i >= 0 {
+			bend = r.voices[i].bend
+		}
+
+		msgs = append(msgs, r.setBend(ch, clampBend(int(bend)+int(value)))...)
+	}
+
+	return
+}
+
+// Convert returns the messages that replace the given message (see Retuner). Messages of skipped channels and
+// messages that are no channel messages are returned as they are.
+func (r *Retuner) Convert(msg midi.Message) []midi.Message {
+	m, ok := msg.(channel.Message)
+
+	if !ok || m.Channel() > 15 || r.skip[m.Channel()] {
+		return []midi.Message{msg}
+	}
+
+	source := m.Channel()
+
+	switch v := m.(type) {
+	case channel.NoteOn:
+		if v.Velocity() == 0 {
+			return r.noteOff(source, v.Key(), func(ch channel.Channel, outKey uint8) midi.Message {
+				return ch.NoteOn(outKey, 0)
+			})
+		}
+		return r.noteOn(source, v.Key(), v.Velocity())
+	case channel.NoteOff:
+		return r.noteOff(source, v.Key(), func(ch channel.Channel, outKey uint8) midi.Message {
+			return ch.NoteOff(outKey)
+		})
+	case channel.NoteOffVelocity:
+		return r.noteOff(source, v.Key(), func(ch channel.Channel, outKey uint8) midi.Message {
+			return ch.NoteOffVelocity(outKey, v.Velocity())
+		})
+	case channel.Pitchbend:
+		return r.pitchbend(source, v.Value())
+	case channel.PolyAftertouch:
+		if i := r.voice(source, v.Key()); i >= 0 {
+			return []midi.Message{channel.Channel(r.voices[i].ch).PolyAftertouch(r.voices[i].outKey, v.Pressure())}
+		}
+		return nil
+	}
+
+	var msgs []midi.Message
+
+	for _, ch := range r.pools[source] {
+		msgs = append(msgs, channel.SetChannel(m, ch))
+	}
+
+	return msgs
+}
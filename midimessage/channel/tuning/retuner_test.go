@@ -0,0 +1,142 @@
+package tuning
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/realtime"
+)
+
+func TestRetuner(t *testing.T) {
+	r := NewRetuner(EqualTemperament(19).Tuning(60), 2, Pool(0, 0, 1, 2, 3, 4, 5))
+	ch := channel.Channel0
+
+	var bf strings.Builder
+
+	convert := func(comment string, msgs ...midi.Message) {
+		bf.WriteString("# " + comment + "\n")
+
+		for _, msg := range msgs {
+			for _, res := range r.Convert(msg) {
+				bf.WriteString(res.String() + "\n")
+			}
+		}
+	}
+
+	convert("six voice chord", ch.NoteOn(60, 100), ch.NoteOn(62, 100), ch.NoteOn(65, 100), ch.NoteOn(68, 100), ch.NoteOn(71, 100), ch.NoteOn(74, 100))
+	convert("the octave shares the channel of the root", ch.NoteOn(79, 100))
+	convert("the channel of the oldest note is stolen", ch.NoteOn(61, 90))
+	convert("the note off of a stolen note is swallowed", ch.NoteOff(60))
+	convert("the pitch bend is reset after the note off", ch.NoteOff(62))
+	convert("pitch bend of the source", ch.Pitchbend(100))
+	convert("other messages", ch.ProgramChange(5), ch.PolyAftertouch(71, 30), ch.PolyAftertouch(72, 30), channel.Channel9.NoteOn(36, 100), realtime.Start)
+
+	expected := `# six voice chord
+channel.NoteOn channel 1 key 60 velocity 100
+channel.Pitchbend channel 2 value 1078 absValue 0
+channel.NoteOn channel 2 key 61 velocity 100
+channel.Pitchbend channel 3 value 647 absValue 0
+channel.NoteOn channel 3 key 63 velocity 100
+channel.Pitchbend channel 4 value 216 absValue 0
+channel.NoteOn channel 4 key 65 velocity 100
+channel.Pitchbend channel 5 value -216 absValue 0
+channel.NoteOn channel 5 key 67 velocity 100
+channel.Pitchbend channel 6 value -647 absValue 0
+channel.NoteOn channel 6 key 69 velocity 100
+# the octave shares the channel of the root
+channel.NoteOn channel 1 key 72 velocity 100
+# the channel of the oldest note is stolen
+channel.NoteOff channel 1 key 60
+channel.NoteOff channel 1 key 72
+channel.Pitchbend channel 1 value -1509 absValue 0
+channel.NoteOn channel 1 key 61 velocity 90
+# the note off of a stolen note is swallowed
+# the pitch bend is reset after the note off
+channel.NoteOff channel 2 key 61
+channel.Pitchbend channel 2 value 0 absValue 0
+# pitch bend of the source
+channel.Pitchbend channel 1 value -1409 absValue 0
+channel.Pitchbend channel 2 value 100 absValue 0
+channel.Pitchbend channel 3 value 747 absValue 0
+channel.Pitchbend channel 4 value 316 absValue 0
+channel.Pitchbend channel 5 value -116 absValue 0
+channel.Pitchbend channel 6 value -547 absValue 0
+# other messages
+channel.ProgramChange channel 1 program 5
+channel.ProgramChange channel 2 program 5
+channel.ProgramChange channel 3 program 5
+channel.ProgramChange channel 4 program 5
+channel.ProgramChange channel 5 program 5
+channel.ProgramChange channel 6 program 5
+channel.PolyAftertouch channel 5 key 67 pressure 30
+channel.NoteOn channel 10 key 36 velocity 100
+Start
+`
+
+	if got := bf.String(); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+func TestRetunerChords(t *testing.T) {
+	// successive six voice chords in 19-TET on a pool of six channels never steal a note
+	r := NewRetuner(EqualTemperament(19).Tuning(60), 2, Pool(0, 0, 1, 2, 3, 4, 5))
+	ch := channel.Channel0
+	chords := [][]uint8{{60, 62, 65, 68, 71, 74}, {61, 64, 66, 69, 72, 75}, {55, 58, 60, 63, 66, 70}}
+
+	// the pitch of each channel, as far as it is sounding
+	var bends [16]int16
+	var sounding = map[[2]uint8]bool{}
+
+	for _, chord := range chords {
+		var msgs []midi.Message
+
+		for _, key := range chord {
+			msgs = append(msgs, r.Convert(ch.NoteOn(key, 100))...)
+		}
+
+		for _, msg := range msgs {
+			switch v := msg.(type) {
+			case channel.Pitchbend:
+				bends[v.Channel()] = v.Value()
+			case channel.NoteOn:
+				sounding[[2]uint8{v.Channel(), v.Key()}] = true
+			case channel.NoteOff:
+				t.Errorf("note %v on channel %v has been stolen", v.Key(), v.Channel())
+			}
+		}
+
+		// each note sounds at its pitch in the tuning
+		for _, key := range chord {
+			outKey, bend := r.target(key)
+			var found bool
+
+			for c := uint8(0); c < 6; c++ {
+				if sounding[[2]uint8{c, outKey}] && bends[c] == bend {
+					found = true
+				}
+			}
+
+			if !found {
+				t.Errorf("key %v does not sound as key %v with bend %v", key, outKey, bend)
+			}
+		}
+
+		for _, key := range chord {
+			for _, msg := range r.Convert(ch.NoteOff(key)) {
+				switch v := msg.(type) {
+				case channel.Pitchbend:
+					bends[v.Channel()] = v.Value()
+				case channel.NoteOff:
+					delete(sounding, [2]uint8{v.Channel(), v.Key()})
+				}
+			}
+		}
+
+		if len(sounding) != 0 || bends != [16]int16{} {
+			t.Errorf("after the chord: sounding %v, bends %v; want none", sounding, bends)
+		}
+	}
+}
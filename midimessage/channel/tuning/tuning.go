@@ -0,0 +1,167 @@
+package tuning
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Tuning is the deviation of the pitch of each key from twelve-tone equal temperament in cents,
+// i.e. key k sounds at k*100 + t[k] cents above key 0.
+type Tuning [128]float64
+
+// PitchClasses returns the Tuning that detunes each pitch class (starting with C) by the given cents
+func PitchClasses(offsets [12]float64) Tuning {
+	var t Tuning
+
+	for k := range t {
+		t[k] = offsets[k%12]
+	}
+
+	return t
+}
+
+// Pitch returns the pitch of the given key in cents above key 0
+func (t Tuning) Pitch(key uint8) float64 {
+	if key > 127 {
+		return 0
+	}
+	return float64(key)*100 + t[key]
+}
+
+// Scale is a scale, as defined by a Scala file (see ParseScala)
+type Scale struct {
+	Description string
+
+	// Degrees are the pitches of the degrees after the root in cents above the root. The last degree is the period
+	// of the scale, usually the octave (1200 cents).
+	Degrees []float64
+}
+
+// EqualTemperament returns the scale that divides the octave into the given number of equal steps
+func EqualTemperament(steps int) Scale {
+	s := Scale{Description: fmt.Sprintf("%v-tone equal temperament", steps)}
+
+	for i := 1; i <= steps; i++ {
+		s.Degrees = append(s.Degrees, 1200*float64(i)/float64(steps))
+	}
+
+	return s
+}
+
+// Tuning returns the Tuning that maps the keys linearly to the degrees of the scale: the given key plays the root
+// at its pitch in twelve-tone equal temperament, each following key the next degree.
+// A scale without degrees returns the Tuning without deviations.
+func (s Scale) Tuning(root uint8) Tuning {
+	var t Tuning
+	n := len(s.Degrees)
+
+	if n == 0 {
+		return t
+	}
+
+	period := s.Degrees[n-1]
+
+	for k := range t {
+		steps := k - int(root)
+		periods := int(math.Floor(float64(steps) / float64(n)))
+		degree := steps - periods*n
+		pitch := float64(root)*100 + float64(periods)*period
+
+		if degree > 0 {
+			pitch += s.Degrees[degree-1]
+		}
+
+		t[k] = pitch - float64(k)*100
+	}
+
+	return t
+}
+
+// ParseScala parses a scale in the format of Scala files (.scl): lines starting with ! are comments, the first
+// line is the description, the second the number of degrees, followed by a line for each degree. A degree is given
+// in cents, if it contains a period (e.g. 701.955), otherwise as ratio (e.g. 3/2) or integer (e.g. 2).
+// Text after the value is ignored.
+func ParseScala(rd io.Reader) (Scale, error) {
+	var s Scale
+	var lines []string
+	sc := bufio.NewScanner(rd)
+
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+
+		if !strings.HasPrefix(line, "!") {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return s, err
+	}
+
+	if len(lines) < 2 {
+		return s, fmt.Errorf("missing description or number of degrees")
+	}
+
+	s.Description = strings.TrimSpace(lines[0])
+	n, err := strconv.Atoi(firstField(lines[1]))
+
+	if err != nil || n < 0 {
+		return s, fmt.Errorf("invalid number of degrees %q", strings.TrimSpace(lines[1]))
+	}
+
+	if len(lines)-2 < n {
+		return s, fmt.Errorf("%v degrees expected, %v found", n, len(lines)-2)
+	}
+
+	for i, line := range lines[2 : 2+n] {
+		cents, err := parseDegree(firstField(line))
+
+		if err != nil {
+			return s, fmt.Errorf("degree %v: %v", i+1, err)
+		}
+
+		s.Degrees = append(s.Degrees, cents)
+	}
+
+	return s, nil
+}
+
+// firstField returns the first field of the given line
+func firstField(line string) string {
+	if f := strings.Fields(line); len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
+
+// parseDegree parses a degree of a Scala file and returns it in cents
+func parseDegree(text string) (float64, error) {
+	if strings.Contains(text, ".") {
+		cents, err := strconv.ParseFloat(text, 64)
+
+		if err != nil {
+			return 0, fmt.Errorf("invalid cents %q", text)
+		}
+
+		return cents, nil
+	}
+
+	num, den, isRatio := strings.Cut(text, "/")
+
+	if !isRatio {
+		den = "1"
+	}
+
+	a, errA := strconv.ParseUint(num, 10, 64)
+	b, errB := strconv.ParseUint(den, 10, 64)
+
+	if errA != nil || errB != nil || a == 0 || b == 0 {
+		return 0, fmt.Errorf("invalid ratio %q", text)
+	}
+
+	return 1200 * math.Log2(float64(a)/float64(b)), nil
+}
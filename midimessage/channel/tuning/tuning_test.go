@@ -0,0 +1,86 @@
+package tuning
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseScala(t *testing.T) {
+	scl := `! meantone.scl
+!
+Quarter-comma meantone
+ 5
+!
+ 76.04900
+ 5/4  major third
+ 696.57843
+ 2
+ 3
+`
+
+	s, err := ParseScala(strings.NewReader(scl))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := s.Description, "Quarter-comma meantone"; got != want {
+		t.Errorf("Description = %q; want %q", got, want)
+	}
+
+	if got, want := fmt.Sprintf("%.3f", s.Degrees), "[76.049 386.314 696.578 1200.000 1901.955]"; got != want {
+		t.Errorf("Degrees = %s; want %s", got, want)
+	}
+
+	errors := []string{
+		"",
+		"description\n",
+		"description\nthree\n",
+		"description\n2\n100.0\n",
+		"description\n1\n3/0\n",
+		"description\n1\nfifth\n",
+		"description\n1\n1.2.3\n",
+	}
+
+	for _, text := range errors {
+		if s, err := ParseScala(strings.NewReader(text)); err == nil {
+			t.Errorf("ParseScala(%q) = %v; want error", text, s)
+		}
+	}
+}
+
+func TestScaleTuning(t *testing.T) {
+	t19 := EqualTemperament(19).Tuning(60)
+
+	tests := []struct {
+		key   uint8
+		pitch float64
+	}{
+		{60, 6000},
+		{61, 6063.158},
+		{62, 6126.316},
+		{71, 6694.737},
+		{79, 7200},
+		{80, 7263.158},
+		{59, 5936.842},
+		{41, 4800},
+		{0, 2210.526},
+	}
+
+	for _, test := range tests {
+		if got, want := fmt.Sprintf("%.3f", t19.Pitch(test.key)), fmt.Sprintf("%.3f", test.pitch); got != want {
+			t.Errorf("Pitch(%v) = %s; want %s", test.key, got, want)
+		}
+	}
+
+	just := PitchClasses([12]float64{0, 11.7, 3.9, 15.6, -13.7, -2, -9.8, 2, 13.7, -15.6, 17.6, -11.7})
+
+	if got, want := just.Pitch(64), 6386.3; got != want {
+		t.Errorf("Pitch(64) = %v; want %v", got, want)
+	}
+
+	if got, want := (Scale{}).Tuning(60), (Tuning{}); got != want {
+		t.Errorf("Tuning of empty scale has deviations")
+	}
+}
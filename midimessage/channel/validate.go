@@ -0,0 +1,27 @@
+package channel
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi"
+)
+
+// validate returns an error for the given message, if the channel is beyond 15 or a data byte is beyond 127
+func validate(msg Message, channel uint8, data ...uint8) error {
+	if channel > 15 {
+		return invalid(msg, "channel %v out of range 0-15", channel)
+	}
+
+	for _, b := range data {
+		if b > 127 {
+			return invalid(msg, "data byte %v out of range 0-127", b)
+		}
+	}
+
+	return nil
+}
+
+// invalid returns an error for the given message that wraps midi.ErrInvalidMessage
+func invalid(msg Message, format string, args ...any) error {
+	return fmt.Errorf("%w %T: %s", midi.ErrInvalidMessage, msg, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,60 @@
+package channel
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gomidi/midi"
+)
+
+func TestValidate(t *testing.T) {
+	invalid := []interface {
+		Message
+		Validate() error
+	}{
+		// channel beyond 15
+		Channel(16).NoteOn(60, 100),
+		Channel(16).NoteOff(60),
+		Channel(16).NoteOffVelocity(60, 64),
+		Channel(16).PolyAftertouch(60, 10),
+		Channel(16).ControlChange(7, 100),
+		Channel(16).ProgramChange(5),
+		Channel(16).Aftertouch(10),
+		Channel(16).Pitchbend(0),
+
+		// data bytes beyond 127
+		NoteOn{key: 128, velocity: 100},
+		NoteOn{key: 60, velocity: 200},
+		NoteOff{key: 200},
+		NoteOffVelocity{NoteOff{key: 60}, 128},
+		PolyAftertouch{key: 60, pressure: 200},
+		ControlChange{controller: 128},
+		ControlChange{controller: 7, value: 200},
+		ProgramChange{program: 128},
+		Aftertouch{pressure: 200},
+
+		// pitch bend beyond the 14 bits
+		Pitchbend{value: PitchHighest + 1},
+		Pitchbend{value: PitchLowest - 1},
+	}
+
+	for _, msg := range invalid {
+		if err := msg.Validate(); !errors.Is(err, midi.ErrInvalidMessage) {
+			t.Errorf("%#v.Validate() = %v; want %v", msg, err, midi.ErrInvalidMessage)
+		}
+	}
+
+	valid := []Message{
+		Channel15.NoteOn(127, 127),
+		Channel15.NoteOffVelocity(127, 127),
+		Channel0.ControlChange(127, 127),
+		Channel0.Pitchbend(PitchLowest),
+		Channel0.Pitchbend(PitchHighest),
+	}
+
+	for _, msg := range valid {
+		if err := midi.Validate(msg); err != nil {
+			t.Errorf("Validate(%v) = %v; want nil", msg, err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package midimessage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/syscommon"
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+// sizeClasses are data lengths at the bounds of the lengths of the variable length quantities
+var sizeClasses = []int{0, 1, 127, 128, 16383, 16384}
+
+// encodedLenCorpus returns messages of every type and size class
+func encodedLenCorpus() []midi.Message {
+	var msgs []midi.Message
+
+	for _, ch := range []channel.Channel{channel.Channel0, channel.Channel9, channel.Channel15} {
+		msgs = append(msgs,
+			ch.NoteOn(60, 100),
+			ch.NoteOff(60),
+			ch.NoteOffVelocity(60, 64),
+			ch.PolyAftertouch(60, 30),
+			ch.ControlChange(7, 100),
+			ch.ProgramChange(3),
+			ch.Aftertouch(40),
+			ch.Pitchbend(-8192),
+			ch.Pitchbend(8191),
+		)
+	}
+
+	msgs = append(msgs,
+		meta.Channel(3),
+		meta.Port(2),
+		meta.EndOfTrack,
+		meta.Key{Key: 2, Num: 2, IsMajor: true},
+		meta.SequenceNo(300),
+		meta.TimeSig{Numerator: 6, Denominator: 8},
+		meta.SMPTE{Hour: 1, Minute: 2, Second: 3, Frame: 4, FractionalFrame: 5},
+		meta.Tempo(0),
+		meta.BPM(120),
+		meta.Tempo(0xFFFFFFFF),
+	)
+
+	for _, n := range sizeClasses {
+		text := strings.Repeat("a", n)
+		data := []byte(text)
+
+		msgs = append(msgs,
+			meta.Text(text),
+			meta.Copyright(text),
+			meta.Sequence(text),
+			meta.Track(text),
+			meta.Marker(text),
+			meta.Lyric(text),
+			meta.Cuepoint(text),
+			meta.Program(text),
+			meta.Device(text),
+			meta.SequencerData(data),
+			meta.Undefined{Typ: 0x60, Data: data},
+			sysex.SysEx(data),
+			sysex.Start(data),
+			sysex.Continue(data),
+			sysex.End(data),
+			sysex.Escape(data),
+		)
+	}
+
+	msgs = append(msgs,
+		realtime.TimingClock,
+		realtime.Tick,
+		realtime.Start,
+		realtime.Continue,
+		realtime.Stop,
+		realtime.Undefined4,
+		realtime.Activesense,
+		realtime.Reset,
+		syscommon.MTC(3),
+		syscommon.SongSelect(4),
+		syscommon.SPP(1000),
+		syscommon.Tune,
+	)
+
+	return msgs
+}
+
+func TestEncodedLen(t *testing.T) {
+	for _, msg := range encodedLenCorpus() {
+		if _, ok := msg.(interface{ EncodedLen() int }); !ok {
+			t.Errorf("%T has no EncodedLen method", msg)
+			continue
+		}
+
+		if got, want := midi.EncodedLen(msg), len(msg.Raw()); got != want {
+			t.Errorf("EncodedLen() of %T (%v bytes) = %v; want %v", msg, want, got, want)
+		}
+	}
+}
+
+func TestEncodedLenRunningStatus(t *testing.T) {
+	tests := []struct {
+		msg      channel.Message
+		running  bool
+		expected int
+	}{
+		{channel.Channel1.NoteOn(60, 100), false, 3},
+		{channel.Channel1.NoteOn(60, 100), true, 2},
+		{channel.Channel1.ProgramChange(3), false, 2},
+		{channel.Channel1.ProgramChange(3), true, 1},
+	}
+
+	for i, test := range tests {
+		if got := channel.EncodedLen(test.msg, test.running); got != test.expected {
+			t.Errorf("[%v] EncodedLen(%v, %v) = %v; want %v", i, test.msg, test.running, got, test.expected)
+		}
+	}
+}
+
+// rawOnly is a message without EncodedLen method
+type rawOnly []byte
+
+func (r rawOnly) String() string { return "rawOnly" }
+func (r rawOnly) Raw() []byte    { return r }
+
+func TestEncodedLenFallback(t *testing.T) {
+	if got, want := midi.EncodedLen(rawOnly{1, 2, 3, 4}), 4; got != want {
+		t.Errorf("EncodedLen() = %v; want %v", got, want)
+	}
+}
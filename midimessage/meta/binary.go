@@ -0,0 +1,238 @@
+package meta
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi/internal/midilib"
+)
+
+// Unmarshal parses the raw bytes of a single meta message (0xFF, the type, the length and the data, as returned by Raw).
+// Unknown types are returned as Undefined. If the message has a value that is out of range, the message with the
+// clamped value is returned together with an *InvalidValueError.
+func Unmarshal(data []byte) (Message, error) {
+	if len(data) < 2 || data[0] != 0xFF {
+		return nil, fmt.Errorf("invalid meta message % X: must start with FF and the type", data)
+	}
+
+	// the data is copied, since variable length data is sliced from it
+	rd := midilib.NewSliceReader(append([]byte{}, data[2:]...))
+	msg, err := NewReader(rd, data[1]).Read()
+
+	if msg == nil {
+		return nil, fmt.Errorf("invalid meta message % X: %v", data, err)
+	}
+
+	if rd.Len() > 0 {
+		return nil, fmt.Errorf("invalid meta message % X: %v bytes after the data", data, rd.Len())
+	}
+
+	return msg, err
+}
+
+// unmarshal sets m to the meta message of the given raw bytes, that must be of type T
+func unmarshal[T Message](data []byte, m *T) error {
+	msg, err := Unmarshal(data)
+
+	if msg == nil {
+		return err
+	}
+
+	v, ok := msg.(T)
+
+	if !ok {
+		return fmt.Errorf("can't unmarshal %T into %T", msg, *m)
+	}
+
+	*m = v
+	return err
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Text) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Text) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Copyright) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Copyright) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Sequence) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Sequence) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Track) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Track) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Lyric) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Lyric) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Marker) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Marker) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Cuepoint) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Cuepoint) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (p Program) MarshalBinary() ([]byte, error) {
+	return p.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (p *Program) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, p)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Device) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Device) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (s SequenceNo) MarshalBinary() ([]byte, error) {
+	return s.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (s *SequenceNo) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, s)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Channel) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Channel) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Port) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Port) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Tempo) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Tempo) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (s SMPTE) MarshalBinary() ([]byte, error) {
+	return s.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (s *SMPTE) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, s)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m TimeSig) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *TimeSig) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Key) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Key) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (s SequencerData) MarshalBinary() ([]byte, error) {
+	return s.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (s *SequencerData) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, s)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Undefined) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Undefined) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m endOfTrack) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *endOfTrack) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
@@ -10,24 +10,42 @@ import (
 // Channel represents the deprecated MIDI channel meta message
 type Channel uint8
 
-// Number returns the number of the MIDI channel (starting with 0)
+// Number returns the number of the MIDI channel (0-15)
 func (m Channel) Number() uint8 {
 	return uint8(m)
 }
 
-// String represents the MIDIChannel message as a string (for debugging)
+// DisplayChannel returns the number of the MIDI channel as it is displayed to users (1-16)
+func (m Channel) DisplayChannel() uint8 {
+	return uint8(m) + 1
+}
+
+// String represents the MIDIChannel message as a string (for debugging), showing the 1-based DisplayChannel
 func (m Channel) String() string {
-	return fmt.Sprintf("%T: %v", m, m.Number())
+	return fmt.Sprintf("%T: %v", m, m.DisplayChannel())
 }
 
 // Raw returns the raw bytes for the message
 func (m Channel) Raw() []byte {
 	return (&metaMessage{
-		Typ:  byte(byteMIDIChannel),
+		Typ:  TypeChannel,
 		Data: []byte{byte(m)},
 	}).Bytes()
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Channel) EncodedLen() int {
+	return encodedLen(1)
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the channel is beyond 15
+func (m Channel) Validate() error {
+	if m > 15 {
+		return invalid(m, "channel %v out of range 0-15", uint8(m))
+	}
+	return nil
+}
+
 func (m Channel) meta() {}
 
 func (m Channel) readFrom(rd io.Reader) (Message, error) {
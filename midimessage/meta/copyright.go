@@ -13,7 +13,7 @@ func (m Copyright) String() string {
 	return fmt.Sprintf("%T: %#v", m, m.Text())
 }
 func (m Copyright) readFrom(rd io.Reader) (Message, error) {
-	text, err := readText(rd)
+	text, err := ReadText(rd)
 
 	if err != nil {
 		return nil, err
@@ -24,10 +24,17 @@ func (m Copyright) readFrom(rd io.Reader) (Message, error) {
 
 // Raw returns the raw MIDI data
 func (m Copyright) Raw() []byte {
-	return (&metaMessage{
-		Typ:  byteCopyright,
-		Data: []byte(m),
-	}).Bytes()
+	return textRaw(TypeCopyright, string(m))
+}
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Copyright) EncodedLen() int {
+	return encodedLen(len(m))
+}
+
+// Validate returns nil, since the text is prefixed by its length and may contain any bytes
+func (m Copyright) Validate() error {
+	return nil
 }
 
 // Text returns the copyright text
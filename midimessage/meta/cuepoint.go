@@ -25,10 +25,17 @@ func (m Cuepoint) Text() string {
 
 // Raw returns the raw MIDI bytes
 func (m Cuepoint) Raw() []byte {
-	return (&metaMessage{
-		Typ:  byte(byteCuepoint),
-		Data: []byte(m),
-	}).Bytes()
+	return textRaw(TypeCuepoint, string(m))
+}
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Cuepoint) EncodedLen() int {
+	return encodedLen(len(m))
+}
+
+// Validate returns nil, since the text is prefixed by its length and may contain any bytes
+func (m Cuepoint) Validate() error {
+	return nil
 }
 
 // String represents the cue point MIDI message as a string (for debugging)
@@ -37,7 +44,7 @@ func (m Cuepoint) String() string {
 }
 
 func (m Cuepoint) readFrom(rd io.Reader) (Message, error) {
-	text, err := readText(rd)
+	text, err := ReadText(rd)
 
 	if err != nil {
 		return nil, err
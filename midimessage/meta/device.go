@@ -45,7 +45,7 @@ func (m Device) String() string {
 func (m Device) meta() {}
 
 func (m Device) readFrom(rd io.Reader) (Message, error) {
-	text, err := readText(rd)
+	text, err := ReadText(rd)
 	if err != nil {
 		return nil, err
 	}
@@ -55,10 +55,17 @@ func (m Device) readFrom(rd io.Reader) (Message, error) {
 
 // Raw returns the raw MIDI data
 func (m Device) Raw() []byte {
-	return (&metaMessage{
-		Typ:  byte(byteDevicePort),
-		Data: []byte(m),
-	}).Bytes()
+	return textRaw(TypeDevice, string(m))
+}
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Device) EncodedLen() int {
+	return encodedLen(len(m))
+}
+
+// Validate returns nil, since the text is prefixed by its length and may contain any bytes
+func (m Device) Validate() error {
+	return nil
 }
 
 // Text returns the name of the device port
@@ -21,10 +21,20 @@ func (m endOfTrack) String() string {
 // Raw returns the raw MIDI data
 func (m endOfTrack) Raw() []byte {
 	return (&metaMessage{
-		Typ: byte(byteEndOfTrack),
+		Typ: TypeEndOfTrack,
 	}).Bytes()
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m endOfTrack) EncodedLen() int {
+	return encodedLen(0)
+}
+
+// Validate returns nil
+func (m endOfTrack) Validate() error {
+	return nil
+}
+
 func (m endOfTrack) meta() {}
 
 func (m endOfTrack) readFrom(rd io.Reader) (Message, error) {
@@ -0,0 +1,22 @@
+package meta
+
+import "errors"
+
+// ErrTextTooLong is returned by ReadText for a text that is longer than the limit set by SetTextLimit
+var ErrTextTooLong = errors.New("text exceeds the size limit")
+
+// InvalidValueError is returned by the Reader for a meta message with a value that is out of the range the
+// SMF specification allows, e.g. a tempo of 0 microseconds per quarter note.
+// The message is returned together with the error, having the value clamped to the nearest valid one.
+type InvalidValueError struct {
+	// Message is the message with the clamped value
+	Message Message
+
+	// Problem describes the invalid value
+	Problem string
+}
+
+// Error returns the error message
+func (e *InvalidValueError) Error() string {
+	return "invalid meta message: " + e.Problem
+}
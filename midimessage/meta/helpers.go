@@ -2,22 +2,33 @@ package meta
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gomidi/midi"
 	"github.com/gomidi/midi/internal/midilib"
 	"github.com/gomidi/midi/internal/vlq"
-	"io"
 )
 
 func unexpectedMessageLengthError(s string) error {
 	return errors.New(s)
 }
 
+// invalid returns an error for the given message that wraps midi.ErrInvalidMessage
+func invalid(msg Message, format string, args ...any) error {
+	return fmt.Errorf("%w %T: %s", midi.ErrInvalidMessage, msg, fmt.Sprintf(format, args...))
+}
+
 type metaMessage struct {
-	Typ  byte
+	Typ  Type
 	Data []byte
 }
 
 func (m *metaMessage) Bytes() []byte {
-	b := []byte{byte(0xFF), m.Typ}
+	b := []byte{byte(0xFF), byte(m.Typ)}
 	b = append(b, vlq.Encode(uint32(len(m.Data)))...)
 	if len(m.Data) != 0 {
 		b = append(b, m.Data...)
@@ -25,12 +36,97 @@ func (m *metaMessage) Bytes() []byte {
 	return b
 }
 
-func readText(rd io.Reader) (string, error) {
-	b, err := midilib.ReadVarLengthData(rd)
+// encodedLen returns the length of a meta message with the given length of data, i.e. the length of the
+// status byte, the type, the variable length quantity of the length and the data
+func encodedLen(dataLen int) int {
+	return 2 + vlq.Len(uint32(dataLen)) + dataLen
+}
+
+// textChunkSize is the size of the buffers that texts are read into from readers that are not in memory
+const textChunkSize = 512
+
+var textBuffers = sync.Pool{
+	New: func() any {
+		b := make([]byte, textChunkSize)
+		return &b
+	},
+}
+
+// textLimit is the maximal length of the texts that ReadText reads, 0 means no limit (see SetTextLimit)
+var textLimit atomic.Int64
+
+// SetTextLimit sets the maximal length in bytes of the texts that ReadText reads, and thereby of the texts of the
+// text meta messages that are read, e.g. by the smfreader. For a longer text, ReadText returns an error wrapping
+// ErrTextTooLong before reading the text. The limit applies globally; 0 (the default) means no limit.
+// It may be called from any goroutine.
+func SetTextLimit(n int) {
+	textLimit.Store(int64(max(n, 0)))
+}
+
+// ReadText reads text as it is stored within the text meta messages (e.g. Text, Lyric or Marker): the length
+// as variable length quantity, followed by the text. Custom meta messages may use it to read text the same way.
+//
+// If the reader is in memory (e.g. for smfreader.NewFromBytes), the text is copied directly from its data. Otherwise
+// it is read in chunks, so that a damaged length allocates no more memory than the reader provides.
+// midi.ErrUnexpectedEOF is returned, if the reader ends before the text, ErrTextTooLong, if the text is longer than
+// the limit (see SetTextLimit).
+func ReadText(rd io.Reader) (string, error) {
+	length, err := midilib.ReadVarLength(rd)
+
+	if err == io.EOF {
+		return "", midi.ErrUnexpectedEOF
+	}
 
 	if err != nil {
 		return "", err
 	}
 
-	return string(b), nil
+	if limit := textLimit.Load(); limit > 0 && int64(length) > limit {
+		return "", fmt.Errorf("%w: %v bytes (limit %v)", ErrTextTooLong, length, limit)
+	}
+
+	if sl, is := rd.(midilib.Slicer); is {
+		b, err := sl.Slice(int(length))
+
+		if err != nil {
+			return "", midi.ErrUnexpectedEOF
+		}
+
+		return string(b), nil
+	}
+
+	var sb strings.Builder
+	sb.Grow(min(int(length), textChunkSize))
+
+	buf := textBuffers.Get().(*[]byte)
+	defer textBuffers.Put(buf)
+
+	for rest := int(length); rest > 0; {
+		n, err := io.ReadFull(rd, (*buf)[:min(rest, textChunkSize)])
+		sb.Write((*buf)[:n])
+		rest -= n
+
+		if err != nil {
+			return "", midi.ErrUnexpectedEOF
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// AppendText appends the given text as it is stored within the text meta messages to dst and returns the
+// extended slice, see ReadText.
+func AppendText(dst []byte, text string) []byte {
+	return append(vlq.Append(dst, uint32(len(text))), text...)
+}
+
+// WriteText writes the given text as it is stored within the text meta messages, see ReadText.
+func WriteText(w io.Writer, text string) error {
+	_, err := w.Write(AppendText(make([]byte, 0, vlq.Len(uint32(len(text)))+len(text)), text))
+	return err
+}
+
+// textRaw returns the raw bytes of a text meta message of the given type
+func textRaw(typ Type, text string) []byte {
+	return AppendText(append(make([]byte, 0, encodedLen(len(text))), 0xFF, byte(typ)), text)
 }
@@ -0,0 +1,90 @@
+package meta
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/internal/midilib"
+)
+
+// streamReader hides the Slicer of a bytes.Reader
+type streamReader struct {
+	rd *bytes.Reader
+}
+
+func (s streamReader) Read(p []byte) (int, error) {
+	return s.rd.Read(p)
+}
+
+func TestReadText(t *testing.T) {
+	long := strings.Repeat("la ", 1000)
+
+	tests := []struct {
+		input    []byte
+		expected string
+		err      error
+	}{
+		{[]byte{0x00}, "", nil},
+		{[]byte{0x03, 'a', 'b', 'c', 'd'}, "abc", nil},
+		{AppendText(nil, "Grüße"), "Grüße", nil},
+		{AppendText(nil, long), long, nil},
+		{[]byte{0x04, 'a', 'b'}, "", midi.ErrUnexpectedEOF},
+		// a damaged length
+		{[]byte{0xFF, 0xFF, 0xFF, 0x7F, 'a'}, "", midi.ErrUnexpectedEOF},
+		{nil, "", midi.ErrUnexpectedEOF},
+	}
+
+	for i, test := range tests {
+		got, err := ReadText(streamReader{bytes.NewReader(test.input)})
+
+		if got != test.expected || err != test.err {
+			t.Errorf("[%v] ReadText() = %q, %v; want %q, %v", i, got, err, test.expected, test.err)
+		}
+
+		got, err = ReadText(midilib.NewSliceReader(test.input))
+
+		if got != test.expected || err != test.err {
+			t.Errorf("[%v] ReadText(SliceReader) = %q, %v; want %q, %v", i, got, err, test.expected, test.err)
+		}
+	}
+}
+
+func TestReadTextLimit(t *testing.T) {
+	SetTextLimit(4)
+	defer SetTextLimit(0)
+
+	if got, err := ReadText(streamReader{bytes.NewReader(AppendText(nil, "abcd"))}); got != "abcd" || err != nil {
+		t.Errorf("ReadText() = %q, %v; want %q, nil", got, err, "abcd")
+	}
+
+	for _, rd := range []io.Reader{streamReader{bytes.NewReader(AppendText(nil, "abcde"))}, midilib.NewSliceReader(AppendText(nil, "abcde"))} {
+		if _, err := ReadText(rd); !errors.Is(err, ErrTextTooLong) {
+			t.Errorf("ReadText() error = %v; want %v", err, ErrTextTooLong)
+		}
+	}
+
+	// the meta messages are read with the limit
+	if _, err := NewReader(bytes.NewReader(AppendText(nil, "verse 1")), byte(TypeLyric)).Read(); !errors.Is(err, ErrTextTooLong) {
+		t.Errorf("Read() error = %v; want %v", err, ErrTextTooLong)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var bf bytes.Buffer
+
+	if err := WriteText(&bf, "verse"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := bf.Bytes(), []byte{0x05, 'v', 'e', 'r', 's', 'e'}; !bytes.Equal(got, want) {
+		t.Errorf("WriteText() wrote % X; want % X", got, want)
+	}
+
+	if got, want := AppendText([]byte{0xFF, 0x06}, "verse"), Marker("verse").Raw(); !bytes.Equal(got, want) {
+		t.Errorf("AppendText() = % X; want % X", got, want)
+	}
+}
@@ -78,11 +78,24 @@ func (m Key) Raw() []byte {
 	}
 
 	return (&metaMessage{
-		Typ:  byteKeySignature,
+		Typ:  TypeKey,
 		Data: []byte{byte(sf), byte(mi)},
 	}).Bytes()
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Key) EncodedLen() int {
+	return encodedLen(2)
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if there are more than 7 sharps or flats
+func (m Key) Validate() error {
+	if m.Num > 7 {
+		return invalid(m, "key signature with %v sharps or flats", m.Num)
+	}
+	return nil
+}
+
 // String represents the key signature message as a string (for debugging)
 func (m Key) String() string {
 	return fmt.Sprintf("%T: %s", m, m.Text())
@@ -159,6 +172,19 @@ func (m Key) readFrom(rd io.Reader) (Message, error) {
 
 	sharpsOrFlats = int8(b)
 
+	var problem string
+
+	// there are no more than 7 sharps or flats
+	if sharpsOrFlats > 7 || sharpsOrFlats < -7 {
+		problem = fmt.Sprintf("key signature with %v sharps or flats", sharpsOrFlats)
+
+		if sharpsOrFlats > 0 {
+			sharpsOrFlats = 7
+		} else {
+			sharpsOrFlats = -7
+		}
+	}
+
 	// Mode is Major or Minor.
 	mode, err = midilib.ReadByte(rd)
 
@@ -173,12 +199,18 @@ func (m Key) readFrom(rd io.Reader) (Message, error) {
 
 	key := midilib.KeyFromSharpsOrFlats(sharpsOrFlats, mode)
 
-	return Key{
+	m = Key{
 		Key:     key,
 		Num:     uint8(num),
 		IsMajor: mode == majorMode,
 		IsFlat:  sharpsOrFlats < 0,
-	}, nil
+	}
+
+	if problem != "" {
+		return m, &InvalidValueError{Message: m, Problem: problem}
+	}
+
+	return m, nil
 
 }
 
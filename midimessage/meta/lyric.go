@@ -14,7 +14,7 @@ func (m Lyric) String() string {
 }
 
 func (m Lyric) readFrom(rd io.Reader) (Message, error) {
-	text, err := readText(rd)
+	text, err := ReadText(rd)
 
 	if err != nil {
 		return nil, err
@@ -25,10 +25,17 @@ func (m Lyric) readFrom(rd io.Reader) (Message, error) {
 
 // Raw returns the raw MIDI data
 func (m Lyric) Raw() []byte {
-	return (&metaMessage{
-		Typ:  byte(byteLyric),
-		Data: []byte(m),
-	}).Bytes()
+	return textRaw(TypeLyric, string(m))
+}
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Lyric) EncodedLen() int {
+	return encodedLen(len(m))
+}
+
+// Validate returns nil, since the text is prefixed by its length and may contain any bytes
+func (m Lyric) Validate() error {
+	return nil
 }
 
 // Text returns the text of the lyric
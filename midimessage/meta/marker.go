@@ -30,14 +30,21 @@ func (m Marker) Text() string {
 
 // Raw returns the raw MIDI data
 func (m Marker) Raw() []byte {
-	return (&metaMessage{
-		Typ:  byte(byteMarker),
-		Data: []byte(m),
-	}).Bytes()
+	return textRaw(TypeMarker, string(m))
+}
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Marker) EncodedLen() int {
+	return encodedLen(len(m))
+}
+
+// Validate returns nil, since the text is prefixed by its length and may contain any bytes
+func (m Marker) Validate() error {
+	return nil
 }
 
 func (m Marker) readFrom(rd io.Reader) (Message, error) {
-	text, err := readText(rd)
+	text, err := ReadText(rd)
 
 	if err != nil {
 		return nil, err
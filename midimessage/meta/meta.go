@@ -82,3 +82,14 @@ func (m metaTimeCodeQuarter) String() string {
 
 func (m metaTimeCodeQuarter) meta() {}
 */
+
+// FirstTrackOnly returns true, if the given message may only be placed in the first track of a format 1 SMF:
+// Time Signature, Key Signature, SMPTE Offset, Marker and Cue Point
+func FirstTrackOnly(msg Message) bool {
+	switch msg.(type) {
+	case TimeSig, Key, SMPTE, Marker, Cuepoint:
+		return true
+	default:
+		return false
+	}
+}
@@ -40,9 +40,17 @@ func TestMessagesString(t *testing.T) {
 			Marker("TODO"),
 			"meta.Marker: \"TODO\"",
 		},
+		{
+			Channel(0),
+			"meta.Channel: 1",
+		},
 		{
 			Channel(3),
-			"meta.Channel: 3",
+			"meta.Channel: 4",
+		},
+		{
+			Channel(15),
+			"meta.Channel: 16",
 		},
 		{
 			Port(10),
@@ -23,11 +23,24 @@ func (m Port) String() string {
 // Raw returns the raw MIDI data
 func (m Port) Raw() []byte {
 	return (&metaMessage{
-		Typ:  byte(byteMIDIPort),
+		Typ:  TypePort,
 		Data: []byte{byte(m)},
 	}).Bytes()
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Port) EncodedLen() int {
+	return encodedLen(1)
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the port is beyond 127
+func (m Port) Validate() error {
+	if m > 127 {
+		return invalid(m, "port %v out of range 0-127", uint8(m))
+	}
+	return nil
+}
+
 func (m Port) meta() {}
 
 func (m Port) readFrom(rd io.Reader) (Message, error) {
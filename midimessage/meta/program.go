@@ -33,14 +33,21 @@ func (p Program) Text() string {
 
 // Raw returns the raw bytes for the message
 func (p Program) Raw() []byte {
-	return (&metaMessage{
-		Typ:  byte(byteProgramName),
-		Data: []byte(p),
-	}).Bytes()
+	return textRaw(TypeProgram, string(p))
+}
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (p Program) EncodedLen() int {
+	return encodedLen(len(p))
+}
+
+// Validate returns nil, since the text is prefixed by its length and may contain any bytes
+func (p Program) Validate() error {
+	return nil
 }
 
 func (p Program) readFrom(rd io.Reader) (Message, error) {
-	text, err := readText(rd)
+	text, err := ReadText(rd)
 
 	if err != nil {
 		return nil, err
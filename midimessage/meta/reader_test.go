@@ -5,6 +5,7 @@ import (
 
 	// "github.com/gomidi/midi/internal/midilib"
 	// "fmt"
+	"errors"
 	"io"
 	"testing"
 
@@ -75,7 +76,7 @@ func TestRead(t *testing.T) {
 		),
 		mkTest(
 			Channel(3),
-			"meta.Channel: 3",
+			"meta.Channel: 4",
 		),
 		mkTest(
 			Port(10),
@@ -143,3 +144,31 @@ func TestRead(t *testing.T) {
 	}
 
 }
+
+func TestReadTruncated(t *testing.T) {
+	msgs := []midi.Message{
+		Copyright("(c) 2017"),
+		Channel(3),
+		EndOfTrack,
+		Key{Key: 2, IsMajor: true, Num: 2},
+		Port(2),
+		SequenceNo(2),
+		SequencerData([]byte{0x7D, 0x01}),
+		SMPTE{1, 2, 3, 4, 5},
+		Tempo(500000),
+		TimeSig{3, 4, 8, 8},
+	}
+
+	for _, msg := range msgs {
+		raw := msg.Raw()
+
+		// after the status byte and the type, there is always at least the length
+		for n := 2; n < len(raw); n++ {
+			_, err := NewReader(bytes.NewReader(raw[2:n]), raw[1]).Read()
+
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("Read(% X) of %s = %v; want io.ErrUnexpectedEOF", raw[2:n], msg, err)
+			}
+		}
+	}
+}
@@ -13,7 +13,7 @@ func (m Sequence) String() string {
 	return fmt.Sprintf("%T: %#v", m, m.Text())
 }
 func (m Sequence) readFrom(rd io.Reader) (Message, error) {
-	text, err := readText(rd)
+	text, err := ReadText(rd)
 
 	if err != nil {
 		return nil, err
@@ -32,8 +32,15 @@ func (m Sequence) meta() {}
 
 // Raw returns the raw bytes for the message
 func (m Sequence) Raw() []byte {
-	return (&metaMessage{
-		Typ:  byteSequence,
-		Data: []byte(m),
-	}).Bytes()
+	return textRaw(TypeSequence, string(m))
+}
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Sequence) EncodedLen() int {
+	return encodedLen(len(m))
+}
+
+// Validate returns nil, since the text is prefixed by its length and may contain any bytes
+func (m Sequence) Validate() error {
+	return nil
 }
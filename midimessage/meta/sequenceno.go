@@ -1,12 +1,11 @@
 package meta
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
 	"io"
 
 	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/midibinary"
 )
 
 // SequenceNo represents the sequence number MIDI meta message
@@ -24,14 +23,22 @@ func (s SequenceNo) String() string {
 
 // Raw returns the raw bytes for the message
 func (s SequenceNo) Raw() []byte {
-	var bf bytes.Buffer
-	binary.Write(&bf, binary.BigEndian, s.Number())
 	return (&metaMessage{
-		Typ:  byteSequenceNumber,
-		Data: bf.Bytes(),
+		Typ:  TypeSequenceNo,
+		Data: midibinary.AppendUint16(nil, s.Number()),
 	}).Bytes()
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (s SequenceNo) EncodedLen() int {
+	return encodedLen(2)
+}
+
+// Validate returns nil, since any sequence number can be encoded
+func (s SequenceNo) Validate() error {
+	return nil
+}
+
 func (s SequenceNo) readFrom(rd io.Reader) (Message, error) {
 	length, err := midilib.ReadByte(rd)
 
@@ -46,7 +53,7 @@ func (s SequenceNo) readFrom(rd io.Reader) (Message, error) {
 
 	// Otherwise length will be 2 to hold the uint16.
 	var sequenceNumber uint16
-	sequenceNumber, err = midilib.ReadUint16(rd)
+	sequenceNumber, err = midibinary.ReadUint16(rd)
 
 	if err != nil {
 		return nil, err
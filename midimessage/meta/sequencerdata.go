@@ -27,11 +27,21 @@ func (s SequencerData) Data() []byte {
 // Raw returns the raw MIDI data
 func (s SequencerData) Raw() []byte {
 	return (&metaMessage{
-		Typ:  byteSequencerSpecific,
+		Typ:  TypeSequencerData,
 		Data: s.Data(),
 	}).Bytes()
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (s SequencerData) EncodedLen() int {
+	return encodedLen(len(s))
+}
+
+// Validate returns nil, since the data is prefixed by its length and may contain any bytes
+func (s SequencerData) Validate() error {
+	return nil
+}
+
 // Len returns the length of the sequencer specific data
 func (s SequencerData) Len() int {
 	return len(s)
@@ -36,11 +36,35 @@ type SMPTE struct {
 // Raw returns the raw bytes for the message
 func (s SMPTE) Raw() []byte {
 	return (&metaMessage{
-		Typ:  byteSMPTEOffset,
+		Typ:  TypeSMPTE,
 		Data: []byte{s.Hour, s.Minute, s.Second, s.Frame, s.FractionalFrame},
 	}).Bytes()
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (s SMPTE) EncodedLen() int {
+	return encodedLen(5)
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if a field is out of range: the hours 0-23
+// (the bits 5 and 6 may hold the frame rate), the minutes and seconds 0-59, the frames 0-29 and the fractional
+// frames 0-99.
+func (s SMPTE) Validate() error {
+	switch {
+	case s.Hour > 0x7F || s.Hour&0x1F > 23:
+		return invalid(s, "hour byte %02X out of range", s.Hour)
+	case s.Minute > 59:
+		return invalid(s, "minute %v out of range 0-59", s.Minute)
+	case s.Second > 59:
+		return invalid(s, "second %v out of range 0-59", s.Second)
+	case s.Frame > 29:
+		return invalid(s, "frame %v out of range 0-29", s.Frame)
+	case s.FractionalFrame > 99:
+		return invalid(s, "fractional frame %v out of range 0-99", s.FractionalFrame)
+	}
+	return nil
+}
+
 // String represents the smpte offset MIDI message as a string (for debugging)
 func (s SMPTE) String() string {
 	return fmt.Sprintf("%T %v:%v:%v %v.%0d", s, s.Hour, s.Minute, s.Second, s.Frame, s.FractionalFrame)
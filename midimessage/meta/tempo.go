@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"math/big"
 
 	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/midibinary"
 )
 
 const bpmFac = 60000000
@@ -16,15 +16,32 @@ func BPM(bpm uint32) Tempo {
 	return FractionalBPM(float64(bpm))
 }
 
-// FractionalBPM returns the meta tempo message that corresponds to the given fractional bpm (beats per minute) value
+// maxTempo is the largest tempo in microseconds per quarter note that can be expressed in a tempo message
+const maxTempo = 0xFFFFFF
+
+// FractionalBPM returns the meta tempo message that corresponds to the given fractional bpm (beats per minute) value.
+// Tempos beyond the range of a tempo message (including 0 and negative bpm) are clamped to it.
 func FractionalBPM(fbpm float64) Tempo {
-	return Tempo(uint32(math.Round(bpmFac / fbpm)))
+	if fbpm <= 0 {
+		return Tempo(maxTempo)
+	}
+
+	t := math.Round(bpmFac / fbpm)
+
+	switch {
+	case t < 1:
+		return Tempo(1)
+	case t > maxTempo:
+		return Tempo(maxTempo)
+	}
+
+	return Tempo(uint32(t))
 }
 
 // Tempo represents a MIDI tempo (change) message in microseconds per crotchet
 type Tempo uint32
 
-// BPM returns the tempo in beats per minute
+// BPM returns the tempo in beats per minute. For the invalid tempo of 0 microseconds per quarter note, 0 is returned.
 func (m Tempo) BPM() uint32 {
 	return uint32(math.Round(m.FractionalBPM()))
 }
@@ -34,8 +51,12 @@ func (m Tempo) MuSecPerQN() uint32 {
 	return uint32(m)
 }
 
-// FractionalBPM returns the tempo in fractional beats per minute
+// FractionalBPM returns the tempo in fractional beats per minute.
+// For the invalid tempo of 0 microseconds per quarter note, 0 is returned.
 func (m Tempo) FractionalBPM() float64 {
+	if m == 0 {
+		return 0
+	}
 	return float64(bpmFac) / float64(m)
 }
 
@@ -44,35 +65,38 @@ func (m Tempo) String() string {
 	return fmt.Sprintf("%T BPM: %0.2f", m, m.FractionalBPM())
 }
 
-// Raw returns the raw MIDI data
+// Raw returns the raw MIDI data.
+// Tempos beyond the range of a tempo message (1 to 16777215 microseconds per quarter note) are clamped to it.
 func (m Tempo) Raw() []byte {
 	r := uint32(m)
-	if r > 0x0FFFFFFF {
-		r = 0x0FFFFFFF
-	}
 
-	b4 := big.NewInt(int64(r)).Bytes()
-
-	var b = []byte{0, 0, 0}
-	switch len(b4) {
-	case 0:
-	case 1:
-		b[2] = b4[0]
-	case 2:
-		b[2] = b4[1]
-		b[1] = b4[0]
-	case 3:
-		b[2] = b4[2]
-		b[1] = b4[1]
-		b[0] = b4[0]
+	switch {
+	case r < 1:
+		r = 1
+	case r > maxTempo:
+		r = maxTempo
 	}
 
 	return (&metaMessage{
-		Typ:  byteTempo,
-		Data: b,
+		Typ:  TypeTempo,
+		Data: midibinary.AppendUint24(nil, r),
 	}).Bytes()
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Tempo) EncodedLen() int {
+	return encodedLen(3)
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the tempo is beyond the range of a tempo message
+// (1 to 16777215 microseconds per quarter note), where Raw would clamp it.
+func (m Tempo) Validate() error {
+	if m < 1 || m > maxTempo {
+		return invalid(m, "tempo of %v microseconds per quarter note out of range 1-%v", uint32(m), maxTempo)
+	}
+	return nil
+}
+
 func (m Tempo) meta() {}
 
 func (m Tempo) readFrom(rd io.Reader) (Message, error) {
@@ -88,10 +112,14 @@ func (m Tempo) readFrom(rd io.Reader) (Message, error) {
 	}
 
 	var microsecondsPerCrotchet uint32
-	microsecondsPerCrotchet, err = midilib.ReadUint24(rd)
+	microsecondsPerCrotchet, err = midibinary.ReadUint24(rd)
 	if err != nil {
 		return nil, err
 	}
 
+	if microsecondsPerCrotchet == 0 {
+		return Tempo(1), &InvalidValueError{Message: Tempo(1), Problem: "tempo of 0 microseconds per quarter note"}
+	}
+
 	return Tempo(microsecondsPerCrotchet), nil
 }
@@ -30,3 +30,138 @@ func TestTempo2(t *testing.T) {
 		t.Errorf("got % X wanted: % X", got, want)
 	}
 }
+
+func TestTempoZero(t *testing.T) {
+	// FF 51 03 00 00 00
+	bt := []byte{0x03, 0x00, 0x00, 0x00}
+
+	var tm Tempo
+	tt, err := tm.readFrom(bytes.NewBuffer(bt))
+
+	ive, is := err.(*InvalidValueError)
+
+	if !is {
+		t.Fatalf("readFrom() error = %v; want *InvalidValueError", err)
+	}
+
+	if got, want := tt, Tempo(1); got != want {
+		t.Errorf("readFrom() = %v; want %v", got, want)
+	}
+
+	if got, want := ive.Message, Tempo(1); got != want {
+		t.Errorf("InvalidValueError.Message = %v; want %v", got, want)
+	}
+
+	if got, want := Tempo(0).BPM(), uint32(0); got != want {
+		t.Errorf("Tempo(0).BPM() = %v; want %v", got, want)
+	}
+}
+
+func TestTempoClamp(t *testing.T) {
+	tests := []struct {
+		tempo    Tempo
+		expected []byte
+	}{
+		{Tempo(0), []byte{0xFF, 0x51, 0x03, 0x00, 0x00, 0x01}},
+		{Tempo(0x1000000), []byte{0xFF, 0x51, 0x03, 0xFF, 0xFF, 0xFF}},
+		{BPM(0), []byte{0xFF, 0x51, 0x03, 0xFF, 0xFF, 0xFF}},
+		{FractionalBPM(-1), []byte{0xFF, 0x51, 0x03, 0xFF, 0xFF, 0xFF}},
+		{FractionalBPM(1e9), []byte{0xFF, 0x51, 0x03, 0x00, 0x00, 0x01}},
+		{BPM(4), []byte{0xFF, 0x51, 0x03, 0xE4, 0xE1, 0xC0}},
+		{BPM(1), []byte{0xFF, 0x51, 0x03, 0xFF, 0xFF, 0xFF}},
+	}
+
+	for i, test := range tests {
+		if got, want := test.tempo.Raw(), test.expected; !reflect.DeepEqual(got, want) {
+			t.Errorf("[%v] Raw() = % X; want % X", i, got, want)
+		}
+	}
+}
+
+func TestTimeSigInvalidDenominator(t *testing.T) {
+	tests := []struct {
+		dd          byte
+		denominator uint8
+		invalid     bool
+	}{
+		{0x00, 1, false},
+		{0x01, 2, false},
+		{0x02, 4, false},
+		{0x07, 128, false},
+		{0x08, 128, true},
+		{0x09, 128, true},
+		{0x1F, 128, true},
+		{0x20, 128, true},
+		{0xFF, 128, true},
+	}
+
+	for _, test := range tests {
+		// FF 58 04 04 dd 18 08
+		bt := []byte{0x04, 0x04, test.dd, 0x18, 0x08}
+
+		var ts TimeSig
+		msg, err := ts.readFrom(bytes.NewBuffer(bt))
+
+		if _, is := err.(*InvalidValueError); is != test.invalid {
+			t.Errorf("[%X] readFrom() error = %v; want invalid: %v", test.dd, err, test.invalid)
+		}
+
+		if got, want := msg.(TimeSig).Denominator, test.denominator; got != want {
+			t.Errorf("[%X] Denominator = %v; want %v", test.dd, got, want)
+		}
+
+		// the clamped denominator survives the round trip
+		if got, want := msg.Raw()[4], min(test.dd, 7); got != want {
+			t.Errorf("[%X] Raw() dd = %X; want %X", test.dd, got, want)
+		}
+	}
+}
+
+func TestTimeSigRawDenominator(t *testing.T) {
+	tests := []struct {
+		denominator uint8
+		dd          byte
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 1},
+		{3, 1},
+		{4, 2},
+		{127, 6},
+		{128, 7},
+		{129, 7},
+		{255, 7},
+	}
+
+	for _, test := range tests {
+		if got, want := (TimeSig{Numerator: 4, Denominator: test.denominator}).Raw()[4], test.dd; got != want {
+			t.Errorf("[%v] Raw() dd = %X; want %X", test.denominator, got, want)
+		}
+	}
+}
+
+func TestKeyInvalidSharpsOrFlats(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected string
+	}{
+		// FF 59 02 80 00
+		{[]byte{0x02, 0x80, 0x00}, "B maj."},
+		// FF 59 02 7F 01
+		{[]byte{0x02, 0x7F, 0x01}, "A♯ min."},
+	}
+
+	for i, test := range tests {
+		var k Key
+		msg, err := k.readFrom(bytes.NewBuffer(test.input))
+
+		if _, is := err.(*InvalidValueError); !is {
+			t.Errorf("[%v] readFrom() error = %v; want *InvalidValueError", i, err)
+			continue
+		}
+
+		if got, want := msg.(Key).Text(), test.expected; got != want {
+			t.Errorf("[%v] readFrom() = %q; want %q", i, got, want)
+		}
+	}
+}
@@ -17,14 +17,21 @@ func (m Text) meta() {}
 
 // Raw returns the raw bytes for the message
 func (m Text) Raw() []byte {
-	return (&metaMessage{
-		Typ:  byteText,
-		Data: []byte(m),
-	}).Bytes()
+	return textRaw(TypeText, string(m))
+}
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Text) EncodedLen() int {
+	return encodedLen(len(m))
+}
+
+// Validate returns nil, since the text is prefixed by its length and may contain any bytes
+func (m Text) Validate() error {
+	return nil
 }
 
 func (m Text) readFrom(rd io.Reader) (Message, error) {
-	text, err := readText(rd)
+	text, err := ReadText(rd)
 	if err != nil {
 		return nil, err
 	}
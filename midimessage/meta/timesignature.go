@@ -62,12 +62,26 @@ func (m TimeSig) Raw() []byte {
 	var denom = dec2binDenom(m.Denominator)
 
 	return (&metaMessage{
-		Typ:  byteTimeSignature,
+		Typ:  TypeTimeSig,
 		Data: []byte{m.Numerator, denom, cpcl, dsqpq},
 	}).Bytes()
 
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m TimeSig) EncodedLen() int {
+	return encodedLen(4)
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the denominator is not a power of 2
+// between 1 and 128, since Raw can't encode it.
+func (m TimeSig) Validate() error {
+	if m.Denominator == 0 || m.Denominator&(m.Denominator-1) != 0 {
+		return invalid(m, "denominator %v is not a power of 2", m.Denominator)
+	}
+	return nil
+}
+
 // Signature returns the time signature in a readable way
 func (m TimeSig) Signature() string {
 	return fmt.Sprintf("%v/%v", m.Numerator, m.Denominator)
@@ -79,18 +93,15 @@ func (m TimeSig) String() string {
 	//return fmt.Sprintf("%T %v/%v", m, m.Numerator, m.Denominator)
 }
 
-// dec2binDenom converts the decimal denominator to the binary one
-// it works, use it!
+// dec2binDenom converts the decimal denominator to the binary one.
+// Denominators that are no power of 2 are rounded down to one, so that the result is within 0-7,
+// like the denominators that are read (see readFrom).
 func dec2binDenom(dec uint8) (bin uint8) {
-	if dec <= 1 {
-		return 0
-	}
-	for dec > 2 {
+	for dec > 1 {
 		bin++
 		dec = dec >> 1
-
 	}
-	return bin + 1
+	return bin
 }
 
 func (m TimeSig) readFrom(rd io.Reader) (Message, error) {
@@ -136,25 +147,20 @@ func (m TimeSig) readFrom(rd io.Reader) (Message, error) {
 	m.DemiSemiQuaverPerQuarter = demiSemiQuaverPerQuarter
 	m.ClocksPerClick = clocksPerClick
 	m.Numerator = numerator
+
+	// the largest power of 2 that fits into the Denominator is 2^7
+	if denominator > 7 {
+		m.Denominator = bin2decDenom(7)
+		return m, &InvalidValueError{Message: m, Problem: fmt.Sprintf("time signature denominator of 2^%v", denominator)}
+	}
+
 	m.Denominator = bin2decDenom(denominator)
 	return m, nil
-	/*
-		// TODO: do something with clocksPerClick and demiSemiQuaverPerQuarter
-		var _ = clocksPerClick
-		var _ = demiSemiQuaverPerQuarter
-
-		return TimeSignature{
-			Numerator:   numerator,
-			Denominator: 2 << (denominator - 1),
-			// ClocksPerClick:           clocksPerClick,
-			// DemiSemiQuaverPerQuarter: demiSemiQuaverPerQuarter,
-		}, nil
-	*/
 }
 
 func (m TimeSig) meta() {}
 
-// bin2decDenom converts the binary denominator to the decimal
+// bin2decDenom converts the binary denominator (0-7) to the decimal
 func bin2decDenom(bin uint8) uint8 {
 	if bin == 0 {
 		return 1
@@ -15,14 +15,21 @@ func (m Track) String() string {
 
 // Raw returns the raw MIDI data
 func (m Track) Raw() []byte {
-	return (&metaMessage{
-		Typ:  byteTrack,
-		Data: []byte(m),
-	}).Bytes()
+	return textRaw(TypeTrack, string(m))
+}
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Track) EncodedLen() int {
+	return encodedLen(len(m))
+}
+
+// Validate returns nil, since the text is prefixed by its length and may contain any bytes
+func (m Track) Validate() error {
+	return nil
 }
 
 func (m Track) readFrom(rd io.Reader) (Message, error) {
-	text, err := readText(rd)
+	text, err := ReadText(rd)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,117 @@
+package meta
+
+import (
+	"fmt"
+)
+
+// Type is the type byte of a meta message (FF <type> <len> <data>)
+type Type byte
+
+// The types of the standard meta messages, named after the messages of this package
+const (
+	TypeSequenceNo    Type = 0x00 // SequenceNo
+	TypeText          Type = 0x01 // Text
+	TypeCopyright     Type = 0x02 // Copyright
+	TypeSequence      Type = 0x03 // Sequence (sequence or track name)
+	TypeTrack         Type = 0x04 // Track (instrument name)
+	TypeLyric         Type = 0x05 // Lyric
+	TypeMarker        Type = 0x06 // Marker
+	TypeCuepoint      Type = 0x07 // Cuepoint
+	TypeProgram       Type = 0x08 // Program (program name)
+	TypeDevice        Type = 0x09 // Device (device or port name)
+	TypeChannel       Type = 0x20 // Channel (MIDI channel prefix)
+	TypePort          Type = 0x21 // Port (MIDI port)
+	TypeEndOfTrack    Type = 0x2F // EndOfTrack
+	TypeTempo         Type = 0x51 // Tempo
+	TypeSMPTE         Type = 0x54 // SMPTE (SMPTE offset)
+	TypeTimeSig       Type = 0x58 // TimeSig (time signature)
+	TypeKey           Type = 0x59 // Key (key signature)
+	TypeSequencerData Type = 0x7F // SequencerData (sequencer specific)
+)
+
+var typeNames = map[Type]string{
+	TypeSequenceNo:    "Sequence Number",
+	TypeText:          "Text",
+	TypeCopyright:     "Copyright",
+	TypeSequence:      "Sequence/Track Name",
+	TypeTrack:         "Instrument Name",
+	TypeLyric:         "Lyric",
+	TypeMarker:        "Marker",
+	TypeCuepoint:      "Cue Point",
+	TypeProgram:       "Program Name",
+	TypeDevice:        "Device Name",
+	TypeChannel:       "MIDI Channel Prefix",
+	TypePort:          "MIDI Port",
+	TypeEndOfTrack:    "End of Track",
+	TypeTempo:         "Tempo",
+	TypeSMPTE:         "SMPTE Offset",
+	TypeTimeSig:       "Time Signature",
+	TypeKey:           "Key Signature",
+	TypeSequencerData: "Sequencer Specific",
+}
+
+// String returns the name of the type as in the SMF specification, e.g. "Time Signature".
+// Undefined types are named by their hex value, e.g. "Undefined 60".
+func (t Type) String() string {
+	if name, has := typeNames[t]; has {
+		return name
+	}
+
+	if IsText(t) {
+		return fmt.Sprintf("Text %02X", byte(t))
+	}
+
+	return fmt.Sprintf("Undefined %02X", byte(t))
+}
+
+// IsText returns true, if the type is reserved for text messages (0x01 to 0x0F)
+func IsText(t Type) bool {
+	return t >= 0x01 && t <= 0x0F
+}
+
+// TypeOf returns the type of the given meta message
+func TypeOf(msg Message) Type {
+	switch m := msg.(type) {
+	case SequenceNo:
+		return TypeSequenceNo
+	case Text:
+		return TypeText
+	case Copyright:
+		return TypeCopyright
+	case Sequence:
+		return TypeSequence
+	case Track:
+		return TypeTrack
+	case Lyric:
+		return TypeLyric
+	case Marker:
+		return TypeMarker
+	case Cuepoint:
+		return TypeCuepoint
+	case Program:
+		return TypeProgram
+	case Device:
+		return TypeDevice
+	case Channel:
+		return TypeChannel
+	case Port:
+		return TypePort
+	case endOfTrack:
+		return TypeEndOfTrack
+	case Tempo:
+		return TypeTempo
+	case SMPTE:
+		return TypeSMPTE
+	case TimeSig:
+		return TypeTimeSig
+	case Key:
+		return TypeKey
+	case SequencerData:
+		return TypeSequencerData
+	case Undefined:
+		return m.Typ
+	default:
+		// can't happen for the messages of this package
+		return Type(msg.Raw()[1])
+	}
+}
@@ -0,0 +1,61 @@
+package meta
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTypeOf(t *testing.T) {
+	for typ, msg := range metaMessages {
+		if got := TypeOf(msg); got != typ {
+			t.Errorf("TypeOf(%T) = %v; want %v", msg, got, typ)
+		}
+
+		// the raw data agrees too
+		if raw := msg.Raw(); Type(raw[1]) != typ {
+			t.Errorf("type byte of %T = %02X; want %02X", msg, raw[1], byte(typ))
+		}
+	}
+
+	if got, want := TypeOf(Undefined{Typ: 0x60}), Type(0x60); got != want {
+		t.Errorf("TypeOf(Undefined) = %v; want %v", got, want)
+	}
+
+	// NewReader reads the message of the type
+	msg, err := NewReader(bytes.NewReader([]byte{0x03, 0x07, 0xA1, 0x20}), byte(TypeTempo)).Read()
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if TypeOf(msg) != TypeTempo {
+		t.Errorf("TypeOf(%T) = %v; want %v", msg, TypeOf(msg), TypeTempo)
+	}
+}
+
+func TestTypeString(t *testing.T) {
+	tests := []struct {
+		typ      Type
+		expected string
+	}{
+		{TypeTimeSig, "Time Signature"},
+		{TypeSequence, "Sequence/Track Name"},
+		{TypeSequencerData, "Sequencer Specific"},
+		{0x0A, "Text 0A"},
+		{0x60, "Undefined 60"},
+	}
+
+	for _, test := range tests {
+		if got := test.typ.String(); got != test.expected {
+			t.Errorf("Type(%02X).String() = %#v; want %#v", byte(test.typ), got, test.expected)
+		}
+	}
+}
+
+func TestIsText(t *testing.T) {
+	for typ := range 256 {
+		if got, want := IsText(Type(typ)), typ >= 0x01 && typ <= 0x0F; got != want {
+			t.Errorf("IsText(%02X) = %v; want %v", typ, got, want)
+		}
+	}
+}
@@ -8,13 +8,13 @@ import (
 
 // Undefined represents an undefined meta message
 type Undefined struct {
-	Typ  byte
+	Typ  Type
 	Data []byte
 }
 
 // String represents the undefined meta message as a string (for debugging)
 func (m Undefined) String() string {
-	return fmt.Sprintf("%T type: % X", m, m.Typ)
+	return fmt.Sprintf("%T type: % X", m, byte(m.Typ))
 }
 
 // Raw returns the raw MIDI data
@@ -25,6 +25,26 @@ func (m Undefined) Raw() []byte {
 	}).Bytes()
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m Undefined) EncodedLen() int {
+	return encodedLen(len(m.Data))
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the type is beyond 127 or if it is the type
+// of a defined message, since the length of its data has to match the type (e.g. 3 bytes for a tempo).
+// The data may contain any bytes, since it is prefixed by its length.
+func (m Undefined) Validate() error {
+	if m.Typ > 0x7F {
+		return invalid(m, "type %02X out of range 00-7F", byte(m.Typ))
+	}
+
+	if _, defined := metaMessages[m.Typ]; defined {
+		return invalid(m, "type %02X is defined (%v)", byte(m.Typ), m.Typ)
+	}
+
+	return nil
+}
+
 func (m Undefined) readFrom(rd io.Reader) (Message, error) {
 	data, err := midilib.ReadVarLengthData(rd)
 
@@ -0,0 +1,63 @@
+package meta
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gomidi/midi"
+)
+
+func TestValidate(t *testing.T) {
+	invalid := []Message{
+		Channel(16),
+		Port(128),
+		Tempo(0),
+		Tempo(maxTempo + 1),
+		SMPTE{Hour: 24},
+		SMPTE{Hour: 0x80},
+		SMPTE{Minute: 60},
+		SMPTE{Second: 60},
+		SMPTE{Frame: 30},
+		SMPTE{FractionalFrame: 100},
+		TimeSig{Numerator: 3, Denominator: 0},
+		TimeSig{Numerator: 3, Denominator: 6},
+		Key{Num: 8},
+		Undefined{Typ: 0x80},
+		// the length of the data doesn't match the type
+		Undefined{Typ: TypeTempo, Data: []byte{0x01}},
+		Undefined{Typ: TypeEndOfTrack},
+	}
+
+	for _, msg := range invalid {
+		if err := midi.Validate(msg); !errors.Is(err, midi.ErrInvalidMessage) {
+			t.Errorf("Validate(%#v) = %v; want %v", msg, err, midi.ErrInvalidMessage)
+		}
+	}
+
+	valid := []Message{
+		Text("raw \xF7 and \xFF"),
+		Lyric("\xF0"),
+		SequencerData{0xF7, 0xF0},
+		Channel(15),
+		Port(127),
+		Tempo(maxTempo),
+		// 01:02:03 at 30 fps (frame rate bits 11)
+		SMPTE{Hour: 0x61, Minute: 2, Second: 3, Frame: 29, FractionalFrame: 99},
+		TimeSig{Numerator: 7, Denominator: 8},
+		Key{Num: 7, IsFlat: true},
+		Undefined{Typ: 0x60, Data: []byte{0xF7}},
+		EndOfTrack,
+	}
+
+	for _, msg := range valid {
+		if err := midi.Validate(msg); err != nil {
+			t.Errorf("Validate(%#v) = %v; want nil", msg, err)
+		}
+	}
+
+	for typ, msg := range metaMessages {
+		if _, ok := msg.(interface{ Validate() error }); !ok {
+			t.Errorf("%T (type %v) has no Validate method", msg, typ)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package midimessage_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/syscommon"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/midireader"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// messagePackages are the packages that define messages
+var messagePackages = []string{"channel", "meta", "realtime", "syscommon", "sysex"}
+
+// instances has an instance of each exported message type. The messages of unexported types are
+// instances of the exported variables.
+var instances = []midi.Message{
+	channel.Channel3.Aftertouch(20),
+	channel.Channel3.ControlChange(7, 100),
+	channel.Channel3.NoteOff(60),
+	channel.Channel3.NoteOffVelocity(60, 40),
+	channel.Channel3.NoteOn(60, 100),
+	channel.Channel3.Pitchbend(-4000),
+	channel.Channel3.PolyAftertouch(60, 30),
+	channel.Channel3.ProgramChange(12),
+
+	meta.Channel(4),
+	meta.Copyright("(c) 2017"),
+	meta.Cuepoint("verse"),
+	meta.Device("synth"),
+	meta.EndOfTrack,
+	meta.Key{Key: 2, IsMajor: true, Num: 2},
+	meta.Lyric("la"),
+	meta.Marker("chorus"),
+	meta.Port(3),
+	meta.Program("piano"),
+	meta.Sequence("song"),
+	meta.SequenceNo(7),
+	meta.SequencerData{0x7D, 0x01},
+	meta.SMPTE{Hour: 1, Minute: 2, Second: 3, Frame: 4, FractionalFrame: 5},
+	meta.Tempo(500000),
+	meta.Text("text"),
+	meta.TimeSig{Numerator: 6, Denominator: 8, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8},
+	meta.Track("drums"),
+	meta.Undefined{Typ: 0x60, Data: []byte{1, 2, 3}},
+
+	realtime.TimingClock,
+
+	syscommon.MTC(0x35),
+	syscommon.SongSelect(3),
+	syscommon.SPP(300),
+	syscommon.Tune,
+
+	sysex.SysEx{0x7D, 0x01, 0x02},
+	sysex.Start{0x7D, 0x01},
+	sysex.Continue{0x02, 0x03},
+	sysex.End{0x04},
+	sysex.Escape{0xF8},
+}
+
+// exportedMessageTypes returns the names of the exported types with a Raw method of the message packages,
+// e.g. "meta.Tempo"
+func exportedMessageTypes(t *testing.T) (names []string) {
+	for _, pkg := range messagePackages {
+		pkgs, err := parser.ParseDir(token.NewFileSet(), pkg, func(fi fs.FileInfo) bool {
+			return !strings.HasSuffix(fi.Name(), "_test.go")
+		}, 0)
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		for _, p := range pkgs {
+			for _, f := range p.Files {
+				for _, decl := range f.Decls {
+					fn, is := decl.(*ast.FuncDecl)
+
+					if !is || fn.Name.Name != "Raw" || fn.Recv == nil {
+						continue
+					}
+
+					if id, is := fn.Recv.List[0].Type.(*ast.Ident); is && id.IsExported() {
+						names = append(names, pkg+"."+id.Name)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return
+}
+
+// raw returns the raw bytes of the given message and whether Raw panics
+func raw(msg midi.Message) (b []byte, panics bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panics = true
+		}
+	}()
+
+	return msg.Raw(), false
+}
+
+// reparse writes the given message and reads it back: realtime and system common messages on the wire, the other
+// messages within a SMF
+func reparse(msg midi.Message) (midi.Message, error) {
+	switch msg.(type) {
+	case realtime.Message, syscommon.Message:
+		var rt midi.Message
+		rd := midireader.New(bytes.NewReader(msg.Raw()), func(m realtime.Message) { rt = m }, midireader.NoteOffVelocity())
+		m, err := rd.Read()
+
+		if rt != nil {
+			return rt, nil
+		}
+
+		return m, err
+	}
+
+	var bf bytes.Buffer
+	wr := smfwriter.New(&bf)
+
+	// within a SMF, continued and ending sysex messages are escapes, if they don't follow a starting sysex message
+	var continued bool
+
+	switch msg.(type) {
+	case sysex.Continue, sysex.End:
+		continued = true
+		wr.Write(sysex.Start{0x7D})
+	}
+
+	if err := wr.Write(msg); err != nil && err != smf.ErrFinished {
+		return nil, err
+	}
+
+	if msg != meta.EndOfTrack {
+		if err := wr.Write(meta.EndOfTrack); err != nil && err != smf.ErrFinished {
+			return nil, err
+		}
+	}
+
+	rd := smfreader.New(bytes.NewReader(bf.Bytes()), smfreader.NoteOffVelocity())
+
+	if err := rd.ReadHeader(); err != nil {
+		return nil, err
+	}
+
+	if continued {
+		if _, err := rd.Read(); err != nil {
+			return nil, err
+		}
+	}
+
+	return rd.Read()
+}
+
+// checkRoundTrip returns an error, if the given message does not re-parse to a message of the same type with
+// the same raw bytes
+func checkRoundTrip(msg midi.Message) error {
+	got, err := reparse(msg)
+
+	if err != nil {
+		return err
+	}
+
+	if reflect.TypeOf(got) != reflect.TypeOf(msg) || !bytes.Equal(got.Raw(), msg.Raw()) {
+		return fmt.Errorf("re-parsed as %T % X", got, got.Raw())
+	}
+
+	return nil
+}
+
+func TestRawOfAllMessages(t *testing.T) {
+	var covered = map[string]bool{}
+
+	for _, msg := range instances {
+		typ := reflect.TypeOf(msg)
+		covered[typ.String()] = true
+
+		if _, panics := raw(msg); panics {
+			t.Errorf("%T: Raw panics", msg)
+			continue
+		}
+
+		if err := midi.Validate(msg); err != nil {
+			t.Errorf("%T: invalid instance: %v", msg, err)
+			continue
+		}
+
+		if err := checkRoundTrip(msg); err != nil {
+			t.Errorf("%T % X: %v", msg, msg.Raw(), err)
+		}
+	}
+
+	for _, name := range exportedMessageTypes(t) {
+		if !covered[name] {
+			t.Errorf("no instance of %s: add one to instances", name)
+		}
+	}
+}
+
+// randomChannelMessage returns a channel message with random values, including invalid ones
+func randomChannelMessage(rnd *rand.Rand) midi.Message {
+	ch := channel.Channel(rnd.Intn(20))
+	a, b := uint8(rnd.Intn(256)), uint8(rnd.Intn(256))
+
+	switch rnd.Intn(8) {
+	case 0:
+		return ch.Aftertouch(a)
+	case 1:
+		return ch.ControlChange(a, b)
+	case 2:
+		return ch.NoteOff(a)
+	case 3:
+		return ch.NoteOffVelocity(a, b)
+	case 4:
+		return ch.NoteOn(a, b)
+	case 5:
+		return ch.Pitchbend(int16(rnd.Intn(1 << 16)))
+	case 6:
+		return ch.PolyAftertouch(a, b)
+	default:
+		return ch.ProgramChange(a)
+	}
+}
+
+// quickable returns true, if testing/quick can generate values of the given type, i.e. it has no unexported fields
+func quickable(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return true
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		if !typ.Field(i).IsExported() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestRawOfRandomMessages(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, inst := range instances {
+		typ := reflect.TypeOf(inst)
+
+		// the values of unexported types are given by the exported variables
+		if !ast.IsExported(typ.Name()) {
+			continue
+		}
+
+		for i := 0; i < 200; i++ {
+			var msg midi.Message
+
+			switch {
+			case typ.PkgPath() == reflect.TypeOf(channel.Channel0).PkgPath():
+				msg = randomChannelMessage(rnd)
+			case quickable(typ):
+				v, ok := quick.Value(typ, rnd)
+
+				if !ok {
+					t.Fatalf("can't generate values of %s", typ)
+				}
+
+				msg = v.Interface().(midi.Message)
+			default:
+				t.Fatalf("can't generate values of %s", typ)
+			}
+
+			b, panics := raw(msg)
+
+			if panics {
+				t.Errorf("%#v: Raw panics", msg)
+				continue
+			}
+
+			if midi.Validate(msg) != nil {
+				continue
+			}
+
+			if len(b) == 0 {
+				t.Errorf("%#v: valid message without raw bytes", msg)
+				continue
+			}
+
+			if err := checkRoundTrip(msg); err != nil {
+				t.Errorf("%#v % X: %v", msg, b, err)
+			}
+		}
+	}
+}
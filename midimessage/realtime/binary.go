@@ -0,0 +1,35 @@
+package realtime
+
+import (
+	"fmt"
+)
+
+// Unmarshal parses the raw bytes of a single realtime message (the status byte, as returned by Raw)
+func Unmarshal(data []byte) (Message, error) {
+	if len(data) != 1 {
+		return nil, fmt.Errorf("invalid realtime message % X: must be a single byte", data)
+	}
+
+	m := dispatch(data[0])
+
+	if m == nil {
+		return nil, fmt.Errorf("invalid realtime message % X: unknown status byte", data)
+	}
+
+	return m, nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m msg) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *msg) UnmarshalBinary(data []byte) error {
+	v, err := Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	*m = v.(msg)
+	return nil
+}
@@ -1,5 +1,11 @@
 package realtime
 
+import (
+	"fmt"
+
+	"github.com/gomidi/midi"
+)
+
 const (
 	// TimingClock is a MIDI timing clock message
 	TimingClock = msg(0xF8)
@@ -46,6 +52,19 @@ func (m msg) Raw() []byte {
 	return []byte{byte(m)}
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m msg) EncodedLen() int {
+	return 1
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the message is not a realtime message (F8-FF)
+func (m msg) Validate() error {
+	if m < 0xF8 {
+		return fmt.Errorf("%w realtime message: status byte %02X out of range F8-FF", midi.ErrInvalidMessage, byte(m))
+	}
+	return nil
+}
+
 /*
 func (m msg) IsLiveMessage() {
 
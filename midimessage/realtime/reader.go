@@ -2,6 +2,8 @@ package realtime
 
 import (
 	"io"
+
+	"github.com/gomidi/midi/midimessage/status"
 )
 
 // Reader is a realtime.Reader
@@ -39,7 +41,7 @@ func (r *reader) Read(target []byte) (n int, err error) {
 		}
 
 		// => no realtime message
-		if bf[0] < 0xF8 {
+		if !status.IsRealtime(bf[0]) {
 			target[n] = bf[0]
 			n++
 			continue
@@ -98,7 +100,7 @@ func (r *discardReader) Read(target []byte) (n int, err error) {
 		}
 
 		// => no realtime message
-		if bf[0] < 0xF8 {
+		if !status.IsRealtime(bf[0]) {
 			target[n] = bf[0]
 			n++
 			continue
@@ -0,0 +1,20 @@
+package realtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gomidi/midi"
+)
+
+func TestValidate(t *testing.T) {
+	for _, msg := range []msg{TimingClock, Tick, Start, Continue, Stop, Undefined4, Activesense, Reset} {
+		if err := msg.Validate(); err != nil {
+			t.Errorf("%v.Validate() = %v; want nil", msg, err)
+		}
+	}
+
+	if err := msg(0xF0).Validate(); !errors.Is(err, midi.ErrInvalidMessage) {
+		t.Errorf("Validate() = %v; want %v", err, midi.ErrInvalidMessage)
+	}
+}
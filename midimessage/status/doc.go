@@ -0,0 +1,6 @@
+// Copyright (c) 2018 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package status provides the classification of MIDI status bytes, as they are used "over the wire".
+package status
@@ -0,0 +1,63 @@
+package status
+
+// Split splits the given status byte into the message type (upper nibble) and the channel (lower nibble).
+// The channel is only meaningful for channel messages (see IsChannel).
+func Split(status byte) (typ, channel uint8) {
+	return status >> 4, status & 0x0F
+}
+
+// IsStatus returns if the given byte is a status byte (i.e. the high bit is set), as opposed to a data byte
+func IsStatus(b byte) bool {
+	return b&0x80 != 0
+}
+
+// IsChannel returns if the given byte is the status byte of a channel message (0x80 to 0xEF)
+func IsChannel(b byte) bool {
+	return b >= 0x80 && b <= 0xEF
+}
+
+// IsSysEx returns if the given byte starts a system exclusive message (0xF0)
+func IsSysEx(b byte) bool {
+	return b == 0xF0
+}
+
+// IsSystemCommon returns if the given byte is the status byte of a system common message (0xF1 to 0xF7).
+// 0xF7 is the end of a system exclusive message (EOX) and 0xF4 and 0xF5 are undefined.
+func IsSystemCommon(b byte) bool {
+	return b >= 0xF1 && b <= 0xF7
+}
+
+// IsRealtime returns if the given byte is a system realtime message (0xF8 to 0xFF).
+// Realtime messages consist only of the status byte and may appear anywhere, even between the data bytes of other messages.
+func IsRealtime(b byte) bool {
+	return b >= 0xF8
+}
+
+// DataLength returns the number of data bytes that follow the given status byte.
+// It returns false, if the length is not fixed: for data bytes, for the start of a system exclusive message
+// and for the undefined system common messages 0xF4 and 0xF5.
+func DataLength(status byte) (int, bool) {
+	switch {
+	case !IsStatus(status):
+		return 0, false
+	case IsChannel(status):
+		if typ, _ := Split(status); typ == 0xC || typ == 0xD {
+			return 1, true
+		}
+		return 2, true
+	case IsRealtime(status):
+		return 0, true
+	}
+
+	switch status {
+	case 0xF1, 0xF3:
+		return 1, true
+	case 0xF2:
+		return 2, true
+	case 0xF6, 0xF7:
+		return 0, true
+	default:
+		// 0xF0 (sysex), 0xF4 and 0xF5 (undefined)
+		return 0, false
+	}
+}
@@ -0,0 +1,97 @@
+package status
+
+import (
+	"testing"
+)
+
+func TestClassification(t *testing.T) {
+	type class struct {
+		status, channel, sysex, common, realtime bool
+		length                                   int
+		fixed                                    bool
+	}
+
+	var tests = []struct {
+		from, to byte
+		expected class
+	}{
+		{0x00, 0x7F, class{}},
+		{0x80, 0xBF, class{status: true, channel: true, length: 2, fixed: true}},
+		{0xC0, 0xDF, class{status: true, channel: true, length: 1, fixed: true}},
+		{0xE0, 0xEF, class{status: true, channel: true, length: 2, fixed: true}},
+		{0xF0, 0xF0, class{status: true, sysex: true}},
+		{0xF1, 0xF1, class{status: true, common: true, length: 1, fixed: true}},
+		{0xF2, 0xF2, class{status: true, common: true, length: 2, fixed: true}},
+		{0xF3, 0xF3, class{status: true, common: true, length: 1, fixed: true}},
+		{0xF4, 0xF5, class{status: true, common: true}},
+		{0xF6, 0xF7, class{status: true, common: true, length: 0, fixed: true}},
+		{0xF8, 0xFF, class{status: true, realtime: true, length: 0, fixed: true}},
+	}
+
+	var seen [256]bool
+
+	for _, test := range tests {
+		for i := int(test.from); i <= int(test.to); i++ {
+			b := byte(i)
+			seen[i] = true
+
+			length, fixed := DataLength(b)
+
+			got := class{
+				status:   IsStatus(b),
+				channel:  IsChannel(b),
+				sysex:    IsSysEx(b),
+				common:   IsSystemCommon(b),
+				realtime: IsRealtime(b),
+				length:   length,
+				fixed:    fixed,
+			}
+
+			if got != test.expected {
+				t.Errorf("classification of % X = %+v; want %+v", b, got, test.expected)
+			}
+		}
+	}
+
+	for i, ok := range seen {
+		if !ok {
+			t.Errorf("% X is not tested", byte(i))
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		typ, ch := Split(byte(i))
+
+		if got, want := typ, uint8(i/16); got != want {
+			t.Errorf("Split(% X) type = %v; want %v", byte(i), got, want)
+		}
+
+		if got, want := ch, uint8(i%16); got != want {
+			t.Errorf("Split(% X) channel = %v; want %v", byte(i), got, want)
+		}
+	}
+
+	var tests = []struct {
+		byte    byte
+		typ     uint8
+		channel uint8
+	}{
+		{0xF0, 15, 0},  /* sysex */
+		{0xF7, 15, 7},  /* sysex */
+		{0xFF, 15, 15}, /* meta */
+		{0xF8, 15, 8},  /* realtime timing clock */
+		{0xC0, 12, 0},  /* prog change chan 0 */
+		{0x92, 9, 2},   /* note on chan2 */
+		{0x81, 8, 1},   /* note off chan 1 */
+	}
+
+	for _, test := range tests {
+		typ, ch := Split(test.byte)
+
+		if typ != test.typ || ch != test.channel {
+			t.Errorf("Split(%X) = %v,%v; want %v,%v", test.byte, typ, ch, test.typ, test.channel)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package syscommon
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi/internal/midilib"
+)
+
+// Unmarshal parses the raw bytes of a single system common message (status byte and data bytes, as returned by Raw).
+// The undefined messages 0xF4 and 0xF5 can't be parsed.
+func Unmarshal(data []byte) (Message, error) {
+	if len(data) == 0 || dispatch(data[0]) == nil {
+		return nil, fmt.Errorf("invalid system common message % X: unknown status byte", data)
+	}
+
+	rd := midilib.NewSliceReader(data[1:])
+	msg, err := NewReader(rd, data[0]).Read()
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid system common message % X: %v", data, err)
+	}
+
+	if rd.Len() > 0 {
+		return nil, fmt.Errorf("invalid system common message % X: %v bytes too many", data, rd.Len())
+	}
+
+	return msg, nil
+}
+
+// unmarshal sets m to the system common message of the given raw bytes, that must be of type T
+func unmarshal[T Message](data []byte, m *T) error {
+	msg, err := Unmarshal(data)
+
+	if err != nil {
+		return err
+	}
+
+	v, ok := msg.(T)
+
+	if !ok {
+		return fmt.Errorf("can't unmarshal %T into %T", msg, *m)
+	}
+
+	*m = v
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m MTC) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *MTC) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m SPP) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *SPP) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m SongSelect) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *SongSelect) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m tune) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *tune) UnmarshalBinary(data []byte) error {
+	return unmarshal(data, m)
+}
@@ -1,6 +1,16 @@
 package syscommon
 
-import "io"
+import (
+	"fmt"
+	"io"
+
+	"github.com/gomidi/midi"
+)
+
+// invalid returns an error for the given message that wraps midi.ErrInvalidMessage
+func invalid(msg Message, format string, args ...any) error {
+	return fmt.Errorf("%w %T: %s", midi.ErrInvalidMessage, msg, fmt.Sprintf(format, args...))
+}
 
 /*
 
@@ -44,6 +44,19 @@ func (m MTC) Raw() []byte {
 	return []byte{byte(0xF1), byte(m)}
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m MTC) EncodedLen() int {
+	return 2
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the quarter frame is beyond 127
+func (m MTC) Validate() error {
+	if m > 127 {
+		return invalid(m, "quarter frame %v out of range 0-127", uint8(m))
+	}
+	return nil
+}
+
 // QuarterFrame returns the quarter frame
 func (m MTC) QuarterFrame() uint8 {
 	return uint8(m)
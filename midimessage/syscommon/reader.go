@@ -2,12 +2,15 @@ package syscommon
 
 import (
 	"io"
+
+	"github.com/gomidi/midi"
 )
 
 // Reader read a syscommon
 type Reader interface {
 	// Read reads a single syscommon message.
 	// It may just be called once per Reader. A second call returns io.EOF
+	// If the input ends within the message, midi.ErrUnexpectedEOF is returned.
 	Read() (Message, error)
 }
 
@@ -34,7 +37,15 @@ func (r *reader) Read() (msg Message, err error) {
 	if msg == nil {
 		return
 	}
-	return msg.readFrom(r.input)
+
+	msg, err = msg.readFrom(r.input)
+
+	// the status has been read, so the message is cut off
+	if err == io.EOF {
+		err = midi.ErrUnexpectedEOF
+	}
+
+	return msg, err
 }
 
 func dispatch(b byte) Message {
@@ -14,6 +14,19 @@ func (m SongSelect) Raw() []byte {
 	return []byte{byte(0xF3), byte(m)}
 }
 
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m SongSelect) EncodedLen() int {
+	return 2
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the song is beyond 127
+func (m SongSelect) Validate() error {
+	if m > 127 {
+		return invalid(m, "song %v out of range 0-127", uint8(m))
+	}
+	return nil
+}
+
 // SongSelect represents the MIDI song select system message
 type SongSelect uint8
 
@@ -67,4 +67,18 @@ func (m SPP) Raw() []byte {
 
 	return []byte{0xF2, b[0], b[1]}
 }
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m SPP) EncodedLen() int {
+	return 3
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the position is beyond 16383,
+// where Raw would cut it to 14 bits.
+func (m SPP) Validate() error {
+	if m > 0x3FFF {
+		return invalid(m, "position %v out of range 0-16383", uint16(m))
+	}
+	return nil
+}
 func (m SPP) sysCommon() {}
@@ -29,3 +29,13 @@ func (m tune) sysCommon() {}
 func (m tune) Raw() []byte {
 	return []byte{byte(0xF6)}
 }
+
+// EncodedLen returns the number of bytes of the message (see Raw), without encoding it
+func (m tune) EncodedLen() int {
+	return 1
+}
+
+// Validate returns nil
+func (m tune) Validate() error {
+	return nil
+}
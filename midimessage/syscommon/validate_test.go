@@ -0,0 +1,35 @@
+package syscommon
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gomidi/midi"
+)
+
+func TestValidate(t *testing.T) {
+	invalid := []Message{
+		MTC(0x80),
+		SongSelect(128),
+		SPP(0x4000),
+	}
+
+	for _, msg := range invalid {
+		if err := midi.Validate(msg); !errors.Is(err, midi.ErrInvalidMessage) {
+			t.Errorf("Validate(%#v) = %v; want %v", msg, err, midi.ErrInvalidMessage)
+		}
+	}
+
+	valid := []Message{
+		MTC(0x7F),
+		SongSelect(127),
+		SPP(0x3FFF),
+		Tune,
+	}
+
+	for _, msg := range valid {
+		if err := midi.Validate(msg); err != nil {
+			t.Errorf("Validate(%#v) = %v; want nil", msg, err)
+		}
+	}
+}
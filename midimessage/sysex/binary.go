@@ -0,0 +1,132 @@
+package sysex
+
+import (
+	"fmt"
+)
+
+// Unmarshal parses the raw bytes of a single system exclusive message (as returned by Raw).
+// Data starting with 0xF0 is returned as SysEx, if it ends with 0xF7, otherwise as Start.
+// Data starting with 0xF7 is returned as End, if it ends with 0xF7, otherwise as Continue.
+// Since its raw bytes are the same as the ones of Continue, Escape is never returned.
+func Unmarshal(data []byte) (Message, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("invalid sysex message: no data")
+	}
+
+	terminated := len(data) > 1 && data[len(data)-1] == byteSysExEnd
+
+	switch {
+	case data[0] == byteSysExStart && terminated:
+		return SysEx(clone(data[1 : len(data)-1])), nil
+	case data[0] == byteSysExStart:
+		return Start(clone(data[1:])), nil
+	case data[0] == byteSysExEnd && terminated:
+		return End(clone(data[1 : len(data)-1])), nil
+	case data[0] == byteSysExEnd:
+		return Continue(clone(data[1:])), nil
+	default:
+		return nil, fmt.Errorf("invalid sysex message % X: must start with F0 or F7", data)
+	}
+}
+
+// clone returns a copy of the given data that doesn't share the memory
+func clone(data []byte) []byte {
+	return append([]byte{}, data...)
+}
+
+// unwrap checks the prefix and the postfix of the given raw bytes and returns a copy of the data between them
+func unwrap(data []byte, prefix byte, terminated bool, typ string) ([]byte, error) {
+	min := 1
+	if terminated {
+		min = 2
+	}
+
+	if len(data) < min || data[0] != prefix {
+		return nil, fmt.Errorf("invalid %s message % X: must start with %X", typ, data, prefix)
+	}
+
+	if !terminated {
+		return clone(data[1:]), nil
+	}
+
+	if data[len(data)-1] != byteSysExEnd {
+		return nil, fmt.Errorf("invalid %s message % X: must end with F7", typ, data)
+	}
+
+	return clone(data[1 : len(data)-1]), nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m SysEx) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *SysEx) UnmarshalBinary(data []byte) error {
+	b, err := unwrap(data, byteSysExStart, true, "sysex.SysEx")
+	if err != nil {
+		return err
+	}
+	*m = SysEx(b)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Start) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Start) UnmarshalBinary(data []byte) error {
+	b, err := unwrap(data, byteSysExStart, false, "sysex.Start")
+	if err != nil {
+		return err
+	}
+	*m = Start(b)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Continue) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Continue) UnmarshalBinary(data []byte) error {
+	b, err := unwrap(data, byteSysExEnd, false, "sysex.Continue")
+	if err != nil {
+		return err
+	}
+	*m = Continue(b)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m End) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *End) UnmarshalBinary(data []byte) error {
+	b, err := unwrap(data, byteSysExEnd, true, "sysex.End")
+	if err != nil {
+		return err
+	}
+	*m = End(b)
+	return nil
+}
+
+// MarshalBinary returns the raw bytes of the message (see Raw). It implements encoding.BinaryMarshaler.
+func (m Escape) MarshalBinary() ([]byte, error) {
+	return m.Raw(), nil
+}
+
+// UnmarshalBinary sets the message to the given raw bytes. It implements encoding.BinaryUnmarshaler.
+func (m *Escape) UnmarshalBinary(data []byte) error {
+	b, err := unwrap(data, byteSysExEnd, false, "sysex.Escape")
+	if err != nil {
+		return err
+	}
+	*m = Escape(b)
+	return nil
+}
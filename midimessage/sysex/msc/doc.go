@@ -0,0 +1,19 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package msc provides the messages of MIDI Show Control (MSC), universal realtime system exclusive messages
+(sub ID 02) that control lighting, sound and other show equipment by cues.
+
+Example
+
+	// GO cue 235.6 of cue list 36.6 on the lighting console with the device ID 1
+	wr.Write(msc.Go(0x01, msc.Lighting, msc.Cue{Number: "235.6", List: "36.6"}))
+
+	// stop all sound devices
+	wr.Write(msc.Stop(msc.AllCall, msc.Sound, msc.Cue{}))
+
+The received system exclusive messages are parsed by Parse.
+*/
+package msc
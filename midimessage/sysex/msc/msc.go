@@ -0,0 +1,275 @@
+package msc
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+const (
+	// UniversalRealtime is the ID of the universal realtime system exclusive messages
+	UniversalRealtime = 0x7F
+
+	// SubID is the sub ID of the MIDI Show Control messages
+	SubID = 0x02
+
+	// AllCall is the device ID that addresses all devices. The IDs 70 to 7E address groups of devices.
+	AllCall = 0x7F
+)
+
+// CommandFormat is the type of the addressed devices
+type CommandFormat byte
+
+// some command formats
+const (
+	Lighting     CommandFormat = 0x01
+	MovingLights CommandFormat = 0x02
+	Sound        CommandFormat = 0x10
+	Music        CommandFormat = 0x11
+	Machinery    CommandFormat = 0x20
+	Video        CommandFormat = 0x30
+	Projection   CommandFormat = 0x40
+	ProcessCtrl  CommandFormat = 0x50
+	Pyro         CommandFormat = 0x60
+	AllTypes     CommandFormat = 0x7F
+)
+
+// Command is a MSC command
+type Command byte
+
+// the general commands (GO, STOP, ...)
+const (
+	CmdGo      Command = 0x01
+	CmdStop    Command = 0x02
+	CmdResume  Command = 0x03
+	CmdTimedGo Command = 0x04
+	CmdLoad    Command = 0x05
+	CmdSet     Command = 0x06
+	CmdFire    Command = 0x07
+	CmdAllOff  Command = 0x08
+	CmdRestore Command = 0x09
+	CmdReset   Command = 0x0A
+	CmdGoOff   Command = 0x0B
+)
+
+var commandNames = map[Command]string{
+	CmdGo:      "GO",
+	CmdStop:    "STOP",
+	CmdResume:  "RESUME",
+	CmdTimedGo: "TIMED_GO",
+	CmdLoad:    "LOAD",
+	CmdSet:     "SET",
+	CmdFire:    "FIRE",
+	CmdAllOff:  "ALL_OFF",
+	CmdRestore: "RESTORE",
+	CmdReset:   "RESET",
+	CmdGoOff:   "GO_OFF",
+}
+
+// String returns the name of the command, e.g. "GO", or its number in hex
+func (c Command) String() string {
+	if name, has := commandNames[c]; has {
+		return name
+	}
+	return fmt.Sprintf("%02X", byte(c))
+}
+
+// hasCue returns true, if the data of the command is a Cue
+func (c Command) hasCue() bool {
+	switch c {
+	case CmdGo, CmdStop, CmdResume, CmdLoad, CmdGoOff:
+		return true
+	}
+	return false
+}
+
+// Cue is the cue number of a command, with the cue list and the cue path that contain it. Each of them is a
+// dotted decimal number of ASCII digits and decimal points, e.g. "235.6" or "1.2.3", or empty. The list may only be
+// set with the number, the path only with the list. An empty Cue addresses the current cue.
+type Cue struct {
+	Number string
+	List   string
+	Path   string
+}
+
+// String returns the number, list and path of the Cue, separated by "/", e.g. "235.6/36.6"
+func (c Cue) String() string {
+	return strings.TrimRight(c.Number+"/"+c.List+"/"+c.Path, "/")
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if a part of the Cue is no dotted decimal number or
+// a list or path is set without the number or list.
+func (c Cue) Validate() error {
+	parts := []struct{ name, value string }{{"number", c.Number}, {"list", c.List}, {"path", c.Path}}
+
+	for i, p := range parts {
+		for _, r := range p.value {
+			if (r < '0' || r > '9') && r != '.' {
+				return fmt.Errorf("%w: invalid character %q in cue %s %q", midi.ErrInvalidMessage, r, p.name, p.value)
+			}
+		}
+
+		if i > 0 && p.value != "" && parts[i-1].value == "" {
+			return fmt.Errorf("%w: cue %s %q without cue %s", midi.ErrInvalidMessage, p.name, p.value, parts[i-1].name)
+		}
+	}
+
+	return nil
+}
+
+// encode returns the bytes of the Cue: the number, list and path in ASCII, separated by 00. Missing parts at the end
+// are omitted.
+func (c Cue) encode() []byte {
+	var b []byte
+
+	for i, p := range []string{c.Number, c.List, c.Path} {
+		if p == "" {
+			break
+		}
+
+		if i > 0 {
+			b = append(b, 0x00)
+		}
+
+		b = append(b, p...)
+	}
+
+	return b
+}
+
+// decodeCue decodes the bytes of a Cue (see encode)
+func decodeCue(data []byte) (c Cue, err error) {
+	parts := bytes.Split(data, []byte{0x00})
+
+	if len(parts) > 3 {
+		return c, fmt.Errorf("invalid cue: %v parts", len(parts))
+	}
+
+	for i, p := range parts {
+		switch i {
+		case 0:
+			c.Number = string(p)
+		case 1:
+			c.List = string(p)
+		case 2:
+			c.Path = string(p)
+		}
+	}
+
+	if len(data) > 0 && c.Number == "" {
+		return c, fmt.Errorf("invalid cue: missing cue number")
+	}
+
+	return c, c.Validate()
+}
+
+// Message is a MIDI Show Control message
+type Message struct {
+	// DeviceID is the ID of the addressed device (00 to 6F), a group (70 to 7E) or AllCall
+	DeviceID byte
+
+	Format  CommandFormat
+	Command Command
+
+	// Cue is the cue of the commands GO, STOP, RESUME, LOAD and GO_OFF
+	Cue Cue
+
+	// Data is the data of the other commands, e.g. the time and cue of TIMED_GO
+	Data []byte
+}
+
+// Go returns the message that starts the given cue (or the next cue, if it is empty)
+func Go(device byte, format CommandFormat, cue Cue) Message {
+	return Message{DeviceID: device, Format: format, Command: CmdGo, Cue: cue}
+}
+
+// Stop returns the message that stops the given cue (or all running cues, if it is empty)
+func Stop(device byte, format CommandFormat, cue Cue) Message {
+	return Message{DeviceID: device, Format: format, Command: CmdStop, Cue: cue}
+}
+
+// Resume returns the message that resumes the given stopped cue (or all stopped cues, if it is empty)
+func Resume(device byte, format CommandFormat, cue Cue) Message {
+	return Message{DeviceID: device, Format: format, Command: CmdResume, Cue: cue}
+}
+
+// Load returns the message that prepares the given cue to be started by the next GO
+func Load(device byte, format CommandFormat, cue Cue) Message {
+	return Message{DeviceID: device, Format: format, Command: CmdLoad, Cue: cue}
+}
+
+// data returns the data after the command
+func (m Message) data() []byte {
+	if m.Command.hasCue() {
+		return m.Cue.encode()
+	}
+	return m.Data
+}
+
+// SysEx returns the system exclusive message: 7F <device ID> 02 <command format> <command> <data>
+func (m Message) SysEx() sysex.SysEx {
+	msg := sysex.SysEx{UniversalRealtime, m.DeviceID, SubID, byte(m.Format), byte(m.Command)}
+	return append(msg, m.data()...)
+}
+
+// Raw returns the bytes of the system exclusive message with the prefix F0 and the postfix F7
+func (m Message) Raw() []byte {
+	return m.SysEx().Raw()
+}
+
+// String represents the message as a string (for debugging), e.g. "msc.Message device: 1 format: 01 GO cue: 235.6/36.6"
+func (m Message) String() string {
+	if m.Command.hasCue() {
+		return fmt.Sprintf("%T device: %v format: %02X %s cue: %s", m, m.DeviceID, byte(m.Format), m.Command, m.Cue)
+	}
+	return fmt.Sprintf("%T device: %v format: %02X %s data: % X", m, m.DeviceID, byte(m.Format), m.Command, m.Data)
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if a byte of the message is above 127 or the Cue is
+// invalid (see Cue.Validate).
+func (m Message) Validate() error {
+	if m.Command.hasCue() {
+		if err := m.Cue.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for i, b := range m.SysEx() {
+		if b > 127 {
+			return fmt.Errorf("%w %T: status byte %02X at position %v", midi.ErrInvalidMessage, m, b, i)
+		}
+	}
+
+	return nil
+}
+
+// Parse parses a MIDI Show Control message. The cues of GO, STOP, RESUME, LOAD and GO_OFF are decoded, the data of
+// the other commands is kept as it is. An error is returned, if the message is no valid MSC message.
+func Parse(msg sysex.SysEx) (m Message, err error) {
+	if len(msg) < 5 {
+		return m, fmt.Errorf("invalid MSC message: too short")
+	}
+
+	if msg[0] != UniversalRealtime || msg[2] != SubID {
+		return m, fmt.Errorf("invalid MSC message: ID %02X %02X", msg[0], msg[2])
+	}
+
+	m.DeviceID = msg[1]
+	m.Format = CommandFormat(msg[3])
+	m.Command = Command(msg[4])
+	data := msg[5:]
+
+	if !m.Command.hasCue() {
+		m.Data = append([]byte{}, data...)
+		return m, nil
+	}
+
+	if m.Cue, err = decodeCue(data); err != nil {
+		return m, fmt.Errorf("invalid MSC message: %w", err)
+	}
+
+	return m, nil
+}
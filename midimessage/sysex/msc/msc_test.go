@@ -0,0 +1,105 @@
+package msc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+func TestMessages(t *testing.T) {
+	tests := []struct {
+		msg      Message
+		expected string
+	}{
+		// GO cue 235.6 of list 36.6 and path 59 on lighting device 1
+		{Go(0x01, Lighting, Cue{Number: "235.6", List: "36.6", Path: "59"}), "F0 7F 01 02 01 01 32 33 35 2E 36 00 33 36 2E 36 00 35 39 F7"},
+		// GO cue 235.6 of list 36.6
+		{Go(0x01, Lighting, Cue{Number: "235.6", List: "36.6"}), "F0 7F 01 02 01 01 32 33 35 2E 36 00 33 36 2E 36 F7"},
+		// GO the next cue
+		{Go(0x01, Lighting, Cue{}), "F0 7F 01 02 01 01 F7"},
+		// STOP all sound devices
+		{Stop(AllCall, Sound, Cue{}), "F0 7F 7F 02 10 02 F7"},
+		// RESUME cue 1.2.3 on all types of a group
+		{Resume(0x70, AllTypes, Cue{Number: "1.2.3"}), "F0 7F 70 02 7F 03 31 2E 32 2E 33 F7"},
+		// LOAD cue 12
+		{Load(0x00, Sound, Cue{Number: "12"}), "F0 7F 00 02 10 05 31 32 F7"},
+		// TIMED_GO 1h 2m 3s frame 4 cue 5
+		{Message{DeviceID: 0x01, Format: Lighting, Command: CmdTimedGo, Data: []byte{0x01, 0x02, 0x03, 0x04, 0x00, 0x35}}, "F0 7F 01 02 01 04 01 02 03 04 00 35 F7"},
+	}
+
+	for i, test := range tests {
+		if got, want := fmt.Sprintf("% X", test.msg.Raw()), test.expected; got != want {
+			t.Errorf("[%v] got %s; want %s", i, got, want)
+		}
+
+		if err := test.msg.Validate(); err != nil {
+			t.Errorf("[%v] Validate() = %v", i, err)
+		}
+
+		raw := test.msg.Raw()
+		res, err := Parse(sysex.SysEx(raw[1 : len(raw)-1]))
+
+		if err != nil {
+			t.Fatalf("[%v] Error: %v", i, err)
+		}
+
+		if got, want := res.String(), test.msg.String(); got != want {
+			t.Errorf("[%v] Parse() = %s; want %s", i, got, want)
+		}
+	}
+
+	if got, want := tests[0].msg.String(), "msc.Message device: 1 format: 01 GO cue: 235.6/36.6/59"; got != want {
+		t.Errorf("String() = %s; want %s", got, want)
+	}
+
+	var _ midi.Message = Message{}
+}
+
+func TestCue(t *testing.T) {
+	invalid := []Cue{
+		{Number: "1,5"},
+		{Number: "A"},
+		{List: "1"},
+		{Number: "1", Path: "2"},
+	}
+
+	for i, c := range invalid {
+		if err := c.Validate(); !errors.Is(err, midi.ErrInvalidMessage) {
+			t.Errorf("[%v] Validate() = %v; want %v", i, err, midi.ErrInvalidMessage)
+		}
+
+		if err := midi.Validate(Go(0x01, Lighting, c)); err == nil {
+			t.Errorf("[%v] midi.Validate() = nil; want an error", i)
+		}
+	}
+
+	if err := midi.Validate(Go(0x80, Lighting, Cue{})); err == nil {
+		t.Errorf("midi.Validate() with device ID 80 = nil; want an error")
+	}
+}
+
+func TestParse(t *testing.T) {
+	invalid := []sysex.SysEx{
+		// too short
+		{0x7F, 0x01, 0x02, 0x01},
+		// non realtime
+		{0x7E, 0x01, 0x02, 0x01, 0x01},
+		// MIDI time code
+		{0x7F, 0x01, 0x01, 0x01, 0x01},
+		// four parts
+		{0x7F, 0x01, 0x02, 0x01, 0x01, 0x31, 0x00, 0x32, 0x00, 0x33, 0x00, 0x34},
+		// list without number
+		{0x7F, 0x01, 0x02, 0x01, 0x01, 0x00, 0x32},
+		// letter in the number
+		{0x7F, 0x01, 0x02, 0x01, 0x01, 0x41},
+	}
+
+	for i, msg := range invalid {
+		if _, err := Parse(msg); err == nil {
+			t.Errorf("[%v] Parse() error = nil; want an error", i)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package roland provides the system exclusive messages of Roland devices: data set (DT1) and data request (RQ1)
+messages with their checksum and the arithmetic of the 7 bit addresses.
+
+Example
+
+	// set the panel mode of a JV-1080 to performance
+	wr.Write(roland.JV1080.DT1(roland.NewAddress(0, 4), []byte{0x00}))
+
+	// request the common parameters of the first performance
+	addr := roland.NewAddress(0x10000000, 4)
+	wr.Write(roland.JV1080.RQ1(addr, 0x40))
+
+The received messages are parsed and their checksum is verified by Device.Parse.
+*/
+package roland
@@ -0,0 +1,190 @@
+package roland
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+const (
+	// ManufacturerID is the manufacturer ID of Roland
+	ManufacturerID = 0x41
+
+	// RQ1 is the command ID of a data request
+	RQ1 = 0x11
+
+	// DT1 is the command ID of a data set
+	DT1 = 0x12
+)
+
+// ErrChecksum is returned by Device.Parse for a message with a wrong checksum
+var ErrChecksum = errors.New("wrong checksum")
+
+// Checksum returns the Roland checksum of the given bytes (the address and the data or size):
+// the value that makes the sum of the bytes and the checksum a multiple of 128.
+func Checksum(data ...[]byte) byte {
+	var sum byte
+
+	for _, d := range data {
+		for _, b := range d {
+			sum += b
+		}
+	}
+
+	return (0x80 - sum&0x7F) & 0x7F
+}
+
+// Address is an address within the parameter memory of a device, as 3 or 4 bytes of 7 bits
+// (most significant byte first)
+type Address []byte
+
+// NewAddress returns the Address of the given size (in bytes) with the given bytes, as they are written in the
+// manuals, e.g. 0x10000000 for 4 bytes becomes 10 00 00 00. Bytes that don't fit into the size are dropped and the
+// highest bit of each byte is cleared.
+func NewAddress(bytes uint32, size int) Address {
+	a := make(Address, size)
+
+	for i := size - 1; i >= 0; i-- {
+		a[i] = byte(bytes) & 0x7F
+		bytes >>= 8
+	}
+
+	return a
+}
+
+// addressOf returns the Address of the given size for the given linear value (see Value)
+func addressOf(value uint32, size int) Address {
+	a := make(Address, size)
+
+	for i := size - 1; i >= 0; i-- {
+		a[i] = byte(value & 0x7F)
+		value >>= 7
+	}
+
+	return a
+}
+
+// Value returns the linear value of the address (the number of bytes from address 0), where each byte holds
+// 7 bits, e.g. 0x4000 for 01 00 00
+func (a Address) Value() uint32 {
+	var v uint32
+
+	for _, b := range a {
+		v = v<<7 | uint32(b&0x7F)
+	}
+
+	return v
+}
+
+// Add returns the address that is the given number of bytes after the address (or before, if n is negative),
+// carrying over the 7 bit bytes, e.g. 00 00 7F + 1 = 00 01 00. The size is kept.
+func (a Address) Add(n int) Address {
+	return addressOf(uint32(int64(a.Value())+int64(n)), len(a))
+}
+
+// Sub returns the number of bytes from b to a
+func (a Address) Sub(b Address) int {
+	return int(int64(a.Value()) - int64(b.Value()))
+}
+
+// String returns the bytes of the address in hex, e.g. "10 00 00 00"
+func (a Address) String() string {
+	return fmt.Sprintf("% X", []byte(a))
+}
+
+// Device is a Roland device, addressed by its device ID and model ID
+type Device struct {
+	// ID is the device ID, 0x10 (device 17) by default
+	ID byte
+
+	// Model is the model ID of one or more bytes, e.g. 6A for the JV-1080
+	Model []byte
+
+	// AddressSize is the size of the addresses and sizes in bytes, 3 or 4
+	AddressSize int
+}
+
+var (
+	// GS is a GS compatible sound module (e.g. the SC-55)
+	GS = Device{ID: 0x10, Model: []byte{0x42}, AddressSize: 3}
+
+	// JV1080 is a JV-1080 or JV-2080
+	JV1080 = Device{ID: 0x10, Model: []byte{0x6A}, AddressSize: 4}
+)
+
+// message returns the message of the given command with the given address and body (data or size)
+func (d Device) message(command byte, address Address, body []byte) sysex.SysEx {
+	msg := sysex.SysEx{ManufacturerID, d.ID}
+	msg = append(msg, d.Model...)
+	msg = append(msg, command)
+	msg = append(msg, address...)
+	msg = append(msg, body...)
+	return append(msg, Checksum(address, body))
+}
+
+// DT1 returns the data set message that writes the given data to the given address
+func (d Device) DT1(address Address, data []byte) sysex.SysEx {
+	return d.message(DT1, address, data)
+}
+
+// RQ1 returns the data request message that requests the given number of bytes from the given address.
+// The size is encoded with 7 bits per byte, like an address of the size of the given address.
+func (d Device) RQ1(address Address, size uint32) sysex.SysEx {
+	return d.message(RQ1, address, addressOf(size, len(address)))
+}
+
+// Message is a parsed DT1 or RQ1 message
+type Message struct {
+	// Command is DT1 or RQ1
+	Command byte
+
+	Address Address
+
+	// Data is the data of a DT1 message, respectively the size of a RQ1 message (see Size)
+	Data []byte
+}
+
+// Size returns the requested size of a RQ1 message
+func (m Message) Size() uint32 {
+	return Address(m.Data).Value()
+}
+
+// Parse parses a DT1 or RQ1 message of the device and verifies its checksum. A message with a wrong checksum
+// returns an error that wraps ErrChecksum. Messages with the device ID 7F (broadcast) are accepted, too.
+func (d Device) Parse(msg sysex.SysEx) (m Message, err error) {
+	header := 2 + len(d.Model) + 1
+
+	if len(msg) < header+d.AddressSize+1 {
+		return m, fmt.Errorf("invalid Roland message: too short")
+	}
+
+	switch {
+	case msg[0] != ManufacturerID:
+		return m, fmt.Errorf("invalid Roland message: manufacturer ID %02X", msg[0])
+	case msg[1] != d.ID && msg[1] != 0x7F:
+		return m, fmt.Errorf("invalid Roland message: device ID %02X", msg[1])
+	case string(msg[2:2+len(d.Model)]) != string(d.Model):
+		return m, fmt.Errorf("invalid Roland message: model ID % X", []byte(msg[2:2+len(d.Model)]))
+	}
+
+	m.Command = msg[header-1]
+
+	if m.Command != DT1 && m.Command != RQ1 {
+		return m, fmt.Errorf("invalid Roland message: unknown command %02X", m.Command)
+	}
+
+	body := msg[header : len(msg)-1]
+	m.Address = Address(append([]byte{}, body[:d.AddressSize]...))
+	m.Data = append([]byte{}, body[d.AddressSize:]...)
+
+	if m.Command == RQ1 && len(m.Data) != d.AddressSize {
+		return m, fmt.Errorf("invalid Roland message: size of %v bytes", len(m.Data))
+	}
+
+	if sum := msg[len(msg)-1]; sum != Checksum(body) {
+		return m, fmt.Errorf("%w %02X, expected %02X", ErrChecksum, sum, Checksum(body))
+	}
+
+	return m, nil
+}
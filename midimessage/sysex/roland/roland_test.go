@@ -0,0 +1,120 @@
+package roland
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+func TestMessages(t *testing.T) {
+	tests := []struct {
+		msg      sysex.SysEx
+		expected string
+	}{
+		// GS reset
+		{GS.DT1(NewAddress(0x40007F, 3), []byte{0x00}), "F0 41 10 42 12 40 00 7F 00 41 F7"},
+		// GS reverb macro room 1
+		{GS.DT1(Address{0x40, 0x01, 0x30}, []byte{0x00}), "F0 41 10 42 12 40 01 30 00 0F F7"},
+		// JV-1080 panel mode performance
+		{JV1080.DT1(NewAddress(0, 4), []byte{0x00}), "F0 41 10 6A 12 00 00 00 00 00 00 F7"},
+		// JV-1080 request of the common parameters of the temporary performance
+		{JV1080.RQ1(NewAddress(0x10000000, 4), 0x40), "F0 41 10 6A 11 10 00 00 00 00 00 00 40 30 F7"},
+		// a size above 127 is split into 7 bit bytes
+		{JV1080.RQ1(Address{0x10, 0x00, 0x00, 0x00}, 0x81), "F0 41 10 6A 11 10 00 00 00 00 00 01 01 6E F7"},
+	}
+
+	for i, test := range tests {
+		if got, want := fmt.Sprintf("% X", test.msg.Raw()), test.expected; got != want {
+			t.Errorf("[%v] got %s; want %s", i, got, want)
+		}
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		address  Address
+		offset   int
+		expected string
+	}{
+		{Address{0x00, 0x00, 0x7F}, 1, "00 01 00"},
+		{Address{0x10, 0x00, 0x00, 0x00}, -1, "0F 7F 7F 7F"},
+		{Address{0x10, 0x00, 0x00, 0x00}, 0x48, "10 00 00 48"},
+		{Address{0x01, 0x7F, 0x7F}, 0x81, "02 01 00"},
+	}
+
+	for i, test := range tests {
+		res := test.address.Add(test.offset)
+
+		if got, want := res.String(), test.expected; got != want {
+			t.Errorf("[%v] Add(%v) = %s; want %s", i, test.offset, got, want)
+		}
+
+		if got, want := res.Sub(test.address), test.offset; got != want {
+			t.Errorf("[%v] Sub() = %v; want %v", i, got, want)
+		}
+	}
+
+	if got, want := NewAddress(0x1000017F, 4).String(), "10 00 01 7F"; got != want {
+		t.Errorf("NewAddress(0x1000017F) = %s; want %s", got, want)
+	}
+
+	if got, want := (Address{0x10, 0x00, 0x00, 0x00}).Value(), uint32(0x2000000); got != want {
+		t.Errorf("Value() = %X; want %X", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	m, err := GS.Parse(sysex.SysEx{0x41, 0x10, 0x42, 0x12, 0x40, 0x01, 0x30, 0x00, 0x0F})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if m.Command != DT1 || m.Address.String() != "40 01 30" || !bytes.Equal(m.Data, []byte{0x00}) {
+		t.Errorf("Parse() = %02X %s % X; want 12 40 01 30 00", m.Command, m.Address, m.Data)
+	}
+
+	// broadcast
+	m, err = JV1080.Parse(sysex.SysEx{0x41, 0x7F, 0x6A, 0x11, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x6E})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if m.Command != RQ1 || m.Size() != 0x81 {
+		t.Errorf("Parse() = %02X size %X; want 11 size 81", m.Command, m.Size())
+	}
+
+	errs := []sysex.SysEx{
+		{0x41, 0x10, 0x42, 0x12, 0x40, 0x01, 0x30, 0x00, 0x10},
+		{0x41, 0x10, 0x42, 0x12, 0x40, 0x01, 0x30, 0x01, 0x0F},
+	}
+
+	for i, msg := range errs {
+		if _, err := GS.Parse(msg); !errors.Is(err, ErrChecksum) {
+			t.Errorf("[%v] Parse() error = %v; want %v", i, err, ErrChecksum)
+		}
+	}
+
+	invalid := []sysex.SysEx{
+		// too short
+		{0x41, 0x10, 0x42, 0x12, 0x40, 0x01},
+		// other manufacturer
+		{0x43, 0x10, 0x42, 0x12, 0x40, 0x01, 0x30, 0x00, 0x0F},
+		// other device
+		{0x41, 0x11, 0x42, 0x12, 0x40, 0x01, 0x30, 0x00, 0x0F},
+		// other model
+		{0x41, 0x10, 0x45, 0x12, 0x40, 0x01, 0x30, 0x00, 0x0F},
+		// unknown command
+		{0x41, 0x10, 0x42, 0x13, 0x40, 0x01, 0x30, 0x00, 0x0F},
+	}
+
+	for i, msg := range invalid {
+		if _, err := GS.Parse(msg); err == nil || errors.Is(err, ErrChecksum) {
+			t.Errorf("[%v] Parse() error = %v; want an error other than %v", i, err, ErrChecksum)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package sysex
 
 import (
 	"fmt"
+
+	"github.com/gomidi/midi"
 )
 
 const (
@@ -9,6 +11,16 @@ const (
 	byteSysExEnd   = byte(0xF7)
 )
 
+// validate returns an error for the given message, if its data contains a status byte
+func validate(m Message) error {
+	for i, b := range m.Data() {
+		if b > 127 {
+			return fmt.Errorf("%w %T: status byte %02X at position %v", midi.ErrInvalidMessage, m, b, i)
+		}
+	}
+	return nil
+}
+
 // Escape is a sysex escape sequence with a prefixed 0xF7
 // it may only used within SMF files (not for live MIDI)
 type Escape []byte
@@ -30,6 +42,16 @@ func (m Escape) Raw() []byte {
 	return b
 }
 
+// EncodedLen returns the number of bytes of the data with the escape prefix (see Raw), without encoding it
+func (m Escape) EncodedLen() int {
+	return len(m) + 1
+}
+
+// Validate returns nil, since an escape may contain any bytes, e.g. realtime messages
+func (m Escape) Validate() error {
+	return nil
+}
+
 // Len returns the length of the sysex data
 func (m Escape) Len() int {
 	return len(m)
@@ -56,6 +78,17 @@ func (m Start) Raw() []byte {
 	return b
 }
 
+// EncodedLen returns the number of bytes of the data with the prefix (see Raw), without encoding it
+func (m Start) EncodedLen() int {
+	return len(m) + 1
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the data contains a status byte (above 127),
+// since the 0xF7 that terminates the message is added by Raw.
+func (m Start) Validate() error {
+	return validate(m)
+}
+
 // Len returns the length of the sysex data
 func (m Start) Len() int {
 	return len(m)
@@ -94,6 +127,17 @@ func (m Continue) Raw() []byte {
 	return b
 }
 
+// EncodedLen returns the number of bytes of the data with the prefix (see Raw), without encoding it
+func (m Continue) EncodedLen() int {
+	return len(m) + 1
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the data contains a status byte (above 127),
+// since the 0xF7 that terminates the message is added by Raw.
+func (m Continue) Validate() error {
+	return validate(m)
+}
+
 // Len returns the length of the sysex data
 func (m Continue) Len() int {
 	return len(m)
@@ -131,6 +175,17 @@ func (m End) Raw() []byte {
 	return b
 }
 
+// EncodedLen returns the number of bytes of the data with the prefix and the postfix (see Raw), without encoding it
+func (m End) EncodedLen() int {
+	return len(m) + 2
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the data contains a status byte (above 127),
+// since the 0xF7 that terminates the message is added by Raw.
+func (m End) Validate() error {
+	return validate(m)
+}
+
 // Message is a System Exclusive Message
 type Message interface {
 	String() string
@@ -177,3 +232,14 @@ func (m SysEx) Raw() []byte {
 	b = append(b, 0xF7)
 	return b
 }
+
+// EncodedLen returns the number of bytes of the data with the prefix and the postfix (see Raw), without encoding it
+func (m SysEx) EncodedLen() int {
+	return len(m) + 2
+}
+
+// Validate returns an error that wraps midi.ErrInvalidMessage, if the data contains a status byte (above 127),
+// since the 0xF7 that terminates the message is added by Raw.
+func (m SysEx) Validate() error {
+	return validate(m)
+}
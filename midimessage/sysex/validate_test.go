@@ -0,0 +1,40 @@
+package sysex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gomidi/midi"
+)
+
+func TestValidate(t *testing.T) {
+	invalid := []Message{
+		SysEx{0x41, 0xF7, 0x10},
+		SysEx{0x41, 0x90},
+		Start{0xF0},
+		Continue{0x10, 0xF8},
+		End{0xFF},
+	}
+
+	for _, msg := range invalid {
+		if err := midi.Validate(msg); !errors.Is(err, midi.ErrInvalidMessage) {
+			t.Errorf("Validate(%#v) = %v; want %v", msg, err, midi.ErrInvalidMessage)
+		}
+	}
+
+	valid := []Message{
+		SysEx{0x7E, 0x7F, 0x09, 0x01},
+		SysEx{},
+		Start{0x41},
+		Continue{0x7F},
+		End{0x00},
+		// escapes may contain any bytes
+		Escape{0xFA},
+	}
+
+	for _, msg := range valid {
+		if err := midi.Validate(msg); err != nil {
+			t.Errorf("Validate(%#v) = %v; want nil", msg, err)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package yamaha provides the bulk dump messages of Yamaha devices and their checksum.
+
+A bulk dump is either a classic dump (e.g. the voice dumps of the DX7), where the checksum covers the data,
+or a dump with an address (e.g. the XG and Motif dumps), where the checksum covers the byte count, the address
+and the data.
+
+	dump, err := yamaha.Parse(msg)
+
+	if errors.Is(err, yamaha.ErrChecksum) {
+		// the dump is corrupted
+	}
+*/
+package yamaha
@@ -0,0 +1,93 @@
+package yamaha
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+// ManufacturerID is the manufacturer ID of Yamaha
+const ManufacturerID = 0x43
+
+// ErrChecksum is returned by Parse for a bulk dump with a wrong checksum
+var ErrChecksum = errors.New("wrong checksum")
+
+// Checksum returns the Yamaha checksum of the given bytes: the value that makes the sum of the bytes and the
+// checksum a multiple of 128.
+func Checksum(data ...[]byte) byte {
+	var sum byte
+
+	for _, d := range data {
+		for _, b := range d {
+			sum += b
+		}
+	}
+
+	return (0x80 - sum&0x7F) & 0x7F
+}
+
+// BulkDump is a bulk dump message
+type BulkDump struct {
+	// Device is the device number (0-15)
+	Device byte
+
+	// Format is the format number of a classic dump (e.g. 0 for a single DX7 voice) or the model ID of a dump
+	// with an address (e.g. 4C for XG)
+	Format byte
+
+	// Address is the address of the data (3 bytes), nil for a classic dump
+	Address []byte
+
+	Data []byte
+}
+
+// SysEx returns the message of the bulk dump with the byte count and the checksum
+func (b BulkDump) SysEx() sysex.SysEx {
+	count := []byte{byte(len(b.Data)>>7) & 0x7F, byte(len(b.Data)) & 0x7F}
+	msg := sysex.SysEx{ManufacturerID, b.Device & 0x0F, b.Format}
+	msg = append(msg, count...)
+	msg = append(msg, b.Address...)
+	msg = append(msg, b.Data...)
+
+	if b.Address == nil {
+		return append(msg, Checksum(b.Data))
+	}
+
+	return append(msg, Checksum(count, b.Address, b.Data))
+}
+
+// Parse parses a bulk dump message and verifies its checksum. Whether the dump has an address is derived from its
+// length. A dump with a wrong checksum returns an error that wraps ErrChecksum.
+func Parse(msg sysex.SysEx) (b BulkDump, err error) {
+	if len(msg) < 6 {
+		return b, fmt.Errorf("invalid Yamaha bulk dump: too short")
+	}
+
+	if msg[0] != ManufacturerID || msg[1]&0xF0 != 0 {
+		return b, fmt.Errorf("invalid Yamaha bulk dump: header % X", []byte(msg[:2]))
+	}
+
+	b.Device, b.Format = msg[1], msg[2]
+	count := int(msg[3])<<7 | int(msg[4])
+	body := msg[5 : len(msg)-1]
+	var sum byte
+
+	switch len(body) {
+	case count:
+		b.Data = append([]byte{}, body...)
+		sum = Checksum(body)
+	case count + 3:
+		b.Address = append([]byte{}, body[:3]...)
+		b.Data = append([]byte{}, body[3:]...)
+		sum = Checksum(msg[3 : len(msg)-1])
+	default:
+		return b, fmt.Errorf("invalid Yamaha bulk dump: %v bytes for a byte count of %v", len(body), count)
+	}
+
+	if got := msg[len(msg)-1]; got != sum {
+		return b, fmt.Errorf("%w %02X, expected %02X", ErrChecksum, got, sum)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,73 @@
+package yamaha
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+func TestBulkDump(t *testing.T) {
+	// a DX7 single voice dump, where all 155 bytes are 1
+	voice := BulkDump{Format: 0x00, Data: bytes.Repeat([]byte{0x01}, 155)}
+	msg := voice.SysEx()
+
+	if got, want := fmt.Sprintf("% X", []byte(msg[:5])), "43 00 00 01 1B"; got != want {
+		t.Errorf("header = %s; want %s", got, want)
+	}
+
+	if got, want := msg[len(msg)-1], byte(0x65); got != want {
+		t.Errorf("checksum = %02X; want %02X", got, want)
+	}
+
+	// a XG dump with an address
+	xg := BulkDump{Device: 0, Format: 0x4C, Address: []byte{0x08, 0x00, 0x00}, Data: []byte{0x01, 0x02}}
+
+	if got, want := fmt.Sprintf("% X", xg.SysEx().Raw()), "F0 43 00 4C 00 02 08 00 00 01 02 73 F7"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+
+	for i, b := range []BulkDump{voice, xg} {
+		res, err := Parse(b.SysEx())
+
+		if err != nil {
+			t.Fatalf("[%v] Error: %v", i, err)
+		}
+
+		if res.Format != b.Format || !bytes.Equal(res.Address, b.Address) || !bytes.Equal(res.Data, b.Data) {
+			t.Errorf("[%v] Parse() = %+v; want %+v", i, res, b)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	corrupt := []sysex.SysEx{
+		{0x43, 0x00, 0x4C, 0x00, 0x02, 0x08, 0x00, 0x00, 0x01, 0x03, 0x73},
+		{0x43, 0x00, 0x00, 0x00, 0x02, 0x01, 0x02, 0x7C},
+	}
+
+	for i, msg := range corrupt {
+		if _, err := Parse(msg); !errors.Is(err, ErrChecksum) {
+			t.Errorf("[%v] Parse() error = %v; want %v", i, err, ErrChecksum)
+		}
+	}
+
+	invalid := []sysex.SysEx{
+		// too short
+		{0x43, 0x00, 0x00, 0x00},
+		// other manufacturer
+		{0x41, 0x00, 0x00, 0x00, 0x02, 0x01, 0x02, 0x7D},
+		// parameter change instead of bulk dump
+		{0x43, 0x10, 0x4C, 0x00, 0x00, 0x7E, 0x00},
+		// wrong byte count
+		{0x43, 0x00, 0x00, 0x00, 0x03, 0x01, 0x02, 0x7D},
+	}
+
+	for i, msg := range invalid {
+		if _, err := Parse(msg); err == nil || errors.Is(err, ErrChecksum) {
+			t.Errorf("[%v] Parse() error = %v; want an error other than %v", i, err, ErrChecksum)
+		}
+	}
+}
@@ -55,11 +55,11 @@ func Example() {
 	}
 
 	// Output:
-	// channel.Pitchbend channel 2 value 5000 absValue 13192
-	// channel.NoteOn channel 2 key 65 velocity 90
+	// channel.Pitchbend channel 3 value 5000 absValue 13192
+	// channel.NoteOn channel 3 key 65 velocity 90
 	// NoteOn at channel 2: key: 65 velocity: 90
 	// Realtime: Reset
-	// channel.NoteOff channel 2 key 65
+	// channel.NoteOff channel 3 key 65
 	// NoteOff at channel 2: key: 65
 
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/gomidi/midi/internal/runningstatus"
 	"github.com/gomidi/midi/midimessage/channel"
 	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/status"
 	"github.com/gomidi/midi/midimessage/syscommon"
 	"github.com/gomidi/midi/midimessage/sysex"
 )
@@ -17,7 +18,8 @@ import (
 // or passed to rthandler (if not) while other MIDI messages will be returned.
 //
 // The Reader does no buffering and makes no attempt to close src.
-// If src.Read returns an io.EOF, the reader stops reading and returns the error.
+// If src.Read returns an io.EOF, the reader stops reading and returns the error. If that happens within a message,
+// midi.ErrUnexpectedEOF is returned instead (for a system exclusive message together with the bytes read so far).
 func New(src io.Reader, rthandler func(realtime.Message), options ...Option) midi.Reader {
 	rd := &reader{
 		input:         realtime.NewReader(src, rthandler),
@@ -75,7 +77,7 @@ func (r *reader) discardUntilNextStatus() (canary byte, err error) {
 			return
 		}
 
-		if midilib.IsStatusByte(canary) {
+		if status.IsStatus(canary) {
 			return
 		}
 	}
@@ -95,7 +97,7 @@ func (r *reader) discardUntilNextStatus() (canary byte, err error) {
 
 // readSysEx reads a sysex
 // here we can ignore incomplete casio style messages (since they are only interrupted in time)
-func (r *reader) readSysEx() (sys sysex.SysEx, status byte, err error) {
+func (r *reader) readSysEx() (sys sysex.SysEx, st byte, err error) {
 	var b byte
 	var bf []byte
 
@@ -113,9 +115,9 @@ func (r *reader) readSysEx() (sys sysex.SysEx, status byte, err error) {
 		}
 
 		// not so elegant way to terminate by sending a new status
-		if midilib.IsStatusByte(b) {
+		if status.IsStatus(b) {
 			sys = sysex.SysEx(bf)
-			status = b
+			st = b
 			return
 		}
 
@@ -125,6 +127,10 @@ func (r *reader) readSysEx() (sys sysex.SysEx, status byte, err error) {
 	// any error, especially io.EOF is considered a failure.
 	// however return the sysex that had been received so far back to the user
 	// and leave him to decide what to do.
+	if err == io.EOF {
+		err = midi.ErrUnexpectedEOF
+	}
+
 	sys = sysex.SysEx(bf)
 	return
 }
@@ -174,6 +180,11 @@ func (r *reader) readMsg(canary byte) (m midi.Message, err error) {
 		// was no running status, we have to read arg1
 		if changed {
 			arg1, err = midilib.ReadByte(r.input)
+
+			if err == io.EOF {
+				err = midi.ErrUnexpectedEOF
+			}
+
 			if err != nil {
 				return
 			}
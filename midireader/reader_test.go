@@ -50,14 +50,14 @@ func TestRead(t *testing.T) {
 	}
 
 	expected := `
-channel.NoteOn channel 1 key 65 velocity 100
+channel.NoteOn channel 2 key 65 velocity 100
 Realtime: Start
 sysex.SysEx len: 1
-channel.NoteOff channel 1 key 65
+channel.NoteOff channel 2 key 65
 syscommon.Tune
-channel.NoteOn channel 2 key 62 velocity 30
+channel.NoteOn channel 3 key 62 velocity 30
 sysex.SysEx len: 2
-channel.NoteOff channel 2 key 62
+channel.NoteOff channel 3 key 62
 `
 	if got, wanted := bf.String(), expected; got != wanted {
 		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, wanted)
@@ -85,14 +85,14 @@ func TestReadNoteOffVelocity(t *testing.T) {
 	}
 
 	expected := `
-channel.NoteOn channel 1 key 65 velocity 100
+channel.NoteOn channel 2 key 65 velocity 100
 Realtime: Start
 sysex.SysEx len: 1
-channel.NoteOffVelocity channel 1 key 65 velocity 64
+channel.NoteOffVelocity channel 2 key 65 velocity 64
 syscommon.Tune
-channel.NoteOn channel 2 key 62 velocity 30
+channel.NoteOn channel 3 key 62 velocity 30
 sysex.SysEx len: 2
-channel.NoteOff channel 2 key 62
+channel.NoteOff channel 3 key 62
 `
 	if got, wanted := bf.String(), expected; got != wanted {
 		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, wanted)
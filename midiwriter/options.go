@@ -2,6 +2,7 @@ package midiwriter
 
 type config struct {
 	noRunningStatus bool
+	unsafe          bool
 }
 
 // Option is a configuration option for a writer
@@ -14,3 +15,12 @@ func NoRunningStatus() Option {
 		c.noRunningStatus = true
 	}
 }
+
+// Unsafe is an option for the writer that lets it write invalid messages, e.g. for torture tests of receivers.
+// Without passing this option, the writer validates each message (see midi.Validate) and returns the error of an
+// invalid message instead of writing it.
+func Unsafe() Option {
+	return func(c *config) {
+		c.unsafe = true
+	}
+}
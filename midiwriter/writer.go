@@ -10,7 +10,7 @@ import (
 //
 // The Writer does no buffering and makes no attempt to close dest.
 //
-// By default the writer uses running status for efficiency.
+// By default the writer validates the messages (see Unsafe) and uses running status for efficiency.
 // You can disable that behaviour by passing the NoRunningStatus() option.
 // If you don't know what running status is, keep the default.
 func New(dest io.Writer, opts ...Option) (wr midi.Writer) {
@@ -28,9 +28,26 @@ func New(dest io.Writer, opts ...Option) (wr midi.Writer) {
 		}
 	}
 
+	if !c.unsafe {
+		wr = validatingWriter{wr}
+	}
+
 	return wr
 }
 
+// validatingWriter validates the messages before they are written (see midi.Validate)
+type validatingWriter struct {
+	midi.Writer
+}
+
+// Write writes the given message, if it is valid, and returns the error of an invalid message otherwise
+func (w validatingWriter) Write(msg midi.Message) error {
+	if err := midi.Validate(msg); err != nil {
+		return err
+	}
+	return w.Writer.Write(msg)
+}
+
 type notRunningWriter struct {
 	output io.Writer
 }
@@ -2,10 +2,13 @@ package midiwriter
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"testing"
 
+	"github.com/gomidi/midi"
 	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/sysex"
 )
 
 func TestRunningStatus(t *testing.T) {
@@ -39,3 +42,57 @@ func TestNoRunningStatus(t *testing.T) {
 		t.Errorf("got:\n%#v\nwanted:\n%#v\n\n", got, want)
 	}
 }
+
+// panickingMessage is a foreign message whose Raw method panics
+type panickingMessage struct{}
+
+func (panickingMessage) String() string { return "panicking" }
+func (panickingMessage) Raw() []byte    { panic("not implemented") }
+
+// emptyMessage is a foreign message without raw bytes
+type emptyMessage struct{}
+
+func (emptyMessage) String() string { return "empty" }
+func (emptyMessage) Raw() []byte    { return nil }
+
+func TestValidation(t *testing.T) {
+
+	var bf bytes.Buffer
+
+	wr := New(&bf)
+
+	if err := wr.Write(channel.Channel(16).NoteOn(50, 33)); !errors.Is(err, midi.ErrInvalidMessage) {
+		t.Errorf("Write() = %v; want %v", err, midi.ErrInvalidMessage)
+	}
+
+	if err := wr.Write(sysex.SysEx{0x41, 0xF7}); !errors.Is(err, midi.ErrInvalidMessage) {
+		t.Errorf("Write() = %v; want %v", err, midi.ErrInvalidMessage)
+	}
+
+	// foreign messages that can't be encoded
+	if err := wr.Write(panickingMessage{}); !errors.Is(err, midi.ErrInvalidMessage) {
+		t.Errorf("Write() = %v; want %v", err, midi.ErrInvalidMessage)
+	}
+
+	if err := wr.Write(emptyMessage{}); !errors.Is(err, midi.ErrInvalidMessage) {
+		t.Errorf("Write() = %v; want %v", err, midi.ErrInvalidMessage)
+	}
+
+	// the invalid messages are not written, the writer keeps working
+	wr.Write(channel.Channel0.NoteOn(50, 33))
+
+	if got, want := fmt.Sprintf("% X", bf.Bytes()), "90 32 21"; got != want {
+		t.Errorf("got:\n%#v\nwanted:\n%#v\n\n", got, want)
+	}
+
+	bf.Reset()
+	wr = New(&bf, Unsafe())
+
+	if err := wr.Write(sysex.SysEx{0x41, 0xF7}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := fmt.Sprintf("% X", bf.Bytes()), "F0 41 F7 F7"; got != want {
+		t.Errorf("got:\n%#v\nwanted:\n%#v\n\n", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package route provides a small rules engine that translates incoming MIDI messages to outgoing messages,
+e.g. for mapping a control surface to a synthesizer and sending feedback to its LEDs.
+
+A Map has Rules that match incoming messages by type, channel, controller and key range. Each matching
+Rule emits the messages of its Output templates, with the value of the incoming message scaled to a target
+range, inverted or latched as toggle (for buttons).
+
+	m, err := route.ReadJSON(presetFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	wr := route.NewWriter(m, midiwriter.New(output))
+	wr.Write(channel.Channel0.ControlChange(20, 127))
+
+Maps can be shared as JSON presets (see ReadJSON and Map.WriteJSON):
+
+	{
+		"rules": [
+			{
+				"match": {"type": "cc", "channel": 0, "controller": 20},
+				"outputs": [{"type": "noteon", "key": 36, "toggle": true}]
+			}
+		],
+		"passThrough": true
+	}
+*/
+package route
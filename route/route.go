@@ -0,0 +1,383 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// Type is the type of a channel message, as used in the JSON presets
+type Type string
+
+const (
+	NoteOn         Type = "noteon"
+	NoteOff        Type = "noteoff"
+	ControlChange  Type = "cc"
+	ProgramChange  Type = "program"
+	Pitchbend      Type = "pitchbend"
+	Aftertouch     Type = "aftertouch"
+	PolyAftertouch Type = "polyaftertouch"
+)
+
+// hasKey returns true, if the messages of the type have a key
+func (t Type) hasKey() bool {
+	return t == NoteOn || t == NoteOff || t == PolyAftertouch
+}
+
+// valid returns true, if the type is one of the defined types
+func (t Type) valid() bool {
+	switch t {
+	case NoteOn, NoteOff, ControlChange, ProgramChange, Pitchbend, Aftertouch, PolyAftertouch:
+		return true
+	}
+	return false
+}
+
+// Number returns a pointer to n, for the optional fields of Match and Output
+func Number(n uint8) *uint8 {
+	return &n
+}
+
+// Range is a range of values from Min to Max (both inclusive)
+type Range struct {
+	Min uint8 `json:"min"`
+	Max uint8 `json:"max"`
+}
+
+// Match selects the incoming messages of a Rule
+type Match struct {
+	Type Type `json:"type"`
+
+	// Channel is the channel of the messages (0-15), nil matches all channels
+	Channel *uint8 `json:"channel,omitempty"`
+
+	// Controller is the controller of control change messages, nil matches all controllers
+	Controller *uint8 `json:"controller,omitempty"`
+
+	// Keys is the range of the keys of note and polyphonic aftertouch messages, nil matches all keys
+	Keys *Range `json:"keys,omitempty"`
+}
+
+// Output is the template of an outgoing message of a Rule.
+//
+// The value of the incoming message (the velocity, controller value, program, pressure or the pitch bend scaled
+// to 0-127, 0 for note off messages) is inverted (if Invert is set) and mapped linearly to the Range. It becomes
+// the velocity, controller value, program, pressure or pitch bend (scaled from 0-127) of the outgoing message.
+type Output struct {
+	Type Type `json:"type"`
+
+	// Channel is the channel of the message, nil uses the channel of the incoming message
+	Channel *uint8 `json:"channel,omitempty"`
+
+	// Controller is the controller of a control change message, nil uses the controller of the incoming message
+	Controller *uint8 `json:"controller,omitempty"`
+
+	// Key is the key of a note or polyphonic aftertouch message, nil uses the key of the incoming message
+	Key *uint8 `json:"key,omitempty"`
+
+	// Range is the target range of the value, nil is 0-127. A Min above Max inverts the value.
+	// Min and Max of the same value result in a fixed value.
+	Range *Range `json:"range,omitempty"`
+
+	// Invert inverts the incoming value (127 - value)
+	Invert bool `json:"invert,omitempty"`
+
+	// Toggle latches the output for buttons: each press (a value above 0) toggles the value between 127 and 0
+	// (before inverting and mapping), a release (a value of 0) is ignored.
+	// The state is kept per channel and controller or key of the incoming message.
+	Toggle bool `json:"toggle,omitempty"`
+}
+
+// Rule emits the messages of its Outputs for each message that matches
+type Rule struct {
+	Match   Match    `json:"match"`
+	Outputs []Output `json:"outputs"`
+}
+
+// Map translates messages by its Rules. All matching Rules emit their Outputs in the order of the Rules.
+// A Map is safe for concurrent use.
+type Map struct {
+	Rules []Rule `json:"rules"`
+
+	// PassThrough passes the messages that are not matched by any Rule unchanged
+	PassThrough bool `json:"passThrough,omitempty"`
+
+	mx      sync.Mutex
+	latches map[latch]bool
+}
+
+// latch identifies the state of a toggling Output
+type latch struct {
+	rule, output    int
+	channel, number uint8
+}
+
+// input is an incoming channel message, broken down into its parts
+type input struct {
+	typ     Type
+	channel uint8
+
+	// number is the controller or key
+	number uint8
+	value  uint8
+}
+
+// parse returns the parts of the given message. ok is false for messages that are no channel messages.
+func parse(msg midi.Message) (in input, ok bool) {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		if v.Velocity() == 0 {
+			return input{NoteOff, v.Channel(), v.Key(), 0}, true
+		}
+		return input{NoteOn, v.Channel(), v.Key(), v.Velocity()}, true
+	case channel.NoteOff:
+		return input{NoteOff, v.Channel(), v.Key(), 0}, true
+	case channel.NoteOffVelocity:
+		return input{NoteOff, v.Channel(), v.Key(), 0}, true
+	case channel.ControlChange:
+		return input{ControlChange, v.Channel(), v.Controller(), v.Value()}, true
+	case channel.ProgramChange:
+		return input{ProgramChange, v.Channel(), 0, v.Program()}, true
+	case channel.Pitchbend:
+		return input{Pitchbend, v.Channel(), 0, uint8((int(v.Value()) + 8192) >> 7)}, true
+	case channel.Aftertouch:
+		return input{Aftertouch, v.Channel(), 0, v.Pressure()}, true
+	case channel.PolyAftertouch:
+		return input{PolyAftertouch, v.Channel(), v.Key(), v.Pressure()}, true
+	}
+	return input{}, false
+}
+
+// matches returns true, if the incoming message is matched
+func (m Match) matches(in input) bool {
+	switch {
+	case m.Type != in.typ:
+		return false
+	case m.Channel != nil && *m.Channel != in.channel:
+		return false
+	case m.Controller != nil && in.typ == ControlChange && *m.Controller != in.number:
+		return false
+	case m.Keys != nil && in.typ.hasKey() && (in.number < m.Keys.Min || in.number > m.Keys.Max):
+		return false
+	}
+	return true
+}
+
+// Scale maps the given value of 0-127 linearly to the range, rounding to the nearest value.
+// 0 becomes Min and 127 becomes Max.
+func (r Range) Scale(value uint8) uint8 {
+	v := float64(r.Min) + float64(value)*(float64(r.Max)-float64(r.Min))/127
+	return uint8(math.Round(v))
+}
+
+// Apply returns the messages that the Rules emit for the given message, nil if no Rule matches
+// (or the message itself, if PassThrough is set).
+func (m *Map) Apply(msg midi.Message) []midi.Message {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	in, ok := parse(msg)
+	var res []midi.Message
+	var matched bool
+
+	for ri, r := range m.Rules {
+		if !ok || !r.Match.matches(in) {
+			continue
+		}
+
+		matched = true
+
+		for oi, o := range r.Outputs {
+			value := in.value
+
+			if o.Toggle {
+				if value == 0 {
+					continue
+				}
+
+				if m.latches == nil {
+					m.latches = map[latch]bool{}
+				}
+
+				l := latch{ri, oi, in.channel, in.number}
+				m.latches[l] = !m.latches[l]
+				value = 0
+
+				if m.latches[l] {
+					value = 127
+				}
+			}
+
+			res = append(res, o.message(in, value))
+		}
+	}
+
+	if !matched && m.PassThrough {
+		return []midi.Message{msg}
+	}
+
+	return res
+}
+
+// Reset releases all latched toggles
+func (m *Map) Reset() {
+	m.mx.Lock()
+	m.latches = nil
+	m.mx.Unlock()
+}
+
+// message returns the outgoing message for the given incoming message and its (latched) value
+func (o Output) message(in input, value uint8) midi.Message {
+	if o.Invert {
+		value = 127 - value
+	}
+
+	if o.Range != nil {
+		value = o.Range.Scale(value)
+	}
+
+	ch := channel.Channel(in.channel)
+
+	if o.Channel != nil {
+		ch = channel.Channel(*o.Channel)
+	}
+
+	number := in.number
+
+	if o.Type == ControlChange && o.Controller != nil {
+		number = *o.Controller
+	}
+
+	if o.Type.hasKey() && o.Key != nil {
+		number = *o.Key
+	}
+
+	switch o.Type {
+	case NoteOn:
+		return ch.NoteOn(number, value)
+	case NoteOff:
+		return ch.NoteOff(number)
+	case ControlChange:
+		return ch.ControlChange(number, value)
+	case ProgramChange:
+		return ch.ProgramChange(value)
+	case Pitchbend:
+		return ch.Pitchbend(pitchbend(value))
+	case Aftertouch:
+		return ch.Aftertouch(value)
+	default:
+		return ch.PolyAftertouch(number, value)
+	}
+}
+
+// pitchbend scales the given value of 0-127 to a pitch bend value, so that 64 is the center and 127 the maximum
+func pitchbend(value uint8) int16 {
+	if value >= 127 {
+		return 8191
+	}
+	return int16(value)*128 - 8192
+}
+
+// Validate checks the types, channels, ranges and the presence of the controllers and keys of the outputs.
+func (m *Map) Validate() error {
+	for ri, r := range m.Rules {
+		if err := r.Match.validate(); err != nil {
+			return fmt.Errorf("rule %v: %v", ri, err)
+		}
+
+		for oi, o := range r.Outputs {
+			if err := o.validate(r.Match.Type); err != nil {
+				return fmt.Errorf("rule %v, output %v: %v", ri, oi, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m Match) validate() error {
+	switch {
+	case !m.Type.valid():
+		return fmt.Errorf("unknown type %q", m.Type)
+	case m.Channel != nil && *m.Channel > 15:
+		return fmt.Errorf("invalid channel %v", *m.Channel)
+	case m.Keys != nil && (m.Keys.Min > 127 || m.Keys.Max > 127):
+		return fmt.Errorf("invalid key range %v-%v", m.Keys.Min, m.Keys.Max)
+	}
+	return nil
+}
+
+// validate checks the output of a rule that matches messages of the given type
+func (o Output) validate(matched Type) error {
+	switch {
+	case !o.Type.valid():
+		return fmt.Errorf("unknown type %q", o.Type)
+	case o.Channel != nil && *o.Channel > 15:
+		return fmt.Errorf("invalid channel %v", *o.Channel)
+	case o.Controller != nil && *o.Controller > 127:
+		return fmt.Errorf("invalid controller %v", *o.Controller)
+	case o.Key != nil && *o.Key > 127:
+		return fmt.Errorf("invalid key %v", *o.Key)
+	case o.Range != nil && (o.Range.Min > 127 || o.Range.Max > 127):
+		return fmt.Errorf("invalid range %v-%v", o.Range.Min, o.Range.Max)
+	case o.Type == ControlChange && o.Controller == nil && matched != ControlChange:
+		return fmt.Errorf("missing controller")
+	case o.Type.hasKey() && o.Key == nil && !matched.hasKey():
+		return fmt.Errorf("missing key")
+	}
+	return nil
+}
+
+// ReadJSON reads a Map from its JSON representation and validates it (see Map.Validate).
+func ReadJSON(r io.Reader) (*Map, error) {
+	var m Map
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("can't read map: %v", err)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid map: %v", err)
+	}
+
+	return &m, nil
+}
+
+// WriteJSON writes the Rules and PassThrough of the Map as indented JSON. The latched toggles are not written.
+func (m *Map) WriteJSON(w io.Writer) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(m)
+}
+
+// Writer is a midi.Writer that writes the messages of a Map for each written message to another midi.Writer
+type Writer struct {
+	m   *Map
+	out midi.Writer
+}
+
+// NewWriter returns a Writer that writes the messages of the given Map to out
+func NewWriter(m *Map, out midi.Writer) *Writer {
+	return &Writer{m: m, out: out}
+}
+
+// Write writes the messages that the Map returns for the given message. It stops at the first error.
+func (w *Writer) Write(msg midi.Message) error {
+	for _, res := range w.m.Apply(msg) {
+		if err := w.out.Write(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
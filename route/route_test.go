@@ -0,0 +1,199 @@
+package route
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// messagesString returns the messages, one per line
+func messagesString(msgs []midi.Message) string {
+	var bf bytes.Buffer
+
+	for _, msg := range msgs {
+		fmt.Fprintf(&bf, "%s\n", msg)
+	}
+
+	return bf.String()
+}
+
+func TestRangeScale(t *testing.T) {
+	tests := []struct {
+		r        Range
+		value    uint8
+		expected uint8
+	}{
+		{Range{0, 127}, 0, 0},
+		{Range{0, 127}, 127, 127},
+		{Range{20, 100}, 0, 20},
+		{Range{20, 100}, 64, 60},
+		{Range{20, 100}, 127, 100},
+		{Range{127, 0}, 0, 127},
+		{Range{127, 0}, 127, 0},
+		{Range{0, 1}, 63, 0},
+		{Range{0, 1}, 64, 1},
+		{Range{64, 64}, 127, 64},
+	}
+
+	for _, test := range tests {
+		if got := test.r.Scale(test.value); got != test.expected {
+			t.Errorf("%+v.Scale(%v) = %v; want %v", test.r, test.value, got, test.expected)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	m := &Map{
+		Rules: []Rule{
+			// a fader controls the volume of channel 2, inverted and limited
+			{
+				Match:   Match{Type: ControlChange, Controller: Number(7)},
+				Outputs: []Output{{Type: ControlChange, Channel: Number(2), Invert: true, Range: &Range{10, 110}}},
+			},
+			// all faders are mirrored as pitch bend on channel 15
+			{
+				Match:   Match{Type: ControlChange},
+				Outputs: []Output{{Type: Pitchbend, Channel: Number(15)}},
+			},
+			// pads are transposed by an octave on their channel, with a fixed velocity
+			{
+				Match:   Match{Type: NoteOn, Keys: &Range{36, 51}},
+				Outputs: []Output{{Type: NoteOn, Range: &Range{100, 100}}, {Type: ProgramChange, Channel: Number(9)}},
+			},
+		},
+	}
+
+	tests := []struct {
+		msg      midi.Message
+		expected string
+	}{
+		{channel.Channel0.ControlChange(7, 0), "channel.ControlChange channel 3 controller 7 (\"Volume (MSB)\") value 110\nchannel.Pitchbend channel 16 value -8192 absValue 0\n"},
+		{channel.Channel0.ControlChange(7, 127), "channel.ControlChange channel 3 controller 7 (\"Volume (MSB)\") value 10\nchannel.Pitchbend channel 16 value 8191 absValue 0\n"},
+		{channel.Channel1.ControlChange(1, 64), "channel.Pitchbend channel 16 value 0 absValue 0\n"},
+		{channel.Channel0.NoteOn(36, 20), "channel.NoteOn channel 1 key 36 velocity 100\nchannel.ProgramChange channel 10 program 20\n"},
+		{channel.Channel0.NoteOn(52, 20), ""},
+		{channel.Channel0.ProgramChange(3), ""},
+	}
+
+	for _, test := range tests {
+		if got := messagesString(m.Apply(test.msg)); got != test.expected {
+			t.Errorf("Apply(%s) got:\n%s\n\nwanted:\n%s\n\n", test.msg, got, test.expected)
+		}
+	}
+
+	m.PassThrough = true
+
+	if got, want := messagesString(m.Apply(channel.Channel0.ProgramChange(3))), "channel.ProgramChange channel 1 program 3\n"; got != want {
+		t.Errorf("Apply() with PassThrough = %q; want %q", got, want)
+	}
+}
+
+func TestToggle(t *testing.T) {
+	// a button toggles the sustain of channel 0 and its LED (note 36)
+	m := &Map{
+		Rules: []Rule{
+			{
+				Match: Match{Type: ControlChange, Channel: Number(0), Controller: Number(20)},
+				Outputs: []Output{
+					{Type: ControlChange, Controller: Number(64), Toggle: true},
+					{Type: NoteOn, Key: Number(36), Toggle: true, Range: &Range{0, 1}},
+				},
+			},
+		},
+	}
+
+	var bf bytes.Buffer
+	wr := NewWriter(m, writerFunc(func(msg midi.Message) error {
+		fmt.Fprintf(&bf, "%s\n", msg)
+		return nil
+	}))
+
+	press, release := channel.Channel0.ControlChange(20, 127), channel.Channel0.ControlChange(20, 0)
+
+	for _, msg := range []midi.Message{press, release, press, release, press} {
+		if err := wr.Write(msg); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+
+	expected := `channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 127 (on)
+channel.NoteOn channel 1 key 36 velocity 1
+channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 0 (off)
+channel.NoteOn channel 1 key 36 velocity 0
+channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 127 (on)
+channel.NoteOn channel 1 key 36 velocity 1
+`
+
+	if got := bf.String(); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	m.Reset()
+
+	if got := messagesString(m.Apply(press)); !strings.HasSuffix(got, "velocity 1\n") {
+		t.Errorf("after Reset got:\n%s", got)
+	}
+}
+
+type writerFunc func(midi.Message) error
+
+func (w writerFunc) Write(msg midi.Message) error { return w(msg) }
+
+func TestJSON(t *testing.T) {
+	preset := `{
+	"rules": [
+		{
+			"match": {"type": "cc", "channel": 0, "controller": 20},
+			"outputs": [{"type": "noteon", "key": 36, "toggle": true}]
+		},
+		{
+			"match": {"type": "noteon", "keys": {"min": 36, "max": 51}},
+			"outputs": [{"type": "noteon", "channel": 9, "range": {"min": 127, "max": 0}, "invert": true}]
+		}
+	],
+	"passThrough": true
+}`
+
+	m, err := ReadJSON(strings.NewReader(preset))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var bf bytes.Buffer
+
+	if err := m.WriteJSON(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	read, err := ReadJSON(&bf)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	for _, msg := range []midi.Message{channel.Channel0.ControlChange(20, 127), channel.Channel0.NoteOn(40, 100), channel.Channel0.NoteOn(60, 100)} {
+		if got, want := messagesString(read.Apply(msg)), messagesString(m.Apply(msg)); got != want {
+			t.Errorf("Apply(%s) after round trip got:\n%s\n\nwanted:\n%s\n\n", msg, got, want)
+		}
+	}
+
+	invalid := []string{
+		`{"rules": [{"match": {"type": "sysex"}}]}`,
+		`{"rules": [{"match": {"type": "cc", "channel": 16}}]}`,
+		`{"rules": [{"match": {"type": "program"}, "outputs": [{"type": "cc"}]}]}`,
+		`{"rules": [{"match": {"type": "cc"}, "outputs": [{"type": "noteon"}]}]}`,
+		`{"rules": [{"match": {"type": "cc"}, "outputs": [{"type": "cc", "range": {"min": 0, "max": 128}}]}]}`,
+		`{"rules": [], "unknown": true}`,
+	}
+
+	for _, preset := range invalid {
+		if _, err := ReadJSON(strings.NewReader(preset)); err == nil {
+			t.Errorf("ReadJSON(%s) must return an error", preset)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// guitarFile returns a SMF where channel 0 has a pitch bend range of 12 semitones (as used by guitar plugins)
+// and channel 1 keeps the default range.
+func guitarFile(t *testing.T) *smftrack.SMF {
+	var tr smftrack.Track
+	ch0, ch1 := channel.Channel0, channel.Channel1
+
+	tr.Add(0,
+		ch0.ControlChange(101, 0),
+		ch0.ControlChange(100, 0),
+		ch0.ControlChange(6, 12),
+		ch0.Pitchbend(8191),
+		ch0.NoteOn(60, 100),
+		ch1.Pitchbend(-8192),
+		ch1.NoteOn(60, 100),
+	)
+	tr.Add(96, ch0.NoteOff(60), ch1.NoteOff(60), ch0.Pitchbend(-4096), ch0.NoteOn(64, 100))
+	tr.Add(192, ch0.NoteOff(64))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	// make sure the information survives writing and reading
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	s, err := smftrack.Read(&bf)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	return s
+}
+
+func TestBendRanges(t *testing.T) {
+	ranges := BendRanges(guitarFile(t))
+
+	if got, want := ranges.At(0, 0), 12.0; got != want {
+		t.Errorf("At(0, 0) = %v; want %v", got, want)
+	}
+
+	if got, want := ranges.At(1, 0), DefaultBendRange; got != want {
+		t.Errorf("At(1, 0) = %v; want %v", got, want)
+	}
+}
+
+func TestNoteFrequencies(t *testing.T) {
+	s := guitarFile(t)
+	notes := s.Notes()
+	freqs := NoteFrequencies(notes, PitchBends(s), BendRanges(s))
+
+	var bf bytes.Buffer
+
+	for i, n := range notes {
+		fmt.Fprintf(&bf, "%v %v %v %v %0.2f\n", n.AbsTicks, n.Duration, n.Channel, n.Key, freqs[i])
+	}
+
+	// channel 0: key 60 + 12 semitones, key 64 - 6 semitones; channel 1: key 60 - 2 semitones
+	expected := `0 96 0 60 523.25
+0 96 1 60 233.08
+96 96 0 64 233.08
+`
+
+	if got, want := bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestMTSTuning(t *testing.T) {
+	var tr smftrack.Track
+	// retune key 60 to 69 (A4) + 1/2 semitone
+	tr.Add(0, sysex.SysEx{0x7F, 0x7F, 0x08, 0x02, 0x00, 0x01, 60, 69, 0x40, 0x00})
+	tr.Add(10, channel.Channel0.NoteOn(60, 100))
+	tr.Add(20, channel.Channel0.NoteOff(60))
+
+	s := smftrack.New(smf.SMF0, nil)
+	s.AddTrack(&tr)
+
+	freqs := NoteFrequencies(s.Notes(), PitchBends(s), BendRanges(s), MTSTuning(s))
+
+	if got, want := fmt.Sprintf("%0.2f", freqs), "[452.89]"; got != want {
+		t.Errorf("NoteFrequencies() = %s; want %s", got, want)
+	}
+}
+
+func TestMTSTuningTruncated(t *testing.T) {
+	tests := [][]byte{
+		{0x7F, 0x00, 0x08, 0x02},
+		{0x7F, 0x00, 0x08, 0x02, 0x00},
+		{0x7F, 0x00, 0x08, 0x07, 0x00, 0x00},
+		{0x7F, 0x00, 0x08, 0x02, 0x00, 0x02, 60, 69, 0x40},
+	}
+
+	for _, data := range tests {
+		if got := parseMTSNoteChange(0, data); len(got) != 0 {
+			t.Errorf("parseMTSNoteChange(% X) = %v; want none", data, got)
+		}
+	}
+}
+
+func TestPitchBendMaxStep(t *testing.T) {
+	var tr smftrack.Track
+	tr.Add(0, channel.Channel0.Pitchbend(0), channel.Channel1.Pitchbend(100))
+	tr.Add(960, channel.Channel0.Pitchbend(8191), channel.Channel1.Pitchbend(-100))
+	tr.Add(1920, channel.Channel0.Pitchbend(-8192))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(960))
+	s.AddTrack(&tr)
+
+	tests := []struct {
+		maxTicks uint32
+		maxValue uint16
+		ticks    uint64
+		value    int
+	}{
+		{48, 0, 48, 820},
+		{0, 512, 60, 512},
+		{48, 512, 48, 512},
+		{1, 0, 1, 18},
+	}
+
+	curve := PitchBends(s)[0]
+
+	if ticks, value := curve.MaxStep(); ticks != 960 || value != 16383 {
+		t.Errorf("MaxStep() = %v, %v; want 960, 16383", ticks, value)
+	}
+
+	for _, test := range tests {
+		smoothed, err := smftrack.SmoothPitchBend(s, 0, test.maxTicks, smftrack.MaxBendStep(test.maxValue))
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		bends := PitchBends(smoothed)
+		c := bends[0]
+
+		if ticks, value := c.MaxStep(); ticks != test.ticks || value != test.value {
+			t.Errorf("MaxStep() after SmoothPitchBend(%v, %v) = %v, %v; want %v, %v",
+				test.maxTicks, test.maxValue, ticks, value, test.ticks, test.value)
+		}
+
+		// the existing points are kept
+		for _, p := range curve.Points {
+			if got := c.ValueAt(p.AbsTicks); got != p.Value {
+				t.Errorf("ValueAt(%v) after SmoothPitchBend(%v, %v) = %v; want %v", p.AbsTicks, test.maxTicks, test.maxValue, got, p.Value)
+			}
+		}
+
+		// the other channel is not smoothed
+		if got := len(bends[1].Points); got != 2 {
+			t.Errorf("SmoothPitchBend(%v, %v) produced %v points on channel 1; want 2", test.maxTicks, test.maxValue, got)
+		}
+	}
+}
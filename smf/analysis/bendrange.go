@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// DefaultBendRange is the pitch bend range in semitones that is assumed, if it has not been set via RPN 0.
+const DefaultBendRange = 2.0
+
+// BendRange is a change of the pitch bend range (pitch bend sensitivity) of a channel
+type BendRange struct {
+	AbsTicks uint64
+
+	// Semitones is the range in semitones in both directions. The fraction is the cents (e.g. 12.5 are 12 semitones and 50 cents).
+	Semitones float64
+}
+
+// BendRangeMap maps the MIDI channels to their changes of the pitch bend range, sorted by their absolute ticks
+type BendRangeMap map[uint8][]BendRange
+
+// At returns the pitch bend range in semitones of the given channel at the given tick.
+// If the range has not been set for the channel until then, DefaultBendRange is returned.
+func (m BendRangeMap) At(ch uint8, absTicks uint64) float64 {
+	changes := m[ch]
+
+	i := sort.Search(len(changes), func(i int) bool {
+		return changes[i].AbsTicks > absTicks
+	})
+
+	if i == 0 {
+		return DefaultBendRange
+	}
+
+	return changes[i-1].Semitones
+}
+
+// BendRanges returns the pitch bend ranges that are set via RPN 0 (pitch bend sensitivity) within the given SMF.
+func BendRanges(s *smftrack.SMF) BendRangeMap {
+	var m = BendRangeMap{}
+	var rd channel.RPNReader
+
+	for _, ev := range s.Merged() {
+		msg, is := ev.Message.(channel.Message)
+		if !is {
+			continue
+		}
+
+		rpn, ok := rd.Read(msg)
+		if !ok || !rpn.IsPitchBendSensitivity() {
+			continue
+		}
+
+		changes := m[rpn.Channel]
+
+		// a data entry LSB that follows the MSB at the same tick refines the change
+		if n := len(changes); n > 0 && changes[n-1].AbsTicks == ev.AbsTicks {
+			changes[n-1].Semitones = rpn.Semitones()
+			continue
+		}
+
+		m[rpn.Channel] = append(changes, BendRange{AbsTicks: ev.AbsTicks, Semitones: rpn.Semitones()})
+	}
+
+	return m
+}
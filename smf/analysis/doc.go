@@ -0,0 +1,26 @@
+// Copyright (c) 2018 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package analysis provides functions that extract musical information from an SMF that has been read via smftrack.
+
+Example
+
+	s, err := smftrack.ReadFile("guitar.mid")
+
+	if err != nil {
+		panic(err)
+	}
+
+	notes := s.Notes()
+	freqs := analysis.NoteFrequencies(notes, analysis.PitchBends(s), analysis.BendRanges(s))
+
+	for i, n := range notes {
+		fmt.Printf("key %v at %v: %0.2f Hz\n", n.Key, n.AbsTicks, freqs[i])
+	}
+
+Since the tracks of SMF format 2 have independent timelines, the functions of this package only make
+sense for SMF format 0 and 1.
+*/
+package analysis
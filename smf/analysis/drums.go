@@ -0,0 +1,171 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/midimessage/sysex/roland"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// minDrumNotes is the minimal number of notes for detecting drums by the statistics of the notes
+const minDrumNotes = 8
+
+// DetectDrumChannels returns the channels of the given SMF that have notes and are likely to be drum channels,
+// in ascending order. It can be passed as smftrack.DrumDetector to the transformations that skip drum channels,
+// e.g. smftrack.DetectDrums(analysis.DetectDrumChannels).
+//
+// The heuristics are applied per channel in the following order, the first one that applies decides:
+//
+//  1. GS "use for rhythm part" (40 1x 15) and XG "part mode" (08 nn 07) system exclusive messages switch the part
+//     of a channel to drums or back to normal. The last of these messages for a channel decides. The parts are
+//     taken to receive on their default channels.
+//  2. A bank select MSB (controller 0) of 120 (GM2 rhythm) or 127 (XG drums, bank 128 in some editors) marks drums,
+//     one of 121 (GM2 melody) marks no drums. The last of these bank selects of a channel decides.
+//  3. A channel with pitch bend (to another value than the center) is no drum channel.
+//  4. Channel 9 (channel 10 in GM) is a drum channel.
+//  5. The notes of another channel are taken as drums, if there are at least 8 of them, at least 90 percent of
+//     them are within the General MIDI percussion map (keys 35-81) and their median duration is not longer than
+//     a sixteenth note (only checked for metric time formats).
+//
+// The statistics of the last step may also match a melodic part that is played staccato in the range of the
+// percussion map. Then the channels should be passed explicitly.
+func DetectDrumChannels(s *smftrack.SMF) []uint8 {
+	var decided [16]bool
+	var drums [16]bool
+	var bends [16]bool
+
+	decide := func(ch uint8, isDrums bool) {
+		decided[ch], drums[ch] = true, isDrums
+	}
+
+	// the part settings via system exclusive messages override the bank selects, even if they came before them
+	var banks [16]int
+
+	for i := range banks {
+		banks[i] = -1
+	}
+
+	for _, ev := range s.Merged() {
+		switch msg := ev.Message.(type) {
+		case sysex.SysEx:
+			if ch, isDrums, ok := drumPart(msg); ok {
+				decide(ch, isDrums)
+			}
+		case channel.ControlChange:
+			if msg.Controller() == 0 && (msg.Value() == 120 || msg.Value() == 121 || msg.Value() == 127) {
+				banks[msg.Channel()] = int(msg.Value())
+			}
+		case channel.Pitchbend:
+			if msg.Value() != 0 {
+				bends[msg.Channel()] = true
+			}
+		}
+	}
+
+	for ch := range banks {
+		if !decided[ch] && banks[ch] >= 0 {
+			decide(uint8(ch), banks[ch] != 121)
+		}
+	}
+
+	var notes [16][]smftrack.Note
+
+	for _, n := range s.Notes() {
+		notes[n.Channel] = append(notes[n.Channel], n)
+	}
+
+	var sixteenth uint64
+
+	if mt, is := s.TimeFormat().(smf.MetricTicks); is {
+		sixteenth = uint64(mt.Number()) / 4
+	}
+
+	var res []uint8
+
+	for ch := uint8(0); ch < 16; ch++ {
+		if len(notes[ch]) == 0 {
+			continue
+		}
+
+		switch {
+		case decided[ch]:
+		case bends[ch]:
+			drums[ch] = false
+		case ch == 9:
+			drums[ch] = true
+		default:
+			drums[ch] = drumStatistics(notes[ch], sixteenth)
+		}
+
+		if drums[ch] {
+			res = append(res, ch)
+		}
+	}
+
+	return res
+}
+
+// drumStatistics returns true, if the given notes of a channel look like drums (see DetectDrumChannels).
+// A sixteenth of 0 skips the check of the durations.
+func drumStatistics(notes []smftrack.Note, sixteenth uint64) bool {
+	if len(notes) < minDrumNotes {
+		return false
+	}
+
+	var inMap int
+	durations := make([]uint64, len(notes))
+
+	for i, n := range notes {
+		if n.Key >= 35 && n.Key <= 81 {
+			inMap++
+		}
+		durations[i] = n.Duration
+	}
+
+	if inMap*10 < len(notes)*9 {
+		return false
+	}
+
+	if sixteenth == 0 {
+		return true
+	}
+
+	sort.Slice(durations, func(a, b int) bool { return durations[a] < durations[b] })
+	return durations[len(durations)/2] <= sixteenth
+}
+
+// drumPart returns the channel and the drum setting of a GS "use for rhythm part" or XG "part mode" message.
+// ok is false for other messages.
+//
+//	41 <device> 42 12 40 1x 15 <mode> <checksum>  (GS, x: part block, mode 0: normal, 1-2: drum map)
+//	43 1<device> 4C 08 <part> 07 <mode>           (XG, mode 0: normal, 1-3: drum)
+func drumPart(msg sysex.SysEx) (ch uint8, isDrums, ok bool) {
+	if len(msg) == 7 && msg[0] == 0x43 && msg[1]&0xF0 == 0x10 && msg[2] == 0x4C && msg[3] == 0x08 && msg[5] == 0x07 {
+		return msg[4] & 0x0F, msg[6] != 0, true
+	}
+
+	m, err := roland.GS.Parse(msg)
+
+	if err != nil || m.Command != roland.DT1 || len(m.Data) != 1 {
+		return 0, false, false
+	}
+
+	if a := m.Address; a[0] != 0x40 || a[1]&0xF0 != 0x10 || a[2] != 0x15 {
+		return 0, false, false
+	}
+
+	// block 0 is part 10, blocks 1-9 are parts 1-9 and blocks A-F are parts 11-16
+	switch block := m.Address[1] & 0x0F; {
+	case block == 0:
+		ch = 9
+	case block <= 9:
+		ch = block - 1
+	default:
+		ch = block
+	}
+
+	return ch, m.Data[0] != 0, true
+}
@@ -0,0 +1,108 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/midimessage/sysex/roland"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// drumSMF returns a SMF with the given setup messages at tick 0, followed by 8 notes on each of the given channels
+// with the given key and duration
+func drumSMF(tf smf.TimeFormat, key uint8, duration uint64, channels []uint8, setup ...midi.Message) *smftrack.SMF {
+	var tr smftrack.Track
+	tr.Add(0, setup...)
+
+	for _, ch := range channels {
+		for i := uint64(0); i < 8; i++ {
+			tr.Add(i*240, channel.Channel(ch).NoteOn(key, 100))
+			tr.Add(i*240+duration, channel.Channel(ch).NoteOff(key))
+		}
+	}
+
+	s := smftrack.New(smf.SMF0, tf)
+	s.AddTrack(&tr)
+	return s
+}
+
+// gsRhythmPart returns a GS "use for rhythm part" message for the given part block and mode
+func gsRhythmPart(block, mode byte) sysex.SysEx {
+	return roland.GS.DT1(roland.Address{0x40, 0x10 | block, 0x15}, []byte{mode})
+}
+
+func TestDetectDrumChannels(t *testing.T) {
+	ticks := smf.MetricTicks(960)
+	melodic, short := uint64(960), uint64(120)
+
+	tests := []struct {
+		name     string
+		s        *smftrack.SMF
+		expected []uint8
+	}{
+		{"GM drum channel", drumSMF(ticks, 60, melodic, []uint8{0, 9}), []uint8{9}},
+		{"GS rhythm part 16", drumSMF(ticks, 60, melodic, []uint8{9, 15}, gsRhythmPart(0x0F, 1)), []uint8{9, 15}},
+		{"GS rhythm part 2", drumSMF(ticks, 60, melodic, []uint8{1}, gsRhythmPart(0x02, 2)), []uint8{1}},
+		{"GS part 10 to normal", drumSMF(ticks, 38, short, []uint8{9}, gsRhythmPart(0x00, 0)), nil},
+		{"GS wrong checksum", drumSMF(ticks, 60, melodic, []uint8{15}, sysex.SysEx{0x41, 0x10, 0x42, 0x12, 0x40, 0x1F, 0x15, 0x01, 0x00}), nil},
+		{"XG part mode", drumSMF(ticks, 60, melodic, []uint8{3}, sysex.SysEx{0x43, 0x10, 0x4C, 0x08, 0x03, 0x07, 0x01}), []uint8{3}},
+		{"XG part 10 to normal", drumSMF(ticks, 60, melodic, []uint8{9}, sysex.SysEx{0x43, 0x10, 0x4C, 0x08, 0x09, 0x07, 0x00}), nil},
+		{"XG drum bank", drumSMF(ticks, 60, melodic, []uint8{2}, channel.Channel2.ControlChange(0, 127)), []uint8{2}},
+		{"GM2 rhythm bank", drumSMF(ticks, 60, melodic, []uint8{4}, channel.Channel4.ControlChange(0, 120)), []uint8{4}},
+		{"GM2 melody bank", drumSMF(ticks, 38, short, []uint8{9}, channel.Channel9.ControlChange(0, 121)), nil},
+		{"other bank", drumSMF(ticks, 60, melodic, []uint8{2}, channel.Channel2.ControlChange(0, 1)), nil},
+		{"sysex before bank", drumSMF(ticks, 60, melodic, []uint8{2},
+			sysex.SysEx{0x43, 0x10, 0x4C, 0x08, 0x02, 0x07, 0x00}, channel.Channel2.ControlChange(0, 127)), nil},
+		{"bank before pitch bend", drumSMF(ticks, 60, melodic, []uint8{2},
+			channel.Channel2.ControlChange(0, 127), channel.Channel2.Pitchbend(100)), []uint8{2}},
+		{"pitch bend on channel 10", drumSMF(ticks, 38, short, []uint8{9}, channel.Channel9.Pitchbend(-200)), nil},
+		{"centered pitch bend", drumSMF(ticks, 38, short, []uint8{9}, channel.Channel9.Pitchbend(0)), []uint8{9}},
+		{"short notes in percussion map", drumSMF(ticks, 42, 240, []uint8{1, 5}), []uint8{1, 5}},
+		{"long notes in percussion map", drumSMF(ticks, 42, 241, []uint8{5}), nil},
+		{"short notes out of percussion map", drumSMF(ticks, 82, short, []uint8{5}), nil},
+		{"short notes with pitch bend", drumSMF(ticks, 42, short, []uint8{5}, channel.Channel5.Pitchbend(1)), nil},
+		{"SMPTE", drumSMF(smf.SMPTE25(40), 42, melodic, []uint8{5}), []uint8{5}},
+		{"setup without notes", drumSMF(ticks, 60, melodic, nil, gsRhythmPart(0x0F, 1)), nil},
+	}
+
+	for _, test := range tests {
+		if got := DetectDrumChannels(test.s); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: DetectDrumChannels() = %v; want %v", test.name, got, test.expected)
+		}
+	}
+
+	// too few notes
+	var tr smftrack.Track
+
+	for i := uint64(0); i < 7; i++ {
+		tr.Add(i*240, channel.Channel5.NoteOn(42, 100))
+		tr.Add(i*240+10, channel.Channel5.NoteOff(42))
+	}
+
+	s := smftrack.New(smf.SMF0, ticks)
+	s.AddTrack(&tr)
+
+	if got := DetectDrumChannels(s); got != nil {
+		t.Errorf("DetectDrumChannels() of 7 notes = %v; want none", got)
+	}
+}
+
+func TestDetectDrumChannelsTransforms(t *testing.T) {
+	s := drumSMF(smf.MetricTicks(960), 61, 960, []uint8{15}, gsRhythmPart(0x0F, 1))
+
+	res := smftrack.ForceToScale(s, 0, smftrack.ScaleMajor, smftrack.SnapNearest, smftrack.DetectDrums(DetectDrumChannels))
+
+	if got, want := res.Notes()[0].Key, uint8(61); got != want {
+		t.Errorf("ForceToScale() with detected drums: key = %v; want %v", got, want)
+	}
+
+	res = smftrack.ForceToScale(s, 0, smftrack.ScaleMajor, smftrack.SnapNearest)
+
+	if got, want := res.Notes()[0].Key, uint8(62); got != want {
+		t.Errorf("ForceToScale() with default drum channel: key = %v; want %v", got, want)
+	}
+}
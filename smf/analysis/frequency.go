@@ -0,0 +1,127 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// TuningChange is a MIDI tuning standard (MTS) single note tuning change of a key
+type TuningChange struct {
+	AbsTicks uint64
+	Key      uint8
+
+	// Pitch is the new pitch of the key in semitones, where 69 is A4 (440 Hz)
+	Pitch float64
+}
+
+// Tuning is a list of MTS single note tuning changes, sorted by their absolute ticks
+type Tuning []TuningChange
+
+// PitchAt returns the pitch of the given key at the given tick in semitones, where 69 is A4 (440 Hz).
+// Keys that have not been retuned until then, have the pitch of their key number.
+func (t Tuning) PitchAt(key uint8, absTicks uint64) float64 {
+	i := sort.Search(len(t), func(i int) bool {
+		return t[i].AbsTicks > absTicks
+	})
+
+	for i--; i >= 0; i-- {
+		if t[i].Key == key {
+			return t[i].Pitch
+		}
+	}
+
+	return float64(key)
+}
+
+// MTSTuning returns the MTS single note tuning changes (realtime, with or without bank) of the given SMF.
+// The device id and the tuning program are not taken into account.
+func MTSTuning(s *smftrack.SMF) Tuning {
+	var t Tuning
+
+	for _, ev := range s.Merged() {
+		sx, is := ev.Message.(sysex.SysEx)
+		if !is {
+			continue
+		}
+		t = append(t, parseMTSNoteChange(ev.AbsTicks, sx.Data())...)
+	}
+
+	return t
+}
+
+// parseMTSNoteChange parses the data of a realtime single note tuning change
+//
+//	7F <device> 08 02 <program> <n> [<key> <xx> <yy> <zz>]...
+//	7F <device> 08 07 <bank> <program> <n> [<key> <xx> <yy> <zz>]...
+func parseMTSNoteChange(absTicks uint64, data []byte) (changes []TuningChange) {
+	if len(data) < 4 || data[0] != 0x7F || data[2] != 0x08 {
+		return nil
+	}
+
+	// the number of bytes before the number of changes
+	var header int
+
+	switch data[3] {
+	case 0x02:
+		header = 5
+	case 0x07:
+		header = 6
+	default:
+		return nil
+	}
+
+	if len(data) <= header {
+		return nil
+	}
+
+	rest := data[header:]
+
+	n := int(rest[0])
+	rest = rest[1:]
+
+	for i := 0; i < n && len(rest) >= 4; i++ {
+		key, xx, yy, zz := rest[0], rest[1], rest[2], rest[3]
+		rest = rest[4:]
+
+		// 7F 7F 7F means: no change
+		if xx == 0x7F && yy == 0x7F && zz == 0x7F {
+			continue
+		}
+
+		fraction := float64(uint16(yy&0x7F)<<7|uint16(zz&0x7F)) / 16384
+		changes = append(changes, TuningChange{AbsTicks: absTicks, Key: key & 0x7F, Pitch: float64(xx&0x7F) + fraction})
+	}
+
+	return changes
+}
+
+// Frequency returns the frequency in Hz of the given pitch in semitones, where 69 is A4 (440 Hz).
+func Frequency(pitch float64) float64 {
+	return 440 * math.Pow(2, (pitch-69)/12)
+}
+
+// NoteFrequencies returns the frequencies in Hz of the given notes at their start, taking the pitch bend
+// of their channel and the pitch bend range into account. If a tuning is given, the pitch of the keys is
+// taken from it (only the first tuning is used).
+func NoteFrequencies(notes []smftrack.Note, bends map[uint8]PitchBendCurve, ranges BendRangeMap, tuning ...Tuning) []float64 {
+	freqs := make([]float64, len(notes))
+
+	for i, n := range notes {
+		pitch := float64(n.Key)
+
+		if len(tuning) > 0 {
+			pitch = tuning[0].PitchAt(n.Key, n.AbsTicks)
+		}
+
+		if c, has := bends[n.Channel]; has {
+			pitch += c.SemitonesAt(n.AbsTicks, ranges)
+		}
+
+		freqs[i] = Frequency(pitch)
+	}
+
+	return freqs
+}
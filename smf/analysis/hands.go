@@ -0,0 +1,267 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// the weights of the costs of SplitHands
+const (
+	// handSideCost is the cost per semitone of a note on the wrong side of the baseline split key
+	handSideCost = 1.0
+
+	// handMoveCost is the cost per semitone of the move of a hand between successive chords
+	handMoveCost = 0.5
+
+	// handCrossCost is the cost of a hand crossing the other hand between successive chords
+	handCrossCost = 24.0
+
+	// handSpanCost is the cost per semitone of a chord of a hand that is wider than the maximal span
+	handSpanCost = 4.0
+)
+
+type handConfig struct {
+	split   uint8
+	fixed   bool
+	maxSpan uint8
+}
+
+// HandOption is an option for SplitHands
+type HandOption func(*handConfig)
+
+// SplitPoint splits the hands at the given key: the notes below it are played by the left hand, the other ones by
+// the right hand. Without it, SplitHands assigns the notes automatically.
+func SplitPoint(key uint8) HandOption {
+	return func(c *handConfig) {
+		c.split, c.fixed = key, true
+	}
+}
+
+// SplitBaseline sets the key around which SplitHands assigns the notes automatically. Default is 60 (middle C).
+func SplitBaseline(key uint8) HandOption {
+	return func(c *handConfig) {
+		c.split, c.fixed = key, false
+	}
+}
+
+// MaxHandSpan sets the widest interval in semitones that a hand plays at once. Default is 14 (a ninth).
+func MaxHandSpan(semitones uint8) HandOption {
+	return func(c *handConfig) {
+		c.maxSpan = semitones
+	}
+}
+
+// HandSplitter returns a smftrack.HandSplitter that splits the hands with SplitHands and the given options, e.g.
+//
+//	res, err := smftrack.ApplyHandSplit(s, 1, analysis.HandSplitter(analysis.MaxHandSpan(12)))
+func HandSplitter(options ...HandOption) smftrack.HandSplitter {
+	return func(notes []smftrack.Note) (left, right []smftrack.Note) {
+		return SplitHands(notes, options...)
+	}
+}
+
+// SplitHands splits the given notes of a piano part into the notes of the left and the right hand, keeping their
+// order. With SplitPoint the notes are split at a fixed key. Otherwise the notes that start at the same tick are
+// taken as a chord, and each chord is split between the hands, so that the total of the following costs is minimal
+// (by dynamic programming):
+//
+//   - the distance of the notes on the wrong side of a baseline key (see SplitBaseline)
+//   - the moves of the hands between successive chords
+//   - a hand crossing the other one between successive chords
+//   - a hand playing a chord that is wider than the maximal span (see MaxHandSpan)
+//
+// So the notes around the baseline go to the hand that is nearer. The result is deterministic, but just a
+// heuristic; the notes of a chord are never split so that the hands cross within the chord.
+func SplitHands(notes []smftrack.Note, options ...HandOption) (left, right []smftrack.Note) {
+	c := handConfig{split: 60, maxSpan: 14}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	var isLeft = make([]bool, len(notes))
+
+	if c.fixed {
+		for i, n := range notes {
+			isLeft[i] = n.Key < c.split
+		}
+	} else {
+		c.assign(notes, isLeft)
+	}
+
+	for i, n := range notes {
+		if isLeft[i] {
+			left = append(left, n)
+		} else {
+			right = append(right, n)
+		}
+	}
+
+	return
+}
+
+// handChord is a chord of notes that start at the same tick, given as the indices of the notes sorted by their key
+type handChord []int
+
+// handPos is the position of a hand: the lowest and highest key of the last chord that it played
+type handPos struct {
+	low, high float64
+	ok        bool
+}
+
+func (p handPos) center() float64 {
+	return (p.low + p.high) / 2
+}
+
+// handState is a state of the dynamic programming: the minimal cost up to a chord for a split of the chord,
+// the split of the chord before on that path and the positions of the hands after the chord
+type handState struct {
+	cost        float64
+	from        int
+	left, right handPos
+}
+
+// assign sets isLeft for the notes that are played by the left hand. The state of the dynamic programming is the
+// number of the lowest notes of a chord that are played by the left hand. The positions of the hands are taken
+// from the best path to a state, so that a hand that pauses keeps its position.
+func (c handConfig) assign(notes []smftrack.Note, isLeft []bool) {
+	order := make([]int, len(notes))
+
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		na, nb := notes[order[a]], notes[order[b]]
+
+		if na.AbsTicks != nb.AbsTicks {
+			return na.AbsTicks < nb.AbsTicks
+		}
+
+		return na.Key < nb.Key
+	})
+
+	var chords []handChord
+
+	for i, idx := range order {
+		if i == 0 || notes[idx].AbsTicks != notes[order[i-1]].AbsTicks {
+			chords = append(chords, nil)
+		}
+
+		chords[len(chords)-1] = append(chords[len(chords)-1], idx)
+	}
+
+	if len(chords) == 0 {
+		return
+	}
+
+	states := make([][]handState, len(chords))
+	prev := []handState{{}}
+
+	for i, ch := range chords {
+		states[i] = make([]handState, len(ch)+1)
+
+		for k := range states[i] {
+			own := c.chordCost(notes, ch, k)
+			left, right := handPosition(notes, ch[:k]), handPosition(notes, ch[k:])
+			best := handState{cost: math.Inf(1)}
+
+			for j, p := range prev {
+				if total := p.cost + handTransitionCost(p, left, right) + own; total < best.cost {
+					best = handState{cost: total, from: j, left: p.left, right: p.right}
+				}
+			}
+
+			if left.ok {
+				best.left = left
+			}
+
+			if right.ok {
+				best.right = right
+			}
+
+			states[i][k] = best
+		}
+
+		prev = states[i]
+	}
+
+	last := len(chords) - 1
+	k := 0
+
+	for j, st := range states[last] {
+		if st.cost < states[last][k].cost {
+			k = j
+		}
+	}
+
+	for i := last; i >= 0; i-- {
+		for _, idx := range chords[i][:k] {
+			isLeft[idx] = true
+		}
+
+		k = states[i][k].from
+	}
+}
+
+// handPosition returns the position of a hand that plays the given notes of a chord
+func handPosition(notes []smftrack.Note, hand []int) handPos {
+	if len(hand) == 0 {
+		return handPos{}
+	}
+
+	return handPos{low: float64(notes[hand[0]].Key), high: float64(notes[hand[len(hand)-1]].Key), ok: true}
+}
+
+// chordCost returns the cost of the sides and spans of the given chord, if the k lowest notes are played by the
+// left hand
+func (c handConfig) chordCost(notes []smftrack.Note, ch handChord, k int) float64 {
+	var cost float64
+	split := int(c.split)
+
+	for i, idx := range ch {
+		key := int(notes[idx].Key)
+
+		switch {
+		case i < k && key >= split:
+			cost += handSideCost * float64(key-split+1)
+		case i >= k && key < split:
+			cost += handSideCost * float64(split-key)
+		}
+	}
+
+	for _, hand := range []handPos{handPosition(notes, ch[:k]), handPosition(notes, ch[k:])} {
+		if span := hand.high - hand.low; hand.ok && span > float64(c.maxSpan) {
+			cost += handSpanCost * (span - float64(c.maxSpan))
+		}
+	}
+
+	return cost
+}
+
+// handTransitionCost returns the cost of the moves and crossings of the hands from their positions in the given
+// state to the given positions of the hands that play the next chord
+func handTransitionCost(from handState, left, right handPos) float64 {
+	var cost float64
+
+	if left.ok && from.left.ok {
+		cost += handMoveCost * math.Abs(left.center()-from.left.center())
+	}
+
+	if right.ok && from.right.ok {
+		cost += handMoveCost * math.Abs(right.center()-from.right.center())
+	}
+
+	// the left hand above the right hand before or the right hand below the left hand before
+	if left.ok && from.right.ok && left.high > from.right.low {
+		cost += handCrossCost
+	}
+
+	if right.ok && from.left.ok && right.low < from.left.high {
+		cost += handCrossCost
+	}
+
+	return cost
+}
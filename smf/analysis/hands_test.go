@@ -0,0 +1,126 @@
+package analysis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// twoHands returns a piano track played by two hands in a single track: the melody of the right hand dips below
+// middle C and the left hand plays above middle C under a wide chord of the right hand
+func twoHands() *smftrack.SMF {
+	var tr smftrack.Track
+	ch := channel.Channel0
+	tr.Add(0, ch.ControlChange(64, 127))
+
+	chords := []struct {
+		tick uint64
+		keys []uint8
+	}{
+		{0, []uint8{48, 64}},
+		{480, []uint8{52, 62}},
+		{960, []uint8{55, 60}},
+		{1440, []uint8{59}},
+		{1920, []uint8{50, 72}},
+		{2400, []uint8{62, 72, 79}},
+	}
+
+	for _, c := range chords {
+		for _, key := range c.keys {
+			tr.Add(c.tick, ch.NoteOn(key, 100))
+			tr.Add(c.tick+480, ch.NoteOff(key))
+		}
+	}
+
+	tr.Add(2880, ch.ControlChange(64, 0))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+	return s
+}
+
+// keys returns the ticks and keys of the given notes
+func keys(notes []smftrack.Note) string {
+	var res string
+
+	for _, n := range notes {
+		res += fmt.Sprintf("%v:%v ", n.AbsTicks, n.Key)
+	}
+
+	return res
+}
+
+func TestSplitHands(t *testing.T) {
+	tests := []struct {
+		options []HandOption
+		left    string
+		right   string
+	}{
+		{
+			nil,
+			"0:48 480:52 960:55 1920:50 2400:62 ",
+			"0:64 480:62 960:60 1440:59 1920:72 2400:72 2400:79 ",
+		},
+		{
+			[]HandOption{SplitPoint(60)},
+			"0:48 480:52 960:55 1440:59 1920:50 ",
+			"0:64 480:62 960:60 1920:72 2400:62 2400:72 2400:79 ",
+		},
+		{
+			// the wide chord fits into the right hand
+			[]HandOption{MaxHandSpan(17)},
+			"0:48 480:52 960:55 1920:50 ",
+			"0:64 480:62 960:60 1440:59 1920:72 2400:62 2400:72 2400:79 ",
+		},
+		{
+			// everything is below the baseline: the right hand only plays, what the left hand can't span
+			[]HandOption{SplitBaseline(90)},
+			"0:48 0:64 480:52 480:62 960:55 960:60 1440:59 1920:50 2400:62 2400:72 ",
+			"1920:72 2400:79 ",
+		},
+	}
+
+	notes := twoHands().Track(0).Notes()
+
+	for i, test := range tests {
+		left, right := SplitHands(notes, test.options...)
+
+		if got, want := keys(left), test.left; got != want {
+			t.Errorf("[%v] left hand:\ngot:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+
+		if got, want := keys(right), test.right; got != want {
+			t.Errorf("[%v] right hand:\ngot:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+
+		// deterministic
+		left2, right2 := SplitHands(notes, test.options...)
+
+		if keys(left) != keys(left2) || keys(right) != keys(right2) {
+			t.Errorf("[%v] SplitHands() is not deterministic", i)
+		}
+	}
+
+	if left, right := SplitHands(nil); len(left) != 0 || len(right) != 0 {
+		t.Errorf("SplitHands(nil) = %v, %v; want no notes", left, right)
+	}
+}
+
+func TestApplyHandSplit(t *testing.T) {
+	res, err := smftrack.ApplyHandSplit(twoHands(), 0, HandSplitter())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if res.NumTracks() != 2 || res.Track(0).Name() != "RH" || res.Track(1).Name() != "LH" {
+		t.Fatalf("ApplyHandSplit() returned %v tracks %q and %q; want RH and LH", res.NumTracks(), res.Track(0).Name(), res.Track(1).Name())
+	}
+
+	if got, want := keys(res.Track(1).Notes()), "0:48 480:52 960:55 1920:50 2400:62 "; got != want {
+		t.Errorf("LH:\ngot:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
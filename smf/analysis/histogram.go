@@ -0,0 +1,319 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// barWidth is the width of the longest bar of the charts in characters
+const barWidth = 40
+
+// bar returns a bar of the chart for the given value with a leading space,
+// scaled so that max has barWidth characters. If the bar is empty, the empty string is returned.
+func bar(value, max float64) string {
+	if max <= 0 {
+		return ""
+	}
+
+	n := int(value/max*barWidth + 0.5)
+
+	if n == 0 {
+		return ""
+	}
+
+	return " " + strings.Repeat("█", n)
+}
+
+// filterChannels returns the notes of the given channels. If no channels are given, all notes are returned.
+func filterChannels(notes []smftrack.Note, channels []uint8) []smftrack.Note {
+	if len(channels) == 0 {
+		return notes
+	}
+
+	var use [16]bool
+
+	for _, ch := range channels {
+		if ch < 16 {
+			use[ch] = true
+		}
+	}
+
+	var res []smftrack.Note
+
+	for _, n := range notes {
+		if n.Channel < 16 && use[n.Channel] {
+			res = append(res, n)
+		}
+	}
+
+	return res
+}
+
+// PitchClasses are the shares of the pitch classes (C, C#, ..., B) of notes, which sum up to 1
+type PitchClasses [12]float64
+
+// PitchHistogram returns the shares of the pitch classes of the given notes, weighted by their durations.
+// If channels are given, only the notes of these channels are taken into account.
+// If the notes have no duration at all, all shares are 0.
+func PitchHistogram(notes []smftrack.Note, channels ...uint8) PitchClasses {
+	var h PitchClasses
+	var total float64
+
+	for _, n := range filterChannels(notes, channels) {
+		h[n.Key%12] += float64(n.Duration)
+		total += float64(n.Duration)
+	}
+
+	if total == 0 {
+		return h
+	}
+
+	for i := range h {
+		h[i] /= total
+	}
+
+	return h
+}
+
+// String returns a bar chart of the pitch classes with one line per pitch class
+func (h PitchClasses) String() string {
+	var max float64
+
+	for _, v := range h {
+		if v > max {
+			max = v
+		}
+	}
+
+	var bd strings.Builder
+
+	for pc, v := range h {
+		fmt.Fprintf(&bd, "%-2s %5.1f%%%s\n", strings.TrimSuffix(channel.NoteName(uint8(pc)), "-1"), v*100, bar(v, max))
+	}
+
+	return bd.String()
+}
+
+// KeyCounts are the number of notes by key
+type KeyCounts [128]uint32
+
+// KeyUsage returns the number of notes of each key within the given notes.
+// If channels are given, only the notes of these channels are counted.
+func KeyUsage(notes []smftrack.Note, channels ...uint8) KeyCounts {
+	var k KeyCounts
+
+	for _, n := range filterChannels(notes, channels) {
+		if n.Key < 128 {
+			k[n.Key]++
+		}
+	}
+
+	return k
+}
+
+// String returns a bar chart of the keys from the highest to the lowest used key with one line per key.
+// If no key is used, the empty string is returned.
+func (k KeyCounts) String() string {
+	lowest, highest := -1, -1
+	var max uint32
+
+	for key, count := range k {
+		if count == 0 {
+			continue
+		}
+
+		if lowest < 0 {
+			lowest = key
+		}
+
+		highest = key
+
+		if count > max {
+			max = count
+		}
+	}
+
+	if lowest < 0 {
+		return ""
+	}
+
+	var bd strings.Builder
+
+	for key := highest; key >= lowest; key-- {
+		fmt.Fprintf(&bd, "%-4s %5d%s\n", channel.NoteName(uint8(key)), k[key], bar(float64(k[key]), float64(max)))
+	}
+
+	return bd.String()
+}
+
+// VelocityCounts are the number of notes by (release) velocity
+type VelocityCounts [128]uint32
+
+// VelocityUsage returns the number of notes of each velocity within the given notes.
+// If channels are given, only the notes of these channels are counted.
+func VelocityUsage(notes []smftrack.Note, channels ...uint8) VelocityCounts {
+	var v VelocityCounts
+
+	for _, n := range filterChannels(notes, channels) {
+		if n.Velocity < 128 {
+			v[n.Velocity]++
+		}
+	}
+
+	return v
+}
+
+// ReleaseVelocityUsage returns the number of notes of each release velocity within the given notes
+// (see smftrack.Note.ReleaseVelocity). Notes with an unknown release velocity of 0 are not counted.
+// If channels are given, only the notes of these channels are counted.
+func ReleaseVelocityUsage(notes []smftrack.Note, channels ...uint8) VelocityCounts {
+	var v VelocityCounts
+
+	for _, n := range filterChannels(notes, channels) {
+		if n.ReleaseVelocity > 0 && n.ReleaseVelocity < 128 {
+			v[n.ReleaseVelocity]++
+		}
+	}
+
+	return v
+}
+
+// String returns a bar chart of the velocities from the lowest to the highest used velocity with one line
+// per velocity. If no velocity is used, the empty string is returned.
+func (v VelocityCounts) String() string {
+	lowest, highest := -1, -1
+	var max uint32
+
+	for vel, count := range v {
+		if count == 0 {
+			continue
+		}
+
+		if lowest < 0 {
+			lowest = vel
+		}
+
+		highest = vel
+
+		if count > max {
+			max = count
+		}
+	}
+
+	if lowest < 0 {
+		return ""
+	}
+
+	var bd strings.Builder
+
+	for vel := lowest; vel <= highest; vel++ {
+		fmt.Fprintf(&bd, "%3d %5d%s\n", vel, v[vel], bar(float64(v[vel]), float64(max)))
+	}
+
+	return bd.String()
+}
+
+// BarDensity is the number of notes that start within a bar
+type BarDensity struct {
+	// Bar is the number of the bar, starting with 0
+	Bar uint64
+
+	// AbsTicks is the start of the bar
+	AbsTicks uint64
+
+	// Notes is the number of notes that start within the bar
+	Notes int
+
+	// Beats are the number of notes that start within each beat of the bar.
+	// A beat is a note value of the denominator of the time signature.
+	Beats []int
+}
+
+// Densities are the note densities of consecutive bars
+type Densities []BarDensity
+
+// Density returns the number of notes that start within each bar and each beat, from the first bar until the
+// last bar in which a note starts. Bars without notes in between are included.
+// If channels are given, only the notes of these channels are counted.
+func Density(notes []smftrack.Note, m *smftrack.MeterMap, channels ...uint8) Densities {
+	var d Densities
+
+	for _, n := range filterChannels(notes, channels) {
+		no, _ := m.Bar(n.AbsTicks)
+
+		for uint64(len(d)) <= no {
+			d = append(d, newBarDensity(m, uint64(len(d))))
+		}
+
+		b := &d[no]
+		b.Notes++
+
+		if len(b.Beats) == 0 {
+			continue
+		}
+
+		length := m.BarStart(no+1) - b.AbsTicks
+		beat := int((n.AbsTicks - b.AbsTicks) * uint64(len(b.Beats)) / length)
+
+		if beat >= len(b.Beats) {
+			beat = len(b.Beats) - 1
+		}
+
+		b.Beats[beat]++
+	}
+
+	return d
+}
+
+// newBarDensity returns an empty BarDensity of the given bar
+func newBarDensity(m *smftrack.MeterMap, bar uint64) BarDensity {
+	start := m.BarStart(bar)
+
+	return BarDensity{
+		Bar:      bar,
+		AbsTicks: start,
+		Beats:    make([]int, m.MeterAt(start).Numerator),
+	}
+}
+
+// String returns a heatmap of the bars with one line per bar: the number of the bar (starting with 1),
+// the notes of each beat as digit (+ for more than 9) and a bar for the notes of the bar.
+func (d Densities) String() string {
+	var max, width int
+
+	for _, b := range d {
+		if b.Notes > max {
+			max = b.Notes
+		}
+
+		if len(b.Beats) > width {
+			width = len(b.Beats)
+		}
+	}
+
+	var bd strings.Builder
+
+	for _, b := range d {
+		beats := make([]byte, width)
+
+		for i := range beats {
+			switch {
+			case i >= len(b.Beats):
+				beats[i] = ' '
+			case b.Beats[i] == 0:
+				beats[i] = '.'
+			case b.Beats[i] > 9:
+				beats[i] = '+'
+			default:
+				beats[i] = byte('0' + b.Beats[i])
+			}
+		}
+
+		fmt.Fprintf(&bd, "%4d |%s| %4d%s\n", b.Bar+1, beats, b.Notes, bar(float64(b.Notes), float64(max)))
+	}
+
+	return bd.String()
+}
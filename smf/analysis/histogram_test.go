@@ -0,0 +1,160 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// histogramNotes are a C major chord on channel 0 (C twice as long as E and G) and a D on channel 1
+var histogramNotes = []smftrack.Note{
+	{Channel: 0, Key: 60, AbsTicks: 0, Duration: 960},
+	{Channel: 0, Key: 64, AbsTicks: 0, Duration: 480},
+	{Channel: 0, Key: 67, AbsTicks: 0, Duration: 480},
+	{Channel: 1, Key: 62, AbsTicks: 480, Duration: 480},
+	{Channel: 0, Key: 72, AbsTicks: 960, Duration: 0},
+}
+
+func TestPitchHistogram(t *testing.T) {
+	tests := []struct {
+		channels []uint8
+		expected PitchClasses
+	}{
+		{nil, PitchClasses{0: 0.4, 2: 0.2, 4: 0.2, 7: 0.2}},
+		{[]uint8{0}, PitchClasses{0: 0.5, 4: 0.25, 7: 0.25}},
+		{[]uint8{1}, PitchClasses{2: 1}},
+		{[]uint8{2}, PitchClasses{}},
+	}
+
+	for i, test := range tests {
+		if got := PitchHistogram(histogramNotes, test.channels...); got != test.expected {
+			t.Errorf("[%v] PitchHistogram() = %v; want %v", i, got, test.expected)
+		}
+	}
+
+	lines := strings.Split(PitchHistogram(histogramNotes).String(), "\n")
+
+	if got, want := lines[0], "C   40.0% "+strings.Repeat("█", barWidth); got != want {
+		t.Errorf("String() line 0 = %q; want %q", got, want)
+	}
+
+	if got, want := lines[1], "C#   0.0%"; got != want {
+		t.Errorf("String() line 1 = %q; want %q", got, want)
+	}
+
+	if got, want := lines[2], "D   20.0% "+strings.Repeat("█", barWidth/2); got != want {
+		t.Errorf("String() line 2 = %q; want %q", got, want)
+	}
+}
+
+func TestKeyUsage(t *testing.T) {
+	notes := append(histogramNotes, smftrack.Note{Channel: 1, Key: 60})
+
+	k := KeyUsage(notes)
+
+	for key, want := range map[int]uint32{60: 2, 62: 1, 64: 1, 67: 1, 72: 1, 61: 0} {
+		if got := k[key]; got != want {
+			t.Errorf("KeyUsage()[%v] = %v; want %v", key, got, want)
+		}
+	}
+
+	if got, want := KeyUsage(notes, 1)[60], uint32(1); got != want {
+		t.Errorf("KeyUsage(1)[60] = %v; want %v", got, want)
+	}
+
+	expected := `D4       1 ████████████████████████████████████████
+C#4      0
+C4       1 ████████████████████████████████████████
+`
+
+	if got := KeyUsage(notes, 1).String(); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	if got := (KeyCounts{}).String(); got != "" {
+		t.Errorf("String() of no keys = %q; want \"\"", got)
+	}
+}
+
+func TestVelocityUsage(t *testing.T) {
+	notes := []smftrack.Note{
+		{Channel: 0, Key: 60, Velocity: 100, ReleaseVelocity: 20},
+		{Channel: 0, Key: 62, Velocity: 100, ReleaseVelocity: 22},
+		{Channel: 0, Key: 64, Velocity: 90, ReleaseVelocity: 20},
+		{Channel: 1, Key: 60, Velocity: 90},
+	}
+
+	if got, want := VelocityUsage(notes)[90], uint32(2); got != want {
+		t.Errorf("VelocityUsage()[90] = %v; want %v", got, want)
+	}
+
+	if got, want := VelocityUsage(notes, 1)[100], uint32(0); got != want {
+		t.Errorf("VelocityUsage(1)[100] = %v; want %v", got, want)
+	}
+
+	if got, want := ReleaseVelocityUsage(notes)[0], uint32(0); got != want {
+		t.Errorf("ReleaseVelocityUsage()[0] = %v; want %v", got, want)
+	}
+
+	expected := ` 20     2 ████████████████████████████████████████
+ 21     0
+ 22     1 ████████████████████
+`
+
+	if got := ReleaseVelocityUsage(notes).String(); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	if got := ReleaseVelocityUsage(notes, 1).String(); got != "" {
+		t.Errorf("String() of no release velocities = %q; want \"\"", got)
+	}
+}
+
+func TestDensity(t *testing.T) {
+	// bar 0 is 4/4, from bar 1 on 3/4 (480 ticks per quarter note)
+	var tr smftrack.Track
+	tr.Add(1920, meta.TimeSig{Numerator: 3, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8})
+	m := smftrack.NewMeterMap(smf.MetricTicks(480), &tr)
+
+	notes := []smftrack.Note{
+		{Channel: 0, Key: 60, AbsTicks: 0},
+		{Channel: 0, Key: 64, AbsTicks: 0},
+		{Channel: 0, Key: 67, AbsTicks: 479},
+		{Channel: 0, Key: 72, AbsTicks: 1440},
+		// bar 1 is empty, bar 2 starts at 1920+1440
+		{Channel: 1, Key: 48, AbsTicks: 3360 + 960},
+	}
+
+	expected := Densities{
+		{Bar: 0, AbsTicks: 0, Notes: 4, Beats: []int{3, 0, 0, 1}},
+		{Bar: 1, AbsTicks: 1920, Notes: 0, Beats: []int{0, 0, 0}},
+		{Bar: 2, AbsTicks: 3360, Notes: 1, Beats: []int{0, 0, 1}},
+	}
+
+	d := Density(notes, m)
+
+	if !reflect.DeepEqual(d, expected) {
+		t.Errorf("Density() = %v; want %v", d, expected)
+	}
+
+	if got := Density(notes, m, 0); len(got) != 1 || got[0].Notes != 4 {
+		t.Errorf("Density(0) = %v; want only bar 0 with 4 notes", got)
+	}
+
+	if got := Density(nil, m); len(got) != 0 {
+		t.Errorf("Density(nil) = %v; want none", got)
+	}
+
+	expectedString := `   1 |3..1|    4 ████████████████████████████████████████
+   2 |... |    0
+   3 |..1 |    1 ██████████
+`
+
+	if got := d.String(); got != expectedString {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expectedString)
+	}
+}
@@ -0,0 +1,199 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// intervalNames are the names of the intervals up to two octaves by their number of semitones
+var intervalNames = [25]string{
+	"unison", "minor second", "major second", "minor third", "major third", "perfect fourth", "tritone",
+	"perfect fifth", "minor sixth", "major sixth", "minor seventh", "major seventh", "octave",
+	"minor ninth", "major ninth", "minor tenth", "major tenth", "perfect eleventh", "compound tritone",
+	"perfect twelfth", "minor thirteenth", "major thirteenth", "minor fourteenth", "major fourteenth", "double octave",
+}
+
+// IntervalName returns the name of the interval of the given number of semitones (the sign is ignored),
+// e.g. "perfect fifth" for 7 and "major tenth" for 16. Intervals above two octaves are named by the
+// interval within the second octave plus the remaining octaves, e.g. "major tenth + 1 octave" for 28.
+// Since only the semitones are known, the augmented fourth and the diminished fifth are both named "tritone".
+func IntervalName(semitones int) string {
+	if semitones < 0 {
+		semitones = -semitones
+	}
+
+	if semitones < len(intervalNames) {
+		return intervalNames[semitones]
+	}
+
+	octaves := (semitones - 13) / 12
+	return fmt.Sprintf("%s + %v octave%s", intervalNames[semitones-octaves*12], octaves, plural(octaves))
+}
+
+// plural returns "s", if n is not 1
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// reduceInterval reduces a compound interval of the given number of semitones (not negative) to a simple one.
+// Octaves are reduced to the octave, not to the unison.
+func reduceInterval(semitones int) int {
+	if semitones <= 12 {
+		return semitones
+	}
+
+	if semitones%12 == 0 {
+		return 12
+	}
+
+	return semitones % 12
+}
+
+// IntervalEvent is the harmonic interval between two voices at an onset of one of them
+type IntervalEvent struct {
+	AbsTicks uint64
+
+	// A and B are the sounding notes of the voices
+	A, B smftrack.Note
+
+	// Semitones is the distance from the key of A to the key of B. It is negative, if B is below A
+	// (crossed voices).
+	Semitones int
+
+	// Name is the name of the interval (see IntervalName and IntervalReduceCompound)
+	Name string
+
+	// ParallelFifths is true, if this and the previous interval are (compound) perfect fifths and both voices
+	// moved in the same direction
+	ParallelFifths bool
+
+	// ParallelOctaves is true, if this and the previous interval are unisons or (compound) octaves and both voices
+	// moved in the same direction
+	ParallelOctaves bool
+}
+
+type intervalConfig struct {
+	tolerance uint64
+	reduce    bool
+}
+
+// IntervalOption is an option for Intervals
+type IntervalOption func(*intervalConfig)
+
+// IntervalTolerance sets the distance in ticks within which onsets are taken as simultaneous. Default is 0.
+func IntervalTolerance(ticks uint64) IntervalOption {
+	return func(c *intervalConfig) {
+		c.tolerance = ticks
+	}
+}
+
+// IntervalReduceCompound names compound intervals by their simple interval, e.g. "major third" for a
+// major tenth. Octaves are named "octave".
+func IntervalReduceCompound() IntervalOption {
+	return func(c *intervalConfig) {
+		c.reduce = true
+	}
+}
+
+// Intervals returns the harmonic intervals between the voices of the given notes (e.g. two tracks or channels
+// that have been filtered from smftrack.SMF.Notes) at each onset of one of the voices where both voices sound.
+// Onsets within the tolerance (see IntervalTolerance) are taken as a single onset at the earliest of them.
+//
+// A voice is expected to be monophonic. If several of its notes sound at an onset, the one that started last
+// is taken, the highest one, if they started together. Parallel fifths and octaves are flagged between
+// consecutive events.
+func Intervals(a, b []smftrack.Note, options ...IntervalOption) []IntervalEvent {
+	var c intervalConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	var onsets []uint64
+
+	for _, n := range a {
+		onsets = append(onsets, n.AbsTicks)
+	}
+
+	for _, n := range b {
+		onsets = append(onsets, n.AbsTicks)
+	}
+
+	sort.Slice(onsets, func(i, j int) bool { return onsets[i] < onsets[j] })
+
+	var res []IntervalEvent
+
+	for i := 0; i < len(onsets); {
+		at := onsets[i]
+
+		for i < len(onsets) && onsets[i] <= at+c.tolerance {
+			i++
+		}
+
+		na, okA := soundingNote(a, at, c.tolerance)
+		nb, okB := soundingNote(b, at, c.tolerance)
+
+		if !okA || !okB {
+			continue
+		}
+
+		ev := IntervalEvent{AbsTicks: at, A: na, B: nb, Semitones: int(nb.Key) - int(na.Key)}
+		size := ev.Semitones
+
+		if size < 0 {
+			size = -size
+		}
+
+		if c.reduce {
+			ev.Name = IntervalName(reduceInterval(size))
+		} else {
+			ev.Name = IntervalName(size)
+		}
+
+		if len(res) > 0 {
+			prev := res[len(res)-1]
+			prevSize := prev.Semitones
+
+			if prevSize < 0 {
+				prevSize = -prevSize
+			}
+
+			if similarMotion(prev, ev) {
+				ev.ParallelFifths = size%12 == 7 && prevSize%12 == 7
+				ev.ParallelOctaves = size%12 == 0 && prevSize%12 == 0
+			}
+		}
+
+		res = append(res, ev)
+	}
+
+	return res
+}
+
+// soundingNote returns the note that sounds at the given tick: the note that started last (within the tolerance
+// after the tick) and has not ended, the highest one of the notes that started together
+func soundingNote(notes []smftrack.Note, absTicks, tolerance uint64) (res smftrack.Note, found bool) {
+	for _, n := range notes {
+		if n.AbsTicks > absTicks+tolerance || n.End() <= absTicks {
+			continue
+		}
+
+		if !found || n.AbsTicks > res.AbsTicks || (n.AbsTicks == res.AbsTicks && n.Key > res.Key) {
+			res, found = n, true
+		}
+	}
+
+	return
+}
+
+// similarMotion returns true, if both voices moved in the same direction from the previous event to the next
+func similarMotion(prev, next IntervalEvent) bool {
+	da := int(next.A.Key) - int(prev.A.Key)
+	db := int(next.B.Key) - int(prev.B.Key)
+	return (da > 0 && db > 0) || (da < 0 && db < 0)
+}
@@ -0,0 +1,128 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// voice returns whole notes (1920 ticks) of the given keys
+func voice(keys ...uint8) []smftrack.Note {
+	var notes []smftrack.Note
+
+	for i, k := range keys {
+		notes = append(notes, smftrack.Note{Key: k, Velocity: 100, AbsTicks: uint64(i) * 1920, Duration: 1920})
+	}
+
+	return notes
+}
+
+// intervalsString returns the events as "tick semitones name flags" lines
+func intervalsString(evts []IntervalEvent) string {
+	var bf bytes.Buffer
+
+	for _, ev := range evts {
+		fmt.Fprintf(&bf, "%v %v %s", ev.AbsTicks, ev.Semitones, ev.Name)
+
+		if ev.ParallelFifths {
+			bf.WriteString(" (parallel fifths)")
+		}
+
+		if ev.ParallelOctaves {
+			bf.WriteString(" (parallel octaves)")
+		}
+
+		bf.WriteString("\n")
+	}
+
+	return bf.String()
+}
+
+func TestIntervals(t *testing.T) {
+	// first species: a cantus firmus in D dorian and a counterpoint with parallel fifths at bar 2 and 8
+	// and parallel octaves at bar 5
+	cantus := voice(50, 53, 52, 50, 55, 53, 57, 55, 53, 52, 50)
+	counterpoint := voice(57, 60, 60, 62, 67, 69, 64, 62, 69, 61, 62)
+
+	expected := `0 7 perfect fifth
+1920 7 perfect fifth (parallel fifths)
+3840 8 minor sixth
+5760 12 octave
+7680 12 octave (parallel octaves)
+9600 16 major tenth
+11520 7 perfect fifth
+13440 7 perfect fifth (parallel fifths)
+15360 16 major tenth
+17280 9 major sixth
+19200 12 octave
+`
+
+	if got := intervalsString(Intervals(cantus, counterpoint)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	// the order of the voices determines the sign, compound intervals are reduced
+	expected = `0 -7 perfect fifth
+1920 -7 perfect fifth (parallel fifths)
+3840 -8 minor sixth
+5760 -12 octave
+7680 -12 octave (parallel octaves)
+9600 -16 major third
+`
+
+	if got := intervalsString(Intervals(counterpoint[:6], cantus, IntervalReduceCompound())); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+func TestIntervalsTolerance(t *testing.T) {
+	cantus := voice(50, 53, 55)
+	counterpoint := voice(57, 60, 67)
+
+	// the counterpoint is played late and ends early
+	for i := range counterpoint {
+		counterpoint[i].AbsTicks += 10
+		counterpoint[i].Duration -= 20
+	}
+
+	expected := `0 7 perfect fifth
+1920 7 perfect fifth (parallel fifths)
+3840 12 octave
+`
+
+	if got := intervalsString(Intervals(cantus, counterpoint, IntervalTolerance(10))); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	// without tolerance, the cantus sounds alone at its onsets
+	expected = `10 7 perfect fifth
+1930 7 perfect fifth (parallel fifths)
+3850 12 octave
+`
+
+	if got := intervalsString(Intervals(cantus, counterpoint)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+func TestIntervalName(t *testing.T) {
+	tests := []struct {
+		semitones int
+		expected  string
+	}{
+		{0, "unison"},
+		{-7, "perfect fifth"},
+		{18, "compound tritone"},
+		{24, "double octave"},
+		{28, "major tenth + 1 octave"},
+		{40, "major tenth + 2 octaves"},
+	}
+
+	for _, test := range tests {
+		if got := IntervalName(test.semitones); got != test.expected {
+			t.Errorf("IntervalName(%v) = %q; want %q", test.semitones, got, test.expected)
+		}
+	}
+}
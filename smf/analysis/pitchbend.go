@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// PitchBendPoint is a pitch bend message at a certain tick
+type PitchBendPoint struct {
+	AbsTicks uint64
+
+	// Value is the pitch bend value (-8192 to 8191)
+	Value int16
+}
+
+// PitchBendCurve is the course of the pitch bend of a MIDI channel.
+// The points are sorted by their absolute ticks. Each value lasts until the next point.
+type PitchBendCurve struct {
+	Channel uint8
+	Points  []PitchBendPoint
+}
+
+// ValueAt returns the pitch bend value at the given tick (0, if there is no pitch bend before).
+func (c PitchBendCurve) ValueAt(absTicks uint64) int16 {
+	i := sort.Search(len(c.Points), func(i int) bool {
+		return c.Points[i].AbsTicks > absTicks
+	})
+
+	if i == 0 {
+		return 0
+	}
+
+	return c.Points[i-1].Value
+}
+
+// SemitonesAt returns the pitch bend at the given tick in semitones, based on the pitch bend
+// range of the channel at that tick.
+func (c PitchBendCurve) SemitonesAt(absTicks uint64, ranges BendRangeMap) float64 {
+	return BendSemitones(c.ValueAt(absTicks), ranges.At(c.Channel, absTicks))
+}
+
+// BendSemitones converts the given pitch bend value to semitones for the given pitch bend range.
+// The extreme values -8192 and 8191 correspond to -bendRange and +bendRange.
+func BendSemitones(value int16, bendRange float64) float64 {
+	if value < 0 {
+		return float64(value) / 8192 * bendRange
+	}
+	return float64(value) / 8191 * bendRange
+}
+
+// PitchBends returns the pitch bend curves of the given SMF, mapped by their MIDI channels.
+func PitchBends(s *smftrack.SMF) map[uint8]PitchBendCurve {
+	var m = map[uint8]PitchBendCurve{}
+
+	for _, ev := range s.Merged() {
+		pb, is := ev.Message.(channel.Pitchbend)
+		if !is {
+			continue
+		}
+
+		c := m[pb.Channel()]
+		c.Channel = pb.Channel()
+		c.Points = append(c.Points, PitchBendPoint{AbsTicks: ev.AbsTicks, Value: pb.Value()})
+		m[pb.Channel()] = c
+	}
+
+	return m
+}
+
+// MaxStep returns the largest change of the value between successive points and the longest time between
+// successive points with different values.
+func (c PitchBendCurve) MaxStep() (ticks uint64, value int) {
+	for i := 1; i < len(c.Points); i++ {
+		a, b := c.Points[i-1], c.Points[i]
+		d := int(b.Value) - int(a.Value)
+
+		if d == 0 {
+			continue
+		}
+
+		if d < 0 {
+			d = -d
+		}
+
+		if d > value {
+			value = d
+		}
+
+		if dt := b.AbsTicks - a.AbsTicks; dt > ticks {
+			ticks = dt
+		}
+	}
+
+	return
+}
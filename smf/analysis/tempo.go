@@ -0,0 +1,274 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+const (
+	// chordWindow is the time within which onsets are taken as a single onset (chords, strums)
+	chordWindow = 0.05
+
+	// minIOI and maxIOI are the bounds of the inter-onset intervals that are taken into account (in seconds)
+	minIOI = 0.07
+	maxIOI = 2.5
+
+	// clusterWidth is the maximal spread of the inter-onset intervals of a cluster, relative to the shortest one
+	clusterWidth = 0.06
+
+	// multipleTolerance is the tolerance for an interval to be a multiple of another one, relative to the latter
+	multipleTolerance = 0.1
+
+	// the beat is folded into the range of minBPM (inclusive) to maxBPM (exclusive)
+	minBPM = 80
+	maxBPM = 160
+)
+
+// InferTempo infers the tempo of an unquantized performance (e.g. recorded without a click) from the onsets of
+// the given notes, whose ticks are converted to time with the given TempoMap.
+// It returns the tempo in beats per minute, the tick of the first beat and the confidence of the result from
+// 0 (onsets without a steady beat) to 1 (onsets exactly on a steady beat).
+// The result can be passed to smftrack.Retime.
+//
+// The inference is a heuristic: the inter-onset intervals are clustered into a histogram and the cluster that is
+// supported best by the clusters of its multiples is taken as the beat, folded into the range of 80 to 160 BPM.
+// The onsets are then placed on a grid of sixteenth notes from one onset to the next, so that gradual tempo
+// changes (rubato) do not disturb the placement, and tempo and phase are fitted to the placed onsets.
+// Since the beat can only be inferred up to a factor of 2, half or double the tempo might be the musically
+// correct one. The result does not depend on the order of the notes.
+//
+// If there are not enough onsets to infer a tempo, all results are 0.
+func InferTempo(notes []smftrack.Note, tm *smftrack.TempoMap) (bpm float64, offsetTicks uint64, confidence float64) {
+	onsets, weights := onsetTimes(notes, tm)
+
+	period := beatPeriod(onsets)
+
+	if period == 0 {
+		return 0, 0, 0
+	}
+
+	var phase, rms float64
+
+	// the second pass places the onsets with the fitted period
+	for pass := 0; pass < 2; pass++ {
+		grid := placeOnsets(onsets, weights, period)
+		p, ph := fitGrid(onsets, grid)
+
+		if p <= 0 {
+			return 0, 0, 0
+		}
+
+		period, phase, rms = p, ph, gridDeviation(onsets, grid, p)
+	}
+
+	phase = math.Mod(phase, period)
+
+	if phase < 0 {
+		phase += period
+	}
+
+	// the deviation of onsets that are randomly distributed on the sixteenth grid is uniform within ±period/8
+	confidence = 1 - rms/(period/8/math.Sqrt(3))
+
+	if confidence < 0 {
+		confidence = 0
+	}
+
+	return 60 / period, tm.Ticks(time.Duration(math.Round(phase * float64(time.Second)))), confidence
+}
+
+// onsetTimes returns the sorted times of the onsets of the notes in seconds, with onsets within
+// chordWindow merged into the first of them. The weight of an onset is the sum of the velocities of its notes.
+func onsetTimes(notes []smftrack.Note, tm *smftrack.TempoMap) (onsets, weights []float64) {
+	sorted := make([]smftrack.Note, len(notes))
+	copy(sorted, notes)
+
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].AbsTicks < sorted[j].AbsTicks })
+
+	for _, n := range sorted {
+		sec := tm.Time(n.AbsTicks).Seconds()
+
+		if len(onsets) > 0 && sec-onsets[len(onsets)-1] < chordWindow {
+			weights[len(weights)-1] += float64(n.Velocity)
+			continue
+		}
+
+		onsets = append(onsets, sec)
+		weights = append(weights, float64(n.Velocity))
+	}
+
+	return
+}
+
+// ioiCluster is a cluster of similar inter-onset intervals
+type ioiCluster struct {
+	interval float64
+	count    int
+	score    float64
+}
+
+// relationWeight returns the weight of a cluster, whose interval is n times the interval of another cluster
+func relationWeight(n int) float64 {
+	if n <= 4 {
+		return float64(6 - n)
+	}
+	return 1
+}
+
+// beatPeriod returns the period of the beat in seconds, based on the clustered inter-onset intervals of the
+// given onsets, or 0, if there are not enough intervals
+func beatPeriod(onsets []float64) float64 {
+	var iois []float64
+
+	for i := range onsets {
+		for j := i + 1; j < len(onsets); j++ {
+			d := onsets[j] - onsets[i]
+
+			if d > maxIOI {
+				break
+			}
+
+			if d >= minIOI {
+				iois = append(iois, d)
+			}
+		}
+	}
+
+	if len(iois) < 2 {
+		return 0
+	}
+
+	sort.Float64s(iois)
+
+	var clusters []*ioiCluster
+	var first, sum float64
+
+	for _, d := range iois {
+		if len(clusters) == 0 || d-first > clusterWidth*first {
+			clusters = append(clusters, &ioiCluster{})
+			first, sum = d, 0
+		}
+
+		c := clusters[len(clusters)-1]
+		c.count++
+		sum += d
+		c.interval = sum / float64(c.count)
+	}
+
+	for _, c := range clusters {
+		c.score = relationWeight(1) * float64(c.count)
+	}
+
+	for i, ci := range clusters {
+		for _, cj := range clusters[i+1:] {
+			n := int(math.Round(cj.interval / ci.interval))
+
+			if n < 1 || n > 8 || math.Abs(cj.interval-float64(n)*ci.interval) > multipleTolerance*ci.interval {
+				continue
+			}
+
+			ci.score += relationWeight(n) * float64(cj.count)
+			cj.score += relationWeight(n) * float64(ci.count)
+		}
+	}
+
+	best := clusters[0]
+
+	for _, c := range clusters[1:] {
+		if c.score > best.score {
+			best = c
+		}
+	}
+
+	period := best.interval
+
+	for 60/period < minBPM {
+		period /= 2
+	}
+
+	for 60/period >= maxBPM {
+		period *= 2
+	}
+
+	return period
+}
+
+// placeOnsets returns the positions of the onsets on a grid of sixteenth notes of the given beat period.
+// Each onset is placed relative to the previous one, so that the placement follows gradual tempo changes.
+// The sixteenth within the beat where the onsets have the highest weight is taken as the beat.
+func placeOnsets(onsets, weights []float64, period float64) []int64 {
+	grid := make([]int64, len(onsets))
+
+	for i := 1; i < len(onsets); i++ {
+		grid[i] = grid[i-1] + int64(math.Round((onsets[i]-onsets[i-1])/(period/4)))
+	}
+
+	var sums [4]float64
+
+	for i, g := range grid {
+		sums[mod4(g)] += weights[i]
+	}
+
+	var beat int64
+
+	for r := int64(1); r < 4; r++ {
+		if sums[r] > sums[beat] {
+			beat = r
+		}
+	}
+
+	for i := range grid {
+		grid[i] -= beat
+	}
+
+	return grid
+}
+
+func mod4(g int64) int64 {
+	return ((g % 4) + 4) % 4
+}
+
+// fitGrid returns the beat period and the time of the beat at grid position 0 that fit the given
+// onsets at the given grid positions best (least squares)
+func fitGrid(onsets []float64, grid []int64) (period, phase float64) {
+	var n, sumX, sumY, sumXX, sumXY float64
+
+	for i, t := range onsets {
+		x := float64(grid[i]) / 4
+		n++
+		sumX += x
+		sumY += t
+		sumXX += x * x
+		sumXY += x * t
+	}
+
+	den := n*sumXX - sumX*sumX
+
+	if den == 0 {
+		return 0, 0
+	}
+
+	period = (n*sumXY - sumX*sumY) / den
+	phase = (sumY - period*sumX) / n
+	return
+}
+
+// gridDeviation returns the root mean square of the deviations of the inter-onset intervals from their
+// placement on the grid
+func gridDeviation(onsets []float64, grid []int64, period float64) float64 {
+	if len(onsets) < 2 {
+		return 0
+	}
+
+	var sum float64
+
+	for i := 1; i < len(onsets); i++ {
+		d := (onsets[i] - onsets[i-1]) - float64(grid[i]-grid[i-1])*period/4
+		sum += d * d
+	}
+
+	return math.Sqrt(sum / float64(len(onsets)-1))
+}
@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// performance returns the notes of a performance with the given mean tempo that starts with a beat at start
+// (in seconds), recorded into a file of 120 BPM with 960 ticks per quarter note. The period of each beat deviates
+// sinusoidally by the factor rubato from the mean period. Each beat has an accented bass note and an off beat
+// eighth, every fourth beat has sixteenths instead. If upbeat is true, an eighth before the first beat is added.
+func performance(bpm, start, rubato float64, beats int, upbeat bool) []smftrack.Note {
+	const ticksPerSecond = 1920
+
+	var notes []smftrack.Note
+	period := 60 / bpm
+
+	add := func(sec float64, key, velocity uint8) {
+		notes = append(notes, smftrack.Note{Key: key, Velocity: velocity, AbsTicks: uint64(math.Round(sec * ticksPerSecond)), Duration: 100})
+	}
+
+	if upbeat {
+		add(start-period/2, 67, 70)
+	}
+
+	t := start
+
+	for k := 0; k < beats; k++ {
+		p := period * (1 + rubato*math.Sin(2*math.Pi*float64(k)/8))
+
+		add(t, 36, 100)
+		add(t, 60, 90)
+
+		if k%4 == 3 {
+			add(t+p/4, 62, 60)
+			add(t+p/2, 64, 70)
+			add(t+p*3/4, 62, 60)
+		} else {
+			add(t+p/2, 64, 70)
+		}
+
+		t += p
+	}
+
+	return notes
+}
+
+func TestInferTempo(t *testing.T) {
+	tm := smftrack.NewTempoMap(smf.MetricTicks(960))
+
+	tests := []struct {
+		bpm, start, rubato float64
+		upbeat             bool
+		bpmTolerance       float64
+		minConfidence      float64
+	}{
+		{100, 0.3, 0, false, 0.01, 0.99},
+		{100, 0.3, 0, true, 0.01, 0.99},
+		{132, 0.4, 0, true, 0.01, 0.99},
+		{90, 0.35, 0.03, false, 0.2, 0.6},
+		{100, 0.3, 0.05, true, 0.2, 0.6},
+		{140, 0.3, 0.04, true, 0.2, 0.6},
+	}
+
+	for i, test := range tests {
+		notes := performance(test.bpm, test.start, test.rubato, 32, test.upbeat)
+		bpm, offset, confidence := InferTempo(notes, tm)
+
+		if math.Abs(bpm-test.bpm) > test.bpmTolerance {
+			t.Errorf("[%v] bpm = %0.3f; wanted %v", i, bpm, test.bpm)
+		}
+
+		// the first beat within 10% of the period
+		if got, want := tm.Time(offset).Seconds(), test.start; math.Abs(got-want) > 6/test.bpm {
+			t.Errorf("[%v] first beat at %0.3fs; wanted %0.3fs", i, got, want)
+		}
+
+		if confidence < test.minConfidence || confidence > 1 {
+			t.Errorf("[%v] confidence = %0.3f; wanted at least %v", i, confidence, test.minConfidence)
+		}
+	}
+}
+
+func TestInferTempoDeterminism(t *testing.T) {
+	tm := smftrack.NewTempoMap(smf.MetricTicks(960))
+	notes := performance(110, 0.25, 0.04, 24, true)
+
+	bpm, offset, confidence := InferTempo(notes, tm)
+
+	// the order of the notes does not matter
+	shuffled := make([]smftrack.Note, len(notes))
+	copy(shuffled, notes)
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	for i := 0; i < 3; i++ {
+		b, o, c := InferTempo(shuffled, tm)
+
+		if b != bpm || o != offset || c != confidence {
+			t.Fatalf("got %v, %v, %v; wanted %v, %v, %v", b, o, c, bpm, offset, confidence)
+		}
+	}
+}
+
+func TestInferTempoNoBeat(t *testing.T) {
+	tm := smftrack.NewTempoMap(smf.MetricTicks(960))
+
+	if bpm, offset, confidence := InferTempo(nil, tm); bpm != 0 || offset != 0 || confidence != 0 {
+		t.Errorf("no notes: got %v, %v, %v; wanted 0, 0, 0", bpm, offset, confidence)
+	}
+
+	// a single chord
+	chord := []smftrack.Note{{Key: 60, Velocity: 100}, {Key: 64, Velocity: 100, AbsTicks: 10}}
+
+	if bpm, offset, confidence := InferTempo(chord, tm); bpm != 0 || offset != 0 || confidence != 0 {
+		t.Errorf("single chord: got %v, %v, %v; wanted 0, 0, 0", bpm, offset, confidence)
+	}
+
+	// onsets at random times have a low confidence
+	rnd := rand.New(rand.NewSource(42))
+	var notes []smftrack.Note
+	var tick uint64
+
+	for i := 0; i < 200; i++ {
+		tick += uint64(150 + rnd.Intn(1500))
+		notes = append(notes, smftrack.Note{Key: 60, Velocity: 100, AbsTicks: tick})
+	}
+
+	if _, _, confidence := InferTempo(notes, tm); confidence > 0.3 {
+		t.Errorf("random onsets: confidence = %0.3f; wanted less than 0.3", confidence)
+	}
+}
@@ -9,6 +9,8 @@ The readers/writers can be found here:
 
   github.com/gomidi/midi/smf/smfreader (read MIDI messages from SMF)
   github.com/gomidi/midi/smf/smfwriter (writes MIDI messages to SMF)
+  github.com/gomidi/midi/smf/smftrack  (in-memory SMF for modification)
+  github.com/gomidi/midi/smf/analysis  (musical analysis of an in-memory SMF)
 
 The MIDI messages that can be read/written from/to a SMF file can be found here:
 
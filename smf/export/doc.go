@@ -0,0 +1,28 @@
+// Copyright (c) 2018 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package export provides exports of the contents of an SMF that has been read via smftrack into other formats.
+
+Example
+
+	s, err := smftrack.ReadFile("song.kar")
+
+	if err != nil {
+		panic(err)
+	}
+
+	// write the lyrics as subtitles, 2 seconds later than in the SMF
+	err = export.SRT(s, os.Stdout, export.Offset(2*time.Second))
+
+The tempo map can be exported as text for the tempo import of a DAW and imported from such text:
+
+	err = export.TempoMapText(s, os.Stdout, export.TempoCSV)
+
+	res, err := export.ImportTempoMapText(s, file, export.TempoTSV)
+
+Since the tracks of SMF format 2 have independent timelines, the functions of this package only make
+sense for SMF format 0 and 1.
+*/
+package export
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+func TestGolden(t *testing.T) {
+	s, err := smftrack.ReadFile(filepath.Join("testdata", "birthday.kar"))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	tests := []struct {
+		golden  string
+		export  func(*smftrack.SMF, *bytes.Buffer, ...Option) error
+		options []Option
+	}{
+		{"birthday.lrc", func(s *smftrack.SMF, bf *bytes.Buffer, opts ...Option) error { return LRC(s, bf, opts...) }, nil},
+		{"birthday.srt", func(s *smftrack.SMF, bf *bytes.Buffer, opts ...Option) error { return SRT(s, bf, opts...) }, nil},
+		{"birthday_gap.srt", func(s *smftrack.SMF, bf *bytes.Buffer, opts ...Option) error { return SRT(s, bf, opts...) },
+			[]Option{LineGap(time.Second), Offset(-500 * time.Millisecond)}},
+	}
+
+	for _, test := range tests {
+		var bf bytes.Buffer
+
+		if err := test.export(s, &bf, test.options...); err != nil {
+			t.Fatalf("%s: Error: %v", test.golden, err)
+		}
+
+		expected, err := ioutil.ReadFile(filepath.Join("testdata", test.golden))
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		if got, want := bf.String(), string(expected); got != want {
+			t.Errorf("%s: got:\n%s\n\nwanted:\n%s\n\n", test.golden, got, want)
+		}
+	}
+}
+
+func TestLinesLyric(t *testing.T) {
+	var tr smftrack.Track
+	tr.Add(0, meta.Text("not part of the lyrics"))
+	tr.Add(96, meta.Lyric("Twin"))
+	tr.Add(144, meta.Lyric("kle\r"))
+	tr.Add(192, meta.Lyric("lit"))
+	tr.Add(240, meta.Lyric("tle "))
+	tr.Add(288, meta.Lyric("star\n"))
+
+	s := smftrack.New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	var bf bytes.Buffer
+	LRC(s, &bf)
+
+	expected := "[00:00.50]Twinkle\n[00:01.00]little star\n"
+
+	if got, want := bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
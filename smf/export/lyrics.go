@@ -0,0 +1,196 @@
+package export
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// linger is the time a line is shown after its last syllable, if the next line does not start earlier
+const linger = 2 * time.Second
+
+// Line is a line of lyrics
+type Line struct {
+	// Start is the time of the first syllable
+	Start time.Duration
+
+	// End is the time when the line should disappear: the start of the next line,
+	// but not later than 2 seconds after the last syllable.
+	End time.Duration
+
+	// Text is the text of the line in UTF-8
+	Text string
+}
+
+type config struct {
+	offset  time.Duration
+	lineGap time.Duration
+	decode  func(string) string
+	ppq     uint16
+}
+
+// Option is an option for the exports
+type Option func(*config)
+
+// Offset shifts the times by the given offset. Times before 0 are set to 0.
+func Offset(d time.Duration) Option {
+	return func(c *config) {
+		c.offset = d
+	}
+}
+
+// LineGap starts a new line, if the time between two syllables is at least the given duration.
+// Without this option, the lines are only broken at the line breaks of the lyrics.
+func LineGap(d time.Duration) Option {
+	return func(c *config) {
+		c.lineGap = d
+	}
+}
+
+// Decode sets the function that converts the text of the SMF to UTF-8.
+// Without this option, text that is valid UTF-8 is kept and other text is decoded as ISO-8859-1 (Latin-1),
+// which is the common encoding of karaoke files.
+func Decode(fn func(string) string) Option {
+	return func(c *config) {
+		c.decode = fn
+	}
+}
+
+// decodeLatin1 returns the given text unchanged, if it is valid UTF-8, otherwise it is decoded as ISO-8859-1
+func decodeLatin1(text string) string {
+	if utf8.ValidString(text) {
+		return text
+	}
+
+	var bf strings.Builder
+
+	for i := 0; i < len(text); i++ {
+		bf.WriteRune(rune(text[i]))
+	}
+
+	return bf.String()
+}
+
+// syllable is a piece of the lyrics at a certain time
+type syllable struct {
+	time time.Duration
+	text string
+	// newLine is true, if the syllable starts a new line
+	newLine bool
+}
+
+// syllables returns the syllables of the lyrics. If there are lyric messages, they are taken. Otherwise the
+// text messages are taken in the way of karaoke (KAR) files: text messages starting with "@" are information
+// about the song, a leading "\" starts a new paragraph and a leading "/" starts a new line.
+// A syllable of a lyric message that ends with a carriage return or line feed ends the line.
+func syllables(s *smftrack.SMF, c config) (res []syllable) {
+	var hasLyrics bool
+
+	evts := s.Merged()
+
+	for _, ev := range evts {
+		if _, is := ev.Message.(meta.Lyric); is {
+			hasLyrics = true
+			break
+		}
+	}
+
+	tm := s.TempoMap()
+	var endsLine bool
+
+	for _, ev := range evts {
+		var text string
+
+		switch v := ev.Message.(type) {
+		case meta.Lyric:
+			text = v.Text()
+		case meta.Text:
+			if hasLyrics || strings.HasPrefix(v.Text(), "@") {
+				continue
+			}
+			text = v.Text()
+		default:
+			continue
+		}
+
+		syl := syllable{time: tm.Time(ev.AbsTicks) + c.offset, newLine: endsLine}
+
+		if syl.time < 0 {
+			syl.time = 0
+		}
+
+		if strings.HasPrefix(text, "\\") || strings.HasPrefix(text, "/") {
+			syl.newLine = true
+			text = text[1:]
+		}
+
+		if t := strings.TrimLeft(text, "\r\n"); t != text {
+			syl.newLine = true
+			text = t
+		}
+
+		trimmed := strings.TrimRight(text, "\r\n")
+		endsLine = trimmed != text
+
+		syl.text = c.decode(trimmed)
+		res = append(res, syl)
+	}
+
+	return
+}
+
+// Lines returns the lines of the lyrics of the given SMF. The lyrics are taken from the lyric messages or,
+// if there are none, from the text messages in the way of karaoke (KAR) files.
+// Lines are broken at the line breaks of the lyrics and at gaps (see LineGap). Empty lines are skipped.
+func Lines(s *smftrack.SMF, options ...Option) (lines []Line) {
+	c := config{decode: decodeLatin1}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	var cur *Line
+	var last time.Duration
+
+	finish := func(next time.Duration) {
+		if cur == nil {
+			return
+		}
+
+		cur.End = last + linger
+
+		if next < cur.End {
+			cur.End = next
+		}
+
+		if cur.Text = strings.TrimSpace(cur.Text); cur.Text != "" {
+			lines = append(lines, *cur)
+		}
+
+		cur = nil
+	}
+
+	for _, syl := range syllables(s, c) {
+		gap := c.lineGap > 0 && cur != nil && syl.time-last >= c.lineGap
+
+		if syl.newLine || gap {
+			finish(syl.time)
+		}
+
+		if cur == nil {
+			if strings.TrimSpace(syl.text) == "" {
+				continue
+			}
+			cur = &Line{Start: syl.time}
+		}
+
+		cur.Text += syl.text
+		last = syl.time
+	}
+
+	finish(last + linger)
+	return
+}
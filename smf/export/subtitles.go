@@ -0,0 +1,49 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// LRC writes the lines of the lyrics of the given SMF (see Lines) in the LRC format to w, e.g.
+//
+//	[00:12.50]Happy birthday to you
+func LRC(s *smftrack.SMF, w io.Writer, options ...Option) error {
+	for _, l := range Lines(s, options...) {
+		cs := l.Start / (10 * time.Millisecond)
+
+		_, err := fmt.Fprintf(w, "[%02d:%02d.%02d]%s\n", cs/6000, cs/100%60, cs%100, l.Text)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SRT writes the lines of the lyrics of the given SMF (see Lines) in the SubRip (SRT) format to w, e.g.
+//
+//	1
+//	00:00:12,500 --> 00:00:14,250
+//	Happy birthday to you
+func SRT(s *smftrack.SMF, w io.Writer, options ...Option) error {
+	for i, l := range Lines(s, options...) {
+		_, err := fmt.Fprintf(w, "%v\n%s --> %s\n%s\n\n", i+1, srtTime(l.Start), srtTime(l.End), l.Text)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// srtTime formats the given time as hours:minutes:seconds,milliseconds
+func srtTime(d time.Duration) string {
+	ms := d / time.Millisecond
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,323 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// TempoFormat is a text format of a tempo map
+type TempoFormat int
+
+const (
+	// TempoTSV is a tab separated tempo list. It states its resolution and has a line per tempo event with the
+	// position in ticks, the tempo in BPM and the type of the event, e.g.
+	//
+	//	Tempo Track
+	//	PPQ	480
+	//	Position	BPM	Type
+	//	0	120.000	Jump
+	//	7680	96.500	Jump
+	//
+	// The exported events are always jumps. Ramps are imported as jumps, since tempo messages can't express ramps.
+	TempoTSV TempoFormat = iota + 1
+
+	// TempoCSV is a CSV with a header line and a line per tempo change with the position in ticks, the tempo in BPM
+	// and the time since the start in seconds, e.g.
+	//
+	//	tick,bpm,time
+	//	0,120.000,0.000
+	//	7680,96.500,8.000
+	//
+	// The time is ignored by the import, since it follows from the tempo changes.
+	TempoCSV
+)
+
+// tsvHeader is the first line of the TempoTSV format
+const tsvHeader = "Tempo Track"
+
+// tsvPPQ is the resolution of the TempoTSV format, if the PPQ option is not given
+const tsvPPQ = 480
+
+// maxTempo is the maximal tempo of a tempo message in microseconds per quarter note
+const maxTempo = 0xFFFFFF
+
+// PPQ sets the resolution of the ticks of a tempo map text in ticks per quarter note. By default, TempoTSV
+// is exported with 480 ticks per quarter note and imported with the resolution that it states, while TempoCSV has
+// the resolution of the SMF. The ticks are converted between the resolutions of the text and the SMF.
+func PPQ(ticksPerQuarter uint16) Option {
+	return func(c *config) {
+		c.ppq = ticksPerQuarter
+	}
+}
+
+// tempoEntry is a tempo change of a tempo map text
+type tempoEntry struct {
+	tick  uint64
+	tempo meta.Tempo
+}
+
+// ppqOf returns the resolution of the SMF, which must have a metric time format
+func ppqOf(s *smftrack.SMF) (uint64, error) {
+	mt, ok := s.TimeFormat().(smf.MetricTicks)
+
+	if !ok || mt.Number() == 0 {
+		return 0, fmt.Errorf("tempo maps need a metric time format, not %s", s.TimeFormat())
+	}
+
+	return uint64(mt.Number()), nil
+}
+
+// convertTicks converts the given ticks of the resolution from to the resolution to, rounded to the nearest tick
+func convertTicks(ticks, from, to uint64) uint64 {
+	if from == to {
+		return ticks
+	}
+	return uint64(math.Round(float64(ticks) * float64(to) / float64(from)))
+}
+
+// TempoMapText writes the tempo map of the given SMF (see smftrack.TempoMap) in the given format to w. The tempo
+// changes include the initial tempo at tick 0 (120 BPM, if there is no tempo message at tick 0).
+// Only the PPQ option is taken into account. An error is returned for SMFs without metric time format.
+func TempoMapText(s *smftrack.SMF, w io.Writer, format TempoFormat, options ...Option) error {
+	var c config
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	filePPQ, err := ppqOf(s)
+
+	if err != nil {
+		return err
+	}
+
+	var textPPQ = uint64(c.ppq)
+	var bf strings.Builder
+
+	switch format {
+	case TempoTSV:
+		if textPPQ == 0 {
+			textPPQ = tsvPPQ
+		}
+
+		fmt.Fprintf(&bf, "%s\nPPQ\t%v\nPosition\tBPM\tType\n", tsvHeader, textPPQ)
+	case TempoCSV:
+		if textPPQ == 0 {
+			textPPQ = filePPQ
+		}
+
+		bf.WriteString("tick,bpm,time\n")
+	default:
+		return fmt.Errorf("unknown tempo format %v", format)
+	}
+
+	for _, ch := range s.TempoMap().Changes() {
+		tick := convertTicks(ch.AbsTicks, filePPQ, textPPQ)
+
+		if format == TempoTSV {
+			fmt.Fprintf(&bf, "%v\t%.3f\tJump\n", tick, ch.Tempo.FractionalBPM())
+		} else {
+			fmt.Fprintf(&bf, "%v,%.3f,%.3f\n", tick, ch.Tempo.FractionalBPM(), ch.Time.Seconds())
+		}
+	}
+
+	_, err = io.WriteString(w, bf.String())
+	return err
+}
+
+// ImportTempoMapText returns a copy of the given SMF where the tempo messages are replaced by the tempo changes of
+// the given text in the given format (see smftrack.ApplyConductor). The given SMF is not modified.
+// Only the PPQ option is taken into account.
+//
+// The ticks of the text must not decrease. Of several tempo changes at the same tick, the last one wins, also if
+// they fall on the same tick by the conversion to the resolution of the SMF. An error is returned for invalid
+// text, for SMFs without metric time format and for SMF format 2.
+func ImportTempoMapText(s *smftrack.SMF, r io.Reader, format TempoFormat, options ...Option) (*smftrack.SMF, error) {
+	var c config
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	filePPQ, err := ppqOf(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []tempoEntry
+	var textPPQ uint64
+
+	switch format {
+	case TempoTSV:
+		entries, textPPQ, err = parseTempoTSV(r)
+	case TempoCSV:
+		entries, err = parseTempoCSV(r)
+		textPPQ = filePPQ
+	default:
+		return nil, fmt.Errorf("unknown tempo format %v", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ppq > 0 {
+		textPPQ = uint64(c.ppq)
+	}
+
+	var marks []smftrack.TempoMark
+
+	for _, e := range entries {
+		tick := convertTicks(e.tick, textPPQ, filePPQ)
+
+		if n := len(marks); n > 0 && marks[n-1].AbsTicks == tick {
+			marks[n-1].Tempo = e.tempo
+			continue
+		}
+
+		marks = append(marks, smftrack.TempoMark{AbsTicks: tick, Tempo: e.tempo})
+	}
+
+	tl := smftrack.Conductor(s)
+	tl.TempoChanges = marks
+	return smftrack.ApplyConductor(s, tl)
+}
+
+// tempoLines calls fn with the number and the fields of each non empty line of the given text
+func tempoLines(r io.Reader, sep string, fn func(line int, fields []string) error) error {
+	sc := bufio.NewScanner(r)
+
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+
+		if text == "" {
+			continue
+		}
+
+		var fields []string
+
+		for _, f := range strings.Split(text, sep) {
+			fields = append(fields, strings.TrimSpace(f))
+		}
+
+		if err := fn(line, fields); err != nil {
+			return err
+		}
+	}
+
+	return sc.Err()
+}
+
+// parseTempoEntry parses the tick and the BPM of a line and checks that the tick does not decrease
+func parseTempoEntry(line int, tick, bpm string, entries []tempoEntry) ([]tempoEntry, error) {
+	t, err := strconv.ParseUint(tick, 10, 64)
+
+	if err != nil {
+		return nil, fmt.Errorf("line %v: invalid tick %q", line, tick)
+	}
+
+	b, err := strconv.ParseFloat(bpm, 64)
+
+	if err != nil || b <= 0 || math.IsInf(b, 0) || math.IsNaN(b) {
+		return nil, fmt.Errorf("line %v: invalid BPM %q", line, bpm)
+	}
+
+	if t := math.Round(60000000 / b); t < 1 || t > maxTempo {
+		return nil, fmt.Errorf("line %v: BPM %q out of range", line, bpm)
+	}
+
+	if n := len(entries); n > 0 && t < entries[n-1].tick {
+		return nil, fmt.Errorf("line %v: tick %v is before tick %v of the line before", line, t, entries[n-1].tick)
+	}
+
+	return append(entries, tempoEntry{tick: t, tempo: meta.FractionalBPM(b)}), nil
+}
+
+// parseTempoTSV parses the TempoTSV format
+func parseTempoTSV(r io.Reader) (entries []tempoEntry, ppq uint64, err error) {
+	var n int
+
+	err = tempoLines(r, "\t", func(line int, fields []string) error {
+		n++
+
+		switch {
+		case n == 1:
+			if fields[0] != tsvHeader {
+				return fmt.Errorf("line %v: missing %q", line, tsvHeader)
+			}
+		case n == 2:
+			var perr error
+
+			if len(fields) == 2 && fields[0] == "PPQ" {
+				ppq, perr = strconv.ParseUint(fields[1], 10, 16)
+			}
+
+			if len(fields) != 2 || fields[0] != "PPQ" || perr != nil || ppq == 0 {
+				return fmt.Errorf("line %v: missing or invalid PPQ", line)
+			}
+		case n == 3:
+			if fields[0] != "Position" {
+				return fmt.Errorf("line %v: missing column headers", line)
+			}
+		default:
+			if len(fields) != 3 {
+				return fmt.Errorf("line %v: want 3 columns, got %v", line, len(fields))
+			}
+
+			if fields[2] != "Jump" && fields[2] != "Ramp" {
+				return fmt.Errorf("line %v: invalid type %q", line, fields[2])
+			}
+
+			var perr error
+			entries, perr = parseTempoEntry(line, fields[0], fields[1], entries)
+			return perr
+		}
+
+		return nil
+	})
+
+	if err == nil && n < 3 {
+		err = fmt.Errorf("missing header")
+	}
+
+	return
+}
+
+// parseTempoCSV parses the TempoCSV format
+func parseTempoCSV(r io.Reader) (entries []tempoEntry, err error) {
+	var header bool
+
+	err = tempoLines(r, ",", func(line int, fields []string) error {
+		if !header {
+			if len(fields) != 3 || fields[0] != "tick" || fields[1] != "bpm" || fields[2] != "time" {
+				return fmt.Errorf("line %v: missing header tick,bpm,time", line)
+			}
+
+			header = true
+			return nil
+		}
+
+		if len(fields) != 3 {
+			return fmt.Errorf("line %v: want 3 columns, got %v", line, len(fields))
+		}
+
+		var perr error
+		entries, perr = parseTempoEntry(line, fields[0], fields[1], entries)
+		return perr
+	})
+
+	if err == nil && !header {
+		err = fmt.Errorf("missing header")
+	}
+
+	return
+}
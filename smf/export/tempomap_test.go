@@ -0,0 +1,144 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// tempoSMF returns a SMF1 with 96 ticks per quarter note and tempo changes in both tracks
+func tempoSMF() *smftrack.SMF {
+	var conductor, part smftrack.Track
+	conductor.Add(0, meta.Track("tempo"), meta.BPM(120))
+	conductor.Add(384, meta.Tempo(math.Round(60000000/96.5)))
+	conductor.Add(1000, meta.BPM(140))
+	part.Add(0, channel.Channel0.NoteOn(60, 100))
+	part.Add(768, meta.BPM(60), channel.Channel0.NoteOff(60))
+
+	s := smftrack.New(smf.SMF1, smf.MetricTicks(96))
+	s.AddTrack(&conductor)
+	s.AddTrack(&part)
+	return s
+}
+
+// changesString returns the tempo changes of the tempo map of the given SMF
+func changesString(s *smftrack.SMF) string {
+	var bf bytes.Buffer
+
+	for _, ch := range s.TempoMap().Changes() {
+		fmt.Fprintf(&bf, "%v %v %v\n", ch.AbsTicks, ch.Tempo.MuSecPerQN(), ch.Time)
+	}
+
+	return bf.String()
+}
+
+func TestTempoMapText(t *testing.T) {
+	tests := []struct {
+		golden  string
+		format  TempoFormat
+		options []Option
+	}{
+		{"tempo.tsv", TempoTSV, nil},
+		{"tempo.csv", TempoCSV, nil},
+		{"tempo960.csv", TempoCSV, []Option{PPQ(960)}},
+	}
+
+	s := tempoSMF()
+
+	for _, test := range tests {
+		var bf bytes.Buffer
+
+		if err := TempoMapText(s, &bf, test.format, test.options...); err != nil {
+			t.Fatalf("%s: Error: %v", test.golden, err)
+		}
+
+		expected, err := ioutil.ReadFile(filepath.Join("testdata", test.golden))
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		if got, want := bf.String(), string(expected); got != want {
+			t.Errorf("%s: got:\n%s\n\nwanted:\n%s\n\n", test.golden, got, want)
+		}
+
+		// the round trip through the text results in the same tempo map
+		var other smftrack.Track
+		other.Add(0, meta.BPM(80))
+		target := smftrack.New(smf.SMF1, smf.MetricTicks(96))
+		target.AddTrack(&other)
+
+		res, err := ImportTempoMapText(target, bytes.NewReader(bf.Bytes()), test.format, test.options...)
+
+		if err != nil {
+			t.Fatalf("%s: Error: %v", test.golden, err)
+		}
+
+		if got, want := changesString(res), changesString(s); got != want {
+			t.Errorf("%s: round trip: got:\n%s\n\nwanted:\n%s\n\n", test.golden, got, want)
+		}
+	}
+
+	if err := TempoMapText(smftrack.New(smf.SMF0, smf.SMPTE25(40)), &bytes.Buffer{}, TempoCSV); err == nil {
+		t.Errorf("TempoMapText() of time code returned no error")
+	}
+}
+
+func TestImportTempoMapText(t *testing.T) {
+	tests := []struct {
+		text     string
+		format   TempoFormat
+		expected string
+	}{
+		// duplicate ticks: the last one wins, the ticks of the TSV are converted from 960 to 96 PPQ
+		{"Tempo Track\nPPQ\t960\nPosition\tBPM\tType\n0\t100.000\tJump\n0\t90.000\tJump\n3840\t150.000\tRamp\n3844\t75.000\tJump\n",
+			TempoTSV, "0 666667 0s\n384 800000 2.666668s\n"},
+		{"tick,bpm,time\n0,120,0\n\n192, 60 ,1\n192,30,1\n", TempoCSV, "0 500000 0s\n192 2000000 1s\n"},
+		{"tick,bpm,time\n0,120,0\n192,60,1\n96,30,1\n", TempoCSV, "line 4: tick 96 is before tick 192 of the line before"},
+		{"tick,bpm,time\n0,0,0\n", TempoCSV, `line 2: invalid BPM "0"`},
+		{"tick,bpm,time\n0,NaN,0\n", TempoCSV, `line 2: invalid BPM "NaN"`},
+		{"tick,bpm,time\n0,3,0\n", TempoCSV, `line 2: BPM "3" out of range`},
+		{"tick,bpm,time\n0,200000000,0\n", TempoCSV, `line 2: BPM "200000000" out of range`},
+		{"tick,bpm,time\n-1,120,0\n", TempoCSV, `line 2: invalid tick "-1"`},
+		{"tick,bpm,time\n0,120\n", TempoCSV, "line 2: want 3 columns, got 2"},
+		{"0,120,0\n", TempoCSV, "line 1: missing header tick,bpm,time"},
+		{"", TempoCSV, "missing header"},
+		{"Tempo Track\nPPQ\t0\n", TempoTSV, "line 2: missing or invalid PPQ"},
+		{"Tempo Track\nPPQ\t480\nPosition\tBPM\tType\n0\t120.000\tCurve\n", TempoTSV, `line 4: invalid type "Curve"`},
+		{"Tempo\n", TempoTSV, `line 1: missing "Tempo Track"`},
+		{"tick,bpm,time\n", 0, "unknown tempo format 0"},
+	}
+
+	for _, test := range tests {
+		var got string
+		res, err := ImportTempoMapText(tempoSMF(), strings.NewReader(test.text), test.format)
+
+		if err != nil {
+			got = err.Error()
+		} else {
+			got = changesString(res)
+		}
+
+		if got != test.expected {
+			t.Errorf("ImportTempoMapText(%q):\ngot:\n%s\n\nwanted:\n%s\n\n", test.text, got, test.expected)
+		}
+	}
+
+	// the tempo messages of the other tracks are removed
+	res, _ := ImportTempoMapText(tempoSMF(), strings.NewReader("tick,bpm,time\n0,100,0\n"), TempoCSV)
+
+	for _, ev := range res.Track(1).Events() {
+		if _, is := ev.Message.(meta.Tempo); is {
+			t.Errorf("tempo message left in track 1 at tick %v", ev.AbsTicks)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package importer provides imports of other formats into an SMF that can be used with smftrack
+(the counterpart of package export).
+
+Pattern imports a drum pattern in the text form of a step sequencer grid, e.g. for quick test content:
+
+	s, err := importer.Pattern(`
+	# a basic rock beat
+	kick:            x.......x.x.....
+	snare:           ....x.......x...
+	hihat velocity=80: x.x.x.x.x.x.x.X.
+	`, importer.Swing(0.6), importer.Repeat(4))
+
+	if err != nil {
+		panic(err)
+	}
+
+	err = s.WriteFile("beat.mid")
+*/
+package importer
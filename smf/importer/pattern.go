@@ -0,0 +1,350 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// drumKeys are the General MIDI percussion keys of the short row names of Pattern
+var drumKeys = map[string]uint8{
+	"kick":       36,
+	"bd":         36,
+	"rim":        37,
+	"snare":      38,
+	"sd":         38,
+	"clap":       39,
+	"lowtom":     41,
+	"hihat":      42,
+	"hh":         42,
+	"midtom":     45,
+	"pedalhat":   44,
+	"openhat":    46,
+	"oh":         46,
+	"hightom":    48,
+	"crash":      49,
+	"ride":       51,
+	"tambourine": 54,
+	"cowbell":    56,
+	"shaker":     70,
+}
+
+// SyntaxError is returned by Pattern for invalid text. Line and Column are 1-based, the column counts runes.
+type SyntaxError struct {
+	Line, Column int
+	Msg          string
+}
+
+// Error returns the message with its position
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %v, column %v: %s", e.Line, e.Column, e.Msg)
+}
+
+type patternConfig struct {
+	resolution   uint16
+	stepsPerBeat uint16
+	swing        float64
+	repeat       int
+	bpm          float64
+}
+
+// PatternOption is an option for Pattern
+type PatternOption func(*patternConfig)
+
+// Resolution sets the resolution of the SMF in ticks per quarter note. Default is 480.
+func Resolution(ticksPerQuarter uint16) PatternOption {
+	return func(c *patternConfig) {
+		c.resolution = ticksPerQuarter
+	}
+}
+
+// StepsPerBeat sets the number of steps per quarter note. Default is 4 (sixteenth notes).
+// The resolution must be divisible by it.
+func StepsPerBeat(n uint16) PatternOption {
+	return func(c *patternConfig) {
+		c.stepsPerBeat = n
+	}
+}
+
+// Swing delays every second step, so that the first step of each pair takes the given ratio of the pair.
+// 0.5 (the default) is straight, 2/3 is a triplet feel. The ratio must be at least 0.5 and below 1.
+func Swing(ratio float64) PatternOption {
+	return func(c *patternConfig) {
+		c.swing = ratio
+	}
+}
+
+// Repeat sets how often the pattern is played. Default is 1.
+func Repeat(n int) PatternOption {
+	return func(c *patternConfig) {
+		c.repeat = n
+	}
+}
+
+// Tempo sets the tempo in beats per minute. Default is 120.
+func Tempo(bpm float64) PatternOption {
+	return func(c *patternConfig) {
+		c.bpm = bpm
+	}
+}
+
+// row is a parsed line of a pattern
+type row struct {
+	key, channel, velocity, accent uint8
+
+	// steps are the velocities of the steps, 0 for a rest
+	steps []uint8
+}
+
+// Pattern builds a single track SMF (format 0) from a drum pattern in the form of a step sequencer grid, e.g.
+//
+//	kick:  x...x...x...x...
+//	snare: ....x.......x...
+//
+// Each line is a row of a name, optional settings and the steps after a colon.
+// The name is one of kick (bd), rim, snare (sd), clap, lowtom, midtom, hightom, hihat (hh), pedalhat,
+// openhat (oh), crash, ride, tambourine, cowbell and shaker, or a note name (see channel.ParseNoteNumber).
+// The settings are key (a number or note name, overriding the key of the name), channel (0-15, default 9),
+// velocity (default 100) and accent (default 127), e.g.
+//
+//	cymbal key=C#3 channel=9 velocity=80: x.......
+//
+// The steps are x (a hit), X (an accented hit) and . or - (a rest). Spaces and | (e.g. as bar lines) are ignored.
+// Empty lines and lines starting with # are skipped. All rows start together, the pattern is as long as its
+// longest row. A hit lasts half a step at most.
+//
+// Invalid text returns a *SyntaxError, invalid options return an error.
+func Pattern(text string, options ...PatternOption) (*smftrack.SMF, error) {
+	c := patternConfig{resolution: 480, stepsPerBeat: 4, swing: 0.5, repeat: 1, bpm: 120}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	switch {
+	case c.stepsPerBeat == 0 || c.resolution%c.stepsPerBeat != 0:
+		return nil, fmt.Errorf("resolution %v is not divisible by %v steps per beat", c.resolution, c.stepsPerBeat)
+	case c.swing < 0.5 || c.swing >= 1:
+		return nil, fmt.Errorf("invalid swing %v", c.swing)
+	case c.repeat < 1:
+		return nil, fmt.Errorf("invalid repeat count %v", c.repeat)
+	}
+
+	var rows []row
+	var steps int
+
+	for i, line := range strings.Split(text, "\n") {
+		r, ok, err := parseRow(i+1, line)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		rows = append(rows, r)
+
+		if len(r.steps) > steps {
+			steps = len(r.steps)
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rows")
+	}
+
+	stepTicks := uint64(c.resolution / c.stepsPerBeat)
+	delay := uint64(math.Round((c.swing - 0.5) * 2 * float64(stepTicks)))
+
+	if delay >= stepTicks {
+		delay = stepTicks - 1
+	}
+
+	b := smftrack.NewBuilder(c.resolution)
+	t := b.Track().Tempo(c.bpm)
+
+	for i := 0; i < steps*c.repeat; i++ {
+		tick := uint64(i) * stepTicks
+		length := stepTicks / 2
+
+		if i%2 == 1 {
+			tick += delay
+
+			if length > stepTicks-delay {
+				length = stepTicks - delay
+			}
+		}
+
+		if length == 0 {
+			length = 1
+		}
+
+		d := smftrack.Duration(float64(length) / float64(4*uint64(c.resolution)))
+
+		for _, r := range rows {
+			if s := i % steps; s < len(r.steps) && r.steps[s] > 0 {
+				t.At(tick).Channel(r.channel).Note(channel.NoteName(r.key), d, r.steps[s])
+			}
+		}
+	}
+
+	t.At(uint64(steps*c.repeat) * stepTicks)
+	return b.Build()
+}
+
+// parseRow parses the given line with the given number. ok is false for empty lines and comments.
+func parseRow(no int, line string) (r row, ok bool, err error) {
+	runes := []rune(line)
+
+	errorf := func(col int, format string, vals ...interface{}) error {
+		return &SyntaxError{Line: no, Column: col + 1, Msg: fmt.Sprintf(format, vals...)}
+	}
+
+	trimmed := strings.TrimSpace(line)
+
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return r, false, nil
+	}
+
+	colon := -1
+
+	for i, c := range runes {
+		if c == ':' {
+			colon = i
+			break
+		}
+	}
+
+	if colon < 0 {
+		return r, false, errorf(len(runes), "missing ':' after the name")
+	}
+
+	fields := splitFields(runes[:colon])
+
+	if len(fields) == 0 {
+		return r, false, errorf(colon, "missing name")
+	}
+
+	r = row{channel: 9, velocity: 100, accent: 127}
+	name := fields[0]
+	key, known := drumKeys[strings.ToLower(name.text)]
+
+	if !known {
+		if k, err := channel.ParseNoteNumber(name.text); err == nil {
+			key, known = k, true
+		}
+	}
+
+	for _, f := range fields[1:] {
+		eq := strings.IndexRune(f.text, '=')
+
+		if eq < 0 {
+			return r, false, errorf(f.col, "invalid setting %q, expected name=value", f.text)
+		}
+
+		setting, value := f.text[:eq], f.text[eq+1:]
+		valueCol := f.col + len([]rune(setting)) + 1
+
+		switch setting {
+		case "key":
+			k, err := parseKey(value)
+
+			if err != nil {
+				return r, false, errorf(valueCol, "invalid key %q", value)
+			}
+
+			key, known = k, true
+		case "channel":
+			n, err := strconv.ParseUint(value, 10, 8)
+
+			if err != nil || n > 15 {
+				return r, false, errorf(valueCol, "invalid channel %q", value)
+			}
+
+			r.channel = uint8(n)
+		case "velocity", "accent":
+			n, err := strconv.ParseUint(value, 10, 8)
+
+			if err != nil || n == 0 || n > 127 {
+				return r, false, errorf(valueCol, "invalid %s %q", setting, value)
+			}
+
+			if setting == "velocity" {
+				r.velocity = uint8(n)
+			} else {
+				r.accent = uint8(n)
+			}
+		default:
+			return r, false, errorf(f.col, "unknown setting %q", setting)
+		}
+	}
+
+	if !known {
+		return r, false, errorf(name.col, "unknown name %q", name.text)
+	}
+
+	r.key = key
+
+	for i := colon + 1; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case c == 'x':
+			r.steps = append(r.steps, r.velocity)
+		case c == 'X':
+			r.steps = append(r.steps, r.accent)
+		case c == '.' || c == '-':
+			r.steps = append(r.steps, 0)
+		case c == '|' || unicode.IsSpace(c):
+		default:
+			return r, false, errorf(i, "invalid step %q", c)
+		}
+	}
+
+	if len(r.steps) == 0 {
+		return r, false, errorf(colon+1, "missing steps")
+	}
+
+	return r, true, nil
+}
+
+// parseKey parses a key number (0-127) or note name
+func parseKey(s string) (uint8, error) {
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil && n <= 127 {
+		return uint8(n), nil
+	}
+	return channel.ParseNoteNumber(s)
+}
+
+// field is a word of a line with its (0-based) column
+type field struct {
+	text string
+	col  int
+}
+
+// splitFields splits the given runes at white space
+func splitFields(runes []rune) []field {
+	var res []field
+	start := -1
+
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && !unicode.IsSpace(runes[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 {
+			res = append(res, field{text: string(runes[start:i]), col: start})
+			start = -1
+		}
+	}
+
+	return res
+}
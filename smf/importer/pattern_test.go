@@ -0,0 +1,190 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+func trackString(tr *smftrack.Track) string {
+	var bf bytes.Buffer
+
+	for _, ev := range tr.Events() {
+		fmt.Fprintf(&bf, "%v %s\n", ev.AbsTicks, ev.Message)
+	}
+
+	fmt.Fprintf(&bf, "%v end\n", tr.End())
+	return bf.String()
+}
+
+func TestPattern(t *testing.T) {
+	text := `
+# comment
+kick:                x.x.
+snare velocity=90:   .X|-x
+C1 channel=0 accent=110: ..X
+`
+
+	s, err := Pattern(text, Resolution(96), Tempo(100))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := s.Format(), smf.SMF0; got != want {
+		t.Errorf("Format() = %v; want %v", got, want)
+	}
+
+	expected := `0 meta.Tempo BPM: 100.00
+0 channel.NoteOn channel 10 key 36 velocity 100
+12 channel.NoteOff channel 10 key 36
+24 channel.NoteOn channel 10 key 38 velocity 127
+36 channel.NoteOff channel 10 key 38
+48 channel.NoteOn channel 10 key 36 velocity 100
+48 channel.NoteOn channel 1 key 24 velocity 110
+60 channel.NoteOff channel 10 key 36
+60 channel.NoteOff channel 1 key 24
+72 channel.NoteOn channel 10 key 38 velocity 90
+84 channel.NoteOff channel 10 key 38
+96 end
+`
+
+	if got, want := trackString(s.Track(0)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestPatternSwing(t *testing.T) {
+	tests := []struct {
+		options  []PatternOption
+		expected string
+	}{
+		{
+			[]PatternOption{Swing(2.0 / 3)},
+			`0 channel.NoteOn channel 10 key 42 velocity 100
+60 channel.NoteOff channel 10 key 42
+160 channel.NoteOn channel 10 key 42 velocity 100
+220 channel.NoteOff channel 10 key 42
+240 channel.NoteOn channel 10 key 42 velocity 100
+300 channel.NoteOff channel 10 key 42
+400 channel.NoteOn channel 10 key 42 velocity 100
+460 channel.NoteOff channel 10 key 42
+480 end
+`,
+		},
+		{
+			// the swung step is shortened, so that it ends before the next step
+			[]PatternOption{Swing(0.9), StepsPerBeat(2)},
+			`0 channel.NoteOn channel 10 key 42 velocity 100
+120 channel.NoteOff channel 10 key 42
+432 channel.NoteOn channel 10 key 42 velocity 100
+480 channel.NoteOff channel 10 key 42
+480 channel.NoteOn channel 10 key 42 velocity 100
+600 channel.NoteOff channel 10 key 42
+912 channel.NoteOn channel 10 key 42 velocity 100
+960 channel.NoteOff channel 10 key 42
+960 end
+`,
+		},
+		{
+			// the swing continues over the repetitions of a pattern with an odd number of steps
+			[]PatternOption{Swing(0.75), Repeat(2), Resolution(8), StepsPerBeat(2)},
+			`0 channel.NoteOn channel 10 key 42 velocity 100
+2 channel.NoteOff channel 10 key 42
+6 channel.NoteOn channel 10 key 42 velocity 100
+8 channel.NoteOff channel 10 key 42
+8 channel.NoteOn channel 10 key 42 velocity 100
+10 channel.NoteOff channel 10 key 42
+14 channel.NoteOn channel 10 key 42 velocity 100
+16 channel.NoteOff channel 10 key 42
+16 channel.NoteOn channel 10 key 42 velocity 100
+18 channel.NoteOff channel 10 key 42
+22 channel.NoteOn channel 10 key 42 velocity 100
+24 channel.NoteOff channel 10 key 42
+24 end
+`,
+		},
+	}
+
+	for i, test := range tests {
+		text := "hh: xxxx"
+
+		if i == 2 {
+			text = "hh: xxx"
+		}
+
+		s, err := Pattern(text, test.options...)
+
+		if err != nil {
+			t.Fatalf("[%v] Error: %v", i, err)
+		}
+
+		// skip the tempo
+		tr := s.Track(0)
+		tr.SetEvents(tr.Events()[1:])
+
+		if got, want := trackString(tr), test.expected; got != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+	}
+}
+
+func TestPatternErrors(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"kick x...", `line 1, column 10: missing ':' after the name`},
+		{"\n  : x...", `line 2, column 3: missing name`},
+		{"kick: x.\n\n  cowbel: x", `line 3, column 3: unknown name "cowbel"`},
+		{"kick: x.o.", `line 1, column 9: invalid step 'o'`},
+		{"kick:   ", `line 1, column 6: missing steps`},
+		{"kick velocity=128: x", `line 1, column 15: invalid velocity "128"`},
+		{"kick accent=0: x", `line 1, column 13: invalid accent "0"`},
+		{"kick channel=16: x", `line 1, column 14: invalid channel "16"`},
+		{"x key=H2: x", `line 1, column 7: invalid key "H2"`},
+		{"kick gate=1: x", `line 1, column 6: unknown setting "gate"`},
+		{"kick loud: x", `line 1, column 6: invalid setting "loud", expected name=value`},
+		{"kick:\u00a0x.o", `line 1, column 9: invalid step 'o'`},
+	}
+
+	for _, test := range tests {
+		_, err := Pattern(test.text)
+
+		if _, ok := err.(*SyntaxError); !ok {
+			t.Errorf("Pattern(%q) = %v; want *SyntaxError", test.text, err)
+			continue
+		}
+
+		if got, want := err.Error(), test.expected; got != want {
+			t.Errorf("Pattern(%q) = %q; want %q", test.text, got, want)
+		}
+	}
+}
+
+func TestPatternOptions(t *testing.T) {
+	tests := []struct {
+		options  []PatternOption
+		expected string
+	}{
+		{[]PatternOption{StepsPerBeat(7)}, "resolution 480 is not divisible by 7 steps per beat"},
+		{[]PatternOption{Swing(0.4)}, "invalid swing 0.4"},
+		{[]PatternOption{Swing(1)}, "invalid swing 1"},
+		{[]PatternOption{Repeat(0)}, "invalid repeat count 0"},
+	}
+
+	for _, test := range tests {
+		_, err := Pattern("kick: x", test.options...)
+
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("got %v; want %q", err, test.expected)
+		}
+	}
+
+	if _, err := Pattern("# nothing\n"); err == nil || err.Error() != "no rows" {
+		t.Errorf("got %v; want %q", err, "no rows")
+	}
+}
@@ -0,0 +1,224 @@
+package smf
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Ratio is the ratio of a tuplet: Num notes are played in the time of Den notes, e.g. Ratio{3, 2} for triplets.
+// The zero value means no tuplet.
+type Ratio struct {
+	Num, Den uint32
+}
+
+// tuplet ratios
+var (
+	Straight   = Ratio{1, 1}
+	Triplet    = Ratio{3, 2}
+	Quintuplet = Ratio{5, 4}
+	Sextuplet  = Ratio{6, 4}
+	Septuplet  = Ratio{7, 4}
+)
+
+func (r Ratio) isStraight() bool {
+	return r == Ratio{} || r.Num == r.Den
+}
+
+// Rounding is the rounding mode for note values that are not a whole number of ticks
+type Rounding int
+
+// rounding modes
+const (
+	// RoundNearest rounds to the nearest tick, halves away from zero
+	RoundNearest Rounding = iota
+
+	// RoundDown rounds down to the previous tick
+	RoundDown
+
+	// RoundUp rounds up to the next tick
+	RoundUp
+)
+
+// NoteValue is a note value of 1/Denominator of a whole note with the given number of dots, played as tuplet
+// of the given ratio.
+type NoteValue struct {
+	Denominator int
+	Dots        int
+	Tuplet      Ratio
+}
+
+// String returns the note value in the notation of ParseNoteValue, e.g. "1/8." or "1/12".
+func (v NoteValue) String() string {
+	den, tuplet := uint64(v.Denominator), ""
+
+	if !v.Tuplet.isStraight() {
+		if v.Tuplet.Den != 0 && den*uint64(v.Tuplet.Num)%uint64(v.Tuplet.Den) == 0 {
+			den = den * uint64(v.Tuplet.Num) / uint64(v.Tuplet.Den)
+		} else {
+			tuplet = fmt.Sprintf("(%v:%v)", v.Tuplet.Num, v.Tuplet.Den)
+		}
+	}
+
+	return fmt.Sprintf("1/%v%s%s", den, strings.Repeat(".", v.Dots), tuplet)
+}
+
+// ParseNoteValue parses a note value like "1/4", "1/8." (dotted eighth), "1/16.." (double dotted sixteenth),
+// "1/12" (triplet eighth) or "1/8(5:4)" (quintuplet eighth).
+// A denominator that is not a power of 2 is expressed as tuplet of the next lower power of 2,
+// e.g. "1/12" returns NoteValue{Denominator: 8, Tuplet: Ratio{3, 2}}.
+func ParseNoteValue(s string) (v NoteValue, err error) {
+	str := strings.TrimSpace(s)
+
+	if !strings.HasPrefix(str, "1/") {
+		return v, fmt.Errorf("invalid note value %q: must start with 1/", s)
+	}
+
+	str = str[2:]
+
+	if i := strings.Index(str, "("); i >= 0 {
+		if !strings.HasSuffix(str, ")") {
+			return v, fmt.Errorf("invalid note value %q: missing ) of tuplet", s)
+		}
+
+		parts := strings.Split(str[i+1:len(str)-1], ":")
+
+		if len(parts) != 2 {
+			return v, fmt.Errorf("invalid note value %q: tuplet must be of the form (n:m)", s)
+		}
+
+		num, errNum := strconv.ParseUint(parts[0], 10, 32)
+		den, errDen := strconv.ParseUint(parts[1], 10, 32)
+
+		if errNum != nil || errDen != nil || num == 0 || den == 0 {
+			return v, fmt.Errorf("invalid note value %q: invalid tuplet", s)
+		}
+
+		v.Tuplet = Ratio{uint32(num), uint32(den)}
+		str = str[:i]
+	}
+
+	for strings.HasSuffix(str, ".") {
+		v.Dots++
+		str = str[:len(str)-1]
+	}
+
+	den, err := strconv.ParseUint(str, 10, 31)
+
+	if err != nil || den == 0 {
+		return NoteValue{}, fmt.Errorf("invalid note value %q: invalid denominator", s)
+	}
+
+	pow := uint64(1)
+
+	for pow*2 <= den {
+		pow *= 2
+	}
+
+	if pow != den {
+		if !v.Tuplet.isStraight() {
+			return NoteValue{}, fmt.Errorf("invalid note value %q: tuplet of a denominator that is not a power of 2", s)
+		}
+
+		g := gcd(den, pow)
+		v.Tuplet = Ratio{uint32(den / g), uint32(pow / g)}
+	}
+
+	v.Denominator = int(pow)
+	return v, nil
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Quarter returns the ticks for a quarter note
+func (q MetricTicks) Quarter() uint32 {
+	return q.Ticks4th()
+}
+
+// noteTicks returns the ticks of the note value as exact fraction
+func (q MetricTicks) noteTicks(denominator int, dots int, tuplet Ratio) (*big.Rat, error) {
+	if denominator <= 0 {
+		return nil, fmt.Errorf("invalid denominator %v", denominator)
+	}
+
+	if dots < 0 || dots > 16 {
+		return nil, fmt.Errorf("invalid number of dots %v", dots)
+	}
+
+	if tuplet.isStraight() {
+		tuplet = Straight
+	}
+
+	if tuplet.Num == 0 || tuplet.Den == 0 {
+		return nil, fmt.Errorf("invalid tuplet %v:%v", tuplet.Num, tuplet.Den)
+	}
+
+	// a note with n dots lasts (2^(n+1) - 1) / 2^n of the undotted note
+	num := new(big.Int).SetUint64(4 * uint64(q.Number()) * (1<<uint(dots+1) - 1))
+	num.Mul(num, new(big.Int).SetUint64(uint64(tuplet.Den)))
+	den := new(big.Int).SetUint64(uint64(denominator) * (1 << uint(dots)))
+	den.Mul(den, new(big.Int).SetUint64(uint64(tuplet.Num)))
+
+	return new(big.Rat).SetFrac(num, den), nil
+}
+
+// Note returns the ticks of a note of 1/denominator of a whole note with the given number of dots, played as tuplet
+// of the given ratio (the zero value for no tuplet), e.g. Note(8, 1, Straight) for a dotted eighth.
+// An error is returned, if the note value is not a whole number of ticks in the resolution (see NoteRounded).
+func (q MetricTicks) Note(denominator int, dots int, tuplet Ratio) (uint32, error) {
+	r, err := q.noteTicks(denominator, dots, tuplet)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !r.IsInt() {
+		return 0, fmt.Errorf("note value %v is %v ticks at %v", NoteValue{denominator, dots, tuplet}, r.RatString(), q)
+	}
+
+	if !r.Num().IsUint64() || r.Num().Uint64() > 0xFFFFFFFF {
+		return 0, fmt.Errorf("note value %v is too long", NoteValue{denominator, dots, tuplet})
+	}
+
+	return uint32(r.Num().Uint64()), nil
+}
+
+// NoteRounded is like Note but rounds the ticks of note values that are not a whole number of ticks with the
+// given rounding mode.
+func (q MetricTicks) NoteRounded(denominator int, dots int, tuplet Ratio, rounding Rounding) (uint32, error) {
+	r, err := q.noteTicks(denominator, dots, tuplet)
+
+	if err != nil {
+		return 0, err
+	}
+
+	ticks, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+
+	if rem.Sign() != 0 {
+		switch rounding {
+		case RoundUp:
+			ticks.Add(ticks, big.NewInt(1))
+		case RoundNearest:
+			if rem.Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+				ticks.Add(ticks, big.NewInt(1))
+			}
+		}
+	}
+
+	if !ticks.IsUint64() || ticks.Uint64() > 0xFFFFFFFF {
+		return 0, fmt.Errorf("note value %v is too long", NoteValue{denominator, dots, tuplet})
+	}
+
+	return uint32(ticks.Uint64()), nil
+}
+
+// NoteValue returns the ticks of the given note value, see Note
+func (q MetricTicks) NoteValue(v NoteValue) (uint32, error) {
+	return q.Note(v.Denominator, v.Dots, v.Tuplet)
+}
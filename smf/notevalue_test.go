@@ -0,0 +1,157 @@
+package smf
+
+import "testing"
+
+func TestNote(t *testing.T) {
+	tests := []struct {
+		resolution  MetricTicks
+		denominator int
+		dots        int
+		tuplet      Ratio
+		expected    uint32
+		err         bool
+	}{
+		{480, 4, 0, Straight, 480, false},
+		{480, 8, 1, Straight, 360, false},
+		{480, 8, 0, Triplet, 160, false},
+		{480, 16, 2, Ratio{}, 210, false},
+		{480, 16, 0, Quintuplet, 96, false},
+		{480, 1, 0, Straight, 1920, false},
+		{480, 64, 1, Straight, 45, false},
+		{480, 128, 1, Straight, 0, true},
+		{96, 4, 0, Triplet, 64, false},
+		{96, 8, 0, Triplet, 32, false},
+		{96, 16, 0, Triplet, 16, false},
+		{96, 32, 0, Triplet, 8, false},
+		{96, 512, 0, Triplet, 0, true},
+		{96, 16, 0, Quintuplet, 0, true},
+		{96, 32, 1, Straight, 18, false},
+		{96, 128, 1, Straight, 0, true},
+		{100, 8, 0, Triplet, 0, true},
+		{0, 4, 0, Straight, 960, false},
+		{960, 12, 0, Straight, 320, false},
+		{960, 0, 0, Straight, 0, true},
+		{960, 4, -1, Straight, 0, true},
+		{960, 4, 0, Ratio{3, 0}, 0, true},
+	}
+
+	for i, test := range tests {
+		got, err := test.resolution.Note(test.denominator, test.dots, test.tuplet)
+
+		if test.err {
+			if err == nil {
+				t.Errorf("[%v] expected an error, got %v", i, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[%v] Error: %v", i, err)
+			continue
+		}
+
+		if got != test.expected {
+			t.Errorf("[%v] Note(%v, %v, %v) at %v = %v; want %v", i, test.denominator, test.dots, test.tuplet, test.resolution, got, test.expected)
+		}
+	}
+}
+
+func TestNoteRounded(t *testing.T) {
+	tests := []struct {
+		resolution  MetricTicks
+		denominator int
+		dots        int
+		tuplet      Ratio
+		rounding    Rounding
+		expected    uint32
+	}{
+		// 100/3 ticks
+		{100, 8, 0, Triplet, RoundNearest, 33},
+		{100, 8, 0, Triplet, RoundDown, 33},
+		{100, 8, 0, Triplet, RoundUp, 34},
+		// 1/2 tick
+		{96, 512, 0, Triplet, RoundNearest, 1},
+		{96, 512, 0, Triplet, RoundDown, 0},
+		{96, 512, 0, Triplet, RoundUp, 1},
+		// 19.2 ticks
+		{96, 16, 0, Quintuplet, RoundNearest, 19},
+		{96, 16, 0, Quintuplet, RoundDown, 19},
+		{96, 16, 0, Quintuplet, RoundUp, 20},
+		// 4.5 ticks
+		{96, 128, 1, Straight, RoundNearest, 5},
+		{96, 128, 1, Straight, RoundDown, 4},
+		// exact
+		{96, 8, 0, Triplet, RoundUp, 32},
+	}
+
+	for i, test := range tests {
+		got, err := test.resolution.NoteRounded(test.denominator, test.dots, test.tuplet, test.rounding)
+
+		if err != nil {
+			t.Errorf("[%v] Error: %v", i, err)
+			continue
+		}
+
+		if got != test.expected {
+			t.Errorf("[%v] NoteRounded(%v, %v, %v, %v) = %v; want %v", i, test.denominator, test.dots, test.tuplet, test.rounding, got, test.expected)
+		}
+	}
+}
+
+func TestParseNoteValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected NoteValue
+		str      string
+	}{
+		{"1/4", NoteValue{4, 0, Ratio{}}, "1/4"},
+		{"1/8.", NoteValue{8, 1, Ratio{}}, "1/8."},
+		{" 1/16.. ", NoteValue{16, 2, Ratio{}}, "1/16.."},
+		{"1/12", NoteValue{8, 0, Triplet}, "1/12"},
+		{"1/6.", NoteValue{4, 1, Triplet}, "1/6."},
+		{"1/20", NoteValue{16, 0, Quintuplet}, "1/20"},
+		{"1/8(5:4)", NoteValue{8, 0, Quintuplet}, "1/10"},
+		{"1/4(2:3)", NoteValue{4, 0, Ratio{2, 3}}, "1/4(2:3)"},
+		{"1/1", NoteValue{1, 0, Ratio{}}, "1/1"},
+	}
+
+	for _, test := range tests {
+		got, err := ParseNoteValue(test.input)
+
+		if err != nil {
+			t.Errorf("ParseNoteValue(%q): Error: %v", test.input, err)
+			continue
+		}
+
+		if got != test.expected {
+			t.Errorf("ParseNoteValue(%q) = %#v; want %#v", test.input, got, test.expected)
+		}
+
+		if got.String() != test.str {
+			t.Errorf("ParseNoteValue(%q).String() = %q; want %q", test.input, got.String(), test.str)
+		}
+	}
+
+	for _, input := range []string{"", "1/", "1/0", "2/4", "1/x", "1/4(3:2", "1/4(3)", "1/4(0:2)", "1/12(3:2)", "1/.8"} {
+		if v, err := ParseNoteValue(input); err == nil {
+			t.Errorf("ParseNoteValue(%q) = %v; expected an error", input, v)
+		}
+	}
+
+	// parsed values at a resolution where small triplets don't divide
+	var resolution MetricTicks = 96
+
+	for input, expected := range map[string]uint32{"1/8.": 72, "1/12": 32, "1/24": 16, "1/48": 8} {
+		v, _ := ParseNoteValue(input)
+
+		if got, err := resolution.NoteValue(v); err != nil || got != expected {
+			t.Errorf("NoteValue(%q) = %v, %v; want %v", input, got, err, expected)
+		}
+	}
+
+	v, _ := ParseNoteValue("1/768")
+
+	if _, err := resolution.NoteValue(v); err == nil {
+		t.Errorf("NoteValue(1/768) at 96 ticks: expected an error")
+	}
+}
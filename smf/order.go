@@ -0,0 +1,200 @@
+package smf
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+// EventClass is a class of messages for the ordering of the messages at the same tick (see OrderPolicy)
+type EventClass int
+
+const (
+	// MetaEvents are meta messages
+	MetaEvents EventClass = iota + 1
+
+	// SysExEvents are sysex messages and escaped messages
+	SysExEvents
+
+	// BankSelects are control change messages of the controllers 0 and 32
+	BankSelects
+
+	// ProgramChanges are program change messages
+	ProgramChanges
+
+	// Controllers are control change messages of all other controllers
+	Controllers
+
+	// OtherChannelEvents are pitch bend, aftertouch and polyphonic aftertouch messages
+	OtherChannelEvents
+
+	// NoteOffs are note off messages, including note on messages with a velocity of 0
+	NoteOffs
+
+	// NoteOns are note on messages with a velocity above 0
+	NoteOns
+)
+
+// ClassOf returns the class of the given message
+func ClassOf(msg midi.Message) EventClass {
+	switch v := msg.(type) {
+	case meta.Message:
+		return MetaEvents
+	case channel.ControlChange:
+		if v.Controller() == 0 || v.Controller() == 32 {
+			return BankSelects
+		}
+		return Controllers
+	case channel.ProgramChange:
+		return ProgramChanges
+	case channel.NoteOn:
+		if v.Velocity() == 0 {
+			return NoteOffs
+		}
+		return NoteOns
+	case channel.NoteOff, channel.NoteOffVelocity:
+		return NoteOffs
+	case channel.Message:
+		return OtherChannelEvents
+	}
+	return SysExEvents
+}
+
+// OrderPolicy defines the order in which the messages at the same tick are written (see smfwriter.Order).
+// The messages are ordered by the position of their class within Classes; messages of classes that are not listed
+// are written after them. Messages of the same class keep their order. Note messages on the same channel and key
+// stay together: a note off message that ends a note that started before the tick is written before the note on
+// messages of the key, so that a note that is retriggered at the same tick is ended before it is played again
+// (see SortSounding), the other note messages of the key keep their order.
+//
+// The zero value (PreserveInput) keeps the order of all messages.
+type OrderPolicy struct {
+	Classes []EventClass
+}
+
+var (
+	// PreserveInput writes the messages in the order in which they are written, byte for byte
+	PreserveInput = OrderPolicy{}
+
+	// Safe writes the setup of the channels before the notes and the note off messages before the note on messages,
+	// so that a synthesizer is set up before a note is played and frees its voices before new notes are played:
+	// meta, sysex, bank select, program change, controller, other channel messages, note off, note on
+	Safe = OrderPolicy{Classes: []EventClass{
+		MetaEvents, SysExEvents, BankSelects, ProgramChanges, Controllers, OtherChannelEvents, NoteOffs, NoteOns,
+	}}
+
+	// LegatoFriendly is like Safe, but writes the note off messages after the note on messages of other keys,
+	// so that monophonic synthesizers play consecutive notes legato:
+	// meta, sysex, bank select, program change, controller, other channel messages, note on, note off
+	LegatoFriendly = OrderPolicy{Classes: []EventClass{
+		MetaEvents, SysExEvents, BankSelects, ProgramChanges, Controllers, OtherChannelEvents, NoteOns, NoteOffs,
+	}}
+)
+
+// Sort sorts the given messages, which are at the same tick, according to the policy.
+// It assumes that no notes are sounding before the tick (see SortSounding).
+func (p OrderPolicy) Sort(msgs []midi.Message) {
+	p.SortSounding(msgs, nil)
+}
+
+// SortSounding sorts the given messages, which are at the same tick, according to the policy. The given NoteTracker
+// holds the notes that are sounding before the tick (nil for none): a note off message that ends one of them is
+// written before the note on messages of its key, while a note on and a note off message of a note that starts and
+// ends at the tick keep their order.
+func (p OrderPolicy) SortSounding(msgs []midi.Message, sounding *channel.NoteTracker) {
+	if len(p.Classes) == 0 || len(msgs) < 2 {
+		return
+	}
+
+	// within the note messages on the same channel and key, the note offs that end earlier notes come first
+	var positions = map[[2]uint8][]int{}
+
+	for i, msg := range msgs {
+		if k, isNote := noteKey(msg); isNote {
+			positions[k] = append(positions[k], i)
+		}
+	}
+
+	for k, pos := range positions {
+		var earlier uint8
+
+		if sounding != nil {
+			earlier = sounding.Count(k[0], k[1])
+		}
+
+		var ended, rest []midi.Message
+
+		for _, i := range pos {
+			if ClassOf(msgs[i]) == NoteOffs && earlier > 0 {
+				earlier--
+				ended = append(ended, msgs[i])
+				continue
+			}
+			rest = append(rest, msgs[i])
+		}
+
+		for j, msg := range append(ended, rest...) {
+			msgs[pos[j]] = msg
+		}
+	}
+
+	var rank = map[EventClass]int{}
+
+	for i, c := range p.Classes {
+		if _, has := rank[c]; !has {
+			rank[c] = i
+		}
+	}
+
+	// the note messages on the same channel and key get the rank of the first one, so that they stay together
+	var keys = map[[2]uint8]int{}
+	var ranks = make([]int, len(msgs))
+
+	for i, msg := range msgs {
+		r, has := rank[ClassOf(msg)]
+
+		if !has {
+			r = len(p.Classes)
+		}
+
+		if k, isNote := noteKey(msg); isNote {
+			if first, has := keys[k]; has {
+				r = first
+			} else {
+				keys[k] = r
+			}
+		}
+
+		ranks[i] = r
+	}
+
+	sort.Stable(byRank{msgs, ranks})
+}
+
+// noteKey returns the channel and key of a note message
+func noteKey(msg midi.Message) (k [2]uint8, isNote bool) {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		return [2]uint8{v.Channel(), v.Key()}, true
+	case channel.NoteOff:
+		return [2]uint8{v.Channel(), v.Key()}, true
+	case channel.NoteOffVelocity:
+		return [2]uint8{v.Channel(), v.Key()}, true
+	}
+	return k, false
+}
+
+type byRank struct {
+	msgs  []midi.Message
+	ranks []int
+}
+
+func (b byRank) Len() int           { return len(b.msgs) }
+func (b byRank) Less(i, j int) bool { return b.ranks[i] < b.ranks[j] }
+
+func (b byRank) Swap(i, j int) {
+	b.msgs[i], b.msgs[j] = b.msgs[j], b.msgs[i]
+	b.ranks[i], b.ranks[j] = b.ranks[j], b.ranks[i]
+}
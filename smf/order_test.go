@@ -0,0 +1,39 @@
+package smf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+func TestSortSounding(t *testing.T) {
+	ch := channel.Channel0
+
+	var sounding channel.NoteTracker
+	sounding.Track(ch.NoteOn(64, 100))
+
+	tests := []struct {
+		sounding *channel.NoteTracker
+		input    []midi.Message
+		expected []midi.Message
+	}{
+		// a note without duration keeps its order
+		{nil, []midi.Message{ch.NoteOn(62, 100), ch.NoteOff(62)}, []midi.Message{ch.NoteOn(62, 100), ch.NoteOff(62)}},
+		{&sounding, []midi.Message{ch.NoteOn(62, 100), ch.NoteOff(62)}, []midi.Message{ch.NoteOn(62, 100), ch.NoteOff(62)}},
+		// the note off of a sounding note comes before the note on of the retriggered note
+		{&sounding, []midi.Message{ch.NoteOn(64, 90), ch.NoteOff(64)}, []midi.Message{ch.NoteOff(64), ch.NoteOn(64, 90)}},
+		// a note without duration after the end of a sounding note
+		{&sounding, []midi.Message{ch.NoteOn(64, 90), ch.NoteOff(64), ch.NoteOff(64)}, []midi.Message{ch.NoteOff(64), ch.NoteOn(64, 90), ch.NoteOff(64)}},
+	}
+
+	for i, test := range tests {
+		msgs := append([]midi.Message{}, test.input...)
+		Safe.SortSounding(msgs, test.sounding)
+
+		if !reflect.DeepEqual(msgs, test.expected) {
+			t.Errorf("[%v] got %v; wanted %v", i, msgs, test.expected)
+		}
+	}
+}
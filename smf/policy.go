@@ -0,0 +1,136 @@
+package smf
+
+import "fmt"
+
+// Category is a category of problems within SMF data (see Policy)
+type Category int
+
+const (
+	// StructuralErrors are corruptions of the data that can be recovered from, e.g. garbage between the chunks,
+	// variable length quantities that are too long or tracks that don't match their declared length
+	StructuralErrors Category = iota + 1
+
+	// PlacementViolations are messages at places where the SMF specification does not allow them, e.g. a key signature
+	// in a track other than the first track of a SMF1
+	PlacementViolations
+
+	// RangeViolations are values that are out of the range of the SMF specification, e.g. a tempo of 0
+	RangeViolations
+
+	// UnknownData is data that the SMF specification allows as an extension, but that is not understood,
+	// e.g. unknown chunks and undefined meta messages
+	UnknownData
+
+	// TruncatedData is data that ends prematurely, within a track or before all tracks
+	TruncatedData
+
+	// Suspicious is data that does not violate the SMF specification, but is likely a mistake, e.g. tracks that end
+	// at very different ticks. It is always reported as a warning, whatever the policy.
+	Suspicious
+)
+
+var categories = map[Category]string{
+	StructuralErrors:    "structural error",
+	PlacementViolations: "placement violation",
+	RangeViolations:     "range violation",
+	UnknownData:         "unknown data",
+	TruncatedData:       "truncated data",
+	Suspicious:          "suspicious data",
+}
+
+// String returns the name of the category
+func (c Category) String() string {
+	if s, has := categories[c]; has {
+		return s
+	}
+	return fmt.Sprintf("category %d", int(c))
+}
+
+// Action is the handling of a Category of problems
+type Action int
+
+const (
+	// Fail lets the problem be an error
+	Fail Action = iota
+
+	// Warn reports the problem as a warning and recovers from it
+	Warn
+
+	// Ignore recovers from the problem silently
+	Ignore
+)
+
+var actions = map[Action]string{
+	Fail:   "fail",
+	Warn:   "warn",
+	Ignore: "ignore",
+}
+
+// String returns the name of the action
+func (a Action) String() string {
+	if s, has := actions[a]; has {
+		return s
+	}
+	return fmt.Sprintf("action %d", int(a))
+}
+
+// Policy defines how problems within SMF data are handled, by category.
+// The zero value fails on every problem (see Strict).
+type Policy struct {
+	StructuralErrors    Action
+	PlacementViolations Action
+	RangeViolations     Action
+	UnknownData         Action
+	TruncatedData       Action
+}
+
+// Action returns the action for the given category. It returns Warn for Suspicious and Fail for an unknown category.
+func (p Policy) Action(c Category) Action {
+	switch c {
+	case StructuralErrors:
+		return p.StructuralErrors
+	case PlacementViolations:
+		return p.PlacementViolations
+	case RangeViolations:
+		return p.RangeViolations
+	case UnknownData:
+		return p.UnknownData
+	case TruncatedData:
+		return p.TruncatedData
+	case Suspicious:
+		return Warn
+	default:
+		return Fail
+	}
+}
+
+// Warns returns true, if the policy reports any category as warnings
+func (p Policy) Warns() bool {
+	return p.StructuralErrors == Warn || p.PlacementViolations == Warn || p.RangeViolations == Warn ||
+		p.UnknownData == Warn || p.TruncatedData == Warn
+}
+
+var (
+	// Strict fails on every problem
+	Strict = Policy{}
+
+	// Default only fails on truncated data and recovers silently from all other problems, so that everything that
+	// can be read is read, e.g. values out of range are clamped
+	Default = Policy{
+		StructuralErrors:    Ignore,
+		PlacementViolations: Ignore,
+		RangeViolations:     Ignore,
+		UnknownData:         Ignore,
+		TruncatedData:       Fail,
+	}
+
+	// Permissive recovers from corrupt and truncated data and values out of range with warnings, and ignores
+	// misplaced messages and unknown data
+	Permissive = Policy{
+		StructuralErrors:    Warn,
+		PlacementViolations: Ignore,
+		RangeViolations:     Warn,
+		UnknownData:         Ignore,
+		TruncatedData:       Warn,
+	}
+)
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/midibinary"
 
 	"github.com/gomidi/midi"
 )
@@ -113,6 +114,21 @@ type Chunk struct {
 	data []byte
 }
 
+// IsChunkType returns true, if the given bytes could be a chunk type, i.e. 4 printable ASCII characters
+func IsChunkType(typ []byte) bool {
+	if len(typ) != 4 {
+		return false
+	}
+
+	for _, b := range typ {
+		if b < 0x20 || b > 0x7E {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Len returns the length of the chunk body
 func (c *Chunk) Len() int {
 	return len(c.data)
@@ -158,7 +174,8 @@ func (c *Chunk) WriteTo(wr io.Writer) (int64, error) {
 
 // ReadHeader reads the header from the given reader
 // returns the length of the following body
-// for errors, length of 0 is returned
+// for errors, length of 0 is returned: io.EOF, if the reader ends before the header and io.ErrUnexpectedEOF,
+// if it ends within the header
 func (c *Chunk) ReadHeader(rd io.Reader) (length uint32, err error) {
 	c.typ, err = midilib.ReadNBytes(4, rd)
 
@@ -167,7 +184,14 @@ func (c *Chunk) ReadHeader(rd io.Reader) (length uint32, err error) {
 		return
 	}
 
-	return midilib.ReadUint32(rd)
+	length, err = midibinary.ReadUint32(rd)
+
+	// the data ends within the header
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return
 }
 
 // Write writes the given bytes to the body of the chunk
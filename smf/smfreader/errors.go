@@ -1,12 +1,18 @@
 package smfreader
 
-import "errors"
+import (
+	"errors"
+	"io"
+
+	"github.com/gomidi/midi/smf"
+)
 
 var (
 	errUnsupportedSMFFormat  = errors.New("The SMF format was not expected.")
 	errExpectedMthd          = errors.New("Expected SMF Midi header.")
 	errBadSizeChunk          = errors.New("Chunk was an unexpected size.")
 	errInterruptedByCallback = errors.New("interrupted by callback")
-	// ErrMissing is the error returned, if there is no more data, but tracks are missing
-	ErrMissing = errors.New("incomplete, tracks missing")
+	// ErrMissing is the error returned, if there is no more data, but tracks are missing. It wraps
+	// io.ErrUnexpectedEOF.
+	ErrMissing error = &Error{Code: MissingTracks, Category: smf.TruncatedData, Track: -1, Message: "incomplete, tracks missing", Err: io.ErrUnexpectedEOF}
 )
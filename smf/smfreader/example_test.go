@@ -59,11 +59,29 @@ func Example() {
 	}
 
 	// Output:
-	// 0 channel.Pitchbend channel 2 value 5000 absValue 13192
-	// 0 channel.NoteOn channel 2 key 65 velocity 90
+	// 0 channel.Pitchbend channel 3 value 5000 absValue 13192
+	// 0 channel.NoteOn channel 3 key 65 velocity 90
 	// [0] NoteOn at channel 2: key 65 velocity 90
-	// 2 channel.NoteOff channel 2 key 65
+	// 2 channel.NoteOff channel 3 key 65
 	// [2] NoteOff at channel 2: key 65
 	// 4 meta.EndOfTrack
 
 }
+
+func ExampleEvents() {
+	rd := smfreader.New(mkMIDI())
+
+	for pos, msg := range smfreader.Events(rd) {
+		fmt.Printf("track %v at %v: %s\n", pos.Track, pos.AbsTicks, msg)
+	}
+
+	if err := smfreader.ErrOf(rd); err != nil {
+		panic("error: " + err.Error())
+	}
+
+	// Output:
+	// track 0 at 0: channel.Pitchbend channel 3 value 5000 absValue 13192
+	// track 0 at 0: channel.NoteOn channel 3 key 65 velocity 90
+	// track 0 at 2: channel.NoteOff channel 3 key 65
+	// track 0 at 6: meta.EndOfTrack
+}
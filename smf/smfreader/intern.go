@@ -0,0 +1,52 @@
+package smfreader
+
+import (
+	"sync"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+// Interner deduplicates identical messages of one or more readers (see Intern).
+// This is safe, since the messages are immutable values: two identical messages can't be told apart.
+// The zero value is not usable, use NewInterner. An Interner may be shared by readers in different goroutines.
+type Interner struct {
+	mx       sync.Mutex
+	messages map[midi.Message]midi.Message
+}
+
+// NewInterner returns a new, empty Interner
+func NewInterner() *Interner {
+	return &Interner{messages: map[midi.Message]midi.Message{}}
+}
+
+// Len returns the number of distinct messages that are held by the Interner
+func (i *Interner) Len() int {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	return len(i.messages)
+}
+
+// intern returns the first message that has been interned and is identical to the given one.
+// Messages that are not interned are returned as they are.
+func (i *Interner) intern(m midi.Message) midi.Message {
+	switch m.(type) {
+	case channel.Message,
+		meta.Text, meta.Lyric, meta.Marker, meta.Cuepoint, meta.Copyright, meta.Track, meta.Sequence,
+		meta.Program, meta.Device:
+	default:
+		// e.g. sysex messages and meta.SequencerData hold slices that can't be compared
+		return m
+	}
+
+	i.mx.Lock()
+	defer i.mx.Unlock()
+
+	if im, has := i.messages[m]; has {
+		return im
+	}
+
+	i.messages[m] = m
+	return m
+}
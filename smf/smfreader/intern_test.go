@@ -0,0 +1,178 @@
+package smfreader
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// karaokeSMF returns a SMF with the given number of repeated lyric syllables, each with a note and a control change
+func karaokeSMF(syllables int) []byte {
+	var bf bytes.Buffer
+	words := []string{"la", "li", "lu", "oh", "yeah"}
+
+	wr := smfwriter.New(&bf)
+	wr.Write(meta.Track("vocals"))
+	wr.Write(sysex.SysEx([]byte{0x7E, 0x7F, 0x09, 0x01}))
+
+	for i := 0; i < syllables; i++ {
+		wr.SetDelta(120)
+		wr.Write(meta.Lyric(words[i%len(words)]))
+		wr.Write(channel.Channel0.ControlChange(7, uint8(100+i%4)))
+		wr.Write(channel.Channel0.NoteOn(uint8(60+i%5), 100))
+		wr.SetDelta(100)
+		wr.Write(channel.Channel0.NoteOff(uint8(60 + i%5)))
+	}
+
+	wr.Write(meta.EndOfTrack)
+	return bf.Bytes()
+}
+
+// readMessages returns all messages of the given SMF data
+func readMessages(data []byte, options ...Option) ([]midi.Message, error) {
+	rd := New(bytes.NewReader(data), options...)
+	var msgs []midi.Message
+
+	for {
+		m, err := rd.Read()
+
+		if err == smf.ErrFinished {
+			return msgs, nil
+		}
+
+		if err != nil {
+			return msgs, err
+		}
+
+		msgs = append(msgs, m)
+	}
+}
+
+func TestIntern(t *testing.T) {
+	data := karaokeSMF(100)
+	in := NewInterner()
+
+	plain, err := readMessages(data)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	first, err := readMessages(data, Intern(in))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if !reflect.DeepEqual(first, plain) {
+		t.Errorf("interned messages differ from the plain messages")
+	}
+
+	// vocals, 5 syllables, 4 control changes, 5 note ons, 5 note offs (the sysex message and the end of track are not interned)
+	if got, want := in.Len(), 1+5+4+5+5; got != want {
+		t.Errorf("Len() = %v; want %v", got, want)
+	}
+
+	// a second file shares the messages of the first one
+	second, err := readMessages(data, Intern(in))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := in.Len(), 1+5+4+5+5; got != want {
+		t.Errorf("Len() = %v; want %v", got, want)
+	}
+
+	text := func(m midi.Message) *byte {
+		return unsafe.StringData(string(m.(meta.Lyric)))
+	}
+
+	// index 2 is the first lyric, index 22 the sixth, i.e. the same syllable
+	if text(second[2]) != text(first[2]) || text(first[22]) != text(first[2]) {
+		t.Errorf("lyrics are not shared")
+	}
+
+	if text(plain[22]) == text(plain[2]) {
+		t.Errorf("lyrics are shared without interning")
+	}
+}
+
+func TestInternPositions(t *testing.T) {
+	// the positions belong to the events, not to the shared messages
+	data := karaokeSMF(10)
+	rd := New(bytes.NewReader(data), Intern(NewInterner()), RetainPositions())
+	plain := New(bytes.NewReader(data), RetainPositions())
+
+	for {
+		m, err := rd.Read()
+		pm, perr := plain.Read()
+
+		if err != perr {
+			t.Fatalf("Error: %v; want %v", err, perr)
+		}
+
+		if err != nil {
+			break
+		}
+
+		if !reflect.DeepEqual(m, pm) {
+			t.Errorf("Read() = %v; want %v", m, pm)
+		}
+
+		if got, want := *PositionOf(rd), *PositionOf(plain); got != want {
+			t.Errorf("PositionOf(%v) = %+v; want %+v", m, got, want)
+		}
+	}
+}
+
+// BenchmarkIntern loads 1000 similar karaoke files into memory and reports the bytes of the heap that are retained
+// by their messages
+func BenchmarkIntern(b *testing.B) {
+	files := make([][]byte, 1000)
+
+	for i := range files {
+		files[i] = karaokeSMF(90 + i%20)
+	}
+
+	for _, interned := range []bool{false, true} {
+		b.Run(fmt.Sprintf("interned=%v", interned), func(b *testing.B) {
+			var retained int64
+
+			for n := 0; n < b.N; n++ {
+				var options []Option
+
+				if interned {
+					options = append(options, Intern(NewInterner()))
+				}
+
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				loaded := make([][]midi.Message, len(files))
+
+				for i, data := range files {
+					loaded[i], _ = readMessages(data, options...)
+				}
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				retained += int64(after.HeapAlloc) - int64(before.HeapAlloc)
+				runtime.KeepAlive(loaded)
+			}
+
+			b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package smfreader
+
+import (
+	"io"
+	"iter"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/smf"
+)
+
+// EventPos is the position of a message that is yielded by Events
+type EventPos struct {
+	// Track is the number of the track (starting with 0)
+	Track int16
+
+	// Delta is the distance to the previous message of the track in ticks
+	Delta uint32
+
+	// AbsTicks is the position of the message within the track in ticks
+	AbsTicks uint64
+}
+
+// Events returns an iterator over the messages of rd, including the end of track messages, together with their
+// positions. Each step reads the next message, so that no messages are kept in memory, and the iteration can
+// only be done once. When the iteration is finished or stopped, rd is closed, if it is an io.Closer
+// (a reader of this package closes the given io.Reader, if it is an io.ReadCloser).
+//
+// The iteration ends with the first error; the error can be retrieved via ErrOf afterwards.
+func Events(rd smf.Reader) iter.Seq2[EventPos, midi.Message] {
+	return func(yield func(EventPos, midi.Message) bool) {
+		if cl, is := rd.(io.Closer); is {
+			defer cl.Close()
+		}
+
+		var pos = EventPos{Track: -1}
+
+		for {
+			msg, err := rd.Read()
+
+			if err != nil {
+				if r, ok := rd.(*reader); ok && err != smf.ErrFinished {
+					r.iterErr = err
+				}
+				return
+			}
+
+			if tr := rd.Track(); tr != pos.Track {
+				pos = EventPos{Track: tr}
+			}
+
+			pos.Delta = rd.Delta()
+			pos.AbsTicks += uint64(pos.Delta)
+
+			if !yield(pos, msg) {
+				return
+			}
+		}
+	}
+}
+
+// ErrOf returns the error that has ended the iteration of Events over rd.
+// It returns nil, if all messages have been read, the iteration has been stopped by the loop or rd is not a reader
+// of this package.
+func ErrOf(rd smf.Reader) error {
+	r, ok := rd.(*reader)
+	if !ok {
+		return nil
+	}
+	return r.iterErr
+}
@@ -0,0 +1,82 @@
+package smfreader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/internal/examples"
+)
+
+// closeRecorder records whether it has been closed
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestEvents(t *testing.T) {
+	var bf strings.Builder
+
+	for pos, msg := range Events(New(bytes.NewReader(examples.SpecSMF1))) {
+		if pos.Track < 2 {
+			fmt.Fprintf(&bf, "%v %v %v %s\n", pos.Track, pos.Delta, pos.AbsTicks, msg)
+		}
+	}
+
+	expected := `0 0 0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+0 0 0 meta.Tempo BPM: 120.00
+0 384 384 meta.EndOfTrack
+1 0 0 channel.ProgramChange channel 1 program 5
+1 192 192 channel.NoteOn channel 1 key 76 velocity 32
+1 192 384 channel.NoteOff channel 1 key 76
+1 0 384 meta.EndOfTrack
+`
+
+	if got := bf.String(); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+func TestEventsBreak(t *testing.T) {
+	src := &closeRecorder{Reader: bytes.NewReader(examples.SpecSMF1)}
+	rd := New(src)
+	var n int
+
+	for range Events(rd) {
+		n++
+		if n == 3 {
+			break
+		}
+	}
+
+	if !src.closed {
+		t.Errorf("reader has not been closed")
+	}
+
+	if err := ErrOf(rd); err != nil {
+		t.Errorf("ErrOf() = %v; want nil", err)
+	}
+
+	// the iteration ends with an error
+	rd = New(bytes.NewReader(examples.SpecSMF1Missing))
+	n = 0
+
+	for range Events(rd) {
+		n++
+	}
+
+	if err := ErrOf(rd); err != ErrMissing {
+		t.Errorf("ErrOf() = %v; want %v", err, ErrMissing)
+	}
+
+	if n == 0 {
+		t.Errorf("no messages have been yielded before the error")
+	}
+}
@@ -1,5 +1,9 @@
 package smfreader
 
+import (
+	"github.com/gomidi/midi/smf"
+)
+
 // Option is an option for the Reader
 type Option func(*reader)
 
@@ -12,16 +16,102 @@ func debug(l logger) Option {
 // NoteOffVelocity lets the reader differentiate between "fake" noteoff messages
 // (which are in fact noteon messages (typ 9) with velocity of 0) and "real" noteoff messages (typ 8)
 // that have a velocity.
-// The former are returned as NoteOffVelocity messages and keep the given velocity, the later
+// The latter are returned as NoteOffVelocity messages and keep the given velocity, the former
 // are returned as NoteOff messages without velocity. That means in order to get all noteoff messages,
 // there must be checks for NoteOff and NoteOffVelocity (if this option is set).
 // If this option is not set, both kinds are returned as NoteOff (default).
+// Since the smfwriter writes NoteOffVelocity messages as "real" noteoff messages, the release velocities
+// survive a round trip through reading with this option and writing.
 func NoteOffVelocity() Option {
 	return func(rd *reader) {
 		rd.readNoteOffPedantic = true
 	}
 }
 
+// RetainPositions lets the reader keep track of the byte position of each MTrk event
+// within the SMF data. The position of the last read event can then be retrieved via PositionOf.
+// Without this option, no bytes are counted and PositionOf returns nil.
+func RetainPositions() Option {
+	return func(rd *reader) {
+		rd.retainPositions = true
+	}
+}
+
+// Preserve lets the reader keep the raw data of the SMF, so that it can be reproduced byte by byte:
+// the raw MThd chunk, the raw data of each MTrk event (including the delta time, as it was encoded),
+// unknown chunks and any data after the last track. The raw data can be retrieved via PreservedOf.
+// Preserve implies RetainPositions.
+func Preserve() Option {
+	return func(rd *reader) {
+		rd.retainPositions = true
+		rd.preserve = true
+	}
+}
+
+// Policy sets how the reader handles the problems within the SMF data, by category (see smf.Policy):
+// The problems of a category that fails are returned by Read as *Error. The problems of a category that warns are
+// reported as warnings that can be retrieved via WarningsOf or received as they are encountered (see Warnings and
+// OnWarning). The reader recovers from the problems of a category that warns or is ignored, where it is possible:
+//
+//   - structural errors: The data after the first end of track message within the declared length of a track chunk
+//     (e.g. a redundant end of track message) is skipped, as well as garbage between the chunks.
+//   - placement violations: The misplaced messages are returned as they are.
+//   - range violations: The values are clamped (see meta.InvalidValueError).
+//   - unknown data: Unknown chunks are skipped and undefined meta messages are returned as meta.Undefined.
+//   - truncated data: If the data ends before all tracks have been read or in the middle of a track, the reading
+//     is finished with the tracks that have been read so far.
+//
+// Default is smf.Default, or smf.Permissive if Warnings or OnWarning is given.
+func Policy(p smf.Policy) Option {
+	return func(rd *reader) {
+		rd.policy = &p
+	}
+}
+
+// Tolerant lets the reader recover from damaged SMF data instead of failing, where it is possible.
+// It is a shortcut for Policy(smf.Permissive).
+func Tolerant() Option {
+	return Policy(smf.Permissive)
+}
+
+// Warnings sends each warning to the given channel as soon as it is encountered, in addition to collecting it
+// (see WarningsOf). The sending does not block: if the channel is not ready to receive, the warning is not sent,
+// so the channel should be buffered. The warnings that were not sent are counted (see SummaryOf). The reader owns the channel: it closes the channel as soon as Read or
+// ReadHeader returns an error (including smf.ErrFinished), so the channel must not be closed by the caller.
+// Unless another policy is given, Warnings implies Tolerant.
+func Warnings(ch chan<- Warning) Option {
+	return func(rd *reader) {
+		rd.warningsCh = ch
+	}
+}
+
+// OnWarning calls the given function for each warning as soon as it is encountered, in addition to collecting it
+// (see WarningsOf). If the function returns an error, the reading is aborted: Read returns a *WarningsError
+// that summarizes the counts of the warnings so far. Once the reading has finished, SummaryOf summarizes all warnings.
+// Unless another policy is given, OnWarning implies Tolerant.
+func OnWarning(fn func(Warning) error) Option {
+	return func(rd *reader) {
+		rd.onWarning = fn
+	}
+}
+
+// Intern lets the reader return the identical messages that it has already returned before instead of new ones,
+// so that e.g. a lyric syllable or a control change message that is repeated thousands of times is kept in memory
+// only once. To deduplicate the messages of many files, the same Interner can be passed to all of their readers.
+// Text messages and channel messages are interned; system exclusive messages and meta messages with binary data
+// are not. Interning is off by default, since it costs a map lookup per message and the Interner keeps every
+// distinct message alive as long as it is referenced.
+//
+// Intern does not affect RetainPositions and Preserve: the positions and the raw data always belong to the last
+// event that has been read, while an interned message is shared by all events that carry it. Therefore a message
+// can't be used to look up the position of an event; the position must be retrieved via PositionOf right after
+// each Read.
+func Intern(in *Interner) Option {
+	return func(rd *reader) {
+		rd.interner = in
+	}
+}
+
 type logger interface {
 	Printf(format string, vals ...interface{})
 }
@@ -0,0 +1,112 @@
+package smfreader
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/midibinary"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// redundantEndOfTrack is an end of track message with delta 0
+var redundantEndOfTrack = []byte{0x00, 0xFF, 0x2F, 0x00}
+
+// readChunkHeader reads the header of the next chunk like smf.Chunk.ReadHeader does.
+// If the chunk type is garbage and the policy does not fail on structural errors, the data is skipped until the
+// next MTrk chunk.
+func (r *reader) readChunkHeader(chunk *smf.Chunk) (length uint32, err error) {
+	typ, err := midilib.ReadNBytes(4, r.input)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !smf.IsChunkType(typ) {
+		if r.policy.StructuralErrors == smf.Fail {
+			return 0, r.newError(Garbage, nil, "garbage instead of a chunk before track %v", r.processedTracks+1)
+		}
+
+		var skipped int
+
+		for !bytes.Equal(typ, []byte("MTrk")) {
+			var b byte
+			b, err = midilib.ReadByte(r.input)
+
+			if err != nil {
+				r.problem(Garbage, "%v bytes of garbage at the end of the data", skipped+len(typ))
+				return 0, err
+			}
+
+			typ = append(typ[1:], b)
+			skipped++
+		}
+
+		r.problem(Garbage, "%v bytes of garbage before track %v", skipped, r.processedTracks+1)
+	}
+
+	chunk.SetType([4]byte{typ[0], typ[1], typ[2], typ[3]})
+	length, err = midibinary.ReadUint32(r.input)
+
+	// the data ends within the header of the chunk
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return length, err
+}
+
+// skipAfterEndOfTrack skips the data after the end of track message within the declared length of the track chunk
+func (r *reader) skipAfterEndOfTrack() error {
+	read := r.counter.n - r.chunkStart
+
+	if read > r.expectedChunkLength {
+		return r.problem(TrackTooLong, "track %v is %v bytes longer than its declared length", r.processedTracks, read-r.expectedChunkLength)
+	}
+
+	rest := r.expectedChunkLength - read
+
+	if rest == 0 {
+		return nil
+	}
+
+	var bf bytes.Buffer
+	n, err := io.CopyN(&bf, r.input, int64(rest))
+
+	var perr error
+
+	switch {
+	case bytes.Equal(bf.Bytes(), redundantEndOfTrack):
+		perr = r.problem(RedundantEndOfTrack, "redundant end of track in track %v", r.processedTracks)
+	default:
+		perr = r.problem(DataAfterEndOfTrack, "%v bytes after the end of track in track %v", n, r.processedTracks)
+	}
+
+	if perr != nil {
+		return perr
+	}
+
+	if err == io.EOF {
+		// the data ended within the track chunk; the track is complete nevertheless
+		return nil
+	}
+
+	return err
+}
+
+// checkMeta checks the placement of the given meta message and whether it is defined
+func (r *reader) checkMeta(m midi.Message) error {
+	if u, is := m.(meta.Undefined); is {
+		if err := r.problem(UndefinedMessage, "meta message of undefined type %02X in track %v", byte(u.Typ), r.processedTracks); err != nil {
+			return err
+		}
+	}
+
+	if mm, is := m.(meta.Message); is && r.header.Format == smf.SMF1 && r.processedTracks > 0 && meta.FirstTrackOnly(mm) {
+		return r.problem(MisplacedMessage, "%s in track %v (only allowed in the first track)", m, r.processedTracks)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package smfreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/gomidi/midi/smf"
+)
+
+// chunk returns a chunk of the given type and data
+func chunk(typ string, data ...byte) []byte {
+	var bf bytes.Buffer
+	bf.WriteString(typ)
+	binary.Write(&bf, binary.BigEndian, uint32(len(data)))
+	bf.Write(data)
+	return bf.Bytes()
+}
+
+// header returns a MThd chunk of the given format and number of tracks with 96 ticks per quarter note
+func header(format, tracks uint16) []byte {
+	return chunk("MThd", 0x00, byte(format), 0x00, byte(tracks), 0x00, 0x60)
+}
+
+// join returns the concatenation of the given parts
+func join(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+var endOfTrack = []byte{0x00, 0xFF, 0x2F, 0x00}
+
+// policyDefects are SMF data with exactly one problem each
+var policyDefects = []struct {
+	name  string
+	input []byte
+	code  WarningCode
+}{
+	{"truncated",
+		join(header(0, 1), chunk("MTrk", 0x00, 0x90, 0x3C, 0x64, 0x60, 0x80, 0x3C, 0x40, 0x00, 0xFF, 0x2F, 0x00))[:29],
+		UnexpectedEnd},
+	{"garbage",
+		join(header(0, 1), []byte{0x12, 0x00}, chunk("MTrk", endOfTrack...)),
+		Garbage},
+	{"long delta time",
+		join(header(0, 1), chunk("MTrk", 0x80, 0x80, 0x80, 0x80, 0x00, 0xFF, 0x2F, 0x00)),
+		LongVarLength},
+	{"redundant end of track",
+		join(header(0, 1), chunk("MTrk", append(endOfTrack, endOfTrack...)...)),
+		RedundantEndOfTrack},
+	{"misplaced key signature",
+		join(header(1, 2), chunk("MTrk", endOfTrack...), chunk("MTrk", 0x00, 0xFF, 0x59, 0x02, 0x00, 0x00, 0x00, 0xFF, 0x2F, 0x00)),
+		MisplacedMessage},
+	{"tempo of 0",
+		join(header(0, 1), chunk("MTrk", 0x00, 0xFF, 0x51, 0x03, 0x00, 0x00, 0x00, 0x00, 0xFF, 0x2F, 0x00)),
+		InvalidValue},
+	{"unknown chunk",
+		join(header(0, 1), chunk("XFIH", 0x01, 0x02), chunk("MTrk", endOfTrack...)),
+		UnknownChunk},
+	{"undefined meta message",
+		join(header(0, 1), chunk("MTrk", 0x00, 0xFF, 0x60, 0x01, 0x05, 0x00, 0xFF, 0x2F, 0x00)),
+		UndefinedMessage},
+	{"data byte after meta message",
+		join(header(0, 1), chunk("MTrk", 0x00, 0x90, 0x3C, 0x64, 0x00, 0xFF, 0x01, 0x01, 0x61, 0x60, 0x3C, 0x00, 0x00, 0xFF, 0x2F, 0x00)),
+		MissingStatus},
+}
+
+// policyFor returns the strict policy, except for the given category that has the given action
+func policyFor(c smf.Category, a smf.Action) smf.Policy {
+	p := smf.Strict
+
+	switch c {
+	case smf.StructuralErrors:
+		p.StructuralErrors = a
+	case smf.PlacementViolations:
+		p.PlacementViolations = a
+	case smf.RangeViolations:
+		p.RangeViolations = a
+	case smf.UnknownData:
+		p.UnknownData = a
+	case smf.TruncatedData:
+		p.TruncatedData = a
+	}
+
+	return p
+}
+
+func TestPolicy(t *testing.T) {
+	for _, test := range policyDefects {
+		category := test.code.Category()
+
+		// all data is fine for the other categories
+		for _, a := range []smf.Action{smf.Warn, smf.Ignore} {
+			if _, err := readAll(test.input, Policy(policyFor(category, a))); err != smf.ErrFinished {
+				t.Errorf("[%s] %s %s: Read() error = %v; want %v", test.name, a, category, err, smf.ErrFinished)
+			}
+		}
+
+		// fail
+		rd, err := readAll(test.input, Policy(policyFor(category, smf.Fail)))
+		var perr *Error
+
+		if !errors.As(err, &perr) {
+			t.Errorf("[%s] fail %s: Read() error = %#v; want *Error", test.name, category, err)
+		} else if perr.Code != test.code || perr.Category != category {
+			t.Errorf("[%s] fail %s: Read() error = %v/%v; want %v/%v", test.name, category, perr.Code, perr.Category, test.code, category)
+		}
+
+		if got := WarningsOf(rd); got != nil {
+			t.Errorf("[%s] fail %s: WarningsOf() = %v; want nil", test.name, category, got)
+		}
+
+		// warn
+		rd, _ = readAll(test.input, Policy(policyFor(category, smf.Warn)))
+		warnings := WarningsOf(rd)
+
+		if len(warnings) != 1 {
+			t.Errorf("[%s] warn %s: WarningsOf() = %v; want 1 warning", test.name, category, warnings)
+		} else if w := warnings[0]; w.Code != test.code || w.Category != category {
+			t.Errorf("[%s] warn %s: warning = %v/%v; want %v/%v", test.name, category, w.Code, w.Category, test.code, category)
+		}
+
+		// ignore
+		rd, _ = readAll(test.input, Policy(policyFor(category, smf.Ignore)))
+
+		if got := WarningsOf(rd); got != nil {
+			t.Errorf("[%s] ignore %s: WarningsOf() = %v; want nil", test.name, category, got)
+		}
+	}
+}
+
+func TestPolicyPresets(t *testing.T) {
+	fails := map[smf.Policy]map[WarningCode]bool{
+		smf.Strict: {UnexpectedEnd: true, Garbage: true, LongVarLength: true, RedundantEndOfTrack: true,
+			MisplacedMessage: true, InvalidValue: true, UnknownChunk: true, UndefinedMessage: true, MissingStatus: true},
+		smf.Default:    {UnexpectedEnd: true},
+		smf.Permissive: {},
+	}
+
+	for p, fail := range fails {
+		for _, test := range policyDefects {
+			_, err := readAll(test.input, Policy(p))
+
+			if got, want := err != smf.ErrFinished, fail[test.code]; got != want {
+				t.Errorf("[%s] %+v: Read() error = %v; want failure: %v", test.name, p, err, want)
+			}
+		}
+	}
+
+	// without a policy, the reader uses smf.Default
+	if _, err := readAll(policyDefects[6].input); err != smf.ErrFinished {
+		t.Errorf("Read() error = %v; want %v", err, smf.ErrFinished)
+	}
+
+	var perr *Error
+
+	if !errors.As(ErrMissing, &perr) || perr.Category != smf.TruncatedData {
+		t.Errorf("ErrMissing = %#v; want *Error of category %v", ErrMissing, smf.TruncatedData)
+	}
+}
+
+func TestDefaultLenient(t *testing.T) {
+	// problems that the reader did not check before the policies were introduced
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"redundant end of track", join(header(0, 1), chunk("MTrk", append(endOfTrack, endOfTrack...)...))},
+		{"track longer than declared", join(header(0, 1), []byte("MTrk"), []byte{0x00, 0x00, 0x00, 0x06}, []byte{0x00, 0x90, 0x3C, 0x64}, endOfTrack)},
+		{"garbage", join(header(0, 1), []byte{0x12, 0x00}, chunk("MTrk", endOfTrack...))},
+		{"long delta time", join(header(0, 1), chunk("MTrk", 0x80, 0x80, 0x80, 0x80, 0x00, 0xFF, 0x2F, 0x00))},
+		{"tempo of 0", join(header(0, 1), chunk("MTrk", 0x00, 0xFF, 0x51, 0x03, 0x00, 0x00, 0x00, 0x00, 0xFF, 0x2F, 0x00))},
+		{"key with 8 sharps", join(header(0, 1), chunk("MTrk", 0x00, 0xFF, 0x59, 0x02, 0x08, 0x00, 0x00, 0xFF, 0x2F, 0x00))},
+		{"denominator of 2^8", join(header(0, 1), chunk("MTrk", 0x00, 0xFF, 0x58, 0x04, 0x04, 0x08, 0x18, 0x08, 0x00, 0xFF, 0x2F, 0x00))},
+	}
+
+	for _, test := range tests {
+		rd := New(bytes.NewReader(test.input))
+
+		if err := rd.ReadHeader(); err != nil {
+			t.Fatalf("[%s] ReadHeader() error = %v", test.name, err)
+		}
+
+		var err error
+		var n int
+
+		for err == nil {
+			_, err = rd.Read()
+			n++
+		}
+
+		if err != smf.ErrFinished {
+			t.Errorf("[%s] Read() error = %v; want %v", test.name, err, smf.ErrFinished)
+		}
+
+		if n < 2 {
+			t.Errorf("[%s] no messages read", test.name)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package smfreader
+
+import (
+	"io"
+
+	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/smf"
+)
+
+// Position is the position of a MTrk event (delta time + event) within the SMF data.
+type Position struct {
+	// Offset is the number of bytes from the beginning of the SMF data to the
+	// first byte of the delta time of the event.
+	Offset uint32
+
+	// Length is the number of bytes of the delta time and the event together.
+	// If the event was written with running status, the status byte is not part of it.
+	Length uint32
+}
+
+// PositionOf returns the Position of the last MTrk event that has been read by rd.
+// It returns nil, if rd has not been created with the RetainPositions option
+// or if no event has been read yet.
+func PositionOf(rd smf.Reader) *Position {
+	r, ok := rd.(*reader)
+	if !ok || !r.retainPositions || r.processedTracks < 0 {
+		return nil
+	}
+	pos := r.position
+	return &pos
+}
+
+// countingReader counts the bytes that have been read from input.
+// If record is true, the bytes are also kept until they are taken.
+type countingReader struct {
+	input  io.Reader
+	n      uint32
+	record bool
+	buf    []byte
+}
+
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.input.Read(p)
+	c.n += uint32(n)
+
+	if c.record {
+		c.buf = append(c.buf, p[:n]...)
+	}
+
+	return
+}
+
+// take returns the recorded bytes and starts a new recording
+func (c *countingReader) take() []byte {
+	b := c.buf
+	c.buf = nil
+	return b
+}
+
+// countingSlicer is a countingReader of a midilib.Slicer that is a midilib.Slicer itself
+type countingSlicer struct {
+	*countingReader
+	slicer midilib.Slicer
+}
+
+func (c *countingSlicer) Slice(n int) ([]byte, error) {
+	b, err := c.slicer.Slice(n)
+	c.n += uint32(len(b))
+
+	if c.record {
+		c.buf = append(c.buf, b...)
+	}
+
+	return b, err
+}
@@ -0,0 +1,33 @@
+package smfreader
+
+import (
+	"github.com/gomidi/midi/smf"
+)
+
+// Preserved is the raw data of a SMF that has been read with the Preserve option.
+type Preserved struct {
+	// Header is the raw MThd chunk (including the chunk header)
+	Header []byte
+
+	// Prefix is the raw data between the previous MTrk chunk (or the MThd chunk) and the MTrk chunk of the event,
+	// i.e. the unknown chunks before the track. It is only set for the first event of a track.
+	Prefix []byte
+
+	// Event is the raw data of the MTrk event, including the delta time.
+	// If the event was written with running status, the status byte is not part of it.
+	Event []byte
+
+	// Trailer is the data after the last track. It is only set for the last event of the last track.
+	Trailer []byte
+}
+
+// PreservedOf returns the preserved raw data of the last MTrk event that has been read by rd.
+// It returns nil, if rd has not been created with the Preserve option or if no event has been read yet.
+func PreservedOf(rd smf.Reader) *Preserved {
+	r, ok := rd.(*reader)
+	if !ok || !r.preserve || r.processedTracks < 0 {
+		return nil
+	}
+	p := r.preserved
+	return &p
+}
@@ -8,10 +8,9 @@ import (
 
 	"github.com/gomidi/midi/internal/runningstatus"
 
-	"errors"
-
 	"github.com/gomidi/midi"
 	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/midibinary"
 	"github.com/gomidi/midi/midimessage/channel"
 	"github.com/gomidi/midi/midimessage/meta"
 	"github.com/gomidi/midi/smf"
@@ -48,6 +47,15 @@ func ReadFile(file string, callback func(smf.Reader), options ...Option) error {
 	return nil
 }
 
+// NewFromBytes returns a smf.Reader of the given SMF data.
+// Unlike New, the data of system exclusive messages, sequencer specific messages (meta.SequencerData) and undefined
+// meta messages is not copied: it is a sub-slice of the given data (text messages are copied though, since they
+// are strings). Therefore the given data must not be modified, as long as the messages are used.
+// This allows to read huge files with few allocations, e.g. from a memory mapped file.
+func NewFromBytes(data []byte, opts ...Option) smf.Reader {
+	return New(midilib.NewSliceReader(data), opts...)
+}
+
 // New returns a smf.Reader
 func New(src io.Reader, opts ...Option) smf.Reader {
 	rd := &reader{
@@ -62,6 +70,22 @@ func New(src io.Reader, opts ...Option) smf.Reader {
 		opt(rd)
 	}
 
+	if rd.policy == nil {
+		rd.policy = &smf.Default
+
+		if rd.warningsCh != nil || rd.onWarning != nil {
+			rd.policy = &smf.Permissive
+		}
+	}
+
+	rd.counter = &countingReader{input: rd.input, record: rd.preserve}
+
+	if sl, is := rd.input.(midilib.Slicer); is {
+		rd.input = &countingSlicer{countingReader: rd.counter, slicer: sl}
+	} else {
+		rd.input = rd.counter
+	}
+
 	if rd.readNoteOffPedantic {
 		rd.channelReader = channel.NewReader(rd.input, channel.ReadNoteOffVelocity())
 	} else {
@@ -73,7 +97,7 @@ func New(src io.Reader, opts ...Option) smf.Reader {
 
 // Close closes the internal reader if it is an io.ReadCloser
 func (r *reader) Close() error {
-	if cl, is := r.input.(io.ReadCloser); is {
+	if cl, is := r.counter.input.(io.ReadCloser); is {
 		return cl.Close()
 	}
 	return nil
@@ -85,6 +109,20 @@ func (r *reader) ReadHeader() error {
 	}
 	r.error = r.readMThd()
 	r.headerIsRead = true
+
+	// the data ends within the header chunk
+	if r.error != io.EOF && isUnexpectedEnd(r.error) {
+		r.error = r.newError(UnexpectedEnd, r.error, "unexpected end of data in header")
+	}
+
+	if r.preserve {
+		r.preserved.Header = r.counter.take()
+	}
+
+	if r.error != nil {
+		r.end(r.error)
+	}
+
 	return r.error
 }
 
@@ -97,6 +135,7 @@ type reader struct {
 	expectChunk         bool
 	expectedChunkLength uint32
 	runningStatus       runningstatus.Reader
+	lastStatus          byte // the last channel status of the current track, to recover from a missing status
 	processedTracks     int16
 	deltatime           uint32
 	header              smf.Header
@@ -109,6 +148,42 @@ type reader struct {
 	headerIsRead        bool
 	// headerError         error
 	readNoteOffPedantic bool
+	retainPositions     bool
+	preserve            bool
+
+	// policy is the handling of the problems within the SMF data (see Policy)
+	policy *smf.Policy
+
+	// warnings are only collected, if the policy warns about any category
+	warnings []Warning
+
+	// warningsCh and onWarning receive the warnings as they are encountered (see Warnings and OnWarning)
+	warningsCh chan<- Warning
+	onWarning  func(Warning) error
+
+	// aborted is set, if onWarning has returned an error
+	aborted *WarningsError
+
+	// dropped is the number of warnings that could not be sent to warningsCh
+	dropped int
+
+	// ended is the error that has ended the reading (see SummaryOf)
+	ended error
+
+	// interner is only set, if the messages are interned (see Intern)
+	interner *Interner
+
+	// chunkStart is the offset of the data of the current track
+	chunkStart uint32
+
+	counter  *countingReader
+	position Position
+
+	// iterErr is the error that has ended the iteration of Events
+	iterErr error
+
+	// preserved is only set, if preserve is true
+	preserved Preserved
 
 	error error
 }
@@ -139,13 +214,69 @@ func (r *reader) tracksMissing() bool {
 // Read reads the next midi message
 // If the file has been read completely, ErrFinished is returned as error.
 func (r *reader) Read() (m midi.Message, err error) {
+	defer func() {
+		if err != nil {
+			r.end(err)
+		}
+	}()
+
+	if r.aborted != nil {
+		return nil, r.aborted
+	}
+
 	msg, err := r.read()
+
+	if r.headerIsRead && r.recover(err) {
+		err = smf.ErrFinished
+		msg = nil
+	}
+
+	if r.aborted != nil {
+		r.isDone = true
+		return nil, r.aborted
+	}
+
 	if err == io.EOF && r.tracksMissing() {
 		return nil, ErrMissing
 	}
+
+	// the data ends within a track
+	if err != io.EOF && isUnexpectedEnd(err) {
+		return nil, r.newError(UnexpectedEnd, err, "%v", err)
+	}
+
+	if r.interner != nil && err == nil && msg != nil {
+		msg = r.interner.intern(msg)
+	}
+
 	return msg, err
 }
 
+// isUnexpectedEnd returns true, if the given error is due to a premature end of the data and has not been
+// wrapped in an *Error yet
+func isUnexpectedEnd(err error) bool {
+	return err == io.EOF || err == io.ErrUnexpectedEOF || err == midi.ErrUnexpectedEOF
+}
+
+// recover finishes the reading, if the given error is due to a premature end of the data and the policy does
+// not fail on truncated data
+func (r *reader) recover(err error) bool {
+	if !isUnexpectedEnd(err) || r.policy.TruncatedData == smf.Fail {
+		return false
+	}
+
+	if r.processedTracks >= 0 && !r.expectChunk {
+		r.problem(UnexpectedEnd, "unexpected end of data in track %v (missing end of track)", r.processedTracks)
+	}
+
+	if r.tracksMissing() {
+		r.problem(MissingTracks, "%v of %v tracks missing", int16(r.header.NumTracks)-r.processedTracks-1, r.header.NumTracks)
+	}
+
+	r.isDone = true
+	return true
+}
+
 func (r *reader) read() (m midi.Message, err error) {
 	if r.isDone {
 		return nil, smf.ErrFinished
@@ -159,7 +290,10 @@ func (r *reader) read() (m midi.Message, err error) {
 		return nil, r.error
 	}
 
-	if r.expectChunk {
+	r.preserved.Prefix = nil
+
+	// skip unknown chunks until the next track
+	for r.expectChunk && r.error == nil {
 		r.readChunk()
 	}
 
@@ -170,6 +304,17 @@ func (r *reader) read() (m midi.Message, err error) {
 	// now we are inside a track
 	r.deltatime = 0
 	m, r.error = r.readEvent()
+
+	if r.preserve && r.error == nil {
+		r.preserved.Event = r.counter.take()
+
+		if r.isDone {
+			// keep everything after the last track
+			_, r.error = io.Copy(ioutil.Discard, r.input)
+			r.preserved.Trailer = r.counter.take()
+		}
+	}
+
 	return m, r.error
 }
 
@@ -202,6 +347,10 @@ func (r *reader) readMThd() (err error) {
 	err = r.parseHeaderData(r.input)
 	r.log("reading body of header type: %v", err)
 
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
 	return // leave at the end
 }
 
@@ -218,7 +367,7 @@ func (r *reader) readChunk() {
 		chunk smf.Chunk
 	)
 
-	r.expectedChunkLength, r.error = chunk.ReadHeader(r.input)
+	r.expectedChunkLength, r.error = r.readChunkHeader(&chunk)
 	r.log("reading header of chunk: %v", r.error)
 
 	if r.error != nil {
@@ -233,6 +382,18 @@ func (r *reader) readChunk() {
 		r.log("is track chunk")
 		r.processedTracks++
 		r.expectChunk = false
+
+		r.chunkStart = r.counter.n
+
+		// the running status does not persist across tracks
+		r.runningStatus = runningstatus.NewSMFReader()
+		r.lastStatus = 0
+
+		if r.preserve {
+			// the unknown chunks before the track, without the track header
+			prefix := r.counter.take()
+			r.preserved.Prefix = prefix[:len(prefix)-8]
+		}
 		//p.state = stateExpectTrackEvent
 		// we are done, lets go to the track events
 		return
@@ -246,6 +407,10 @@ func (r *reader) readChunk() {
 	*/
 
 	// The header is of an unknown type, skip over it.
+	if r.error = r.problem(UnknownChunk, "unknown chunk of type %q before track %v", chunk.Type(), r.processedTracks+1); r.error != nil {
+		return
+	}
+
 	_, r.error = io.CopyN(ioutil.Discard, r.input, int64(r.expectedChunkLength))
 	r.log("skipping chunk: %v", r.error)
 	if r.error != nil {
@@ -261,6 +426,21 @@ func (r *reader) _readEvent(canary byte) (m midi.Message, err error) {
 	status, changed := r.runningStatus.Read(canary)
 	r.log("got status: % X, changed: %v", status, changed)
 
+	// a data byte without running status: meta and system exclusive messages cancel the running status, but some
+	// exporters ignore that, so we continue with the previous channel status, like most sequencers do
+	if status == 0 && canary < 0x80 {
+		if r.lastStatus == 0 {
+			return nil, r.newError(MissingStatus, nil, "data byte %02X without status in track %v", canary, r.processedTracks)
+		}
+
+		if err = r.problem(MissingStatus, "data byte %02X without running status in track %v (continued with status %02X)", canary, r.processedTracks, r.lastStatus); err != nil {
+			return nil, err
+		}
+
+		status, _ = r.runningStatus.Read(r.lastStatus)
+		changed = false
+	}
+
 	// a non-channel message has reset the status
 	if status == 0 {
 
@@ -278,13 +458,27 @@ func (r *reader) _readEvent(canary byte) (m midi.Message, err error) {
 			r.log("read system common type: % X, err: %v", typ, err)
 
 			if err != nil {
-				return nil, nil
+				return nil, err
 			}
 
 			// since System Common messages are not allowed within smf files, there could only be meta messages
 			// all (event unknown) meta messages must be handled by the meta dispatcher
 			m, err = meta.NewReader(r.input, typ).Read()
+
+			// the message has been read completely, but its value is out of range
+			if ive, is := err.(*meta.InvalidValueError); is {
+				if r.policy.RangeViolations == smf.Fail {
+					return nil, r.newError(InvalidValue, ive, "%s in track %v", ive.Problem, r.processedTracks)
+				}
+
+				r.problem(InvalidValue, "%s in track %v (clamped)", ive.Problem, r.processedTracks)
+				m, err = ive.Message, nil
+			}
 			r.log("got meta: %T", m)
+
+			if err == nil {
+				err = r.checkMeta(m)
+			}
 		default:
 			panic(fmt.Sprintf("must not happen: invalid canary % X", canary))
 		}
@@ -301,6 +495,8 @@ func (r *reader) _readEvent(canary byte) (m midi.Message, err error) {
 			}
 		}
 
+		r.lastStatus = status
+
 		// since every possible status is covered by a voice message type, m can't be nil
 		m, err = r.channelReader.Read(status, arg1)
 		r.log("got channel message: %#v, err: %v", m, err)
@@ -329,6 +525,10 @@ func (r *reader) _readEvent(canary byte) (m midi.Message, err error) {
 
 		// TODO check the read length of the track against the length thas has been read
 		// return ErrTruncatedTrack if meta.EndOfTrack comes to early or ErrOverflowingTrack it it comes too late
+		if err := r.skipAfterEndOfTrack(); err != nil {
+			return nil, err
+		}
+
 		if uint16(r.processedTracks+1) == r.header.NumTracks {
 			r.log("last track has been read")
 			r.isDone = true
@@ -347,14 +547,31 @@ func (r *reader) readEvent() (m midi.Message, err error) {
 		return nil, r.error
 	}
 
+	r.position.Offset = r.counter.n
+	defer func() {
+		r.position.Length = r.counter.n - r.position.Offset
+	}()
+
 	var deltatime uint32
 
 	deltatime, err = midilib.ReadVarLength(r.input)
 	r.log("read delta: %v, err: %v", deltatime, err)
+
+	// the data ends within the track chunk
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
 	if err != nil {
 		return
 	}
 
+	if n := r.counter.n - r.position.Offset; n > 4 {
+		if err = r.problem(LongVarLength, "delta time of %v bytes in track %v", n, r.processedTracks); err != nil {
+			return
+		}
+	}
+
 	r.deltatime = deltatime
 
 	// read the canary in the coal mine to see, if we have a running status byte or a given one
@@ -362,17 +579,28 @@ func (r *reader) readEvent() (m midi.Message, err error) {
 	canary, err = midilib.ReadByte(r.input)
 	r.log("read canary: %v, err: %v", canary, err)
 
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
 	if err != nil {
 		return
 	}
 
-	return r._readEvent(canary)
+	m, err = r._readEvent(canary)
+
+	// the data ends within the event
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return m, err
 }
 
 // parseHeaderData parses SMF-header chunk header data.
 func (r *reader) parseHeaderData(reader io.Reader) error {
 
-	format, err := midilib.ReadUint16(reader)
+	format, err := midibinary.ReadUint16(reader)
 
 	if err != nil {
 		return err
@@ -389,14 +617,14 @@ func (r *reader) parseHeaderData(reader io.Reader) error {
 		return errUnsupportedSMFFormat
 	}
 
-	r.header.NumTracks, err = midilib.ReadUint16(reader)
+	r.header.NumTracks, err = midibinary.ReadUint16(reader)
 
 	if err != nil {
 		return err
 	}
 
 	var division uint16
-	division, err = midilib.ReadUint16(reader)
+	division, err = midibinary.ReadUint16(reader)
 
 	if err != nil {
 		return err
@@ -476,5 +704,3 @@ func parseTimeCode(raw uint16) (t smf.TimeCode) {
 	t.SubFrames = byte(raw & uint16(255))
 	return
 }
-
-var errUnexpectedEOF = errors.New("Unexpected End of File found.")
@@ -2,7 +2,10 @@ package smfreader
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"testing/iotest"
 
 	"github.com/gomidi/midi"
 	"github.com/gomidi/midi/internal/examples"
@@ -10,6 +13,7 @@ import (
 	"github.com/gomidi/midi/midimessage/meta"
 	"github.com/gomidi/midi/midimessage/realtime"
 	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf"
 	"github.com/gomidi/midi/smf/smfwriter"
 
 	// "log"
@@ -57,17 +61,17 @@ SMF0
 TimeFormat: 96 MetricTicks
 Track 0@0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
 Track 0@0 meta.Tempo BPM: 120.00
-Track 0@0 channel.ProgramChange channel 0 program 5
-Track 0@0 channel.ProgramChange channel 1 program 46
-Track 0@0 channel.ProgramChange channel 2 program 70
-Track 0@0 channel.NoteOn channel 2 key 48 velocity 96
-Track 0@0 channel.NoteOn channel 2 key 60 velocity 96
-Track 0@96 channel.NoteOn channel 1 key 67 velocity 64
-Track 0@96 channel.NoteOn channel 0 key 76 velocity 32
-Track 0@192 channel.NoteOff channel 2 key 48
-Track 0@0 channel.NoteOff channel 2 key 60
-Track 0@0 channel.NoteOff channel 1 key 67
-Track 0@0 channel.NoteOff channel 0 key 76
+Track 0@0 channel.ProgramChange channel 1 program 5
+Track 0@0 channel.ProgramChange channel 2 program 46
+Track 0@0 channel.ProgramChange channel 3 program 70
+Track 0@0 channel.NoteOn channel 3 key 48 velocity 96
+Track 0@0 channel.NoteOn channel 3 key 60 velocity 96
+Track 0@96 channel.NoteOn channel 2 key 67 velocity 64
+Track 0@96 channel.NoteOn channel 1 key 76 velocity 32
+Track 0@192 channel.NoteOff channel 3 key 48
+Track 0@0 channel.NoteOff channel 3 key 60
+Track 0@0 channel.NoteOff channel 2 key 67
+Track 0@0 channel.NoteOff channel 1 key 76
 Track 0@0 meta.EndOfTrack
 `
 
@@ -100,19 +104,19 @@ TimeFormat: 96 MetricTicks
 Track 0@0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
 Track 0@0 meta.Tempo BPM: 120.00
 Track 0@384 meta.EndOfTrack
-Track 1@0 channel.ProgramChange channel 0 program 5
-Track 1@192 channel.NoteOn channel 0 key 76 velocity 32
-Track 1@192 channel.NoteOff channel 0 key 76
+Track 1@0 channel.ProgramChange channel 1 program 5
+Track 1@192 channel.NoteOn channel 1 key 76 velocity 32
+Track 1@192 channel.NoteOff channel 1 key 76
 Track 1@0 meta.EndOfTrack
-Track 2@0 channel.ProgramChange channel 1 program 46
-Track 2@96 channel.NoteOn channel 1 key 67 velocity 64
-Track 2@288 channel.NoteOff channel 1 key 67
+Track 2@0 channel.ProgramChange channel 2 program 46
+Track 2@96 channel.NoteOn channel 2 key 67 velocity 64
+Track 2@288 channel.NoteOff channel 2 key 67
 Track 2@0 meta.EndOfTrack
-Track 3@0 channel.ProgramChange channel 2 program 70
-Track 3@0 channel.NoteOn channel 2 key 48 velocity 96
-Track 3@0 channel.NoteOn channel 2 key 60 velocity 96
-Track 3@384 channel.NoteOff channel 2 key 48
-Track 3@0 channel.NoteOff channel 2 key 60
+Track 3@0 channel.ProgramChange channel 3 program 70
+Track 3@0 channel.NoteOn channel 3 key 48 velocity 96
+Track 3@0 channel.NoteOn channel 3 key 60 velocity 96
+Track 3@384 channel.NoteOff channel 3 key 48
+Track 3@0 channel.NoteOff channel 3 key 60
 Track 3@0 meta.EndOfTrack
 `
 
@@ -130,19 +134,19 @@ TimeFormat: 96 MetricTicks
 Track 0@0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
 Track 0@0 meta.Tempo BPM: 120.00
 Track 0@384 meta.EndOfTrack
-Track 1@0 channel.ProgramChange channel 0 program 5
-Track 1@192 channel.NoteOn channel 0 key 76 velocity 32
-Track 1@192 channel.NoteOff channel 0 key 76
+Track 1@0 channel.ProgramChange channel 1 program 5
+Track 1@192 channel.NoteOn channel 1 key 76 velocity 32
+Track 1@192 channel.NoteOff channel 1 key 76
 Track 1@0 meta.EndOfTrack
-Track 2@0 channel.ProgramChange channel 1 program 46
-Track 2@96 channel.NoteOn channel 1 key 67 velocity 64
-Track 2@288 channel.NoteOff channel 1 key 67
+Track 2@0 channel.ProgramChange channel 2 program 46
+Track 2@96 channel.NoteOn channel 2 key 67 velocity 64
+Track 2@288 channel.NoteOff channel 2 key 67
 Track 2@0 meta.EndOfTrack
-Track 3@0 channel.ProgramChange channel 2 program 70
-Track 3@0 channel.NoteOn channel 2 key 48 velocity 96
-Track 3@0 channel.NoteOn channel 2 key 60 velocity 96
-Track 3@384 channel.NoteOff channel 2 key 48
-Track 3@0 channel.NoteOff channel 2 key 60
+Track 3@0 channel.ProgramChange channel 3 program 70
+Track 3@0 channel.NoteOn channel 3 key 48 velocity 96
+Track 3@0 channel.NoteOn channel 3 key 60 velocity 96
+Track 3@384 channel.NoteOff channel 3 key 48
+Track 3@0 channel.NoteOff channel 3 key 60
 Track 3@0 meta.EndOfTrack
 `
 
@@ -159,17 +163,17 @@ SMF0
 TimeFormat: 96 MetricTicks
 Track 0@0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
 Track 0@0 meta.Tempo BPM: 120.00
-Track 0@0 channel.ProgramChange channel 0 program 5
-Track 0@0 channel.ProgramChange channel 1 program 46
-Track 0@0 channel.ProgramChange channel 2 program 70
-Track 0@0 channel.NoteOn channel 2 key 48 velocity 96
-Track 0@0 channel.NoteOn channel 2 key 60 velocity 96
-Track 0@96 channel.NoteOn channel 1 key 67 velocity 64
-Track 0@96 channel.NoteOn channel 0 key 76 velocity 32
-Track 0@192 channel.NoteOffVelocity channel 2 key 48 velocity 64
-Track 0@0 channel.NoteOffVelocity channel 2 key 60 velocity 64
-Track 0@0 channel.NoteOffVelocity channel 1 key 67 velocity 64
-Track 0@0 channel.NoteOffVelocity channel 0 key 76 velocity 64
+Track 0@0 channel.ProgramChange channel 1 program 5
+Track 0@0 channel.ProgramChange channel 2 program 46
+Track 0@0 channel.ProgramChange channel 3 program 70
+Track 0@0 channel.NoteOn channel 3 key 48 velocity 96
+Track 0@0 channel.NoteOn channel 3 key 60 velocity 96
+Track 0@96 channel.NoteOn channel 2 key 67 velocity 64
+Track 0@96 channel.NoteOn channel 1 key 76 velocity 32
+Track 0@192 channel.NoteOffVelocity channel 3 key 48 velocity 64
+Track 0@0 channel.NoteOffVelocity channel 3 key 60 velocity 64
+Track 0@0 channel.NoteOffVelocity channel 2 key 67 velocity 64
+Track 0@0 channel.NoteOffVelocity channel 1 key 76 velocity 64
 Track 0@0 meta.EndOfTrack
 `
 
@@ -179,10 +183,47 @@ Track 0@0 meta.EndOfTrack
 
 }
 
+func TestNoteOffVelocityRoundTrip(t *testing.T) {
+	ch := channel.Channel2
+
+	for _, options := range [][]smfwriter.Option{nil, {smfwriter.NoRunningStatus()}} {
+		var bf bytes.Buffer
+
+		wr := smfwriter.New(&bf, options...)
+		wr.Write(ch.NoteOn(48, 96))
+		wr.Write(ch.NoteOn(60, 96))
+		wr.SetDelta(96)
+		wr.Write(ch.NoteOffVelocity(48, 35))
+		wr.Write(ch.NoteOffVelocity(60, 0))
+		wr.Write(ch.NoteOn(64, 96))
+		wr.SetDelta(96)
+		wr.Write(ch.NoteOff(64))
+		wr.Write(meta.EndOfTrack)
+
+		expected := `
+SMF0
+1 Track(s)
+TimeFormat: 960 MetricTicks
+Track 0@0 channel.NoteOn channel 3 key 48 velocity 96
+Track 0@0 channel.NoteOn channel 3 key 60 velocity 96
+Track 0@96 channel.NoteOffVelocity channel 3 key 48 velocity 35
+Track 0@0 channel.NoteOffVelocity channel 3 key 60 velocity 0
+Track 0@0 channel.NoteOn channel 3 key 64 velocity 96
+Track 0@96 channel.NoteOff channel 3 key 64
+Track 0@0 meta.EndOfTrack
+`
+
+		if got, want := testRead(t, bf.Bytes(), NoteOffVelocity()), expected; got != want {
+			t.Errorf("%v options: got:\n%v\n\nwanted\n%v\n\n", len(options), got, want)
+		}
+	}
+}
+
 func TestReadSysEx(t *testing.T) {
 	var bf bytes.Buffer
 
-	wr := smfwriter.New(&bf)
+	// the data contains status bytes on purpose
+	wr := smfwriter.New(&bf, smfwriter.Unsafe())
 	wr.Write(sysex.Escape(realtime.Start.Raw()))
 	wr.SetDelta(0)
 	wr.Write(channel.Channel2.NoteOn(65, 90))
@@ -272,3 +313,98 @@ func TestX(t *testing.T) {
 	_ = msg
 	// fmt.Printf("%s\n", msg)
 }
+
+func TestReadMaxDelta(t *testing.T) {
+	data := join(header(0, 1), chunk("MTrk", 0xFF, 0xFF, 0xFF, 0x7F, 0x90, 0x3C, 0x64, 0x81, 0x80, 0x80, 0x00, 0xFF, 0x2F, 0x00))
+
+	for name, rd := range map[string]smf.Reader{
+		"New":          New(bytes.NewReader(data)),
+		"NewFromBytes": NewFromBytes(data),
+	} {
+		var deltas []uint32
+
+		for {
+			_, err := rd.Read()
+
+			if err != nil {
+				if err != smf.ErrFinished {
+					t.Errorf("[%s] Read() error = %v; want %v", name, err, smf.ErrFinished)
+				}
+				break
+			}
+
+			deltas = append(deltas, rd.Delta())
+		}
+
+		if got, want := fmt.Sprint(deltas), "[268435455 2097152]"; got != want {
+			t.Errorf("[%s] deltas = %v; want %v", name, got, want)
+		}
+	}
+}
+
+// truncateFixture is a small SMF1 with meta, channel (with running status) and sysex messages in two tracks
+var truncateFixture = join(
+	header(1, 2),
+	chunk("MTrk", join(
+		[]byte{0x00, 0xFF, 0x51, 0x03, 0x07, 0xA1, 0x20},
+		[]byte{0x00, 0xFF, 0x58, 0x04, 0x04, 0x02, 0x18, 0x08},
+		[]byte{0x00, 0xFF, 0x03, 0x04, 'd', 'e', 'm', 'o'},
+		endOfTrack,
+	)...),
+	chunk("MTrk", join(
+		[]byte{0x00, 0xC0, 0x05},
+		[]byte{0x00, 0x90, 0x3C, 0x64},
+		[]byte{0x60, 0x3C, 0x00},
+		[]byte{0x00, 0xE0, 0x00, 0x40},
+		[]byte{0x00, 0xF0, 0x03, 0x7D, 0x01, 0xF7},
+		[]byte{0x81, 0x00, 0x80, 0x3C, 0x40},
+		[]byte{0x00, 0xFF, 0x7F, 0x02, 0x7D, 0x01},
+		endOfTrack,
+	)...),
+)
+
+// readUntilError reads the header and all messages and returns the first error
+func readUntilError(rd smf.Reader) error {
+	if err := rd.ReadHeader(); err != nil {
+		return err
+	}
+
+	for {
+		if _, err := rd.Read(); err != nil {
+			return err
+		}
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	var readers = map[string]func([]byte) smf.Reader{
+		"New":          func(b []byte) smf.Reader { return New(bytes.NewReader(b)) },
+		"NewFromBytes": func(b []byte) smf.Reader { return NewFromBytes(b) },
+		"OneByteReader": func(b []byte) smf.Reader {
+			return New(iotest.OneByteReader(bytes.NewReader(b)))
+		},
+	}
+
+	for name, newReader := range readers {
+		if err := readUntilError(newReader(truncateFixture)); err != smf.ErrFinished {
+			t.Errorf("%s: reading the complete data returned %v; want %v", name, err, smf.ErrFinished)
+		}
+
+		for n := 0; n < len(truncateFixture); n++ {
+			err := readUntilError(newReader(truncateFixture[:n]))
+
+			if n == 0 {
+				if err != io.EOF {
+					t.Errorf("%s: reading no data returned %v; want io.EOF", name, err)
+				}
+				continue
+			}
+
+			var e *Error
+
+			if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.As(err, &e) {
+				t.Errorf("%s: reading the data truncated to %v bytes returned %#v; want *Error wrapping io.ErrUnexpectedEOF", name, n, err)
+			}
+		}
+	}
+}
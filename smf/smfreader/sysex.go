@@ -36,9 +36,9 @@ func (s *sysexReader) Read(startcode byte, rd io.Reader) (sys sysex.Message, err
 		}
 
 		// complete sysex
-		if data[len(data)-1] == 0xF7 {
+		if len(data) > 0 && data[len(data)-1] == 0xF7 {
 			s.inSequence = false
-			return sysex.SysEx(data[0 : len(data)-1]), nil
+			return sysex.SysEx(data[0 : len(data)-1 : len(data)-1]), nil
 		}
 
 		// casio style
@@ -54,11 +54,11 @@ func (s *sysexReader) Read(startcode byte, rd io.Reader) (sys sysex.Message, err
 		}
 
 		// End of sysex sequence
-		if data[len(data)-1] == 0xF7 {
+		if len(data) > 0 && data[len(data)-1] == 0xF7 {
 			// casio style
 			if s.inSequence {
 				s.inSequence = false
-				return sysex.End(data[0 : len(data)-1]), nil
+				return sysex.End(data[0 : len(data)-1 : len(data)-1]), nil
 			}
 			return sysex.Escape(data), nil
 
@@ -0,0 +1,307 @@
+package smfreader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gomidi/midi/smf"
+)
+
+// WarningCode is the kind of a Warning
+type WarningCode int
+
+const (
+	// UnexpectedEnd means that the data ends within a track
+	UnexpectedEnd WarningCode = iota + 1
+
+	// MissingTracks means that the data ends before all tracks have been read
+	MissingTracks
+
+	// Garbage means that there are bytes between the chunks that are not a chunk
+	Garbage
+
+	// TrackTooLong means that a track is longer than its declared length
+	TrackTooLong
+
+	// RedundantEndOfTrack means that an end of track message follows the end of track message of a track
+	RedundantEndOfTrack
+
+	// DataAfterEndOfTrack means that there are bytes after the end of track message within the declared length of a track
+	DataAfterEndOfTrack
+
+	// InvalidValue means that a meta message has a value that is out of range (see meta.InvalidValueError)
+	InvalidValue
+
+	// LongVarLength means that a delta time is encoded with more than 4 bytes
+	LongVarLength
+
+	// MisplacedMessage means that a message is only allowed in the first track of a SMF1 (see meta.FirstTrackOnly)
+	MisplacedMessage
+
+	// UnknownChunk means that there is a chunk of an unknown type
+	UnknownChunk
+
+	// UndefinedMessage means that there is a meta message of an undefined type (see meta.Undefined)
+	UndefinedMessage
+
+	// MissingStatus means that there is a data byte where a status byte is required, e.g. after a meta or
+	// system exclusive message, that cancel the running status
+	MissingStatus
+)
+
+var warningCodes = map[WarningCode]string{
+	UnexpectedEnd:       "unexpected end",
+	MissingTracks:       "missing tracks",
+	Garbage:             "garbage",
+	TrackTooLong:        "track too long",
+	RedundantEndOfTrack: "redundant end of track",
+	DataAfterEndOfTrack: "data after end of track",
+	InvalidValue:        "invalid value",
+	LongVarLength:       "long variable length quantity",
+	MisplacedMessage:    "misplaced message",
+	UnknownChunk:        "unknown chunk",
+	UndefinedMessage:    "undefined message",
+	MissingStatus:       "missing status",
+}
+
+// String returns the name of the code
+func (c WarningCode) String() string {
+	if s, has := warningCodes[c]; has {
+		return s
+	}
+	return fmt.Sprintf("warning code %d", int(c))
+}
+
+// Category returns the category of the policy that the code belongs to (see Policy)
+func (c WarningCode) Category() smf.Category {
+	switch c {
+	case MisplacedMessage:
+		return smf.PlacementViolations
+	case InvalidValue:
+		return smf.RangeViolations
+	case UnknownChunk, UndefinedMessage:
+		return smf.UnknownData
+	case UnexpectedEnd, MissingTracks:
+		return smf.TruncatedData
+	default:
+		return smf.StructuralErrors
+	}
+}
+
+// Warning is a problem within the SMF data that the reader recovered from (see Policy).
+type Warning struct {
+	// Code is the kind of the problem
+	Code WarningCode
+
+	// Category is the category of the problem (see WarningCode.Category)
+	Category smf.Category
+
+	// Track is the number of the track (starting with 0) or -1, if the problem is not within a track
+	Track int16
+
+	// Offset is the number of bytes from the beginning of the SMF data to the position where the problem was noticed
+	Offset uint32
+
+	// Message describes the problem
+	Message string
+}
+
+// String represents the warning as a string
+func (w Warning) String() string {
+	return fmt.Sprintf("offset %v: %s", w.Offset, w.Message)
+}
+
+// WarningsOf returns the warnings that have been collected by rd so far.
+// It returns nil, if the policy of rd does not warn about any category (see Policy), otherwise a non nil slice.
+func WarningsOf(rd smf.Reader) []Warning {
+	r, ok := rd.(*reader)
+	if !ok || !r.policy.Warns() {
+		return nil
+	}
+	res := make([]Warning, len(r.warnings))
+	copy(res, r.warnings)
+	return res
+}
+
+// WarningsError summarizes the warnings of a reading. It is returned by Read, if the reading has been aborted by the
+// callback of OnWarning, and by SummaryOf.
+type WarningsError struct {
+	// Counts are the numbers of warnings by code, including the warning that caused the abort
+	Counts map[WarningCode]int
+
+	// Dropped is the number of warnings that could not be sent to the channel of the Warnings option
+	Dropped int
+
+	// Err is the error that has been returned by the callback, respectively the error that has ended the reading,
+	// e.g. smf.ErrFinished
+	Err error
+}
+
+// Error returns the error message, summarizing the counts of the warnings
+func (e *WarningsError) Error() string {
+	var codes []int
+	var total int
+
+	for c, n := range e.Counts {
+		codes = append(codes, int(c))
+		total += n
+	}
+
+	sort.Ints(codes)
+	var counts = make([]string, len(codes))
+
+	for i, c := range codes {
+		counts[i] = fmt.Sprintf("%v %s", e.Counts[WarningCode(c)], WarningCode(c))
+	}
+
+	if e.Dropped > 0 {
+		counts = append(counts, fmt.Sprintf("%v not sent", e.Dropped))
+	}
+
+	if e.Err == smf.ErrFinished {
+		return fmt.Sprintf("reading finished with %v warnings (%s)", total, strings.Join(counts, ", "))
+	}
+
+	return fmt.Sprintf("reading aborted after %v warnings (%s): %v", total, strings.Join(counts, ", "), e.Err)
+}
+
+// Unwrap returns the error of the callback, respectively the error that has ended the reading
+func (e *WarningsError) Unwrap() error {
+	return e.Err
+}
+
+// SummaryOf returns the summary of the warnings of rd, once the reading has ended, i.e. Read or ReadHeader has
+// returned an error (including smf.ErrFinished). It returns nil, if the reading has not ended yet or rd is not
+// a reader of this package.
+func SummaryOf(rd smf.Reader) *WarningsError {
+	r, ok := rd.(*reader)
+	if !ok || r.ended == nil {
+		return nil
+	}
+	if r.aborted != nil {
+		return r.aborted
+	}
+	return r.summary(r.ended)
+}
+
+// summary returns the summary of the warnings so far with the given error
+func (r *reader) summary(err error) *WarningsError {
+	res := &WarningsError{Counts: map[WarningCode]int{}, Dropped: r.dropped, Err: err}
+
+	for _, w := range r.warnings {
+		res.Counts[w.Code]++
+	}
+
+	return res
+}
+
+// Error is returned by Read for a problem within the SMF data, if the category of the problem fails by the
+// policy of the reader (see Policy)
+type Error struct {
+	// Code is the kind of the problem
+	Code WarningCode
+
+	// Category is the category of the problem (see WarningCode.Category)
+	Category smf.Category
+
+	// Track is the number of the track (starting with 0) or -1, if the problem is not within a track
+	Track int16
+
+	// Offset is the number of bytes from the beginning of the SMF data to the position where the problem was noticed
+	Offset uint32
+
+	// Message describes the problem
+	Message string
+
+	// Err is the underlying error, if any
+	Err error
+}
+
+// Error returns the message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// PolicyOf returns the policy of rd (see Policy). It returns smf.Default, if rd is not a reader of this package.
+func PolicyOf(rd smf.Reader) smf.Policy {
+	r, ok := rd.(*reader)
+	if !ok {
+		return smf.Default
+	}
+	return *r.policy
+}
+
+// problem handles a problem of the given code according to the policy: if the category of the code fails,
+// an *Error is returned, if it warns, a warning is reported.
+func (r *reader) problem(code WarningCode, format string, vals ...interface{}) error {
+	switch r.policy.Action(code.Category()) {
+	case smf.Fail:
+		return r.newError(code, nil, format, vals...)
+	case smf.Warn:
+		r.warn(code, format, vals...)
+	}
+	return nil
+}
+
+// newError returns an *Error for the given code and underlying error at the current position
+func (r *reader) newError(code WarningCode, err error, format string, vals ...interface{}) *Error {
+	return &Error{
+		Code:     code,
+		Category: code.Category(),
+		Track:    r.processedTracks,
+		Offset:   r.counter.n,
+		Message:  fmt.Sprintf(format, vals...),
+		Err:      err,
+	}
+}
+
+func (r *reader) warn(code WarningCode, format string, vals ...interface{}) {
+	w := Warning{
+		Code:     code,
+		Category: code.Category(),
+		Track:    r.processedTracks,
+		Offset:   r.counter.n,
+		Message:  fmt.Sprintf(format, vals...),
+	}
+
+	r.log("warning: %s", w)
+	r.warnings = append(r.warnings, w)
+
+	if r.warningsCh != nil {
+		r.sendWarning(w)
+	}
+
+	if r.onWarning != nil && r.aborted == nil {
+		if err := r.onWarning(w); err != nil {
+			r.aborted = r.summary(err)
+		}
+	}
+}
+
+// sendWarning sends the given warning to the channel of the Warnings option without blocking.
+// The warnings that can't be sent are counted.
+func (r *reader) sendWarning(w Warning) {
+	select {
+	case r.warningsCh <- w:
+	default:
+		r.dropped++
+	}
+}
+
+// end records the error that has ended the reading and closes the channel of the Warnings option
+func (r *reader) end(err error) {
+	if r.ended == nil {
+		r.ended = err
+	}
+
+	if r.warningsCh != nil {
+		close(r.warningsCh)
+		r.warningsCh = nil
+	}
+}
@@ -0,0 +1,493 @@
+package smfreader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/smf"
+)
+
+func readAll(input []byte, options ...Option) (smf.Reader, error) {
+	rd := New(bytes.NewReader(input), options...)
+	err := rd.ReadHeader()
+
+	for err == nil {
+		_, err = rd.Read()
+	}
+
+	return rd, err
+}
+
+func TestTolerant(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected []string
+	}{
+		{examples.SpecSMF1, nil},
+		{examples.SpecSMF1Missing, []string{
+			"offset 89: 1 of 4 tracks missing",
+		}},
+		{examples.SpecSMF1[:len(examples.SpecSMF1)-3], []string{
+			"offset 115: unexpected end of data in track 3 (missing end of track)",
+		}},
+		{examples.SpecSMF1[:60], []string{
+			"offset 60: unexpected end of data in track 1 (missing end of track)",
+			"offset 60: 2 of 4 tracks missing",
+		}},
+	}
+
+	for i, test := range tests {
+		rd, err := readAll(test.input, Tolerant())
+
+		if err != smf.ErrFinished {
+			t.Errorf("[%v] Read() error = %v; want %v", i, err, smf.ErrFinished)
+		}
+
+		warnings := WarningsOf(rd)
+
+		if warnings == nil {
+			t.Errorf("[%v] WarningsOf() = nil; want non nil", i)
+		}
+
+		if got, want := len(warnings), len(test.expected); got != want {
+			t.Errorf("[%v] len(WarningsOf()) = %v; want %v: %v", i, got, want, warnings)
+			continue
+		}
+
+		for j, w := range warnings {
+			if got, want := w.String(), test.expected[j]; got != want {
+				t.Errorf("[%v] WarningsOf()[%v] = %q; want %q", i, j, got, want)
+			}
+		}
+	}
+
+	// without the option, the data is rejected
+	if _, err := readAll(examples.SpecSMF1Missing); err != ErrMissing {
+		t.Errorf("Read() error = %v; want %v", err, ErrMissing)
+	}
+
+	if rd, _ := readAll(examples.SpecSMF1); WarningsOf(rd) != nil {
+		t.Errorf("WarningsOf() = %v; want nil", WarningsOf(rd))
+	}
+}
+
+// insertAfterTrack0 returns examples.SpecSMF1 with the given bytes inserted after the first track.
+// If inLength is true, the bytes are counted by the length of the track chunk.
+func insertAfterTrack0(b []byte, inLength bool) []byte {
+	data := append([]byte{}, examples.SpecSMF1[:42]...)
+	data = append(data, b...)
+	data = append(data, examples.SpecSMF1[42:]...)
+
+	if inLength {
+		data[21] += byte(len(b))
+	}
+
+	return data
+}
+
+func TestTolerantGarbage(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected []string
+	}{
+		{insertAfterTrack0([]byte{0x00, 0xFF, 0x2F, 0x00}, true), []string{
+			"offset 46: redundant end of track in track 0",
+		}},
+		{insertAfterTrack0([]byte{0x12, 0x34, 0x56}, true), []string{
+			"offset 45: 3 bytes after the end of track in track 0",
+		}},
+		{insertAfterTrack0([]byte{0x00, 0xFF, 0x2F, 0x00, 0x12}, false), []string{
+			"offset 51: 5 bytes of garbage before track 1",
+		}},
+	}
+
+	for i, test := range tests {
+		// the strict reader fails
+		if _, err := readAll(test.input, Policy(smf.Strict)); err == smf.ErrFinished {
+			t.Errorf("[%v] expected error with smf.Strict", i)
+		}
+
+		got := testRead(t, test.input, Tolerant())
+
+		if want := testRead(t, examples.SpecSMF1); got != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+
+		rd, _ := readAll(test.input, Tolerant())
+		warnings := WarningsOf(rd)
+
+		if got, want := len(warnings), len(test.expected); got != want {
+			t.Errorf("[%v] len(WarningsOf()) = %v; want %v: %v", i, got, want, warnings)
+			continue
+		}
+
+		for j, w := range warnings {
+			if got, want := w.String(), test.expected[j]; got != want {
+				t.Errorf("[%v] WarningsOf()[%v] = %q; want %q", i, j, got, want)
+			}
+		}
+	}
+}
+
+// patchSpecSMF1 returns a copy of examples.SpecSMF1 with the bytes at the given offset replaced by b
+func patchSpecSMF1(offset int, b ...byte) []byte {
+	data := append([]byte{}, examples.SpecSMF1...)
+	copy(data[offset:], b)
+	return data
+}
+
+func TestTolerantInvalidValues(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected string
+		message  string
+	}{
+		// FF 51 03 00 00 00
+		{patchSpecSMF1(34, 0x00, 0x00, 0x00),
+			"offset 37: tempo of 0 microseconds per quarter note in track 0 (clamped)",
+			"meta.Tempo BPM: 60000000.00",
+		},
+		// FF 58 04 04 08 18 08
+		{patchSpecSMF1(27, 0x08),
+			"offset 30: time signature denominator of 2^8 in track 0 (clamped)",
+			"meta.TimeSig 4/128 clocksperclick 24 dsqpq 8",
+		},
+		// FF 58 04 04 1F 18 08
+		{patchSpecSMF1(27, 0x1F),
+			"offset 30: time signature denominator of 2^31 in track 0 (clamped)",
+			"meta.TimeSig 4/128 clocksperclick 24 dsqpq 8",
+		},
+	}
+
+	for i, test := range tests {
+		// the strict reader fails
+		if _, err := readAll(test.input, Policy(smf.Strict)); err == smf.ErrFinished {
+			t.Errorf("[%v] expected error with smf.Strict", i)
+		}
+
+		rd := New(bytes.NewReader(test.input), Tolerant())
+		err := rd.ReadHeader()
+		var found bool
+
+		for err == nil {
+			var msg midi.Message
+			msg, err = rd.Read()
+
+			if err == nil && msg.String() == test.message {
+				found = true
+			}
+		}
+
+		if err != smf.ErrFinished {
+			t.Errorf("[%v] Read() error = %v; want %v", i, err, smf.ErrFinished)
+		}
+
+		if !found {
+			t.Errorf("[%v] missing clamped message %q", i, test.message)
+		}
+
+		warnings := WarningsOf(rd)
+
+		if len(warnings) != 1 {
+			t.Errorf("[%v] len(WarningsOf()) = %v; want 1: %v", i, len(warnings), warnings)
+			continue
+		}
+
+		if got, want := warnings[0].String(), test.expected; got != want {
+			t.Errorf("[%v] WarningsOf()[0] = %q; want %q", i, got, want)
+		}
+	}
+}
+
+// trackEvents returns the deltas and messages of the given SMF data, read with the given options
+func trackEvents(input []byte, options ...Option) (evts []string, rd smf.Reader, err error) {
+	rd = New(bytes.NewReader(input), options...)
+	err = rd.ReadHeader()
+
+	for err == nil {
+		var msg midi.Message
+		msg, err = rd.Read()
+
+		if err == nil {
+			evts = append(evts, fmt.Sprintf("%v %v: %v", rd.Track(), rd.Delta(), msg))
+		}
+	}
+
+	return
+}
+
+func TestTolerantMissingStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected []byte
+		warnings []string
+	}{
+		// an exporter that keeps the running status after meta messages
+		{"meta",
+			join(header(0, 1), chunk("MTrk",
+				0x00, 0x90, 0x3C, 0x64,
+				0x00, 0xFF, 0x01, 0x01, 0x61,
+				0x00, 0x40, 0x64, 0x60, 0x3C, 0x00,
+				0x00, 0xFF, 0x06, 0x01, 0x62,
+				0x00, 0x40, 0x00,
+				0x00, 0xFF, 0x2F, 0x00)),
+			join(header(0, 1), chunk("MTrk",
+				0x00, 0x90, 0x3C, 0x64,
+				0x00, 0xFF, 0x01, 0x01, 0x61,
+				0x00, 0x90, 0x40, 0x64, 0x60, 0x3C, 0x00,
+				0x00, 0xFF, 0x06, 0x01, 0x62,
+				0x00, 0x90, 0x40, 0x00,
+				0x00, 0xFF, 0x2F, 0x00)),
+			[]string{
+				"offset 33: data byte 40 without running status in track 0 (continued with status 90)",
+				"offset 44: data byte 40 without running status in track 0 (continued with status 90)",
+			},
+		},
+		// and after system exclusive messages
+		{"sysex",
+			join(header(0, 1), chunk("MTrk",
+				0x00, 0xB1, 0x07, 0x64,
+				0x00, 0xF0, 0x03, 0x7E, 0x09, 0xF7,
+				0x00, 0x0A, 0x40,
+				0x00, 0xFF, 0x2F, 0x00)),
+			join(header(0, 1), chunk("MTrk",
+				0x00, 0xB1, 0x07, 0x64,
+				0x00, 0xF0, 0x03, 0x7E, 0x09, 0xF7,
+				0x00, 0xB1, 0x0A, 0x40,
+				0x00, 0xFF, 0x2F, 0x00)),
+			[]string{
+				"offset 34: data byte 0A without running status in track 0 (continued with status B1)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, rd, err := trackEvents(test.input, Tolerant())
+
+		if err != smf.ErrFinished {
+			t.Errorf("[%s] Read() error = %v; want %v", test.name, err, smf.ErrFinished)
+		}
+
+		expected, _, _ := trackEvents(test.expected)
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("[%s] got:\n%v\n\nwanted:\n%v\n\n", test.name, strings.Join(got, "\n"), strings.Join(expected, "\n"))
+		}
+
+		var warnings []string
+
+		for _, w := range WarningsOf(rd) {
+			warnings = append(warnings, w.String())
+		}
+
+		if !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("[%s] WarningsOf() = %q; want %q", test.name, warnings, test.warnings)
+		}
+
+		// the strict reader fails
+		var perr *Error
+
+		if _, _, err := trackEvents(test.input, Policy(smf.Strict)); !errors.As(err, &perr) || perr.Code != MissingStatus {
+			t.Errorf("[%s] Read() error = %v; want missing status", test.name, err)
+		}
+	}
+
+	// without previous channel message, there is no status to continue with, even across tracks
+	unrecoverable := [][]byte{
+		join(header(0, 1), chunk("MTrk", 0x00, 0x3C, 0x64, 0x00, 0xFF, 0x2F, 0x00)),
+		join(header(1, 2), chunk("MTrk", 0x00, 0x90, 0x3C, 0x64, 0x00, 0xFF, 0x2F, 0x00), chunk("MTrk", 0x00, 0x3C, 0x00, 0x00, 0xFF, 0x2F, 0x00)),
+	}
+
+	for i, input := range unrecoverable {
+		var perr *Error
+
+		if _, _, err := trackEvents(input, Tolerant()); !errors.As(err, &perr) || perr.Code != MissingStatus {
+			t.Errorf("[%v] Read() error = %v; want missing status", i, err)
+		}
+	}
+}
+
+// multiDefect returns examples.SpecSMF1 with a tempo of 0, 5 bytes of garbage before track 1 and a missing end of
+// the last track
+func multiDefect() []byte {
+	data := patchSpecSMF1(34, 0x00, 0x00, 0x00)
+	data = append(data[:42:42], append([]byte{0x00, 0xFF, 0x2F, 0x00, 0x12}, data[42:]...)...)
+	return data[:len(data)-3]
+}
+
+func TestWarningsStreamed(t *testing.T) {
+	expected := []Warning{
+		{Code: InvalidValue, Category: smf.RangeViolations, Track: 0, Offset: 37, Message: "tempo of 0 microseconds per quarter note in track 0 (clamped)"},
+		{Code: Garbage, Category: smf.StructuralErrors, Track: 0, Offset: 51, Message: "5 bytes of garbage before track 1"},
+		{Code: UnexpectedEnd, Category: smf.TruncatedData, Track: 3, Offset: 120, Message: "unexpected end of data in track 3 (missing end of track)"},
+	}
+
+	ch := make(chan Warning, 10)
+	var called []Warning
+
+	rd := New(bytes.NewReader(multiDefect()), Warnings(ch), OnWarning(func(w Warning) error {
+		called = append(called, w)
+
+		// the warning has been sent before the callback is called
+		if got := len(ch); got != len(called) {
+			t.Errorf("len(ch) = %v; want %v", got, len(called))
+		}
+		return nil
+	}))
+
+	err := rd.ReadHeader()
+
+	for err == nil {
+		_, err = rd.Read()
+	}
+
+	if err != smf.ErrFinished {
+		t.Errorf("Read() error = %v; want %v", err, smf.ErrFinished)
+	}
+
+	// the channel has been closed by the reader
+	var sent []Warning
+
+	for w := range ch {
+		sent = append(sent, w)
+	}
+
+	for _, got := range [][]Warning{called, sent, WarningsOf(rd)} {
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("warnings = %v; want %v", got, expected)
+		}
+	}
+
+	summary := SummaryOf(rd)
+
+	if summary == nil || summary.Err != smf.ErrFinished {
+		t.Fatalf("SummaryOf() = %v; want summary of finished reading", summary)
+	}
+
+	if got, want := summary.Error(), "reading finished with 3 warnings (1 unexpected end, 1 garbage, 1 invalid value)"; got != want {
+		t.Errorf("SummaryOf().Error() = %q; want %q", got, want)
+	}
+}
+
+func TestWarningsAbort(t *testing.T) {
+	budget := errors.New("budget exceeded")
+	var messages int
+
+	rd := New(bytes.NewReader(multiDefect()), OnWarning(func(w Warning) error {
+		if w.Code == Garbage {
+			return budget
+		}
+		return nil
+	}))
+
+	err := rd.ReadHeader()
+
+	for err == nil {
+		_, err = rd.Read()
+		messages++
+	}
+
+	werr, is := err.(*WarningsError)
+
+	if !is {
+		t.Fatalf("Read() error = %v; want *WarningsError", err)
+	}
+
+	if werr.Err != budget {
+		t.Errorf("Err = %v; want %v", werr.Err, budget)
+	}
+
+	if got, want := werr.Error(), "reading aborted after 2 warnings (1 garbage, 1 invalid value): budget exceeded"; got != want {
+		t.Errorf("Error() = %q; want %q", got, want)
+	}
+
+	// the reading stops within the second track
+	if messages > 8 {
+		t.Errorf("read %v messages; want at most 8", messages)
+	}
+
+	if _, err := rd.Read(); err != werr {
+		t.Errorf("second Read() error = %v; want %v", err, werr)
+	}
+
+	if got := SummaryOf(rd); got != werr {
+		t.Errorf("SummaryOf() = %v; want %v", got, werr)
+	}
+}
+
+func TestWarningsChannelNotReceived(t *testing.T) {
+	tests := map[string]struct {
+		mk      func() chan Warning
+		dropped int
+	}{
+		"ignored": {func() chan Warning { return make(chan Warning) }, 3},
+		"full":    {func() chan Warning { return make(chan Warning, 1) }, 2},
+	}
+
+	for name, test := range tests {
+		done := make(chan error)
+		ch := test.mk()
+
+		go func() {
+			rd, err := readAll(multiDefect(), Warnings(ch))
+
+			if len(WarningsOf(rd)) != 3 {
+				t.Errorf("[%v] len(WarningsOf()) = %v; want 3", name, len(WarningsOf(rd)))
+			}
+
+			// the warnings that were not sent are counted
+			if summary := SummaryOf(rd); summary == nil || summary.Dropped != test.dropped {
+				t.Errorf("[%v] SummaryOf() = %v; want %v dropped", name, summary, test.dropped)
+			}
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err != smf.ErrFinished {
+				t.Errorf("[%v] Read() error = %v; want %v", name, err, smf.ErrFinished)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("[%v] reading is blocked", name)
+		}
+
+		for range ch {
+		}
+	}
+}
+
+func TestWarningsChannelConcurrent(t *testing.T) {
+	ch := make(chan Warning, 1)
+	received := make(chan int)
+
+	go func() {
+		var n int
+		for range ch {
+			n++
+		}
+		received <- n
+	}()
+
+	_, err := readAll(multiDefect(), Warnings(ch))
+
+	if err != smf.ErrFinished {
+		t.Errorf("Read() error = %v; want %v", err, smf.ErrFinished)
+	}
+
+	select {
+	case n := <-received:
+		if n < 1 || n > 3 {
+			t.Errorf("received %v warnings; want 1 to 3", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("channel has not been closed by the reader")
+	}
+}
@@ -0,0 +1,690 @@
+package smftest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// TB is the part of testing.TB that is used by Assert and AssertFile
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// AssertFile reads the given SMF file and checks the given assertions like Assert.
+// If the file can't be read, the test fails immediately.
+func AssertFile(t TB, file string, assertions ...string) {
+	t.Helper()
+	s, err := smftrack.ReadFile(file)
+
+	if err != nil {
+		t.Fatalf("can't read %s: %v", file, err)
+		return
+	}
+
+	Assert(t, s, assertions...)
+}
+
+// Assert checks the given assertions against the given SMF (see Check for the grammar) and reports each failed or
+// invalid assertion as an error of the test.
+func Assert(t TB, s *smftrack.SMF, assertions ...string) {
+	t.Helper()
+
+	for _, a := range assertions {
+		if err := Check(s, a); err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+}
+
+// Check checks the given assertions against the given SMF and returns the failed and invalid assertions as
+// errors. A failure lists the nearest candidates, i.e. the messages of the type that fail the least conditions.
+//
+// An assertion has the form
+//
+//	[scope ":"] [count] type {condition} ["@" ticks]
+//
+// The scope is "track N" (starting with 0), "conductor" (the first track) or "any" (the default).
+// The count is "exactly N", "at least N", "at most N" or "no"; the default is at least one message.
+// The type is one of
+//
+//	noteon noteoff cc program pitchbend aftertouch polyaftertouch
+//	tempo timesig text marker lyric trackname cuepoint copyright sysex
+//
+// A note on message with velocity 0 is a noteoff. A condition is a field, an operator (= != < <= > >=) and a
+// value without spaces, e.g. vel>=90, or just a value for the main field of the type (shown first below):
+//
+//	noteon, noteoff:  key vel ch
+//	polyaftertouch:   key value ch
+//	cc:               cc value ch
+//	program:          program ch
+//	pitchbend:        value ch
+//	aftertouch:       value ch
+//	tempo:            bpm
+//	timesig:          sig num denom
+//	text types:       text
+//	sysex:            len
+//
+// Keys may be given as note names (e.g. C4 for 60, see channel.ParseNoteNumber), channels are counted from 1, as in
+// the message strings, and texts are quoted with double quotes. The value of = may be a range of the form a..b that
+// includes both ends. The ticks are a single tick N, or a range of the form a..b that includes a but not b; either
+// end of the range may be left out. Examples:
+//
+//	track 2: noteon C4 vel>=90 @ 0..480
+//	conductor: tempo 120 @ 0
+//	exactly 4 noteon ch=10
+//	no pitchbend
+//	track 1: marker "chorus" @ 3840..
+func Check(s *smftrack.SMF, assertions ...string) error {
+	var errs []error
+
+	for _, text := range assertions {
+		a, err := parseAssertion(text)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid assertion %q: %v", text, err))
+			continue
+		}
+
+		if err := a.check(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// assertion is a parsed assertion of Check
+type assertion struct {
+	text string
+
+	// track is the number of the track, -1 for any track
+	track int
+
+	// min and max are the bounds of the number of matching messages, max is -1 for no bound
+	min, max int
+
+	typ   string
+	conds []condition
+
+	// from and to are the range of the ticks, to is excluded, if hasTo is true
+	from  uint64
+	to    uint64
+	hasTo bool
+}
+
+// condition is a condition of an assertion
+type condition struct {
+	text  string
+	field string
+	op    string
+
+	// num and numTo are numeric values (numTo for ranges), str is the value of string fields
+	num   float64
+	numTo float64
+	str   string
+}
+
+// fields are the fields of the types, the main field first
+var fields = map[string][]string{
+	"noteon":         {"key", "vel", "ch"},
+	"noteoff":        {"key", "vel", "ch"},
+	"polyaftertouch": {"key", "value", "ch"},
+	"cc":             {"cc", "value", "ch"},
+	"program":        {"program", "ch"},
+	"pitchbend":      {"value", "ch"},
+	"aftertouch":     {"value", "ch"},
+	"tempo":          {"bpm"},
+	"timesig":        {"sig", "num", "denom"},
+	"text":           {"text"},
+	"marker":         {"text"},
+	"lyric":          {"text"},
+	"trackname":      {"text"},
+	"cuepoint":       {"text"},
+	"copyright":      {"text"},
+	"sysex":          {"len"},
+}
+
+// stringFields are the fields with string values
+var stringFields = map[string]bool{"sig": true, "text": true}
+
+var conditionRegexp = regexp.MustCompile(`^([a-z]+)(<=|>=|!=|=|<|>)(.+)$`)
+
+// operatorReplacer replaces the typographic operators
+var operatorReplacer = strings.NewReplacer("≥", ">=", "≤", "<=", "≠", "!=", "–", "..")
+
+func parseAssertion(text string) (*assertion, error) {
+	a := &assertion{text: text, track: -1, min: 1, max: -1}
+	rest := operatorReplacer.Replace(text)
+
+	if i := strings.Index(rest, ":"); i >= 0 && !strings.Contains(rest[:i], `"`) {
+		if err := a.parseScope(strings.Fields(rest[:i])); err != nil {
+			return nil, err
+		}
+		rest = rest[i+1:]
+	}
+
+	tokens, err := tokenize(rest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err = a.parseCount(tokens)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("missing message type")
+	}
+
+	a.typ = tokens[0]
+
+	if _, known := fields[a.typ]; !known {
+		return nil, fmt.Errorf("unknown message type %q", a.typ)
+	}
+
+	tokens = tokens[1:]
+
+	for i, tok := range tokens {
+		if strings.HasPrefix(tok, "@") {
+			return a, a.parseTicks(strings.Join(append([]string{tok[1:]}, tokens[i+1:]...), ""))
+		}
+
+		c, err := parseCondition(a.typ, tok)
+
+		if err != nil {
+			return nil, err
+		}
+
+		a.conds = append(a.conds, c)
+	}
+
+	return a, nil
+}
+
+func (a *assertion) parseScope(words []string) error {
+	switch {
+	case len(words) == 1 && words[0] == "conductor":
+		a.track = 0
+	case len(words) == 1 && words[0] == "any":
+	case len(words) == 2 && words[0] == "track":
+		n, err := strconv.Atoi(words[1])
+
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid track %q", words[1])
+		}
+
+		a.track = n
+	default:
+		return fmt.Errorf("invalid scope %q", strings.Join(words, " "))
+	}
+
+	return nil
+}
+
+// parseCount parses the count at the start of the given tokens and returns the rest
+func (a *assertion) parseCount(tokens []string) ([]string, error) {
+	number := func(i int) (int, error) {
+		if len(tokens) <= i {
+			return 0, fmt.Errorf("missing count")
+		}
+
+		n, err := strconv.Atoi(tokens[i])
+
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid count %q", tokens[i])
+		}
+
+		return n, nil
+	}
+
+	switch {
+	case len(tokens) > 0 && tokens[0] == "no":
+		a.min, a.max = 0, 0
+		return tokens[1:], nil
+	case len(tokens) > 0 && tokens[0] == "exactly":
+		n, err := number(1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		a.min, a.max = n, n
+		return tokens[2:], nil
+	case len(tokens) > 1 && tokens[0] == "at" && (tokens[1] == "least" || tokens[1] == "most"):
+		n, err := number(2)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if tokens[1] == "least" {
+			a.min = n
+		} else {
+			a.min, a.max = 0, n
+		}
+
+		return tokens[3:], nil
+	}
+
+	return tokens, nil
+}
+
+// parseTicks parses the ticks after the @
+func (a *assertion) parseTicks(s string) error {
+	from, to, isRange := strings.Cut(s, "..")
+
+	parse := func(s string) (uint64, error) {
+		n, err := strconv.ParseUint(s, 10, 64)
+
+		if err != nil {
+			return 0, fmt.Errorf("invalid ticks %q", s)
+		}
+
+		return n, nil
+	}
+
+	var err error
+
+	if !isRange {
+		a.from, err = parse(from)
+		a.to, a.hasTo = a.from+1, true
+		return err
+	}
+
+	if from != "" {
+		if a.from, err = parse(from); err != nil {
+			return err
+		}
+	}
+
+	if to != "" {
+		if a.to, err = parse(to); err != nil {
+			return err
+		}
+
+		a.hasTo = true
+	}
+
+	return nil
+}
+
+// tokenize splits s at spaces, keeping quoted strings (that may be the value of a condition) together
+func tokenize(s string) (tokens []string, err error) {
+	var tok strings.Builder
+	var quoted, escaped bool
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && (r == ' ' || r == '\t'):
+			if tok.Len() > 0 {
+				tokens = append(tokens, tok.String())
+				tok.Reset()
+			}
+			continue
+		}
+
+		tok.WriteRune(r)
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated string")
+	}
+
+	if tok.Len() > 0 {
+		tokens = append(tokens, tok.String())
+	}
+
+	return tokens, nil
+}
+
+func parseCondition(typ, tok string) (c condition, err error) {
+	c.text = tok
+	c.field, c.op = fields[typ][0], "="
+	value := tok
+
+	if m := conditionRegexp.FindStringSubmatch(tok); m != nil && !strings.HasPrefix(tok, `"`) {
+		c.field, c.op, value = m[1], m[2], m[3]
+	}
+
+	valid := false
+
+	for _, f := range fields[typ] {
+		valid = valid || f == c.field
+	}
+
+	if !valid {
+		return c, fmt.Errorf("unknown field %q of %s", c.field, typ)
+	}
+
+	if stringFields[c.field] {
+		if c.op != "=" && c.op != "!=" {
+			return c, fmt.Errorf("invalid operator %s for %s", c.op, c.field)
+		}
+
+		c.str = value
+
+		if strings.HasPrefix(value, `"`) {
+			c.str, err = strconv.Unquote(value)
+		}
+
+		return c, err
+	}
+
+	from, to, isRange := strings.Cut(value, "..")
+
+	if isRange && c.op != "=" {
+		return c, fmt.Errorf("invalid operator %s for range %s", c.op, value)
+	}
+
+	if c.num, err = parseNumber(c.field, from); err != nil {
+		return c, err
+	}
+
+	c.numTo = c.num
+
+	if isRange {
+		c.op = ".."
+		c.numTo, err = parseNumber(c.field, to)
+	}
+
+	return c, err
+}
+
+// parseNumber parses the value of a numeric field; keys may be note names
+func parseNumber(field, s string) (float64, error) {
+	if field == "key" && s != "" && (s[0] < '0' || s[0] > '9') {
+		key, err := channel.ParseNoteNumber(s)
+		return float64(key), err
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q of %s", s, field)
+	}
+
+	return v, nil
+}
+
+// typeOf returns the type of the given message in terms of the assertions, or an empty string
+func typeOf(msg midi.Message) string {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		if v.Velocity() == 0 {
+			return "noteoff"
+		}
+		return "noteon"
+	case channel.NoteOff, channel.NoteOffVelocity:
+		return "noteoff"
+	case channel.ControlChange:
+		return "cc"
+	case channel.ProgramChange:
+		return "program"
+	case channel.Pitchbend:
+		return "pitchbend"
+	case channel.Aftertouch:
+		return "aftertouch"
+	case channel.PolyAftertouch:
+		return "polyaftertouch"
+	case meta.Tempo:
+		return "tempo"
+	case meta.TimeSig:
+		return "timesig"
+	case meta.Text:
+		return "text"
+	case meta.Marker:
+		return "marker"
+	case meta.Lyric:
+		return "lyric"
+	case meta.Track:
+		return "trackname"
+	case meta.Cuepoint:
+		return "cuepoint"
+	case meta.Copyright:
+		return "copyright"
+	case sysex.Message:
+		return "sysex"
+	}
+
+	return ""
+}
+
+// fieldOf returns the value of the given field of the given message
+func fieldOf(msg midi.Message, field string) (num float64, str string) {
+	if cm, is := msg.(channel.Message); is && field == "ch" {
+		return float64(cm.Channel()) + 1, ""
+	}
+
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		if field == "key" {
+			return float64(v.Key()), ""
+		}
+		return float64(v.Velocity()), ""
+	case channel.NoteOff:
+		if field == "key" {
+			return float64(v.Key()), ""
+		}
+		return 0, ""
+	case channel.NoteOffVelocity:
+		if field == "key" {
+			return float64(v.Key()), ""
+		}
+		return float64(v.Velocity()), ""
+	case channel.PolyAftertouch:
+		if field == "key" {
+			return float64(v.Key()), ""
+		}
+		return float64(v.Pressure()), ""
+	case channel.ControlChange:
+		if field == "cc" {
+			return float64(v.Controller()), ""
+		}
+		return float64(v.Value()), ""
+	case channel.ProgramChange:
+		return float64(v.Program()), ""
+	case channel.Pitchbend:
+		return float64(v.Value()), ""
+	case channel.Aftertouch:
+		return float64(v.Pressure()), ""
+	case meta.Tempo:
+		return math.Round(v.FractionalBPM()*100) / 100, ""
+	case meta.TimeSig:
+		switch field {
+		case "num":
+			return float64(v.Numerator), ""
+		case "denom":
+			return float64(v.Denominator), ""
+		}
+		return 0, v.Signature()
+	case interface{ Text() string }:
+		return 0, v.Text()
+	case sysex.Message:
+		return float64(len(v.Data())), ""
+	}
+
+	return 0, ""
+}
+
+// matches returns true, if the given message fulfills the condition
+func (c condition) matches(msg midi.Message) bool {
+	num, str := fieldOf(msg, c.field)
+
+	if stringFields[c.field] {
+		return (str == c.str) == (c.op == "=")
+	}
+
+	switch c.op {
+	case "=":
+		return num == c.num
+	case "!=":
+		return num != c.num
+	case "<":
+		return num < c.num
+	case "<=":
+		return num <= c.num
+	case ">":
+		return num > c.num
+	case ">=":
+		return num >= c.num
+	default:
+		return num >= c.num && num <= c.numTo
+	}
+}
+
+// candidate is a message of the type of an assertion with the conditions that it fails
+type candidate struct {
+	track    int
+	ev       smftrack.Event
+	failed   []string
+	distance uint64
+}
+
+func (c candidate) String() string {
+	s := fmt.Sprintf("track %v at tick %v: %s", c.track, c.ev.AbsTicks, c.ev.Message)
+
+	if len(c.failed) > 0 {
+		s += " (not " + strings.Join(c.failed, ", not ") + ")"
+	}
+
+	return s
+}
+
+// ticksText returns the tick condition as text
+func (a *assertion) ticksText() string {
+	switch {
+	case a.hasTo && a.to == a.from+1:
+		return fmt.Sprintf("@ %v", a.from)
+	case a.hasTo:
+		return fmt.Sprintf("@ %v..%v", a.from, a.to)
+	default:
+		return fmt.Sprintf("@ %v..", a.from)
+	}
+}
+
+// distance returns the distance of the given tick to the range of the ticks
+func (a *assertion) distance(tick uint64) uint64 {
+	switch {
+	case tick < a.from:
+		return a.from - tick
+	case a.hasTo && tick >= a.to:
+		return tick - a.to + 1
+	}
+
+	return 0
+}
+
+func (a *assertion) check(s *smftrack.SMF) error {
+	tracks := s.Tracks()
+
+	if a.track >= len(tracks) {
+		return fmt.Errorf("assertion %q failed: track %v does not exist (%v tracks)", a.text, a.track, len(tracks))
+	}
+
+	var matching, others []candidate
+
+	for no, tr := range tracks {
+		if a.track >= 0 && no != a.track {
+			continue
+		}
+
+		for _, ev := range tr.Events() {
+			if typeOf(ev.Message) != a.typ {
+				continue
+			}
+
+			c := candidate{track: no, ev: ev, distance: a.distance(ev.AbsTicks)}
+
+			for _, cond := range a.conds {
+				if !cond.matches(ev.Message) {
+					c.failed = append(c.failed, cond.text)
+				}
+			}
+
+			if c.distance > 0 {
+				c.failed = append(c.failed, a.ticksText())
+			}
+
+			if len(c.failed) == 0 {
+				matching = append(matching, c)
+			} else {
+				others = append(others, c)
+			}
+		}
+	}
+
+	n := len(matching)
+
+	if n >= a.min && (a.max < 0 || n <= a.max) {
+		return nil
+	}
+
+	var want string
+
+	switch {
+	case a.max == 0:
+		want = "none"
+	case a.min == a.max:
+		want = fmt.Sprintf("exactly %v", a.min)
+	case a.max < 0:
+		want = fmt.Sprintf("at least %v", a.min)
+	default:
+		want = fmt.Sprintf("at most %v", a.max)
+	}
+
+	msg := fmt.Sprintf("assertion %q failed: %v matching messages, want %s", a.text, n, want)
+	list, title := matching, "matching"
+
+	// too few: the candidates that fail the least conditions, nearest to the ticks first
+	if n < a.min {
+		list, title = others, "nearest candidates"
+		sort.SliceStable(list, func(i, j int) bool {
+			if len(list[i].failed) != len(list[j].failed) {
+				return len(list[i].failed) < len(list[j].failed)
+			}
+			return list[i].distance < list[j].distance
+		})
+	}
+
+	if len(list) == 0 {
+		return errors.New(msg + fmt.Sprintf("; no %s messages", a.typ))
+	}
+
+	msg += "; " + title + ":"
+
+	for i, c := range list {
+		if i == maxCandidates {
+			msg += fmt.Sprintf("\n\t... and %v more", len(list)-i)
+			break
+		}
+
+		msg += "\n\t" + c.String()
+	}
+
+	return errors.New(msg)
+}
+
+// maxCandidates is the maximal number of messages that are listed by a failure
+const maxCandidates = 3
@@ -0,0 +1,159 @@
+package smftest
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// assertSMF returns a SMF1 with a conductor track, a track with 4 drum notes and a bass track
+func assertSMF() *smftrack.SMF {
+	var conductor, drums, bass smftrack.Track
+	conductor.Add(0, meta.BPM(120), meta.TimeSig{Numerator: 3, Denominator: 4}, meta.Track("song"))
+	conductor.Add(1440, meta.Marker("chorus: loud"), meta.Tempo(476190))
+
+	for i := uint64(0); i < 4; i++ {
+		drums.Add(i*240, channel.Channel9.NoteOn(36, uint8(80+i*10)))
+		drums.Add(i*240+60, channel.Channel9.NoteOff(36))
+	}
+
+	bass.Add(0, channel.Channel1.ProgramChange(33), channel.Channel1.ControlChange(7, 100))
+	bass.Add(480, channel.Channel1.NoteOn(60, 95), sysex.SysEx{0x7D, 0x01})
+	bass.Add(960, channel.Channel1.NoteOn(60, 0), channel.Channel1.Pitchbend(-200))
+
+	s := smftrack.New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&drums)
+	s.AddTrack(&bass)
+	return s
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		assertion string
+		expected  string
+	}{
+		{`track 2: noteon C4 vel>=90 @ 0..481`, ``},
+		{`track 2: noteon C4 vel≥90 @ 0–481`, ``},
+		{`conductor: tempo 120 @ 0`, ``},
+		{`conductor: tempo bpm=125.99..126.01 @ 1440`, ``},
+		{`timesig 3/4`, ``},
+		{`timesig num=3 denom=4`, ``},
+		{`conductor: trackname "song"`, ``},
+		{`marker "chorus: loud" @ 1440..`, ``},
+		{`exactly 4 noteon ch=10 key=36`, ``},
+		{`exactly 3 noteon vel>85 vel!=110`, ``},
+		{`at least 2 noteoff`, ``},
+		{`at most 1 noteon @ ..240`, ``},
+		{`track 1: no noteon key=C4`, ``},
+		{`no polyaftertouch`, ``},
+		{`track 2: noteoff C4 @ 960`, ``},
+		{`track 2: program 33 ch=2`, ``},
+		{`cc 7 value=100`, ``},
+		{`pitchbend value<0`, ``},
+		{`sysex len=2`, ``},
+		{
+			`track 2: noteon C4 vel>=96 @ 0..480`,
+			`assertion "track 2: noteon C4 vel>=96 @ 0..480" failed: 0 matching messages, want at least 1; nearest candidates:
+	track 2 at tick 480: channel.NoteOn channel 2 key 60 velocity 95 (not vel>=96, not @ 0..480)`,
+		},
+		{
+			`track 1: noteon vel=100 @ 600`,
+			`assertion "track 1: noteon vel=100 @ 600" failed: 0 matching messages, want at least 1; nearest candidates:
+	track 1 at tick 480: channel.NoteOn channel 10 key 36 velocity 100 (not @ 600)
+	track 1 at tick 720: channel.NoteOn channel 10 key 36 velocity 110 (not vel=100, not @ 600)
+	track 1 at tick 240: channel.NoteOn channel 10 key 36 velocity 90 (not vel=100, not @ 600)
+	... and 1 more`,
+		},
+		{
+			`exactly 3 noteon ch=10`,
+			`assertion "exactly 3 noteon ch=10" failed: 4 matching messages, want exactly 3; matching:
+	track 1 at tick 0: channel.NoteOn channel 10 key 36 velocity 80
+	track 1 at tick 240: channel.NoteOn channel 10 key 36 velocity 90
+	track 1 at tick 480: channel.NoteOn channel 10 key 36 velocity 100
+	... and 1 more`,
+		},
+		{
+			`no pitchbend`,
+			`assertion "no pitchbend" failed: 1 matching messages, want none; matching:
+	track 2 at tick 960: channel.Pitchbend channel 2 value -200 absValue 0`,
+		},
+		{
+			`conductor: noteon`,
+			`assertion "conductor: noteon" failed: 0 matching messages, want at least 1; no noteon messages`,
+		},
+		{`track 3: noteon`, `assertion "track 3: noteon" failed: track 3 does not exist (3 tracks)`},
+		{`noteon vel=>90`, `invalid assertion "noteon vel=>90": invalid value ">90" of vel`},
+		{`noteon H4`, `invalid assertion "noteon H4": invalid note name "H4"`},
+		{`noteon bpm=120`, `invalid assertion "noteon bpm=120": unknown field "bpm" of noteon`},
+		{`note C4`, `invalid assertion "note C4": unknown message type "note"`},
+		{`bar 2: noteon`, `invalid assertion "bar 2: noteon": invalid scope "bar 2"`},
+		{`exactly noteon`, `invalid assertion "exactly noteon": invalid count "noteon"`},
+		{`at least`, `invalid assertion "at least": missing count`},
+		{`marker "verse`, `invalid assertion "marker \"verse": unterminated string`},
+		{`marker text<"a"`, `invalid assertion "marker text<\"a\"": invalid operator < for text`},
+		{`noteon @ x..480`, `invalid assertion "noteon @ x..480": invalid ticks "x"`},
+		{`noteon vel>1..2`, `invalid assertion "noteon vel>1..2": invalid operator > for range 1..2`},
+		{``, `invalid assertion "": missing message type`},
+	}
+
+	s := assertSMF()
+
+	for _, test := range tests {
+		var got string
+
+		if err := Check(s, test.assertion); err != nil {
+			got = err.Error()
+		}
+
+		if got != test.expected {
+			t.Errorf("Check(%q):\ngot:\n%s\n\nwanted:\n%s\n\n", test.assertion, got, test.expected)
+		}
+	}
+}
+
+// recordingTB records the errors of Assert and AssertFile
+type recordingTB struct {
+	errors []string
+	fatal  string
+}
+
+func (t *recordingTB) Helper() {}
+
+func (t *recordingTB) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingTB) Fatalf(format string, args ...interface{}) {
+	t.fatal = fmt.Sprintf(format, args...)
+}
+
+func TestAssertFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "assert.mid")
+
+	if err := assertSMF().WriteFile(file); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	AssertFile(t, file, "track 2: noteon C4 vel>=90 @ 0..481", "conductor: tempo 120 @ 0", "exactly 4 noteon ch=10")
+
+	var rec recordingTB
+	AssertFile(&rec, file, "no noteon", "conductor: tempo 120 @ 0", "noteon vel=")
+
+	if len(rec.errors) != 2 || rec.fatal != "" {
+		t.Errorf("AssertFile() reported %q, fatal %q; want 2 errors", rec.errors, rec.fatal)
+	}
+
+	rec = recordingTB{}
+	AssertFile(&rec, filepath.Join(t.TempDir(), "missing.mid"), "noteon")
+
+	if rec.fatal == "" {
+		t.Errorf("AssertFile() of missing file did not fail")
+	}
+}
@@ -0,0 +1,104 @@
+package smftest
+
+import (
+	"encoding/binary"
+	"math/rand"
+)
+
+// Corruption is a kind of damage that Generate applies to the generated data
+type Corruption int
+
+const (
+	// Truncate cuts the data at a random position within the last track chunk
+	Truncate Corruption = iota + 1
+
+	// FlipLengthByte changes the lowest byte of the declared length of a random track chunk
+	FlipLengthByte
+
+	// DropEndOfTrack removes the end of track message (including its delta time) of a random track chunk,
+	// while keeping the declared length of the chunk correct
+	DropEndOfTrack
+)
+
+// Corruptions returns all kinds of Corruption
+func Corruptions() []Corruption {
+	return []Corruption{Truncate, FlipLengthByte, DropEndOfTrack}
+}
+
+// String returns the name of the corruption
+func (c Corruption) String() string {
+	switch c {
+	case Truncate:
+		return "Truncate"
+	case FlipLengthByte:
+		return "FlipLengthByte"
+	case DropEndOfTrack:
+		return "DropEndOfTrack"
+	}
+	return "unknown corruption"
+}
+
+// apply applies the corruption to the given data and returns the result
+func (c Corruption) apply(r *rand.Rand, data []byte) []byte {
+	chunks := trackChunks(data)
+
+	if len(chunks) == 0 {
+		return data
+	}
+
+	switch c {
+	case Truncate:
+		last := chunks[len(chunks)-1]
+		if len(data)-last.data < 1 {
+			return data
+		}
+		return data[:last.data+r.Intn(len(data)-last.data)]
+	case FlipLengthByte:
+		ch := chunks[r.Intn(len(chunks))]
+		data[ch.data-1] ^= byte(1 + r.Intn(255))
+	case DropEndOfTrack:
+		ch := chunks[r.Intn(len(chunks))]
+		end := ch.data + ch.length
+
+		if end > len(data) || ch.length < 4 || data[end-3] != 0xFF || data[end-2] != 0x2F || data[end-1] != 0x00 {
+			return data
+		}
+
+		// the delta time ends with a byte below 0x80 and may have preceding bytes with the high bit set
+		start := end - 4
+		for start > ch.data && data[start-1] >= 0x80 {
+			start--
+		}
+
+		res := append(append([]byte{}, data[:start]...), data[end:]...)
+		binary.BigEndian.PutUint32(res[ch.data-4:], uint32(ch.length-(end-start)))
+		return res
+	}
+
+	return data
+}
+
+// trackChunk is the position of a track chunk within the data
+type trackChunk struct {
+	// data is the offset of the data after the chunk header
+	data int
+
+	// length is the declared length
+	length int
+}
+
+// trackChunks returns the track chunks of the given SMF data
+func trackChunks(data []byte) (chunks []trackChunk) {
+	// after the header chunk
+	for pos := 14; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos+4:]))
+
+		if string(data[pos:pos+4]) == "MTrk" {
+			chunks = append(chunks, trackChunk{data: pos + 8, length: length})
+		}
+
+		pos += 8 + length
+	}
+
+	return
+}
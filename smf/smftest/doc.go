@@ -0,0 +1,37 @@
+// Copyright (c) 2018 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package smftest generates pseudo random, but reproducible SMF data for tests and checks the content of SMF data
+with text based assertions.
+
+Example
+
+	// 4 tracks with 3 notes per quarter note, with damaged track lengths
+	data := smftest.Generate(42, smftest.Spec{
+		Tracks:      4,
+		Density:     3,
+		Corruptions: []smftest.Corruption{smftest.FlipLengthByte},
+	})
+
+	s, err := smftrack.Read(bytes.NewReader(data), smfreader.Tolerant())
+
+The same seed and Spec always generate the same data, so that the data can be used for unit tests and
+as fuzz corpora. The corruptions target specific defect classes that the tolerant reader (see smfreader.Tolerant)
+has to deal with.
+
+Assertions
+
+The content of a SMF can be checked with assertions that describe the expected messages (see Check for the grammar):
+
+	smftest.AssertFile(t, "song.mid",
+		"track 2: noteon C4 vel>=90 @ 0..480",
+		"conductor: tempo 120 @ 0",
+		"exactly 4 noteon ch=10",
+		"no pitchbend",
+	)
+
+A failed assertion reports the nearest candidates, respectively the unexpected matches.
+*/
+package smftest
@@ -0,0 +1,209 @@
+package smftest
+
+import (
+	"bytes"
+	"math/rand"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// Spec specifies the SMF data that is generated by Generate.
+// The zero value specifies 4 bars of a single track with 2 notes per quarter note.
+type Spec struct {
+	// Tracks is the number of tracks. For more than one track, SMF format 1 is generated, otherwise format 0.
+	// Default is 1.
+	Tracks int
+
+	// Bars is the length in bars. Default is 4.
+	Bars int
+
+	// Resolution is the number of ticks per quarter note. Default is 480.
+	Resolution smf.MetricTicks
+
+	// Density is the average number of notes per quarter note and track. Default is 2.
+	Density float64
+
+	// ControllerDensity is the average number of control change messages per quarter note and track.
+	ControllerDensity float64
+
+	// Channels are the channels that are chosen from for each track. Default is all 16 channels.
+	Channels []uint8
+
+	// Programs are the programs that are chosen from for the program change at the start of each track.
+	// Default is all 128 programs.
+	Programs []uint8
+
+	// TempoChanges is the probability of a tempo change at the start of each bar (0 to 1).
+	// The tempo changes are in the first track.
+	TempoChanges float64
+
+	// MeterChanges is the probability of a change of the time signature at the start of each bar (0 to 1).
+	// The time signature changes are in the first track.
+	MeterChanges float64
+
+	// SysEx adds a system exclusive message (GM System On) to the start of each track.
+	SysEx bool
+
+	// Corruptions are applied to the generated data in the given order.
+	Corruptions []Corruption
+}
+
+// meters are the time signatures that are chosen from for changes of the time signature
+var meters = []meta.TimeSig{
+	{Numerator: 2, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8},
+	{Numerator: 3, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8},
+	{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8},
+	{Numerator: 5, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8},
+	{Numerator: 6, Denominator: 8, ClocksPerClick: 36, DemiSemiQuaverPerQuarter: 8},
+	{Numerator: 7, Denominator: 8, ClocksPerClick: 36, DemiSemiQuaverPerQuarter: 8},
+}
+
+// gmSystemOn is the data of the GM System On message
+var gmSystemOn = sysex.SysEx{0x7E, 0x7F, 0x09, 0x01}
+
+// Generate returns the SMF data that is generated by the given seed according to the given Spec.
+// The same seed and Spec always result in the same data.
+func Generate(seed int64, spec Spec) []byte {
+	var bf bytes.Buffer
+	r := rand.New(rand.NewSource(seed))
+
+	if err := generate(r, spec).Write(&bf); err != nil {
+		// can't happen, since the generated messages are valid and the buffer does not fail
+		panic(err.Error())
+	}
+
+	data := bf.Bytes()
+
+	for _, c := range spec.Corruptions {
+		data = c.apply(r, data)
+	}
+
+	return data
+}
+
+// GenerateSMF returns the SMF that is generated by the given seed according to the given Spec,
+// as Generate does before applying the corruptions.
+func GenerateSMF(seed int64, spec Spec) *smftrack.SMF {
+	return generate(rand.New(rand.NewSource(seed)), spec)
+}
+
+// generate returns the SMF that is generated by the given random numbers according to the given Spec
+func generate(r *rand.Rand, spec Spec) *smftrack.SMF {
+	spec.defaults()
+
+	format := smf.SMF0
+	if spec.Tracks > 1 {
+		format = smf.SMF1
+	}
+
+	s := smftrack.New(format, spec.Resolution)
+	ticks4th := uint64(spec.Resolution.Ticks4th())
+
+	var conductor smftrack.Track
+	var meter = meters[2]
+	var tick uint64
+
+	conductor.Add(0, meter, meta.BPM(120))
+
+	// the conductor messages at the start of each bar
+	for bar := 0; bar < spec.Bars; bar++ {
+		if bar > 0 && r.Float64() < spec.MeterChanges {
+			meter = meters[r.Intn(len(meters))]
+			conductor.Add(tick, meter)
+		}
+
+		if bar > 0 && r.Float64() < spec.TempoChanges {
+			conductor.Add(tick, meta.BPM(uint32(40+r.Intn(200))))
+		}
+
+		tick += uint64(meter.Numerator) * 4 * ticks4th / uint64(meter.Denominator)
+	}
+
+	end := tick
+
+	for i := 0; i < spec.Tracks; i++ {
+		tr := &smftrack.Track{}
+
+		if i == 0 {
+			tr = &conductor
+		}
+
+		spec.fill(r, tr, ticks4th, end)
+		s.AddTrack(tr)
+	}
+
+	return s
+}
+
+// defaults sets the defaults of the unset fields
+func (spec *Spec) defaults() {
+	if spec.Tracks <= 0 {
+		spec.Tracks = 1
+	}
+
+	if spec.Bars <= 0 {
+		spec.Bars = 4
+	}
+
+	if spec.Resolution == 0 {
+		spec.Resolution = 480
+	}
+
+	if spec.Density == 0 {
+		spec.Density = 2
+	}
+
+	if len(spec.Channels) == 0 {
+		for ch := uint8(0); ch < 16; ch++ {
+			spec.Channels = append(spec.Channels, ch)
+		}
+	}
+
+	if len(spec.Programs) == 0 {
+		for prog := 0; prog < 128; prog++ {
+			spec.Programs = append(spec.Programs, uint8(prog))
+		}
+	}
+}
+
+// fill adds the channel messages of a track until the given end
+func (spec Spec) fill(r *rand.Rand, tr *smftrack.Track, ticks4th, end uint64) {
+	ch := channel.Channel(spec.Channels[r.Intn(len(spec.Channels))])
+
+	if spec.SysEx {
+		tr.Add(0, gmSystemOn)
+	}
+
+	tr.Add(0, ch.ProgramChange(spec.Programs[r.Intn(len(spec.Programs))]))
+
+	for start := uint64(0); start < end; start += ticks4th {
+		for n := count(r, spec.Density); n > 0; n-- {
+			on := start + uint64(r.Int63n(int64(ticks4th)))
+			key := uint8(36 + r.Intn(61))
+			tr.Add(on, ch.NoteOn(key, uint8(1+r.Intn(127))))
+			tr.Add(on+1+uint64(r.Int63n(int64(ticks4th))), ch.NoteOff(key))
+		}
+
+		for n := count(r, spec.ControllerDensity); n > 0; n-- {
+			tr.Add(start+uint64(r.Int63n(int64(ticks4th))), ch.ControlChange(uint8(r.Intn(120)), uint8(r.Intn(128))))
+		}
+	}
+
+	tr.SetEnd(end)
+}
+
+// count returns the number of events for the given average: the integral part plus one for the
+// probability of the fractional part
+func count(r *rand.Rand, average float64) int {
+	n := int(average)
+
+	if r.Float64() < average-float64(n) {
+		n++
+	}
+
+	return n
+}
@@ -0,0 +1,155 @@
+package smftest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+func TestGenerateDeterministic(t *testing.T) {
+	spec := Spec{Tracks: 3, Bars: 8, Density: 2.5, ControllerDensity: 0.5, TempoChanges: 0.5, MeterChanges: 0.5, SysEx: true}
+
+	for seed := int64(0); seed < 10; seed++ {
+		if a, b := Generate(seed, spec), Generate(seed, spec); !bytes.Equal(a, b) {
+			t.Errorf("[%v] Generate() is not deterministic", seed)
+		}
+	}
+
+	if a, b := Generate(1, spec), Generate(2, spec); bytes.Equal(a, b) {
+		t.Errorf("Generate() returns the same data for different seeds")
+	}
+
+	// the spec is not modified by the defaults
+	if spec.Channels != nil || spec.Programs != nil {
+		t.Errorf("Generate() modified the Spec")
+	}
+}
+
+func TestGenerateSpec(t *testing.T) {
+	spec := Spec{
+		Tracks:       4,
+		Bars:         16,
+		Density:      3,
+		Channels:     []uint8{5},
+		Programs:     []uint8{42},
+		TempoChanges: 1,
+		MeterChanges: 1,
+		SysEx:        true,
+	}
+
+	s, err := smftrack.Read(bytes.NewReader(Generate(7, spec)))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := s.Format(), smf.SMF1; got != want {
+		t.Errorf("Format() = %v; want %v", got, want)
+	}
+
+	if got, want := s.NumTracks(), uint16(4); got != want {
+		t.Errorf("NumTracks() = %v; want %v", got, want)
+	}
+
+	var tempos, meters, sysexes int
+
+	for _, ev := range s.Merged() {
+		switch ev.Message.(type) {
+		case meta.Tempo:
+			tempos++
+		case meta.TimeSig:
+			meters++
+		case sysex.SysEx:
+			sysexes++
+		}
+	}
+
+	// the initial ones plus one for each following bar
+	if tempos != 16 || meters != 16 {
+		t.Errorf("got %v tempo and %v time signature messages; want 16 each", tempos, meters)
+	}
+
+	if sysexes != 4 {
+		t.Errorf("got %v sysex messages; want 4", sysexes)
+	}
+
+	notes := s.Notes()
+
+	// 3 notes per quarter note for at least 2 quarter notes per bar
+	if len(notes) < 4*16*2*3 {
+		t.Errorf("got %v notes; want at least %v", len(notes), 4*16*2*3)
+	}
+
+	for _, n := range notes {
+		if n.Channel != 5 {
+			t.Errorf("note on channel %v; want 5", n.Channel)
+			break
+		}
+	}
+
+	// a single track is format 0
+	if got, want := GenerateSMF(7, Spec{}).Format(), smf.SMF0; got != want {
+		t.Errorf("Format() = %v; want %v", got, want)
+	}
+}
+
+// readAll reads the given data with the strict reader and returns the error that ends the reading
+func readAll(data []byte) error {
+	rd := smfreader.New(bytes.NewReader(data))
+	err := rd.ReadHeader()
+
+	for err == nil {
+		_, err = rd.Read()
+	}
+
+	return err
+}
+
+func TestGenerateCorruptions(t *testing.T) {
+	spec := Spec{Tracks: 3}
+
+	for _, c := range Corruptions() {
+		for seed := int64(0); seed < 10; seed++ {
+			valid := Generate(seed, spec)
+			data := Generate(seed, Spec{Tracks: 3, Corruptions: []Corruption{c}})
+
+			if bytes.Equal(valid, data) {
+				t.Errorf("[%s %v] data has not been corrupted", c, seed)
+				continue
+			}
+
+			if !bytes.Equal(data, Generate(seed, Spec{Tracks: 3, Corruptions: []Corruption{c}})) {
+				t.Errorf("[%s %v] corruption is not deterministic", c, seed)
+			}
+
+			err := readAll(data)
+
+			// the readers rely on the end of track messages instead of the declared lengths
+			if c == FlipLengthByte {
+				continue
+			}
+
+			if err == smf.ErrFinished {
+				t.Errorf("[%s %v] expected error from the strict reader", c, seed)
+			}
+
+			// the tolerant reader recovers from truncated data and missing end of track messages
+
+			s, err := smftrack.Read(bytes.NewReader(data), smfreader.Tolerant())
+
+			if err != nil {
+				t.Errorf("[%s %v] tolerant reader: %v", c, seed, err)
+				continue
+			}
+
+			if len(s.Warnings()) == 0 {
+				t.Errorf("[%s %v] tolerant reader: no warnings", c, seed)
+			}
+		}
+	}
+}
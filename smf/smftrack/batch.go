@@ -0,0 +1,150 @@
+package smftrack
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+// NamedReader is the named source of SMF data for Batch, e.g. a file
+type NamedReader struct {
+	Name   string
+	Reader io.Reader
+}
+
+// Result is the result of processing a single SMF within Batch
+type Result struct {
+	// Name is the name of the NamedReader
+	Name string
+
+	// SMF is the SMF returned by the operation. It is nil, if Err is not nil.
+	SMF *SMF
+
+	// Err is the error of reading the SMF or of the operation. A panic within the operation is returned as PanicError.
+	// If the batch has been canceled before the SMF has been processed, it is the error of the context.
+	Err error
+
+	// Warnings are the warnings of reading the SMF (see smfreader.Tolerant)
+	Warnings []smfreader.Warning
+
+	// Duration is the time it took to read and process the SMF
+	Duration time.Duration
+}
+
+// PanicError is the error of a panic that was recovered within Batch
+type PanicError struct {
+	// Name is the name of the NamedReader
+	Name string
+
+	// Value is the value that has been passed to panic
+	Value interface{}
+
+	// Stack is the stack trace of the panic
+	Stack []byte
+}
+
+// Error returns the error message
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic while processing %s: %v", p.Name, p.Value)
+}
+
+// Unwrap returns the value that has been passed to panic, if it is an error
+func (p *PanicError) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// Batch reads the SMF data of the given inputs and passes each SMF to op, processing up to parallelism SMFs
+// at the same time (all CPUs, if parallelism is not positive). The results are returned in the order of the inputs.
+//
+// The SMF data is read with the smfreader.Tolerant option and the given reader options.
+// When the context is canceled, the inputs that have not been started yet are not processed, while the inputs
+// that are in progress are finished.
+func Batch(ctx context.Context, inputs []NamedReader, op func(*SMF) (*SMF, error), parallelism int, options ...smfreader.Option) []Result {
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+
+	options = append([]smfreader.Option{smfreader.Tolerant()}, options...)
+
+	var (
+		results = make([]Result, len(inputs))
+		jobs    = make(chan int)
+		wg      sync.WaitGroup
+	)
+
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					results[i] = Result{Name: inputs[i].Name, Err: err}
+					continue
+				}
+				results[i] = process(inputs[i], op, options)
+			}
+		}()
+	}
+
+	i := 0
+
+dispatch:
+	for ; i < len(inputs); i++ {
+		// prefer the cancellation over the next job
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	for ; i < len(inputs); i++ {
+		results[i] = Result{Name: inputs[i].Name, Err: ctx.Err()}
+	}
+
+	return results
+}
+
+// process reads and processes a single input of Batch
+func process(in NamedReader, op func(*SMF) (*SMF, error), options []smfreader.Option) (res Result) {
+	res.Name = in.Name
+	start := time.Now()
+
+	defer func() {
+		if v := recover(); v != nil {
+			res.SMF = nil
+			res.Err = &PanicError{Name: in.Name, Value: v, Stack: debug.Stack()}
+		}
+		res.Duration = time.Since(start)
+	}()
+
+	s, err := Read(in.Reader, options...)
+
+	if err != nil {
+		res.Err = fmt.Errorf("reading %s: %w", in.Name, err)
+		return
+	}
+
+	res.Warnings = s.Warnings()
+	res.SMF, res.Err = op(s)
+
+	if res.Err != nil {
+		res.SMF = nil
+	}
+
+	return
+}
@@ -0,0 +1,140 @@
+package smftrack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+func namedReader(name string, data []byte) NamedReader {
+	return NamedReader{Name: name, Reader: bytes.NewReader(data)}
+}
+
+func TestBatch(t *testing.T) {
+	b := NewBuilder(480)
+	b.Track().Note("C4", Quarter, 100)
+	built, _ := b.Build()
+
+	var bf bytes.Buffer
+	built.Write(&bf)
+
+	inputs := []NamedReader{
+		namedReader("good.mid", examples.SpecSMF1),
+		namedReader("broken.mid", []byte("RIFF")),
+		namedReader("truncated.mid", examples.SpecSMF1Missing),
+		namedReader("panic.mid", examples.SpecSMF0),
+		namedReader("failing.mid", bf.Bytes()),
+	}
+
+	errFailing := errors.New("failing")
+
+	results := Batch(context.Background(), inputs, func(s *SMF) (*SMF, error) {
+		if s.TimeFormat() == smf.MetricTicks(480) {
+			return nil, errFailing
+		}
+
+		if s.Format() == smf.SMF0 {
+			panic("boom")
+		}
+
+		return MoveFirstTrackMetas(s), nil
+	}, 2)
+
+	if got, want := len(results), len(inputs); got != want {
+		t.Fatalf("len(Batch()) = %v; want %v", got, want)
+	}
+
+	for i, res := range results {
+		if got, want := res.Name, inputs[i].Name; got != want {
+			t.Errorf("[%v] Name = %v; want %v", i, got, want)
+		}
+	}
+
+	if res := results[0]; res.Err != nil || res.SMF == nil || len(res.Warnings) != 0 {
+		t.Errorf("good: Err = %v, SMF = %v, Warnings = %v", res.Err, res.SMF, res.Warnings)
+	}
+
+	if res := results[1]; res.Err == nil || !strings.HasPrefix(res.Err.Error(), "reading broken.mid: ") {
+		t.Errorf("broken: Err = %v", res.Err)
+	}
+
+	if res := results[2]; res.Err != nil || res.SMF.NumTracks() != 3 || len(res.Warnings) != 1 {
+		t.Errorf("truncated: Err = %v, Warnings = %v", res.Err, res.Warnings)
+	}
+
+	var perr *PanicError
+
+	if res := results[3]; !errors.As(res.Err, &perr) || perr.Name != "panic.mid" || perr.Value != "boom" || res.SMF != nil {
+		t.Errorf("panic: Err = %v", res.Err)
+	}
+
+	if res := results[4]; res.Err != errFailing {
+		t.Errorf("failing: Err = %v; want %v", res.Err, errFailing)
+	}
+}
+
+func TestBatchCancel(t *testing.T) {
+	var inputs []NamedReader
+
+	for i := 0; i < 10; i++ {
+		inputs = append(inputs, namedReader(fmt.Sprintf("%v.mid", i), examples.SpecSMF0))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var processed int
+
+	results := Batch(ctx, inputs, func(s *SMF) (*SMF, error) {
+		processed++
+		if processed == 3 {
+			cancel()
+		}
+		return s, nil
+	}, 1)
+
+	if got, want := processed, 3; got != want {
+		t.Errorf("processed = %v; want %v", got, want)
+	}
+
+	for i, res := range results {
+		var want error
+		if i >= 3 {
+			want = context.Canceled
+		}
+
+		if res.Err != want {
+			t.Errorf("[%v] Err = %v; want %v", i, res.Err, want)
+		}
+	}
+}
+
+func TestBatchParallel(t *testing.T) {
+	var inputs []NamedReader
+
+	for i := 0; i < 20; i++ {
+		inputs = append(inputs, namedReader(fmt.Sprintf("%v.mid", i), examples.SpecSMF1))
+	}
+
+	results := Batch(context.Background(), inputs, func(s *SMF) (*SMF, error) {
+		return Resample(s, 480)
+	}, 4, smfreader.NoteOffVelocity())
+
+	for i, res := range results {
+		if res.Err != nil {
+			t.Errorf("[%v] Err = %v", i, res.Err)
+			continue
+		}
+
+		if got, want := res.SMF.TimeFormat(), smf.MetricTicks(480); got != want {
+			t.Errorf("[%v] TimeFormat() = %v; want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/meta/meter"
+	"github.com/gomidi/midi/smf"
+)
+
+// Duration is a note value relative to a whole note. It is converted to ticks by the Builder,
+// based on its resolution.
+type Duration float64
+
+// note values
+const (
+	Whole        Duration = 1
+	Half         Duration = 1.0 / 2
+	Quarter      Duration = 1.0 / 4
+	Eighth       Duration = 1.0 / 8
+	Sixteenth    Duration = 1.0 / 16
+	ThirtySecond Duration = 1.0 / 32
+)
+
+// Dotted returns the dotted note value of d
+func Dotted(d Duration) Duration {
+	return d * 3 / 2
+}
+
+// Triplet returns the triplet note value of d
+func Triplet(d Duration) Duration {
+	return d * 2 / 3
+}
+
+// BuildError is returned by Builder.Build and contains all errors that happened while building
+type BuildError []error
+
+// Error returns the messages of all errors
+func (b BuildError) Error() string {
+	var msgs []string
+	for _, err := range b {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors
+func (b BuildError) Unwrap() []error {
+	return b
+}
+
+// Builder constructs a SMF fluently in code, e.g.
+//
+//	b := smftrack.NewBuilder(480)
+//	b.Track().Tempo(120).Meter(4, 4).Channel(0).Program(33).Note("C4", smftrack.Quarter, 100).Rest(smftrack.Eighth)
+//	s, err := b.Build()
+//
+// Errors are collected and returned by Build.
+type Builder struct {
+	tpq    uint16
+	tracks []*TrackBuilder
+	meters []meterChange
+	errors BuildError
+}
+
+type meterChange struct {
+	tick     uint64
+	num, den uint8
+	track    int
+}
+
+// NewBuilder returns a Builder with the given resolution in ticks per quarter note
+func NewBuilder(ticksPerQuarter uint16) *Builder {
+	return &Builder{tpq: ticksPerQuarter}
+}
+
+// Track adds a new track and returns its TrackBuilder
+func (b *Builder) Track() *TrackBuilder {
+	tb := &TrackBuilder{builder: b, no: len(b.tracks)}
+	b.tracks = append(b.tracks, tb)
+	return tb
+}
+
+// Ticks returns the number of ticks of the given duration
+func (b *Builder) Ticks(d Duration) uint64 {
+	return uint64(math.Round(float64(d) * 4 * float64(b.tpq)))
+}
+
+// Build returns the built SMF. It is of format 0, if there is a single track, and of format 1 otherwise.
+// If any errors happened while building, they are returned as BuildError.
+// The bar assertions (see TrackBuilder.Bar) are checked against the time signatures of all tracks.
+func (b *Builder) Build() (*SMF, error) {
+	errs := append(BuildError{}, b.errors...)
+
+	if len(b.tracks) == 0 {
+		errs = append(errs, fmt.Errorf("no tracks"))
+	}
+
+	meters := append([]meterChange{}, b.meters...)
+	sort.SliceStable(meters, func(a, c int) bool { return meters[a].tick < meters[c].tick })
+
+	// every time signature must be at the start of a bar of the time signature before it
+	for i, m := range meters {
+		if ok, bar := b.onBar(meters[:i], m.tick); !ok {
+			errs = append(errs, fmt.Errorf("track %v at tick %v: time signature %v/%v is not at the start of a bar (bar length %v)", m.track, m.tick, m.num, m.den, bar))
+		}
+	}
+
+	for _, tb := range b.tracks {
+		for _, tick := range tb.bars {
+			if ok, bar := b.onBar(meters, tick); !ok {
+				errs = append(errs, fmt.Errorf("track %v at tick %v: not at the start of a bar (bar length %v)", tb.no, tick, bar))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	format := smf.SMF0
+	if len(b.tracks) > 1 {
+		format = smf.SMF1
+	}
+
+	s := New(format, smf.MetricTicks(b.tpq))
+
+	for _, tb := range b.tracks {
+		tr := tb.track.clone()
+		tr.SetEnd(tb.endTick())
+		s.AddTrack(tr)
+	}
+
+	return s, nil
+}
+
+// onBar returns, if the given tick is at the start of a bar regarding the given time signatures (default 4/4),
+// and the length of the bar in ticks.
+func (b *Builder) onBar(meters []meterChange, tick uint64) (bool, uint64) {
+	var start uint64
+	var num, den = uint64(4), uint64(4)
+
+	for _, m := range meters {
+		if m.tick > tick {
+			break
+		}
+		start, num, den = m.tick, uint64(m.num), uint64(m.den)
+	}
+
+	if den == 0 {
+		return false, 0
+	}
+
+	bar := num * 4 * uint64(b.tpq) / den
+
+	if bar == 0 {
+		return tick == start, 0
+	}
+
+	return (tick-start)%bar == 0, bar
+}
+
+// TrackBuilder builds a track, see Builder
+type TrackBuilder struct {
+	builder *Builder
+	no      int
+	track   Track
+	pos     uint64
+	end     uint64
+	channel channel.Channel
+	bars    []uint64
+}
+
+func (t *TrackBuilder) errorf(format string, vals ...interface{}) {
+	msg := fmt.Sprintf(format, vals...)
+	t.builder.errors = append(t.builder.errors, fmt.Errorf("track %v at tick %v: %s", t.no, t.pos, msg))
+}
+
+// Name adds a track name
+func (t *TrackBuilder) Name(name string) *TrackBuilder {
+	t.track.Add(t.pos, meta.Track(name))
+	return t
+}
+
+// Tempo adds a tempo change to the given beats per minute
+func (t *TrackBuilder) Tempo(bpm float64) *TrackBuilder {
+	if bpm <= 0 {
+		t.errorf("invalid tempo %v", bpm)
+		return t
+	}
+	t.track.Add(t.pos, meta.FractionalBPM(bpm))
+	return t
+}
+
+// Meter adds a time signature. The denominator must be a power of 2.
+func (t *TrackBuilder) Meter(num, den uint8) *TrackBuilder {
+	if num == 0 || den == 0 || den&(den-1) != 0 {
+		t.errorf("invalid time signature %v/%v", num, den)
+		return t
+	}
+	t.track.Add(t.pos, meter.Meter(num, den))
+	t.builder.meters = append(t.builder.meters, meterChange{tick: t.pos, num: num, den: den, track: t.no})
+	return t
+}
+
+// Channel sets the channel (0-15) for the following messages
+func (t *TrackBuilder) Channel(ch uint8) *TrackBuilder {
+	if ch > 15 {
+		t.errorf("invalid channel %v", ch)
+		return t
+	}
+	t.channel = channel.Channel(ch)
+	return t
+}
+
+// Program adds a program change
+func (t *TrackBuilder) Program(program uint8) *TrackBuilder {
+	t.track.Add(t.pos, t.channel.ProgramChange(program))
+	return t
+}
+
+// ControlChange adds a control change
+func (t *TrackBuilder) ControlChange(controller, value uint8) *TrackBuilder {
+	t.track.Add(t.pos, t.channel.ControlChange(controller, value))
+	return t
+}
+
+// Note adds a note of the given note name (see channel.ParseNoteNumber), duration and velocity
+// and moves forward by the duration.
+func (t *TrackBuilder) Note(name string, d Duration, velocity uint8) *TrackBuilder {
+	return t.Chord([]string{name}, d, velocity)
+}
+
+// Chord adds notes of the given note names (see channel.ParseNoteNumber), that start together and
+// have the same duration and velocity, and moves forward by the duration.
+func (t *TrackBuilder) Chord(names []string, d Duration, velocity uint8) *TrackBuilder {
+	keys, ok := t.keys(names, velocity)
+
+	if !ok {
+		return t
+	}
+
+	ticks := t.builder.Ticks(d)
+
+	if ticks == 0 {
+		t.errorf("duration %v is too short for the resolution", d)
+		return t
+	}
+
+	return t.chord(keys, ticks, velocity)
+}
+
+// NoteValue adds a note of the given note name (see channel.ParseNoteNumber), note value (see smf.ParseNoteValue,
+// e.g. "1/8." for a dotted eighth) and velocity and moves forward by the note value.
+// Other than with Note, the note value must be a whole number of ticks in the resolution of the Builder.
+func (t *TrackBuilder) NoteValue(name string, value string, velocity uint8) *TrackBuilder {
+	return t.ChordValue([]string{name}, value, velocity)
+}
+
+// ChordValue adds notes of the given note names (see channel.ParseNoteNumber), that start together and have the
+// same note value (see NoteValue) and velocity, and moves forward by the note value.
+func (t *TrackBuilder) ChordValue(names []string, value string, velocity uint8) *TrackBuilder {
+	keys, ok := t.keys(names, velocity)
+
+	if !ok {
+		return t
+	}
+
+	ticks, ok := t.valueTicks(value)
+
+	if !ok {
+		return t
+	}
+
+	return t.chord(keys, ticks, velocity)
+}
+
+// keys returns the keys of the given note names. Invalid note names are skipped and, like an invalid velocity,
+// add an error. If the velocity is invalid, ok is false.
+func (t *TrackBuilder) keys(names []string, velocity uint8) (keys []uint8, ok bool) {
+	for _, name := range names {
+		key, err := channel.ParseNoteNumber(name)
+
+		if err != nil {
+			t.errorf("%v", err)
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	if velocity == 0 || velocity > 127 {
+		t.errorf("invalid velocity %v", velocity)
+		return nil, false
+	}
+
+	return keys, true
+}
+
+// valueTicks returns the ticks of the given note value. If it is invalid or not a whole number of ticks,
+// an error is added and ok is false.
+func (t *TrackBuilder) valueTicks(value string) (ticks uint64, ok bool) {
+	v, err := smf.ParseNoteValue(value)
+
+	if err != nil {
+		t.errorf("%v", err)
+		return 0, false
+	}
+
+	n, err := smf.MetricTicks(t.builder.tpq).NoteValue(v)
+
+	if err != nil {
+		t.errorf("%v", err)
+		return 0, false
+	}
+
+	return uint64(n), true
+}
+
+// chord adds notes of the given keys and moves forward by the given ticks
+func (t *TrackBuilder) chord(keys []uint8, ticks uint64, velocity uint8) *TrackBuilder {
+	for _, key := range keys {
+		t.track.Add(t.pos, t.channel.NoteOn(key, velocity))
+	}
+
+	for _, key := range keys {
+		t.track.Add(t.pos+ticks, t.channel.NoteOff(key))
+	}
+
+	t.pos += ticks
+	return t
+}
+
+// At moves to the given tick, e.g. to add overlapping voices to the same track. The end of the track
+// is the farthest position that has been reached.
+func (t *TrackBuilder) At(tick uint64) *TrackBuilder {
+	t.end = t.endTick()
+	t.pos = tick
+	return t
+}
+
+// endTick returns the farthest position that has been reached
+func (t *TrackBuilder) endTick() uint64 {
+	if t.pos > t.end {
+		return t.pos
+	}
+	return t.end
+}
+
+// Rest moves forward by the given duration
+func (t *TrackBuilder) Rest(d Duration) *TrackBuilder {
+	t.pos += t.builder.Ticks(d)
+	return t
+}
+
+// RestValue moves forward by the given note value (see NoteValue)
+func (t *TrackBuilder) RestValue(value string) *TrackBuilder {
+	if ticks, ok := t.valueTicks(value); ok {
+		t.pos += ticks
+	}
+	return t
+}
+
+// Bar asserts that the current position is at the start of a bar. It is checked by Build.
+func (t *TrackBuilder) Bar() *TrackBuilder {
+	t.bars = append(t.bars, t.pos)
+	return t
+}
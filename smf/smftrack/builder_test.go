@@ -0,0 +1,187 @@
+package smftrack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/smf"
+)
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(480)
+	b.Track().Name("bass").Tempo(120).Meter(3, 4).Channel(1).Program(33).
+		Note("C4", Quarter, 100).Rest(Eighth).Note("D4", Eighth, 90).
+		Chord([]string{"E4", "G4"}, Quarter, 80).Bar().
+		Note("A3", Dotted(Half), 70).Bar()
+
+	s, err := b.Build()
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := s.Format(), smf.SMF0; got != want {
+		t.Errorf("Format() = %v; want %v", got, want)
+	}
+
+	if got, want := s.TimeFormat(), smf.MetricTicks(480); got != want {
+		t.Errorf("TimeFormat() = %v; want %v", got, want)
+	}
+
+	expected := `0 meta.Track: "bass"
+0 meta.Tempo BPM: 120.00
+0 meta.TimeSig 3/4 clocksperclick 8 dsqpq 8
+0 channel.ProgramChange channel 2 program 33
+0 channel.NoteOn channel 2 key 60 velocity 100
+480 channel.NoteOff channel 2 key 60
+720 channel.NoteOn channel 2 key 62 velocity 90
+960 channel.NoteOff channel 2 key 62
+960 channel.NoteOn channel 2 key 64 velocity 80
+960 channel.NoteOn channel 2 key 67 velocity 80
+1440 channel.NoteOff channel 2 key 64
+1440 channel.NoteOff channel 2 key 67
+1440 channel.NoteOn channel 2 key 57 velocity 70
+2880 channel.NoteOff channel 2 key 57
+2880 end
+`
+
+	if got, want := trackString(s.Track(0)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestBuilderTracks(t *testing.T) {
+	b := NewBuilder(96)
+	b.Track().Meter(2, 4)
+	b.Track().Note("C4", Half, 100).Bar().Note("C4", Triplet(Quarter), 100)
+
+	s, err := b.Build()
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := s.Format(), smf.SMF1; got != want {
+		t.Errorf("Format() = %v; want %v", got, want)
+	}
+
+	if got, want := s.Track(1).End(), uint64(256); got != want {
+		t.Errorf("End() = %v; want %v", got, want)
+	}
+}
+
+func TestBuilderNoteValues(t *testing.T) {
+	b := NewBuilder(96)
+	b.Track().NoteValue("C4", "1/8.", 100).NoteValue("D4", "1/16", 100).
+		ChordValue([]string{"E4", "G4"}, "1/12", 80).RestValue("1/6").NoteValue("C5", "1/24", 90)
+
+	s, err := b.Build()
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0 channel.NoteOn channel 1 key 60 velocity 100
+72 channel.NoteOff channel 1 key 60
+72 channel.NoteOn channel 1 key 62 velocity 100
+96 channel.NoteOff channel 1 key 62
+96 channel.NoteOn channel 1 key 64 velocity 80
+96 channel.NoteOn channel 1 key 67 velocity 80
+128 channel.NoteOff channel 1 key 64
+128 channel.NoteOff channel 1 key 67
+192 channel.NoteOn channel 1 key 72 velocity 90
+208 channel.NoteOff channel 1 key 72
+208 end
+`
+
+	if got, want := trackString(s.Track(0)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestBuilderAt(t *testing.T) {
+	b := NewBuilder(96)
+	b.Track().Channel(9).Note("C2", Half, 100).At(0).Note("D2", Quarter, 90).At(48).Note("F#2", Eighth, 80)
+
+	s, err := b.Build()
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0 channel.NoteOn channel 10 key 36 velocity 100
+0 channel.NoteOn channel 10 key 38 velocity 90
+48 channel.NoteOn channel 10 key 42 velocity 80
+96 channel.NoteOff channel 10 key 38
+96 channel.NoteOff channel 10 key 42
+192 channel.NoteOff channel 10 key 36
+192 end
+`
+
+	if got, want := trackString(s.Track(0)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := []struct {
+		build    func(b *Builder)
+		expected []string
+	}{
+		{
+			func(b *Builder) {},
+			[]string{"no tracks"},
+		},
+		{
+			func(b *Builder) {
+				b.Track().Note("C4", Quarter, 100).Bar()
+			},
+			[]string{"track 0 at tick 480: not at the start of a bar (bar length 1920)"},
+		},
+		{
+			func(b *Builder) {
+				b.Track().Meter(3, 4).Note("C4", Half, 100).Meter(4, 4).Note("H4", Quarter, 100)
+			},
+			[]string{
+				`track 0 at tick 960: invalid note name "H4"`,
+				"track 0 at tick 960: time signature 4/4 is not at the start of a bar (bar length 1440)",
+			},
+		},
+		{
+			func(b *Builder) {
+				b.Track().Meter(3, 5).Channel(16).Tempo(0).Note("C4", Quarter, 0)
+			},
+			[]string{
+				"track 0 at tick 0: invalid time signature 3/5",
+				"track 0 at tick 0: invalid channel 16",
+				"track 0 at tick 0: invalid tempo 0",
+				"track 0 at tick 0: invalid velocity 0",
+			},
+		},
+		{
+			func(b *Builder) {
+				b.Track().NoteValue("C4", "1/1536", 100).RestValue("3/4").ChordValue([]string{"C4"}, "1/1024", 100)
+			},
+			[]string{
+				"track 0 at tick 0: note value 1/1536 is 5/4 ticks at 480 MetricTicks",
+				`track 0 at tick 0: invalid note value "3/4": must start with 1/`,
+				"track 0 at tick 0: note value 1/1024 is 15/8 ticks at 480 MetricTicks",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		b := NewBuilder(480)
+		test.build(b)
+		_, err := b.Build()
+
+		if err == nil {
+			t.Errorf("[%v] expected error", i)
+			continue
+		}
+
+		if got, want := err.Error(), strings.Join(test.expected, "; "); got != want {
+			t.Errorf("[%v] Build() error = %q; want %q", i, got, want)
+		}
+	}
+}
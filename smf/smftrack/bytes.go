@@ -0,0 +1,58 @@
+package smftrack
+
+import (
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+// ReadBytes reads the SMF from the given data without copying the data of the messages where possible
+// (see smfreader.NewFromBytes): the data of system exclusive messages, sequencer specific messages and undefined
+// meta messages are sub-slices of the given data. Therefore the data must not be modified as long as the SMF is used,
+// or the SMF must be detached from the data (see Detach). That makes ReadBytes suitable for read-only memory mapped
+// files.
+func ReadBytes(data []byte, options ...smfreader.Option) (*SMF, error) {
+	return ReadFrom(smfreader.NewFromBytes(data, options...))
+}
+
+// Detach returns a copy of the SMF where the data of the messages is copied, so that it does not share memory with
+// the data the SMF has been read from by ReadBytes. The SMF itself is not modified.
+func (s *SMF) Detach() *SMF {
+	res := s.clone()
+	res.warnings = s.warnings
+
+	for _, tr := range res.tracks {
+		for i := range tr.evs() {
+			tr.evs()[i].Message = detach(tr.evs()[i].Message)
+		}
+	}
+
+	return res
+}
+
+// detach returns a copy of the given message, if it has data that might be shared with the data it has been read from
+func detach(msg midi.Message) midi.Message {
+	cp := func(b []byte) []byte {
+		return append([]byte(nil), b...)
+	}
+
+	switch v := msg.(type) {
+	case sysex.SysEx:
+		return sysex.SysEx(cp(v))
+	case sysex.Start:
+		return sysex.Start(cp(v))
+	case sysex.Continue:
+		return sysex.Continue(cp(v))
+	case sysex.End:
+		return sysex.End(cp(v))
+	case sysex.Escape:
+		return sysex.Escape(cp(v))
+	case meta.SequencerData:
+		return meta.SequencerData(cp(v))
+	case meta.Undefined:
+		return meta.Undefined{Typ: v.Typ, Data: cp(v.Data)}
+	default:
+		return msg
+	}
+}
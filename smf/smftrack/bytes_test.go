@@ -0,0 +1,197 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// sysexData returns the data of a SMF with the given number of system exclusive messages of the given size
+func sysexData(t testing.TB, num, size int) []byte {
+	var tr Track
+	tr.Add(0, meta.Text("capture"), meta.SequencerData{0x41, 0x01})
+
+	for i := 0; i < num; i++ {
+		payload := bytes.Repeat([]byte{byte(i % 128)}, size)
+		tr.Add(uint64(i*10), sysex.SysEx(payload), channel.Channel0.ControlChange(7, uint8(i%128)))
+	}
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	return bf.Bytes()
+}
+
+func TestReadBytes(t *testing.T) {
+	data := sysexData(t, 3, 4)
+
+	for _, options := range [][]smfreader.Option{nil, {smfreader.Tolerant()}, {smfreader.Preserve()}} {
+		s, err := ReadBytes(data, options...)
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		want, _ := Read(bytes.NewReader(data), options...)
+
+		if got, want := trackString(s.Track(0)), trackString(want.Track(0)); got != want {
+			t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+		}
+	}
+
+	s, _ := ReadBytes(data)
+	detached := s.Detach()
+
+	// the payload is a sub-slice of the data, so modifying the data modifies the message
+	sx := s.Track(0).Event(2).Message.(sysex.SysEx)
+	i := bytes.Index(data, []byte(sx))
+	data[i] = 0x7F
+
+	if got := s.Track(0).Event(2).Message.(sysex.SysEx)[0]; got != 0x7F {
+		t.Errorf("sysex data = % X; want shared data", got)
+	}
+
+	if got := s.Track(0).Event(1).Message.(meta.SequencerData); !bytes.Equal(got, []byte{0x41, 0x01}) {
+		t.Errorf("sequencer data = % X; want 41 01", got)
+	}
+
+	// appending to the payload does not overwrite the data
+	_ = append(sx, 0x11)
+
+	if got := data[i+len(sx)]; got != 0xF7 {
+		t.Errorf("data after the sysex = % X; want F7", got)
+	}
+
+	// the detached SMF does not share data
+	if got := detached.Track(0).Event(2).Message.(sysex.SysEx)[0]; got != 0x00 {
+		t.Errorf("detached sysex data = % X; want 00", got)
+	}
+}
+
+func TestReadBytesTruncated(t *testing.T) {
+	data := sysexData(t, 3, 4)
+
+	// within the data of the last sysex
+	data = data[:len(data)-12]
+
+	if _, err := ReadBytes(data); err == nil {
+		t.Errorf("expected error")
+	}
+
+	s, err := ReadBytes(data, smfreader.Tolerant())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	want, _ := Read(bytes.NewReader(data), smfreader.Tolerant())
+
+	if got, want := trackString(s.Track(0)), trackString(want.Track(0)); got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if got := len(s.Warnings()); got != 1 {
+		t.Errorf("len(Warnings()) = %v; want 1: %v", got, s.Warnings())
+	}
+}
+
+func BenchmarkReadSysEx(b *testing.B) {
+	data := sysexData(b, 4000, 1000)
+
+	b.Run("Read", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := Read(bytes.NewReader(data)); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	})
+
+	b.Run("ReadBytes", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := ReadBytes(data); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	})
+}
+
+// lyricData returns a SMF with num syllables of lyrics, each followed by a note
+func lyricData(t testing.TB, num int) []byte {
+	var tr Track
+	tr.Add(0, meta.Track("vocals"))
+
+	for i := 0; i < num; i++ {
+		tr.Add(uint64(i*120), meta.Lyric(fmt.Sprintf("syl-%v ", i)), channel.Channel0.NoteOn(60, 100))
+		tr.Add(uint64(i*120+100), channel.Channel0.NoteOff(60))
+	}
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	return bf.Bytes()
+}
+
+func BenchmarkReadLyrics(b *testing.B) {
+	data := lyricData(b, 5000)
+
+	b.Run("Read", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := Read(bytes.NewReader(data)); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	})
+
+	b.Run("ReadBytes", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := ReadBytes(data); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	})
+
+	s, err := ReadBytes(data)
+
+	if err != nil {
+		b.Fatalf("Error: %v", err)
+	}
+
+	b.Run("Write", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			// uncached, so that the messages are encoded
+			if err := s.writeTracks(smfwriter.New(io.Discard, s.writerOptions(nil)...)); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package smftrack
+
+import (
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+// RemapChannels returns a copy of the given SMF where the channel messages of the channels that are keys of the
+// given map are moved to the mapped channels. The channel prefix messages (meta.Channel) are mapped too.
+// Mappings to channels above 15 are ignored. The given SMF is not modified.
+func RemapChannels(s *SMF, m map[uint8]uint8) *SMF {
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		var changed bool
+		evts := make([]Event, len(tr.evs()))
+
+		for i, ev := range tr.evs() {
+			evts[i] = ev
+
+			switch v := ev.Message.(type) {
+			case channel.Message:
+				if to, ok := m[v.Channel()]; ok && to < 16 && to != v.Channel() {
+					evts[i].Message = channel.SetChannel(v, to)
+					changed = true
+				}
+			case meta.Channel:
+				if to, ok := m[uint8(v)]; ok && to < 16 && to != uint8(v) {
+					evts[i].Message = meta.Channel(to)
+					changed = true
+				}
+			}
+		}
+
+		if changed {
+			tr.SetEvents(evts)
+		}
+	}
+
+	return res
+}
+
+// channelUsage returns the channels that are used by channel messages and the first program of each channel,
+// -1 if there is no program change on the channel
+func (s *SMF) channelUsage() (used [16]bool, programs [16]int) {
+	for i := range programs {
+		programs[i] = -1
+	}
+
+	for _, ev := range s.Merged() {
+		msg, ok := ev.Message.(channel.Message)
+
+		if !ok || msg.Channel() > 15 {
+			continue
+		}
+
+		used[msg.Channel()] = true
+
+		if pc, is := msg.(channel.ProgramChange); is && programs[pc.Channel()] < 0 {
+			programs[pc.Channel()] = int(pc.Program())
+		}
+	}
+
+	return
+}
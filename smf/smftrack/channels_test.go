@@ -0,0 +1,41 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestRemapChannels(t *testing.T) {
+	var tr Track
+	tr.Add(0, meta.Channel(1), channel.Channel1.ProgramChange(3), channel.Channel2.NoteOn(60, 100))
+	tr.Add(10, channel.Channel1.NoteOn(62, 100), channel.Channel3.NoteOn(64, 100))
+	tr.Add(20, channel.Channel1.NoteOff(62), channel.Channel2.NoteOff(60), channel.Channel3.NoteOff(64))
+
+	s := New(smf.SMF0, smf.MetricTicks(960))
+	s.AddTrack(&tr)
+
+	res := RemapChannels(s, map[uint8]uint8{1: 5, 2: 1, 3: 16})
+
+	expected := `0 meta.Channel: 6
+0 channel.ProgramChange channel 6 program 3
+0 channel.NoteOn channel 2 key 60 velocity 100
+10 channel.NoteOn channel 6 key 62 velocity 100
+10 channel.NoteOn channel 4 key 64 velocity 100
+20 channel.NoteOff channel 6 key 62
+20 channel.NoteOff channel 2 key 60
+20 channel.NoteOff channel 4 key 64
+20 end
+`
+
+	if got := trackString(res.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	// the original is untouched
+	if got, want := s.Track(0).Event(1).Message, channel.Channel1.ProgramChange(3); got != want {
+		t.Errorf("the given SMF has been modified: %v; wanted %v", got, want)
+	}
+}
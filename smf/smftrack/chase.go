@@ -0,0 +1,133 @@
+package smftrack
+
+import (
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+// chaser keeps track of the state that is established by the messages of a track
+// (tempo, time signature, key, programs, controllers, pitch bend and aftertouch),
+// so that it can be reestablished at another position.
+type chaser struct {
+	tempo   midi.Message
+	timeSig midi.Message
+	key     midi.Message
+
+	program     [16]*channel.ProgramChange
+	controllers [16][128]*channel.ControlChange
+	pitchbend   [16]*channel.Pitchbend
+	aftertouch  [16]*channel.Aftertouch
+}
+
+// isStateController returns true, if the given controller establishes a state that is chased.
+// Data entry, (N)RPN selection and channel mode messages are not chased.
+func isStateController(controller uint8) bool {
+	switch {
+	case controller == 6 || controller == 38:
+		return false
+	case controller >= 96 && controller <= 101:
+		return false
+	case controller >= 120:
+		return false
+	}
+	return true
+}
+
+// isStateMessage returns true, if the chaser keeps track of the given message
+func isStateMessage(msg midi.Message) bool {
+	switch v := msg.(type) {
+	case meta.Tempo, meta.TimeSig, meta.Key:
+		return true
+	case channel.ProgramChange, channel.Pitchbend, channel.Aftertouch:
+		return true
+	case channel.ControlChange:
+		return isStateController(v.Controller())
+	}
+	return false
+}
+
+// add passes the given message to the chaser
+func (c *chaser) add(msg midi.Message) {
+	switch v := msg.(type) {
+	case meta.Tempo:
+		c.tempo = v
+	case meta.TimeSig:
+		c.timeSig = v
+	case meta.Key:
+		c.key = v
+	case channel.ProgramChange:
+		if v.Channel() < 16 {
+			c.program[v.Channel()] = &v
+		}
+	case channel.Pitchbend:
+		if v.Channel() < 16 {
+			c.pitchbend[v.Channel()] = &v
+		}
+	case channel.Aftertouch:
+		if v.Channel() < 16 {
+			c.aftertouch[v.Channel()] = &v
+		}
+	case channel.ControlChange:
+		if v.Channel() < 16 && v.Controller() < 128 && isStateController(v.Controller()) {
+			c.controllers[v.Channel()][v.Controller()] = &v
+		}
+	}
+}
+
+// messages returns the messages that reestablish the state: the meta messages first,
+// then for each channel the bank select, the program, the other controllers, the pitch bend and the aftertouch.
+func (c *chaser) messages() (msgs []midi.Message) {
+	for _, msg := range []midi.Message{c.tempo, c.timeSig, c.key} {
+		if msg != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	for ch := 0; ch < 16; ch++ {
+		ctrls := &c.controllers[ch]
+
+		for _, cc := range []uint8{0, 32} {
+			if ctrls[cc] != nil {
+				msgs = append(msgs, *ctrls[cc])
+			}
+		}
+
+		if c.program[ch] != nil {
+			msgs = append(msgs, *c.program[ch])
+		}
+
+		for cc, v := range ctrls {
+			if v != nil && cc != 0 && cc != 32 {
+				msgs = append(msgs, *v)
+			}
+		}
+
+		if c.pitchbend[ch] != nil {
+			msgs = append(msgs, *c.pitchbend[ch])
+		}
+
+		if c.aftertouch[ch] != nil {
+			msgs = append(msgs, *c.aftertouch[ch])
+		}
+	}
+
+	return
+}
+
+// restore returns the messages that reestablish the state of c, as far as it differs from the state of other
+func (c *chaser) restore(other *chaser) (msgs []midi.Message) {
+	var established = map[string]bool{}
+
+	for _, msg := range other.messages() {
+		established[string(msg.Raw())] = true
+	}
+
+	for _, msg := range c.messages() {
+		if !established[string(msg.Raw())] {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,280 @@
+package smftrack
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// TempoMark is a tempo message at a certain tick of a Timeline
+type TempoMark struct {
+	AbsTicks uint64
+	Tempo    meta.Tempo
+}
+
+// MeterMark is a time signature message at a certain tick of a Timeline
+type MeterMark struct {
+	AbsTicks uint64
+	TimeSig  meta.TimeSig
+}
+
+// KeyMark is a key signature message at a certain tick of a Timeline
+type KeyMark struct {
+	AbsTicks uint64
+	Key      meta.Key
+}
+
+// TextMark is a marker or cue point message at a certain tick of a Timeline
+type TextMark struct {
+	AbsTicks uint64
+	Text     string
+}
+
+// Timeline is the content of the conductor track of a SMF as typed lists, sorted by their ticks.
+// Entries at the same tick keep the order of the tracks and the order within a track.
+// It can be modified and written back into the first track with ApplyConductor.
+type Timeline struct {
+	timeFormat smf.TimeFormat
+
+	TempoChanges []TempoMark
+	MeterChanges []MeterMark
+	KeyChanges   []KeyMark
+	Markers      []TextMark
+	CuePoints    []TextMark
+
+	// SMPTEOffset is nil, if there is no SMPTE offset message
+	SMPTEOffset *meta.SMPTE
+}
+
+// Conductor returns the Timeline of the conductor messages (tempo, time signature, key, markers, cue points and
+// SMPTE offset) of all tracks of the given SMF. For SMF2, only the first track is taken, since the tracks are
+// independent.
+func Conductor(s *SMF) *Timeline {
+	tracks := s.tracks
+
+	if s.format == smf.SMF2 && len(tracks) > 1 {
+		tracks = tracks[:1]
+	}
+
+	return newTimeline(s.timeFormat, tracks...)
+}
+
+// newTimeline returns the Timeline of the conductor messages of the given tracks
+func newTimeline(timeFormat smf.TimeFormat, tracks ...*Track) *Timeline {
+	tl := &Timeline{timeFormat: timeFormat}
+
+	for _, tr := range tracks {
+		for _, ev := range tr.evs() {
+			switch v := ev.Message.(type) {
+			case meta.Tempo:
+				tl.TempoChanges = append(tl.TempoChanges, TempoMark{AbsTicks: ev.AbsTicks, Tempo: v})
+			case meta.TimeSig:
+				tl.MeterChanges = append(tl.MeterChanges, MeterMark{AbsTicks: ev.AbsTicks, TimeSig: v})
+			case meta.Key:
+				tl.KeyChanges = append(tl.KeyChanges, KeyMark{AbsTicks: ev.AbsTicks, Key: v})
+			case meta.Marker:
+				tl.Markers = append(tl.Markers, TextMark{AbsTicks: ev.AbsTicks, Text: v.Text()})
+			case meta.Cuepoint:
+				tl.CuePoints = append(tl.CuePoints, TextMark{AbsTicks: ev.AbsTicks, Text: v.Text()})
+			case meta.SMPTE:
+				if tl.SMPTEOffset == nil {
+					smpte := v
+					tl.SMPTEOffset = &smpte
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(tl.TempoChanges, func(a, b int) bool { return tl.TempoChanges[a].AbsTicks < tl.TempoChanges[b].AbsTicks })
+	sort.SliceStable(tl.MeterChanges, func(a, b int) bool { return tl.MeterChanges[a].AbsTicks < tl.MeterChanges[b].AbsTicks })
+	sort.SliceStable(tl.KeyChanges, func(a, b int) bool { return tl.KeyChanges[a].AbsTicks < tl.KeyChanges[b].AbsTicks })
+	sort.SliceStable(tl.Markers, func(a, b int) bool { return tl.Markers[a].AbsTicks < tl.Markers[b].AbsTicks })
+	sort.SliceStable(tl.CuePoints, func(a, b int) bool { return tl.CuePoints[a].AbsTicks < tl.CuePoints[b].AbsTicks })
+
+	return tl
+}
+
+// TempoMap returns the TempoMap of the tempo changes
+func (tl *Timeline) TempoMap() *TempoMap {
+	return newTempoMap(tl.timeFormat, tl.TempoChanges)
+}
+
+// MeterMap returns the MeterMap of the time signature changes
+func (tl *Timeline) MeterMap() *MeterMap {
+	return newMeterMap(tl.timeFormat, tl.MeterChanges)
+}
+
+// SetTempo sets the tempo at the given tick, replacing the tempo changes at that tick
+func (tl *Timeline) SetTempo(absTicks uint64, tempo meta.Tempo) {
+	i, n := markRange(len(tl.TempoChanges), absTicks, func(i int) uint64 { return tl.TempoChanges[i].AbsTicks })
+	tl.TempoChanges = append(tl.TempoChanges[:i], append([]TempoMark{{absTicks, tempo}}, tl.TempoChanges[i+n:]...)...)
+}
+
+// RemoveTempo removes the tempo changes at the given tick
+func (tl *Timeline) RemoveTempo(absTicks uint64) {
+	i, n := markRange(len(tl.TempoChanges), absTicks, func(i int) uint64 { return tl.TempoChanges[i].AbsTicks })
+	tl.TempoChanges = append(tl.TempoChanges[:i], tl.TempoChanges[i+n:]...)
+}
+
+// SetMeter sets the time signature at the given tick, replacing the time signature changes at that tick
+func (tl *Timeline) SetMeter(absTicks uint64, timeSig meta.TimeSig) {
+	i, n := markRange(len(tl.MeterChanges), absTicks, func(i int) uint64 { return tl.MeterChanges[i].AbsTicks })
+	tl.MeterChanges = append(tl.MeterChanges[:i], append([]MeterMark{{absTicks, timeSig}}, tl.MeterChanges[i+n:]...)...)
+}
+
+// RemoveMeter removes the time signature changes at the given tick
+func (tl *Timeline) RemoveMeter(absTicks uint64) {
+	i, n := markRange(len(tl.MeterChanges), absTicks, func(i int) uint64 { return tl.MeterChanges[i].AbsTicks })
+	tl.MeterChanges = append(tl.MeterChanges[:i], tl.MeterChanges[i+n:]...)
+}
+
+// SetKey sets the key signature at the given tick, replacing the key changes at that tick
+func (tl *Timeline) SetKey(absTicks uint64, key meta.Key) {
+	i, n := markRange(len(tl.KeyChanges), absTicks, func(i int) uint64 { return tl.KeyChanges[i].AbsTicks })
+	tl.KeyChanges = append(tl.KeyChanges[:i], append([]KeyMark{{absTicks, key}}, tl.KeyChanges[i+n:]...)...)
+}
+
+// RemoveKey removes the key changes at the given tick
+func (tl *Timeline) RemoveKey(absTicks uint64) {
+	i, n := markRange(len(tl.KeyChanges), absTicks, func(i int) uint64 { return tl.KeyChanges[i].AbsTicks })
+	tl.KeyChanges = append(tl.KeyChanges[:i], tl.KeyChanges[i+n:]...)
+}
+
+// AddMarker adds a marker at the given tick, after the markers at the same tick
+func (tl *Timeline) AddMarker(absTicks uint64, text string) {
+	i, n := markRange(len(tl.Markers), absTicks, func(i int) uint64 { return tl.Markers[i].AbsTicks })
+	tl.Markers = append(tl.Markers[:i+n], append([]TextMark{{absTicks, text}}, tl.Markers[i+n:]...)...)
+}
+
+// AddCuePoint adds a cue point at the given tick, after the cue points at the same tick
+func (tl *Timeline) AddCuePoint(absTicks uint64, text string) {
+	i, n := markRange(len(tl.CuePoints), absTicks, func(i int) uint64 { return tl.CuePoints[i].AbsTicks })
+	tl.CuePoints = append(tl.CuePoints[:i+n], append([]TextMark{{absTicks, text}}, tl.CuePoints[i+n:]...)...)
+}
+
+// markRange returns the index of the first of the n entries of a sorted list of the given length that are at the
+// given tick. If there are none, i is the index where an entry for the tick would be inserted.
+func markRange(length int, absTicks uint64, tick func(i int) uint64) (i, n int) {
+	i = sort.Search(length, func(i int) bool { return tick(i) >= absTicks })
+	for i+n < length && tick(i+n) == absTicks {
+		n++
+	}
+	return
+}
+
+// messages returns the messages of the timeline as events, sorted by their ticks.
+// At the same tick, the SMPTE offset comes first, followed by time signatures, keys, tempos, markers and cue points.
+func (tl *Timeline) messages() (evts []Event) {
+	if tl.SMPTEOffset != nil {
+		evts = append(evts, Event{Message: *tl.SMPTEOffset})
+	}
+
+	for _, m := range tl.MeterChanges {
+		evts = append(evts, Event{AbsTicks: m.AbsTicks, Message: m.TimeSig})
+	}
+
+	for _, m := range tl.KeyChanges {
+		evts = append(evts, Event{AbsTicks: m.AbsTicks, Message: m.Key})
+	}
+
+	for _, m := range tl.TempoChanges {
+		evts = append(evts, Event{AbsTicks: m.AbsTicks, Message: m.Tempo})
+	}
+
+	for _, m := range tl.Markers {
+		evts = append(evts, Event{AbsTicks: m.AbsTicks, Message: meta.Marker(m.Text)})
+	}
+
+	for _, m := range tl.CuePoints {
+		evts = append(evts, Event{AbsTicks: m.AbsTicks, Message: meta.Cuepoint(m.Text)})
+	}
+
+	sort.SliceStable(evts, func(a, b int) bool { return evts[a].AbsTicks < evts[b].AbsTicks })
+	return
+}
+
+// isHeaderMessage returns true for the meta messages that are kept before the conductor messages at the same tick
+func isHeaderMessage(msg midi.Message) bool {
+	switch msg.(type) {
+	case meta.SequenceNo, meta.Track, meta.Sequence, meta.Copyright:
+		return true
+	}
+	return false
+}
+
+// ApplyConductor returns a copy of the given SMF where the conductor messages (tempo, time signature, key, markers,
+// cue points and SMPTE offset) of all tracks are replaced by the messages of the given Timeline within the first
+// track, as the SMF specification requires it for format 1. The given SMF is not modified.
+//
+// At the same tick, the conductor messages are placed after the sequence number, sequence or track name and
+// copyright messages and before any other messages. The other tracks are left untouched, if they have no conductor messages.
+// The conductor messages are created from the Timeline and have no tag.
+// SMF2 is not supported, since its tracks are independent.
+func ApplyConductor(s *SMF, tl *Timeline) (*SMF, error) {
+	if s.format == smf.SMF2 {
+		return nil, fmt.Errorf("can't apply conductor: %w", ErrNoConductor)
+	}
+
+	res := s.clone()
+
+	if len(res.tracks) == 0 {
+		res.tracks = append(res.tracks, &Track{})
+	}
+
+	for _, tr := range res.tracks[1:] {
+		var kept []Event
+
+		for _, ev := range tr.evs() {
+			if !isConductorMessage(ev.Message) {
+				kept = append(kept, ev)
+			}
+		}
+
+		if len(kept) != len(tr.evs()) {
+			tr.SetEvents(kept)
+		}
+	}
+
+	first := res.tracks[0]
+
+	// rank orders the events at the same tick
+	type ranked struct {
+		Event
+		rank int
+	}
+
+	var evts []ranked
+
+	for _, ev := range first.evs() {
+		switch {
+		case isConductorMessage(ev.Message):
+		case isHeaderMessage(ev.Message):
+			evts = append(evts, ranked{ev, 0})
+		default:
+			evts = append(evts, ranked{ev, 2})
+		}
+	}
+
+	for _, ev := range tl.messages() {
+		evts = append(evts, ranked{ev, 1})
+	}
+
+	sort.SliceStable(evts, func(a, b int) bool {
+		if evts[a].AbsTicks != evts[b].AbsTicks {
+			return evts[a].AbsTicks < evts[b].AbsTicks
+		}
+		return evts[a].rank < evts[b].rank
+	})
+
+	var sorted = make([]Event, len(evts))
+
+	for i, ev := range evts {
+		sorted[i] = ev.Event
+	}
+
+	first.SetEvents(sorted)
+	return res, nil
+}
@@ -0,0 +1,100 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/meta/key"
+	"github.com/gomidi/midi/midimessage/meta/meter"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestConductor(t *testing.T) {
+	var first, second Track
+	first.Add(0, meta.Track("Conductor"), meter.Meter(4, 4), meta.BPM(120))
+	second.Add(0, meta.Track("Piano"), channel.Channel0.NoteOn(60, 100))
+	// misplaced for SMF1
+	second.Add(384, meta.Marker("Verse"), channel.Channel0.NoteOff(60))
+
+	s := New(smf.SMF1, smf.MetricTicks(96))
+	s.AddTrack(&first)
+	s.AddTrack(&second)
+
+	tl := Conductor(s)
+
+	if got, want := fmt.Sprint(tl.Markers), "[{384 Verse}]"; got != want {
+		t.Errorf("Markers = %v; want %v", got, want)
+	}
+
+	tl.SetTempo(0, meta.BPM(100))
+	tl.SetTempo(384, meta.BPM(60))
+	tl.SetMeter(768, meter.Meter(3, 4))
+	tl.SetKey(0, key.DMaj())
+	tl.AddCuePoint(384, "Go")
+
+	if got, want := tl.TempoMap().Time(480).String(), "3.4s"; got != want {
+		t.Errorf("TempoMap().Time(480) = %v; want %v", got, want)
+	}
+
+	if bar, _ := tl.MeterMap().Bar(1100); bar != 3 {
+		t.Errorf("MeterMap().Bar(1100) = %v; want %v", bar, 3)
+	}
+
+	res, err := ApplyConductor(s, tl)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var bf bytes.Buffer
+
+	if err := res.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	data := bf.Bytes()
+
+	expected := "4D 54 72 6B 00 00 00 46" +
+		" 00 FF 04 09 43 6F 6E 64 75 63 74 6F 72" + // track name
+		" 00 FF 58 04 04 02 08 08" + // 4/4
+		" 00 FF 59 02 02 00" + // D major
+		" 00 FF 51 03 09 27 C0" + // 100 BPM
+		" 83 00 FF 51 03 0F 42 40" + // 60 BPM at 384
+		" 00 FF 06 05 56 65 72 73 65" + // marker
+		" 00 FF 07 02 47 6F" + // cue point
+		" 83 00 FF 58 04 03 02 08 08" + // 3/4 at 768
+		" 00 FF 2F 00" +
+		" 4D 54 72 6B 00 00 00 15" +
+		" 00 FF 04 05 50 69 61 6E 6F" + // track name
+		" 00 90 3C 64 83 00 3C 00" + // the marker is gone
+		" 00 FF 2F 00"
+
+	if got, want := fmt.Sprintf("% X", data[14:]), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	s, err = Read(bytes.NewReader(data))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	tl2 := Conductor(s)
+
+	if got, want := fmt.Sprintf("%v %v %v %v %v", tl2.TempoChanges, tl2.MeterChanges, tl2.KeyChanges, tl2.Markers, tl2.CuePoints),
+		fmt.Sprintf("%v %v %v %v %v", tl.TempoChanges, tl.MeterChanges, tl.KeyChanges, tl.Markers, tl.CuePoints); got != want {
+		t.Errorf("round trip:\ngot:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestApplyConductorSMF2(t *testing.T) {
+	s := New(smf.SMF2, smf.MetricTicks(96))
+	s.AddTrack(&Track{})
+
+	if _, err := ApplyConductor(s, Conductor(s)); err == nil {
+		t.Errorf("expected error for SMF2")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package smftrack provides an in-memory representation of Standard MIDI Files (SMF) that allows
+modification of tracks.
+
+While the smfreader and smfwriter packages are streaming MIDI messages, smftrack keeps the complete
+file in memory. Each message of a track is stored as an Event with the absolute position in ticks
+from the beginning of the track. The delta times are calculated when writing.
+
+Usage
+
+	import (
+		"github.com/gomidi/midi/smf/smftrack"
+		. "github.com/gomidi/midi/midimessage/channel"
+	)
+
+	s, err := smftrack.ReadFile("file.mid")
+
+	if err != nil {
+		// deal with err
+	}
+
+	// add a note on the first track at the second quarter note
+	tpq := s.TimeFormat().(smf.MetricTicks)
+	s.Track(0).Add(uint64(tpq.Ticks4th()), Channel2.NoteOn(65, 90))
+	s.Track(0).Add(uint64(tpq.Ticks4th()*2), Channel2.NoteOff(65))
+
+	err = s.WriteFile("modified.mid")
+
+Tags
+
+An application may attach a tag (an opaque ID) to each event, e.g. to keep the selection of an editor while
+the file is transformed. Tags are never written, so a file that is read has no tags.
+
+	err = s.Track(0).SetTag(3, 42)
+
+	res := smftrack.Legato(s, 10)
+	// the event with tag 42 is still in res.Track(0), possibly at another tick
+
+The transforming functions keep the tags of the events they move, change or copy. Events that they create
+(e.g. messages that reestablish a chased state or note messages that split notes) have no tag.
+
+RecordProvenance uses the tags to record the source of each event (a file ID, the track and the index), so that
+the origin of an event can be queried after the file has been transformed or merged with others:
+
+	s = smftrack.RecordProvenance(s, "a.mid")
+	res, _, err := smftrack.Mixdown(s, other)
+	src, ok := res.Provenance(1, 3) // e.g. a.mid track 1 event 3
+
+Positions
+
+The MeterMap converts ticks to positions of bars and beats and back, following the changes of the time signature:
+
+	pos := s.MeterMap().Position(7680) // e.g. bar 5 beat 1.00
+	p, err := smftrack.ParsePosition("17.3.240")
+	tick, err := s.MeterMap().PositionTicks(p)
+
+Pickup bars are numbered by the FirstBar and Pickup options. The problems of Validate show positions with the
+BarPositions option.
+
+Search
+
+A Query finds the events that match all of its criteria. It is compiled once and can search many files:
+
+	q, err := smftrack.NewQuery(smftrack.QueryTypes(meta.Lyric("")), smftrack.QueryTextRegexp(`(?i)love`))
+	matches := smftrack.Find(s, q) // with the positions in the track and in the Index
+	n := smftrack.Count(other, q)
+
+Signatures
+
+Sign embeds the name and version of the producing tool and the Fingerprint of the content, so that the SMF can
+be identified later and modifications after the signing are detected:
+
+	signed, err := smftrack.Sign(s, "arranger", "1.2.0")
+	sig, ok := smftrack.ReadSignature(signed) // ok is false, if modified since the signing
+
+Concurrency
+
+A SMF may be read by multiple goroutines at the same time, as long as no goroutine modifies it.
+The functions that transform a SMF (e.g. Slice, Legato or ApplyPatch) only read the given SMF and return a copy.
+To enforce this for a SMF that is shared (e.g. a cache of parsed files), it can be frozen:
+
+	s.Freeze()
+
+	// in any goroutine
+	err = s.Track(0).Add(0, Channel2.NoteOn(65, 90)) // returns smftrack.ErrFrozen
+	res := smftrack.Legato(s, 10)                     // returns a modifiable copy
+
+*/
+package smftrack
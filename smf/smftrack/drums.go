@@ -0,0 +1,170 @@
+package smftrack
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// DrumDetector returns the drum channels of the given SMF, e.g. analysis.DetectDrumChannels.
+// It lets the transformations that treat drums differently find the drum channels automatically
+// (see DetectDrums).
+type DrumDetector func(*SMF) []uint8
+
+// drums are the drum channels of a transformation
+type drums struct {
+	drumChannels map[uint8]bool
+	detectDrums  DrumDetector
+}
+
+// resolveDrums sets the drum channels to the channels that the DrumDetector returns for the given SMF, if there is one
+func (d *drums) resolveDrums(s *SMF) {
+	if d.detectDrums != nil {
+		d.drumChannels = channelSet(d.detectDrums(s))
+	}
+}
+
+// DrumOption is an option for all transformations that treat drums differently:
+// Legato, Retrigger, ForceToScale, VelocityRamp and RemapDrums.
+type DrumOption struct {
+	detect DrumDetector
+}
+
+// DetectDrums sets the drum channels to the channels that the given DrumDetector returns for the given SMF,
+// e.g. analysis.DetectDrumChannels.
+func DetectDrums(d DrumDetector) DrumOption {
+	return DrumOption{detect: d}
+}
+
+func (o DrumOption) applyLegato(c *legatoConfig) {
+	c.detectDrums = o.detect
+}
+
+func (o DrumOption) applyRetrigger(c *retriggerConfig) {
+	c.detectDrums = o.detect
+}
+
+func (o DrumOption) applyScale(c *scaleConfig) {
+	c.detectDrums = o.detect
+}
+
+func (o DrumOption) applyRamp(c *rampConfig) {
+	c.detectDrums = o.detect
+}
+
+func (o DrumOption) applyRemap(c *remapConfig) {
+	c.detectDrums = o.detect
+}
+
+// channelSet returns the given channels as a set
+func channelSet(channels []uint8) map[uint8]bool {
+	res := map[uint8]bool{}
+	for _, ch := range channels {
+		res[ch] = true
+	}
+	return res
+}
+
+type remapConfig struct {
+	dropUnmapped bool
+	drums
+}
+
+// RemapOption is an option for RemapDrums (see also DetectDrums)
+type RemapOption interface {
+	applyRemap(*remapConfig)
+}
+
+type remapOption func(*remapConfig)
+
+func (o remapOption) applyRemap(c *remapConfig) {
+	o(c)
+}
+
+// DropUnmapped removes the notes with unmapped keys. Without this option, they are kept unchanged.
+func DropUnmapped() RemapOption {
+	return remapOption(func(c *remapConfig) {
+		c.dropUnmapped = true
+	})
+}
+
+// RemapDrums returns a copy of the given SMF where the keys of the note on, note off and polyphonic aftertouch
+// messages on the given channel (see DetectDrums) are mapped by the given DrumMap, e.g. to convert a recording
+// of an e-drum kit to General MIDI (see channel.DrumMapPreset). The given SMF is not modified.
+//
+// The unmapped keys that have been encountered are returned in ascending order.
+func RemapDrums(s *SMF, m channel.DrumMap, ch uint8, options ...RemapOption) (res *SMF, unmapped []uint8) {
+	c := remapConfig{
+		drums: drums{drumChannels: map[uint8]bool{ch: true}},
+	}
+
+	for _, opt := range options {
+		opt.applyRemap(&c)
+	}
+
+	c.resolveDrums(s)
+
+	var missing = map[uint8]bool{}
+
+	res = s.clone()
+
+	for _, tr := range res.tracks {
+		var evts []Event
+		var changed bool
+
+		for _, ev := range tr.evs() {
+			msg, is := ev.Message.(channel.Message)
+
+			if !is || !c.drumChannels[msg.Channel()] {
+				evts = append(evts, ev)
+				continue
+			}
+
+			mapped, ok := m.Remap(msg)
+
+			if !ok {
+				missing[drumKey(msg)] = true
+
+				if c.dropUnmapped {
+					changed = true
+					continue
+				}
+			}
+
+			if mapped != msg {
+				changed = true
+			}
+
+			evts = append(evts, Event{AbsTicks: ev.AbsTicks, Message: mapped, Tag: ev.Tag})
+		}
+
+		if changed {
+			tr.SetEvents(evts)
+		}
+	}
+
+	for key := range missing {
+		unmapped = append(unmapped, key)
+	}
+
+	sort.Slice(unmapped, func(a, b int) bool {
+		return unmapped[a] < unmapped[b]
+	})
+
+	return res, unmapped
+}
+
+// drumKey returns the key of the given note on, note off or polyphonic aftertouch message
+func drumKey(msg channel.Message) uint8 {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		return v.Key()
+	case channel.NoteOffVelocity:
+		return v.Key()
+	case channel.NoteOff:
+		return v.Key()
+	case channel.PolyAftertouch:
+		return v.Key()
+	}
+	return 0
+}
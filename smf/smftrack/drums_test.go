@@ -0,0 +1,89 @@
+package smftrack
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestRemapDrums(t *testing.T) {
+	var tr Track
+	drums, piano := channel.Channel9, channel.Channel0
+	tr.Add(0, drums.NoteOn(36, 100), drums.NoteOn(22, 80), piano.NoteOn(22, 90))
+	tr.Add(10, drums.NoteOff(36), drums.NoteOff(22), piano.NoteOff(22))
+	tr.Add(20, drums.NoteOn(90, 70))
+	tr.Add(30, drums.NoteOff(90))
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	tests := []struct {
+		options  []RemapOption
+		expected string
+	}{
+		{nil, `0 channel.NoteOn channel 10 key 36 velocity 100
+0 channel.NoteOn channel 10 key 42 velocity 80
+0 channel.NoteOn channel 1 key 22 velocity 90
+10 channel.NoteOff channel 10 key 36
+10 channel.NoteOff channel 10 key 42
+10 channel.NoteOff channel 1 key 22
+20 channel.NoteOn channel 10 key 90 velocity 70
+30 channel.NoteOff channel 10 key 90
+30 end
+`},
+		{[]RemapOption{DropUnmapped()}, `0 channel.NoteOn channel 10 key 36 velocity 100
+0 channel.NoteOn channel 10 key 42 velocity 80
+0 channel.NoteOn channel 1 key 22 velocity 90
+10 channel.NoteOff channel 10 key 36
+10 channel.NoteOff channel 10 key 42
+10 channel.NoteOff channel 1 key 22
+30 end
+`},
+	}
+
+	for i, test := range tests {
+		res, unmapped := RemapDrums(s, channel.DrumMapPreset("TD"), 9, test.options...)
+
+		if got, want := trackString(res.Track(0)), test.expected; got != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+
+		if got, want := fmt.Sprint(unmapped), "[90]"; got != want {
+			t.Errorf("[%v] unmapped = %v; want %v", i, got, want)
+		}
+	}
+
+	if got, want := s.Track(0).Event(1).Message, drums.NoteOn(22, 80); got != want {
+		t.Errorf("RemapDrums modified its input: %s", got)
+	}
+}
+
+func TestDetectDrums(t *testing.T) {
+	var tr Track
+	drums, piano := channel.Channel9, channel.Channel15
+	tr.Add(0, drums.NoteOn(22, 80), piano.NoteOn(22, 90))
+	tr.Add(10, drums.NoteOff(22), piano.NoteOff(22))
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	detect := func(*SMF) []uint8 { return []uint8{15} }
+	res, unmapped := RemapDrums(s, channel.DrumMapPreset("TD"), 9, DetectDrums(detect))
+
+	expected := `0 channel.NoteOn channel 10 key 22 velocity 80
+0 channel.NoteOn channel 16 key 42 velocity 90
+10 channel.NoteOff channel 10 key 22
+10 channel.NoteOff channel 16 key 42
+10 end
+`
+
+	if got, want := trackString(res.Track(0)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if len(unmapped) != 0 {
+		t.Errorf("unmapped = %v; want none", unmapped)
+	}
+}
@@ -0,0 +1,215 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// OverlapPolicy determines what EditNotes does with an edited note that overlaps another note
+// on the same track, channel and key
+type OverlapPolicy int
+
+const (
+	// OverlapTruncate ends the earlier note at the start of the later one
+	OverlapTruncate OverlapPolicy = iota
+
+	// OverlapAllow keeps the overlapping notes. Since a note off message ends the earliest sounding note on its
+	// key, overlapping notes may be paired differently, when they are read again.
+	OverlapAllow
+)
+
+// NoteError is an edit of a note that has been rejected by EditNotes
+type NoteError struct {
+	// Note is the note before the edit
+	Note Note
+
+	// Edited is the note that has been returned by the edit function
+	Edited Note
+
+	Err error
+}
+
+// Error returns the error message
+func (e NoteError) Error() string {
+	return fmt.Sprintf("track %v: note %v at %v: %v", e.Note.Track, e.Note.Key, e.Note.AbsTicks, e.Err)
+}
+
+// Unwrap returns the error of the edit
+func (e NoteError) Unwrap() error {
+	return e.Err
+}
+
+// EditError is returned by EditNotes and contains the rejected edits
+type EditError []NoteError
+
+// Error returns the messages of all rejected edits
+func (e EditError) Error() string {
+	var msgs []string
+	for _, ne := range e {
+		msgs = append(msgs, ne.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the rejected edits as errors
+func (e EditError) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, ne := range e {
+		errs[i] = ne
+	}
+	return errs
+}
+
+type editConfig struct {
+	overlaps OverlapPolicy
+}
+
+// EditOption is an option for EditNotes
+type EditOption func(*editConfig)
+
+// EditOverlaps sets the OverlapPolicy. Default is OverlapTruncate.
+func EditOverlaps(policy OverlapPolicy) EditOption {
+	return func(c *editConfig) {
+		c.overlaps = policy
+	}
+}
+
+// EditNotes returns a copy of the given SMF where the given edit function is applied to each note that is selected
+// by the given where function (nil selects all notes). The edit function may change the channel, key, velocity,
+// start, duration and release velocity of a note; the note on and note off messages are moved and changed
+// accordingly.
+// The given SMF is not modified.
+//
+// An edit is rejected and the note is kept unchanged, if the edited note has a channel above 15, a key above 127,
+// a velocity of 0 or above 127, a release velocity above 127, another track or a start or duration that has become negative by an underflow
+// (i.e. that is not below 1<<63). The rejected edits are returned as EditError, together with the copy that has
+// all other edits.
+//
+// Edited notes that overlap another note on the same track, channel and key are resolved by the
+// OverlapPolicy (see EditOverlaps). Notes without a note off message get one, if their end is before the end of
+// the track; the end of the track is moved behind the end of the edited notes.
+func EditNotes(s *SMF, where func(Note) bool, edit func(Note) Note, options ...EditOption) (*SMF, error) {
+	var c editConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	res := s.clone()
+	var rejected EditError
+
+	for no, tr := range res.tracks {
+		notes := tr.Notes()
+		var edited = make([]bool, len(notes))
+		var end uint64
+
+		for i := range notes {
+			n := &notes[i]
+			n.Track = no
+
+			if where != nil && !where(*n) {
+				continue
+			}
+
+			e := edit(*n)
+
+			if err := checkEdit(*n, e); err != nil {
+				rejected = append(rejected, NoteError{Note: *n, Edited: e, Err: err})
+				continue
+			}
+
+			// the edit function may return a new Note
+			e.on, e.off = n.on, n.off
+			*n = e
+			edited[i] = true
+			end = max(end, n.End())
+		}
+
+		if c.overlaps == OverlapTruncate {
+			truncateOverlaps(notes, edited)
+		}
+
+		var changed []Note
+
+		for i, n := range notes {
+			// the notes without note off message must keep their end, if the end of the track is moved
+			if edited[i] || (end > tr.end && n.off < 0) {
+				changed = append(changed, n)
+			}
+		}
+
+		if len(changed) == 0 {
+			continue
+		}
+
+		if end > tr.end {
+			tr.end = end
+		}
+
+		// can't fail, since the notes are from the track
+		tr.SetNotes(changed)
+	}
+
+	if len(rejected) > 0 {
+		return res, rejected
+	}
+
+	return res, nil
+}
+
+// checkEdit returns an error, if the edited note is invalid
+func checkEdit(n, e Note) error {
+	switch {
+	case e.Track != n.Track:
+		return fmt.Errorf("can't move note to track %v", e.Track)
+	case e.Channel > 15:
+		return fmt.Errorf("invalid channel %v", e.Channel)
+	case e.Key > 127:
+		return fmt.Errorf("invalid key %v", e.Key)
+	case e.Velocity == 0 || e.Velocity > 127:
+		return fmt.Errorf("invalid velocity %v", e.Velocity)
+	case e.ReleaseVelocity > 127:
+		return fmt.Errorf("invalid release velocity %v", e.ReleaseVelocity)
+	case e.AbsTicks > math.MaxInt64:
+		return fmt.Errorf("negative start")
+	case e.Duration > math.MaxInt64:
+		return fmt.Errorf("negative duration")
+	}
+	return nil
+}
+
+// truncateOverlaps ends each note at the start of the first later note on the same channel and key that it overlaps,
+// if one of both notes has been edited. Truncated notes are marked as edited.
+func truncateOverlaps(notes []Note, edited []bool) {
+	var byKey = map[[2]uint8][]int{}
+
+	for i, n := range notes {
+		k := [2]uint8{n.Channel, n.Key}
+		byKey[k] = append(byKey[k], i)
+	}
+
+	for _, idx := range byKey {
+		// notes that start at the same tick keep their order
+		sort.SliceStable(idx, func(a, b int) bool {
+			return notes[idx[a]].AbsTicks < notes[idx[b]].AbsTicks
+		})
+
+		for pos, i := range idx {
+			n := &notes[i]
+
+			for _, j := range idx[pos+1:] {
+				if notes[j].AbsTicks >= n.End() {
+					break
+				}
+
+				if edited[i] || edited[j] {
+					n.Duration = notes[j].AbsTicks - n.AbsTicks
+					edited[i] = true
+					break
+				}
+			}
+		}
+	}
+}
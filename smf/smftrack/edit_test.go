@@ -0,0 +1,334 @@
+package smftrack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+// sortedNotes returns the notes as "track channel key velocity start duration" lines, sorted
+func sortedNotes(notes []Note) string {
+	var lines []string
+
+	for _, n := range notes {
+		lines = append(lines, fmt.Sprintf("%v %v %v %v %v %v", n.Track, n.Channel, n.Key, n.Velocity, n.AbsTicks, n.Duration))
+	}
+
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func TestEditNotes(t *testing.T) {
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, ch.NoteOn(60, 100), ch.NoteOn(64, 90))
+	tr.Add(480, ch.NoteOff(60), ch.NoteOff(64), ch.NoteOn(62, 80))
+	tr.Add(960, ch.NoteOff(62), ch.NoteOn(60, 70))
+	tr.Add(1440, ch.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	tests := []struct {
+		name     string
+		where    func(Note) bool
+		edit     func(Note) Note
+		options  []EditOption
+		expected string
+		rejected int
+	}{
+		{
+			"transpose",
+			nil,
+			func(n Note) Note { n.Key += 2; return n },
+			nil,
+			"0 0 62 100 0 480\n0 0 62 70 960 480\n0 0 64 80 480 480\n0 0 66 90 0 480",
+			0,
+		},
+		{
+			// the new note must not be ended by the note off of the first note
+			"new note",
+			func(n Note) bool { return n.Key == 62 },
+			func(n Note) Note { return Note{Channel: 0, Key: 60, Velocity: 50, AbsTicks: 240, Duration: 960} },
+			nil,
+			"0 0 60 100 0 240\n0 0 60 50 240 720\n0 0 60 70 960 480\n0 0 64 90 0 480",
+			0,
+		},
+		{
+			// the note off of the moved note ends the first note
+			"allow overlaps",
+			func(n Note) bool { return n.AbsTicks == 960 },
+			func(n Note) Note { n.AbsTicks, n.Duration = 240, 100; return n },
+			[]EditOption{EditOverlaps(OverlapAllow)},
+			"0 0 60 100 0 340\n0 0 60 70 240 240\n0 0 62 80 480 480\n0 0 64 90 0 480",
+			0,
+		},
+		{
+			"longer",
+			func(n Note) bool { return n.AbsTicks == 960 },
+			func(n Note) Note { n.Duration = 960; return n },
+			nil,
+			"0 0 60 100 0 480\n0 0 60 70 960 960\n0 0 62 80 480 480\n0 0 64 90 0 480",
+			0,
+		},
+		{
+			"invalid",
+			nil,
+			func(n Note) Note {
+				switch n.Key {
+				case 60:
+					n.Key = 128
+				case 62:
+					n.AbsTicks -= 960
+				default:
+					n.Velocity = 127
+				}
+				return n
+			},
+			nil,
+			"0 0 60 100 0 480\n0 0 60 70 960 480\n0 0 62 80 480 480\n0 0 64 127 0 480",
+			3,
+		},
+	}
+
+	for _, test := range tests {
+		res, err := EditNotes(s, test.where, test.edit, test.options...)
+
+		var rejected EditError
+		if err != nil && !errors.As(err, &rejected) {
+			t.Fatalf("[%s] Error: %v", test.name, err)
+		}
+
+		if len(rejected) != test.rejected {
+			t.Errorf("[%s] rejected %v edits (%v); want %v", test.name, len(rejected), err, test.rejected)
+		}
+
+		if got := sortedNotes(res.Notes()); got != test.expected {
+			t.Errorf("[%s] got:\n%s\n\nwanted:\n%s\n\n", test.name, got, test.expected)
+		}
+	}
+
+	if got, want := trackString(s.Track(0)), trackString(&tr); got != want {
+		t.Errorf("EditNotes modified the given SMF:\n%s", got)
+	}
+
+	_, err := EditNotes(s, nil, func(n Note) Note { n.Velocity = 0; return n })
+
+	if got, want := err.Error(), "track 0: note 60 at 0: invalid velocity 0; "; !strings.HasPrefix(got, want) {
+		t.Errorf("Error() = %q; want prefix %q", got, want)
+	}
+}
+
+// editModel applies the edit to the notes like EditNotes, but naively: each note is checked against all others
+func editModel(notes []Note, where func(Note) bool, edit func(Note) Note) []Note {
+	res := make([]Note, len(notes))
+	edited := make([]bool, len(notes))
+
+	for i, n := range notes {
+		res[i] = n
+
+		if !where(n) {
+			continue
+		}
+
+		e := edit(n)
+
+		if e.Channel > 15 || e.Key > 127 || e.Velocity == 0 || e.Velocity > 127 || int64(e.AbsTicks) < 0 || int64(e.Duration) < 0 {
+			continue
+		}
+
+		res[i], edited[i] = e, true
+	}
+
+	for i := range res {
+		a := &res[i]
+
+		for j, b := range res {
+			later := b.AbsTicks > a.AbsTicks || (b.AbsTicks == a.AbsTicks && j > i)
+			same := a.Track == b.Track && a.Channel == b.Channel && a.Key == b.Key
+
+			if j != i && same && later && b.AbsTicks < a.End() && (edited[i] || edited[j]) {
+				a.Duration = b.AbsTicks - a.AbsTicks
+			}
+		}
+	}
+
+	return res
+}
+
+// TestEditNotesModel compares EditNotes with editModel for random SMFs and random edits
+func TestEditNotesModel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for run := 0; run < 200; run++ {
+		s := New(smf.SMF1, smf.MetricTicks(96))
+
+		for no := 0; no < 2; no++ {
+			var tr Track
+
+			// the notes on each key don't overlap, the last one has no note off
+			for key := uint8(60); key < 63; key++ {
+				var tick uint64
+
+				for i := 0; i < 4; i++ {
+					tick += uint64(r.Intn(100))
+					ch := channel.Channel(r.Intn(2))
+					tr.Add(tick, ch.NoteOn(key, uint8(1+r.Intn(127))))
+
+					if i < 3 {
+						tick += uint64(r.Intn(100))
+						tr.Add(tick, ch.NoteOff(key))
+					}
+				}
+			}
+
+			s.AddTrack(&tr)
+		}
+
+		notes := s.Notes()
+
+		var decisions = make([]bool, len(notes))
+		var edits = make([]Note, len(notes))
+
+		for i, n := range notes {
+			decisions[i] = r.Intn(2) == 0
+			n.Key = uint8(int(n.Key) + r.Intn(5) - 2)
+			n.Channel = uint8(r.Intn(3) / 2)
+			n.Velocity = uint8(r.Intn(129))
+			n.AbsTicks = uint64(int64(n.AbsTicks) + int64(r.Intn(200)) - 100)
+			n.Duration = uint64(int64(n.Duration) + int64(r.Intn(200)) - 50)
+			edits[i] = n
+		}
+
+		// the model visits the notes in the order of Notes, EditNotes track by track
+		var order []int
+		for no := 0; no < 2; no++ {
+			for k, n := range notes {
+				if n.Track == no {
+					order = append(order, k)
+				}
+			}
+		}
+
+		var i, j int
+		expected := sortedNotes(editModel(notes,
+			func(Note) bool { i++; return decisions[i-1] },
+			func(Note) Note { return edits[i-1] },
+		))
+
+		res, err := EditNotes(s,
+			func(Note) bool { j++; return decisions[order[j-1]] },
+			func(Note) Note { return edits[order[j-1]] },
+		)
+
+		var rejected EditError
+		if err != nil && !errors.As(err, &rejected) {
+			t.Fatalf("[%v] Error: %v", run, err)
+		}
+
+		if got := sortedNotes(res.Notes()); got != expected {
+			t.Fatalf("[%v] got:\n%s\n\nwanted:\n%s\n\n", run, got, expected)
+		}
+
+		var bf bytes.Buffer
+
+		if err := res.Write(&bf); err != nil {
+			t.Fatalf("[%v] Error: %v", run, err)
+		}
+
+		read, err := Read(&bf)
+
+		if err != nil {
+			t.Fatalf("[%v] Error: %v", run, err)
+		}
+
+		if got := sortedNotes(read.Notes()); got != expected {
+			t.Fatalf("[%v] after round trip got:\n%s\n\nwanted:\n%s\n\n", run, got, expected)
+		}
+	}
+}
+
+func TestEditNotesReleaseVelocity(t *testing.T) {
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, ch.NoteOn(60, 100), ch.NoteOn(64, 90))
+	tr.Add(96, ch.NoteOffVelocity(60, 20), ch.NoteOffVelocity(64, 127))
+	tr.Add(96, ch.NoteOn(60, 80), ch.NoteOn(67, 70))
+	tr.Add(192, ch.NoteOffVelocity(60, 1), ch.NoteOff(67))
+	tr.Add(288, ch.NoteOn(72, 60))
+	tr.SetEnd(384)
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	read, err := Read(&bf, smfreader.NoteOffVelocity())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// releases returns the keys with their release velocities
+	releases := func(s *SMF) string {
+		var res []string
+
+		for _, n := range s.Notes() {
+			res = append(res, fmt.Sprintf("%v:%v", n.Key, n.ReleaseVelocity))
+		}
+
+		return strings.Join(res, " ")
+	}
+
+	if got, want := releases(read), "60:20 64:127 60:1 67:0 72:0"; got != want {
+		t.Errorf("after reading got %q; want %q", got, want)
+	}
+
+	edited, err := EditNotes(read, nil, func(n Note) Note {
+		n.Key += 2
+		n.AbsTicks += 10
+
+		if n.Key == 74 {
+			n.Duration = 50
+			n.ReleaseVelocity = 33
+		}
+
+		return n
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	bf.Reset()
+
+	if err := edited.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	read, err = Read(&bf, smfreader.NoteOffVelocity())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := releases(read), "62:20 66:127 62:1 69:0 74:33"; got != want {
+		t.Errorf("after editing got %q; want %q", got, want)
+	}
+
+	if _, err := EditNotes(read, nil, func(n Note) Note { n.ReleaseVelocity = 128; return n }); err == nil {
+		t.Errorf("expected error for a release velocity of 128")
+	}
+}
@@ -0,0 +1,328 @@
+package smftrack
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+// QueryOption is a criterion of a Query (see NewQuery)
+type QueryOption func(*Query)
+
+// QueryTypes matches the messages of the same types as the given messages,
+// e.g. QueryTypes(channel.NoteOn{}, meta.Lyric(""))
+func QueryTypes(prototypes ...midi.Message) QueryOption {
+	return func(q *Query) {
+		q.types = map[reflect.Type]bool{}
+
+		for _, p := range prototypes {
+			q.types[reflect.TypeOf(p)] = true
+		}
+	}
+}
+
+// QueryChannels matches the channel messages of the given channels
+func QueryChannels(channels ...uint8) QueryOption {
+	return func(q *Query) {
+		q.channels = &[16]bool{}
+
+		for _, ch := range channels {
+			if ch < 16 {
+				q.channels[ch] = true
+			}
+		}
+	}
+}
+
+// QueryKeys matches the note messages and polyphonic aftertouch messages with a key from min to max (inclusive)
+func QueryKeys(min, max uint8) QueryOption {
+	return func(q *Query) {
+		q.keys = &[2]int{int(min), int(max)}
+	}
+}
+
+// QueryVelocities matches the note on messages and the note off messages with velocity
+// (see channel.NoteOffVelocity) with a velocity from min to max (inclusive). Note on messages with velocity 0 are
+// note off messages.
+func QueryVelocities(min, max uint8) QueryOption {
+	return func(q *Query) {
+		q.velocities = &[2]int{int(min), int(max)}
+	}
+}
+
+// QueryValues matches the channel messages with a value from min to max (inclusive): the value of control changes,
+// the program of program changes, the pressure of aftertouch messages and the value of pitch bend messages
+// (-8192 to 8191).
+func QueryValues(min, max int) QueryOption {
+	return func(q *Query) {
+		q.values = &[2]int{min, max}
+	}
+}
+
+// QueryText matches the text meta messages (e.g. lyrics, markers and track names) that contain the given text and
+// the system exclusive messages whose data in hex (e.g. "41 10 42 12") contains it.
+func QueryText(substr string) QueryOption {
+	return func(q *Query) {
+		q.text = func(s string) bool {
+			return strings.Contains(s, substr)
+		}
+	}
+}
+
+// QueryTextRegexp matches the text meta messages and the system exclusive messages (see QueryText) that match the
+// given regular expression. An invalid expression is returned as error by NewQuery.
+func QueryTextRegexp(expr string) QueryOption {
+	return func(q *Query) {
+		re, err := regexp.Compile(expr)
+
+		if err != nil {
+			q.err = fmt.Errorf("invalid text expression: %w", err)
+			return
+		}
+
+		q.text = re.MatchString
+	}
+}
+
+// QueryTicks matches the events from (inclusive) to (exclusive) the given ticks
+func QueryTicks(from, to uint64) QueryOption {
+	return func(q *Query) {
+		q.ticks = &[2]uint64{from, to}
+	}
+}
+
+// QueryTime matches the events from (inclusive) to (exclusive) the given times since the start, according to the
+// tempo map of the searched SMF (see TempoMap)
+func QueryTime(from, to time.Duration) QueryOption {
+	return func(q *Query) {
+		q.time = &[2]time.Duration{from, to}
+	}
+}
+
+// Query is a compiled search for events that match all of its criteria (see Find). It does not depend on a SMF,
+// so it can be used to search several files.
+type Query struct {
+	types      map[reflect.Type]bool
+	channels   *[16]bool
+	keys       *[2]int
+	velocities *[2]int
+	values     *[2]int
+	text       func(string) bool
+	ticks      *[2]uint64
+	time       *[2]time.Duration
+	err        error
+}
+
+// NewQuery returns the Query of the given criteria (e.g. QueryTypes or QueryTicks). A Query without criteria matches
+// all events. An error is returned, if a criterion is invalid (e.g. the expression of QueryTextRegexp).
+func NewQuery(options ...QueryOption) (*Query, error) {
+	q := &Query{}
+
+	for _, opt := range options {
+		opt(q)
+	}
+
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	return q, nil
+}
+
+// inRange returns true, if the given range is not set or contains the given value
+func inRange(r *[2]int, v int) bool {
+	return r == nil || (v >= r[0] && v <= r[1])
+}
+
+// matchMessage returns true, if the given message matches the criteria of the query that don't depend on the
+// position
+func (q *Query) matchMessage(msg midi.Message) bool {
+	if q.types != nil && !q.types[reflect.TypeOf(msg)] {
+		return false
+	}
+
+	if q.channels != nil {
+		m, is := msg.(channel.Message)
+
+		if !is || m.Channel() > 15 || !q.channels[m.Channel()] {
+			return false
+		}
+	}
+
+	if q.keys != nil {
+		m, is := msg.(interface{ Key() uint8 })
+
+		if _, isChannel := msg.(channel.Message); !is || !isChannel || !inRange(q.keys, int(m.Key())) {
+			return false
+		}
+	}
+
+	if q.velocities != nil {
+		m, is := msg.(interface{ Velocity() uint8 })
+
+		if _, isChannel := msg.(channel.Message); !is || !isChannel || !inRange(q.velocities, int(m.Velocity())) {
+			return false
+		}
+	}
+
+	if q.values != nil {
+		v, has := messageValue(msg)
+
+		if !has || !inRange(q.values, v) {
+			return false
+		}
+	}
+
+	if q.text != nil {
+		t, has := messageText(msg)
+
+		if !has || !q.text(t) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// messageValue returns the value of the given channel message (see QueryValues)
+func messageValue(msg midi.Message) (int, bool) {
+	switch v := msg.(type) {
+	case channel.ControlChange:
+		return int(v.Value()), true
+	case channel.ProgramChange:
+		return int(v.Program()), true
+	case channel.Aftertouch:
+		return int(v.Pressure()), true
+	case channel.PolyAftertouch:
+		return int(v.Pressure()), true
+	case channel.Pitchbend:
+		return int(v.Value()), true
+	}
+	return 0, false
+}
+
+// messageText returns the text of the given text meta message or the data of the given system exclusive message
+// in hex (see QueryText)
+func messageText(msg midi.Message) (string, bool) {
+	switch v := msg.(type) {
+	case meta.Text:
+		return v.Text(), true
+	case meta.Lyric:
+		return v.Text(), true
+	case meta.Marker:
+		return v.Text(), true
+	case meta.Cuepoint:
+		return v.Text(), true
+	case meta.Copyright:
+		return v.Text(), true
+	case meta.Track:
+		return v.Text(), true
+	case meta.Sequence:
+		return v.Text(), true
+	case meta.Program:
+		return v.Text(), true
+	case meta.Device:
+		return v.Text(), true
+	case sysex.Message:
+		return fmt.Sprintf("% X", v.Data()), true
+	}
+	return "", false
+}
+
+// fileQuery is a Query for a certain SMF, whose time range has been converted to ticks
+type fileQuery struct {
+	*Query
+	tempoMap *TempoMap
+}
+
+// forFile returns the Query for the given SMF
+func (q *Query) forFile(s *SMF) fileQuery {
+	fq := fileQuery{Query: q}
+
+	if q.time != nil {
+		fq.tempoMap = s.TempoMap()
+	}
+
+	return fq
+}
+
+// match returns true, if the given event matches all criteria
+func (q fileQuery) match(ev Event) bool {
+	if q.ticks != nil && (ev.AbsTicks < q.ticks[0] || ev.AbsTicks >= q.ticks[1]) {
+		return false
+	}
+
+	if q.time != nil {
+		if t := q.tempoMap.Time(ev.AbsTicks); t < q.time[0] || t >= q.time[1] {
+			return false
+		}
+	}
+
+	return q.matchMessage(ev.Message)
+}
+
+// Match is an event that has been found by Find
+type Match struct {
+	TrackEvent
+
+	// Index is the index of the event in its track (see Track.Event and Track.Delete)
+	Index int
+
+	// Merged is the index of the event in the merged events (see Merged and Index.Event)
+	Merged int
+}
+
+// Find returns the events of the given SMF that match the given Query, in the order of Merged.
+func Find(s *SMF, q *Query) []Match {
+	var res []Match
+	var merged int
+	var indices = make([]int, len(s.tracks))
+	fq := q.forFile(s)
+
+	for ev := range s.All() {
+		if fq.match(ev.Event) {
+			res = append(res, Match{TrackEvent: ev, Index: indices[ev.Track], Merged: merged})
+		}
+
+		indices[ev.Track]++
+		merged++
+	}
+
+	return res
+}
+
+// Count returns the number of events of the given SMF that match the given Query, i.e. len(Find(s, q)), without
+// merging the tracks and collecting the matches. The events before the tick range (see QueryTicks) are skipped.
+func Count(s *SMF, q *Query) (n int) {
+	fq := q.forFile(s)
+
+	for _, tr := range s.tracks {
+		i := 0
+
+		if q.ticks != nil {
+			i = sort.Search(len(tr.evs()), func(i int) bool {
+				return tr.evs()[i].AbsTicks >= q.ticks[0]
+			})
+		}
+
+		for ; i < len(tr.evs()); i++ {
+			if q.ticks != nil && tr.evs()[i].AbsTicks >= q.ticks[1] {
+				break
+			}
+
+			if fq.match(tr.evs()[i]) {
+				n++
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,176 @@
+package smftrack
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf"
+)
+
+// findSMF returns a SMF of format 1 with a song: the conductor track with markers and a GS reset, the vocals
+// with lyrics and the piano
+func findSMF() *SMF {
+	var conductor, vocals, piano Track
+	conductor.Add(0, meta.BPM(120), meta.Marker("Intro"), sysex.SysEx{0x41, 0x10, 0x42, 0x12, 0x40, 0x00, 0x7F, 0x00, 0x41})
+	conductor.Add(1920, meta.Marker("Verse"))
+	conductor.Add(5760, meta.Marker("Chorus"))
+
+	ch := channel.Channel0
+	vocals.Add(1920, meta.Lyric("Love"), ch.NoteOn(67, 80))
+	vocals.Add(2400, ch.NoteOff(67), meta.Lyric("me"), ch.NoteOn(69, 90))
+	vocals.Add(2880, ch.NoteOff(69), meta.Lyric("lovely"), ch.NoteOn(71, 100))
+	vocals.Add(3840, ch.NoteOff(71))
+	vocals.Add(5760, meta.Lyric("Loving"), ch.NoteOn(72, 110))
+	vocals.Add(6720, ch.NoteOff(72))
+
+	ch = channel.Channel1
+	piano.Add(0, ch.ProgramChange(0), ch.ControlChange(7, 90))
+	piano.Add(1920, ch.NoteOn(48, 60), ch.NoteOn(55, 70), ch.ControlChange(64, 127))
+	piano.Add(3840, ch.NoteOff(48), ch.NoteOff(55), ch.ControlChange(64, 0), ch.Pitchbend(-2000))
+	piano.Add(5760, ch.NoteOn(53, 100), ch.Pitchbend(0))
+	piano.Add(7680, ch.NoteOff(53))
+
+	s := New(smf.SMF1, smf.MetricTicks(960))
+	s.AddTrack(&conductor)
+	s.AddTrack(&vocals)
+	s.AddTrack(&piano)
+	return s
+}
+
+// matchesString returns the matches, one per line
+func matchesString(matches []Match) string {
+	var bd strings.Builder
+
+	for _, m := range matches {
+		fmt.Fprintf(&bd, "[%v:%v] %v: %s\n", m.Track, m.Index, m.AbsTicks, m.Message)
+	}
+
+	return bd.String()
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		descr    string
+		options  []QueryOption
+		expected string
+	}{
+		{
+			"lyrics that start with love in any case, with the words",
+			[]QueryOption{QueryTypes(meta.Lyric("")), QueryTextRegexp(`(?i)^lov`)},
+			`[1:0] 1920: meta.Lyric: "Love"
+[1:6] 2880: meta.Lyric: "lovely"
+[1:9] 5760: meta.Lyric: "Loving"
+`,
+		},
+		{
+			"loud note ons of the vocals in the verse",
+			[]QueryOption{QueryTypes(channel.NoteOn{}), QueryChannels(0), QueryVelocities(90, 127), QueryTicks(1920, 5760)},
+			`[1:4] 2400: channel.NoteOn channel 1 key 69 velocity 90
+[1:7] 2880: channel.NoteOn channel 1 key 71 velocity 100
+`,
+		},
+		{
+			"keys below C4 of all note messages",
+			[]QueryOption{QueryKeys(0, 59)},
+			`[2:2] 1920: channel.NoteOn channel 2 key 48 velocity 60
+[2:3] 1920: channel.NoteOn channel 2 key 55 velocity 70
+[2:5] 3840: channel.NoteOff channel 2 key 48
+[2:6] 3840: channel.NoteOff channel 2 key 55
+[2:9] 5760: channel.NoteOn channel 2 key 53 velocity 100
+[2:11] 7680: channel.NoteOff channel 2 key 53
+`,
+		},
+		{
+			"pitch bends up to 0 and pedal releases in bar 2 (2s to 4s at 120 BPM)",
+			[]QueryOption{QueryValues(-8192, 0), QueryChannels(1), QueryTime(2*time.Second, 4*time.Second)},
+			`[2:7] 3840: channel.ControlChange channel 2 controller 64 ("Hold Pedal (on/off)") value 0 (off)
+[2:8] 3840: channel.Pitchbend channel 2 value -2000 absValue 0
+[2:10] 5760: channel.Pitchbend channel 2 value 0 absValue 0
+`,
+		},
+		{
+			"sysex as hex",
+			[]QueryOption{QueryText("40 00 7F")},
+			`[0:2] 0: sysex.SysEx len: 9
+`,
+		},
+		{
+			"markers at or after the chorus",
+			[]QueryOption{QueryTypes(meta.Marker(""), meta.Cuepoint("")), QueryTicks(5760, 100000)},
+			`[0:4] 5760: meta.Marker: "Chorus"
+`,
+		},
+	}
+
+	s := findSMF()
+	idx := NewIndex(s)
+
+	for _, test := range tests {
+		q, err := NewQuery(test.options...)
+
+		if err != nil {
+			t.Fatalf("[%s] Error: %v", test.descr, err)
+		}
+
+		matches := Find(s, q)
+
+		if got, want := matchesString(matches), test.expected; got != want {
+			t.Errorf("[%s]\ngot:\n%s\n\nwanted:\n%s\n\n", test.descr, got, want)
+		}
+
+		if got, want := Count(s, q), len(matches); got != want {
+			t.Errorf("[%s] Count() = %v; want %v", test.descr, got, want)
+		}
+
+		for _, m := range matches {
+			if got, want := fmt.Sprint(s.Track(m.Track).Event(m.Index)), fmt.Sprint(m.Event); got != want {
+				t.Errorf("[%s] Track(%v).Event(%v) = %v; want %v", test.descr, m.Track, m.Index, got, want)
+			}
+
+			if got, want := fmt.Sprint(idx.Event(m.Merged)), fmt.Sprint(m.TrackEvent); got != want {
+				t.Errorf("[%s] Index.Event(%v) = %v; want %v", test.descr, m.Merged, got, want)
+			}
+		}
+	}
+}
+
+func TestQueryReuse(t *testing.T) {
+	q, err := NewQuery(QueryTypes(channel.NoteOn{}), QueryVelocities(100, 127))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	a := findSMF()
+	b, _ := EditNotes(a, nil, func(n Note) Note {
+		n.Velocity = 100
+		return n
+	})
+
+	if got, want := Count(a, q), 3; got != want {
+		t.Errorf("Count(a) = %v; want %v", got, want)
+	}
+
+	if got, want := Count(b, q), 7; got != want {
+		t.Errorf("Count(b) = %v; want %v", got, want)
+	}
+
+	if got, want := len(Find(a, q)), 3; got != want {
+		t.Errorf("len(Find(a)) = %v; want %v", got, want)
+	}
+
+	if _, err := NewQuery(QueryTextRegexp(`(lov`)); err == nil {
+		t.Errorf("NewQuery with an invalid expression must return an error")
+	}
+
+	all, _ := NewQuery()
+
+	if got, want := Count(a, all), len(a.Merged()); got != want {
+		t.Errorf("Count() without criteria = %v; want %v", got, want)
+	}
+}
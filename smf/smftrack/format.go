@@ -0,0 +1,111 @@
+package smftrack
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gomidi/midi/smf"
+)
+
+// ErrTrackCount is returned, if a SMF format 0 would get more than one track (see TrackPolicy)
+var ErrTrackCount = errors.New("SMF format 0 must have exactly one track")
+
+// ErrNoConductor is returned by the operations on the conductor track for SMF format 2, since its tracks are
+// independent and none of them is a conductor track
+var ErrNoConductor = errors.New("SMF format 2 has no conductor track")
+
+// TrackPolicy defines, what happens if a track is added to a SMF format 0 that already has a track
+type TrackPolicy int
+
+const (
+	// UpgradeFormat converts the SMF to format 1 before the track is added (see ToFormat1). This is the default.
+	UpgradeFormat TrackPolicy = iota
+
+	// RejectTracks refuses to add the track with ErrTrackCount
+	RejectTracks
+)
+
+// SetTrackPolicy sets the policy for adding a track to a SMF format 0 that already has a track (see AddTrack).
+// The policy is passed on to the copies that are returned by the transforming functions.
+// ErrFrozen is returned, if the SMF is frozen.
+func (s *SMF) SetTrackPolicy(p TrackPolicy) error {
+	if s.frozen {
+		return ErrFrozen
+	}
+
+	s.trackPolicy = p
+	return nil
+}
+
+// TrackPolicy returns the policy for adding a track to a SMF format 0 (see SetTrackPolicy)
+func (s *SMF) TrackPolicy() TrackPolicy {
+	return s.trackPolicy
+}
+
+// RemoveTrack removes the track with the given number. The following tracks move back by one.
+// Removing the first track of a SMF format 1 also removes its conductor messages.
+// ErrFrozen is returned, if the SMF is frozen, and an error, if the track does not exist.
+func (s *SMF) RemoveTrack(no int) error {
+	if s.frozen {
+		return ErrFrozen
+	}
+
+	if no < 0 || no >= len(s.tracks) {
+		return fmt.Errorf("track %v out of range [0,%v)", no, len(s.tracks))
+	}
+
+	s.tracks = append(s.tracks[:no], s.tracks[no+1:]...)
+	return nil
+}
+
+// ToFormat1 returns a copy of the given SMF in format 1. The conductor messages (tempo, time signature, key,
+// markers, cue points and SMPTE offset) of the single track of a SMF format 0 are extracted into a new first track,
+// together with its sequence number, track name, sequence and copyright messages at tick 0, which name the
+// sequence. The other messages stay in the second track and all events keep their tags.
+// A SMF format 1 is just copied. The given SMF is not modified.
+// ErrNoConductor is returned for SMF format 2 and ErrTrackCount for a SMF format 0 with more than one track.
+func ToFormat1(s *SMF) (*SMF, error) {
+	switch {
+	case s.format == smf.SMF2:
+		return nil, fmt.Errorf("can't convert to SMF format 1: %w", ErrNoConductor)
+	case s.format == smf.SMF0 && len(s.tracks) > 1:
+		return nil, fmt.Errorf("can't convert to SMF format 1: %w", ErrTrackCount)
+	}
+
+	res := s.clone()
+	res.format = smf.SMF1
+
+	if len(res.tracks) == 1 && s.format == smf.SMF0 {
+		// the copy of the track is not frozen
+		conductor, _ := res.tracks[0].splitConductor()
+		res.tracks = []*Track{conductor, res.tracks[0]}
+	}
+
+	return res, nil
+}
+
+// splitConductor moves the conductor messages of the track into a new conductor track, which is returned.
+// The other messages stay in the track. ErrFrozen is returned, if the track is frozen.
+func (t *Track) splitConductor() (*Track, error) {
+	var conductor Track
+	var cevts, revts []Event
+
+	for _, ev := range t.evs() {
+		if isConductorMessage(ev.Message) || (ev.AbsTicks == 0 && isHeaderMessage(ev.Message)) {
+			cevts = append(cevts, ev)
+		} else {
+			revts = append(revts, ev)
+		}
+	}
+
+	// an unchanged track keeps its raw data
+	if len(cevts) > 0 {
+		if err := t.SetEvents(revts); err != nil {
+			return nil, err
+		}
+	}
+
+	conductor.end = t.end
+	conductor.SetEvents(cevts)
+	return &conductor, nil
+}
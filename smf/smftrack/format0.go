@@ -0,0 +1,143 @@
+package smftrack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// format0Buffer is the size of the read buffer of each track of ToFormat0Stream
+const format0Buffer = 32 * 1024
+
+// streamEvent is a message of a track that is read by ToFormat0Stream
+type streamEvent struct {
+	absTicks uint64
+	msg      midi.Message
+}
+
+// ToFormat0Stream converts the SMF format 0 or 1 of r to format 0 and writes it to w, without reading the SMF
+// into memory: the tracks are read simultaneously and merged by their absolute ticks while the merged track is
+// written (see smfwriter.Streaming), so that the memory is bounded by the number of tracks.
+// Messages at the same tick are ordered like by Merged: by the number of their track, while keeping the order
+// within a track. The end of the merged track is the last end of the tracks.
+//
+// The given options are passed to the reader of each track. An error is returned for SMF format 2, since its tracks
+// have independent timelines, and smfreader.ErrMissing, if tracks are missing.
+func ToFormat0Stream(r io.ReaderAt, w io.WriteSeeker, options ...smfreader.Option) error {
+	var hd [14]byte
+
+	if n, err := r.ReadAt(hd[:], 0); n < len(hd) {
+		return fmt.Errorf("can't read header: %v", err)
+	}
+
+	// additional data of a longer header is skipped
+	single := append([]byte{}, hd[:]...)
+	binary.BigEndian.PutUint32(single[4:], 6)
+
+	rd := smfreader.New(bytes.NewReader(single), options...)
+
+	if err := rd.ReadHeader(); err != nil {
+		return err
+	}
+
+	header := rd.Header()
+
+	if header.Format == smf.SMF2 {
+		return fmt.Errorf("can't convert SMF format 2 to format 0")
+	}
+
+	// each track is read as a SMF format 0 with a single track, followed by the track chunk
+	binary.BigEndian.PutUint16(single[8:], 0)
+	binary.BigEndian.PutUint16(single[10:], 1)
+
+	var readers []smf.Reader
+	var seqs []iter.Seq[streamEvent]
+	var end uint64
+
+	off := 8 + int64(binary.BigEndian.Uint32(hd[4:]))
+
+	for len(readers) < int(header.NumTracks) {
+		var ch [8]byte
+
+		if n, _ := r.ReadAt(ch[:], off); n < len(ch) {
+			return smfreader.ErrMissing
+		}
+
+		length := int64(binary.BigEndian.Uint32(ch[4:]))
+
+		if string(ch[:4]) == "MTrk" {
+			src := io.MultiReader(bytes.NewReader(single), bufio.NewReaderSize(io.NewSectionReader(r, off, 8+length), format0Buffer))
+			trd := smfreader.New(src, options...)
+			readers = append(readers, trd)
+			seqs = append(seqs, trackEvents(trd, &end))
+		}
+
+		off += 8 + length
+	}
+
+	wr := smfwriter.New(w, smfwriter.NumTracks(1), smfwriter.Format(smf.SMF0), smfwriter.TimeFormat(header.TimeFormat), smfwriter.Streaming())
+	var last uint64
+
+	for ev := range merge(seqs, func(ev streamEvent) uint64 { return ev.absTicks }) {
+		if err := setDelta(wr, ev.absTicks-last, ev.msg); err != nil {
+			return err
+		}
+
+		if err := wr.Write(ev.msg); err != nil {
+			return err
+		}
+
+		last = ev.absTicks
+	}
+
+	for _, trd := range readers {
+		if err := smfreader.ErrOf(trd); err != nil {
+			return err
+		}
+	}
+
+	if end > last {
+		if err := setDelta(wr, end-last, meta.EndOfTrack); err != nil {
+			return err
+		}
+	}
+
+	if err := wr.Write(meta.EndOfTrack); err != smf.ErrFinished {
+		return err
+	}
+
+	return nil
+}
+
+// trackEvents returns an iterator over the messages of the single track of rd without the end of track message.
+// The end of the track is stored in end, if it is after the value of end.
+func trackEvents(rd smf.Reader, end *uint64) iter.Seq[streamEvent] {
+	return func(yield func(streamEvent) bool) {
+		for pos, msg := range smfreader.Events(rd) {
+			// a truncated event may be returned as nil before the error
+			if msg == nil {
+				continue
+			}
+
+			if msg == meta.EndOfTrack {
+				if pos.AbsTicks > *end {
+					*end = pos.AbsTicks
+				}
+				continue
+			}
+
+			if !yield(streamEvent{absTicks: pos.AbsTicks, msg: msg}) {
+				return
+			}
+		}
+	}
+}
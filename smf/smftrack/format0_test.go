@@ -0,0 +1,221 @@
+package smftrack
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// toFormat0 converts the given SMF data via ToFormat0Stream and reads the result
+func toFormat0(t testing.TB, data []byte) (*SMF, error) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "format0.mid"))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	defer f.Close()
+
+	if err := ToFormat0Stream(bytes.NewReader(data), f); err != nil {
+		return nil, err
+	}
+
+	return ReadFile(f.Name())
+}
+
+func TestToFormat0Stream(t *testing.T) {
+	var conductor, bass, drums Track
+	conductor.Add(0, meta.BPM(120), meta.Track("song"))
+	conductor.SetEnd(1000)
+	bass.Add(0, channel.Channel1.ProgramChange(33), channel.Channel1.NoteOn(40, 100))
+	bass.Add(480, channel.Channel1.NoteOff(40), channel.Channel1.NoteOn(43, 90))
+	bass.Add(960, channel.Channel1.NoteOff(43))
+	drums.Add(480, channel.Channel9.NoteOn(36, 100))
+	drums.Add(480, channel.Channel9.NoteOff(36))
+	drums.SetEnd(1920)
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&bass)
+	s.AddTrack(&drums)
+
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res, err := toFormat0(t, bf.Bytes())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if res.Format() != smf.SMF0 || res.TimeFormat() != smf.MetricTicks(480) || res.NumTracks() != 1 {
+		t.Fatalf("got %v with %v tracks and %v; want SMF0 with 1 track and %v", res.Format(), res.NumTracks(), res.TimeFormat(), smf.MetricTicks(480))
+	}
+
+	expected := `0 meta.Tempo BPM: 120.00
+0 meta.Track: "song"
+0 channel.ProgramChange channel 2 program 33
+0 channel.NoteOn channel 2 key 40 velocity 100
+480 channel.NoteOff channel 2 key 40
+480 channel.NoteOn channel 2 key 43 velocity 90
+480 channel.NoteOn channel 10 key 36 velocity 100
+480 channel.NoteOff channel 10 key 36
+960 channel.NoteOff channel 2 key 43
+1920 end
+`
+
+	if got, want := trackString(res.Track(0)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	// the same order as the merged events in memory
+	var merged bytes.Buffer
+
+	for _, ev := range s.Merged() {
+		merged.WriteString(ev.Message.String())
+	}
+
+	var streamed bytes.Buffer
+
+	for _, ev := range res.Track(0).Events() {
+		streamed.WriteString(ev.Message.String())
+	}
+
+	if merged.String() != streamed.String() {
+		t.Errorf("order differs from Merged")
+	}
+}
+
+func TestToFormat0StreamErrors(t *testing.T) {
+	write := func(format smf.Format, tracks uint16, written int) []byte {
+		var bf bytes.Buffer
+		wr := smfwriter.New(&bf, smfwriter.Format(format), smfwriter.NumTracks(tracks))
+
+		for i := 0; i < written; i++ {
+			wr.Write(channel.Channel0.NoteOn(60, 100))
+			wr.Write(meta.EndOfTrack)
+		}
+
+		return bf.Bytes()
+	}
+
+	if _, err := toFormat0(t, write(smf.SMF2, 2, 2)); err == nil || err.Error() != "can't convert SMF format 2 to format 0" {
+		t.Errorf("ToFormat0Stream() of SMF2 = %v; want error", err)
+	}
+
+	if _, err := toFormat0(t, write(smf.SMF1, 3, 2)); !errors.Is(err, smfreader.ErrMissing) {
+		t.Errorf("ToFormat0Stream() with missing track = %v; want %v", err, smfreader.ErrMissing)
+	}
+
+	if _, err := toFormat0(t, []byte("MThd")); err == nil {
+		t.Errorf("ToFormat0Stream() of truncated header returned no error")
+	}
+
+	data := write(smf.SMF1, 2, 2)
+
+	if _, err := toFormat0(t, data[:len(data)-2]); err == nil {
+		t.Errorf("ToFormat0Stream() of truncated track returned no error")
+	}
+}
+
+// format0BenchSize is the approximate size of the SMF of BenchmarkToFormat0
+const format0BenchSize = 100 << 20
+
+// writeFormat0Fixture writes a SMF format 1 of about the given size with 16 tracks of notes to the given file
+func writeFormat0Fixture(b *testing.B, file string, size int) {
+	f, err := os.Create(file)
+
+	if err != nil {
+		b.Fatalf("Error: %v", err)
+	}
+
+	defer f.Close()
+
+	const tracks = 16
+	wr := smfwriter.New(f, smfwriter.NumTracks(tracks), smfwriter.Streaming())
+
+	// a note on and a note off with running status take 6 bytes
+	for tr := 0; tr < tracks; tr++ {
+		ch := channel.Channel(tr)
+
+		for i := 0; i < size/tracks/6; i++ {
+			wr.SetDelta(uint32(tr + 1))
+			wr.Write(ch.NoteOn(uint8(i%128), 100))
+			wr.SetDelta(uint32(tr + 1))
+			wr.Write(ch.NoteOff(uint8(i % 128)))
+		}
+
+		wr.Write(meta.EndOfTrack)
+	}
+}
+
+func BenchmarkToFormat0(b *testing.B) {
+	if testing.Short() {
+		b.Skip("skipping the 100 MB fixture in short mode")
+	}
+
+	dir := b.TempDir()
+	fixture := filepath.Join(dir, "fixture.mid")
+	writeFormat0Fixture(b, fixture, format0BenchSize)
+
+	b.Run("Stream", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			in, err := os.Open(fixture)
+
+			if err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+
+			out, err := os.Create(filepath.Join(dir, "stream.mid"))
+
+			if err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+
+			if err := ToFormat0Stream(in, out); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+
+			in.Close()
+			out.Close()
+		}
+	})
+
+	b.Run("InMemory", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			s, err := ReadFile(fixture)
+
+			if err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+
+			var tr Track
+
+			for _, ev := range s.Merged() {
+				tr.events = append(tr.events, ev.Event)
+			}
+
+			res := New(smf.SMF0, s.TimeFormat())
+			res.AddTrack(&tr)
+
+			if err := res.WriteFile(filepath.Join(dir, "memory.mid")); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package smftrack
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// format0SMF returns a SMF0 with a single track of conductor and channel messages
+func format0SMF() *SMF {
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, meta.Track("Song"), meta.Copyright("(c) 2020"), meta.Tempo(500000), meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8})
+	tr.Add(0, ch.ProgramChange(3), ch.NoteOn(60, 100))
+	tr.Add(480, meta.Marker("verse"), ch.NoteOff(60))
+	tr.Add(960, meta.Track("late name"))
+	tr.SetEnd(1920)
+
+	for i := range tr.events {
+		tr.events[i].Tag = uint64(i + 1)
+	}
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+	return s
+}
+
+// headerOf writes the SMF and reads its header back
+func headerOf(t *testing.T, s *SMF, options ...smfwriter.Option) smf.Header {
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf, options...); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+
+	res, err := Read(&bf)
+
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+
+	return res.Header()
+}
+
+func TestAddTrackUpgradesFormat0(t *testing.T) {
+	s := format0SMF()
+	first := s.Track(0)
+
+	var added Track
+	added.Add(0, channel.Channel1.NoteOn(40, 90))
+
+	if err := s.AddTrack(&added); err != nil {
+		t.Fatalf("AddTrack() = %v", err)
+	}
+
+	if got, want := s.Format(), smf.SMF1; got != want {
+		t.Errorf("Format() = %v; want %v", got, want)
+	}
+
+	if got, want := s.NumTracks(), uint16(3); got != want {
+		t.Fatalf("NumTracks() = %v; want %v", got, want)
+	}
+
+	expected := []string{
+		`0 meta.Track: "Song"
+0 meta.Copyright: "(c) 2020"
+0 meta.Tempo BPM: 120.00
+0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+480 meta.Marker: "verse"
+1920 end
+`,
+		`0 channel.ProgramChange channel 1 program 3
+0 channel.NoteOn channel 1 key 60 velocity 100
+480 channel.NoteOff channel 1 key 60
+960 meta.Track: "late name"
+1920 end
+`,
+		`0 channel.NoteOn channel 2 key 40 velocity 90
+0 end
+`,
+	}
+
+	for i, want := range expected {
+		if got := trackString(s.Track(i)); got != want {
+			t.Errorf("track %v:\ngot:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+	}
+
+	// the tracks of the caller stay in the SMF, the first one keeps the other messages
+	if s.Track(1) != first || s.Track(2) != &added {
+		t.Errorf("tracks = %p, %p; want %p, %p", s.Track(1), s.Track(2), first, &added)
+	}
+
+	if got, want := trackString(first), expected[1]; got != want {
+		t.Errorf("first track:\ngot:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	// the events keep their tags
+	if got, want := s.Track(0).Tag(2), uint64(3); got != want {
+		t.Errorf("tag of tempo = %v; want %v", got, want)
+	}
+
+	if got, want := s.Track(1).Tag(1), uint64(6); got != want {
+		t.Errorf("tag of note on = %v; want %v", got, want)
+	}
+
+	for _, options := range [][]smfwriter.Option{nil, {smfwriter.NoRunningStatus()}} {
+		if got, want := headerOf(t, s, options...), (smf.Header{Format: smf.SMF1, NumTracks: 3, TimeFormat: smf.MetricTicks(480)}); got != want {
+			t.Errorf("written header = %v; want %v", got, want)
+		}
+	}
+}
+
+func TestAddTrackToEmptyFormat0(t *testing.T) {
+	s := New(smf.SMF0, smf.MetricTicks(480))
+
+	if err := s.AddTrack(&Track{}); err != nil {
+		t.Fatalf("AddTrack() = %v", err)
+	}
+
+	if got, want := s.Header(), (smf.Header{Format: smf.SMF0, NumTracks: 1, TimeFormat: smf.MetricTicks(480)}); got != want {
+		t.Errorf("Header() = %v; want %v", got, want)
+	}
+}
+
+func TestAddTrackRejectTracks(t *testing.T) {
+	s := format0SMF()
+
+	if err := s.SetTrackPolicy(RejectTracks); err != nil {
+		t.Fatalf("SetTrackPolicy() = %v", err)
+	}
+
+	// the policy is passed on to copies
+	s = Slice(s, 0, 960)
+
+	if err := s.AddTrack(&Track{}); !errors.Is(err, ErrTrackCount) {
+		t.Errorf("AddTrack() = %v; want %v", err, ErrTrackCount)
+	}
+
+	if got, want := s.Header(), (smf.Header{Format: smf.SMF0, NumTracks: 1, TimeFormat: smf.MetricTicks(480)}); got != want {
+		t.Errorf("Header() = %v; want %v", got, want)
+	}
+
+	s.Freeze()
+
+	if err := s.SetTrackPolicy(UpgradeFormat); err != ErrFrozen {
+		t.Errorf("SetTrackPolicy() of frozen SMF = %v; want %v", err, ErrFrozen)
+	}
+}
+
+func TestRemoveTrack(t *testing.T) {
+	s := New(smf.SMF1, smf.MetricTicks(480))
+
+	for _, name := range []string{"conductor", "piano", "bass"} {
+		var tr Track
+		tr.Add(0, meta.Track(name))
+		s.AddTrack(&tr)
+	}
+
+	if err := s.RemoveTrack(1); err != nil {
+		t.Fatalf("RemoveTrack() = %v", err)
+	}
+
+	if got, want := s.Track(1).Name(), "bass"; got != want {
+		t.Errorf("name of track 1 = %q; want %q", got, want)
+	}
+
+	if got, want := headerOf(t, s), (smf.Header{Format: smf.SMF1, NumTracks: 2, TimeFormat: smf.MetricTicks(480)}); got != want {
+		t.Errorf("written header = %v; want %v", got, want)
+	}
+
+	for _, no := range []int{-1, 2} {
+		if err := s.RemoveTrack(no); err == nil {
+			t.Errorf("RemoveTrack(%v) = nil; want error", no)
+		}
+	}
+
+	s.Freeze()
+
+	if err := s.RemoveTrack(0); err != ErrFrozen {
+		t.Errorf("RemoveTrack() of frozen SMF = %v; want %v", err, ErrFrozen)
+	}
+}
+
+func TestWriteFormat0WithTracks(t *testing.T) {
+	// as it may be read with a tolerant policy
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.tracks = []*Track{{}, {}}
+
+	for _, options := range [][]smfwriter.Option{nil, {smfwriter.NoRunningStatus()}} {
+		if err := s.Write(&bytes.Buffer{}, options...); !errors.Is(err, ErrTrackCount) {
+			t.Errorf("Write() = %v; want %v", err, ErrTrackCount)
+		}
+	}
+
+	if _, err := ToFormat1(s); !errors.Is(err, ErrTrackCount) {
+		t.Errorf("ToFormat1() = %v; want %v", err, ErrTrackCount)
+	}
+}
+
+func TestToFormat1(t *testing.T) {
+	s := format0SMF()
+	res, err := ToFormat1(s)
+
+	if err != nil {
+		t.Fatalf("ToFormat1() = %v", err)
+	}
+
+	if got, want := res.Header(), (smf.Header{Format: smf.SMF1, NumTracks: 2, TimeFormat: smf.MetricTicks(480)}); got != want {
+		t.Errorf("Header() = %v; want %v", got, want)
+	}
+
+	// the given SMF is not modified
+	if got, want := s.Header(), (smf.Header{Format: smf.SMF0, NumTracks: 1, TimeFormat: smf.MetricTicks(480)}); got != want {
+		t.Errorf("Header() of given SMF = %v; want %v", got, want)
+	}
+
+	// a SMF1 is just copied
+	again, err := ToFormat1(res)
+
+	if err != nil {
+		t.Fatalf("ToFormat1() of SMF1 = %v", err)
+	}
+
+	for i := range res.tracks {
+		if got, want := trackString(again.Track(i)), trackString(res.Track(i)); got != want {
+			t.Errorf("track %v:\ngot:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+	}
+}
+
+func TestFormat2Conductor(t *testing.T) {
+	s := New(smf.SMF2, smf.MetricTicks(480))
+
+	for i := 0; i < 2; i++ {
+		var tr Track
+		tr.Add(0, meta.Tempo(500000), channel.Channel0.NoteOn(60, 100))
+		tr.Add(480, channel.Channel0.NoteOff(60))
+
+		if err := s.AddTrack(&tr); err != nil {
+			t.Fatalf("AddTrack() = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"ToFormat1", func() error { _, err := ToFormat1(s); return err }},
+		{"ApplyConductor", func() error { _, err := ApplyConductor(s, Conductor(s)); return err }},
+		{"TempoRamp", func() error { _, err := TempoRamp(s, 0, 480, 100, 120, 120, LinearBPM); return err }},
+		{"Retime", func() error { _, err := Retime(s, 100, 0); return err }},
+	}
+
+	for _, test := range tests {
+		if err := test.fn(); !errors.Is(err, ErrNoConductor) {
+			t.Errorf("%s() = %v; want %v", test.name, err, ErrNoConductor)
+		}
+	}
+
+	if got, want := headerOf(t, s), (smf.Header{Format: smf.SMF2, NumTracks: 2, TimeFormat: smf.MetricTicks(480)}); got != want {
+		t.Errorf("written header = %v; want %v", got, want)
+	}
+}
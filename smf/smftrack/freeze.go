@@ -0,0 +1,34 @@
+package smftrack
+
+import (
+	"errors"
+)
+
+// ErrFrozen is returned by the methods that would modify a frozen SMF or Track
+var ErrFrozen = errors.New("SMF is frozen")
+
+// Freeze prevents any further modification of the SMF and its tracks: the modifying methods return ErrFrozen.
+// Freeze must be called before the SMF is shared across goroutines. It can't be undone, but the functions that
+// transform a SMF still work and return unfrozen copies (that share the unchanged raw data of the tracks).
+func (s *SMF) Freeze() {
+	s.frozen = true
+
+	for _, tr := range s.tracks {
+		tr.Freeze()
+	}
+}
+
+// IsFrozen returns true, if the SMF is frozen (see Freeze)
+func (s *SMF) IsFrozen() bool {
+	return s.frozen
+}
+
+// Freeze prevents any further modification of the track: the modifying methods return ErrFrozen (see SMF.Freeze).
+func (t *Track) Freeze() {
+	t.frozen = true
+}
+
+// IsFrozen returns true, if the track is frozen (see Freeze)
+func (t *Track) IsFrozen() bool {
+	return t.frozen
+}
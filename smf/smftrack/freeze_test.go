@@ -0,0 +1,148 @@
+package smftrack
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+func TestFreeze(t *testing.T) {
+	s := patchBase()
+	before := trackString(s.Track(0))
+	s.Freeze()
+
+	tr := s.Track(0)
+	ch := channel.Channel0
+
+	if !s.IsFrozen() || !tr.IsFrozen() {
+		t.Fatalf("IsFrozen() = false; want true")
+	}
+
+	errs := []error{
+		tr.Add(10, ch.NoteOn(70, 100)),
+		tr.SetEvents(nil),
+		tr.SetEnd(100000),
+		tr.SetNotes(tr.Notes()),
+		s.SetNotes(s.Notes()),
+		s.AddTrack(&Track{}),
+	}
+
+	for i, err := range errs {
+		if err != ErrFrozen {
+			t.Errorf("[%v] error = %v; want %v", i, err, ErrFrozen)
+		}
+	}
+
+	if got := trackString(s.Track(0)); got != before {
+		t.Errorf("frozen track has been modified:\n%s", got)
+	}
+
+	if got, want := s.NumTracks(), uint16(1); got != want {
+		t.Errorf("NumTracks() = %v; want %v", got, want)
+	}
+
+	// transformations return unfrozen copies
+	res := Legato(s, 0)
+
+	if res.IsFrozen() || res.Track(0).IsFrozen() {
+		t.Errorf("result of a transformation is frozen")
+	}
+
+	if err := res.Track(0).Add(10, ch.NoteOn(70, 100)); err != nil {
+		t.Errorf("Add() on the copy: %v", err)
+	}
+}
+
+// TestFreezeConcurrent must be run with -race to prove the absence of data races
+func TestFreezeConcurrent(t *testing.T) {
+	var bf bytes.Buffer
+	patchBase().Write(&bf)
+
+	// read with Preserve, so that the copies share the raw data
+	s, err := Read(bytes.NewReader(bf.Bytes()), smfreader.Preserve())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	s.Freeze()
+
+	// the results of a sequential run
+	written := bf.String()
+	notes := len(s.Notes())
+	legato := trackString(Legato(s, 10).Track(0))
+	slice := trackString(Slice(s, 480, 1440).Track(0))
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 100)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				var out bytes.Buffer
+
+				if err := s.Write(&out); err != nil || out.String() != written {
+					errs <- "Write() differs"
+				}
+
+				if len(s.Notes()) != notes {
+					errs <- "Notes() differs"
+				}
+
+				NewIndex(s).StateAt(960)
+				s.TempoMap().Time(960)
+			}
+		}()
+	}
+
+	// one transformer that modifies its copies
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for j := 0; j < 20; j++ {
+			res := Legato(s, 10)
+
+			if trackString(res.Track(0)) != legato {
+				errs <- "Legato() differs"
+			}
+
+			res.Track(0).Add(0, channel.Channel1.NoteOn(70, 100))
+			res.Track(0).SetEnd(10000)
+
+			if trackString(Slice(s, 480, 1440).Track(0)) != slice {
+				errs <- "Slice() differs"
+			}
+
+			var out bytes.Buffer
+			res.Write(&out)
+
+			if resampled, err := Resample(s, 960); err != nil || resampled.TimeFormat() != smf.MetricTicks(960) {
+				errs <- "Resample() failed"
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+
+	for msg := range errs {
+		t.Error(msg)
+	}
+
+	var out bytes.Buffer
+	s.Write(&out)
+
+	if out.String() != written {
+		t.Errorf("shared SMF has been modified")
+	}
+}
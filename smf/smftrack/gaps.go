@@ -0,0 +1,253 @@
+package smftrack
+
+import (
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// Gap is a region of a SMF where no note sounds
+type Gap struct {
+	// From (inclusive) and To (exclusive) are the bounds in ticks
+	From, To uint64
+
+	// Start and End are the bounds as time, based on the TempoMap
+	Start, End time.Duration
+}
+
+// Duration returns the duration of the gap
+func (g Gap) Duration() time.Duration {
+	return g.End - g.Start
+}
+
+// Gaps returns the regions of the given SMF where no note of any track sounds and that last at least
+// minDuration, sorted by their start. The silence before the first note and after the last note up to the end of
+// the longest track is included.
+// This only makes sense for SMF format 0 and 1, since the tracks of format 2 have independent timelines.
+func Gaps(s *SMF, minDuration time.Duration) []Gap {
+	var gaps []Gap
+	var silentFrom uint64
+
+	m := s.TempoMap()
+	end, _ := Length(s)
+
+	add := func(from, to uint64) {
+		if to <= from {
+			return
+		}
+
+		g := Gap{From: from, To: to, Start: m.Time(from), End: m.Time(to)}
+
+		if g.Duration() >= minDuration {
+			gaps = append(gaps, g)
+		}
+	}
+
+	for n := range s.AllNotes() {
+		add(silentFrom, n.AbsTicks)
+
+		if n.End() > silentFrom {
+			silentFrom = n.End()
+		}
+	}
+
+	add(silentFrom, end)
+	return gaps
+}
+
+// SilenceOption is an option for InsertSilence and RemoveRegion
+type SilenceOption func(*silenceConfig)
+
+type silenceConfig struct {
+	split bool
+}
+
+// SplitNotes splits the notes that cross a boundary of the inserted or removed region: they are ended at the
+// boundary and struck again after the inserted silence, respectively after the removed region.
+// By default, these notes are held, i.e. only their note off message is shifted.
+func SplitNotes() SilenceOption {
+	return func(c *silenceConfig) {
+		c.split = true
+	}
+}
+
+// InsertSilence returns a copy of the given SMF with length ticks of silence inserted at the given tick.
+// The given SMF is not modified.
+//
+// All events at and after atTick are shifted to the right, including the conductor events and the end of track
+// of the tracks that don't end before atTick. Note off messages at atTick stay there, if they end a note that
+// starts before. Notes that sound across atTick are held through the silence, see SplitNotes for the alternative.
+// The shifted events keep their tags, the note messages that split notes have none.
+func InsertSilence(s *SMF, atTick, length uint64, options ...SilenceOption) *SMF {
+	var c silenceConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	res := s.clone()
+
+	if length == 0 {
+		return res
+	}
+
+	for _, tr := range res.tracks {
+		tr.insertSilence(atTick, length, c.split)
+	}
+
+	return res
+}
+
+func (t *Track) insertSilence(at, length uint64, split bool) {
+	var (
+		before, restruck, after []Event
+		// the note offs that are not shifted
+		staying = map[int]bool{}
+	)
+
+	for _, n := range t.Notes() {
+		if n.AbsTicks >= at || n.End() < at {
+			continue
+		}
+
+		if n.End() == at {
+			if n.off >= 0 {
+				staying[n.off] = true
+			}
+			continue
+		}
+
+		if split {
+			ch := channel.Channel(n.Channel)
+			before = append(before, Event{AbsTicks: at, Message: ch.NoteOff(n.Key)})
+			restruck = append(restruck, Event{AbsTicks: at + length, Message: ch.NoteOn(n.Key, n.Velocity)})
+		}
+	}
+
+	for i, ev := range t.evs() {
+		if ev.AbsTicks >= at && !staying[i] {
+			after = append(after, Event{AbsTicks: ev.AbsTicks + length, Message: ev.Message, Tag: ev.Tag})
+			continue
+		}
+
+		before = append(before, ev)
+	}
+
+	if t.end >= at {
+		t.end += length
+	}
+
+	t.SetEvents(append(append(before, restruck...), after...))
+}
+
+// RemoveRegion returns a copy of the given SMF without the region from (inclusive) to (exclusive), i.e. the inverse
+// of InsertSilence. The given SMF is not modified.
+//
+// All events at and after to are shifted to the left by the length of the region, including the conductor events
+// and the end of track. The state that is established within the region (tempo, time signature, key, programs,
+// controllers, pitch bend and aftertouch) is reestablished at from, the sequence number, sequence or track name
+// and copyright messages are moved to from. Other events within the region are removed.
+//
+// Notes that start within the region are removed, notes that start before from and end within the region are ended
+// at from. Notes that sound across the whole region are held, see SplitNotes for the alternative.
+// With SplitNotes, the notes that sound across from are ended there and the notes that sound across to are
+// struck again at from.
+//
+// The shifted and moved events keep their tags. The messages that reestablish the state and the note messages
+// that split notes have none.
+func RemoveRegion(s *SMF, from, to uint64, options ...SilenceOption) *SMF {
+	var c silenceConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	res := s.clone()
+
+	if to <= from {
+		return res
+	}
+
+	for _, tr := range res.tracks {
+		tr.removeRegion(from, to, c.split)
+	}
+
+	return res
+}
+
+func (t *Track) removeRegion(from, to uint64, split bool) {
+	var (
+		before, restruck, after []Event
+		length                  = to - from
+		// the state that is established within the region
+		state chaser
+		// the note on and note off events that are removed
+		removed = map[int]bool{}
+		// the note offs that are moved to from
+		ending = map[int]bool{}
+	)
+
+	for _, n := range t.Notes() {
+		switch {
+		case n.AbsTicks >= to || n.End() < from:
+			continue
+		case n.AbsTicks >= from:
+			removed[n.on] = true
+
+			if n.off >= 0 && n.End() <= to {
+				removed[n.off] = true
+				continue
+			}
+
+			if !split {
+				if n.off >= 0 {
+					removed[n.off] = true
+				}
+				continue
+			}
+		case n.End() <= to:
+			if n.off >= 0 {
+				ending[n.off] = true
+			}
+			continue
+		case split:
+			before = append(before, Event{AbsTicks: from, Message: channel.Channel(n.Channel).NoteOff(n.Key)})
+		default:
+			continue
+		}
+
+		// the note sounds across to and is struck again at from
+		restruck = append(restruck, Event{AbsTicks: from, Message: channel.Channel(n.Channel).NoteOn(n.Key, n.Velocity)})
+	}
+
+	var moved []Event
+
+	for i, ev := range t.evs() {
+		switch {
+		case removed[i]:
+		case ending[i]:
+			before = append(before, Event{AbsTicks: from, Message: ev.Message, Tag: ev.Tag})
+		case ev.AbsTicks < from:
+			before = append(before, ev)
+		case ev.AbsTicks >= to:
+			after = append(after, Event{AbsTicks: ev.AbsTicks - length, Message: ev.Message, Tag: ev.Tag})
+		case isHeaderMessage(ev.Message):
+			moved = append(moved, Event{AbsTicks: from, Message: ev.Message, Tag: ev.Tag})
+		default:
+			state.add(ev.Message)
+		}
+	}
+
+	for _, msg := range state.messages() {
+		moved = append(moved, Event{AbsTicks: from, Message: msg})
+	}
+
+	switch {
+	case t.end >= to:
+		t.end -= length
+	case t.end > from:
+		t.end = from
+	}
+
+	t.SetEvents(append(append(append(before, moved...), restruck...), after...))
+}
@@ -0,0 +1,235 @@
+package smftrack
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestGaps(t *testing.T) {
+	var conductor Track
+	conductor.Add(0, meta.BPM(120))
+	conductor.Add(1920, meta.BPM(60))
+
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(480, ch.NoteOn(60, 100))
+	tr.Add(960, ch.NoteOn(64, 100))
+	tr.Add(1200, ch.NoteOff(60))
+	tr.Add(1440, ch.NoteOff(64))
+	tr.Add(1500, ch.NoteOn(67, 100))
+	tr.Add(1560, ch.NoteOff(67))
+	tr.Add(2400, ch.NoteOn(72, 100))
+	tr.Add(2880, ch.NoteOff(72))
+	tr.SetEnd(3840)
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&tr)
+
+	tests := []struct {
+		minDuration time.Duration
+		expected    []Gap
+	}{
+		{0, []Gap{
+			{0, 480, 0, 500 * time.Millisecond},
+			{1440, 1500, 1500 * time.Millisecond, 1562500 * time.Microsecond},
+			{1560, 2400, 1625 * time.Millisecond, 3 * time.Second},
+			{2880, 3840, 4 * time.Second, 6 * time.Second},
+		}},
+		// the gap from 1560 to 2400 crosses the tempo change
+		{time.Second, []Gap{
+			{1560, 2400, 1625 * time.Millisecond, 3 * time.Second},
+			{2880, 3840, 4 * time.Second, 6 * time.Second},
+		}},
+		{10 * time.Second, nil},
+	}
+
+	for i, test := range tests {
+		if got := Gaps(s, test.minDuration); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("[%v] Gaps(%v) = %v; want %v", i, test.minDuration, got, test.expected)
+		}
+	}
+}
+
+// crossingSMF returns a SMF with a tempo change at 960 and two notes: one from 0 to 960 and
+// one from 480 to 1440 that crosses the tempo change
+func crossingSMF() *SMF {
+	var conductor Track
+	conductor.Add(0, meta.BPM(120))
+	conductor.Add(960, meta.BPM(60))
+	conductor.SetEnd(1920)
+
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, ch.NoteOn(60, 100))
+	tr.Add(480, ch.NoteOn(64, 90))
+	tr.Add(960, ch.NoteOff(60), ch.ControlChange(7, 80))
+	tr.Add(1440, ch.NoteOff(64))
+	tr.SetEnd(1920)
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&tr)
+	return s
+}
+
+func TestInsertSilence(t *testing.T) {
+	tests := []struct {
+		at        uint64
+		options   []SilenceOption
+		conductor string
+		track     string
+	}{
+		// the note that crosses 960 is held, the note that ends at 960 is not
+		{960, nil,
+			"0 meta.Tempo BPM: 120.00\n" +
+				"1440 meta.Tempo BPM: 60.00\n" +
+				"2400 end\n",
+			"0 channel.NoteOn channel 1 key 60 velocity 100\n" +
+				"480 channel.NoteOn channel 1 key 64 velocity 90\n" +
+				"960 channel.NoteOff channel 1 key 60\n" +
+				"1440 channel.ControlChange channel 1 controller 7 (\"Volume (MSB)\") value 80\n" +
+				"1920 channel.NoteOff channel 1 key 64\n" +
+				"2400 end\n"},
+		{960, []SilenceOption{SplitNotes()},
+			"0 meta.Tempo BPM: 120.00\n" +
+				"1440 meta.Tempo BPM: 60.00\n" +
+				"2400 end\n",
+			"0 channel.NoteOn channel 1 key 60 velocity 100\n" +
+				"480 channel.NoteOn channel 1 key 64 velocity 90\n" +
+				"960 channel.NoteOff channel 1 key 64\n" +
+				"960 channel.NoteOff channel 1 key 60\n" +
+				"1440 channel.NoteOn channel 1 key 64 velocity 90\n" +
+				"1440 channel.ControlChange channel 1 controller 7 (\"Volume (MSB)\") value 80\n" +
+				"1920 channel.NoteOff channel 1 key 64\n" +
+				"2400 end\n"},
+		// both notes sound at 240
+		{240, []SilenceOption{SplitNotes()},
+			"0 meta.Tempo BPM: 120.00\n" +
+				"1440 meta.Tempo BPM: 60.00\n" +
+				"2400 end\n",
+			"0 channel.NoteOn channel 1 key 60 velocity 100\n" +
+				"240 channel.NoteOff channel 1 key 60\n" +
+				"720 channel.NoteOn channel 1 key 60 velocity 100\n" +
+				"960 channel.NoteOn channel 1 key 64 velocity 90\n" +
+				"1440 channel.NoteOff channel 1 key 60\n" +
+				"1440 channel.ControlChange channel 1 controller 7 (\"Volume (MSB)\") value 80\n" +
+				"1920 channel.NoteOff channel 1 key 64\n" +
+				"2400 end\n"},
+		// at the end of the tracks
+		{1920, nil,
+			"0 meta.Tempo BPM: 120.00\n" +
+				"960 meta.Tempo BPM: 60.00\n" +
+				"2400 end\n",
+			"0 channel.NoteOn channel 1 key 60 velocity 100\n" +
+				"480 channel.NoteOn channel 1 key 64 velocity 90\n" +
+				"960 channel.NoteOff channel 1 key 60\n" +
+				"960 channel.ControlChange channel 1 controller 7 (\"Volume (MSB)\") value 80\n" +
+				"1440 channel.NoteOff channel 1 key 64\n" +
+				"2400 end\n"},
+	}
+
+	for i, test := range tests {
+		s := crossingSMF()
+		res := InsertSilence(s, test.at, 480, test.options...)
+
+		if got := trackString(res.Track(0)); got != test.conductor {
+			t.Errorf("[%v] conductor got:\n%s\n\nwanted:\n%s\n\n", i, got, test.conductor)
+		}
+
+		if got := trackString(res.Track(1)); got != test.track {
+			t.Errorf("[%v] track got:\n%s\n\nwanted:\n%s\n\n", i, got, test.track)
+		}
+
+		if got := trackString(s.Track(1)); got != trackString(crossingSMF().Track(1)) {
+			t.Errorf("[%v] InsertSilence modified the given SMF:\n%s", i, got)
+		}
+	}
+}
+
+func TestRemoveRegion(t *testing.T) {
+	tests := []struct {
+		from, to  uint64
+		options   []SilenceOption
+		conductor string
+		track     string
+	}{
+		// the note from 480 to 1440 is removed, the state of the region is reestablished at 240
+		{240, 720, nil,
+			"0 meta.Tempo BPM: 120.00\n" +
+				"480 meta.Tempo BPM: 60.00\n" +
+				"1440 end\n",
+			"0 channel.NoteOn channel 1 key 60 velocity 100\n" +
+				"480 channel.NoteOff channel 1 key 60\n" +
+				"480 channel.ControlChange channel 1 controller 7 (\"Volume (MSB)\") value 80\n" +
+				"1440 end\n"},
+		// the note that ends within the region is ended at from, the tempo change is chased
+		{720, 1200, nil,
+			"0 meta.Tempo BPM: 120.00\n" +
+				"720 meta.Tempo BPM: 60.00\n" +
+				"1440 end\n",
+			"0 channel.NoteOn channel 1 key 60 velocity 100\n" +
+				"480 channel.NoteOn channel 1 key 64 velocity 90\n" +
+				"720 channel.NoteOff channel 1 key 60\n" +
+				"720 channel.ControlChange channel 1 controller 7 (\"Volume (MSB)\") value 80\n" +
+				"960 channel.NoteOff channel 1 key 64\n" +
+				"1440 end\n"},
+		{720, 1200, []SilenceOption{SplitNotes()},
+			"0 meta.Tempo BPM: 120.00\n" +
+				"720 meta.Tempo BPM: 60.00\n" +
+				"1440 end\n",
+			"0 channel.NoteOn channel 1 key 60 velocity 100\n" +
+				"480 channel.NoteOn channel 1 key 64 velocity 90\n" +
+				"720 channel.NoteOff channel 1 key 64\n" +
+				"720 channel.NoteOff channel 1 key 60\n" +
+				"720 channel.ControlChange channel 1 controller 7 (\"Volume (MSB)\") value 80\n" +
+				"720 channel.NoteOn channel 1 key 64 velocity 90\n" +
+				"960 channel.NoteOff channel 1 key 64\n" +
+				"1440 end\n"},
+		// the note that sounds across the region is split, the tail of the note that starts within the region is kept
+		{240, 720, []SilenceOption{SplitNotes()},
+			"0 meta.Tempo BPM: 120.00\n" +
+				"480 meta.Tempo BPM: 60.00\n" +
+				"1440 end\n",
+			"0 channel.NoteOn channel 1 key 60 velocity 100\n" +
+				"240 channel.NoteOff channel 1 key 60\n" +
+				"240 channel.NoteOn channel 1 key 60 velocity 100\n" +
+				"240 channel.NoteOn channel 1 key 64 velocity 90\n" +
+				"480 channel.NoteOff channel 1 key 60\n" +
+				"480 channel.ControlChange channel 1 controller 7 (\"Volume (MSB)\") value 80\n" +
+				"960 channel.NoteOff channel 1 key 64\n" +
+				"1440 end\n"},
+	}
+
+	for i, test := range tests {
+		s := crossingSMF()
+		res := RemoveRegion(s, test.from, test.to, test.options...)
+
+		if got := trackString(res.Track(0)); got != test.conductor {
+			t.Errorf("[%v] conductor got:\n%s\n\nwanted:\n%s\n\n", i, got, test.conductor)
+		}
+
+		if got := trackString(res.Track(1)); got != test.track {
+			t.Errorf("[%v] track got:\n%s\n\nwanted:\n%s\n\n", i, got, test.track)
+		}
+	}
+}
+
+func TestRemoveInsertedSilence(t *testing.T) {
+	s := crossingSMF()
+
+	for _, at := range []uint64{0, 240, 960, 1920} {
+		res := RemoveRegion(InsertSilence(s, at, 480), at, at+480)
+
+		for i := range s.tracks {
+			if got, want := trackString(res.Track(i)), trackString(s.Track(i)); got != want {
+				t.Errorf("[%v] track %v got:\n%s\n\nwanted:\n%s\n\n", at, i, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package smftrack
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// HandSplitter splits the given notes of a piano part into the notes of the left and the right hand, e.g.
+// analysis.HandSplitter. It lets ApplyHandSplit use the heuristics of the analysis package.
+type HandSplitter func(notes []Note) (left, right []Note)
+
+// ApplyHandSplit returns a copy of the given SMF, where the given track is replaced by two tracks named "RH" and
+// "LH" (in this order), e.g. for the two staves of a piano part. The notes that the given HandSplitter returns as
+// notes of the left hand are moved to the LH track, all other events stay in the RH track, including the
+// controllers like the sustain pedal. The track names of the track are replaced and the events keep their tags.
+// The tracks after the given track move back by one. A SMF of format 0 becomes a SMF of format 1.
+// The given SMF is not modified.
+func ApplyHandSplit(s *SMF, track int, split HandSplitter) (*SMF, error) {
+	if track < 0 || track >= len(s.tracks) {
+		return nil, fmt.Errorf("track %v out of range [0,%v)", track, len(s.tracks))
+	}
+
+	if s.format == smf.SMF2 {
+		return nil, fmt.Errorf("can't split the hands of a track of SMF format 2")
+	}
+
+	src := s.tracks[track]
+	left, _ := split(src.Notes())
+
+	// the indices of the note on and off events of the left hand
+	var isLeft = map[int]bool{}
+
+	for _, n := range left {
+		isLeft[n.on] = true
+
+		if n.off >= 0 {
+			isLeft[n.off] = true
+		}
+	}
+
+	var rh, lh Track
+	rh.events = []Event{{Message: meta.Track("RH")}}
+	lh.events = []Event{{Message: meta.Track("LH")}}
+
+	for i, ev := range src.evs() {
+		if _, isName := ev.Message.(meta.Track); isName {
+			continue
+		}
+
+		if isLeft[i] {
+			lh.events = append(lh.evs(), ev)
+		} else {
+			rh.events = append(rh.evs(), ev)
+		}
+	}
+
+	rh.end, lh.end = src.End(), src.End()
+
+	res := s.clone()
+	res.format = smf.SMF1
+	res.tracks = append(res.tracks[:track], append([]*Track{&rh, &lh}, res.tracks[track+1:]...)...)
+	return res, nil
+}
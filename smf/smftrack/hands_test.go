@@ -0,0 +1,93 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// splitAt returns a HandSplitter that splits at the given key
+func splitAt(key uint8) HandSplitter {
+	return func(notes []Note) (left, right []Note) {
+		for _, n := range notes {
+			if n.Key < key {
+				left = append(left, n)
+			} else {
+				right = append(right, n)
+			}
+		}
+		return
+	}
+}
+
+func TestApplyHandSplit(t *testing.T) {
+	var conductor, piano, strings Track
+	ch := channel.Channel0
+	conductor.Add(0, meta.BPM(120))
+	piano.Add(0, meta.Track("piano"), ch.ControlChange(64, 127), ch.NoteOn(48, 100), ch.NoteOn(64, 100))
+	piano.Add(480, ch.NoteOff(48), ch.NoteOff(64))
+	piano.Add(960, ch.ControlChange(64, 0))
+	piano.SetTag(2, 7)
+	strings.Add(0, meta.Track("strings"))
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&piano)
+	s.AddTrack(&strings)
+
+	res, err := ApplyHandSplit(s, 1, splitAt(60))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := []string{
+		"0 meta.Tempo BPM: 120.00\n0 end\n",
+		`0 meta.Track: "RH"
+0 channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 127 (on)
+0 channel.NoteOn channel 1 key 64 velocity 100
+480 channel.NoteOff channel 1 key 64
+960 channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 0 (off)
+960 end
+`,
+		`0 meta.Track: "LH"
+0 channel.NoteOn channel 1 key 48 velocity 100
+480 channel.NoteOff channel 1 key 48
+960 end
+`,
+		"0 meta.Track: \"strings\"\n0 end\n",
+	}
+
+	if got, want := int(res.NumTracks()), len(expected); got != want {
+		t.Fatalf("ApplyHandSplit() returned %v tracks; want %v", got, want)
+	}
+
+	for i, want := range expected {
+		if got := trackString(res.Track(i)); got != want {
+			t.Errorf("track %v:\ngot:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+	}
+
+	if got, want := res.Track(2).Tag(1), uint64(7); got != want {
+		t.Errorf("tag of note on in LH = %v; want %v", got, want)
+	}
+
+	if got, want := s.NumTracks(), uint16(3); got != want {
+		t.Errorf("ApplyHandSplit() modified the given SMF: %v tracks; want %v", got, want)
+	}
+
+	// format 0 becomes format 1
+	s0 := New(smf.SMF0, smf.MetricTicks(480))
+	s0.AddTrack(&piano)
+	res, _ = ApplyHandSplit(s0, 0, splitAt(60))
+
+	if got, want := res.Format(), smf.SMF1; got != want || res.NumTracks() != 2 {
+		t.Errorf("ApplyHandSplit() of format 0 = format %v with %v tracks; want format %v with 2 tracks", got, res.NumTracks(), want)
+	}
+
+	if _, err := ApplyHandSplit(s, 3, splitAt(60)); err == nil {
+		t.Errorf("ApplyHandSplit() of track 3 of 3 tracks returned no error")
+	}
+}
@@ -0,0 +1,131 @@
+package smftrack
+
+import (
+	"math"
+	"math/rand"
+)
+
+type humanizeConfig struct {
+	gaussian bool
+}
+
+// HumanizeOption is an option for Humanize
+type HumanizeOption func(*humanizeConfig)
+
+// HumanizeGaussian uses a gaussian distribution for the random amounts instead of a uniform one.
+// The standard deviation is half of the maximal amount and the amounts are limited to the maximal amount.
+func HumanizeGaussian() HumanizeOption {
+	return func(c *humanizeConfig) {
+		c.gaussian = true
+	}
+}
+
+// Humanize returns a copy of the given SMF where the notes are moved randomly by up to timing ticks in both directions
+// and their velocities are changed randomly by up to velocity in both directions. The duration of the notes is kept.
+// The given SMF is not modified.
+//
+// Notes are never moved before tick 0 or across the neighboring notes on the same key (and track and channel).
+// Velocities are kept between 1 and 127.
+// The same seed always produces the same result for the same SMF.
+func Humanize(s *SMF, timing uint64, velocity uint8, seed int64, options ...HumanizeOption) *SMF {
+	var c humanizeConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		notes := tr.Notes()
+
+		// indices of the notes by channel and key
+		var byKey = map[[2]uint8][]int{}
+
+		for i, n := range notes {
+			k := [2]uint8{n.Channel, n.Key}
+			byKey[k] = append(byKey[k], i)
+		}
+
+		// position of the note within byKey
+		var keyPos = make([]int, len(notes))
+
+		for _, idx := range byKey {
+			for pos, i := range idx {
+				keyPos[i] = pos
+			}
+		}
+
+		for i := range notes {
+			n := &notes[i]
+
+			// always draw both amounts, so that the sequence of random numbers does not depend on the constraints
+			offset := c.random(rnd, int64(timing))
+			vel := int64(n.Velocity) + c.random(rnd, int64(velocity))
+
+			if vel < 1 {
+				vel = 1
+			}
+
+			if vel > 127 {
+				vel = 127
+			}
+
+			n.Velocity = uint8(vel)
+
+			// the allowed range of the start
+			lo, hi := int64(0), int64(math.MaxInt64)
+			idx, pos := byKey[[2]uint8{n.Channel, n.Key}], keyPos[i]
+
+			if pos > 0 {
+				lo = int64(notes[idx[pos-1]].End())
+			}
+
+			if pos+1 < len(idx) {
+				hi = int64(notes[idx[pos+1]].AbsTicks) - int64(n.Duration)
+			}
+
+			start := int64(n.AbsTicks) + offset
+
+			if lo > int64(n.AbsTicks) || hi < int64(n.AbsTicks) {
+				// the note already overlaps a neighbor: don't move it
+				start = int64(n.AbsTicks)
+			} else if start < lo {
+				start = lo
+			} else if start > hi {
+				start = hi
+			}
+
+			n.AbsTicks = uint64(start)
+		}
+
+		// can't fail, since the notes are from the track
+		tr.SetNotes(notes)
+	}
+
+	return res
+}
+
+// random returns a random amount between -max and max
+func (c humanizeConfig) random(rnd *rand.Rand, max int64) int64 {
+	if max <= 0 {
+		return 0
+	}
+
+	if !c.gaussian {
+		return rnd.Int63n(2*max+1) - max
+	}
+
+	v := int64(math.Round(rnd.NormFloat64() * float64(max) / 2))
+
+	if v < -max {
+		return -max
+	}
+
+	if v > max {
+		return max
+	}
+
+	return v
+}
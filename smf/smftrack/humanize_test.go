@@ -0,0 +1,110 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+func humanizeSource() *SMF {
+	var tr Track
+	ch := channel.Channel0
+
+	// notes on different keys every 96 ticks
+	for i := uint64(0); i < 500; i++ {
+		tr.Add(i*96, ch.NoteOn(uint8(40+i%40), uint8(1+i%127)))
+		tr.Add(i*96+48, ch.NoteOff(uint8(40+i%40)))
+	}
+
+	// repeated notes on the same key that are only 2 ticks apart
+	for i := uint64(0); i < 50; i++ {
+		tr.Add(100000+i*10, ch.NoteOn(90, 100))
+		tr.Add(100000+i*10+8, ch.NoteOff(90))
+	}
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+	return s
+}
+
+func TestHumanizeBounds(t *testing.T) {
+	for _, options := range [][]HumanizeOption{nil, {HumanizeGaussian()}} {
+		src := humanizeSource()
+		res := Humanize(src, 20, 10, 42, options...)
+
+		orig, notes := src.Notes(), res.Notes()
+
+		if len(orig) != len(notes) {
+			t.Fatalf("number of notes = %v; want %v", len(notes), len(orig))
+		}
+
+		var sum int64
+		var byKey = map[uint8][]Note{}
+
+		for i, n := range notes {
+			o := orig[i]
+
+			if n.Duration != o.Duration {
+				t.Errorf("duration of note %v changed: %v; want %v", i, n.Duration, o.Duration)
+			}
+
+			d := int64(n.AbsTicks) - int64(o.AbsTicks)
+			sum += d
+
+			if d < -20 || d > 20 {
+				t.Errorf("note %v moved by %v; want at most 20", i, d)
+			}
+
+			if v := int(n.Velocity) - int(o.Velocity); n.Velocity < 1 || n.Velocity > 127 || v < -10 || v > 10 {
+				t.Errorf("velocity of note %v changed from %v to %v", i, o.Velocity, n.Velocity)
+			}
+
+			byKey[n.Key] = append(byKey[n.Key], n)
+		}
+
+		// the mean offset should be close to 0
+		if mean := float64(sum) / float64(len(notes)); mean < -3 || mean > 3 {
+			t.Errorf("mean offset = %v; want close to 0", mean)
+		}
+
+		for key, ns := range byKey {
+			for i := 1; i < len(ns); i++ {
+				if ns[i].AbsTicks < ns[i-1].End() {
+					t.Errorf("notes on key %v overlap at %v", key, ns[i].AbsTicks)
+				}
+			}
+		}
+	}
+}
+
+func TestHumanizeDeterminism(t *testing.T) {
+	a := notesString(Humanize(humanizeSource(), 20, 10, 7))
+	b := notesString(Humanize(humanizeSource(), 20, 10, 7))
+	c := notesString(Humanize(humanizeSource(), 20, 10, 8))
+
+	if a != b {
+		t.Errorf("different results for the same seed")
+	}
+
+	if a == c {
+		t.Errorf("same results for different seeds")
+	}
+}
+
+func TestHumanizeStart(t *testing.T) {
+	var tr Track
+	tr.Add(0, channel.Channel0.NoteOn(60, 1))
+	tr.Add(10, channel.Channel0.NoteOff(60))
+
+	s := New(smf.SMF0, nil)
+	s.AddTrack(&tr)
+
+	for seed := int64(0); seed < 20; seed++ {
+		n := Humanize(s, 100, 50, seed).Notes()[0]
+
+		if n.AbsTicks > 100 || n.Velocity < 1 || n.Velocity > 51 {
+			t.Errorf("seed %v: note at %v with velocity %v", seed, n.AbsTicks, n.Velocity)
+		}
+	}
+}
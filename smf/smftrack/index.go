@@ -0,0 +1,272 @@
+package smftrack
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// ChannelState is the state of a MIDI channel at a certain tick
+type ChannelState struct {
+	// Program is the program of the last program change message, -1 if there is none
+	Program int8
+
+	// Controllers are the values of the last control change messages by controller, -1 if there is none
+	Controllers [128]int8
+
+	// Pitchbend is the value of the last pitch bend message, 0 if there is none
+	Pitchbend int16
+
+	// Aftertouch is the pressure of the last aftertouch message, -1 if there is none
+	Aftertouch int8
+}
+
+// State is the state of a SMF at a certain tick (see Index.StateAt)
+type State struct {
+	// Tempo is the tempo, 120 BPM until the first tempo message
+	Tempo meta.Tempo
+
+	// TimeSig is the time signature, 4/4 until the first time signature message
+	TimeSig meta.TimeSig
+
+	// Key is the key signature, nil until the first key signature message
+	Key *meta.Key
+
+	Channels [16]ChannelState
+
+	// Notes are the sounding notes
+	Notes channel.NoteTracker
+}
+
+// newState returns the state at the start of a SMF
+func newState() State {
+	st := State{
+		Tempo:   meta.BPM(120),
+		TimeSig: meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8},
+	}
+
+	for ch := range st.Channels {
+		c := &st.Channels[ch]
+		c.Program = -1
+		c.Aftertouch = -1
+
+		for i := range c.Controllers {
+			c.Controllers[i] = -1
+		}
+	}
+
+	return st
+}
+
+// ActiveNotes returns the sounding keys of the given channel in ascending order
+func (st *State) ActiveNotes(ch uint8) []uint8 {
+	return st.Notes.Active(ch)
+}
+
+// Messages returns the channel messages that reestablish the state of the channels: for each channel the bank select,
+// the program, the other controllers, the pitch bend (if not 0) and the aftertouch.
+// Data entry, (N)RPN selection and channel mode messages are skipped.
+func (st *State) Messages() (msgs []midi.Message) {
+	for no := range st.Channels {
+		c, ch := &st.Channels[no], channel.Channel(no)
+
+		for _, cc := range []uint8{0, 32} {
+			if c.Controllers[cc] >= 0 {
+				msgs = append(msgs, ch.ControlChange(cc, uint8(c.Controllers[cc])))
+			}
+		}
+
+		if c.Program >= 0 {
+			msgs = append(msgs, ch.ProgramChange(uint8(c.Program)))
+		}
+
+		for cc, v := range c.Controllers {
+			if v >= 0 && cc != 0 && cc != 32 && isStateController(uint8(cc)) {
+				msgs = append(msgs, ch.ControlChange(uint8(cc), uint8(v)))
+			}
+		}
+
+		if c.Pitchbend != 0 {
+			msgs = append(msgs, ch.Pitchbend(c.Pitchbend))
+		}
+
+		if c.Aftertouch >= 0 {
+			msgs = append(msgs, ch.Aftertouch(uint8(c.Aftertouch)))
+		}
+	}
+
+	return
+}
+
+// apply changes the state according to the given message
+func (st *State) apply(msg midi.Message) {
+	switch v := msg.(type) {
+	case meta.Tempo:
+		st.Tempo = v
+	case meta.TimeSig:
+		st.TimeSig = v
+	case meta.Key:
+		st.Key = &v
+	case channel.Message:
+		ch := v.Channel()
+
+		if ch > 15 {
+			return
+		}
+
+		st.Notes.Track(v)
+
+		switch cm := v.(type) {
+		case channel.ProgramChange:
+			st.Channels[ch].Program = int8(cm.Program())
+		case channel.ControlChange:
+			if cm.Controller() < 128 {
+				st.Channels[ch].Controllers[cm.Controller()] = int8(cm.Value())
+			}
+		case channel.Pitchbend:
+			st.Channels[ch].Pitchbend = cm.Value()
+		case channel.Aftertouch:
+			st.Channels[ch].Aftertouch = int8(cm.Pressure())
+		}
+	}
+}
+
+// StateAt returns the state of the given SMF after all events at or before the given tick.
+// It applies all events from the start, use an Index for repeated queries.
+func StateAt(s *SMF, absTicks uint64) State {
+	st := newState()
+
+	for ev := range s.All() {
+		if ev.AbsTicks > absTicks {
+			break
+		}
+
+		st.apply(ev.Message)
+	}
+
+	return st
+}
+
+// IndexOption is an option for NewIndex
+type IndexOption func(*Index)
+
+// SnapshotInterval sets the minimal distance in ticks between the snapshots of the state.
+// Smaller intervals make StateAt faster, but need more memory (about 4KB per snapshot).
+// Default is 4 quarter notes.
+func SnapshotInterval(ticks uint64) IndexOption {
+	return func(idx *Index) {
+		idx.interval = ticks
+	}
+}
+
+// Index is a seekable index of the merged events of a SMF (see Merged).
+// It is built once and allows fast queries of the events and the state at a certain tick.
+// The Index is not updated, if the SMF is modified.
+// It can be stored next to the SMF with FreezeIndex and restored with Thaw.
+type Index struct {
+	events    []TrackEvent
+	interval  uint64
+	snapshots []snapshot
+}
+
+// snapshot is the state before the event with the index idx, which is the first event at or after absTicks
+type snapshot struct {
+	absTicks uint64
+	idx      int
+	state    State
+}
+
+// NewIndex returns the Index of the given SMF.
+// Since the tracks of SMF2 are independent, the Index only makes sense for SMF format 0 and 1.
+func NewIndex(s *SMF, options ...IndexOption) *Index {
+	idx := &Index{events: s.Merged(), interval: uint64(smf.MetricTicks(0).Ticks4th()) * 4}
+
+	if mt, ok := s.timeFormat.(smf.MetricTicks); ok {
+		idx.interval = uint64(mt.Ticks4th()) * 4
+	}
+
+	for _, opt := range options {
+		opt(idx)
+	}
+
+	if idx.interval == 0 {
+		idx.interval = 1
+	}
+
+	st := newState()
+	idx.snapshots = []snapshot{{state: st}}
+	next := idx.interval
+
+	for i, ev := range idx.events {
+		if ev.AbsTicks >= next {
+			idx.snapshots = append(idx.snapshots, snapshot{absTicks: ev.AbsTicks, idx: i, state: st})
+			next = (ev.AbsTicks/idx.interval + 1) * idx.interval
+		}
+
+		st.apply(ev.Message)
+	}
+
+	return idx
+}
+
+// Len returns the number of events
+func (idx *Index) Len() int {
+	return len(idx.events)
+}
+
+// Event returns the event with the given index
+func (idx *Index) Event(i int) TrackEvent {
+	return idx.events[i]
+}
+
+// Search returns the index of the first event at or after the given tick. If there is none, Len is returned.
+func (idx *Index) Search(absTicks uint64) int {
+	return sort.Search(len(idx.events), func(i int) bool {
+		return idx.events[i].AbsTicks >= absTicks
+	})
+}
+
+// EventsBetween returns the events from (inclusive) to (exclusive)
+func (idx *Index) EventsBetween(from, to uint64) []TrackEvent {
+	if to <= from {
+		return nil
+	}
+
+	a, b := idx.Search(from), idx.Search(to)
+	res := make([]TrackEvent, b-a)
+	copy(res, idx.events[a:b])
+	return res
+}
+
+// Next returns the first event after the given tick. ok is false, if there is none.
+func (idx *Index) Next(absTicks uint64) (ev TrackEvent, ok bool) {
+	i := sort.Search(len(idx.events), func(i int) bool {
+		return idx.events[i].AbsTicks > absTicks
+	})
+
+	if i == len(idx.events) {
+		return ev, false
+	}
+
+	return idx.events[i], true
+}
+
+// StateAt returns the state after all events at or before the given tick.
+// It starts with the nearest snapshot before the tick, so that at most the events of one snapshot interval are applied.
+func (idx *Index) StateAt(absTicks uint64) State {
+	s := sort.Search(len(idx.snapshots), func(i int) bool {
+		return idx.snapshots[i].absTicks > absTicks
+	}) - 1
+
+	snap := &idx.snapshots[s]
+	st := snap.state
+
+	for i := snap.idx; i < len(idx.events) && idx.events[i].AbsTicks <= absTicks; i++ {
+		st.apply(idx.events[i].Message)
+	}
+
+	return st
+}
@@ -0,0 +1,176 @@
+package smftrack
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// indexSMF returns a SMF of format 1 with state changes in both tracks
+func indexSMF() *SMF {
+	var conductor, tr Track
+	conductor.Add(0, meta.BPM(100))
+	conductor.Add(960, meta.TimeSig{Numerator: 3, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8})
+	conductor.Add(1920, meta.BPM(140), meta.Key{Key: 7, IsMajor: true, Num: 1})
+
+	ch := channel.Channel2
+	tr.Add(0, ch.ProgramChange(5), ch.ControlChange(7, 100))
+	tr.Add(480, ch.NoteOn(60, 100), ch.NoteOn(64, 100))
+	tr.Add(960, ch.NoteOff(60), ch.Pitchbend(1000))
+	tr.Add(1440, ch.NoteOff(64), ch.ControlChange(7, 80), ch.Aftertouch(30))
+	tr.Add(2400, ch.NoteOn(67, 90))
+	tr.SetEnd(3840)
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&tr)
+	return s
+}
+
+// chaseState returns the state at the given tick by applying all events up to it
+func chaseState(s *SMF, absTicks uint64) State {
+	st := newState()
+
+	for _, ev := range s.Merged() {
+		if ev.AbsTicks > absTicks {
+			break
+		}
+		st.apply(ev.Message)
+	}
+
+	return st
+}
+
+func TestIndexStateAt(t *testing.T) {
+	s := indexSMF()
+
+	for _, interval := range []uint64{1, 100, 480, 1920, 100000} {
+		idx := NewIndex(s, SnapshotInterval(interval))
+
+		for tick := uint64(0); tick < 4000; tick += 60 {
+			if got, want := idx.StateAt(tick), chaseState(s, tick); !reflect.DeepEqual(got, want) {
+				t.Errorf("[%v] StateAt(%v) = %+v; want %+v", interval, tick, got, want)
+			}
+		}
+	}
+
+	st := NewIndex(s).StateAt(1440)
+	c := st.Channels[2]
+
+	if got, want := st.Tempo.BPM(), uint32(100); got != want {
+		t.Errorf("Tempo = %v; want %v", got, want)
+	}
+
+	if got, want := st.TimeSig.Signature(), "3/4"; got != want {
+		t.Errorf("TimeSig = %v; want %v", got, want)
+	}
+
+	if st.Key != nil {
+		t.Errorf("Key = %v; want nil", st.Key)
+	}
+
+	if c.Program != 5 || c.Controllers[7] != 80 || c.Controllers[10] != -1 || c.Pitchbend != 1000 || c.Aftertouch != 30 {
+		t.Errorf("Channels[2] = program %v volume %v pan %v pitchbend %v aftertouch %v; want 5 80 -1 1000 30",
+			c.Program, c.Controllers[7], c.Controllers[10], c.Pitchbend, c.Aftertouch)
+	}
+
+	if got := st.ActiveNotes(2); len(got) != 0 {
+		t.Errorf("ActiveNotes(2) = %v; want none", got)
+	}
+
+	st = NewIndex(s).StateAt(1439)
+
+	if got, want := st.ActiveNotes(2), []uint8{64}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ActiveNotes(2) = %v; want %v", got, want)
+	}
+
+	if st := NewIndex(s).StateAt(3000); st.Key == nil || st.Key.Key != 7 {
+		t.Errorf("Key = %v; want G major", st.Key)
+	}
+}
+
+func TestIndexEvents(t *testing.T) {
+	idx := NewIndex(indexSMF())
+
+	if got, want := idx.Len(), 14; got != want {
+		t.Errorf("Len() = %v; want %v", got, want)
+	}
+
+	var got []string
+
+	for _, ev := range idx.EventsBetween(960, 1920) {
+		got = append(got, ev.Message.String())
+	}
+
+	expected := []string{
+		"meta.TimeSig 3/4 clocksperclick 24 dsqpq 8",
+		"channel.NoteOff channel 3 key 60",
+		"channel.Pitchbend channel 3 value 1000 absValue 0",
+		"channel.NoteOff channel 3 key 64",
+		`channel.ControlChange channel 3 controller 7 ("Volume (MSB)") value 80`,
+		"channel.Aftertouch channel 3 pressure 30",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("EventsBetween() = %v; want %v", got, expected)
+	}
+
+	if ev, ok := idx.Next(1920); !ok || ev.AbsTicks != 2400 || ev.Track != 1 {
+		t.Errorf("Next(1920) = %v, %v; want note on at 2400", ev, ok)
+	}
+
+	if _, ok := idx.Next(2400); ok {
+		t.Errorf("Next(2400) should not find an event")
+	}
+
+	if got, want := idx.Search(961), 8; got != want {
+		t.Errorf("Search(961) = %v; want %v", got, want)
+	}
+
+	if got := idx.EventsBetween(1920, 960); got != nil {
+		t.Errorf("EventsBetween() = %v; want nil", got)
+	}
+}
+
+// BenchmarkIndexStateAt measures StateAt for random ticks of a file with 1M events
+func BenchmarkIndexStateAt(b *testing.B) {
+	var tr Track
+	r := rand.New(rand.NewSource(1))
+	evts := make([]Event, 0, 1000000)
+
+	for i := 0; len(evts) < 1000000; i++ {
+		tick := uint64(i) * 10
+		ch := channel.Channel(r.Intn(16))
+
+		switch i % 4 {
+		case 0:
+			evts = append(evts, Event{AbsTicks: tick, Message: ch.NoteOn(uint8(r.Intn(128)), 100)})
+		case 1:
+			evts = append(evts, Event{AbsTicks: tick, Message: ch.NoteOff(uint8(r.Intn(128)))})
+		case 2:
+			evts = append(evts, Event{AbsTicks: tick, Message: ch.ControlChange(uint8(r.Intn(128)), uint8(r.Intn(128)))})
+		case 3:
+			evts = append(evts, Event{AbsTicks: tick, Message: ch.Pitchbend(int16(r.Intn(8192)))})
+		}
+	}
+
+	tr.SetEvents(evts)
+	s := New(smf.SMF0, smf.MetricTicks(960))
+	s.AddTrack(&tr)
+
+	for _, interval := range []uint64{960, 3840, 15360} {
+		b.Run(fmt.Sprintf("interval%v", interval), func(b *testing.B) {
+			idx := NewIndex(s, SnapshotInterval(interval))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				idx.StateAt(uint64(r.Int63n(10000000)))
+			}
+		})
+	}
+}
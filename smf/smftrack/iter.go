@@ -0,0 +1,165 @@
+package smftrack
+
+import (
+	"iter"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// All returns an iterator over the messages of the track together with their absolute ticks,
+// without copying the events (see Events).
+func (t *Track) All() iter.Seq2[uint64, midi.Message] {
+	return func(yield func(uint64, midi.Message) bool) {
+		for i := 0; i < len(t.evs()); i++ {
+			if !yield(t.evs()[i].AbsTicks, t.evs()[i].Message) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the events of all tracks in the order of Merged, without collecting them.
+func (s *SMF) All() iter.Seq[TrackEvent] {
+	seqs := make([]iter.Seq[TrackEvent], len(s.tracks))
+
+	for no, tr := range s.tracks {
+		seqs[no] = func(yield func(TrackEvent) bool) {
+			for _, ev := range tr.evs() {
+				if !yield(TrackEvent{Track: no, Event: ev}) {
+					return
+				}
+			}
+		}
+	}
+
+	return merge(seqs, func(ev TrackEvent) uint64 { return ev.AbsTicks })
+}
+
+// AllNotes returns an iterator over the notes of the track in the order of Notes.
+// The notes are paired while iterating: a note is yielded as soon as it and all notes that start before it
+// have been ended.
+func (t *Track) AllNotes() iter.Seq[Note] {
+	return func(yield func(Note) bool) {
+		// pending are the notes that have not been yielded yet, pending[0] has the number first
+		var pending []Note
+		var first int
+
+		// sounding are the numbers of the notes that have not been ended yet, by channel and key
+		var sounding = map[[2]uint8][]int{}
+
+		end := func(ch, key, velocity uint8, idx int) {
+			k := [2]uint8{ch, key}
+			open := sounding[k]
+			if len(open) == 0 {
+				return
+			}
+			n := &pending[open[0]-first]
+			n.off = idx
+			n.ReleaseVelocity = velocity
+			n.Duration = t.evs()[idx].AbsTicks - n.AbsTicks
+			sounding[k] = open[1:]
+		}
+
+		for i, ev := range t.evs() {
+			switch v := ev.Message.(type) {
+			case channel.NoteOn:
+				if v.Velocity() == 0 {
+					end(v.Channel(), v.Key(), 0, i)
+					break
+				}
+				k := [2]uint8{v.Channel(), v.Key()}
+				sounding[k] = append(sounding[k], first+len(pending))
+				pending = append(pending, Note{Channel: v.Channel(), Key: v.Key(), Velocity: v.Velocity(), AbsTicks: ev.AbsTicks, on: i, off: -1})
+				continue
+			case channel.NoteOff:
+				end(v.Channel(), v.Key(), 0, i)
+			case channel.NoteOffVelocity:
+				end(v.Channel(), v.Key(), v.Velocity(), i)
+			default:
+				continue
+			}
+
+			for len(pending) > 0 && pending[0].off >= 0 {
+				if !yield(pending[0]) {
+					return
+				}
+				pending = pending[1:]
+				first++
+			}
+		}
+
+		// the remaining notes last until the end of the track
+		for _, n := range pending {
+			if n.off < 0 {
+				n.Duration = t.end - n.AbsTicks
+			}
+
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// AllNotes returns an iterator over the notes of all tracks in the order of Notes, without collecting them.
+func (s *SMF) AllNotes() iter.Seq[Note] {
+	seqs := make([]iter.Seq[Note], len(s.tracks))
+
+	for no, tr := range s.tracks {
+		seqs[no] = func(yield func(Note) bool) {
+			for n := range tr.AllNotes() {
+				n.Track = no
+				if !yield(n) {
+					return
+				}
+			}
+		}
+	}
+
+	return merge(seqs, func(n Note) uint64 { return n.AbsTicks })
+}
+
+// merge returns an iterator that merges the given iterators, which are sorted by the given ticks.
+// Values with the same ticks are yielded in the order of the iterators.
+func merge[T any](seqs []iter.Seq[T], ticks func(T) uint64) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		type head struct {
+			value T
+			next  func() (T, bool)
+		}
+
+		var heads []*head
+
+		for _, seq := range seqs {
+			next, stop := iter.Pull(seq)
+			defer stop()
+
+			if v, ok := next(); ok {
+				heads = append(heads, &head{value: v, next: next})
+			}
+		}
+
+		for len(heads) > 0 {
+			min := 0
+
+			for i, h := range heads {
+				if ticks(h.value) < ticks(heads[min].value) {
+					min = i
+				}
+			}
+
+			h := heads[min]
+
+			if !yield(h.value) {
+				return
+			}
+
+			var ok bool
+
+			if h.value, ok = h.next(); !ok {
+				heads = append(heads[:min], heads[min+1:]...)
+			}
+		}
+	}
+}
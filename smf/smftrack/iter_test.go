@@ -0,0 +1,147 @@
+package smftrack
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func iterSMF() *SMF {
+	var tr0, tr1 Track
+	tr0.Add(0, meta.BPM(120), channel.Channel0.NoteOn(60, 100))
+	tr0.Add(480, channel.Channel0.NoteOn(64, 90))
+	tr0.Add(960, channel.Channel0.NoteOff(60), channel.Channel0.NoteOff(64))
+	tr0.Add(1440, channel.Channel0.NoteOn(67, 80))
+
+	tr1.Add(0, channel.Channel1.NoteOn(36, 100))
+	tr1.Add(480, channel.Channel1.NoteOff(36), channel.Channel1.NoteOn(36, 70))
+	tr1.Add(960, channel.Channel1.NoteOff(36))
+	tr1.SetEnd(1920)
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&tr0)
+	s.AddTrack(&tr1)
+	return s
+}
+
+func TestIterators(t *testing.T) {
+	s := iterSMF()
+
+	var merged []TrackEvent
+
+	for ev := range s.All() {
+		merged = append(merged, ev)
+	}
+
+	var expected []TrackEvent
+
+	for no, tr := range s.tracks {
+		for _, ev := range tr.events {
+			expected = append(expected, TrackEvent{Track: no, Event: ev})
+		}
+	}
+
+	// stable sort by ticks
+	for i := 1; i < len(expected); i++ {
+		for j := i; j > 0 && expected[j].AbsTicks < expected[j-1].AbsTicks; j-- {
+			expected[j], expected[j-1] = expected[j-1], expected[j]
+		}
+	}
+
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("All() = %v; want %v", merged, expected)
+	}
+
+	var got []string
+
+	for n := range s.AllNotes() {
+		got = append(got, fmt.Sprintf("%v:%v@%v+%v", n.Track, n.Key, n.AbsTicks, n.Duration))
+	}
+
+	want := []string{"0:60@0+960", "1:36@0+480", "0:64@480+480", "1:36@480+480", "0:67@1440+0"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AllNotes() = %v; want %v", got, want)
+	}
+
+	var events []Event
+
+	for abs, msg := range s.Track(1).All() {
+		events = append(events, Event{AbsTicks: abs, Message: msg})
+	}
+
+	if want := s.Track(1).Events(); !reflect.DeepEqual(events, want) {
+		t.Errorf("Track.All() = %v; want %v", events, want)
+	}
+}
+
+func TestIteratorsBreak(t *testing.T) {
+	s := iterSMF()
+
+	var n int
+
+	for range s.All() {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+
+	if n != 2 {
+		t.Errorf("All() yielded %v events; want 2", n)
+	}
+
+	var notes []Note
+
+	for note := range s.AllNotes() {
+		notes = append(notes, note)
+		if len(notes) == 3 {
+			break
+		}
+	}
+
+	if want := s.Notes()[:3]; !reflect.DeepEqual(notes, want) {
+		t.Errorf("AllNotes() = %v; want %v", notes, want)
+	}
+}
+
+func ExampleTrack_All() {
+	var tr Track
+	tr.Add(0, channel.Channel0.NoteOn(60, 100))
+	tr.Add(480, channel.Channel0.NoteOff(60))
+
+	for abs, msg := range tr.All() {
+		fmt.Println(abs, msg)
+	}
+
+	// Output:
+	// 0 channel.NoteOn channel 1 key 60 velocity 100
+	// 480 channel.NoteOff channel 1 key 60
+}
+
+func ExampleSMF_AllNotes() {
+	var tr Track
+	tr.Add(0, channel.Channel0.NoteOn(60, 100), channel.Channel0.NoteOn(64, 100))
+	tr.Add(480, channel.Channel0.NoteOff(64))
+	tr.Add(960, channel.Channel0.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	for n := range s.AllNotes() {
+		fmt.Printf("key %v at %v for %v ticks\n", n.Key, n.AbsTicks, n.Duration)
+
+		// the iteration may be stopped at any time
+		if n.Key == 64 {
+			break
+		}
+	}
+
+	// Output:
+	// key 60 at 0 for 960 ticks
+	// key 64 at 0 for 480 ticks
+}
@@ -0,0 +1,290 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/internal/vlq"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+/*
+The custom names of the drum lanes are stored as sequencer specific meta message (FF 7F) at tick 0 of the
+first track:
+
+	FF 7F <len> 7D 67 6D 44 4D <version> [<key> <len> <name>]...
+
+Like the TrackMeta, it starts with the manufacturer ID 7D, followed by the signature "gmDM" and the version of
+the format (currently 01). Each name consists of the key, the length of the name as variable length quantity
+and the name in UTF-8.
+*/
+
+// drumNamesPrefix is the beginning of the sequencer specific data of the drum names
+var drumNamesPrefix = []byte{0x7D, 'g', 'm', 'D', 'M'}
+
+const drumNamesVersion = 0x01
+
+// drumChannel is the channel of the General MIDI percussion (channel 10 in GM)
+const drumChannel = 9
+
+// drumLaneOrder is the conventional order of the General MIDI percussion keys in drum editors:
+// kicks, snares, hi-hats, toms from high to low, crash and ride cymbals, followed by the percussion
+var drumLaneOrder = []uint8{
+	36, 35, // kicks
+	38, 40, 37, 39, // snares, side stick and hand clap
+	42, 44, 46, // hi-hats
+	50, 48, 47, 45, 43, 41, // toms
+	49, 57, 55, 52, // crash cymbals
+	51, 59, 53, // ride cymbals
+	54, 56, 58, 60, 61, 62, 63, 64, 65, 66, 67, 68, 69, 70, 71, 72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+}
+
+// Lane is a lane of a drum editor: the notes of a single key on the drum channel (channel 9, channel 10 in GM)
+type Lane struct {
+	Key uint8
+
+	// Name is the custom name of the key (see RenameLane), the General MIDI name (see channel.DrumName)
+	// or the note name (see channel.NoteName) for keys outside of the General MIDI percussion map.
+	Name string
+
+	// Custom is true, if Name is a custom name
+	Custom bool
+
+	// Notes are the notes of the key on the drum channel of all tracks, sorted by their start
+	Notes []Note
+}
+
+// DrumLanes returns a Lane for each key that has notes on the drum channel in any track of the given SMF.
+// The lanes are ordered like the drum editors do: kicks, snares, hi-hats, toms, cymbals and the other
+// percussion, followed by the keys outside of the General MIDI percussion map in ascending order.
+// The custom names are read from the drum names of the first track (see Track.DrumNames).
+func DrumLanes(s *SMF) []Lane {
+	var names map[uint8]string
+
+	if len(s.tracks) > 0 {
+		names = s.tracks[0].DrumNames()
+	}
+
+	var lanes []Lane
+	var byKey = map[uint8]int{}
+
+	for n := range s.AllNotes() {
+		if n.Channel != drumChannel {
+			continue
+		}
+
+		i, has := byKey[n.Key]
+
+		if !has {
+			i = len(lanes)
+			byKey[n.Key] = i
+			lanes = append(lanes, newLane(n.Key, names))
+		}
+
+		lanes[i].Notes = append(lanes[i].Notes, n)
+	}
+
+	rank := map[uint8]int{}
+
+	for i, key := range drumLaneOrder {
+		rank[key] = i
+	}
+
+	sort.Slice(lanes, func(a, b int) bool {
+		ra, hasA := rank[lanes[a].Key]
+		rb, hasB := rank[lanes[b].Key]
+
+		switch {
+		case hasA && hasB:
+			return ra < rb
+		case hasA != hasB:
+			return hasA
+		default:
+			return lanes[a].Key < lanes[b].Key
+		}
+	})
+
+	return lanes
+}
+
+// newLane returns a Lane without notes for the given key, named by the given custom names
+func newLane(key uint8, names map[uint8]string) Lane {
+	if name, has := names[key]; has {
+		return Lane{Key: key, Name: name, Custom: true}
+	}
+
+	if name := channel.DrumName(key); name != "" {
+		return Lane{Key: key, Name: name}
+	}
+
+	return Lane{Key: key, Name: channel.NoteName(key)}
+}
+
+// RenameLane returns a copy of the given SMF where the lane of the given key has the given custom name.
+// An empty name removes the custom name. The given SMF is not modified.
+//
+// The custom names are written to the first track (see Track.SetDrumNames).
+// An error is returned, if the SMF has no tracks, the key is not a valid MIDI key or the name is not valid UTF-8.
+func RenameLane(s *SMF, key uint8, name string) (*SMF, error) {
+	if len(s.tracks) == 0 {
+		return nil, fmt.Errorf("can't rename lane %v: SMF has no tracks", key)
+	}
+
+	res := s.clone()
+	names := res.tracks[0].DrumNames()
+
+	if names == nil {
+		names = map[uint8]string{}
+	}
+
+	if name == "" {
+		delete(names, key)
+	} else {
+		names[key] = name
+	}
+
+	if err := res.tracks[0].SetDrumNames(names); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// MoveLane returns a copy of the given SMF where the notes of the lane of the key from are moved to the key to,
+// i.e. the keys of the note on, note off and polyphonic aftertouch messages on the drum channel are rewritten
+// (see RemapDrums). The custom name of the lane moves with it, while a lane without custom name is named
+// by the new key. The given SMF is not modified.
+//
+// An error is returned, if the key to is not a valid MIDI key or if there are notes on it, since the lanes
+// would be merged.
+func MoveLane(s *SMF, from, to uint8) (*SMF, error) {
+	if to > 127 {
+		return nil, fmt.Errorf("can't move lane %v to %v: invalid key", from, to)
+	}
+
+	if from == to {
+		return s.clone(), nil
+	}
+
+	for n := range s.AllNotes() {
+		if n.Channel == drumChannel && n.Key == to {
+			return nil, fmt.Errorf("can't move lane %v to %v: key %v has notes", from, to, to)
+		}
+	}
+
+	res, _ := RemapDrums(s, channel.DrumMap{from: to}, drumChannel)
+
+	if len(res.tracks) == 0 {
+		return res, nil
+	}
+
+	names := res.tracks[0].DrumNames()
+	name, has := names[from]
+	_, hasTo := names[to]
+
+	if !has && !hasTo {
+		return res, nil
+	}
+
+	delete(names, from)
+	delete(names, to)
+
+	if has {
+		names[to] = name
+	}
+
+	if err := res.tracks[0].SetDrumNames(names); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// DrumNames returns the custom names of the drum keys that are stored in the track, nil if there are none.
+// The names are read from the first sequencer specific message of the track that contains drum names;
+// damaged names are ignored.
+func (t *Track) DrumNames() map[uint8]string {
+	data := t.sequencerData(drumNamesPrefix)
+
+	if len(data) < len(drumNamesPrefix)+1 {
+		return nil
+	}
+
+	var names map[uint8]string
+	rd := bytes.NewReader(data[len(drumNamesPrefix)+1:])
+
+	for rd.Len() > 0 {
+		key, _ := rd.ReadByte()
+		length, err := midilib.ReadVarLength(rd)
+
+		if err != nil || length > uint32(rd.Len()) {
+			break
+		}
+
+		name := make([]byte, length)
+
+		if _, err := io.ReadFull(rd, name); err != nil {
+			break
+		}
+
+		if key > 127 || len(name) == 0 || !utf8.Valid(name) {
+			continue
+		}
+
+		if names == nil {
+			names = map[uint8]string{}
+		}
+
+		names[key] = string(name)
+	}
+
+	return names
+}
+
+// SetDrumNames replaces the custom names of the drum keys of the track by the given ones. They are written as
+// sequencer specific message at tick 0, after the leading meta messages (e.g. the track name), so that they
+// survive writing and reading the SMF. Setting no names removes the message.
+// An error is returned for invalid keys and names that are empty or not valid UTF-8.
+// ErrFrozen is returned, if the track is frozen.
+func (t *Track) SetDrumNames(names map[uint8]string) error {
+	if t.frozen {
+		return ErrFrozen
+	}
+
+	var keys []int
+
+	for key, name := range names {
+		if key > 127 {
+			return fmt.Errorf("invalid drum key %v", key)
+		}
+
+		if name == "" || !utf8.ValidString(name) {
+			return fmt.Errorf("invalid name %q of drum key %v", name, key)
+		}
+
+		keys = append(keys, int(key))
+	}
+
+	if len(keys) == 0 {
+		return t.setSequencerData(drumNamesPrefix, nil)
+	}
+
+	sort.Ints(keys)
+
+	var bf bytes.Buffer
+	bf.Write(drumNamesPrefix)
+	bf.WriteByte(drumNamesVersion)
+
+	for _, key := range keys {
+		name := names[uint8(key)]
+		bf.WriteByte(uint8(key))
+		bf.Write(vlq.Encode(uint32(len(name))))
+		bf.WriteString(name)
+	}
+
+	return t.setSequencerData(drumNamesPrefix, bf.Bytes())
+}
@@ -0,0 +1,222 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// drumSMF returns a SMF of format 1 with a drum track (hi-hat, kick, snare, key 90) and a piano track
+// that has a note on the drum channel too
+func drumSMF() *SMF {
+	var drums Track
+	ch := channel.Channel9
+	drums.Add(0, meta.Track("drums"), ch.NoteOn(42, 80), ch.NoteOn(36, 100))
+	drums.Add(120, ch.NoteOff(42), ch.NoteOff(36))
+	drums.Add(240, ch.NoteOn(38, 90), ch.NoteOn(90, 70))
+	drums.Add(360, ch.NoteOff(38), ch.NoteOff(90))
+
+	var piano Track
+	piano.Add(0, channel.Channel0.NoteOn(42, 60))
+	piano.Add(480, channel.Channel0.NoteOff(42), ch.NoteOn(36, 110))
+	piano.Add(600, ch.NoteOff(36))
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&drums)
+	s.AddTrack(&piano)
+	return s
+}
+
+// lanesString returns the lanes as "key name (custom) ticks of the notes" lines
+func lanesString(lanes []Lane) string {
+	var bf strings.Builder
+
+	for _, l := range lanes {
+		fmt.Fprintf(&bf, "%v %s", l.Key, l.Name)
+
+		if l.Custom {
+			bf.WriteString(" (custom)")
+		}
+
+		for _, n := range l.Notes {
+			fmt.Fprintf(&bf, " %v/%v", n.Track, n.AbsTicks)
+		}
+
+		bf.WriteString("\n")
+	}
+
+	return bf.String()
+}
+
+func TestDrumLanes(t *testing.T) {
+	s := drumSMF()
+
+	expected := `36 Bass Drum 1 0/0 1/480
+38 Acoustic Snare 0/240
+42 Closed Hi-Hat 0/0
+90 F#6 0/240
+`
+
+	if got := lanesString(DrumLanes(s)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	res, err := RenameLane(s, 90, "Tambourine (foot)")
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res, err = RenameLane(res, 38, "Snare")
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected = `36 Bass Drum 1 0/0 1/480
+38 Snare (custom) 0/240
+42 Closed Hi-Hat 0/0
+90 Tambourine (foot) (custom) 0/240
+`
+
+	if got := lanesString(DrumLanes(res)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	if got := lanesString(DrumLanes(s)); strings.Contains(got, "custom") {
+		t.Errorf("RenameLane modified the given SMF:\n%s", got)
+	}
+
+	// the names are written after the track name
+	if got, want := trackString(res.Track(0)), "0 meta.Track: \"drums\"\n0 meta.SequencerData len 32\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("got:\n%s\n\nwanted prefix:\n%s\n\n", got, want)
+	}
+
+	res, err = RenameLane(res, 38, "")
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := res.Track(0).DrumNames(), map[uint8]string{90: "Tambourine (foot)"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DrumNames() = %v; want %v", got, want)
+	}
+
+	if _, err := RenameLane(s, 128, "invalid"); err == nil {
+		t.Errorf("RenameLane(128) must return an error")
+	}
+}
+
+func TestMoveLane(t *testing.T) {
+	s, err := RenameLane(drumSMF(), 90, "Shaker")
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the custom name moves with the lane, the lane without custom name is named by its new key
+	res, err := MoveLane(s, 90, 82)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res, err = MoveLane(res, 38, 40)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `36 Bass Drum 1 0/0 1/480
+40 Electric Snare 0/240
+42 Closed Hi-Hat 0/0
+82 Shaker (custom) 0/240
+`
+
+	if got := lanesString(DrumLanes(res)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	// the key 42 on the piano channel is not touched
+	res, err = MoveLane(res, 42, 44)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := res.Track(1).Event(0).Message, channel.Channel0.NoteOn(42, 60); got != want {
+		t.Errorf("piano note = %v; want %v", got, want)
+	}
+
+	if _, err := MoveLane(s, 38, 36); err == nil {
+		t.Errorf("MoveLane to a key with notes must return an error")
+	}
+}
+
+func TestDrumNamesRoundTrip(t *testing.T) {
+	s, err := RenameLane(drumSMF(), 36, "Kick (Ä)")
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	data := s.Track(0).Event(1).Message.(meta.SequencerData).Data()
+	expectedData := []byte{0x7D, 'g', 'm', 'D', 'M', 0x01, 36, 0x09, 'K', 'i', 'c', 'k', ' ', '(', 0xC3, 0x84, ')'}
+
+	if !bytes.Equal(data, expectedData) {
+		t.Errorf("data = % X; want % X", data, expectedData)
+	}
+
+	read, err := Read(&bf)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got := lanesString(DrumLanes(read)); got != lanesString(DrumLanes(s)) {
+		t.Errorf("lanes after round trip:\n%s\n\nwanted:\n%s\n\n", got, lanesString(DrumLanes(s)))
+	}
+
+	// the drum names and the TrackMeta don't interfere
+	if err := read.SetTrackMeta(0, TrackMeta{Mute: true}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := read.Track(0).DrumNames(), map[uint8]string{36: "Kick (Ä)"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DrumNames() = %v; want %v", got, want)
+	}
+
+	if err := read.Track(0).SetDrumNames(nil); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, _ := read.TrackMeta(0); !got.Mute {
+		t.Errorf("TrackMeta() = %+v; want Mute", got)
+	}
+
+	if got := read.Track(0).DrumNames(); got != nil {
+		t.Errorf("DrumNames() = %v; want nil", got)
+	}
+}
+
+func TestDrumNamesDamaged(t *testing.T) {
+	// a length beyond the data ends the decoding before allocating
+	var tr Track
+	tr.Add(0, meta.SequencerData{0x7D, 'g', 'm', 'D', 'M', 0x01, 36, 0x04, 'K', 'i', 'c', 'k', 38, 0xFF, 0xFF, 0xFF, 0x7F})
+
+	if got, want := tr.DrumNames(), map[uint8]string{36: "Kick"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DrumNames() = %v; want %v", got, want)
+	}
+}
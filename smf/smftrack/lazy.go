@@ -0,0 +1,237 @@
+package smftrack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+// ReadLazy reads the SMF data from src like Read with the smfreader.Preserve option, but the events of a track
+// are only decoded, when they are accessed for the first time. Tracks that are never accessed are written back
+// from their original data, so transforming a single track of a large SMF neither decodes nor encodes the others.
+//
+// The tracks are only read lazily, if the data consists of the header and well formed track chunks only.
+// Otherwise (e.g. for unknown chunks or damaged data) all tracks are decoded while reading. Since the tracks are
+// decoded later, a problem that is only noticed while decoding (e.g. a text that exceeds the limit of
+// meta.SetTextLimit) ends the events of the track, instead of being returned as an error.
+func ReadLazy(src io.Reader) (*SMF, error) {
+	data, err := ioutil.ReadAll(src)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := readLazy(data)
+
+	if !ok {
+		return Read(bytes.NewReader(data), smfreader.Preserve())
+	}
+
+	return s, nil
+}
+
+// readLazy returns the SMF of the given data with tracks that have not been decoded. It returns false, if the data
+// is not just the header and well formed track chunks.
+func readLazy(data []byte) (*SMF, bool) {
+	if len(data) < 14 || string(data[:4]) != "MThd" || binary.BigEndian.Uint32(data[4:8]) != 6 {
+		return nil, false
+	}
+
+	rd := smfreader.New(bytes.NewReader(data[:14]))
+
+	if err := rd.ReadHeader(); err != nil {
+		return nil, false
+	}
+
+	h := rd.Header()
+
+	if h.NumTracks == 0 || (h.Format == smf.SMF0 && h.NumTracks != 1) {
+		return nil, false
+	}
+
+	s := New(h.Format, h.TimeFormat)
+	s.preserved = &preserved{header: h, raw: data[:14]}
+	rest := data[14:]
+
+	for i := 0; i < int(h.NumTracks); i++ {
+		if len(rest) < 8 || string(rest[:4]) != "MTrk" {
+			return nil, false
+		}
+
+		length := binary.BigEndian.Uint32(rest[4:8])
+
+		if uint64(len(rest)-8) < uint64(length) {
+			return nil, false
+		}
+
+		chunk := rest[:8+length]
+		end, ok := scanEvents(chunk[8:])
+
+		if !ok {
+			return nil, false
+		}
+
+		tr := &Track{end: end}
+		tr.lazy.Store(&chunk)
+		tr.encoded.Store(&chunk)
+		s.tracks = append(s.tracks, tr)
+		rest = rest[8+length:]
+	}
+
+	return s, len(rest) == 0
+}
+
+// scanEvents checks that the given data of a track chunk consists of well formed events that end with the end of
+// track message, so that it is read without any problem and independent of the tracks before.
+// It returns the ticks of the end of track.
+func scanEvents(data []byte) (end uint64, ok bool) {
+	var status byte
+
+	for i := 0; i < len(data); {
+		delta, n := scanVarLength(data[i:])
+
+		if n == 0 || i+n >= len(data) {
+			return 0, false
+		}
+
+		i += n
+		end += uint64(delta)
+		b := data[i]
+
+		switch {
+		case b < 0x80:
+			// running status
+			if status == 0 {
+				return 0, false
+			}
+		case b < 0xF0:
+			status = b
+			i++
+		case b == 0xF0:
+			// system exclusive messages that are continued by escapes depend on the messages before
+			status = 0
+			length, n := scanVarLength(data[i+1:])
+
+			if n == 0 || length == 0 || i+1+n+int(length) > len(data) || data[i+n+int(length)] != 0xF7 {
+				return 0, false
+			}
+
+			i += 1 + n + int(length)
+			continue
+		case b == 0xFF:
+			status = 0
+
+			if i+2 >= len(data) {
+				return 0, false
+			}
+
+			typ := data[i+1]
+			length, n := scanVarLength(data[i+2:])
+
+			if n == 0 || i+2+n+int(length) > len(data) {
+				return 0, false
+			}
+
+			// the meta messages of a fixed length
+			if l, fixed := metaLengths[typ]; fixed && int(length) != l && !(typ == 0x00 && length == 0) {
+				return 0, false
+			}
+
+			i += 2 + n + int(length)
+
+			// the end of track has to end the chunk
+			if typ == 0x2F {
+				return end, length == 0 && i == len(data)
+			}
+			continue
+		default:
+			return 0, false
+		}
+
+		n = 2
+
+		if st := status & 0xF0; st == 0xC0 || st == 0xD0 {
+			n = 1
+		}
+
+		if i+n > len(data) {
+			return 0, false
+		}
+
+		for _, d := range data[i : i+n] {
+			if d >= 0x80 {
+				return 0, false
+			}
+		}
+
+		i += n
+	}
+
+	// missing end of track
+	return 0, false
+}
+
+// metaLengths are the lengths of the meta messages of a fixed length by their type
+var metaLengths = map[byte]int{
+	0x00: 2, // sequence number (may be empty)
+	0x20: 1, // channel prefix
+	0x21: 1, // port
+	0x2F: 0, // end of track
+	0x51: 3, // tempo
+	0x54: 5, // SMPTE offset
+	0x58: 4, // time signature
+	0x59: 2, // key signature
+}
+
+// scanVarLength returns the variable length quantity at the beginning of data and the number of its bytes.
+// The number is 0, if data does not begin with a variable length quantity of at most 4 bytes.
+func scanVarLength(data []byte) (val uint32, n int) {
+	for n < len(data) && n < 4 {
+		b := data[n]
+		val = val<<7 | uint32(b&0x7F)
+		n++
+
+		if b&0x80 == 0 {
+			return val, n
+		}
+	}
+
+	return 0, 0
+}
+
+// decodeChunk returns the events of the given track chunk, that has been checked by scanEvents.
+// The events end before a problem that is only noticed while decoding.
+func decodeChunk(chunk []byte) (evts []Event) {
+	var bf bytes.Buffer
+	bf.Grow(14 + len(chunk))
+	bf.WriteString("MThd")
+	binary.Write(&bf, binary.BigEndian, [5]uint16{0, 6, 0, 1, 960})
+	bf.Write(chunk)
+
+	rd := smfreader.New(&bf)
+
+	if rd.ReadHeader() != nil {
+		return nil
+	}
+
+	var abs uint64
+
+	for {
+		msg, err := rd.Read()
+
+		if err != nil {
+			return
+		}
+
+		abs += uint64(rd.Delta())
+
+		if msg != meta.EndOfTrack {
+			evts = append(evts, Event{AbsTicks: abs, Message: msg})
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package smftrack
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+// lazyFile returns the data of a SMF1 with a conductor track and 3 tracks of notes
+func lazyFile() []byte {
+	s := multiTrackFile(3, 20)
+
+	var conductor Track
+	conductor.Add(0, meta.SequenceNo(1), meta.BPM(120), meta.TimeSig{Numerator: 3, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8})
+	conductor.Add(0, sysex.SysEx([]byte{0x7E, 0x7F, 0x09, 0x01}), meta.Key{Key: 2, Num: 2, IsMajor: true})
+	conductor.Add(960, meta.Marker("verse"), meta.Undefined{Typ: 0x60, Data: []byte{1, 2}})
+	conductor.SetEnd(4800)
+	s.tracks = append([]*Track{&conductor}, s.tracks...)
+
+	var bf bytes.Buffer
+	s.Write(&bf)
+	return bf.Bytes()
+}
+
+func isLazy(s *SMF) (lazy []bool) {
+	for _, tr := range s.tracks {
+		lazy = append(lazy, tr.lazy.Load() != nil)
+	}
+	return
+}
+
+func TestReadLazy(t *testing.T) {
+	data := lazyFile()
+
+	eager, err := Read(bytes.NewReader(data), smfreader.Preserve())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	s, err := ReadLazy(bytes.NewReader(data))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// writing and copying does not decode the tracks
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if !bytes.Equal(bf.Bytes(), data) {
+		t.Errorf("written data differs from the original data")
+	}
+
+	res := s.clone()
+
+	for _, lazy := range [][]bool{isLazy(s), isLazy(res)} {
+		for no, l := range lazy {
+			if !l {
+				t.Errorf("track %v has been decoded", no)
+			}
+		}
+	}
+
+	// the ends are known without decoding
+	for no := range s.tracks {
+		if got, want := s.Track(no).End(), eager.Track(no).End(); got != want {
+			t.Errorf("track %v: End() = %v; want %v", no, got, want)
+		}
+	}
+
+	// a modified track is decoded, the others are written from their original data
+	eager.Track(2).Delete(3)
+	res.Track(2).Delete(3)
+
+	var want bytes.Buffer
+	eager.Write(&want)
+	bf.Reset()
+	res.Write(&bf)
+
+	if !bytes.Equal(bf.Bytes(), want.Bytes()) {
+		t.Errorf("written data of the modified copy differs from the eagerly read one")
+	}
+
+	if got, want := isLazy(res), []bool{true, true, false, true}; !equalBools(got, want) {
+		t.Errorf("lazy tracks = %v; want %v", got, want)
+	}
+
+	// accessing the events decodes them like the eager reading
+	for no := range s.tracks {
+		if got, want := trackString(s.Track(no)), trackString(eager.Track(no)); no != 2 && got != want {
+			t.Errorf("track %v:\ngot:\n%s\n\nwanted:\n%s\n\n", no, got, want)
+		}
+	}
+
+	if got, want := isLazy(s), []bool{false, false, false, false}; !equalBools(got, want) {
+		t.Errorf("lazy tracks after access = %v; want %v", got, want)
+	}
+}
+
+func TestReadLazyConcurrent(t *testing.T) {
+	data := lazyFile()
+	eager, _ := Read(bytes.NewReader(data))
+	s, _ := ReadLazy(bytes.NewReader(data))
+	s.Freeze()
+
+	// a frozen SMF may be shared, so the tracks may be decoded concurrently
+	var wg sync.WaitGroup
+	got := make([]string, 8)
+
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i] = trackString(s.Track(i % 4))
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i := range got {
+		if want := trackString(eager.Track(i % 4)); got[i] != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got[i], want)
+		}
+	}
+}
+
+func TestReadLazyFallback(t *testing.T) {
+	var tr Track
+	tr.Add(0, channel.Channel0.NoteOn(60, 100))
+	tr.Add(96, channel.Channel0.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	var bf bytes.Buffer
+	s.Write(&bf)
+	data := bf.Bytes()
+
+	tests := map[string][]byte{
+		"trailing garbage":  append(append([]byte{}, data...), 0x01, 0x02),
+		"unknown chunk":     append(append([]byte{}, data...), 'X', 'Y', 'Z', 'W', 0, 0, 0, 1, 0),
+		"redundant end":     append(append([]byte{}, data[:len(data)-4]...), 0x00, 0xFF, 0x2F, 0x00, 0x00, 0xFF, 0x2F, 0x00),
+		"missing status":    append(append([]byte{}, data[:len(data)-4]...), 0x00, 0x40, 0x00, 0x00, 0xFF, 0x2F, 0x00),
+		"short tempo":       append(append([]byte{}, data[:len(data)-4]...), 0x00, 0xFF, 0x51, 0x02, 0x07, 0xA1, 0x00, 0xFF, 0x2F, 0x00),
+		"continued sysex":   append(append([]byte{}, data[:len(data)-4]...), 0x00, 0xF0, 0x02, 0x7E, 0x7F, 0x00, 0xFF, 0x2F, 0x00),
+		"long delta":        append(append([]byte{}, data[:len(data)-4]...), 0x80, 0x80, 0x80, 0x80, 0x00, 0xFF, 0x2F, 0x00),
+		"missing end":       data[:len(data)-4],
+		"too short":         data[:len(data)-1],
+		"system common msg": append(append([]byte{}, data[:len(data)-4]...), 0x00, 0xF2, 0x00, 0x00, 0x00, 0xFF, 0x2F, 0x00),
+	}
+
+	for name, input := range tests {
+		if _, ok := readLazy(input); ok {
+			t.Errorf("[%v] data has been read lazily", name)
+		}
+
+		res, err := ReadLazy(bytes.NewReader(input))
+		_, eagerErr := Read(bytes.NewReader(input), smfreader.Preserve())
+
+		if (err == nil) != (eagerErr == nil) {
+			t.Errorf("[%v] ReadLazy() error = %v; want %v", name, err, eagerErr)
+		}
+
+		if res != nil && isLazy(res)[0] {
+			t.Errorf("[%v] track has been read lazily", name)
+		}
+	}
+}
+
+func equalBools(a, b []bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// BenchmarkTransformOneTrack reads a SMF of 16 tracks with 2000 notes each, changes one track and writes it.
+func BenchmarkTransformOneTrack(b *testing.B) {
+	var src, bf bytes.Buffer
+	multiTrackFile(16, 2000).Write(&src)
+	data := src.Bytes()
+
+	transform := func(b *testing.B, read func() (*SMF, error)) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			s, err := read()
+
+			if err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+
+			s.Track(5).Delete(10)
+			bf.Reset()
+
+			if err := s.Write(&bf); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	}
+
+	b.Run("eager", func(b *testing.B) {
+		transform(b, func() (*SMF, error) { return Read(bytes.NewReader(data), smfreader.Preserve()) })
+	})
+
+	b.Run("lazy", func(b *testing.B) {
+		transform(b, func() (*SMF, error) { return ReadLazy(bytes.NewReader(data)) })
+	})
+}
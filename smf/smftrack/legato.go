@@ -0,0 +1,140 @@
+package smftrack
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi/smf"
+)
+
+type legatoConfig struct {
+	minDuration uint64
+	phraseGap   uint64
+	drums
+}
+
+// LegatoOption is an option for Legato (see also DetectDrums)
+type LegatoOption interface {
+	applyLegato(*legatoConfig)
+}
+
+type legatoOption func(*legatoConfig)
+
+func (o legatoOption) applyLegato(c *legatoConfig) {
+	o(c)
+}
+
+// LegatoMinDuration sets the minimal duration of a note in ticks. Default is 1.
+func LegatoMinDuration(ticks uint64) LegatoOption {
+	return legatoOption(func(c *legatoConfig) {
+		c.minDuration = ticks
+	})
+}
+
+// LegatoPhraseGap sets the gap in ticks between the end of a note and the start of the next note,
+// that is considered a phrase break. The last note before a phrase break is not changed.
+// Default is a quarter note for metric time formats, otherwise there are no phrase breaks.
+// 0 disables the detection of phrase breaks.
+func LegatoPhraseGap(ticks uint64) LegatoOption {
+	return legatoOption(func(c *legatoConfig) {
+		c.phraseGap = ticks
+	})
+}
+
+// LegatoDrumChannels sets the channels that are skipped. Default is channel 9 (channel 10 in GM).
+func LegatoDrumChannels(channels ...uint8) LegatoOption {
+	return legatoOption(func(c *legatoConfig) {
+		c.drumChannels = channelSet(channels)
+		c.detectDrums = nil
+	})
+}
+
+// Legato returns a copy of the given SMF where the notes are ended overlapTicks after the start of the next note
+// on the same track and channel (monophonic interpretation). A negative overlapTicks ends the notes before
+// the start of the next note. The given SMF is not modified.
+//
+// Notes that start at the same tick (chords) are treated alike. The duration of a note is never shorter than the
+// minimal duration (see LegatoMinDuration) and a note never overlaps the next note on the same key.
+// The last note of each phrase (see LegatoPhraseGap) and the notes on drum channels (see LegatoDrumChannels)
+// are not changed.
+func Legato(s *SMF, overlapTicks int32, options ...LegatoOption) *SMF {
+	c := legatoConfig{
+		minDuration: 1,
+		drums:       drums{drumChannels: map[uint8]bool{9: true}},
+	}
+
+	if mt, is := s.timeFormat.(smf.MetricTicks); is {
+		c.phraseGap = uint64(mt.Number())
+	}
+
+	for _, opt := range options {
+		opt.applyLegato(&c)
+	}
+
+	c.resolveDrums(s)
+
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		notes := tr.Notes()
+		var byChannel = map[uint8][]int{}
+
+		for i, n := range notes {
+			if !c.drumChannels[n.Channel] {
+				byChannel[n.Channel] = append(byChannel[n.Channel], i)
+			}
+		}
+
+		for _, idx := range byChannel {
+			c.legato(notes, idx, int64(overlapTicks))
+		}
+
+		// can't fail, since the notes are from the track
+		tr.SetNotes(notes)
+	}
+
+	return res
+}
+
+// legato changes the durations of the notes with the given indices (sorted by start) of the same channel
+func (c legatoConfig) legato(notes []Note, idx []int, overlap int64) {
+	for a, i := range idx {
+		n := &notes[i]
+
+		// the next note that starts after n
+		b := a + sort.Search(len(idx)-a, func(b int) bool {
+			return notes[idx[a+b]].AbsTicks > n.AbsTicks
+		})
+
+		if b >= len(idx) {
+			continue
+		}
+
+		next := notes[idx[b]]
+
+		if c.phraseGap > 0 && next.AbsTicks > n.End() && next.AbsTicks-n.End() > c.phraseGap {
+			continue
+		}
+
+		end := int64(next.AbsTicks) + overlap
+
+		if sameKey := c.nextOnKey(notes, idx[a+1:], n); sameKey != nil && end > int64(sameKey.AbsTicks) {
+			end = int64(sameKey.AbsTicks)
+		}
+
+		if min := int64(n.AbsTicks + c.minDuration); end < min {
+			end = min
+		}
+
+		n.Duration = uint64(end) - n.AbsTicks
+	}
+}
+
+// nextOnKey returns the first note after n on the same key within the notes with the given indices
+func (c legatoConfig) nextOnKey(notes []Note, idx []int, n *Note) *Note {
+	for _, i := range idx {
+		if notes[i].Key == n.Key && notes[i].AbsTicks > n.AbsTicks {
+			return &notes[i]
+		}
+	}
+	return nil
+}
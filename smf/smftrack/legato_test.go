@@ -0,0 +1,150 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+func melody() *SMF {
+	var tr Track
+	ch, drums := channel.Channel0, channel.Channel9
+
+	// gap of 16 ticks
+	tr.Add(0, ch.NoteOn(60, 100), drums.NoteOn(36, 100))
+	tr.Add(10, drums.NoteOff(36))
+	tr.Add(80, ch.NoteOff(60))
+	tr.Add(96, ch.NoteOn(62, 100), drums.NoteOn(36, 100))
+	tr.Add(106, drums.NoteOff(36))
+	// overlap of 24 ticks
+	tr.Add(192, ch.NoteOn(64, 100))
+	tr.Add(216, ch.NoteOff(62))
+	// phrase break after this note
+	tr.Add(240, ch.NoteOff(64))
+	tr.Add(480, ch.NoteOn(64, 100))
+	tr.Add(528, ch.NoteOff(64))
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+	return s
+}
+
+func notesString(s *SMF) string {
+	var bf bytes.Buffer
+
+	for _, n := range s.Notes() {
+		fmt.Fprintf(&bf, "%v %v %v %v\n", n.Channel, n.Key, n.AbsTicks, n.Duration)
+	}
+
+	return bf.String()
+}
+
+func TestLegato(t *testing.T) {
+	tests := []struct {
+		overlap  int32
+		options  []LegatoOption
+		expected string
+	}{
+		{
+			10,
+			nil,
+			`0 60 0 106
+9 36 0 10
+0 62 96 106
+9 36 96 10
+0 64 192 48
+0 64 480 48
+`,
+		},
+		{
+			-10,
+			nil,
+			`0 60 0 86
+9 36 0 10
+0 62 96 86
+9 36 96 10
+0 64 192 48
+0 64 480 48
+`,
+		},
+		{
+			// no phrase breaks, but the note must not overlap the next note on the same key
+			10,
+			[]LegatoOption{LegatoPhraseGap(0), LegatoDrumChannels()},
+			`0 60 0 106
+9 36 0 96
+0 62 96 106
+9 36 96 10
+0 64 192 288
+0 64 480 48
+`,
+		},
+		{
+			// no drums detected
+			10,
+			[]LegatoOption{LegatoPhraseGap(0), DetectDrums(func(*SMF) []uint8 { return nil })},
+			`0 60 0 106
+9 36 0 96
+0 62 96 106
+9 36 96 10
+0 64 192 288
+0 64 480 48
+`,
+		},
+		{
+			-90,
+			[]LegatoOption{LegatoMinDuration(20)},
+			`0 60 0 20
+9 36 0 10
+0 62 96 20
+9 36 96 10
+0 64 192 48
+0 64 480 48
+`,
+		},
+	}
+
+	for i, test := range tests {
+		src := melody()
+		before := notesString(src)
+		res := Legato(src, test.overlap, test.options...)
+
+		if got, want := notesString(res), test.expected; got != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+
+		if got, want := notesString(src), before; got != want {
+			t.Errorf("[%v] source has been modified", i)
+		}
+	}
+}
+
+func TestSetNotesSameKey(t *testing.T) {
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, ch.NoteOn(60, 100))
+	tr.Add(50, ch.NoteOff(60))
+	tr.Add(96, ch.NoteOn(60, 100))
+	tr.Add(150, ch.NoteOff(60))
+
+	notes := tr.Notes()
+	notes[0].Duration = 96
+
+	if err := tr.SetNotes(notes); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0 channel.NoteOn channel 1 key 60 velocity 100
+96 channel.NoteOff channel 1 key 60
+96 channel.NoteOn channel 1 key 60 velocity 100
+150 channel.NoteOff channel 1 key 60
+150 end
+`
+
+	if got, want := trackString(&tr), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
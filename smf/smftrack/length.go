@@ -0,0 +1,173 @@
+package smftrack
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+type lengthMode int
+
+const (
+	lengthEndOfTrack lengthMode = iota
+	lengthLastEvent
+	lengthLastNoteOff
+)
+
+type lengthConfig struct {
+	mode    lengthMode
+	release time.Duration
+}
+
+// LengthOption is an option for Length
+type LengthOption func(*lengthConfig)
+
+// LastEvent lets Length measure up to the last event of all tracks, ignoring the end of track messages
+func LastEvent() LengthOption {
+	return func(c *lengthConfig) {
+		c.mode = lengthLastEvent
+	}
+}
+
+// LastNoteOff lets Length measure up to the end of the last note of all tracks, ignoring the events after it
+// (e.g. controller tails). Notes without a note off message last until the end of their track.
+func LastNoteOff() LengthOption {
+	return func(c *lengthConfig) {
+		c.mode = lengthLastNoteOff
+	}
+}
+
+// PaddedRelease lets Length add the given time for the release of the last sound
+func PaddedRelease(extra time.Duration) LengthOption {
+	return func(c *lengthConfig) {
+		c.release = extra
+	}
+}
+
+// Length returns the length of the given SMF in ticks and as time, based on its TempoMap.
+// By default, the length is the end of the longest track. See LastEvent, LastNoteOff and PaddedRelease for alternatives.
+// If there is a padding for the release, ticks is the tick nearest to the padded time.
+func Length(s *SMF, options ...LengthOption) (ticks uint64, d time.Duration) {
+	var c lengthConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	for _, tr := range s.tracks {
+		var end uint64
+
+		switch c.mode {
+		case lengthEndOfTrack:
+			end = tr.End()
+		case lengthLastEvent:
+			if n := len(tr.evs()); n > 0 {
+				end = tr.evs()[n-1].AbsTicks
+			}
+		case lengthLastNoteOff:
+			for _, n := range tr.Notes() {
+				if n.End() > end {
+					end = n.End()
+				}
+			}
+		}
+
+		if end > ticks {
+			ticks = end
+		}
+	}
+
+	m := s.TempoMap()
+	d = m.Time(ticks)
+
+	if c.release > 0 {
+		d += c.release
+		ticks = m.Ticks(d)
+	}
+
+	return
+}
+
+// SetTrackLength returns a copy of the given SMF where the end of track message of the given track (starting with 0)
+// is at the given ticks, e.g. to pad a short track to the length of the song or to the end of a bar. The end is not
+// moved before the last event of the track. The given SMF is not modified.
+// An error is returned, if the track does not exist.
+func SetTrackLength(s *SMF, track int, ticks uint64) (*SMF, error) {
+	if track < 0 || track >= len(s.tracks) {
+		return nil, fmt.Errorf("track %v out of range [0,%v)", track, len(s.tracks))
+	}
+
+	res := s.clone()
+	res.tracks[track].SetEnd(ticks)
+	return res, nil
+}
+
+// TrimSilence returns a copy of the given SMF without the silence before the first note and the dead space after
+// the last event. The given SMF is not modified.
+//
+// All events are shifted to the left, so that the first note on message of all tracks is at tick 0.
+// The events before it (e.g. tempo, program and controller messages for the setup) are moved to tick 0,
+// keeping their order. The end of each track is moved to its last event, unless a note of the track lasts until
+// the end of track, because it has no note off message.
+// Controller tails after the last note are kept; Slice can be used to cut them off.
+// If there are no notes, only the dead space at the end is removed.
+func TrimSilence(s *SMF) *SMF {
+	var first uint64
+	var hasNotes bool
+
+	for _, tr := range s.tracks {
+		for _, ev := range tr.evs() {
+			if on, is := ev.Message.(channel.NoteOn); is && on.Velocity() > 0 {
+				if !hasNotes || ev.AbsTicks < first {
+					first = ev.AbsTicks
+				}
+				hasNotes = true
+				break
+			}
+		}
+	}
+
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		tr.trimSilence(first)
+	}
+
+	return res
+}
+
+// trimSilence shifts the events of the track by first ticks to the left and moves the end of track to the last event
+func (t *Track) trimSilence(first uint64) {
+	var unfinished bool
+
+	for _, n := range t.Notes() {
+		if n.off < 0 {
+			unfinished = true
+			break
+		}
+	}
+
+	evts := t.Events()
+
+	for i := range evts {
+		if evts[i].AbsTicks < first {
+			evts[i].AbsTicks = 0
+		} else {
+			evts[i].AbsTicks -= first
+		}
+	}
+
+	var end uint64
+
+	switch {
+	case unfinished && t.end > first:
+		end = t.end - first
+	case len(evts) > 0:
+		end = evts[len(evts)-1].AbsTicks
+	}
+
+	t.end = 0
+	t.SetEvents(evts)
+	t.SetEnd(end)
+}
@@ -0,0 +1,179 @@
+package smftrack
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// ccTailSMF returns a SMF with a note from 960 to 1440, a long controller tail until 2880
+// and the end of track at 3840. The tempo changes from 120 BPM to 60 BPM at 1920.
+func ccTailSMF() *SMF {
+	var conductor Track
+	conductor.Add(0, meta.BPM(120))
+	conductor.Add(1920, meta.BPM(60))
+
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, ch.ProgramChange(3), ch.ControlChange(7, 100))
+	tr.Add(480, ch.ControlChange(10, 64))
+	tr.Add(960, ch.NoteOn(60, 100))
+	tr.Add(1440, ch.NoteOff(60))
+
+	for i := 0; i < 4; i++ {
+		tr.Add(uint64(1440+i*480), ch.ControlChange(7, uint8(100-i*20)))
+	}
+
+	tr.SetEnd(3840)
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&tr)
+	return s
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		options  []LengthOption
+		ticks    uint64
+		duration time.Duration
+	}{
+		{nil, 3840, 6 * time.Second},
+		{[]LengthOption{LastEvent()}, 2880, 4 * time.Second},
+		{[]LengthOption{LastNoteOff()}, 1440, 1500 * time.Millisecond},
+		{[]LengthOption{LastNoteOff(), PaddedRelease(250 * time.Millisecond)}, 1680, 1750 * time.Millisecond},
+		// the padding crosses the tempo change
+		{[]LengthOption{LastNoteOff(), PaddedRelease(time.Second)}, 2160, 2500 * time.Millisecond},
+	}
+
+	s := ccTailSMF()
+
+	for i, test := range tests {
+		ticks, d := Length(s, test.options...)
+
+		if ticks != test.ticks || d != test.duration {
+			t.Errorf("[%v] Length() = %v, %v; want %v, %v", i, ticks, d, test.ticks, test.duration)
+		}
+	}
+}
+
+func TestTrimSilence(t *testing.T) {
+	s := ccTailSMF()
+	res := TrimSilence(s)
+
+	expected := []string{
+		`0 meta.Tempo BPM: 120.00
+960 meta.Tempo BPM: 60.00
+960 end
+`,
+		`0 channel.ProgramChange channel 1 program 3
+0 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+0 channel.ControlChange channel 1 controller 10 ("Pan position (MSB)") value 64
+0 channel.NoteOn channel 1 key 60 velocity 100
+480 channel.NoteOff channel 1 key 60
+480 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+960 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 80
+1440 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 60
+1920 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 40
+1920 end
+`,
+	}
+
+	for i, want := range expected {
+		if got := trackString(res.Track(i)); got != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+	}
+
+	// the source is untouched
+	if got, want := s.Track(1).End(), uint64(3840); got != want {
+		t.Errorf("source has been modified: end = %v; want %v", got, want)
+	}
+}
+
+func TestTrimSilenceUnfinished(t *testing.T) {
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(96, ch.NoteOn(60, 100))
+	tr.SetEnd(960)
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	// the note without note off keeps its duration
+	if got, want := trackString(TrimSilence(s).Track(0)), "0 channel.NoteOn channel 1 key 60 velocity 100\n864 end\n"; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestEndAfterTail(t *testing.T) {
+	s := ccTailSMF()
+
+	// a transformation that sets the end before the controller tail
+	s.Track(1).end = 1440
+
+	if got, want := s.Track(1).End(), uint64(2880); got != want {
+		t.Errorf("End() = %v; want %v", got, want)
+	}
+
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res, err := Read(&bf)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := res.Track(1).End(), uint64(2880); got != want {
+		t.Errorf("end of track after writing = %v; want %v", got, want)
+	}
+}
+
+func TestSetTrackLength(t *testing.T) {
+	s := ccTailSMF()
+
+	tests := []struct {
+		track int
+		ticks uint64
+		end   uint64
+	}{
+		// pad the conductor track to the end of the song
+		{0, 3840, 3840},
+		// pad to the end of the bar
+		{1, 5760, 5760},
+		// shorten, but keep the controller tail
+		{1, 2000, 2880},
+		{1, 0, 2880},
+	}
+
+	for i, test := range tests {
+		res, err := SetTrackLength(s, test.track, test.ticks)
+
+		if err != nil {
+			t.Fatalf("[%v] Error: %v", i, err)
+		}
+
+		if got := res.Track(test.track).End(); got != test.end {
+			t.Errorf("[%v] SetTrackLength(%v, %v): end = %v; want %v", i, test.track, test.ticks, got, test.end)
+		}
+	}
+
+	// the source is untouched
+	if got, want := s.Track(0).End(), uint64(1920); got != want {
+		t.Errorf("source has been modified: end = %v; want %v", got, want)
+	}
+
+	for _, track := range []int{-1, 2} {
+		if _, err := SetTrackLength(s, track, 960); err == nil {
+			t.Errorf("SetTrackLength(%v) returned no error", track)
+		}
+	}
+}
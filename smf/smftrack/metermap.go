@@ -0,0 +1,146 @@
+package smftrack
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// MeterChange is a change of the time signature within a MeterMap
+type MeterChange struct {
+	AbsTicks uint64
+	TimeSig  meta.TimeSig
+
+	// Bar is the number of the bar (starting with 0) that starts with the change
+	Bar uint64
+}
+
+// length returns the length of a bar in ticks
+func (c MeterChange) length(ticks4th uint64) uint64 {
+	return uint64(c.TimeSig.Numerator) * 4 * ticks4th / uint64(c.TimeSig.Denominator)
+}
+
+// MeterMap maps ticks to bars and vice versa, based on the time signature messages of a SMF.
+// Until the first time signature message, the time signature is 4/4.
+// A time signature message that is not at the start of a bar ends the bar before it, so that it starts a new bar.
+// For time code based time formats (smf.TimeCode), a quarter note has the ticks of smf.MetricTicks(0).
+type MeterMap struct {
+	ticks4th uint64
+	changes  []MeterChange
+}
+
+// NewMeterMap returns the MeterMap of the time signature messages of the given tracks (see Timeline.MeterMap).
+// Time signature messages at the same tick are applied in the order of the tracks, so that the last one wins.
+// Time signatures with a numerator or denominator of 0 are ignored.
+func NewMeterMap(timeFormat smf.TimeFormat, tracks ...*Track) *MeterMap {
+	return newTimeline(timeFormat, tracks...).MeterMap()
+}
+
+// newMeterMap returns the MeterMap of the given time signature changes that are sorted by their ticks
+func newMeterMap(timeFormat smf.TimeFormat, meters []MeterMark) *MeterMap {
+	ticks4th := uint64(smf.MetricTicks(0).Ticks4th())
+
+	if mt, ok := timeFormat.(smf.MetricTicks); ok && mt > 0 {
+		ticks4th = uint64(mt.Ticks4th())
+	}
+
+	return buildMeterMap(ticks4th, meters)
+}
+
+// buildMeterMap returns the MeterMap of the given time signature changes with the given ticks per quarter note
+func buildMeterMap(ticks4th uint64, meters []MeterMark) *MeterMap {
+	m := &MeterMap{ticks4th: ticks4th}
+	m.changes = []MeterChange{{TimeSig: meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8}}}
+
+	for _, mm := range meters {
+		if mm.TimeSig.Numerator == 0 || mm.TimeSig.Denominator == 0 {
+			continue
+		}
+
+		last := &m.changes[len(m.changes)-1]
+
+		if mm.AbsTicks == last.AbsTicks {
+			last.TimeSig = mm.TimeSig
+			continue
+		}
+
+		c := MeterChange{AbsTicks: mm.AbsTicks, TimeSig: mm.TimeSig}
+		length := last.length(m.ticks4th)
+		c.Bar = last.Bar + 1
+
+		if length > 0 {
+			c.Bar = last.Bar + (c.AbsTicks-last.AbsTicks+length-1)/length
+		}
+
+		m.changes = append(m.changes, c)
+	}
+
+	return m
+}
+
+// MeterMap returns the MeterMap of the SMF, based on the time signature messages of all tracks.
+// Since the tracks of SMF2 are independent, the MeterMap of a single track should be used for SMF2 (see NewMeterMap).
+func (s *SMF) MeterMap() *MeterMap {
+	return NewMeterMap(s.timeFormat, s.tracks...)
+}
+
+// Changes returns the changes of the time signature. The first change is always at tick 0.
+func (m *MeterMap) Changes() []MeterChange {
+	res := make([]MeterChange, len(m.changes))
+	copy(res, m.changes)
+	return res
+}
+
+// MeterAt returns the time signature at the given tick
+func (m *MeterMap) MeterAt(absTicks uint64) meta.TimeSig {
+	return m.changes[m.changeAtTick(absTicks)].TimeSig
+}
+
+// Bar returns the number of the bar (starting with 0) that contains the given tick, and the tick of its start
+func (m *MeterMap) Bar(absTicks uint64) (bar, start uint64) {
+	c := m.changes[m.changeAtTick(absTicks)]
+	length := c.length(m.ticks4th)
+
+	if length == 0 {
+		return c.Bar, c.AbsTicks
+	}
+
+	n := (absTicks - c.AbsTicks) / length
+	return c.Bar + n, c.AbsTicks + n*length
+}
+
+// BarStart returns the tick of the start of the given bar (starting with 0)
+func (m *MeterMap) BarStart(bar uint64) uint64 {
+	i := sort.Search(len(m.changes), func(i int) bool {
+		return m.changes[i].Bar > bar
+	}) - 1
+
+	c := m.changes[i]
+	return c.AbsTicks + (bar-c.Bar)*c.length(m.ticks4th)
+}
+
+// snap returns the start of the bar that is nearest to the given tick.
+// If ceil is true, the start of the next bar is returned for ticks within a bar instead.
+func (m *MeterMap) snap(absTicks uint64, ceil bool) uint64 {
+	bar, start := m.Bar(absTicks)
+
+	if start == absTicks {
+		return start
+	}
+
+	next := m.BarStart(bar + 1)
+
+	if ceil || next-absTicks < absTicks-start {
+		return next
+	}
+
+	return start
+}
+
+// changeAtTick returns the index of the change that is valid at the given tick
+func (m *MeterMap) changeAtTick(absTicks uint64) int {
+	return sort.Search(len(m.changes), func(i int) bool {
+		return m.changes[i].AbsTicks > absTicks
+	}) - 1
+}
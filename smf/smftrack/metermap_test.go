@@ -0,0 +1,51 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestMeterMap(t *testing.T) {
+	var tr Track
+	tr.Add(0, meta.TimeSig{Numerator: 3, Denominator: 4})
+	// not at the start of a bar: the bar before is shortened
+	tr.Add(480, meta.TimeSig{Numerator: 7, Denominator: 8})
+	tr.Add(1320, meta.TimeSig{Numerator: 4, Denominator: 4})
+
+	m := NewMeterMap(smf.MetricTicks(96), &tr)
+
+	tests := []struct {
+		absTicks uint64
+		bar      uint64
+		start    uint64
+		meter    string
+	}{
+		{0, 0, 0, "3/4"},
+		{287, 0, 0, "3/4"},
+		{288, 1, 288, "3/4"},
+		{479, 1, 288, "3/4"},
+		{480, 2, 480, "7/8"},
+		{816, 3, 816, "7/8"},
+		{1319, 4, 1152, "7/8"},
+		{1320, 5, 1320, "4/4"},
+		{2000, 6, 1704, "4/4"},
+	}
+
+	for _, test := range tests {
+		bar, start := m.Bar(test.absTicks)
+
+		if bar != test.bar || start != test.start {
+			t.Errorf("Bar(%v) = %v, %v; want %v, %v", test.absTicks, bar, start, test.bar, test.start)
+		}
+
+		if got, want := m.BarStart(test.bar), test.start; got != want {
+			t.Errorf("BarStart(%v) = %v; want %v", test.bar, got, want)
+		}
+
+		if got, want := m.MeterAt(test.absTicks).Signature(), test.meter; got != want {
+			t.Errorf("MeterAt(%v) = %v; want %v", test.absTicks, got, want)
+		}
+	}
+}
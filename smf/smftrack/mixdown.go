@@ -0,0 +1,206 @@
+package smftrack
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi/smf"
+)
+
+type mixdownConfig struct {
+	offset     uint64
+	offsetBars uint64
+	force      bool
+}
+
+// MixdownOption is an option for Mixdown
+type MixdownOption func(*mixdownConfig)
+
+// MixdownOffset moves the second SMF by the given ticks (in the resolution of the result)
+func MixdownOffset(ticks uint64) MixdownOption {
+	return func(c *mixdownConfig) {
+		c.offset = ticks
+	}
+}
+
+// MixdownOffsetBars moves the second SMF by the given number of bars, according to the time signatures of the
+// first SMF. It is added to the offset of MixdownOffset.
+func MixdownOffsetBars(bars uint64) MixdownOption {
+	return func(c *mixdownConfig) {
+		c.offsetBars = bars
+	}
+}
+
+// MixdownForce keeps the channels of the second SMF, even if they are used by the first SMF.
+func MixdownForce() MixdownOption {
+	return func(c *mixdownConfig) {
+		c.force = true
+	}
+}
+
+// ChannelConflict is a channel that is used by both SMFs of Mixdown with different programs, but has been merged.
+// A program of -1 means that the SMF has no program change on the channel.
+type ChannelConflict struct {
+	Channel  uint8
+	ProgramA int
+	ProgramB int
+}
+
+// String returns a description of the conflict, showing the channel 1-based (1-16)
+func (c ChannelConflict) String() string {
+	return fmt.Sprintf("channel %v is used with program %v and program %v", c.Channel+1, c.ProgramA, c.ProgramB)
+}
+
+// Mixdown overlays the SMF b onto the SMF a and returns the result as SMF format 1. The given SMFs are not modified.
+//
+// Both SMFs are resampled to the higher resolution of them (see Resample) and b is moved by the offset of the
+// MixdownOffset and MixdownOffsetBars options. The tracks of b follow the tracks of a. Tracks of b that contain
+// nothing but conductor messages and the track name, copyright and sequence messages (e.g. its conductor track)
+// are dropped.
+//
+// The channels of b that are used by a are moved to the lowest channels that are used by neither of them, so that
+// the parts don't collide. The drum channel (channel 9) is never moved, and the channels are kept, if there are
+// no free channels left or the MixdownForce option is given. Channels that are used by both SMFs are returned as
+// conflicts, if their initial programs (the first program changes on the channels) differ.
+//
+// The conductor messages of both SMFs are combined in the first track (see ApplyConductor): tempo, time signature
+// and key changes of b are dropped, if a has a change of the same kind at the same tick. Markers and cue points of
+// b follow the ones of a at the same tick. The SMPTE offset of b is only taken, if a has none and b is not moved.
+// Since the conductor messages are rebuilt, they lose their tags, while the other events keep them.
+//
+// An error is returned, if one of the SMFs is of format 2 or does not have a metric time format.
+func Mixdown(a, b *SMF, options ...MixdownOption) (res *SMF, conflicts []ChannelConflict, err error) {
+	var c mixdownConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	var tpq uint16
+
+	for i, s := range []*SMF{a, b} {
+		if s.format == smf.SMF2 {
+			return nil, nil, fmt.Errorf("can't mix down SMF no %v: format 2 is not supported", i)
+		}
+
+		mt, ok := s.timeFormat.(smf.MetricTicks)
+
+		if !ok {
+			return nil, nil, fmt.Errorf("can't mix down SMF no %v: time format %s is not supported", i, s.timeFormat)
+		}
+
+		if mt.Number() > tpq {
+			tpq = mt.Number()
+		}
+	}
+
+	if a, err = Resample(a, tpq); err != nil {
+		return nil, nil, err
+	}
+
+	if b, err = Resample(b, tpq); err != nil {
+		return nil, nil, err
+	}
+
+	offset := c.offset + a.MeterMap().BarStart(c.offsetBars)
+
+	// channels
+	usedA, programsA := a.channelUsage()
+	usedB, programsB := b.channelUsage()
+
+	var taken = usedA
+	var mapping = map[uint8]uint8{}
+
+	for ch := range taken {
+		taken[ch] = taken[ch] || usedB[ch]
+	}
+
+	for ch := uint8(0); ch < 16; ch++ {
+		if !usedA[ch] || !usedB[ch] || c.force || ch == 9 {
+			continue
+		}
+
+		for to := uint8(0); to < 16; to++ {
+			if !taken[to] && to != 9 {
+				mapping[ch] = to
+				taken[to] = true
+				break
+			}
+		}
+	}
+
+	for ch := uint8(0); ch < 16; ch++ {
+		if _, moved := mapping[ch]; !moved && usedA[ch] && usedB[ch] && programsA[ch] != programsB[ch] {
+			conflicts = append(conflicts, ChannelConflict{Channel: ch, ProgramA: programsA[ch], ProgramB: programsB[ch]})
+		}
+	}
+
+	if len(mapping) > 0 {
+		b = RemapChannels(b, mapping)
+	}
+
+	// conductor
+	tl := Conductor(a)
+	tlB := Conductor(b)
+
+	for _, m := range tlB.TempoChanges {
+		if _, n := markRange(len(tl.TempoChanges), m.AbsTicks+offset, func(i int) uint64 { return tl.TempoChanges[i].AbsTicks }); n == 0 {
+			tl.SetTempo(m.AbsTicks+offset, m.Tempo)
+		}
+	}
+
+	for _, m := range tlB.MeterChanges {
+		if _, n := markRange(len(tl.MeterChanges), m.AbsTicks+offset, func(i int) uint64 { return tl.MeterChanges[i].AbsTicks }); n == 0 {
+			tl.SetMeter(m.AbsTicks+offset, m.TimeSig)
+		}
+	}
+
+	for _, m := range tlB.KeyChanges {
+		if _, n := markRange(len(tl.KeyChanges), m.AbsTicks+offset, func(i int) uint64 { return tl.KeyChanges[i].AbsTicks }); n == 0 {
+			tl.SetKey(m.AbsTicks+offset, m.Key)
+		}
+	}
+
+	for _, m := range tlB.Markers {
+		tl.AddMarker(m.AbsTicks+offset, m.Text)
+	}
+
+	for _, m := range tlB.CuePoints {
+		tl.AddCuePoint(m.AbsTicks+offset, m.Text)
+	}
+
+	if tl.SMPTEOffset == nil && offset == 0 {
+		tl.SMPTEOffset = tlB.SMPTEOffset
+	}
+
+	res = New(smf.SMF1, smf.MetricTicks(tpq))
+	res.inheritProvenance(a, b)
+	res.tracks = append(res.tracks, a.tracks...)
+
+	for _, tr := range b.tracks {
+		if !tr.hasPart() {
+			continue
+		}
+
+		nt := &Track{events: make([]Event, len(tr.evs())), end: tr.end + offset}
+
+		for i, ev := range tr.evs() {
+			nt.evs()[i] = Event{AbsTicks: ev.AbsTicks + offset, Message: ev.Message, Tag: ev.Tag}
+		}
+
+		res.tracks = append(res.tracks, nt)
+	}
+
+	res, err = ApplyConductor(res, tl)
+	return res, conflicts, err
+}
+
+// hasPart returns true, if the track has other messages than conductor messages and the track name, copyright and
+// sequence messages
+func (t *Track) hasPart() bool {
+	for _, ev := range t.evs() {
+		if !isConductorMessage(ev.Message) && !isHeaderMessage(ev.Message) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// mixdownFile returns a SMF1 with a conductor track of the given tempo at tick 0 and a track per given channel,
+// each with a program change to the given program (if not negative) and a note.
+func mixdownFile(tpq uint16, bpm uint32, program int8, channels ...uint8) *SMF {
+	var tr0 Track
+	tr0.Add(0, meta.Track("conductor"), meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8}, meta.BPM(bpm))
+
+	s := New(smf.SMF1, smf.MetricTicks(tpq))
+	s.AddTrack(&tr0)
+
+	for _, ch := range channels {
+		var tr Track
+		c := channel.Channel(ch)
+
+		if program >= 0 {
+			tr.Add(0, c.ProgramChange(uint8(program)))
+		}
+
+		tr.Add(uint64(tpq), c.NoteOn(60, 100))
+		tr.Add(uint64(tpq)*2, c.NoteOff(60))
+		s.AddTrack(&tr)
+	}
+
+	return s
+}
+
+func TestMixdown(t *testing.T) {
+	a := mixdownFile(480, 120, 1, 0, 1)
+	b := mixdownFile(960, 90, 5, 0, 2)
+
+	res, conflicts, err := Mixdown(a, b, MixdownOffset(960))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if len(conflicts) != 0 {
+		t.Errorf("conflicts: %v; wanted none", conflicts)
+	}
+
+	if got, want := res.Format(), smf.SMF1; got != want {
+		t.Errorf("Format() = %v; want %v", got, want)
+	}
+
+	if got, want := res.TimeFormat(), smf.MetricTicks(960); got != want {
+		t.Errorf("TimeFormat() = %v; want %v", got, want)
+	}
+
+	// the conductor track of b is dropped, its time signature and tempo are added at the offset
+	if got, want := res.NumTracks(), uint16(5); got != want {
+		t.Fatalf("NumTracks() = %v; want %v", got, want)
+	}
+
+	expected := `0 meta.Track: "conductor"
+0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+0 meta.Tempo BPM: 120.00
+960 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+960 meta.Tempo BPM: 90.00
+960 end
+`
+
+	if got := trackString(res.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	tests := []struct {
+		track    int
+		expected string
+	}{
+		{1, "0 channel.ProgramChange channel 1 program 1\n960 channel.NoteOn channel 1 key 60 velocity 100\n1920 channel.NoteOff channel 1 key 60\n1920 end\n"},
+		{2, "0 channel.ProgramChange channel 2 program 1\n960 channel.NoteOn channel 2 key 60 velocity 100\n1920 channel.NoteOff channel 2 key 60\n1920 end\n"},
+		// channel 0 of b collides with a and is moved to the lowest free channel
+		{3, "960 channel.ProgramChange channel 4 program 5\n1920 channel.NoteOn channel 4 key 60 velocity 100\n2880 channel.NoteOff channel 4 key 60\n2880 end\n"},
+		// channel 2 of b is not used by a
+		{4, "960 channel.ProgramChange channel 3 program 5\n1920 channel.NoteOn channel 3 key 60 velocity 100\n2880 channel.NoteOff channel 3 key 60\n2880 end\n"},
+	}
+
+	for _, test := range tests {
+		if got := trackString(res.Track(test.track)); got != test.expected {
+			t.Errorf("track %v got:\n%s\n\nwanted:\n%s\n\n", test.track, got, test.expected)
+		}
+	}
+
+	// the given SMFs are untouched
+	if got, want := b.Track(1).Event(0).Message, channel.Channel0.ProgramChange(5); got != want || b.TimeFormat() != smf.MetricTicks(960) {
+		t.Errorf("the given SMF has been modified: %v; wanted %v", got, want)
+	}
+}
+
+func TestMixdownDrums(t *testing.T) {
+	a := mixdownFile(960, 120, 0, 9)
+	b := mixdownFile(960, 120, 0, 9)
+
+	res, conflicts, err := Mixdown(a, b)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if len(conflicts) != 0 {
+		t.Errorf("conflicts: %v; wanted none", conflicts)
+	}
+
+	if got, want := res.Track(2).Event(0).Message, channel.Channel9.ProgramChange(0); got != want {
+		t.Errorf("drum track starts with %v; wanted %v", got, want)
+	}
+}
+
+func TestMixdownConflicts(t *testing.T) {
+	all := make([]uint8, 16)
+
+	for i := range all {
+		all[i] = uint8(i)
+	}
+
+	tests := []struct {
+		a, b     *SMF
+		options  []MixdownOption
+		expected []ChannelConflict
+	}{
+		// forced merge of channel 1 with different programs
+		{mixdownFile(960, 120, 3, 1), mixdownFile(960, 120, 7, 1), []MixdownOption{MixdownForce()},
+			[]ChannelConflict{{Channel: 1, ProgramA: 3, ProgramB: 7}}},
+		// forced merge of channel 1 with the same programs
+		{mixdownFile(960, 120, 3, 1), mixdownFile(960, 120, 3, 1), []MixdownOption{MixdownForce()}, nil},
+		// forced merge of channel 1 without program in b
+		{mixdownFile(960, 120, 3, 1), mixdownFile(960, 120, -1, 1), []MixdownOption{MixdownForce()},
+			[]ChannelConflict{{Channel: 1, ProgramA: 3, ProgramB: -1}}},
+		// no free channel left
+		{mixdownFile(960, 120, 3, all...), mixdownFile(960, 120, 4, 2), nil,
+			[]ChannelConflict{{Channel: 2, ProgramA: 3, ProgramB: 4}}},
+		// drums with different programs
+		{mixdownFile(960, 120, 0, 9), mixdownFile(960, 120, 8, 9), nil,
+			[]ChannelConflict{{Channel: 9, ProgramA: 0, ProgramB: 8}}},
+	}
+
+	for i, test := range tests {
+		res, conflicts, err := Mixdown(test.a, test.b, test.options...)
+
+		if err != nil {
+			t.Fatalf("[%v] Error: %v", i, err)
+		}
+
+		if len(conflicts) != len(test.expected) {
+			t.Errorf("[%v] conflicts: %v; wanted %v", i, conflicts, test.expected)
+			continue
+		}
+
+		for j := range conflicts {
+			if conflicts[j] != test.expected[j] {
+				t.Errorf("[%v] conflicts: %v; wanted %v", i, conflicts, test.expected)
+			}
+		}
+
+		// the channels of b are kept
+		last := res.Track(int(res.NumTracks()) - 1)
+
+		if got, want := last.Event(last.Len()-1).Message.(channel.Message).Channel(), test.b.Track(1).Event(test.b.Track(1).Len()-1).Message.(channel.Message).Channel(); got != want {
+			t.Errorf("[%v] channel of b is %v; wanted %v", i, got, want)
+		}
+	}
+
+	if got, want := (ChannelConflict{Channel: 1, ProgramA: 3, ProgramB: 7}).String(), "channel 2 is used with program 3 and program 7"; got != want {
+		t.Errorf("String() = %q; want %q", got, want)
+	}
+}
+
+func TestMixdownConductor(t *testing.T) {
+	var a0, b0 Track
+	a0.Add(0, meta.TimeSig{Numerator: 3, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8}, meta.BPM(120), meta.Marker("a"))
+	a0.Add(2880, meta.Key{Key: 0, Num: 0, IsMajor: true})
+	b0.Add(0, meta.SMPTE{Hour: 1}, meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8}, meta.Key{Key: 2, Num: 2, IsMajor: true}, meta.BPM(90), meta.Marker("b"))
+	b0.Add(960, meta.BPM(100), meta.Cuepoint("cue b"))
+
+	a := New(smf.SMF1, smf.MetricTicks(960))
+	a.AddTrack(&a0)
+	b := New(smf.SMF1, smf.MetricTicks(960))
+	b.AddTrack(&b0)
+
+	// b starts at the second bar of a, where the key of b clashes with the key of a
+	res, _, err := Mixdown(a, b, MixdownOffsetBars(1))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0 meta.TimeSig 3/4 clocksperclick 24 dsqpq 8
+0 meta.Tempo BPM: 120.00
+0 meta.Marker: "a"
+2880 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+2880 meta.Key: C maj.
+2880 meta.Tempo BPM: 90.00
+2880 meta.Marker: "b"
+3840 meta.Tempo BPM: 100.00
+3840 meta.Cuepoint: "cue b"
+3840 end
+`
+
+	if got := trackString(res.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	// without offset, the time signature and tempo of a win at tick 0 and the SMPTE offset of b is taken
+	res, _, err = Mixdown(a, b)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected = `0 meta.SMPTE 1:0:0 0.0
+0 meta.TimeSig 3/4 clocksperclick 24 dsqpq 8
+0 meta.Key: D maj.
+0 meta.Tempo BPM: 120.00
+0 meta.Marker: "a"
+0 meta.Marker: "b"
+960 meta.Tempo BPM: 100.00
+960 meta.Cuepoint: "cue b"
+2880 meta.Key: C maj.
+2880 end
+`
+
+	if got := trackString(res.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+func TestMixdownErrors(t *testing.T) {
+	valid := mixdownFile(960, 120, 0, 0)
+
+	smf2 := New(smf.SMF2, smf.MetricTicks(960))
+	smf2.AddTrack(&Track{})
+
+	timeCode := New(smf.SMF1, smf.SMPTE25(40))
+	timeCode.AddTrack(&Track{})
+
+	tests := []struct {
+		a, b *SMF
+	}{
+		{smf2, valid},
+		{valid, smf2},
+		{timeCode, valid},
+		{valid, timeCode},
+	}
+
+	for i, test := range tests {
+		if _, _, err := Mixdown(test.a, test.b); err == nil {
+			t.Errorf("[%v] expected an error", i)
+		}
+	}
+}
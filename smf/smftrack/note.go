@@ -0,0 +1,167 @@
+package smftrack
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// TrackEvent is an Event together with the number of its track
+type TrackEvent struct {
+	Track int
+	Event
+}
+
+// Merged returns the events of all tracks merged into a single list that is sorted by the absolute ticks.
+// Events at the same tick are ordered by the number of their track, while keeping the order within a track.
+// This only makes sense for SMF format 0 and 1, since the tracks of format 2 have independent timelines.
+func (s *SMF) Merged() []TrackEvent {
+	return slices.Collect(s.All())
+}
+
+// Note is a note of a track, i.e. the interval between a note on message and its corresponding note off message.
+type Note struct {
+	// Track is the number of the track
+	Track int
+
+	Channel  uint8
+	Key      uint8
+	Velocity uint8
+
+	// ReleaseVelocity is the velocity of the note off message, 0 if it is unknown. It is only known for "real"
+	// note off messages that have been read with the NoteOffVelocity option of the smfreader
+	// (see channel.NoteOffVelocity).
+	ReleaseVelocity uint8
+
+	// AbsTicks is the position of the note on message
+	AbsTicks uint64
+
+	// Duration is the distance in ticks between the note on and the note off message.
+	// If there is no note off message, the note lasts until the end of the track.
+	Duration uint64
+
+	// on and off are the indices of the note on and the note off events within the track.
+	// off is -1, if there is no note off message.
+	on, off int
+}
+
+// End returns the position of the end of the note in ticks
+func (n Note) End() uint64 {
+	return n.AbsTicks + n.Duration
+}
+
+// Notes returns the notes of the track, sorted by their start.
+// A note off message (or a note on message with velocity 0) ends the
+// earliest note on the same channel and key that is still sounding.
+func (t *Track) Notes() []Note {
+	return slices.Collect(t.AllNotes())
+}
+
+// Notes returns the notes of all tracks, sorted by their start.
+// Notes that start at the same tick are ordered by the number of their track.
+func (s *SMF) Notes() []Note {
+	return slices.Collect(s.AllNotes())
+}
+
+// SetNotes writes the given notes back to the track: the note on and note off events of each note are
+// changed according to the channel, key, velocity, position, duration and release velocity of the note.
+// A note with a release velocity gets a channel.NoteOffVelocity message (written as "real" note off),
+// a note without keeps the kind of its note off message.
+// Notes without a note off message get one, if their end is before the end of the track. The changed note on and
+// note off events keep their tags, the added note off messages have none.
+//
+// The notes must have been returned by Notes of the same track, and the track must not have been modified since.
+// Written note off messages are placed before the other events at the same tick, so that a following note on
+// the same key is not ended by them.
+// ErrFrozen is returned, if the track is frozen.
+func (t *Track) SetNotes(notes []Note) error {
+	if t.frozen {
+		return ErrFrozen
+	}
+
+	type sortable struct {
+		Event
+		noteOff bool
+	}
+
+	var evts = make([]sortable, len(t.evs()))
+
+	for i, ev := range t.evs() {
+		evts[i] = sortable{Event: ev}
+	}
+
+	for _, n := range notes {
+		if n.on < 0 || n.on >= len(t.evs()) || n.off >= len(t.evs()) {
+			return fmt.Errorf("note %v at %v does not belong to the track", n.Key, n.AbsTicks)
+		}
+
+		if _, is := t.evs()[n.on].Message.(channel.NoteOn); !is {
+			return fmt.Errorf("note %v at %v does not belong to the track", n.Key, n.AbsTicks)
+		}
+
+		ch := channel.Channel(n.Channel)
+		evts[n.on].Event = Event{AbsTicks: n.AbsTicks, Message: ch.NoteOn(n.Key, n.Velocity), Tag: t.evs()[n.on].Tag}
+
+		var off channel.Message = ch.NoteOff(n.Key)
+
+		if n.ReleaseVelocity > 0 {
+			off = ch.NoteOffVelocity(n.Key, n.ReleaseVelocity)
+		}
+
+		if n.off >= 0 {
+			if _, is := t.evs()[n.off].Message.(channel.NoteOffVelocity); is && n.ReleaseVelocity == 0 {
+				off = ch.NoteOffVelocity(n.Key, 0)
+			}
+			// a note off of a note without duration must stay behind its note on
+			evts[n.off] = sortable{Event: Event{AbsTicks: n.End(), Message: off, Tag: t.evs()[n.off].Tag}, noteOff: n.Duration > 0}
+			continue
+		}
+
+		if n.End() < t.end {
+			evts = append(evts, sortable{Event: Event{AbsTicks: n.End(), Message: off}, noteOff: n.Duration > 0})
+		}
+	}
+
+	sort.SliceStable(evts, func(a, b int) bool {
+		if evts[a].AbsTicks != evts[b].AbsTicks {
+			return evts[a].AbsTicks < evts[b].AbsTicks
+		}
+		return evts[a].noteOff && !evts[b].noteOff
+	})
+
+	var res = make([]Event, len(evts))
+
+	for i, ev := range evts {
+		res[i] = ev.Event
+	}
+
+	return t.SetEvents(res)
+}
+
+// SetNotes writes the given notes back to their tracks (see Track.SetNotes).
+// The notes must have been returned by Notes of the SMF, and the SMF must not have been modified since.
+// ErrFrozen is returned, if the SMF is frozen.
+func (s *SMF) SetNotes(notes []Note) error {
+	if s.frozen {
+		return ErrFrozen
+	}
+
+	var byTrack = map[int][]Note{}
+
+	for _, n := range notes {
+		if n.Track < 0 || n.Track >= len(s.tracks) {
+			return fmt.Errorf("track %v does not exist", n.Track)
+		}
+		byTrack[n.Track] = append(byTrack[n.Track], n)
+	}
+
+	for no, ns := range byTrack {
+		if err := s.tracks[no].SetNotes(ns); err != nil {
+			return fmt.Errorf("track %v: %v", no, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package smftrack
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gomidi/midi/smf"
+)
+
+// NudgeTrack returns a copy of the given SMF where the events of the given track are moved by the given offset in time.
+// The offset is converted to ticks based on the TempoMap at the position of each event, so that the offset is the same
+// in time, even across tempo changes. Events that would be moved before the start are placed at tick 0.
+// Tempo, time signature, key, marker, cue point and SMPTE offset messages stay in place.
+// The given SMF is not modified.
+func NudgeTrack(s *SMF, trackIndex int, offset time.Duration) (*SMF, error) {
+	if trackIndex < 0 || trackIndex >= len(s.tracks) {
+		return nil, fmt.Errorf("track %v does not exist", trackIndex)
+	}
+
+	tm := s.TempoMap()
+
+	if s.format == smf.SMF2 {
+		tm = NewTempoMap(s.timeFormat, s.tracks[trackIndex])
+	}
+
+	return s.nudge(trackIndex, func(absTicks uint64) uint64 {
+		return tm.Ticks(tm.Time(absTicks) + offset)
+	})
+}
+
+// NudgeTrackTicks returns a copy of the given SMF where the events of the given track are moved by the given offset
+// in ticks. Events that would be moved before the start are placed at tick 0.
+// Tempo, time signature, key, marker, cue point and SMPTE offset messages stay in place.
+// The given SMF is not modified.
+func NudgeTrackTicks(s *SMF, trackIndex int, offset int64) (*SMF, error) {
+	return s.nudge(trackIndex, func(absTicks uint64) uint64 {
+		if offset < 0 && uint64(-offset) > absTicks {
+			return 0
+		}
+		return uint64(int64(absTicks) + offset)
+	})
+}
+
+func (s *SMF) nudge(trackIndex int, move func(absTicks uint64) uint64) (*SMF, error) {
+	if trackIndex < 0 || trackIndex >= len(s.tracks) {
+		return nil, fmt.Errorf("track %v does not exist", trackIndex)
+	}
+
+	res := s.clone()
+	tr := res.tracks[trackIndex]
+	evts := tr.Events()
+
+	for i, ev := range evts {
+		if isConductorMessage(ev.Message) {
+			continue
+		}
+		evts[i].AbsTicks = move(ev.AbsTicks)
+	}
+
+	tr.SetEvents(evts)
+	return res, nil
+}
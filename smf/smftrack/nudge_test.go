@@ -0,0 +1,114 @@
+package smftrack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func nudgeSMF() *SMF {
+	var conductor, tr Track
+	conductor.Add(0, meta.BPM(120))
+	conductor.Add(1920, meta.BPM(60))
+
+	ch := channel.Channel0
+	tr.Add(10, ch.NoteOn(60, 100))
+	tr.Add(960, meta.Marker("verse"), ch.NoteOff(60))
+	tr.Add(1900, ch.NoteOn(62, 100))
+	tr.Add(1920, ch.NoteOff(62))
+	tr.Add(2880, ch.ControlChange(7, 100))
+	tr.SetEnd(3840)
+
+	s := New(smf.SMF1, smf.MetricTicks(960))
+	s.AddTrack(&conductor)
+	s.AddTrack(&tr)
+	return s
+}
+
+func TestNudgeTrack(t *testing.T) {
+	tests := []struct {
+		offset   time.Duration
+		expected string
+	}{
+		{
+			20 * time.Millisecond,
+			`48 channel.NoteOn channel 1 key 60 velocity 100
+960 meta.Marker: "verse"
+998 channel.NoteOff channel 1 key 60
+1929 channel.NoteOn channel 1 key 62 velocity 100
+1939 channel.NoteOff channel 1 key 62
+2899 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+3840 end
+`,
+		},
+		{
+			-20 * time.Millisecond,
+			`0 channel.NoteOn channel 1 key 60 velocity 100
+922 channel.NoteOff channel 1 key 60
+960 meta.Marker: "verse"
+1862 channel.NoteOn channel 1 key 62 velocity 100
+1882 channel.NoteOff channel 1 key 62
+2861 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+3840 end
+`,
+		},
+	}
+
+	for i, test := range tests {
+		s := nudgeSMF()
+		res, err := NudgeTrack(s, 1, test.offset)
+
+		if err != nil {
+			t.Fatalf("[%v] Error: %v", i, err)
+		}
+
+		if got, want := trackString(res.Track(1)), test.expected; got != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+
+		// the original is untouched
+		if got, want := s.Track(1).Event(0).AbsTicks, uint64(10); got != want {
+			t.Errorf("[%v] AbsTicks = %v; want %v", i, got, want)
+		}
+	}
+
+	s := nudgeSMF()
+	s.format = smf.SMF2
+
+	if _, err := NudgeTrack(s, 2, time.Millisecond); err == nil {
+		t.Errorf("expected error for missing track")
+	}
+}
+
+func TestNudgeTrackTicks(t *testing.T) {
+	res, err := NudgeTrackTicks(nudgeSMF(), 1, -20)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0 channel.NoteOn channel 1 key 60 velocity 100
+940 channel.NoteOff channel 1 key 60
+960 meta.Marker: "verse"
+1880 channel.NoteOn channel 1 key 62 velocity 100
+1900 channel.NoteOff channel 1 key 62
+2860 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+3840 end
+`
+
+	if got, want := trackString(res.Track(1)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	// the conductor track stays in place
+	if got, want := trackString(res.Track(0)), trackString(nudgeSMF().Track(0)); got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if _, err := NudgeTrackTicks(nudgeSMF(), 2, 10); err == nil {
+		t.Errorf("expected error for missing track")
+	}
+}
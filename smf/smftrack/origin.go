@@ -0,0 +1,269 @@
+package smftrack
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// Origin is the software that produced a SMF (see IdentifyOrigin)
+type Origin string
+
+const (
+	OriginUnknown    Origin = "unknown"
+	OriginLogic      Origin = "Logic Pro"
+	OriginCubase     Origin = "Cubase"
+	OriginFLStudio   Origin = "FL Studio"
+	OriginMuseScore  Origin = "MuseScore"
+	OriginPythonMIDI Origin = "python-midi"
+
+	// OriginGomidi is this package, recognized by the signature of the smfwriter.Signature option
+	OriginGomidi Origin = "gomidi"
+)
+
+// OriginRule is a rule of IdentifyOrigin: a structural quirk of the SMFs that are produced by a software
+type OriginRule struct {
+	Origin Origin
+
+	// Quirk describes the quirk, e.g. "division of 96 ticks per quarter note"
+	Quirk string
+
+	// Weight is the confidence (0 to 1) that a SMF with the quirk has been produced by the Origin
+	Weight float64
+
+	// Match returns true, if the SMF has the quirk
+	Match func(*SMF) bool
+}
+
+// originRules are the default rules of IdentifyOrigin. The rules are based on the defaults of the software
+// and are meant to be refined by fixtures of the software.
+var originRules = []OriginRule{
+	{OriginGomidi, "signature of the smfwriter.Signature option", 1, MatchSequencerData(smfwriter.IsSignature)},
+	{OriginGomidi, "division of 960 ticks per quarter note", 0.2, MatchDivision(960)},
+
+	{OriginLogic, "mentions Logic in a text message", 0.6, MatchText("logic")},
+	{OriginLogic, "division of 480 ticks per quarter note", 0.2, MatchDivision(480)},
+	{OriginLogic, "SMPTE offset after the track name of the first track", 0.4, MatchLeadingMetas(meta.Track(""), meta.SMPTE{})},
+
+	{OriginCubase, "mentions Cubase in a text message", 0.6, MatchText("cubase")},
+	{OriginCubase, "sequencer specific data of Steinberg (manufacturer ID 3A)", 0.8, MatchSequencerData(hasPrefix(0x3A))},
+	{OriginCubase, "division of 480 ticks per quarter note", 0.2, MatchDivision(480)},
+	{OriginCubase, "text that is not valid UTF-8 (e.g. Latin-1)", 0.2, MatchInvalidText()},
+
+	{OriginFLStudio, "mentions FL Studio in a text message", 0.6, MatchText("fl studio")},
+	{OriginFLStudio, "division of 96 ticks per quarter note", 0.4, MatchDivision(96)},
+	{OriginFLStudio, "MIDI port message in the first track", 0.2, MatchLeadingMetas(meta.Track(""), meta.Port(0))},
+
+	{OriginMuseScore, "mentions MuseScore in a text message", 0.6, MatchText("musescore")},
+	{OriginMuseScore, "division of 480 ticks per quarter note", 0.2, MatchDivision(480)},
+	{OriginMuseScore, "time signature before the key signature and the tempo", 0.3, MatchLeadingMetas(meta.TimeSig{}, meta.Key{}, meta.Tempo(0))},
+
+	{OriginPythonMIDI, "division of 220 ticks per quarter note", 0.7, MatchDivision(220)},
+	{OriginPythonMIDI, "no running status", 0.3, MatchRunningStatus(false)},
+}
+
+// DefaultOriginRules returns the default rules of IdentifyOrigin
+func DefaultOriginRules() []OriginRule {
+	res := make([]OriginRule, len(originRules))
+	copy(res, originRules)
+	return res
+}
+
+type originConfig struct {
+	rules []OriginRule
+}
+
+// OriginOption is an option for IdentifyOrigin
+type OriginOption func(*originConfig)
+
+// ExtraOriginRules adds the given rules to the rules of IdentifyOrigin, e.g. for other software or quirks that
+// have been found in fixtures.
+func ExtraOriginRules(rules ...OriginRule) OriginOption {
+	return func(c *originConfig) {
+		c.rules = append(c.rules, rules...)
+	}
+}
+
+// IdentifyOrigin guesses the software that produced the given SMF by its structural quirks (see DefaultOriginRules
+// and ExtraOriginRules) and returns the confidence of the guess (0 to 1). This is meant for triage, not for proof.
+//
+// The confidence of an Origin combines the weights of its matching rules like independent evidence,
+// i.e. it is 1 - (1 - w1) * (1 - w2) * ... for the weights w1, w2, ... of the matching rules. The Origin with the
+// highest confidence is returned, for equal confidences the one with the first rule. If no Origin reaches a
+// confidence of 0.5, OriginUnknown and 0 are returned.
+//
+// The running status of the events is only known, if the SMF was read with the smfreader.Preserve option.
+func IdentifyOrigin(s *SMF, options ...OriginOption) (Origin, float64) {
+	c := originConfig{rules: DefaultOriginRules()}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	var (
+		origins []Origin
+		// doubt is the product of (1 - weight) of the matching rules per origin
+		doubt = map[Origin]float64{}
+	)
+
+	for _, r := range c.rules {
+		if _, has := doubt[r.Origin]; !has {
+			origins = append(origins, r.Origin)
+			doubt[r.Origin] = 1
+		}
+
+		if r.Match(s) {
+			doubt[r.Origin] *= 1 - r.Weight
+		}
+	}
+
+	var (
+		best       = OriginUnknown
+		confidence float64
+	)
+
+	for _, o := range origins {
+		if conf := 1 - doubt[o]; conf > confidence {
+			best, confidence = o, conf
+		}
+	}
+
+	if confidence < 0.5 {
+		return OriginUnknown, 0
+	}
+
+	return best, confidence
+}
+
+// MatchDivision returns a function for OriginRule.Match that matches the SMFs with the time format
+// smf.MetricTicks of the given resolution.
+func MatchDivision(ticks uint16) func(*SMF) bool {
+	return func(s *SMF) bool {
+		mt, ok := s.timeFormat.(smf.MetricTicks)
+		return ok && mt.Quarter() == uint32(ticks)
+	}
+}
+
+// MatchText returns a function for OriginRule.Match that matches the SMFs with a text meta message
+// (e.g. text, copyright or track name) that contains the given text, ignoring the case.
+func MatchText(text string) func(*SMF) bool {
+	text = strings.ToLower(text)
+
+	return func(s *SMF) bool {
+		return s.anyEvent(func(ev Event) bool {
+			tm, ok := ev.Message.(interface{ Text() string })
+			return ok && strings.Contains(strings.ToLower(tm.Text()), text)
+		})
+	}
+}
+
+// MatchInvalidText returns a function for OriginRule.Match that matches the SMFs with a text meta message that is
+// not valid UTF-8, i.e. that has been written in another encoding, e.g. Latin-1.
+func MatchInvalidText() func(*SMF) bool {
+	return func(s *SMF) bool {
+		return s.anyEvent(func(ev Event) bool {
+			tm, ok := ev.Message.(interface{ Text() string })
+			return ok && !utf8.ValidString(tm.Text())
+		})
+	}
+}
+
+// MatchSequencerData returns a function for OriginRule.Match that matches the SMFs with a sequencer specific meta
+// message whose data is matched by the given function.
+func MatchSequencerData(match func(data []byte) bool) func(*SMF) bool {
+	return func(s *SMF) bool {
+		return s.anyEvent(func(ev Event) bool {
+			sd, ok := ev.Message.(meta.SequencerData)
+			return ok && match(sd.Data())
+		})
+	}
+}
+
+// hasPrefix returns a function that matches data beginning with the given prefix, e.g. a manufacturer ID
+func hasPrefix(prefix ...byte) func([]byte) bool {
+	return func(data []byte) bool {
+		return bytes.HasPrefix(data, prefix)
+	}
+}
+
+// MatchLeadingMetas returns a function for OriginRule.Match that matches the SMFs where the first track starts
+// with meta messages of the types of the given messages in the given order (their values don't matter).
+func MatchLeadingMetas(types ...midi.Message) func(*SMF) bool {
+	return func(s *SMF) bool {
+		if len(s.tracks) == 0 || s.tracks[0].Len() < len(types) {
+			return false
+		}
+
+		for i, typ := range types {
+			if reflect.TypeOf(s.tracks[0].evs()[i].Message) != reflect.TypeOf(typ) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// MatchRunningStatus returns a function for OriginRule.Match that matches the SMFs that use running status
+// (if used is true) respectively that have channel messages, but don't use running status (if used is false).
+// It only matches SMFs that have been read with the smfreader.Preserve option and have not been modified.
+func MatchRunningStatus(used bool) func(*SMF) bool {
+	return func(s *SMF) bool {
+		var running, channel bool
+
+		for _, tr := range s.tracks {
+			if tr.raw == nil && tr.Len() > 0 {
+				return false
+			}
+
+			for _, raw := range tr.raw {
+				r, c := isRunningStatus(raw)
+				running = running || r
+				channel = channel || c
+			}
+		}
+
+		if used {
+			return running
+		}
+
+		return channel && !running
+	}
+}
+
+// isRunningStatus returns whether the given raw MTrk event is written with running status and whether it is
+// a channel message
+func isRunningStatus(raw []byte) (running, channel bool) {
+	// skip the delta time
+	i := 0
+	for i < len(raw) && raw[i]&0x80 != 0 {
+		i++
+	}
+	i++
+
+	if i >= len(raw) {
+		return false, false
+	}
+
+	status := raw[i]
+	return status < 0x80, status < 0xF0
+}
+
+// anyEvent returns true, if the given function returns true for any event of the SMF
+func (s *SMF) anyEvent(fn func(Event) bool) bool {
+	for _, tr := range s.tracks {
+		for _, ev := range tr.evs() {
+			if fn(ev) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package smftrack
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// originSMF returns a SMF of format 0 with the given division, that starts with the given meta messages,
+// followed by some notes
+func originSMF(ticks uint16, metas ...midi.Message) *SMF {
+	var tr Track
+	tr.Add(0, metas...)
+	tr.Add(0, channel.Channel0.NoteOn(60, 100), channel.Channel0.NoteOn(64, 100))
+	tr.Add(uint64(ticks), channel.Channel0.NoteOff(60), channel.Channel0.NoteOff(64))
+
+	s := New(smf.SMF0, smf.MetricTicks(ticks))
+	s.AddTrack(&tr)
+	return s
+}
+
+// rewrite writes the SMF with the given options and reads it with the smfreader.Preserve option
+func rewrite(t *testing.T, s *SMF, options ...smfwriter.Option) *SMF {
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf, options...); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res, err := Read(&bf, smfreader.Preserve())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	return res
+}
+
+func TestIdentifyOrigin(t *testing.T) {
+	tests := []struct {
+		name       string
+		smf        *SMF
+		origin     Origin
+		confidence float64
+	}{
+		{"signature", rewrite(t, originSMF(480), smfwriter.Signature()), OriginGomidi, 1},
+		{"python-midi", originSMF(220), OriginPythonMIDI, 0.7},
+		{"python-midi without running status", rewrite(t, originSMF(220), smfwriter.NoRunningStatus()), OriginPythonMIDI, 0.79},
+		{"python-midi with running status", rewrite(t, originSMF(220)), OriginPythonMIDI, 0.7},
+		{"Logic", originSMF(480, meta.Track("Inst 1"), meta.SMPTE{Hour: 1}, meta.Text("Logic Pro X")), OriginLogic, 0.808},
+		{"Cubase", originSMF(480, meta.SequencerData{0x3A, 0x01}), OriginCubase, 0.84},
+		{"FL Studio", originSMF(96, meta.Track("Piano"), meta.Port(0)), OriginFLStudio, 0.52},
+		{"MuseScore", originSMF(480, meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8},
+			meta.Key{IsMajor: true}, meta.BPM(120), meta.Copyright("MuseScore 3")), OriginMuseScore, 0.776},
+		// the division is shared by several programs
+		{"unknown", originSMF(480, meta.Track("Piano")), OriginUnknown, 0},
+	}
+
+	for _, test := range tests {
+		origin, confidence := IdentifyOrigin(test.smf)
+
+		if origin != test.origin || math.Abs(confidence-test.confidence) > 1e-9 {
+			t.Errorf("[%s] IdentifyOrigin() = %q, %v; want %q, %v", test.name, origin, confidence, test.origin, test.confidence)
+		}
+	}
+}
+
+func TestExtraOriginRules(t *testing.T) {
+	s := originSMF(384, meta.Track("Sequence 1"))
+
+	if origin, _ := IdentifyOrigin(s); origin != OriginUnknown {
+		t.Errorf("IdentifyOrigin() = %q; want %q", origin, OriginUnknown)
+	}
+
+	const tracker Origin = "tracker"
+
+	origin, confidence := IdentifyOrigin(s, ExtraOriginRules(
+		OriginRule{tracker, "division of 384 ticks per quarter note", 0.4, MatchDivision(384)},
+		OriginRule{tracker, "track named Sequence", 0.5, MatchText("sequence ")},
+	))
+
+	if origin != tracker || math.Abs(confidence-0.7) > 1e-9 {
+		t.Errorf("IdentifyOrigin() = %q, %v; want %q, 0.7", origin, confidence, tracker)
+	}
+}
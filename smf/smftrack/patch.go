@@ -0,0 +1,455 @@
+package smftrack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midireader"
+	"github.com/gomidi/midi/smf"
+)
+
+// ChangeKind is the kind of a Change
+type ChangeKind string
+
+const (
+	// Added is an event that is only part of the modified SMF
+	Added ChangeKind = "add"
+
+	// Removed is an event that is only part of the base SMF
+	Removed ChangeKind = "remove"
+
+	// Changed is an event that is part of both SMFs, but with a different content
+	Changed ChangeKind = "change"
+)
+
+// PatchEvent is the content of an event of a Patch
+type PatchEvent struct {
+	// Message is the raw message in hexadecimal notation, e.g. "90 3C 64". For notes, it is the note on message.
+	Message string `json:"msg"`
+
+	// Duration is the duration of a note in ticks
+	Duration uint64 `json:"duration,omitempty"`
+
+	// NoteOff is the raw note off message of a note. It is empty for notes without note off message.
+	NoteOff string `json:"noteoff,omitempty"`
+}
+
+// Change is an event level change of a Patch. It is keyed by the number of the track, the tick and
+// the identity of the event at that tick.
+//
+// The identity of a note is the interval between its note on and note off message: it consists of the channel and
+// the key of the note (e.g. "note/0/60"), so that a change of the velocity or the length of a note is a change
+// of the note. The identity of other messages consists of the type and the channel, controller or key they address
+// (e.g. "channel.ControlChange/0/7" or "meta.Tempo"), so that a change of the value is a change of the event.
+// Each identity ends with the number of the events with the same identity before it at the same tick (e.g. "#0").
+type Change struct {
+	Kind     ChangeKind `json:"kind"`
+	Track    int        `json:"track"`
+	AbsTicks uint64     `json:"tick"`
+	ID       string     `json:"id"`
+
+	// Old is the event of the base SMF, it is nil for Added
+	Old *PatchEvent `json:"old,omitempty"`
+
+	// New is the event of the modified SMF, it is nil for Removed
+	New *PatchEvent `json:"new,omitempty"`
+}
+
+// Patch describes the differences between two versions of a SMF on the level of events,
+// so that they can be applied to another version (see CreatePatch and ApplyPatch).
+// It can be serialized as JSON.
+type Patch struct {
+	Changes []Change `json:"changes"`
+}
+
+// Conflict is a change of a Patch that has not been applied, since the base SMF has diverged at the key of the change
+type Conflict struct {
+	Change Change
+
+	// Current is the event of the base SMF at the key of the change, it is nil if there is none
+	Current *PatchEvent
+}
+
+// patchKey is the key of an event of a track within a Patch
+type patchKey struct {
+	absTicks uint64
+	id       string
+}
+
+// patchEntity is a note or another event of a track
+type patchEntity struct {
+	PatchEvent
+
+	// on and off are the indices of the events within the track, off is -1, if there is none
+	on, off int
+}
+
+// CreatePatch returns the Patch that turns the base SMF into the modified SMF.
+// The changes are sorted by the number of the track, the tick and the identity of the event.
+// The end of the tracks is not part of the patch.
+func CreatePatch(base, modified *SMF) Patch {
+	var p = Patch{Changes: []Change{}}
+
+	n := len(base.tracks)
+	if len(modified.tracks) > n {
+		n = len(modified.tracks)
+	}
+
+	for no := 0; no < n; no++ {
+		var before, after map[patchKey]patchEntity
+
+		if no < len(base.tracks) {
+			before = base.tracks[no].patchEntities()
+		}
+
+		if no < len(modified.tracks) {
+			after = modified.tracks[no].patchEntities()
+		}
+
+		var changes []Change
+
+		for k, b := range before {
+			old := b.PatchEvent
+			a, has := after[k]
+
+			switch {
+			case !has:
+				changes = append(changes, Change{Kind: Removed, Track: no, AbsTicks: k.absTicks, ID: k.id, Old: &old})
+			case a.PatchEvent != b.PatchEvent:
+				nw := a.PatchEvent
+				changes = append(changes, Change{Kind: Changed, Track: no, AbsTicks: k.absTicks, ID: k.id, Old: &old, New: &nw})
+			}
+		}
+
+		for k, a := range after {
+			if _, has := before[k]; !has {
+				nw := a.PatchEvent
+				changes = append(changes, Change{Kind: Added, Track: no, AbsTicks: k.absTicks, ID: k.id, New: &nw})
+			}
+		}
+
+		sort.Slice(changes, func(a, b int) bool {
+			if changes[a].AbsTicks != changes[b].AbsTicks {
+				return changes[a].AbsTicks < changes[b].AbsTicks
+			}
+			return changes[a].ID < changes[b].ID
+		})
+
+		p.Changes = append(p.Changes, changes...)
+	}
+
+	return p
+}
+
+// ApplyPatch returns a copy of the given base SMF with the changes of the given Patch applied.
+// The given SMF is not modified.
+//
+// A change is a conflict and is not applied, if the base SMF has diverged at its key: if the event that is
+// removed or changed is not the old event of the change, or if an event is added where there already is another one.
+// Changes that are already part of the base SMF are skipped without conflict.
+//
+// Added events are placed after the existing events at the same tick, moved note off messages before them.
+// Tracks that are missing for added events are added, unless the SMF is of format 0.
+// Changed and moved events keep their tags (see Event.Tag), added events have none.
+// An error is returned for invalid changes.
+func ApplyPatch(base *SMF, p Patch) (*SMF, []Conflict, error) {
+	res := base.clone()
+	var byTrack = map[int][]Change{}
+	var tracks []int
+
+	for _, c := range p.Changes {
+		if c.Track < 0 {
+			return nil, nil, fmt.Errorf("invalid track %v in patch", c.Track)
+		}
+
+		if _, has := byTrack[c.Track]; !has {
+			tracks = append(tracks, c.Track)
+		}
+
+		byTrack[c.Track] = append(byTrack[c.Track], c)
+	}
+
+	sort.Ints(tracks)
+	var conflicts []Conflict
+
+	for _, no := range tracks {
+		if no >= len(res.tracks) && res.format == smf.SMF0 {
+			return nil, nil, fmt.Errorf("can't patch track %v of SMF format 0", no)
+		}
+
+		for no >= len(res.tracks) {
+			res.tracks = append(res.tracks, &Track{})
+		}
+
+		c, err := res.tracks[no].applyPatch(byTrack[no])
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't patch track %v: %v", no, err)
+		}
+
+		conflicts = append(conflicts, c...)
+	}
+
+	return res, conflicts, nil
+}
+
+// applyPatch applies the given changes to the track and returns the conflicts
+func (t *Track) applyPatch(changes []Change) (conflicts []Conflict, err error) {
+	entities := t.patchEntities()
+
+	type sortable struct {
+		Event
+		// rank orders the events at the same tick: moved note offs, existing events, added events
+		rank int
+	}
+
+	var (
+		removed  = map[int]bool{}
+		replaced = map[int]midi.Message{}
+		added    []sortable
+	)
+
+	// add adds the message (on) and the note off (off) of the given PatchEvent at the given tick
+	add := func(absTicks uint64, ev *PatchEvent, on, off bool) error {
+		if on {
+			msg, err := decodePatchMessage(ev.Message)
+			if err != nil {
+				return err
+			}
+			added = append(added, sortable{Event{AbsTicks: absTicks, Message: msg}, 2})
+		}
+
+		if off && ev.NoteOff != "" {
+			msg, err := decodePatchMessage(ev.NoteOff)
+			if err != nil {
+				return err
+			}
+			added = append(added, sortable{Event{AbsTicks: absTicks + ev.Duration, Message: msg}, 0})
+		}
+
+		return nil
+	}
+
+	for _, c := range changes {
+		cur, has := entities[patchKey{c.AbsTicks, c.ID}]
+		var current *PatchEvent
+
+		if has {
+			pe := cur.PatchEvent
+			current = &pe
+		}
+
+		if (c.Kind != Removed && c.New == nil) || (c.Kind != Added && c.Old == nil) {
+			return nil, fmt.Errorf("missing event for %s of %s at tick %v", c.Kind, c.ID, c.AbsTicks)
+		}
+
+		switch c.Kind {
+		case Added:
+			if has {
+				if cur.PatchEvent != *c.New {
+					conflicts = append(conflicts, Conflict{Change: c, Current: current})
+				}
+				continue
+			}
+
+			if err := add(c.AbsTicks, c.New, true, true); err != nil {
+				return nil, err
+			}
+		case Removed:
+			if !has {
+				continue
+			}
+
+			if cur.PatchEvent != *c.Old {
+				conflicts = append(conflicts, Conflict{Change: c, Current: current})
+				continue
+			}
+
+			removed[cur.on] = true
+			if cur.off >= 0 {
+				removed[cur.off] = true
+			}
+		case Changed:
+			if has && cur.PatchEvent == *c.New {
+				continue
+			}
+
+			if !has || cur.PatchEvent != *c.Old {
+				conflicts = append(conflicts, Conflict{Change: c, Current: current})
+				continue
+			}
+
+			// the events are replaced in place, the note off is moved, if the length of the note changes
+			if c.New.Message != c.Old.Message {
+				msg, err := decodePatchMessage(c.New.Message)
+				if err != nil {
+					return nil, err
+				}
+				replaced[cur.on] = msg
+			}
+
+			switch {
+			case cur.off >= 0 && c.New.NoteOff != "" && c.New.Duration == c.Old.Duration:
+				if c.New.NoteOff != c.Old.NoteOff {
+					msg, err := decodePatchMessage(c.New.NoteOff)
+					if err != nil {
+						return nil, err
+					}
+					replaced[cur.off] = msg
+				}
+			case cur.off >= 0:
+				removed[cur.off] = true
+				fallthrough
+			default:
+				n := len(added)
+
+				if err := add(c.AbsTicks, c.New, false, true); err != nil {
+					return nil, err
+				}
+
+				// the moved note off keeps its tag
+				if cur.off >= 0 && len(added) > n {
+					added[n].Tag = t.evs()[cur.off].Tag
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown kind of change %q", c.Kind)
+		}
+	}
+
+	var evts []sortable
+
+	for i, ev := range t.evs() {
+		if removed[i] {
+			continue
+		}
+
+		if msg, has := replaced[i]; has {
+			ev.Message = msg
+		}
+
+		evts = append(evts, sortable{ev, 1})
+	}
+
+	evts = append(evts, added...)
+
+	sort.SliceStable(evts, func(a, b int) bool {
+		if evts[a].AbsTicks != evts[b].AbsTicks {
+			return evts[a].AbsTicks < evts[b].AbsTicks
+		}
+		return evts[a].rank < evts[b].rank
+	})
+
+	var res = make([]Event, len(evts))
+
+	for i, ev := range evts {
+		res[i] = ev.Event
+	}
+
+	t.SetEvents(res)
+	return conflicts, nil
+}
+
+// patchEntities returns the notes and the other events of the track by their key
+func (t *Track) patchEntities() map[patchKey]patchEntity {
+	var res = map[patchKey]patchEntity{}
+	var ofNote = map[int]bool{}
+
+	// set adds the entity with the given identity, numbering the entities with the same identity at the same tick
+	set := func(absTicks uint64, id string, e patchEntity) {
+		for n := 0; ; n++ {
+			k := patchKey{absTicks, fmt.Sprintf("%s#%v", id, n)}
+			if _, has := res[k]; !has {
+				res[k] = e
+				return
+			}
+		}
+	}
+
+	for _, n := range t.Notes() {
+		e := patchEntity{on: n.on, off: n.off}
+		e.Message = patchMessage(t.evs()[n.on].Message)
+		ofNote[n.on] = true
+
+		if n.off >= 0 {
+			e.Duration = n.Duration
+			e.NoteOff = patchMessage(t.evs()[n.off].Message)
+			ofNote[n.off] = true
+		}
+
+		set(n.AbsTicks, fmt.Sprintf("note/%v/%v", n.Channel, n.Key), e)
+	}
+
+	for i, ev := range t.evs() {
+		if ofNote[i] {
+			continue
+		}
+
+		e := patchEntity{on: i, off: -1}
+		e.Message = patchMessage(ev.Message)
+		set(ev.AbsTicks, patchIdentity(ev.Message), e)
+	}
+
+	return res
+}
+
+// patchIdentity returns the identity of a message that is not part of a note
+func patchIdentity(msg midi.Message) string {
+	switch v := msg.(type) {
+	case channel.ControlChange:
+		return fmt.Sprintf("%T/%v/%v", v, v.Channel(), v.Controller())
+	case channel.PolyAftertouch:
+		return fmt.Sprintf("%T/%v/%v", v, v.Channel(), v.Key())
+	case channel.NoteOn:
+		return fmt.Sprintf("%T/%v/%v", v, v.Channel(), v.Key())
+	case channel.NoteOff:
+		return fmt.Sprintf("%T/%v/%v", v, v.Channel(), v.Key())
+	case channel.NoteOffVelocity:
+		return fmt.Sprintf("%T/%v/%v", v, v.Channel(), v.Key())
+	case channel.Message:
+		return fmt.Sprintf("%T/%v", v, v.Channel())
+	}
+
+	return fmt.Sprintf("%T", msg)
+}
+
+// patchMessage returns the raw message in hexadecimal notation
+func patchMessage(msg midi.Message) string {
+	return fmt.Sprintf("% X", msg.Raw())
+}
+
+// decodePatchMessage returns the message of the given raw message in hexadecimal notation
+func decodePatchMessage(s string) (midi.Message, error) {
+	raw, err := hex.DecodeString(strings.Replace(s, " ", "", -1))
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid message %q: %v", s, err)
+	}
+
+	if len(raw) > 1 && raw[0] == 0xFF {
+		msg, err := meta.NewReader(bytes.NewReader(raw[2:]), raw[1]).Read()
+		if err != nil {
+			return nil, fmt.Errorf("invalid message %q: %v", s, err)
+		}
+		return msg, nil
+	}
+
+	msg, err := midireader.New(bytes.NewReader(raw), nil, midireader.NoteOffVelocity()).Read()
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid message %q: %v", s, err)
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,218 @@
+package smftrack
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// patchBase returns a SMF with a bar of four quarter notes
+func patchBase() *SMF {
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, meta.BPM(120))
+
+	for i, key := range []uint8{60, 62, 64, 65} {
+		tr.Add(uint64(i*480), ch.NoteOn(key, 100))
+		tr.Add(uint64(i*480+480), ch.NoteOff(key))
+	}
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+	return s
+}
+
+func TestCreatePatch(t *testing.T) {
+	base := patchBase()
+	ch := channel.Channel0
+
+	// the first note is shortened, the tempo is changed and a controller is added
+	modified := base.clone()
+	tr := modified.Track(0)
+	evts := tr.Events()
+	evts[0].Message = meta.BPM(60)
+	evts[2].AbsTicks = 240
+	tr.SetEvents(evts)
+	tr.Add(480, ch.ControlChange(7, 90))
+
+	p := CreatePatch(base, modified)
+
+	expected := []Change{
+		{Kind: Changed, Track: 0, AbsTicks: 0, ID: "meta.Tempo#0",
+			Old: &PatchEvent{Message: "FF 51 03 07 A1 20"},
+			New: &PatchEvent{Message: "FF 51 03 0F 42 40"},
+		},
+		{Kind: Changed, Track: 0, AbsTicks: 0, ID: "note/0/60#0",
+			Old: &PatchEvent{Message: "90 3C 64", Duration: 480, NoteOff: "90 3C 00"},
+			New: &PatchEvent{Message: "90 3C 64", Duration: 240, NoteOff: "90 3C 00"},
+		},
+		{Kind: Added, Track: 0, AbsTicks: 480, ID: "channel.ControlChange/0/7#0",
+			New: &PatchEvent{Message: "B0 07 5A"},
+		},
+	}
+
+	if !reflect.DeepEqual(p.Changes, expected) {
+		t.Errorf("CreatePatch() = %+v; want %+v", p.Changes, expected)
+	}
+
+	// round trip through JSON
+	data, err := json.Marshal(p)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var decoded Patch
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res, conflicts, err := ApplyPatch(base, decoded)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if len(conflicts) != 0 {
+		t.Errorf("ApplyPatch() conflicts = %+v; want none", conflicts)
+	}
+
+	if got, want := trackString(res.Track(0)), trackString(modified.Track(0)); got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	// the base is untouched
+	if got, want := trackString(base.Track(0)), trackString(patchBase().Track(0)); got != want {
+		t.Errorf("base has been modified:\n%s", got)
+	}
+
+	// no changes between equal SMFs
+	if got := CreatePatch(base, base.clone()); len(got.Changes) != 0 {
+		t.Errorf("CreatePatch() of equal SMFs = %+v; want no changes", got.Changes)
+	}
+}
+
+func TestApplyPatchConcurrent(t *testing.T) {
+	base := patchBase()
+	ch := channel.Channel0
+
+	// alice lengthens the first note, raises the velocity of the third note and adds a controller
+	alice := base.clone()
+	evts := alice.Track(0).Events()
+	evts[2].AbsTicks = 720
+	evts[5].Message = ch.NoteOn(64, 127)
+	alice.Track(0).SetEvents(evts)
+	alice.Track(0).Add(480, ch.ControlChange(7, 90))
+
+	// bob shortens the first and the second note and removes the last note
+	bob := base.clone()
+	evts = bob.Track(0).Events()
+	evts[2].AbsTicks = 240
+	evts[4].AbsTicks = 720
+	bob.Track(0).SetEvents(evts[:7])
+
+	res, conflicts, err := ApplyPatch(bob, CreatePatch(base, alice))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the first note has been changed by both
+	if len(conflicts) != 1 {
+		t.Fatalf("ApplyPatch() conflicts = %+v; want 1", conflicts)
+	}
+
+	c := conflicts[0]
+
+	if got, want := c.Change.ID, "note/0/60#0"; got != want {
+		t.Errorf("conflict ID = %q; want %q", got, want)
+	}
+
+	if got, want := *c.Current, (PatchEvent{Message: "90 3C 64", Duration: 240, NoteOff: "90 3C 00"}); got != want {
+		t.Errorf("conflict Current = %+v; want %+v", got, want)
+	}
+
+	expected := `0 meta.Tempo BPM: 120.00
+0 channel.NoteOn channel 1 key 60 velocity 100
+240 channel.NoteOff channel 1 key 60
+480 channel.NoteOn channel 1 key 62 velocity 100
+480 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 90
+720 channel.NoteOff channel 1 key 62
+960 channel.NoteOn channel 1 key 64 velocity 127
+1440 channel.NoteOff channel 1 key 64
+1920 end
+`
+
+	if got := trackString(res.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	// applying the patch again does not change anything and has the same conflict
+	again, conflicts, _ := ApplyPatch(res, CreatePatch(base, alice))
+
+	if got := trackString(again.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	if len(conflicts) != 1 {
+		t.Errorf("ApplyPatch() conflicts = %+v; want 1", conflicts)
+	}
+}
+
+func TestApplyPatchLengthChange(t *testing.T) {
+	// the first note is lengthened to the start of the next note on the same key:
+	// the moved note off must stay before the note on
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, ch.NoteOn(60, 100))
+	tr.Add(480, ch.NoteOff(60))
+	tr.Add(960, ch.NoteOn(60, 100))
+	tr.Add(1440, ch.NoteOff(60))
+	base := New(smf.SMF0, smf.MetricTicks(480))
+	base.AddTrack(&tr)
+
+	p := Patch{Changes: []Change{
+		{Kind: Changed, Track: 0, AbsTicks: 0, ID: "note/0/60#0",
+			Old: &PatchEvent{Message: "90 3C 64", Duration: 480, NoteOff: "90 3C 00"},
+			New: &PatchEvent{Message: "90 3C 64", Duration: 960, NoteOff: "80 3C 40"},
+		},
+	}}
+
+	res, conflicts, err := ApplyPatch(base, p)
+
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("ApplyPatch() = %v, %v; want no conflicts and no error", conflicts, err)
+	}
+
+	expected := `0 channel.NoteOn channel 1 key 60 velocity 100
+960 channel.NoteOffVelocity channel 1 key 60 velocity 64
+960 channel.NoteOn channel 1 key 60 velocity 100
+1440 channel.NoteOff channel 1 key 60
+1440 end
+`
+
+	if got := trackString(res.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	tests := []Patch{
+		{Changes: []Change{{Kind: Added, AbsTicks: 0, ID: "x#0", New: &PatchEvent{Message: "zz"}}}},
+		{Changes: []Change{{Kind: Added, AbsTicks: 0, ID: "x#0", New: &PatchEvent{Message: "90 3C"}}}},
+		{Changes: []Change{{Kind: Added, AbsTicks: 0, ID: "x#0"}}},
+		{Changes: []Change{{Kind: "move", AbsTicks: 0, ID: "x#0", Old: &PatchEvent{}, New: &PatchEvent{}}}},
+		{Changes: []Change{{Kind: Added, Track: 1, AbsTicks: 0, ID: "x#0", New: &PatchEvent{Message: "B0 07 5A"}}}},
+	}
+
+	for i, p := range tests {
+		if _, _, err := ApplyPatch(patchBase(), p); err == nil {
+			t.Errorf("[%v] expected error", i)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+type bendConfig struct {
+	maxDelta int
+}
+
+// BendOption is an option for SmoothPitchBend
+type BendOption func(*bendConfig)
+
+// MaxBendStep sets the maximal change of the value between two successive pitch bend messages that
+// SmoothPitchBend produces. Default is 0, i.e. only the time between the messages is limited.
+func MaxBendStep(delta uint16) BendOption {
+	return func(c *bendConfig) {
+		c.maxDelta = int(delta)
+	}
+}
+
+// bendPoints returns the indices of the pitch bend messages of the given channel in the given track
+func (t *Track) bendPoints(ch uint8) []int {
+	var idx []int
+
+	for i, ev := range t.evs() {
+		if pb, is := ev.Message.(channel.Pitchbend); is && pb.Channel() == ch {
+			idx = append(idx, i)
+		}
+	}
+
+	return idx
+}
+
+// SmoothPitchBend returns a copy of the given SMF, where pitch bend messages are inserted between the successive
+// pitch bend messages of the given channel in each track, so that the value changes linearly from one message to
+// the next one. A step between two messages lasts at most maxStepTicks and changes the value at most by the
+// delta of MaxBendStep (if given). A maxStepTicks of 0 does not limit the time.
+// Inserted messages that would repeat the value before or after them are left out, and there is at most one
+// message per tick.
+//
+// The existing messages are kept, so the first and last value of each bend are preserved exactly.
+// The inserted messages have no tag. The given SMF is not modified.
+// An error is returned for an invalid channel or if neither the time nor the value of a step is limited.
+func SmoothPitchBend(s *SMF, ch uint8, maxStepTicks uint32, options ...BendOption) (*SMF, error) {
+	if ch > 15 {
+		return nil, fmt.Errorf("invalid channel %v", ch)
+	}
+
+	var c bendConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	if maxStepTicks == 0 && c.maxDelta == 0 {
+		return nil, fmt.Errorf("neither the ticks nor the value of a step are limited")
+	}
+
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		points := tr.bendPoints(ch)
+
+		if len(points) < 2 {
+			continue
+		}
+
+		evts := tr.evs()
+
+		for i := 1; i < len(points); i++ {
+			a, b := tr.evs()[points[i-1]], tr.evs()[points[i]]
+			evts = appendBendSteps(evts, ch, a, b, uint64(maxStepTicks), c.maxDelta)
+		}
+
+		tr.SetEvents(evts)
+	}
+
+	return res, nil
+}
+
+// appendBendSteps appends the pitch bend messages that interpolate between a and b to evts
+func appendBendSteps(evts []Event, ch uint8, a, b Event, maxTicks uint64, maxDelta int) []Event {
+	dt := b.AbsTicks - a.AbsTicks
+	v0, v1 := int(a.Message.(channel.Pitchbend).Value()), int(b.Message.(channel.Pitchbend).Value())
+	dv := v1 - v0
+
+	if dv == 0 || dt < 2 {
+		return evts
+	}
+
+	var n uint64 = 1
+
+	if maxTicks > 0 {
+		n = (dt + maxTicks - 1) / maxTicks
+	}
+
+	if maxDelta > 0 {
+		if m := uint64((abs(dv) + maxDelta - 1) / maxDelta); m > n {
+			n = m
+		}
+	}
+
+	if n > dt {
+		n = dt
+	}
+
+	lastTick, lastValue := a.AbsTicks, v0
+
+	for k := uint64(1); k < n; k++ {
+		x := float64(k) / float64(n)
+		tick := a.AbsTicks + uint64(math.Round(x*float64(dt)))
+		value := v0 + int(math.Round(x*float64(dv)))
+
+		if tick == lastTick || value == lastValue || value == v1 {
+			continue
+		}
+
+		evts = append(evts, Event{AbsTicks: tick, Message: channel.Channel(ch).Pitchbend(int16(value))})
+		lastTick, lastValue = tick, value
+	}
+
+	return evts
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// SimplifyPitchBend returns a copy of the given SMF with less pitch bend messages of the given channel. In each
+// track the course of the pitch bend is reduced with the Douglas-Peucker algorithm: a message is dropped, if the
+// value of the line between the kept messages around it differs at most by the given tolerance from its value.
+// The first and the last pitch bend message of each track are always kept.
+//
+// The dropped messages are meant to be restored by SmoothPitchBend, which follows the lines between the kept
+// messages; without it a bend becomes a sequence of jumps. The given SMF is not modified.
+// An error is returned for an invalid channel.
+func SimplifyPitchBend(s *SMF, ch uint8, tolerance uint16) (*SMF, error) {
+	if ch > 15 {
+		return nil, fmt.Errorf("invalid channel %v", ch)
+	}
+
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		points := tr.bendPoints(ch)
+
+		if len(points) < 3 {
+			continue
+		}
+
+		keep := make([]bool, len(points))
+		keep[0], keep[len(points)-1] = true, true
+
+		// the ranges of points that are still to be reduced
+		var ranges = [][2]int{{0, len(points) - 1}}
+
+		for len(ranges) > 0 {
+			from, to := ranges[len(ranges)-1][0], ranges[len(ranges)-1][1]
+			ranges = ranges[:len(ranges)-1]
+
+			farthest, dist := -1, float64(tolerance)
+
+			for i := from + 1; i < to; i++ {
+				if d := tr.bendDistance(points[i], points[from], points[to]); d > dist {
+					farthest, dist = i, d
+				}
+			}
+
+			if farthest < 0 {
+				continue
+			}
+
+			keep[farthest] = true
+			ranges = append(ranges, [2]int{from, farthest}, [2]int{farthest, to})
+		}
+
+		var drop = map[int]bool{}
+
+		for i, p := range points {
+			if !keep[i] {
+				drop[p] = true
+			}
+		}
+
+		evts := make([]Event, 0, len(tr.evs())-len(drop))
+
+		for i, ev := range tr.evs() {
+			if !drop[i] {
+				evts = append(evts, ev)
+			}
+		}
+
+		tr.SetEvents(evts)
+	}
+
+	return res, nil
+}
+
+// bendDistance returns the difference between the pitch bend value of the event i and the value of the line
+// between the events a and b at its tick
+func (t *Track) bendDistance(i, a, b int) float64 {
+	p, pa, pb := t.evs()[i], t.evs()[a], t.evs()[b]
+	va := float64(pa.Message.(channel.Pitchbend).Value())
+	vb := float64(pb.Message.(channel.Pitchbend).Value())
+	line := vb
+
+	if pb.AbsTicks > pa.AbsTicks {
+		line = va + (vb-va)*float64(p.AbsTicks-pa.AbsTicks)/float64(pb.AbsTicks-pa.AbsTicks)
+	}
+
+	return math.Abs(float64(p.Message.(channel.Pitchbend).Value()) - line)
+}
@@ -0,0 +1,186 @@
+package smftrack
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+// bendValues returns the pitch bend values of the given channel by tick
+func bendValues(tr *Track, ch uint8) (ticks []uint64, values []int16) {
+	for _, ev := range tr.Events() {
+		if pb, is := ev.Message.(channel.Pitchbend); is && pb.Channel() == ch {
+			ticks = append(ticks, ev.AbsTicks)
+			values = append(values, pb.Value())
+		}
+	}
+
+	return
+}
+
+// bendAt returns the pitch bend value that lasts at the given tick
+func bendAt(ticks []uint64, values []int16, tick uint64) (value int16) {
+	for i, t := range ticks {
+		if t > tick {
+			break
+		}
+		value = values[i]
+	}
+
+	return
+}
+
+func TestSmoothPitchBend(t *testing.T) {
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, ch.Pitchbend(0), channel.Channel1.Pitchbend(0))
+	tr.Add(50, ch.NoteOn(60, 100))
+	tr.Add(100, ch.Pitchbend(1000), channel.Channel1.Pitchbend(1000))
+	tr.Add(101, ch.Pitchbend(-1000))
+	tr.Add(200, ch.Pitchbend(-1000), ch.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	tests := []struct {
+		maxTicks uint32
+		options  []BendOption
+		expected string
+	}{
+		{
+			25, nil,
+			`0 channel.Pitchbend channel 1 value 0 absValue 0
+0 channel.Pitchbend channel 2 value 0 absValue 0
+25 channel.Pitchbend channel 1 value 250 absValue 0
+50 channel.NoteOn channel 1 key 60 velocity 100
+50 channel.Pitchbend channel 1 value 500 absValue 0
+75 channel.Pitchbend channel 1 value 750 absValue 0
+100 channel.Pitchbend channel 1 value 1000 absValue 0
+100 channel.Pitchbend channel 2 value 1000 absValue 0
+101 channel.Pitchbend channel 1 value -1000 absValue 0
+200 channel.Pitchbend channel 1 value -1000 absValue 0
+200 channel.NoteOff channel 1 key 60
+200 end
+`,
+		},
+		{
+			0, []BendOption{MaxBendStep(400)},
+			`0 channel.Pitchbend channel 1 value 0 absValue 0
+0 channel.Pitchbend channel 2 value 0 absValue 0
+33 channel.Pitchbend channel 1 value 333 absValue 0
+50 channel.NoteOn channel 1 key 60 velocity 100
+67 channel.Pitchbend channel 1 value 667 absValue 0
+100 channel.Pitchbend channel 1 value 1000 absValue 0
+100 channel.Pitchbend channel 2 value 1000 absValue 0
+101 channel.Pitchbend channel 1 value -1000 absValue 0
+200 channel.Pitchbend channel 1 value -1000 absValue 0
+200 channel.NoteOff channel 1 key 60
+200 end
+`,
+		},
+	}
+
+	for _, test := range tests {
+		res, err := SmoothPitchBend(s, 0, test.maxTicks, test.options...)
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		if got, want := trackString(res.Track(0)), test.expected; got != want {
+			t.Errorf("SmoothPitchBend(%v):\ngot:\n%s\n\nwanted:\n%s\n\n", test.maxTicks, got, want)
+		}
+	}
+
+	if got, want := len(tr.Events()), 8; got != want {
+		t.Errorf("SmoothPitchBend modified the given SMF: %v events; want %v", got, want)
+	}
+
+	// one tick steps with a small change of the value: no message repeats the value
+	var slow Track
+	slow.Add(0, ch.Pitchbend(0))
+	slow.Add(100, ch.Pitchbend(10))
+	s = New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&slow)
+	res, _ := SmoothPitchBend(s, 0, 1)
+
+	if got, want := res.Track(0).Len(), 11; got != want {
+		t.Errorf("SmoothPitchBend(1) of a change by 10 in 100 ticks produced %v messages; want %v", got, want)
+	}
+
+	if _, err := SmoothPitchBend(s, 0, 0); err == nil {
+		t.Errorf("SmoothPitchBend() without limits returned no error")
+	}
+
+	if _, err := SmoothPitchBend(s, 16, 10); err == nil {
+		t.Errorf("SmoothPitchBend() of channel 16 returned no error")
+	}
+}
+
+func TestSimplifyPitchBend(t *testing.T) {
+	var tr Track
+	ch := channel.Channel2
+
+	// a vibrato of 2 periods with a message every tick, then a plateau and a jump back to 0
+	for i := 0; i < 200; i++ {
+		tr.Add(uint64(i), ch.Pitchbend(int16(math.Round(2000*math.Sin(float64(i)*math.Pi/50)))))
+	}
+
+	for i := 200; i < 300; i += 10 {
+		tr.Add(uint64(i), ch.Pitchbend(-1))
+	}
+
+	tr.Add(300, ch.Pitchbend(0), ch.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+	ticks, values := bendValues(&tr, 2)
+
+	tests := []struct {
+		tolerance uint16
+		maxCount  int
+	}{
+		{0, 200},
+		{20, 60},
+		{100, 30},
+		{1000, 15},
+	}
+
+	for _, test := range tests {
+		res, err := SimplifyPitchBend(s, 2, test.tolerance)
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		sticks, svalues := bendValues(res.Track(0), 2)
+
+		if len(sticks) > test.maxCount {
+			t.Errorf("SimplifyPitchBend(%v) kept %v of %v messages; want at most %v", test.tolerance, len(sticks), len(ticks), test.maxCount)
+		}
+
+		if sticks[0] != 0 || svalues[0] != values[0] || sticks[len(sticks)-1] != 300 || svalues[len(svalues)-1] != 0 {
+			t.Errorf("SimplifyPitchBend(%v) did not keep the first and last message", test.tolerance)
+		}
+
+		if got, want := res.Track(0).Len(), len(sticks)+1; got != want {
+			t.Errorf("SimplifyPitchBend(%v) left %v events; want %v", test.tolerance, got, want)
+		}
+
+		// smoothing restores the course within the tolerance
+		smoothed, _ := SmoothPitchBend(res, 2, 1)
+		rticks, rvalues := bendValues(smoothed.Track(0), 2)
+
+		for i, tick := range ticks {
+			if d := math.Abs(float64(bendAt(rticks, rvalues, tick)) - float64(values[i])); d > float64(test.tolerance) {
+				t.Errorf("SimplifyPitchBend(%v): restored value at %v differs by %v", test.tolerance, tick, d)
+			}
+		}
+	}
+
+	if _, err := SimplifyPitchBend(s, 16, 10); err == nil {
+		t.Errorf("SimplifyPitchBend() of channel 16 returned no error")
+	}
+}
@@ -0,0 +1,66 @@
+package smftrack
+
+import (
+	"bytes"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// isFirstTrackOnly returns true for the meta messages, that may only be placed in the first track of a SMF1 (see meta.FirstTrackOnly)
+func isFirstTrackOnly(msg midi.Message) bool {
+	mm, is := msg.(meta.Message)
+	return is && meta.FirstTrackOnly(mm)
+}
+
+// MoveFirstTrackMetas returns a copy of the given SMF where the Time Signature, Key Signature, SMPTE Offset, Marker
+// and Cue Point messages of all but the first track are moved to the first track, keeping their absolute ticks.
+// Messages that already exist in the first track at the same tick are dropped.
+// Since the SMF specification only allows these messages in the first track of a format 1 SMF, only SMF1 is
+// affected; for other formats a copy of the SMF is returned.
+// The given SMF is not modified.
+func MoveFirstTrackMetas(s *SMF) *SMF {
+	res := s.clone()
+
+	if res.format != smf.SMF1 || len(res.tracks) < 2 {
+		return res
+	}
+
+	first := res.tracks[0]
+
+	exists := func(ev Event) bool {
+		for _, e := range first.evs() {
+			if e.AbsTicks == ev.AbsTicks && bytes.Equal(e.Message.Raw(), ev.Message.Raw()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, tr := range res.tracks[1:] {
+		var kept, moved []Event
+
+		for _, ev := range tr.evs() {
+			if isFirstTrackOnly(ev.Message) {
+				moved = append(moved, ev)
+			} else {
+				kept = append(kept, ev)
+			}
+		}
+
+		if len(moved) == 0 {
+			continue
+		}
+
+		tr.SetEvents(kept)
+
+		for _, ev := range moved {
+			if !exists(ev) {
+				first.SetEvents(append(first.Events(), ev))
+			}
+		}
+	}
+
+	return res
+}
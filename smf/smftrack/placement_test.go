@@ -0,0 +1,102 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/meta/meter"
+	"github.com/gomidi/midi/smf"
+)
+
+func misplacedMetas() *SMF {
+	var conductor, bass, drums Track
+	conductor.Add(0, meter.Meter(4, 4), meta.BPM(120))
+	conductor.SetEnd(3840)
+
+	bass.Add(0, meta.Track("bass"), channel.Channel1.NoteOn(36, 100))
+	bass.Add(480, channel.Channel1.NoteOff(36))
+	bass.Add(1920, meta.Marker("verse"), meter.Meter(3, 4))
+	bass.SetEnd(3840)
+
+	drums.Add(0, meter.Meter(4, 4), channel.Channel9.NoteOn(36, 100))
+	drums.Add(10, channel.Channel9.NoteOff(36))
+
+	s := New(smf.SMF1, smf.MetricTicks(960))
+	s.AddTrack(&conductor)
+	s.AddTrack(&bass)
+	s.AddTrack(&drums)
+	return s
+}
+
+func TestValidateMetaPlacement(t *testing.T) {
+	s := misplacedMetas()
+	problems := Validate(s)
+
+	expected := []string{
+		`track 1 at tick 1920: meta.Marker: "verse" is only allowed in the first track (meta-placement)`,
+		`track 1 at tick 1920: meta.TimeSig 3/4 clocksperclick 8 dsqpq 8 is only allowed in the first track (meta-placement)`,
+		`track 2 at tick 0: meta.TimeSig 4/4 clocksperclick 8 dsqpq 8 is only allowed in the first track (meta-placement)`,
+	}
+
+	if got, want := len(problems), len(expected); got != want {
+		t.Fatalf("len(Validate()) = %v; want %v: %v", got, want, problems)
+	}
+
+	for i, p := range problems {
+		if got, want := p.String(), expected[i]; got != want {
+			t.Errorf("Validate()[%v] = %q; want %q", i, got, want)
+		}
+	}
+
+	if got, want := problems[1].Event, 4; got != want {
+		t.Errorf("Event = %v; want %v", got, want)
+	}
+
+	if got := Validate(MoveFirstTrackMetas(s)); len(got) != 0 {
+		t.Errorf("Validate(MoveFirstTrackMetas()) = %v; want none", got)
+	}
+}
+
+func TestMoveFirstTrackMetas(t *testing.T) {
+	s := misplacedMetas()
+	res := MoveFirstTrackMetas(s)
+
+	expected := []string{
+		`0 meta.TimeSig 4/4 clocksperclick 8 dsqpq 8
+0 meta.Tempo BPM: 120.00
+1920 meta.Marker: "verse"
+1920 meta.TimeSig 3/4 clocksperclick 8 dsqpq 8
+3840 end
+`,
+		`0 meta.Track: "bass"
+0 channel.NoteOn channel 2 key 36 velocity 100
+480 channel.NoteOff channel 2 key 36
+3840 end
+`,
+		`0 channel.NoteOn channel 10 key 36 velocity 100
+10 channel.NoteOff channel 10 key 36
+10 end
+`,
+	}
+
+	for i, want := range expected {
+		if got := trackString(res.Track(i)); got != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+	}
+
+	// the original is untouched
+	if got, want := s.Track(1).Len(), 5; got != want {
+		t.Errorf("Len() = %v; want %v", got, want)
+	}
+
+	// other formats are not affected
+	s0 := New(smf.SMF2, nil)
+	s0.AddTrack(s.Track(0))
+	s0.AddTrack(s.Track(1))
+
+	if got, want := MoveFirstTrackMetas(s0).Track(1).Len(), 5; got != want {
+		t.Errorf("Len() = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+package smftrack
+
+import (
+	"strconv"
+
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+// Ports returns the names of the ports that the tracks of the given SMF are routed to by device (port) name messages,
+// in the order of their first appearance (see Merged). Obsolete MIDI port messages are named by their number (e.g. "1").
+func Ports(s *SMF) []string {
+	var names []string
+	var seen = map[string]bool{}
+
+	for _, ev := range s.Merged() {
+		var name string
+
+		switch v := ev.Message.(type) {
+		case meta.Device:
+			name = v.Text()
+		case meta.Port:
+			name = strconv.Itoa(int(v.Number()))
+		default:
+			continue
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,212 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Position is a musical position within a MeterMap: a bar, a beat within the bar and the ticks after the start of
+// the beat. The beats are counted in the note values of the denominator of the time signature, e.g. in eighths
+// for 7/8.
+type Position struct {
+	// Bar is the number of the bar, starting with 1 (see FirstBar)
+	Bar int64
+
+	// Beat is the number of the beat within the bar, starting with 1
+	Beat uint32
+
+	// Tick is the number of ticks after the start of the beat
+	Tick uint64
+
+	// beatTicks is the length of the beat in ticks, if the position has been returned by a MeterMap
+	beatTicks uint64
+}
+
+// String returns the position with the fraction of the beat, e.g. "bar 17 beat 3.50". The fraction is truncated,
+// so that a position before a beat never shows that beat. The length of the beat is unknown for a position that has
+// not been returned by a MeterMap, so its ticks are shown separately, e.g. "bar 17 beat 3 tick 240".
+func (p Position) String() string {
+	switch {
+	case p.beatTicks > 0:
+		beat := float64(p.Beat) + math.Floor(float64(p.Tick)*100/float64(p.beatTicks))/100
+		return fmt.Sprintf("bar %v beat %.2f", p.Bar, beat)
+	case p.Tick > 0:
+		return fmt.Sprintf("bar %v beat %v tick %v", p.Bar, p.Beat, p.Tick)
+	default:
+		return fmt.Sprintf("bar %v beat %v", p.Bar, p.Beat)
+	}
+}
+
+// Short returns the position as bar, beat and ticks, separated by dots, e.g. "17.3.240" (see ParsePosition)
+func (p Position) Short() string {
+	return fmt.Sprintf("%v.%v.%v", p.Bar, p.Beat, p.Tick)
+}
+
+// ParsePosition parses a position of the form bar.beat.ticks (see Position.Short), e.g. "17.3.240".
+// The beat and the ticks may be left out, e.g. "17.3" or "17". Bars may be negative (see FirstBar),
+// beats start with 1.
+func ParsePosition(s string) (p Position, err error) {
+	parts := strings.Split(strings.TrimSpace(s), ".")
+
+	if len(parts) > 3 {
+		return p, fmt.Errorf("invalid position %q: want bar.beat.ticks", s)
+	}
+
+	p.Beat = 1
+
+	if p.Bar, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return p, fmt.Errorf("invalid bar in position %q", s)
+	}
+
+	if len(parts) > 1 {
+		beat, err := strconv.ParseUint(parts[1], 10, 32)
+
+		if err != nil || beat == 0 {
+			return p, fmt.Errorf("invalid beat in position %q", s)
+		}
+
+		p.Beat = uint32(beat)
+	}
+
+	if len(parts) > 2 {
+		if p.Tick, err = strconv.ParseUint(parts[2], 10, 64); err != nil {
+			return p, fmt.Errorf("invalid ticks in position %q", s)
+		}
+	}
+
+	return p, nil
+}
+
+type positionConfig struct {
+	firstBar int64
+	pickup   uint64
+}
+
+// PositionOption is an option for the positions of a MeterMap
+type PositionOption func(*positionConfig)
+
+// FirstBar sets the number of the first bar. Default is 1. With a pickup bar that has its own time signature,
+// FirstBar(0) numbers the pickup bar 0 and the first full bar 1. Negative numbers are allowed, e.g. FirstBar(-1)
+// for two bars of count-in before bar 1.
+func FirstBar(n int64) PositionOption {
+	return func(c *positionConfig) {
+		c.firstBar = n
+	}
+}
+
+// Pickup sets the length of a pickup bar in ticks for SMFs that start with an incomplete bar without its own time
+// signature: the bars are counted from the end of the pickup bar, which gets the number before the first bar
+// (see FirstBar). The pickup bar is the end of a bar of the first time signature, so that its beats are numbered
+// like the last beats of a full bar. Time signature changes within the pickup bar are ignored.
+func Pickup(ticks uint64) PositionOption {
+	return func(c *positionConfig) {
+		c.pickup = ticks
+	}
+}
+
+// positions returns the configuration of the given options and the MeterMap whose bar 0 is the first bar
+func (m *MeterMap) positions(options []PositionOption) (positionConfig, *MeterMap) {
+	c := positionConfig{firstBar: 1}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	if c.pickup == 0 {
+		return c, m
+	}
+
+	// the bars are counted from the end of the pickup bar
+	marks := []MeterMark{{TimeSig: m.MeterAt(c.pickup)}}
+
+	for _, ch := range m.changes {
+		if ch.AbsTicks > c.pickup {
+			marks = append(marks, MeterMark{AbsTicks: ch.AbsTicks - c.pickup, TimeSig: ch.TimeSig})
+		}
+	}
+
+	return c, buildMeterMap(m.ticks4th, marks)
+}
+
+// beatLength returns the length of a beat of the given change in ticks
+func (c MeterChange) beatLength(ticks4th uint64) uint64 {
+	if c.TimeSig.Denominator == 0 {
+		return 0
+	}
+	return 4 * ticks4th / uint64(c.TimeSig.Denominator)
+}
+
+// position returns the position at the given offset from the start of a bar of the given change
+func (c MeterChange) position(bar int64, offset, ticks4th uint64) Position {
+	p := Position{Bar: bar, Beat: 1, Tick: offset, beatTicks: c.beatLength(ticks4th)}
+
+	if p.beatTicks > 0 {
+		p.Beat, p.Tick = uint32(offset/p.beatTicks)+1, offset%p.beatTicks
+	}
+
+	return p
+}
+
+// Position returns the position of the given tick
+func (m *MeterMap) Position(absTicks uint64, options ...PositionOption) Position {
+	c, grid := m.positions(options)
+
+	if absTicks < c.pickup {
+		first := m.changes[0]
+		offset := absTicks
+
+		if length := first.length(m.ticks4th); length > c.pickup {
+			offset += length - c.pickup
+		}
+
+		return first.position(c.firstBar-1, offset, m.ticks4th)
+	}
+
+	absTicks -= c.pickup
+	bar, start := grid.Bar(absTicks)
+	return grid.changes[grid.changeAtTick(absTicks)].position(c.firstBar+int64(bar), absTicks-start, m.ticks4th)
+}
+
+// PositionTicks returns the tick of the given position, which must be given with the same options as for Position.
+// An error is returned for a bar before the first bar (respectively the pickup bar), a beat beyond the bar or more
+// ticks than the length of the beat.
+func (m *MeterMap) PositionTicks(p Position, options ...PositionOption) (uint64, error) {
+	c, grid := m.positions(options)
+
+	var ch MeterChange
+	var start, shift uint64
+
+	switch {
+	case c.pickup > 0 && p.Bar == c.firstBar-1:
+		ch = m.changes[0]
+
+		if length := ch.length(m.ticks4th); length > c.pickup {
+			shift = length - c.pickup
+		}
+	case p.Bar < c.firstBar:
+		return 0, fmt.Errorf("bar %v is before the first bar %v", p.Bar, c.firstBar)
+	default:
+		start = grid.BarStart(uint64(p.Bar-c.firstBar)) + c.pickup
+		ch = grid.changes[grid.changeAtTick(start-c.pickup)]
+	}
+
+	beatLength := ch.beatLength(m.ticks4th)
+
+	if p.Beat == 0 || (beatLength > 0 && p.Beat > uint32(ch.TimeSig.Numerator)) {
+		return 0, fmt.Errorf("beat %v is not within bar %v of %v/%v", p.Beat, p.Bar, ch.TimeSig.Numerator, ch.TimeSig.Denominator)
+	}
+
+	if beatLength > 0 && p.Tick >= beatLength {
+		return 0, fmt.Errorf("tick %v is not within a beat of %v ticks", p.Tick, beatLength)
+	}
+
+	offset := uint64(p.Beat-1)*beatLength + p.Tick
+
+	if offset < shift {
+		return 0, fmt.Errorf("position %s is before the pickup bar", p.Short())
+	}
+
+	return start + offset - shift, nil
+}
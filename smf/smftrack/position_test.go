@@ -0,0 +1,183 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// meterSMF returns a SMF1 with a track of the given time signatures
+func meterSMF(timeSigs ...MeterMark) *SMF {
+	var tr Track
+
+	for _, m := range timeSigs {
+		tr.Add(m.AbsTicks, m.TimeSig)
+	}
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+	return s
+}
+
+func timeSig(num, denom uint8) meta.TimeSig {
+	return meta.TimeSig{Numerator: num, Denominator: denom, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8}
+}
+
+func TestPosition(t *testing.T) {
+	changing := meterSMF(MeterMark{0, timeSig(4, 4)}, MeterMark{3840, timeSig(3, 4)}, MeterMark{6720, timeSig(7, 8)})
+	pickup := meterSMF(MeterMark{0, timeSig(1, 4)}, MeterMark{480, timeSig(4, 4)})
+	plain := meterSMF(MeterMark{2400, timeSig(3, 4)})
+
+	tests := []struct {
+		s       *SMF
+		options []PositionOption
+		ticks   []uint64
+		golden  string
+	}{
+		{
+			changing,
+			nil,
+			[]uint64{0, 240, 1919, 3840, 6719, 6720, 9960},
+			`0 bar 1 beat 1.00 1.1.0
+240 bar 1 beat 1.50 1.1.240
+1919 bar 1 beat 4.99 1.4.479
+3840 bar 3 beat 1.00 3.1.0
+6719 bar 4 beat 3.99 4.3.479
+6720 bar 5 beat 1.00 5.1.0
+9960 bar 6 beat 7.50 6.7.120
+`,
+		},
+		{
+			// a pickup bar with its own time signature
+			pickup,
+			[]PositionOption{FirstBar(0)},
+			[]uint64{0, 240, 480, 2400},
+			`0 bar 0 beat 1.00 0.1.0
+240 bar 0 beat 1.50 0.1.240
+480 bar 1 beat 1.00 1.1.0
+2400 bar 2 beat 1.00 2.1.0
+`,
+		},
+		{
+			// count-in bars
+			changing,
+			[]PositionOption{FirstBar(-1)},
+			[]uint64{0, 1920, 3840, 6720},
+			`0 bar -1 beat 1.00 -1.1.0
+1920 bar 0 beat 1.00 0.1.0
+3840 bar 1 beat 1.00 1.1.0
+6720 bar 3 beat 1.00 3.1.0
+`,
+		},
+		{
+			// a pickup bar of a quarter without its own time signature
+			plain,
+			[]PositionOption{Pickup(480)},
+			[]uint64{0, 240, 480, 2399, 2400, 3840},
+			`0 bar 0 beat 4.00 0.4.0
+240 bar 0 beat 4.50 0.4.240
+480 bar 1 beat 1.00 1.1.0
+2399 bar 1 beat 4.99 1.4.479
+2400 bar 2 beat 1.00 2.1.0
+3840 bar 3 beat 1.00 3.1.0
+`,
+		},
+	}
+
+	for i, test := range tests {
+		m := test.s.MeterMap()
+		var bf bytes.Buffer
+
+		for _, tick := range test.ticks {
+			pos := m.Position(tick, test.options...)
+			fmt.Fprintf(&bf, "%v %s %s\n", tick, pos, pos.Short())
+
+			// the ticks of the position and of the parsed position are the ticks again
+			parsed, err := ParsePosition(pos.Short())
+
+			if err != nil {
+				t.Fatalf("[%v] ParsePosition(%q) = %v", i, pos.Short(), err)
+			}
+
+			for _, p := range []Position{pos, parsed} {
+				if got, err := m.PositionTicks(p, test.options...); err != nil || got != tick {
+					t.Errorf("[%v] PositionTicks(%s) = %v, %v; want %v", i, p.Short(), got, err, tick)
+				}
+			}
+		}
+
+		if got, want := bf.String(), test.golden; got != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+	}
+}
+
+func TestPositionTicksErrors(t *testing.T) {
+	m := meterSMF(MeterMark{0, timeSig(4, 4)}, MeterMark{3840, timeSig(3, 4)}).MeterMap()
+
+	tests := []struct {
+		pos     string
+		options []PositionOption
+	}{
+		{"0.1.0", nil},
+		{"3.4.0", nil},
+		{"1.1.480", nil},
+		{"1.5", nil},
+		// the pickup bar is the last beat of 4/4
+		{"0.3.0", []PositionOption{Pickup(480)}},
+		{"-1.4.0", []PositionOption{Pickup(480)}},
+	}
+
+	for _, test := range tests {
+		pos, err := ParsePosition(test.pos)
+
+		if err != nil {
+			t.Fatalf("ParsePosition(%q) = %v", test.pos, err)
+		}
+
+		if got, err := m.PositionTicks(pos, test.options...); err == nil {
+			t.Errorf("PositionTicks(%s) = %v; want error", test.pos, got)
+		}
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"17.3.240", "bar 17 beat 3 tick 240"},
+		{" 17.3 ", "bar 17 beat 3"},
+		{"17", "bar 17 beat 1"},
+		{"-2.1.0", "bar -2 beat 1"},
+		{"17.0", ""},
+		{"17.3.240.1", ""},
+		{"x.1", ""},
+		{"1.x", ""},
+		{"1.1.-5", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		pos, err := ParsePosition(test.text)
+
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("ParsePosition(%q) = %s; want error", test.text, pos)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParsePosition(%q) = %v", test.text, err)
+			continue
+		}
+
+		if got, want := pos.String(), test.expected; got != want {
+			t.Errorf("ParsePosition(%q) = %q; want %q", test.text, got, want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package smftrack
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+type prerollConfig struct {
+	order smf.OrderPolicy
+}
+
+// PrerollOption is an option for PrerollPrograms
+type PrerollOption func(*prerollConfig)
+
+// PrerollOrder sets the order policy by which a moved group is placed among the events at its new tick.
+// Default is smf.Safe.
+func PrerollOrder(p smf.OrderPolicy) PrerollOption {
+	return func(c *prerollConfig) {
+		c.order = p
+	}
+}
+
+// ShortPreroll is a group of bank select and program change messages that PrerollPrograms could not move
+// by the full lead
+type ShortPreroll struct {
+	Track   int
+	Channel uint8
+
+	// AbsTicks is the tick of the group before it was moved
+	AbsTicks uint64
+
+	// Moved is the number of ticks by which the group was moved
+	Moved uint64
+}
+
+// String returns a description of the group, showing the channel 1-based (1-16)
+func (p ShortPreroll) String() string {
+	return fmt.Sprintf("program change of channel %v in track %v at tick %v moved by %v ticks only", p.Channel+1, p.Track, p.AbsTicks, p.Moved)
+}
+
+// PrerollPrograms returns a copy of the given SMF, where each program change is moved earlier by up to
+// leadTicks, so that the patch is already set, when the following notes are played. A group of a program change and
+// the bank select messages (controllers 0 and 32) before it at the same tick and channel is moved as a whole,
+// keeping its order. The given SMF is not modified.
+//
+// A group is not moved before tick 0 or before a note off message of its channel. It is also not moved to the tick
+// of a note on, bank select or program change message before it on its channel, so that these messages keep the
+// old patch. At its new tick, the group is placed after the note off messages of its channel and before the first
+// event of a class that comes after bank select messages in the order policy (see PrerollOrder). The groups that could not be moved by the full lead are returned.
+func PrerollPrograms(s *SMF, leadTicks uint32, options ...PrerollOption) (res *SMF, short []ShortPreroll) {
+	c := prerollConfig{order: smf.Safe}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	res = s.clone()
+
+	for ti, tr := range res.tracks {
+		evts := tr.Events()
+		groupOf, n := programGroups(evts)
+
+		if n == 0 {
+			continue
+		}
+
+		for g := 0; g < n; g++ {
+			var from, moved uint64
+			var ch uint8
+			evts, groupOf, from, moved, ch = c.preroll(evts, groupOf, g, uint64(leadTicks))
+
+			if moved < uint64(leadTicks) {
+				short = append(short, ShortPreroll{Track: ti, Channel: ch, AbsTicks: from, Moved: moved})
+			}
+		}
+
+		tr.SetEvents(evts)
+	}
+
+	return
+}
+
+// programGroups returns the group of bank select and program change messages of each event (-1 for events that
+// are not part of a group) and the number of groups. The groups are numbered in the order of their program changes.
+func programGroups(evts []Event) (groupOf []int, n int) {
+	groupOf = make([]int, len(evts))
+
+	// the bank select messages at the tick of the last one for each channel
+	var banks [16][]int
+	var bankTicks [16]uint64
+
+	for i, ev := range evts {
+		groupOf[i] = -1
+
+		switch v := ev.Message.(type) {
+		case channel.ControlChange:
+			if v.Controller() != 0 && v.Controller() != 32 {
+				continue
+			}
+
+			ch := v.Channel()
+
+			if len(banks[ch]) > 0 && bankTicks[ch] != ev.AbsTicks {
+				banks[ch] = nil
+			}
+
+			banks[ch] = append(banks[ch], i)
+			bankTicks[ch] = ev.AbsTicks
+		case channel.ProgramChange:
+			ch := v.Channel()
+
+			if bankTicks[ch] == ev.AbsTicks {
+				for _, b := range banks[ch] {
+					groupOf[b] = n
+				}
+			}
+
+			banks[ch] = nil
+			groupOf[i] = n
+			n++
+		}
+	}
+
+	return
+}
+
+// preroll moves the group g within evts and returns the events, the groups of the events, the tick of the group
+// before the move, the ticks it was moved by and its channel
+func (c prerollConfig) preroll(evts []Event, groupOf []int, g int, lead uint64) ([]Event, []int, uint64, uint64, uint8) {
+	first := -1
+
+	for i := range evts {
+		if groupOf[i] == g {
+			first = i
+			break
+		}
+	}
+
+	tick := evts[first].AbsTicks
+	ch := evts[first].Message.(channel.Message).Channel()
+
+	// the earliest tick the group may be moved to and the index of the last note off message at that tick
+	var limit uint64
+	var noteOff = -1
+	var found bool
+
+	for i := first - 1; i >= 0 && evts[i].AbsTicks+1 > limit; i-- {
+		msg, is := evts[i].Message.(channel.Message)
+
+		if !is || msg.Channel() != ch {
+			continue
+		}
+
+		switch smf.ClassOf(msg) {
+		case smf.NoteOffs:
+			if !found || evts[i].AbsTicks > limit {
+				limit, noteOff, found = evts[i].AbsTicks, i, true
+			}
+		case smf.NoteOns, smf.BankSelects, smf.ProgramChanges:
+			if !found || evts[i].AbsTicks+1 > limit {
+				limit, noteOff, found = evts[i].AbsTicks+1, -1, true
+			}
+		}
+	}
+
+	var target uint64
+
+	if tick > lead {
+		target = tick - lead
+	}
+
+	if limit > target {
+		target = limit
+	}
+
+	if target >= tick {
+		return evts, groupOf, tick, 0, ch
+	}
+
+	var group []Event
+	var restEvts = make([]Event, 0, len(evts))
+	var restGroups = make([]int, 0, len(evts))
+
+	for i, ev := range evts {
+		if groupOf[i] == g {
+			group = append(group, ev)
+			continue
+		}
+
+		restEvts = append(restEvts, ev)
+		restGroups = append(restGroups, groupOf[i])
+	}
+
+	rank := c.rank(group[0])
+	pos := 0
+
+	// the events before the group keep their indices
+	if noteOff >= 0 && evts[noteOff].AbsTicks == target {
+		pos = noteOff + 1
+	}
+
+	for pos < len(restEvts) && (restEvts[pos].AbsTicks < target || (restEvts[pos].AbsTicks == target && c.rank(restEvts[pos]) <= rank)) {
+		pos++
+	}
+
+	var resEvts = make([]Event, 0, len(evts))
+	var resGroups = make([]int, 0, len(evts))
+
+	resEvts = append(resEvts, restEvts[:pos]...)
+	resGroups = append(resGroups, restGroups[:pos]...)
+
+	for _, ev := range group {
+		ev.AbsTicks = target
+		resEvts = append(resEvts, ev)
+		resGroups = append(resGroups, g)
+	}
+
+	resEvts = append(resEvts, restEvts[pos:]...)
+	resGroups = append(resGroups, restGroups[pos:]...)
+
+	return resEvts, resGroups, tick, tick - target, ch
+}
+
+// rank returns the position of the class of the message of the given event within the order policy
+func (c prerollConfig) rank(ev Event) int {
+	class := smf.ClassOf(ev.Message)
+
+	for i, cl := range c.order.Classes {
+		if cl == class {
+			return i
+		}
+	}
+
+	return len(c.order.Classes)
+}
@@ -0,0 +1,103 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+// prerollSMF returns a SMF with patch changes of channel 0 between phrases, some of them back to back,
+// and a part on channel 1
+func prerollSMF() *SMF {
+	var tr Track
+	ch0, ch1 := channel.Channel0, channel.Channel1
+	tr.Add(0, ch0.ProgramChange(1), ch0.NoteOn(60, 100))
+	tr.Add(480, ch0.NoteOff(60))
+	tr.Add(840, ch0.ControlChange(7, 100))
+	tr.Add(900, ch1.NoteOn(48, 100))
+	tr.Add(960, ch0.ControlChange(0, 0), ch0.ControlChange(32, 1), ch0.ProgramChange(5), ch0.NoteOn(62, 100))
+	tr.Add(1000, ch1.NoteOff(48))
+	tr.Add(1440, ch0.NoteOff(62))
+	tr.Add(1450, ch0.ControlChange(0, 0), ch0.ProgramChange(7), ch0.NoteOn(64, 100))
+	tr.Add(1700, ch1.ProgramChange(3), ch1.NoteOn(50, 100))
+	tr.Add(1920, ch0.NoteOff(64), ch0.ProgramChange(9), ch0.NoteOn(65, 100), ch1.NoteOff(50))
+	tr.SetEnd(2400)
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+	return s
+}
+
+func TestPrerollPrograms(t *testing.T) {
+	s := prerollSMF()
+	before := trackString(s.Track(0))
+	res, short := PrerollPrograms(s, 120)
+
+	// the group at 1450 only moves to the note off at 1440, the one at 1920 directly follows a note off
+	expected := `0 channel.ProgramChange channel 1 program 1
+0 channel.NoteOn channel 1 key 60 velocity 100
+480 channel.NoteOff channel 1 key 60
+840 channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 0
+840 channel.ControlChange channel 1 controller 32 ("Bank Select (LSB)") value 1
+840 channel.ProgramChange channel 1 program 5
+840 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+900 channel.NoteOn channel 2 key 48 velocity 100
+960 channel.NoteOn channel 1 key 62 velocity 100
+1000 channel.NoteOff channel 2 key 48
+1440 channel.NoteOff channel 1 key 62
+1440 channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 0
+1440 channel.ProgramChange channel 1 program 7
+1450 channel.NoteOn channel 1 key 64 velocity 100
+1580 channel.ProgramChange channel 2 program 3
+1700 channel.NoteOn channel 2 key 50 velocity 100
+1920 channel.NoteOff channel 1 key 64
+1920 channel.ProgramChange channel 1 program 9
+1920 channel.NoteOn channel 1 key 65 velocity 100
+1920 channel.NoteOff channel 2 key 50
+2400 end
+`
+
+	if got, want := trackString(res.Track(0)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	var bf bytes.Buffer
+
+	for _, p := range short {
+		fmt.Fprintln(&bf, p)
+	}
+
+	expected = `program change of channel 1 in track 0 at tick 0 moved by 0 ticks only
+program change of channel 1 in track 0 at tick 1450 moved by 10 ticks only
+program change of channel 1 in track 0 at tick 1920 moved by 0 ticks only
+`
+
+	if got, want := bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if got := trackString(s.Track(0)); got != before {
+		t.Errorf("PrerollPrograms() modified the given SMF")
+	}
+
+	// without order policy the group follows the events at its new tick
+	res, _ = PrerollPrograms(s, 120, PrerollOrder(smf.PreserveInput))
+	var got bytes.Buffer
+
+	for _, ev := range res.Track(0).Events()[3:7] {
+		fmt.Fprintf(&got, "%v %s\n", ev.AbsTicks, ev.Message)
+	}
+
+	expected = `840 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+840 channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 0
+840 channel.ControlChange channel 1 controller 32 ("Bank Select (LSB)") value 1
+840 channel.ProgramChange channel 1 program 5
+`
+
+	if got, want := got.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
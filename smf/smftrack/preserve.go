@@ -0,0 +1,180 @@
+package smftrack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// writeChunks writes the SMF without writer options: the header and the cached chunks of the tracks (see
+// Track.chunk), so that only the modified tracks are encoded. If the SMF has been read with the smfreader.Preserve
+// option, the preserved raw data is written for the unmodified header and tracks.
+func (s *SMF) writeChunks(dest io.Writer) error {
+	if err := s.checkTrackCount(); err != nil {
+		return err
+	}
+
+	var bf bytes.Buffer
+
+	if s.preserved != nil && s.Header() == s.preserved.header {
+		bf.Write(s.preserved.raw)
+	} else if err := smfwriter.New(&bf, s.writerOptions(nil)...).WriteHeader(); err != nil {
+		return err
+	}
+
+	for _, tr := range s.tracks {
+		chunk, err := tr.chunk()
+
+		if err != nil {
+			return err
+		}
+
+		bf.Write(tr.prefix)
+		bf.Write(chunk)
+	}
+
+	if s.preserved != nil {
+		bf.Write(s.preserved.trailer)
+	}
+
+	_, err := dest.Write(bf.Bytes())
+	return err
+}
+
+// Strip returns a copy of the given SMF without the garbage that has been kept by the smfreader.Preserve option,
+// i.e. the data after the end of track messages, as well as data between and after the chunks that are not
+// well formed chunks. Unknown chunks are kept. After Strip, Write produces data that can be read without the
+// smfreader.Tolerant option.
+func Strip(s *SMF) *SMF {
+	res := s.clone()
+
+	if res.preserved != nil {
+		p := *res.preserved
+		if !isChunks(p.trailer) {
+			p.trailer = nil
+		}
+		res.preserved = &p
+	}
+
+	for _, tr := range res.tracks {
+		if !isChunks(tr.prefix) {
+			tr.prefix = nil
+		}
+
+		n := len(tr.raw)
+
+		if n == 0 {
+			continue
+		}
+
+		raw := make([][]byte, n)
+		copy(raw, tr.raw)
+		raw[n-1] = stripEndOfTrack(raw[n-1])
+		tr.raw = raw
+		tr.encoded.Store(nil)
+	}
+
+	return res
+}
+
+// stripEndOfTrack removes everything after the end of track message from the given raw end of track
+func stripEndOfTrack(raw []byte) []byte {
+	// skip the delta time
+	i := 0
+	for i < len(raw) && raw[i]&0x80 != 0 {
+		i++
+	}
+
+	if end := i + 4; end < len(raw) {
+		return raw[:end]
+	}
+
+	return raw
+}
+
+// isChunks returns true, if the given data consists of complete chunks only
+func isChunks(data []byte) bool {
+	for len(data) > 0 {
+		if len(data) < 8 || !smf.IsChunkType(data[:4]) {
+			return false
+		}
+
+		end := 8 + int(binary.BigEndian.Uint32(data[4:8]))
+
+		if len(data) < end {
+			return false
+		}
+
+		data = data[end:]
+	}
+
+	return true
+}
+
+// Difference is a region where the written data differs from the original data
+type Difference struct {
+	// Offset is the position of the first differing byte
+	Offset int
+
+	// Original and Written are the differing bytes of the original and the written data
+	Original, Written []byte
+}
+
+// VerifyRoundTrip reads the SMF data from r with the smfreader.Preserve option, writes it again and compares
+// the result with the original data. It returns true, if both are identical. Otherwise the differences are returned,
+// beginning with the first divergent offset.
+// An error is returned, if the data can't be read or written.
+func VerifyRoundTrip(r io.Reader) (identical bool, diffs []Difference, err error) {
+	orig, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return false, nil, err
+	}
+
+	s, err := Read(bytes.NewReader(orig), smfreader.Preserve())
+
+	if err != nil {
+		return false, nil, err
+	}
+
+	var bf bytes.Buffer
+
+	if err = s.Write(&bf); err != nil {
+		return false, nil, err
+	}
+
+	diffs = compareBytes(orig, bf.Bytes())
+	return len(diffs) == 0, diffs, nil
+}
+
+// compareBytes returns the regions where a and b differ
+func compareBytes(a, b []byte) (diffs []Difference) {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+
+	for i := 0; i < n; i++ {
+		if a[i] == b[i] {
+			continue
+		}
+
+		start := i
+		for i < n && a[i] != b[i] {
+			i++
+		}
+
+		diffs = append(diffs, Difference{Offset: start, Original: a[start:i], Written: b[start:i]})
+	}
+
+	if len(a) != len(b) {
+		diffs = append(diffs, Difference{Offset: n, Original: a[n:], Written: b[n:]})
+	}
+
+	return
+}
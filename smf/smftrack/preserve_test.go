@@ -0,0 +1,170 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+func TestVerifyRoundTripCorpus(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "roundtrip", "*.mid"))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if len(files) == 0 {
+		t.Fatalf("no fixtures found")
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		ok, diffs, err := VerifyRoundTrip(f)
+		f.Close()
+
+		if err != nil {
+			t.Errorf("%s: Error: %v", file, err)
+			continue
+		}
+
+		if !ok {
+			t.Errorf("%s: round trip differs at offset %v: % X vs % X", file, diffs[0].Offset, diffs[0].Original, diffs[0].Written)
+		}
+	}
+}
+
+func TestPreserveModifiedTrack(t *testing.T) {
+	orig, err := ioutil.ReadFile(filepath.Join("testdata", "roundtrip", "quirks.mid"))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	s, err := Read(bytes.NewReader(orig))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var bf bytes.Buffer
+	s.Write(&bf)
+
+	// without preservation, the quirks are lost
+	if bytes.Equal(bf.Bytes(), orig) {
+		t.Errorf("expected differences without the Preserve option")
+	}
+
+	s, err = Read(bytes.NewReader(orig), smfreader.Preserve())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	s.Track(1).Add(500, channel.Channel1.ProgramChange(6))
+
+	bf.Reset()
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	diffs := compareBytes(orig, bf.Bytes())
+
+	// the first track and the unknown chunks before the second track are unchanged
+	second := strings.Index(string(orig), "XFKM") + 8
+
+	if len(diffs) == 0 || diffs[0].Offset < second {
+		t.Errorf("first difference at %v; want at or after %v", diffs, second)
+	}
+
+	if !bytes.HasSuffix(bf.Bytes(), []byte("XTRL\x00\x00\x00\x08trailing")) {
+		t.Errorf("trailing chunk is missing")
+	}
+
+	res, err := Read(bytes.NewReader(bf.Bytes()))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := fmt.Sprint(res.Track(1).Event(res.Track(1).Len()-1).Message), "channel.ProgramChange channel 2 program 6"; got != want {
+		t.Errorf("last event = %v; want %v", got, want)
+	}
+}
+
+func TestStrip(t *testing.T) {
+	var orig []byte
+	orig = append(orig, examples.SpecSMF1[:42]...)
+	// a redundant end of track within the declared length of the first track
+	orig = append(orig, 0x00, 0xFF, 0x2F, 0x00)
+	// garbage before the second track
+	orig = append(orig, 0x12, 0x34)
+	orig = append(orig, examples.SpecSMF1[42:]...)
+	// garbage at the end
+	orig = append(orig, 0x56)
+	orig[21] += 4
+
+	if _, err := Read(bytes.NewReader(orig), smfreader.Policy(smf.Strict)); err == nil {
+		t.Fatalf("expected error with smf.Strict")
+	}
+
+	s, err := Read(bytes.NewReader(orig), smfreader.Tolerant(), smfreader.Preserve())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := len(s.Warnings()), 2; got != want {
+		t.Errorf("len(Warnings()) = %v; want %v: %v", got, want, s.Warnings())
+	}
+
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if !bytes.Equal(bf.Bytes(), orig) {
+		t.Errorf("the preserved garbage is missing")
+	}
+
+	bf.Reset()
+
+	if err := Strip(s).Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := bf.Bytes(), examples.SpecSMF1; !bytes.Equal(got, want) {
+		t.Errorf("got:\n% X\n\nwanted:\n% X\n\n", got, want)
+	}
+
+	// the input is not modified
+	bf.Reset()
+	s.Write(&bf)
+
+	if !bytes.Equal(bf.Bytes(), orig) {
+		t.Errorf("Strip modified its input")
+	}
+}
+
+func TestCompareBytes(t *testing.T) {
+	diffs := compareBytes([]byte{1, 2, 3, 4, 5}, []byte{1, 9, 9, 4})
+
+	if got, want := fmt.Sprintf("%v", diffs), "[{1 [2 3] [9 9]} {4 [5] []}]"; got != want {
+		t.Errorf("compareBytes() = %s; want %s", got, want)
+	}
+}
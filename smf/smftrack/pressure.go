@@ -0,0 +1,70 @@
+package smftrack
+
+import (
+	"reflect"
+
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// PolyToChannelPressure returns a copy of the given SMF where the polyphonic aftertouch messages are replaced by
+// channel aftertouch messages, combining the pressures of the sounding keys according to reduce
+// (see channel.NewPolyToChannelPressure). Each track is converted on its own. The given SMF is not modified.
+// A channel aftertouch message has the tag of the polyphonic aftertouch message it results from, the channel
+// aftertouch messages that follow the release of a key have none.
+func PolyToChannelPressure(s *SMF, reduce channel.Reduce) *SMF {
+	return convertPressure(s, func() *channel.PressureConverter {
+		return channel.NewPolyToChannelPressure(reduce)
+	})
+}
+
+// ChannelToPolyPressure returns a copy of the given SMF where the channel aftertouch messages are replaced by
+// polyphonic aftertouch messages for the keys that are sounding on the channel (see channel.NewChannelToPolyPressure).
+// Each track is converted on its own. The given SMF is not modified.
+// The polyphonic aftertouch messages have the tag of the channel aftertouch message they result from, the ones
+// that are added for a note on message have none.
+func ChannelToPolyPressure(s *SMF) *SMF {
+	return convertPressure(s, func() *channel.PressureConverter {
+		return channel.NewChannelToPolyPressure(nil)
+	})
+}
+
+func convertPressure(s *SMF, newConverter func() *channel.PressureConverter) *SMF {
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		c := newConverter()
+		var evts []Event
+
+		// changed is true, if a message has been replaced, dropped or added
+		var changed bool
+
+		for _, ev := range tr.evs() {
+			_, isPoly := ev.Message.(channel.PolyAftertouch)
+			_, isChannel := ev.Message.(channel.Aftertouch)
+
+			msgs := c.Convert(ev.Message)
+
+			// messages that are not converted are passed through as they are
+			if len(msgs) != 1 || reflect.TypeOf(msgs[0]) != reflect.TypeOf(ev.Message) {
+				changed = true
+			}
+
+			for _, msg := range msgs {
+				tag := ev.Tag
+
+				// the aftertouch messages that are added for a note message have no tag
+				if !isPoly && !isChannel && reflect.TypeOf(msg) != reflect.TypeOf(ev.Message) {
+					tag = 0
+				}
+
+				evts = append(evts, Event{AbsTicks: ev.AbsTicks, Message: msg, Tag: tag})
+			}
+		}
+
+		if changed {
+			tr.SetEvents(evts)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,95 @@
+package smftrack
+
+import (
+	"io"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestPressureConversion(t *testing.T) {
+	ch := channel.Channel0
+	var tr Track
+	tr.Add(0, ch.NoteOn(60, 100), ch.NoteOn(64, 100))
+	tr.Add(10, ch.PolyAftertouch(60, 40), ch.PolyAftertouch(64, 80))
+	tr.Add(20, ch.NoteOff(64))
+	tr.Add(30, ch.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+	before := trackString(s.Track(0))
+
+	res := PolyToChannelPressure(s, channel.ReduceMax)
+
+	expected := `0 channel.NoteOn channel 1 key 60 velocity 100
+0 channel.NoteOn channel 1 key 64 velocity 100
+10 channel.Aftertouch channel 1 pressure 40
+10 channel.Aftertouch channel 1 pressure 80
+20 channel.NoteOff channel 1 key 64
+20 channel.Aftertouch channel 1 pressure 40
+30 channel.NoteOff channel 1 key 60
+30 channel.Aftertouch channel 1 pressure 0
+30 end
+`
+
+	if got := trackString(res.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	// and back: each channel aftertouch is fanned out to the sounding keys
+	expected = `0 channel.NoteOn channel 1 key 60 velocity 100
+0 channel.NoteOn channel 1 key 64 velocity 100
+10 channel.PolyAftertouch channel 1 key 60 pressure 40
+10 channel.PolyAftertouch channel 1 key 64 pressure 40
+10 channel.PolyAftertouch channel 1 key 60 pressure 80
+10 channel.PolyAftertouch channel 1 key 64 pressure 80
+20 channel.NoteOff channel 1 key 64
+20 channel.PolyAftertouch channel 1 key 60 pressure 40
+30 channel.NoteOff channel 1 key 60
+30 end
+`
+
+	if got := trackString(ChannelToPolyPressure(res).Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	if got := trackString(s.Track(0)); got != before {
+		t.Errorf("given SMF has been modified:\n%s", got)
+	}
+}
+
+func TestPressureConversionUnchanged(t *testing.T) {
+	var notes, pressure Track
+	notes.Add(0, channel.Channel0.NoteOn(60, 100))
+	notes.Add(100, channel.Channel0.NoteOff(60))
+	pressure.Add(0, channel.Channel1.NoteOn(60, 100))
+	pressure.Add(10, channel.Channel1.PolyAftertouch(60, 40))
+	pressure.Add(100, channel.Channel1.NoteOff(60))
+
+	s := New(smf.SMF1, smf.MetricTicks(96))
+	s.AddTrack(&notes)
+	s.AddTrack(&pressure)
+
+	if err := s.Write(io.Discard); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// only the track with converted messages is modified, so that the other one keeps its cached chunk
+	res := PolyToChannelPressure(s, channel.ReduceMax)
+
+	for no, shared := range []bool{true, false} {
+		if got := res.Track(no).encoded.Load() == s.Track(no).encoded.Load(); got != shared {
+			t.Errorf("track %v shares the encoded data: %v; want %v", no, got, shared)
+		}
+	}
+
+	// without channel aftertouch, no track is modified
+	res = ChannelToPolyPressure(s)
+
+	for no := 0; no < 2; no++ {
+		if res.Track(no).encoded.Load() != s.Track(no).encoded.Load() {
+			t.Errorf("track %v does not share the encoded data", no)
+		}
+	}
+}
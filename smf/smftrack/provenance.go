@@ -0,0 +1,151 @@
+package smftrack
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// Source is the origin of an event, as recorded by RecordProvenance
+type Source struct {
+	// File is the ID of the file, that was given to RecordProvenance
+	File string `json:"file"`
+
+	// Track is the number of the track within the file
+	Track int `json:"track"`
+
+	// Index is the index of the event within the track
+	Index int `json:"index"`
+}
+
+// String returns a description of the source
+func (s Source) String() string {
+	return fmt.Sprintf("%s track %v event %v", s.File, s.Track, s.Index)
+}
+
+// provenanceTags is the last tag that has been assigned by RecordProvenance, so that the tags of the events of
+// different files never collide
+var provenanceTags atomic.Uint64
+
+// RecordProvenance returns a copy of the given SMF in provenance mode: each event gets a new tag and its source
+// (the given file ID, its track and its index) is recorded by the tag, so that it can be queried with Provenance.
+// The given SMF is not modified.
+//
+// Since the sources are bound to the tags, they have the same contract as tags: they survive the transforming
+// functions that keep the tags of the events, including the conversions of several SMFs into one (e.g. Mixdown
+// and Concat), which combine the recorded sources. Events that are created by a transforming function have no tag
+// and therefore no source. The tags of the given SMF are replaced.
+func RecordProvenance(s *SMF, file string) *SMF {
+	res := s.clone()
+	res.provenance = map[uint64]Source{}
+
+	for no, tr := range res.tracks {
+		for i := range tr.evs() {
+			tag := provenanceTags.Add(1)
+			tr.evs()[i].Tag = tag
+			res.provenance[tag] = Source{File: file, Track: no, Index: i}
+		}
+	}
+
+	return res
+}
+
+// Provenance returns the source of the event at the given index of the given track, if it is known
+// (see RecordProvenance).
+func (s *SMF) Provenance(track, index int) (Source, bool) {
+	if track < 0 || track >= len(s.tracks) || index < 0 || index >= len(s.tracks[track].evs()) {
+		return Source{}, false
+	}
+
+	tag := s.tracks[track].evs()[index].Tag
+
+	if tag == 0 {
+		return Source{}, false
+	}
+
+	src, has := s.provenance[tag]
+	return src, has
+}
+
+// inheritProvenance sets the recorded sources of the SMF to the recorded sources of the given SMFs
+func (s *SMF) inheritProvenance(from ...*SMF) {
+	var n int
+	var single map[uint64]Source
+
+	for _, f := range from {
+		if len(f.provenance) > 0 {
+			n++
+			single = f.provenance
+		}
+	}
+
+	// the recorded sources are never modified, so they may be shared
+	if n < 2 {
+		s.provenance = single
+		return
+	}
+
+	s.provenance = map[uint64]Source{}
+
+	for _, f := range from {
+		for tag, src := range f.provenance {
+			s.provenance[tag] = src
+		}
+	}
+}
+
+// provenanceEntry is the serialized source of the event at an index of a track
+type provenanceEntry struct {
+	Track  int    `json:"track"`
+	Index  int    `json:"index"`
+	Source Source `json:"source"`
+}
+
+// MarshalProvenance returns the known sources of the events of the given SMF by their position as JSON,
+// so that they can be stored beside the file and restored with UnmarshalProvenance after reading it.
+func MarshalProvenance(s *SMF) ([]byte, error) {
+	var entries = []provenanceEntry{}
+
+	for no, tr := range s.tracks {
+		for i := range tr.evs() {
+			if src, has := s.Provenance(no, i); has {
+				entries = append(entries, provenanceEntry{Track: no, Index: i, Source: src})
+			}
+		}
+	}
+
+	return json.Marshal(entries)
+}
+
+// UnmarshalProvenance returns a copy of the given SMF in provenance mode with the sources of the given JSON
+// (see MarshalProvenance). The events with a source get a new tag, the tags of the other events are removed.
+// The given SMF is not modified.
+// An error is returned, if the JSON is invalid or refers to an event that does not exist.
+func UnmarshalProvenance(s *SMF, data []byte) (*SMF, error) {
+	var entries []provenanceEntry
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid provenance: %v", err)
+	}
+
+	res := s.clone()
+	res.provenance = map[uint64]Source{}
+
+	for _, tr := range res.tracks {
+		for i := range tr.evs() {
+			tr.evs()[i].Tag = 0
+		}
+	}
+
+	for _, e := range entries {
+		if e.Track < 0 || e.Track >= len(res.tracks) || e.Index < 0 || e.Index >= len(res.tracks[e.Track].evs()) {
+			return nil, fmt.Errorf("invalid provenance: event %v of track %v does not exist", e.Index, e.Track)
+		}
+
+		tag := provenanceTags.Add(1)
+		res.tracks[e.Track].evs()[e.Index].Tag = tag
+		res.provenance[tag] = e.Source
+	}
+
+	return res, nil
+}
@@ -0,0 +1,138 @@
+package smftrack
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// findNoteOn returns the track and index of the first note on message of the given channel and key
+func findNoteOn(s *SMF, ch, key uint8) (track, index int) {
+	for no, tr := range s.tracks {
+		for i, ev := range tr.events {
+			if on, is := ev.Message.(channel.NoteOn); is && on.Channel() == ch && on.Key() == key && on.Velocity() > 0 {
+				return no, i
+			}
+		}
+	}
+	return -1, -1
+}
+
+func TestProvenance(t *testing.T) {
+	var conductor, piano, bass, drums Track
+	conductor.Add(0, meta.Track("a"), meta.BPM(120))
+	piano.Add(0, channel.Channel0.ProgramChange(1), channel.Channel0.NoteOn(60, 100))
+	piano.Add(480, channel.Channel0.NoteOff(60))
+	bass.Add(0, channel.Channel1.ProgramChange(33))
+	bass.Add(240, channel.Channel1.NoteOn(40, 90))
+	bass.Add(720, channel.Channel1.NoteOff(40))
+	drums.Add(0, channel.Channel9.NoteOn(36, 100), channel.Channel9.NoteOff(36))
+
+	a := New(smf.SMF1, smf.MetricTicks(480))
+	a.AddTrack(&conductor)
+	a.AddTrack(&piano)
+	a.AddTrack(&bass)
+	a.AddTrack(&drums)
+
+	var conductorB, lead Track
+	conductorB.Add(0, meta.BPM(100))
+	lead.Add(960, channel.Channel0.NoteOn(72, 80))
+	lead.Add(1200, channel.Channel0.NoteOff(72))
+
+	b := New(smf.SMF1, smf.MetricTicks(960))
+	b.AddTrack(&conductorB)
+	b.AddTrack(&lead)
+
+	a, b = RecordProvenance(a, "a.mid"), RecordProvenance(b, "b.mid")
+
+	if src, has := a.Provenance(2, 1); !has || src != (Source{File: "a.mid", Track: 2, Index: 1}) {
+		t.Errorf("Provenance(2, 1) = %v, %v; want a.mid track 2 event 1", src, has)
+	}
+
+	merged, _, err := Mixdown(a, b)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res, err := EditNotes(merged, func(Note) bool { return true }, func(n Note) Note {
+		n.Key += 2
+		return n
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the lead of b is moved to the free channel 2 by Mixdown
+	tests := []struct {
+		ch, key  uint8
+		expected string
+	}{
+		{1, 42, "a.mid track 2 event 1"},
+		{0, 62, "a.mid track 1 event 1"},
+		{2, 74, "b.mid track 1 event 0"},
+	}
+
+	for _, test := range tests {
+		track, index := findNoteOn(res, test.ch, test.key)
+		src, has := res.Provenance(track, index)
+
+		if !has || src.String() != test.expected {
+			t.Errorf("Provenance of note on %v of channel %v = %v, %v; want %v", test.key, test.ch, src, has, test.expected)
+		}
+	}
+
+	// the tempo messages are rebuilt by Mixdown
+	for i, ev := range res.Track(0).Events() {
+		if _, is := ev.Message.(meta.Tempo); !is {
+			continue
+		}
+
+		if src, has := res.Provenance(0, i); has {
+			t.Errorf("Provenance(0, %v) of tempo = %v; want none", i, src)
+		}
+	}
+
+	if _, has := New(smf.SMF0, smf.MetricTicks(480)).Provenance(0, 0); has {
+		t.Errorf("Provenance() of SMF without tracks returned a source")
+	}
+
+	// the sources survive writing and reading the file, if they are stored beside it
+	data, err := MarshalProvenance(res)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var bf bytes.Buffer
+	res.Write(&bf)
+	read, err := Read(&bf)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	track, index := findNoteOn(read, 1, 42)
+
+	if _, has := read.Provenance(track, index); has {
+		t.Errorf("Provenance() of read file without stored sources returned a source")
+	}
+
+	read, err = UnmarshalProvenance(read, data)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if src, has := read.Provenance(track, index); !has || src.String() != "a.mid track 2 event 1" {
+		t.Errorf("Provenance() after UnmarshalProvenance = %v, %v; want a.mid track 2 event 1", src, has)
+	}
+
+	if _, err := UnmarshalProvenance(read, []byte(`[{"track":9,"index":0,"source":{"file":"a.mid"}}]`)); err == nil {
+		t.Errorf("UnmarshalProvenance() of missing event returned no error")
+	}
+}
@@ -0,0 +1,252 @@
+package smftrack
+
+import (
+	"time"
+)
+
+type quantizeConfig struct {
+	ends      bool
+	threshold uint64
+	bucket    uint32
+}
+
+// QuantizeOption is an option for Quantize
+type QuantizeOption func(*quantizeConfig)
+
+// QuantizeEnds quantizes the ends of the notes too. Otherwise the durations are kept.
+// A note whose end would be quantized to its start, lasts one grid step.
+func QuantizeEnds() QuantizeOption {
+	return func(c *quantizeConfig) {
+		c.ends = true
+	}
+}
+
+// QuantizeThreshold sets the displacement in ticks above which a note is reported as exceeding
+// (see QuantizeReport.Exceeding). Default is a quarter of the grid.
+func QuantizeThreshold(ticks uint64) QuantizeOption {
+	return func(c *quantizeConfig) {
+		c.threshold = ticks
+	}
+}
+
+// QuantizeHistogramBucket sets the size of the buckets of the histogram of the displacements in ticks
+// (see QuantizeReport.Histogram). Default is an eighth of the grid.
+func QuantizeHistogramBucket(ticks uint32) QuantizeOption {
+	return func(c *quantizeConfig) {
+		if ticks > 0 {
+			c.bucket = ticks
+		}
+	}
+}
+
+// Displacement is the movement of a note by Quantize
+type Displacement struct {
+	Track   int   `json:"track"`
+	Channel uint8 `json:"channel"`
+	Key     uint8 `json:"key"`
+
+	// From and To are the start of the note before and after the quantization in ticks
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+
+	// Ticks is the displacement in ticks, negative if the note has been moved to the front
+	Ticks int64 `json:"ticks"`
+
+	// Millis is the displacement in milliseconds according to the tempo map
+	Millis float64 `json:"ms"`
+
+	// Merged is true, if the note has been merged into a note on the same key that has been quantized
+	// to the same tick
+	Merged bool `json:"merged,omitempty"`
+}
+
+// QuantizeReport describes how much Quantize has changed the notes. It can be marshalled to JSON.
+type QuantizeReport struct {
+	// Grid is the grid in ticks
+	Grid uint32 `json:"grid"`
+
+	// Notes are the displacements of all notes, by track and start
+	Notes []Displacement `json:"notes"`
+
+	// Histogram counts the notes by their absolute displacement: Histogram[i] is the number of notes that have been
+	// moved by i*HistogramBucket up to (i+1)*HistogramBucket-1 ticks
+	Histogram       []int  `json:"histogram"`
+	HistogramBucket uint32 `json:"histogramBucket"`
+
+	// Merged is the number of notes that have been merged into another note
+	Merged int `json:"merged"`
+
+	// ZeroLengthPrevented is the number of notes whose end would have been quantized to their start (see QuantizeEnds)
+	ZeroLengthPrevented int `json:"zeroLengthPrevented"`
+
+	// Exceeding are the displacements of the notes that have been moved by more than Threshold ticks,
+	// e.g. tuplets that do not fit the grid
+	Threshold uint64         `json:"threshold"`
+	Exceeding []Displacement `json:"exceeding"`
+}
+
+// add adds the given displacement to the report
+func (r *QuantizeReport) add(d Displacement) {
+	r.Notes = append(r.Notes, d)
+
+	abs := d.Ticks
+	if abs < 0 {
+		abs = -abs
+	}
+
+	b := int(uint64(abs) / uint64(r.HistogramBucket))
+
+	for len(r.Histogram) <= b {
+		r.Histogram = append(r.Histogram, 0)
+	}
+
+	r.Histogram[b]++
+
+	if uint64(abs) > r.Threshold {
+		r.Exceeding = append(r.Exceeding, d)
+	}
+}
+
+// Quantize returns a copy of the given SMF where the starts of the notes are moved to the nearest multiple of
+// gridTicks, and a report of the displacements. The durations are kept, unless the ends are quantized too
+// (see QuantizeEnds). The given SMF is not modified. A gridTicks of 0 does not change the notes.
+//
+// Notes on the same track, channel and key that are quantized to the same tick are merged into the first one,
+// which lasts until the later end. A note that would overlap the next note on the same key is ended at its start.
+func Quantize(s *SMF, gridTicks uint32, options ...QuantizeOption) (*SMF, QuantizeReport) {
+	c := quantizeConfig{threshold: uint64(gridTicks / 4), bucket: gridTicks / 8}
+
+	if c.bucket == 0 {
+		c.bucket = 1
+	}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	res := s.clone()
+	rep := QuantizeReport{Grid: gridTicks, HistogramBucket: c.bucket, Threshold: c.threshold}
+
+	if gridTicks == 0 {
+		return res, rep
+	}
+
+	tm := s.TempoMap()
+	grid := uint64(gridTicks)
+
+	round := func(ticks uint64) uint64 {
+		return (ticks + grid/2) / grid * grid
+	}
+
+	for no, tr := range res.tracks {
+		notes := tr.Notes()
+		var merged = make([]bool, len(notes))
+		var anyMerged bool
+
+		// the index of the last note that has not been merged, by channel and key
+		var last = map[[2]uint8]int{}
+
+		for i := range notes {
+			n := &notes[i]
+			from, end := n.AbsTicks, n.End()
+			n.AbsTicks = round(from)
+
+			if c.ends && n.off >= 0 {
+				end = round(end)
+
+				if end <= n.AbsTicks {
+					end = n.AbsTicks + grid
+					rep.ZeroLengthPrevented++
+				}
+			} else {
+				end = n.AbsTicks + n.Duration
+			}
+
+			n.Duration = end - n.AbsTicks
+
+			d := Displacement{
+				Track:   no,
+				Channel: n.Channel,
+				Key:     n.Key,
+				From:    from,
+				To:      n.AbsTicks,
+				Ticks:   int64(n.AbsTicks) - int64(from),
+				Millis:  float64(tm.Time(n.AbsTicks)-tm.Time(from)) / float64(time.Millisecond),
+			}
+
+			k := [2]uint8{n.Channel, n.Key}
+
+			if j, has := last[k]; has {
+				prev := &notes[j]
+
+				switch {
+				case prev.AbsTicks == n.AbsTicks:
+					if n.End() > prev.End() {
+						prev.Duration = n.End() - prev.AbsTicks
+					}
+
+					merged[i], anyMerged, d.Merged = true, true, true
+					rep.Merged++
+				case prev.End() > n.AbsTicks:
+					prev.Duration = n.AbsTicks - prev.AbsTicks
+				}
+			}
+
+			if !merged[i] {
+				last[k] = i
+			}
+
+			rep.add(d)
+		}
+
+		if anyMerged {
+			notes = tr.removeNotes(notes, merged)
+		}
+
+		// can't fail, since the notes are from the track
+		tr.SetNotes(notes)
+	}
+
+	return res, rep
+}
+
+// removeNotes removes the note on and note off events of the given notes (returned by Notes) that are marked as
+// removed from the track and returns the remaining notes of the track with the properties of the given ones.
+func (t *Track) removeNotes(notes []Note, removed []bool) []Note {
+	var drop = map[int]bool{}
+
+	for i, n := range notes {
+		if removed[i] {
+			drop[n.on] = true
+			if n.off >= 0 {
+				drop[n.off] = true
+			}
+		}
+	}
+
+	var evts = make([]Event, 0, len(t.evs())-len(drop))
+
+	for i, ev := range t.evs() {
+		if !drop[i] {
+			evts = append(evts, ev)
+		}
+	}
+
+	t.SetEvents(evts)
+
+	// removing a note on and its note off does not change the pairing of the other notes
+	kept := t.Notes()
+	k := 0
+
+	for i, n := range notes {
+		if removed[i] {
+			continue
+		}
+
+		n.on, n.off = kept[k].on, kept[k].off
+		kept[k] = n
+		k++
+	}
+
+	return kept
+}
@@ -0,0 +1,120 @@
+package smftrack
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestQuantize(t *testing.T) {
+	var tr Track
+	ch := channel.Channel0
+
+	note := func(tick, duration uint64, key uint8) {
+		tr.Add(tick, ch.NoteOn(key, 100))
+		tr.Add(tick+duration, ch.NoteOff(key))
+	}
+
+	// straight sixteenths
+	note(0, 100, 60)
+	note(120, 100, 62)
+	note(240, 100, 64)
+	note(360, 100, 65)
+
+	// eighth triplets
+	note(480, 150, 67)
+	note(640, 150, 65)
+	note(800, 150, 64)
+
+	// slightly late, a double trigger (whose first note is too short) and a short note
+	note(965, 100, 62)
+	note(1190, 10, 60)
+	note(1210, 100, 60)
+	note(1450, 20, 59)
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	res, rep := Quantize(s, 120, QuantizeEnds())
+
+	expected := `0 channel.NoteOn channel 1 key 60 velocity 100
+120 channel.NoteOff channel 1 key 60
+120 channel.NoteOn channel 1 key 62 velocity 100
+240 channel.NoteOff channel 1 key 62
+240 channel.NoteOn channel 1 key 64 velocity 100
+360 channel.NoteOff channel 1 key 64
+360 channel.NoteOn channel 1 key 65 velocity 100
+480 channel.NoteOff channel 1 key 65
+480 channel.NoteOn channel 1 key 67 velocity 100
+600 channel.NoteOff channel 1 key 67
+600 channel.NoteOn channel 1 key 65 velocity 100
+840 channel.NoteOff channel 1 key 65
+840 channel.NoteOn channel 1 key 64 velocity 100
+960 channel.NoteOff channel 1 key 64
+960 channel.NoteOn channel 1 key 62 velocity 100
+1080 channel.NoteOff channel 1 key 62
+1200 channel.NoteOn channel 1 key 60 velocity 100
+1320 channel.NoteOff channel 1 key 60
+1440 channel.NoteOn channel 1 key 59 velocity 100
+1560 channel.NoteOff channel 1 key 59
+1560 end
+`
+
+	if got := trackString(res.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	var ticks []int64
+
+	for _, d := range rep.Notes {
+		ticks = append(ticks, d.Ticks)
+	}
+
+	if want := []int64{0, 0, 0, 0, 0, -40, 40, -5, 10, -10, -10}; !reflect.DeepEqual(ticks, want) {
+		t.Errorf("displacements = %v; want %v", ticks, want)
+	}
+
+	// the triplets that do not fit the grid are flagged
+	var exceeding []uint64
+
+	for _, d := range rep.Exceeding {
+		exceeding = append(exceeding, d.From)
+	}
+
+	if want := []uint64{640, 800}; !reflect.DeepEqual(exceeding, want) {
+		t.Errorf("exceeding notes at %v; want %v", exceeding, want)
+	}
+
+	if got, want := rep.Exceeding[0].Millis, -41.666; got > want || got < want-0.001 {
+		t.Errorf("displacement of the triplet = %vms; want %vms", got, want)
+	}
+
+	if want := []int{9, 0, 2}; !reflect.DeepEqual(rep.Histogram, want) {
+		t.Errorf("histogram = %v; want %v", rep.Histogram, want)
+	}
+
+	if rep.Merged != 1 || rep.ZeroLengthPrevented != 2 || !rep.Notes[9].Merged {
+		t.Errorf("merged = %v, zero length prevented = %v; want 1, 2", rep.Merged, rep.ZeroLengthPrevented)
+	}
+
+	data, err := json.Marshal(rep)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if want := `"exceeding":[{"track":0,"channel":0,"key":65,"from":640,"to":600,"ticks":-40,"ms":-41.666667`; !strings.Contains(string(data), want) {
+		t.Errorf("JSON %s does not contain %s", data, want)
+	}
+
+	// the durations are kept without QuantizeEnds
+	res, _ = Quantize(s, 120, QuantizeThreshold(5), QuantizeHistogramBucket(20))
+
+	if got, want := res.Track(0).Notes()[5].Duration, uint64(150); got != want {
+		t.Errorf("duration = %v; want %v", got, want)
+	}
+}
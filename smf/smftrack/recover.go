@@ -0,0 +1,39 @@
+package smftrack
+
+import (
+	"io"
+
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+// Recover reads the SMF data of src that may end anywhere, e.g. the file of a recording that has been written
+// with the smfwriter.Checkpoints option, when the recording process crashed. It reads the data like Read with the
+// smfreader.Tolerant option: the data after the last complete message is dropped, missing tracks are tolerated and
+// the end of the last track is its last message. The problems are kept as warnings (see Warnings).
+//
+// For a file with checkpoints, the result contains all messages up to the last checkpoint. The messages after it
+// are contained as far as they have been written completely; a write that has been interrupted by a crash of the
+// system may leave a corrupt message after them.
+// An error is returned, if the header is incomplete.
+func Recover(src io.Reader) (*SMF, error) {
+	s, err := Read(src, smfreader.Tolerant())
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tr := range s.tracks {
+		evts := tr.evs()[:0]
+
+		// a message that is truncated within its data is returned as nil
+		for _, ev := range tr.evs() {
+			if ev.Message != nil {
+				evts = append(evts, ev)
+			}
+		}
+
+		tr.events = evts
+	}
+
+	return s, nil
+}
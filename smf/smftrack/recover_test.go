@@ -0,0 +1,216 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/sysex"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// crashFile is an in-memory io.WriteSeeker that keeps the states of the data, as a crash could leave them:
+// after each byte that has been written
+type crashFile struct {
+	data []byte
+	pos  int64
+
+	// flushed is the number of messages that have been written before the last checkpoint
+	flushed int
+
+	states []crashState
+}
+
+type crashState struct {
+	data    []byte
+	flushed int
+}
+
+func (f *crashFile) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if f.pos == int64(len(f.data)) {
+			f.data = append(f.data, b)
+		} else {
+			f.data[f.pos] = b
+		}
+
+		f.pos++
+		f.states = append(f.states, crashState{data: append([]byte{}, f.data...), flushed: f.flushed})
+	}
+
+	return len(p), nil
+}
+
+func (f *crashFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		f.pos = offset
+	case io.SeekCurrent:
+		f.pos += offset
+	case io.SeekEnd:
+		f.pos = int64(len(f.data)) + offset
+	}
+
+	return f.pos, nil
+}
+
+// recording returns the messages of a recording with the given number of messages
+func recording(n int) (msgs []midi.Message) {
+	for i := 0; len(msgs) < n; i++ {
+		key := uint8(48 + i%24)
+
+		switch i % 4 {
+		case 0, 1:
+			msgs = append(msgs, channel.Channel0.NoteOn(key, 100), channel.Channel0.NoteOff(key))
+		case 2:
+			msgs = append(msgs, channel.Channel0.ControlChange(1, uint8(i%128)), channel.Channel0.Aftertouch(uint8(i%128)))
+		case 3:
+			msgs = append(msgs, sysex.SysEx{0x7D, byte(i % 128), 0x01}, meta.Marker(fmt.Sprintf("take %v", i)))
+		}
+	}
+
+	return msgs[:n]
+}
+
+// eventsString returns the messages of the given events with their absolute ticks, one per line
+func eventsString(evts []Event) string {
+	var bf bytes.Buffer
+
+	for _, ev := range evts {
+		fmt.Fprintf(&bf, "%v %s\n", ev.AbsTicks, ev.Message)
+	}
+
+	return bf.String()
+}
+
+func TestRecover(t *testing.T) {
+	const checkpointEvery = 5
+	msgs := recording(48)
+
+	var f crashFile
+	var expected []Event
+	wr := smfwriter.New(&f, smfwriter.TimeFormat(smf.MetricTicks(96)), smfwriter.Checkpoints(0))
+
+	for i, msg := range msgs {
+		wr.SetDelta(uint32(i % 3 * 40))
+
+		if err := wr.Write(msg); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		var abs uint64
+
+		if len(expected) > 0 {
+			abs = expected[len(expected)-1].AbsTicks
+		}
+
+		expected = append(expected, Event{AbsTicks: abs + uint64(i%3*40), Message: msg})
+
+		if (i+1)%checkpointEvery == 0 {
+			if err := smfwriter.Checkpoint(wr); err != nil {
+				t.Fatalf("Error: %v", err)
+			}
+
+			f.flushed = i + 1
+
+			// the checkpoint is a complete SMF
+			s, err := Read(bytes.NewReader(f.data))
+
+			if err != nil {
+				t.Fatalf("Read() of checkpoint after %v messages: %v", i+1, err)
+			}
+
+			if got, want := eventsString(s.Track(0).Events()), eventsString(expected); got != want {
+				t.Fatalf("checkpoint after %v messages:\ngot:\n%s\n\nwanted:\n%s\n\n", i+1, got, want)
+			}
+		}
+	}
+
+	if err := wr.Write(meta.EndOfTrack); err != smf.ErrFinished {
+		t.Fatalf("Error: %v", err)
+	}
+
+	for i, state := range f.states {
+		s, err := Recover(bytes.NewReader(state.data))
+
+		if err != nil {
+			// the header is incomplete
+			if i < 14 {
+				continue
+			}
+
+			t.Fatalf("Recover() of %v bytes: %v", len(state.data), err)
+		}
+
+		if state.flushed == 0 {
+			continue
+		}
+
+		var got string
+
+		if s.NumTracks() > 0 {
+			got = eventsString(s.Track(0).Events())
+		}
+
+		if want := eventsString(expected[:state.flushed]); len(got) < len(want) || got[:len(want)] != want {
+			t.Fatalf("Recover() of state %v with %v checkpointed messages:\ngot:\n%s\n\nwanted:\n%s\n\n", i, state.flushed, got, want)
+		}
+	}
+
+	// the final file is complete
+	s, err := Read(bytes.NewReader(f.data))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := eventsString(s.Track(0).Events()), eventsString(expected); got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestRecoverMissingTracks(t *testing.T) {
+	var f crashFile
+	wr := smfwriter.New(&f, smfwriter.NumTracks(3), smfwriter.Checkpoints(2))
+
+	wr.Write(meta.BPM(100))
+	wr.Write(meta.EndOfTrack)
+	wr.Write(channel.Channel0.NoteOn(60, 100))
+	wr.SetDelta(960)
+	wr.Write(channel.Channel0.NoteOff(60))
+	wr.SetDelta(960)
+	// not checkpointed
+	wr.Write(channel.Channel0.ControlChange(7, 0))
+
+	if _, err := Read(bytes.NewReader(f.data)); err == nil {
+		t.Errorf("Read() of recording with missing tracks returned no error")
+	}
+
+	s, err := Recover(bytes.NewReader(f.data))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := s.NumTracks(), uint16(2); got != want {
+		t.Fatalf("NumTracks() = %v; want %v", got, want)
+	}
+
+	expected := `0 channel.NoteOn channel 1 key 60 velocity 100
+960 channel.NoteOff channel 1 key 60
+960 end
+`
+
+	if got, want := trackString(s.Track(1)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if len(s.Warnings()) == 0 {
+		t.Errorf("Recover() returned no warnings")
+	}
+}
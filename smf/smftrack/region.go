@@ -0,0 +1,202 @@
+package smftrack
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi/smf"
+)
+
+// Region is a region of a SMF that has been copied by CopyRegion to be pasted by PasteRegion
+type Region struct {
+	timeFormat smf.TimeFormat
+	length     uint64
+	tracks     []*Track
+}
+
+// Length returns the length of the region in ticks
+func (r Region) Length() uint64 {
+	return r.length
+}
+
+// TimeFormat returns the time format of the SMF from which the region has been copied
+func (r Region) TimeFormat() smf.TimeFormat {
+	return r.timeFormat
+}
+
+// Tracks returns copies of the tracks of the region. The events are positioned relative to the start of the region.
+func (r Region) Tracks() []*Track {
+	res := make([]*Track, len(r.tracks))
+	for i, tr := range r.tracks {
+		res[i] = tr.clone()
+	}
+	return res
+}
+
+// PasteMode is the way PasteRegion pastes a region
+type PasteMode int
+
+const (
+	// Insert shifts the events at and after the paste point to the right by the length of the region
+	Insert PasteMode = iota
+
+	// Merge overlays the events of the region with the existing events
+	Merge
+)
+
+// RegionOption is an option for CopyRegion and PasteRegion
+type RegionOption func(*regionConfig)
+
+type regionConfig struct {
+	snapToBars bool
+}
+
+// SnapToBars snaps the positions to the bar lines of the time signatures (see MeterMap).
+// CopyRegion extends the region to complete bars, PasteRegion pastes at the nearest bar line.
+func SnapToBars() RegionOption {
+	return func(c *regionConfig) {
+		c.snapToBars = true
+	}
+}
+
+// CopyRegion returns the region from (inclusive) to (exclusive) of the given SMF.
+// The tracks of the region are cut like Slice does: the state at from is chased, notes that start before from are
+// left out and notes that last beyond to are ended at the end of the region.
+// The copied events keep their tags, the chased messages and added note off messages have none.
+func CopyRegion(s *SMF, from, to uint64, options ...RegionOption) Region {
+	var c regionConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	if to < from {
+		to = from
+	}
+
+	if c.snapToBars {
+		m := s.MeterMap()
+		_, from = m.Bar(from)
+		to = m.snap(to, true)
+	}
+
+	return Region{timeFormat: s.timeFormat, length: to - from, tracks: Slice(s, from, to).tracks}
+}
+
+// PasteRegion returns a copy of the given SMF with the given region pasted at the given tick.
+// The given SMF is not modified.
+//
+// The tracks of the region are pasted into the tracks with the same number. Missing tracks are added,
+// unless the SMF is of format 0. The events of the region are placed after existing events at the same tick.
+//
+// With Insert, all events at and after the paste point are shifted to the right by the length of the region,
+// including the end of track, like InsertSilence does: note off messages at the paste point stay there, if they end
+// a note that starts before, and notes that sound across the paste point are held through the pasted region.
+// With Merge, the events of the region are overlaid with the existing events.
+//
+// The state that is established by the region (tempo, time signature, key, programs, controllers, pitch bend and
+// aftertouch) is reset to the state of the SMF at the end of the pasted region, as far as it differs.
+// The pasted events keep the tags they have in the region, while the messages that reset the state have none.
+//
+// The time format of the region must match the time format of the SMF.
+func PasteRegion(s *SMF, r Region, atTick uint64, mode PasteMode, options ...RegionOption) (*SMF, error) {
+	var c regionConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	if r.timeFormat != s.timeFormat {
+		return nil, fmt.Errorf("can't paste region with time format %s into SMF with time format %s", r.timeFormat, s.timeFormat)
+	}
+
+	if s.format == smf.SMF0 && len(r.tracks) > 1 {
+		return nil, fmt.Errorf("can't paste region with %v tracks into SMF format 0", len(r.tracks))
+	}
+
+	if c.snapToBars {
+		atTick = s.MeterMap().snap(atTick, false)
+	}
+
+	res := s.clone()
+
+	for len(res.tracks) < len(r.tracks) {
+		res.tracks = append(res.tracks, &Track{})
+	}
+
+	for i, tr := range res.tracks {
+		var pasted *Track
+
+		if i < len(r.tracks) {
+			pasted = r.tracks[i]
+		}
+
+		tr.paste(pasted, atTick, r.length, mode)
+	}
+
+	return res, nil
+}
+
+// paste pastes the given track of a region with the given length at the given tick. pasted may be nil.
+func (t *Track) paste(pasted *Track, at, length uint64, mode PasteMode) {
+	var (
+		before, after []Event
+		regionEnd     = at + length
+		// the state of the track at the end of the pasted region
+		intended chaser
+		// the note offs at the paste point that are not shifted
+		staying = map[int]bool{}
+	)
+
+	if mode == Insert {
+		for _, n := range t.Notes() {
+			if n.AbsTicks < at && n.off >= 0 && n.End() == at {
+				staying[n.off] = true
+			}
+		}
+	}
+
+	for i, ev := range t.evs() {
+		switch {
+		case mode == Insert && ev.AbsTicks >= at && !staying[i]:
+			after = append(after, Event{AbsTicks: ev.AbsTicks + length, Message: ev.Message, Tag: ev.Tag})
+		case mode == Merge && ev.AbsTicks >= regionEnd:
+			after = append(after, ev)
+		default:
+			before = append(before, ev)
+			intended.add(ev.Message)
+		}
+	}
+
+	end := t.end
+
+	if mode == Insert && end >= at {
+		end += length
+	}
+
+	if pasted != nil {
+		if at+pasted.end > end {
+			end = at + pasted.end
+		}
+
+		for _, ev := range pasted.evs() {
+			before = append(before, Event{AbsTicks: at + ev.AbsTicks, Message: ev.Message, Tag: ev.Tag})
+		}
+	}
+
+	t.end = end
+	t.SetEvents(before)
+
+	var actual chaser
+
+	for _, ev := range t.evs() {
+		actual.add(ev.Message)
+	}
+
+	var evts = t.evs()
+
+	for _, msg := range intended.restore(&actual) {
+		evts = append(evts, Event{AbsTicks: regionEnd, Message: msg})
+	}
+
+	t.SetEvents(append(evts, after...))
+}
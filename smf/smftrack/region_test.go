@@ -0,0 +1,155 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func regionSMF() *SMF {
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8})
+	tr.Add(0, ch.ControlChange(7, 100), ch.NoteOn(60, 100))
+	tr.Add(96, ch.NoteOff(60))
+	tr.Add(192, ch.ControlChange(7, 50), ch.NoteOn(62, 100))
+	tr.Add(288, ch.NoteOff(62))
+	tr.Add(384, ch.NoteOn(64, 100))
+	tr.Add(480, ch.NoteOff(64))
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+	return s
+}
+
+func TestPasteRegion(t *testing.T) {
+	tests := []struct {
+		at       uint64
+		mode     PasteMode
+		options  []RegionOption
+		expected string
+	}{
+		{96, Insert, nil, `0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+0 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+0 channel.NoteOn channel 1 key 60 velocity 100
+96 channel.NoteOff channel 1 key 60
+96 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+96 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+96 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 50
+96 channel.NoteOn channel 1 key 62 velocity 100
+192 channel.NoteOff channel 1 key 62
+288 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+384 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 50
+384 channel.NoteOn channel 1 key 62 velocity 100
+480 channel.NoteOff channel 1 key 62
+576 channel.NoteOn channel 1 key 64 velocity 100
+672 channel.NoteOff channel 1 key 64
+672 end
+`},
+		// the note that sounds at the paste point is held through the pasted region
+		{48, Insert, nil, `0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+0 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+0 channel.NoteOn channel 1 key 60 velocity 100
+48 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+48 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+48 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 50
+48 channel.NoteOn channel 1 key 62 velocity 100
+144 channel.NoteOff channel 1 key 62
+240 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+288 channel.NoteOff channel 1 key 60
+384 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 50
+384 channel.NoteOn channel 1 key 62 velocity 100
+480 channel.NoteOff channel 1 key 62
+576 channel.NoteOn channel 1 key 64 velocity 100
+672 channel.NoteOff channel 1 key 64
+672 end
+`},
+		{480, Merge, nil, `0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+0 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+0 channel.NoteOn channel 1 key 60 velocity 100
+96 channel.NoteOff channel 1 key 60
+192 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 50
+192 channel.NoteOn channel 1 key 62 velocity 100
+288 channel.NoteOff channel 1 key 62
+384 channel.NoteOn channel 1 key 64 velocity 100
+480 channel.NoteOff channel 1 key 64
+480 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+480 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+480 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 50
+480 channel.NoteOn channel 1 key 62 velocity 100
+576 channel.NoteOff channel 1 key 62
+672 end
+`},
+		// snapped to the nearest bar line
+		{250, Merge, []RegionOption{SnapToBars()}, `0 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+0 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+0 channel.NoteOn channel 1 key 60 velocity 100
+96 channel.NoteOff channel 1 key 60
+192 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 50
+192 channel.NoteOn channel 1 key 62 velocity 100
+288 channel.NoteOff channel 1 key 62
+384 channel.NoteOn channel 1 key 64 velocity 100
+384 meta.TimeSig 4/4 clocksperclick 24 dsqpq 8
+384 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+384 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 50
+384 channel.NoteOn channel 1 key 62 velocity 100
+480 channel.NoteOff channel 1 key 64
+480 channel.NoteOff channel 1 key 62
+576 end
+`},
+	}
+
+	for i, test := range tests {
+		s := regionSMF()
+		r := CopyRegion(s, 192, 384)
+
+		if got, want := r.Length(), uint64(192); got != want {
+			t.Errorf("[%v] Length() = %v; want %v", i, got, want)
+		}
+
+		res, err := PasteRegion(s, r, test.at, test.mode, test.options...)
+
+		if err != nil {
+			t.Fatalf("[%v] Error: %v", i, err)
+		}
+
+		if got, want := trackString(res.Track(0)), test.expected; got != want {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+
+		// the input is not modified
+		if got, want := trackString(s.Track(0)), trackString(regionSMF().Track(0)); got != want {
+			t.Errorf("[%v] PasteRegion modified its input", i)
+		}
+	}
+}
+
+func TestCopyRegionSnapToBars(t *testing.T) {
+	s := regionSMF()
+	s.Track(0).Add(384, meta.TimeSig{Numerator: 3, Denominator: 4})
+
+	r := CopyRegion(s, 200, 390, SnapToBars())
+
+	if got, want := r.Length(), uint64(384+288); got != want {
+		t.Errorf("Length() = %v; want %v", got, want)
+	}
+}
+
+func TestPasteRegionErrors(t *testing.T) {
+	r := CopyRegion(regionSMF(), 0, 96)
+
+	if _, err := PasteRegion(New(smf.SMF1, smf.MetricTicks(480)), r, 0, Insert); err == nil {
+		t.Errorf("expected error for different time formats")
+	}
+
+	s := New(smf.SMF1, smf.MetricTicks(96))
+	s.AddTrack(&Track{})
+	s.AddTrack(&Track{})
+	r = CopyRegion(s, 0, 96)
+
+	if _, err := PasteRegion(regionSMF(), r, 0, Insert); err == nil {
+		t.Errorf("expected error for pasting two tracks into SMF0")
+	}
+}
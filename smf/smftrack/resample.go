@@ -0,0 +1,228 @@
+package smftrack
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// Resample returns a copy of the given SMF that is retimed to the given resolution (ticks per quarter note).
+// The absolute position of every event is rescaled exactly and rounded to the nearest tick. Therefore the rounding
+// errors do not accumulate, events keep their order and events at the same tick stay at the same tick.
+// The given SMF is not modified. It returns an error, if the SMF does not have a metric time format.
+// The events keep their tags.
+func Resample(s *SMF, newTPQ uint16) (*SMF, error) {
+	if newTPQ == 0 {
+		return nil, fmt.Errorf("invalid resolution: 0 ticks per quarter note")
+	}
+
+	mt, ok := s.timeFormat.(smf.MetricTicks)
+
+	if !ok {
+		return nil, fmt.Errorf("can't resample time format %s", s.timeFormat)
+	}
+
+	oldTPQ := uint64(mt.Number())
+	res := New(s.format, smf.MetricTicks(newTPQ))
+	res.inheritProvenance(s)
+	res.trackPolicy = s.trackPolicy
+
+	for _, tr := range s.tracks {
+		var nt = &Track{events: make([]Event, len(tr.evs()))}
+
+		for i, ev := range tr.evs() {
+			nt.evs()[i] = Event{AbsTicks: scaleTicks(ev.AbsTicks, uint64(newTPQ), oldTPQ), Message: ev.Message, Tag: ev.Tag}
+		}
+
+		nt.end = scaleTicks(tr.end, uint64(newTPQ), oldTPQ)
+		res.tracks = append(res.tracks, nt)
+	}
+
+	return res, nil
+}
+
+// scaleTicks returns ticks * num / den, rounded to the nearest integer (without overflowing)
+func scaleTicks(ticks, num, den uint64) uint64 {
+	if num == den {
+		return ticks
+	}
+
+	hi, lo := bits.Mul64(ticks, num)
+
+	var carry uint64
+	lo, carry = bits.Add64(lo, den/2, 0)
+	hi += carry
+
+	if hi >= den {
+		// the result does not fit into uint64
+		return ^uint64(0)
+	}
+
+	q, _ := bits.Div64(hi, lo, den)
+	return q
+}
+
+// Concat concatenates the given SMFs end to end and returns the result. The given SMFs are not modified.
+//
+// All SMFs are resampled to the highest resolution among them. The tracks are concatenated by their number,
+// and each SMF starts at the end of the longest track of the SMFs before it.
+// At the seam the initial state of the following SMF (tempo, time signature and programs) is inserted,
+// if it differs from the state at the end of the SMFs before and is not set at the start of the following SMF.
+// The result is of format 0, if it has a single track and of format 1 otherwise.
+// The messages that are inserted at a seam have no tag.
+//
+// All SMFs must have a metric time format and must not be of format 2.
+func Concat(files ...*SMF) (*SMF, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("nothing to concatenate")
+	}
+
+	var tpq uint16
+	var numTracks int
+
+	for i, f := range files {
+		if f.format == smf.SMF2 {
+			return nil, fmt.Errorf("can't concatenate SMF no %v: format 2 is not supported", i)
+		}
+
+		mt, ok := f.timeFormat.(smf.MetricTicks)
+
+		if !ok {
+			return nil, fmt.Errorf("can't concatenate SMF no %v: time format %s is not supported", i, f.timeFormat)
+		}
+
+		if mt.Number() > tpq {
+			tpq = mt.Number()
+		}
+
+		if len(f.tracks) > numTracks {
+			numTracks = len(f.tracks)
+		}
+	}
+
+	var format = smf.SMF0
+
+	if numTracks > 1 {
+		format = smf.SMF1
+	}
+
+	res := New(format, smf.MetricTicks(tpq))
+	res.inheritProvenance(files...)
+	res.trackPolicy = files[0].trackPolicy
+
+	for i := 0; i < numTracks; i++ {
+		res.tracks = append(res.tracks, &Track{})
+	}
+
+	for i, f := range files {
+		f, err := Resample(f, tpq)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var seam uint64
+
+		for _, tr := range res.tracks {
+			if tr.end > seam {
+				seam = tr.end
+			}
+		}
+
+		var seamEvents = make([][]Event, numTracks)
+
+		if i > 0 {
+			res.seamState(f, seam, seamEvents)
+		}
+
+		for no, tr := range res.tracks {
+			evts := append(tr.evs(), seamEvents[no]...)
+
+			if no < len(f.tracks) {
+				for _, ev := range f.tracks[no].evs() {
+					evts = append(evts, Event{AbsTicks: seam + ev.AbsTicks, Message: ev.Message, Tag: ev.Tag})
+				}
+				tr.end = seam + f.tracks[no].end
+			}
+
+			tr.SetEvents(evts)
+		}
+	}
+
+	return res, nil
+}
+
+// concatState is the state that is relevant at the seam of two concatenated SMFs
+type concatState struct {
+	tempo   meta.Tempo
+	timeSig meta.TimeSig
+	// programs of the channels; programTracks are the tracks of the program changes
+	programs      [16]uint8
+	programTracks [16]int
+}
+
+func defaultConcatState() concatState {
+	return concatState{
+		tempo:   meta.BPM(120),
+		timeSig: meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8},
+	}
+}
+
+// seamState adds the messages that establish the initial state of next to seamEvents, if the state
+// at the end of s differs.
+func (s *SMF) seamState(next *SMF, seam uint64, seamEvents [][]Event) {
+	var cur = defaultConcatState()
+
+	for _, ev := range s.Merged() {
+		cur.apply(ev)
+	}
+
+	var init = defaultConcatState()
+	var hasTempo, hasTimeSig bool
+	var hasProgram [16]bool
+
+	for _, ev := range next.Merged() {
+		if ev.AbsTicks > 0 {
+			break
+		}
+
+		switch v := ev.Message.(type) {
+		case meta.Tempo:
+			hasTempo = true
+		case meta.TimeSig:
+			hasTimeSig = true
+		case channel.ProgramChange:
+			hasProgram[v.Channel()] = true
+		}
+	}
+
+	if !hasTempo && cur.tempo != init.tempo {
+		seamEvents[0] = append(seamEvents[0], Event{AbsTicks: seam, Message: init.tempo})
+	}
+
+	if !hasTimeSig && cur.timeSig != init.timeSig {
+		seamEvents[0] = append(seamEvents[0], Event{AbsTicks: seam, Message: init.timeSig})
+	}
+
+	for ch := uint8(0); ch < 16; ch++ {
+		if !hasProgram[ch] && cur.programs[ch] != init.programs[ch] {
+			no := cur.programTracks[ch]
+			seamEvents[no] = append(seamEvents[no], Event{AbsTicks: seam, Message: channel.Channel(ch).ProgramChange(init.programs[ch])})
+		}
+	}
+}
+
+func (c *concatState) apply(ev TrackEvent) {
+	switch v := ev.Message.(type) {
+	case meta.Tempo:
+		c.tempo = v
+	case meta.TimeSig:
+		c.timeSig = v
+	case channel.ProgramChange:
+		c.programs[v.Channel()] = v.Program()
+		c.programTracks[v.Channel()] = ev.Track
+	}
+}
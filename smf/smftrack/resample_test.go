@@ -0,0 +1,127 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func trackString(tr *Track) string {
+	var bf bytes.Buffer
+
+	for _, ev := range tr.Events() {
+		fmt.Fprintf(&bf, "%v %s\n", ev.AbsTicks, ev.Message)
+	}
+
+	fmt.Fprintf(&bf, "%v end\n", tr.End())
+	return bf.String()
+}
+
+func TestResample(t *testing.T) {
+	var tr Track
+	ch := channel.Channel0
+	tr.Add(0, ch.NoteOn(60, 100))
+	tr.Add(1, ch.NoteOn(62, 100), ch.NoteOn(64, 100))
+	tr.Add(2, ch.NoteOff(60))
+	tr.Add(3, ch.NoteOff(62), ch.NoteOff(64))
+	tr.SetEnd(96)
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	res, err := Resample(s, 64)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0 channel.NoteOn channel 1 key 60 velocity 100
+1 channel.NoteOn channel 1 key 62 velocity 100
+1 channel.NoteOn channel 1 key 64 velocity 100
+1 channel.NoteOff channel 1 key 60
+2 channel.NoteOff channel 1 key 62
+2 channel.NoteOff channel 1 key 64
+64 end
+`
+
+	if got, want := trackString(res.Track(0)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if got, want := res.TimeFormat(), smf.MetricTicks(64); got != want {
+		t.Errorf("TimeFormat() = %v; want %v", got, want)
+	}
+
+	// the original is untouched
+	if got, want := s.Track(0).Event(4).AbsTicks, uint64(3); got != want {
+		t.Errorf("AbsTicks = %v; want %v", got, want)
+	}
+
+	if _, err := Resample(New(smf.SMF0, smf.SMPTE25(40)), 96); err == nil {
+		t.Errorf("expected error for SMPTE time format")
+	}
+}
+
+func TestScaleTicks(t *testing.T) {
+	tests := []struct {
+		ticks, num, den uint64
+		expected        uint64
+	}{
+		{0, 3, 2, 0},
+		{1, 3, 2, 2},
+		{5, 1, 3, 2},
+		{4, 1, 3, 1},
+		{1 << 62, 4, 2, 1 << 63},
+		{1 << 63, 4, 1, ^uint64(0)},
+	}
+
+	for _, test := range tests {
+		if got, want := scaleTicks(test.ticks, test.num, test.den), test.expected; got != want {
+			t.Errorf("scaleTicks(%v, %v, %v) = %v; want %v", test.ticks, test.num, test.den, got, want)
+		}
+	}
+}
+
+func TestConcat(t *testing.T) {
+	var a, b Track
+	a.Add(0, meta.BPM(140), channel.Channel0.ProgramChange(5), channel.Channel0.NoteOn(60, 100))
+	a.Add(96, channel.Channel0.NoteOff(60))
+
+	b.Add(0, channel.Channel0.NoteOn(62, 100))
+	b.Add(24, channel.Channel0.NoteOff(62))
+	b.SetEnd(48)
+
+	first := New(smf.SMF0, smf.MetricTicks(96))
+	first.AddTrack(&a)
+	second := New(smf.SMF0, smf.MetricTicks(48))
+	second.AddTrack(&b)
+
+	res, err := Concat(first, second)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0 meta.Tempo BPM: 140.00
+0 channel.ProgramChange channel 1 program 5
+0 channel.NoteOn channel 1 key 60 velocity 100
+96 channel.NoteOff channel 1 key 60
+96 meta.Tempo BPM: 120.00
+96 channel.ProgramChange channel 1 program 0
+96 channel.NoteOn channel 1 key 62 velocity 100
+144 channel.NoteOff channel 1 key 62
+192 end
+`
+
+	if got, want := trackString(res.Track(0)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if got, want := res.Header().String(), "<Format: SMF0 (singletrack), NumTracks: 1, TimeFormat: 96 MetricTicks>"; got != want {
+		t.Errorf("Header() = %#v; want %#v", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// Retime returns a copy of the given SMF, where the beat of a performance that has been recorded without a click
+// lands on the quarter notes. bpm is the tempo of the beat and offsetTicks the tick of its first beat, as they are
+// returned by analysis.InferTempo. The given SMF is not modified.
+//
+// The ticks of all events are rewritten and the tempo messages are replaced by a tempo message of bpm in the first
+// track, so that the wall-clock time of the performance is preserved: the time of every event is kept up to the
+// rounding to the nearest tick of the new tempo map, i.e. it deviates by half a tick at most and the rounding errors
+// do not accumulate. Events keep their order and events at the same tick stay at the same tick.
+//
+// If the first beat is not at the start, the time before it becomes an upbeat of whole quarter notes with a tempo
+// of its own, so that the first beat is on a quarter note too. The new tempo messages have no tag.
+//
+// An error is returned for SMF2, for time formats other than smf.MetricTicks and for tempos that can't be
+// expressed by a tempo message.
+func Retime(s *SMF, bpm float64, offsetTicks uint64) (*SMF, error) {
+	if s.format == smf.SMF2 {
+		return nil, fmt.Errorf("retiming is not supported: %w", ErrNoConductor)
+	}
+
+	mt, ok := s.timeFormat.(smf.MetricTicks)
+
+	if !ok {
+		return nil, fmt.Errorf("retiming is not supported for time format %v", s.timeFormat)
+	}
+
+	if len(s.tracks) == 0 {
+		return nil, fmt.Errorf("SMF has no tracks")
+	}
+
+	beat := 60000000 / bpm
+
+	if math.IsNaN(beat) || beat < 1 || beat > 0xFFFFFF {
+		return nil, fmt.Errorf("invalid tempo %v BPM", bpm)
+	}
+
+	var (
+		oldMap = s.TempoMap()
+		upbeat = float64(oldMap.Time(offsetTicks)) / float64(time.Microsecond)
+		tpq    = uint64(mt.Ticks4th())
+		tempos []TempoMark
+		first  uint64
+	)
+
+	if upbeat > 0 {
+		quarters := math.Ceil(upbeat / beat)
+		tempos = append(tempos, TempoMark{AbsTicks: 0, Tempo: meta.Tempo(math.Max(1, math.Round(upbeat/quarters)))})
+		first = uint64(quarters) * tpq
+	}
+
+	tempos = append(tempos, TempoMark{AbsTicks: first, Tempo: meta.Tempo(math.Round(beat))})
+
+	newMap := newTempoMap(s.timeFormat, tempos)
+	retime := func(absTicks uint64) uint64 {
+		return newMap.Ticks(oldMap.Time(absTicks))
+	}
+
+	res := New(s.format, s.timeFormat)
+	res.inheritProvenance(s)
+	res.trackPolicy = s.trackPolicy
+
+	for _, tr := range s.tracks {
+		var nt = &Track{events: make([]Event, 0, len(tr.evs()))}
+
+		for _, ev := range tr.evs() {
+			if _, is := ev.Message.(meta.Tempo); is {
+				continue
+			}
+			nt.events = append(nt.evs(), Event{AbsTicks: retime(ev.AbsTicks), Message: ev.Message, Tag: ev.Tag})
+		}
+
+		nt.end = retime(tr.end)
+		res.tracks = append(res.tracks, nt)
+	}
+
+	res.tracks[0].insertTempos(tempos)
+	return res, nil
+}
+
+// insertTempos inserts the given tempo messages into the track. At the same tick, they are placed after the header
+// and conductor messages and before any other messages (see ApplyConductor).
+func (t *Track) insertTempos(tempos []TempoMark) {
+	type ranked struct {
+		Event
+		rank int
+	}
+
+	var evts []ranked
+
+	for _, ev := range t.evs() {
+		switch {
+		case isHeaderMessage(ev.Message):
+			evts = append(evts, ranked{ev, 0})
+		case isConductorMessage(ev.Message):
+			evts = append(evts, ranked{ev, 1})
+		default:
+			evts = append(evts, ranked{ev, 3})
+		}
+	}
+
+	for _, tm := range tempos {
+		evts = append(evts, ranked{Event{AbsTicks: tm.AbsTicks, Message: tm.Tempo}, 2})
+	}
+
+	sort.SliceStable(evts, func(a, b int) bool {
+		if evts[a].AbsTicks != evts[b].AbsTicks {
+			return evts[a].AbsTicks < evts[b].AbsTicks
+		}
+		return evts[a].rank < evts[b].rank
+	})
+
+	var sorted = make([]Event, len(evts))
+
+	for i, ev := range evts {
+		sorted[i] = ev.Event
+	}
+
+	t.SetEvents(sorted)
+}
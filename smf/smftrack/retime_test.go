@@ -0,0 +1,189 @@
+package smftrack
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// rubatoFile returns a performance with a mean tempo of 100 BPM and the first beat at 0.3 seconds, recorded into
+// a SMF1 of 120 BPM with 960 ticks per quarter note. The beats (velocity 100) are played with the given rubato,
+// each followed by an off beat eighth (velocity 60).
+func rubatoFile(rubato float64) *SMF {
+	const period = 0.6
+
+	var tr0, tr1 Track
+	tr0.Add(0, meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8}, meta.BPM(120))
+
+	ticks := func(sec float64) uint64 { return uint64(math.Round(sec * 1920)) }
+	t := 0.3
+
+	for k := 0; k < 16; k++ {
+		p := period * (1 + rubato*math.Sin(2*math.Pi*float64(k)/8))
+		tr1.Add(ticks(t), channel.Channel0.NoteOn(36, 100))
+		tr1.Add(ticks(t+p/2), channel.Channel0.NoteOff(36), channel.Channel0.NoteOn(64, 60))
+		tr1.Add(ticks(t+p*0.9), channel.Channel0.NoteOff(64))
+		t += p
+	}
+
+	tr1.SetEnd(ticks(t + 1))
+
+	s := New(smf.SMF1, smf.MetricTicks(960))
+	s.AddTrack(&tr0)
+	s.AddTrack(&tr1)
+	return s
+}
+
+func withoutTempos(tr *Track) (evts []Event) {
+	for _, ev := range tr.Events() {
+		if _, is := ev.Message.(meta.Tempo); !is {
+			evts = append(evts, ev)
+		}
+	}
+	return
+}
+
+func TestRetime(t *testing.T) {
+	for _, rubato := range []float64{0, 0.05} {
+		s := rubatoFile(rubato)
+		before := s.Track(1).Events()
+
+		res, err := Retime(s, 100, 576)
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		var tempos []Event
+
+		for _, ev := range res.Track(0).Events() {
+			if _, is := ev.Message.(meta.Tempo); is {
+				tempos = append(tempos, ev)
+			}
+		}
+
+		// the upbeat of 0.3 seconds becomes a quarter note of 200 BPM
+		want := []Event{{AbsTicks: 0, Message: meta.BPM(200)}, {AbsTicks: 960, Message: meta.BPM(100)}}
+
+		if len(tempos) != 2 || tempos[0] != want[0] || tempos[1] != want[1] {
+			t.Fatalf("[%v] tempo messages: %v; wanted %v", rubato, tempos, want)
+		}
+
+		oldMap, newMap := s.TempoMap(), res.TempoMap()
+
+		for no := range s.tracks {
+			old, evts := withoutTempos(s.Track(no)), withoutTempos(res.Track(no))
+
+			if len(old) != len(evts) {
+				t.Fatalf("[%v] track %v has %v events; wanted %v", rubato, no, len(evts), len(old))
+			}
+
+			for i := range old {
+				if old[i].Message != evts[i].Message {
+					t.Fatalf("[%v] track %v event %v is %v; wanted %v", rubato, no, i, evts[i].Message, old[i].Message)
+				}
+
+				// the time is preserved up to half a tick
+				halfTick := time.Duration(newMap.TempoAt(evts[i].AbsTicks)) * time.Microsecond / (2 * 960)
+				diff := newMap.Time(evts[i].AbsTicks) - oldMap.Time(old[i].AbsTicks)
+
+				if diff < -halfTick || diff > halfTick {
+					t.Errorf("[%v] track %v event %v moved by %v", rubato, no, i, diff)
+				}
+			}
+		}
+
+		if rubato != 0 {
+			continue
+		}
+
+		// the beats are on quarter notes, the off beats on eighths
+		for _, ev := range res.Track(1).Events() {
+			on, is := ev.Message.(channel.NoteOn)
+
+			if !is {
+				continue
+			}
+
+			grid := uint64(960)
+
+			if on.Velocity() != 100 {
+				grid = 480
+			}
+
+			if ev.AbsTicks%grid != 0 {
+				t.Errorf("%v is not on the grid of %v ticks", ev, grid)
+			}
+		}
+
+		// the given SMF is not modified
+		if got := s.Track(1).Events(); len(got) != len(before) || got[0] != before[0] {
+			t.Errorf("the given SMF has been modified")
+		}
+	}
+}
+
+func TestRetimeNoUpbeat(t *testing.T) {
+	var tr Track
+	tr.Add(0, channel.Channel0.NoteOn(60, 100))
+	tr.Add(1152, channel.Channel0.NoteOff(60), channel.Channel0.NoteOn(62, 100))
+	tr.Add(2304, channel.Channel0.NoteOff(62))
+
+	s := New(smf.SMF0, smf.MetricTicks(960))
+	s.AddTrack(&tr)
+
+	res, err := Retime(s, 100, 0)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0 meta.Tempo BPM: 100.00
+0 channel.NoteOn channel 1 key 60 velocity 100
+960 channel.NoteOff channel 1 key 60
+960 channel.NoteOn channel 1 key 62 velocity 100
+1920 channel.NoteOff channel 1 key 62
+1920 end
+`
+
+	if got := trackString(res.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
+
+func TestRetimeErrors(t *testing.T) {
+	var tr Track
+	tr.Add(0, channel.Channel0.NoteOn(60, 100))
+
+	smf2 := New(smf.SMF2, smf.MetricTicks(960))
+	smf2.AddTrack(&tr)
+
+	timeCode := New(smf.SMF0, smf.SMPTE25(40))
+	timeCode.AddTrack(&tr)
+
+	valid := New(smf.SMF0, smf.MetricTicks(960))
+	valid.AddTrack(&tr)
+
+	tests := []struct {
+		s   *SMF
+		bpm float64
+	}{
+		{smf2, 100},
+		{timeCode, 100},
+		{New(smf.SMF0, smf.MetricTicks(960)), 100},
+		{valid, 0},
+		{valid, -10},
+		{valid, math.NaN()},
+		{valid, 3},
+	}
+
+	for i, test := range tests {
+		if _, err := Retime(test.s, test.bpm, 0); err == nil {
+			t.Errorf("[%v] expected an error", i)
+		}
+	}
+}
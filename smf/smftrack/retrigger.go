@@ -0,0 +1,161 @@
+package smftrack
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+type retriggerConfig struct {
+	decay float64
+	drums
+}
+
+// RetriggerOption is an option for Retrigger (see also DetectDrums)
+type RetriggerOption interface {
+	applyRetrigger(*retriggerConfig)
+}
+
+type retriggerOption func(*retriggerConfig)
+
+func (o retriggerOption) applyRetrigger(c *retriggerConfig) {
+	o(c)
+}
+
+// RetriggerDecay multiplies the velocity of each segment by the given factor, so that the velocity of the nth segment
+// (starting with 0) is velocity * factor^n. The velocities are kept between 1 and 127. Default is 1 (no decay).
+func RetriggerDecay(factor float64) RetriggerOption {
+	return retriggerOption(func(c *retriggerConfig) {
+		c.decay = factor
+	})
+}
+
+// RetriggerDrumChannels sets the channels that are skipped. Default is channel 9 (channel 10 in GM).
+func RetriggerDrumChannels(channels ...uint8) RetriggerOption {
+	return retriggerOption(func(c *retriggerConfig) {
+		c.drumChannels = channelSet(channels)
+		c.detectDrums = nil
+	})
+}
+
+// Retrigger returns a copy of the given SMF where the notes that are longer than maxTicks are split into consecutive
+// segments of maxTicks, so that they can be played by devices that cut long notes.
+// The segments are separated by gaps of gapTicks: each segment but the last one ends gapTicks before the start of the
+// next segment. The last segment ends with the note off message of the note.
+// If gapTicks is not smaller than maxTicks, there are no gaps. A maxTicks of 0 does not split any notes.
+// The given SMF is not modified.
+//
+// The segments keep the velocity of the note (see RetriggerDecay). Notes on drum channels (see RetriggerDrumChannels)
+// are not changed. Since the segments are measured in ticks, their durations follow the tempo changes.
+// The note on and note off messages of the note keep their tags, the note messages of the segments in between
+// have none.
+func Retrigger(s *SMF, maxTicks, gapTicks uint64, options ...RetriggerOption) *SMF {
+	c := retriggerConfig{
+		decay: 1,
+		drums: drums{drumChannels: map[uint8]bool{9: true}},
+	}
+
+	for _, opt := range options {
+		opt.applyRetrigger(&c)
+	}
+
+	c.resolveDrums(s)
+
+	if gapTicks >= maxTicks {
+		gapTicks = 0
+	}
+
+	res := s.clone()
+
+	if maxTicks == 0 {
+		return res
+	}
+
+	for _, tr := range res.tracks {
+		notes := tr.Notes()
+		var segments []Event
+
+		for _, n := range notes {
+			if n.Duration <= maxTicks || c.drumChannels[n.Channel] {
+				continue
+			}
+
+			// the note on message of the note starts the first segment and its note off message ends the last one
+			ch := channel.Channel(n.Channel)
+			num := (n.Duration + maxTicks - 1) / maxTicks
+
+			for seg := uint64(1); seg < num; seg++ {
+				start := n.AbsTicks + seg*maxTicks
+				segments = append(segments,
+					Event{AbsTicks: start - gapTicks, Message: ch.NoteOff(n.Key)},
+					Event{AbsTicks: start, Message: ch.NoteOn(n.Key, c.velocity(n.Velocity, seg))},
+				)
+			}
+		}
+
+		if len(segments) == 0 {
+			continue
+		}
+
+		tr.SetEvents(insertSegments(tr.evs(), segments))
+	}
+
+	return res
+}
+
+// velocity returns the velocity of the segment with the given number
+func (c retriggerConfig) velocity(velocity uint8, segment uint64) uint8 {
+	v := math.Round(float64(velocity) * math.Pow(c.decay, float64(segment)))
+
+	switch {
+	case v < 1:
+		return 1
+	case v > 127:
+		return 127
+	default:
+		return uint8(v)
+	}
+}
+
+// insertSegments returns the given events with the note on and note off messages of the given segments.
+// At the same tick, the note off messages of the segments are placed before the existing events and their note on
+// messages after the existing events, so that neither a segment ends a note nor a note ends a segment that starts
+// at the same tick.
+func insertSegments(evts []Event, segments []Event) []Event {
+	type sortable struct {
+		Event
+		order int
+	}
+
+	var all = make([]sortable, 0, len(evts)+len(segments))
+
+	for _, ev := range evts {
+		all = append(all, sortable{Event: ev, order: 1})
+	}
+
+	for _, ev := range segments {
+		order := 0
+
+		if _, is := ev.Message.(channel.NoteOn); is {
+			order = 2
+		}
+
+		all = append(all, sortable{Event: ev, order: order})
+	}
+
+	sort.SliceStable(all, func(a, b int) bool {
+		if all[a].AbsTicks != all[b].AbsTicks {
+			return all[a].AbsTicks < all[b].AbsTicks
+		}
+		return all[a].order < all[b].order
+	})
+
+	var res = make([]Event, len(all))
+
+	for i, ev := range all {
+		res[i] = ev.Event
+	}
+
+	return res
+}
@@ -0,0 +1,157 @@
+package smftrack
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestRetrigger(t *testing.T) {
+	ch := channel.Channel0
+	var tr Track
+	tr.Add(0, ch.NoteOn(60, 100), channel.Channel9.NoteOn(36, 100), ch.NoteOn(72, 100))
+	tr.Add(480, ch.NoteOff(72))
+	tr.Add(960, ch.NoteOn(64, 90))
+	tr.Add(1920, ch.NoteOff(64))
+	tr.Add(2000, ch.NoteOffVelocity(60, 30), channel.Channel9.NoteOff(36))
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+	before := trackString(s.Track(0))
+
+	tests := []struct {
+		options  []RetriggerOption
+		expected string
+	}{
+		{nil, `0 channel.NoteOn channel 1 key 60 velocity 100
+0 channel.NoteOn channel 10 key 36 velocity 100
+0 channel.NoteOn channel 1 key 72 velocity 100
+480 channel.NoteOff channel 1 key 72
+900 channel.NoteOff channel 1 key 60
+960 channel.NoteOn channel 1 key 64 velocity 90
+960 channel.NoteOn channel 1 key 60 velocity 100
+1860 channel.NoteOff channel 1 key 60
+1920 channel.NoteOff channel 1 key 64
+1920 channel.NoteOn channel 1 key 60 velocity 100
+2000 channel.NoteOffVelocity channel 1 key 60 velocity 30
+2000 channel.NoteOff channel 10 key 36
+2000 end
+`},
+		{[]RetriggerOption{RetriggerDecay(0.5), RetriggerDrumChannels()}, `0 channel.NoteOn channel 1 key 60 velocity 100
+0 channel.NoteOn channel 10 key 36 velocity 100
+0 channel.NoteOn channel 1 key 72 velocity 100
+480 channel.NoteOff channel 1 key 72
+900 channel.NoteOff channel 1 key 60
+900 channel.NoteOff channel 10 key 36
+960 channel.NoteOn channel 1 key 64 velocity 90
+960 channel.NoteOn channel 1 key 60 velocity 50
+960 channel.NoteOn channel 10 key 36 velocity 50
+1860 channel.NoteOff channel 1 key 60
+1860 channel.NoteOff channel 10 key 36
+1920 channel.NoteOff channel 1 key 64
+1920 channel.NoteOn channel 1 key 60 velocity 25
+1920 channel.NoteOn channel 10 key 36 velocity 25
+2000 channel.NoteOffVelocity channel 1 key 60 velocity 30
+2000 channel.NoteOff channel 10 key 36
+2000 end
+`},
+	}
+
+	for i, test := range tests {
+		res := Retrigger(s, 960, 60, test.options...)
+
+		if got := trackString(res.Track(0)); got != test.expected {
+			t.Errorf("[%v] got:\n%s\n\nwanted:\n%s\n\n", i, got, test.expected)
+		}
+
+		for _, n := range res.Notes() {
+			if n.Channel == 0 && n.Duration > 960 {
+				t.Errorf("[%v] note %v at %v has duration %v; want at most 960", i, n.Key, n.AbsTicks, n.Duration)
+			}
+		}
+	}
+
+	if got := trackString(s.Track(0)); got != before {
+		t.Errorf("given SMF has been modified:\n%s", got)
+	}
+
+	// without a maximum, nothing is split
+	if got := trackString(Retrigger(s, 0, 0).Track(0)); got != before {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, before)
+	}
+}
+
+func TestRetriggerChord(t *testing.T) {
+	// a held chord without gaps: each segment ends exactly where the next one starts
+	ch := channel.Channel0
+	var tr Track
+	tr.Add(0, ch.NoteOn(60, 100), ch.NoteOn(64, 100))
+	tr.Add(1920, ch.NoteOff(60), ch.NoteOff(64))
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	res := Retrigger(s, 960, 960)
+
+	expected := `0 channel.NoteOn channel 1 key 60 velocity 100
+0 channel.NoteOn channel 1 key 64 velocity 100
+960 channel.NoteOff channel 1 key 60
+960 channel.NoteOff channel 1 key 64
+960 channel.NoteOn channel 1 key 60 velocity 100
+960 channel.NoteOn channel 1 key 64 velocity 100
+1920 channel.NoteOff channel 1 key 60
+1920 channel.NoteOff channel 1 key 64
+1920 end
+`
+
+	if got := trackString(res.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	var got []uint64
+
+	for _, n := range res.Notes() {
+		got = append(got, n.AbsTicks, n.Duration)
+	}
+
+	if want := []uint64{0, 960, 0, 960, 960, 960, 960, 960}; !reflect.DeepEqual(got, want) {
+		t.Errorf("notes = %v; want %v", got, want)
+	}
+}
+
+func TestRetriggerTempo(t *testing.T) {
+	// 120 BPM for the first quarter note, then 60 BPM: the segments have the same ticks, but the
+	// first one is shorter in time
+	ch := channel.Channel0
+	var tr Track
+	tr.Add(0, meta.BPM(120), ch.NoteOn(60, 100))
+	tr.Add(480, meta.BPM(60))
+	tr.Add(2880, ch.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	res := Retrigger(s, 960, 0)
+	tm := res.TempoMap()
+
+	var got []time.Duration
+
+	for _, n := range res.Notes() {
+		got = append(got, tm.Time(n.End())-tm.Time(n.AbsTicks))
+	}
+
+	want := []time.Duration{1500 * time.Millisecond, 2 * time.Second, 2 * time.Second}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("segment durations = %v; want %v", got, want)
+	}
+
+	// the note as a whole keeps its length in time
+	if got, want := tm.Time(2880), 5500*time.Millisecond; got != want {
+		t.Errorf("Time(2880) = %v; want %v", got, want)
+	}
+}
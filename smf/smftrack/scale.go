@@ -0,0 +1,311 @@
+package smftrack
+
+import (
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+// Scale is a set of pitch classes relative to the root of the scale (bit 0 is the root, bit 1 the minor second etc.)
+type Scale uint16
+
+// NewScale returns the Scale of the given intervals in semitones above the root.
+// Intervals of an octave or more are reduced to the octave, the root is always part of the Scale.
+func NewScale(intervals ...uint8) Scale {
+	sc := Scale(1)
+
+	for _, iv := range intervals {
+		sc |= 1 << (iv % 12)
+	}
+
+	return sc
+}
+
+// Scale presets
+var (
+	ScaleMajor           = NewScale(0, 2, 4, 5, 7, 9, 11)
+	ScaleNaturalMinor    = NewScale(0, 2, 3, 5, 7, 8, 10)
+	ScaleHarmonicMinor   = NewScale(0, 2, 3, 5, 7, 8, 11)
+	ScaleDorian          = NewScale(0, 2, 3, 5, 7, 9, 10)
+	ScaleMajorPentatonic = NewScale(0, 2, 4, 7, 9)
+	ScaleMinorPentatonic = NewScale(0, 3, 5, 7, 10)
+	ScaleChromatic       = NewScale(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11)
+)
+
+// Contains returns true, if the given key is a member of the Scale on the given root (0 is C, 1 is C# etc.)
+func (sc Scale) Contains(root, key uint8) bool {
+	return sc&(1<<((int(key)-int(root%12)+12)%12)) != 0
+}
+
+// KeyScale returns the root and the Scale of the given key signature: major keys have ScaleMajor,
+// minor keys ScaleNaturalMinor.
+func KeyScale(k meta.Key) (root uint8, sc Scale) {
+	if k.IsMajor {
+		return k.Key % 12, ScaleMajor
+	}
+	return k.Key % 12, ScaleNaturalMinor
+}
+
+// ScalePolicy determines the scale member that replaces a key that is not part of the scale
+type ScalePolicy int
+
+const (
+	// SnapNearest uses the nearest member, the upper one if both are equally near
+	SnapNearest ScalePolicy = iota
+
+	// SnapNearestDown uses the nearest member, the lower one if both are equally near
+	SnapNearestDown
+
+	// SnapUp uses the next member above
+	SnapUp
+
+	// SnapDown uses the next member below
+	SnapDown
+)
+
+// CollisionPolicy determines what happens, if a replaced key is already used by another sounding note
+// of the same track and channel
+type CollisionPolicy int
+
+const (
+	// AvoidUnisons uses the next best scale member within an octave that is not used by another sounding note.
+	// If there is none, the key collides nevertheless.
+	AvoidUnisons CollisionPolicy = iota
+
+	// AllowUnisons always uses the scale member of the ScalePolicy
+	AllowUnisons
+)
+
+type scaleConfig struct {
+	collisions CollisionPolicy
+	keys       bool
+	drums
+}
+
+// ScaleOption is an option for ForceToScale (see also DetectDrums)
+type ScaleOption interface {
+	applyScale(*scaleConfig)
+}
+
+type scaleOption func(*scaleConfig)
+
+func (o scaleOption) applyScale(c *scaleConfig) {
+	o(c)
+}
+
+// ScaleCollisions sets the CollisionPolicy. Default is AvoidUnisons.
+func ScaleCollisions(policy CollisionPolicy) ScaleOption {
+	return scaleOption(func(c *scaleConfig) {
+		c.collisions = policy
+	})
+}
+
+// ScaleDrumChannels sets the channels that are skipped. Default is channel 9 (channel 10 in GM).
+func ScaleDrumChannels(channels ...uint8) ScaleOption {
+	return scaleOption(func(c *scaleConfig) {
+		c.drumChannels = channelSet(channels)
+		c.detectDrums = nil
+	})
+}
+
+// ScaleFromKeySignatures uses the root and the scale of the key signature (see KeyScale) that is valid
+// at the start of each note. The root and scale that are passed to ForceToScale are only used for the notes
+// before the first key signature.
+func ScaleFromKeySignatures() ScaleOption {
+	return scaleOption(func(c *scaleConfig) {
+		c.keys = true
+	})
+}
+
+// keyChange is the root and the scale of a key signature
+type keyChange struct {
+	absTicks uint64
+	root     uint8
+	scale    Scale
+}
+
+// ForceToScale returns a copy of the given SMF where the keys of the notes that are not part of the given scale
+// on the given root (0 is C, 1 is C# etc.) are replaced by a member of the scale according to the given policy.
+// The given SMF is not modified. Notes on drum channels (see ScaleDrumChannels) are not changed.
+//
+// Notes that are already part of the scale keep their keys, so that applying ForceToScale twice does not change
+// anything. By default, a replaced key does not collide with another sounding note on the same track and channel,
+// so that chords don't collapse to unisons (see ScaleCollisions).
+func ForceToScale(s *SMF, root uint8, sc Scale, policy ScalePolicy, options ...ScaleOption) *SMF {
+	c := scaleConfig{
+		drums: drums{drumChannels: map[uint8]bool{9: true}},
+	}
+
+	for _, opt := range options {
+		opt.applyScale(&c)
+	}
+
+	c.resolveDrums(s)
+
+	keys := []keyChange{{root: root % 12, scale: sc}}
+
+	if c.keys {
+		for _, ev := range s.Merged() {
+			if k, is := ev.Message.(meta.Key); is {
+				kc := keyChange{absTicks: ev.AbsTicks}
+				kc.root, kc.scale = KeyScale(k)
+
+				if last := &keys[len(keys)-1]; last.absTicks == kc.absTicks {
+					*last = kc
+					continue
+				}
+
+				keys = append(keys, kc)
+			}
+		}
+	}
+
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		notes := tr.Notes()
+		var byChannel = map[uint8][]int{}
+
+		for i, n := range notes {
+			if !c.drumChannels[n.Channel] {
+				byChannel[n.Channel] = append(byChannel[n.Channel], i)
+			}
+		}
+
+		for _, idx := range byChannel {
+			c.forceToScale(notes, idx, keys, policy)
+		}
+
+		// can't fail, since the notes are from the track
+		tr.SetNotes(notes)
+	}
+
+	return res
+}
+
+// forceToScale replaces the keys of the notes with the given indices (sorted by start) of the same channel
+func (c scaleConfig) forceToScale(notes []Note, idx []int, keys []keyChange, policy ScalePolicy) {
+	// byKey are the indices of the notes by their (new) key
+	var byKey [128][]int
+	var outside []int
+
+	for _, i := range idx {
+		n := &notes[i]
+		k := keyAt(keys, n.AbsTicks)
+
+		if n.Key > 127 || k.scale.Contains(k.root, n.Key) {
+			continue
+		}
+
+		outside = append(outside, i)
+	}
+
+	if len(outside) == 0 {
+		return
+	}
+
+	for _, i := range idx {
+		if notes[i].Key <= 127 {
+			byKey[notes[i].Key] = append(byKey[notes[i].Key], i)
+		}
+	}
+
+	for _, i := range outside {
+		n := &notes[i]
+		k := keyAt(keys, n.AbsTicks)
+		candidates := scaleCandidates(k.root, k.scale, n.Key, policy)
+
+		if len(candidates) == 0 {
+			continue
+		}
+
+		key := candidates[0]
+
+		if c.collisions == AvoidUnisons {
+			for _, cand := range candidates {
+				if !collides(notes, byKey[cand], i) {
+					key = cand
+					break
+				}
+			}
+		}
+
+		old := byKey[n.Key]
+
+		for j, o := range old {
+			if o == i {
+				byKey[n.Key] = append(old[:j:j], old[j+1:]...)
+				break
+			}
+		}
+
+		n.Key = key
+		byKey[key] = append(byKey[key], i)
+	}
+}
+
+// collides returns true, if one of the notes with the given indices sounds at the same time as the note with index i
+func collides(notes []Note, others []int, i int) bool {
+	n := notes[i]
+
+	for _, o := range others {
+		if o == i {
+			continue
+		}
+
+		m := notes[o]
+
+		if m.AbsTicks == n.AbsTicks || (m.AbsTicks < n.End() && n.AbsTicks < m.End()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keyAt returns the key change that is valid at the given tick
+func keyAt(keys []keyChange, absTicks uint64) keyChange {
+	i := sort.Search(len(keys), func(i int) bool {
+		return keys[i].absTicks > absTicks
+	}) - 1
+
+	if i < 0 {
+		i = 0
+	}
+
+	return keys[i]
+}
+
+// scaleCandidates returns the members of the scale within an octave around the given key in the order of preference
+// of the given policy: the member that the policy chooses comes first, the others follow by their distance.
+func scaleCandidates(root uint8, sc Scale, key uint8, policy ScalePolicy) []uint8 {
+	upFirst := policy == SnapNearest || policy == SnapUp
+	var res []uint8
+
+	for d := 1; d <= 12; d++ {
+		first, second := int(key)+d, int(key)-d
+
+		if !upFirst {
+			first, second = second, first
+		}
+
+		for _, k := range [2]int{first, second} {
+			if k >= 0 && k <= 127 && sc.Contains(root, uint8(k)) {
+				res = append(res, uint8(k))
+			}
+		}
+	}
+
+	// the member of the policy in the given direction comes first, even if the other direction is nearer
+	if policy == SnapUp || policy == SnapDown {
+		for i, k := range res {
+			if (policy == SnapUp) == (k > key) {
+				copy(res[1:i+1], res[:i])
+				res[0] = k
+				break
+			}
+		}
+	}
+
+	return res
+}
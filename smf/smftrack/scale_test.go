@@ -0,0 +1,186 @@
+package smftrack
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta/key"
+	"github.com/gomidi/midi/smf"
+)
+
+// scaleSMF returns a SMF with a note of each given key per quarter note on the given channel
+func scaleSMF(ch channel.Channel, keys ...uint8) *SMF {
+	var tr Track
+
+	for i, k := range keys {
+		tr.Add(uint64(i*480), ch.NoteOn(k, 100))
+		tr.Add(uint64(i*480+480), ch.NoteOff(k))
+	}
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+	return s
+}
+
+// noteKeys returns the keys of the notes of the given SMF
+func noteKeys(s *SMF) []uint8 {
+	var keys []uint8
+
+	for _, n := range s.Notes() {
+		keys = append(keys, n.Key)
+	}
+
+	return keys
+}
+
+func TestForceToScale(t *testing.T) {
+	keys := []uint8{60, 61, 63, 65, 66, 70}
+
+	tests := []struct {
+		root     uint8
+		scale    Scale
+		policy   ScalePolicy
+		expected []uint8
+	}{
+		{0, ScaleMajor, SnapNearest, []uint8{60, 62, 64, 65, 67, 71}},
+		{0, ScaleMajor, SnapNearestDown, []uint8{60, 60, 62, 65, 65, 69}},
+		{0, ScaleMajor, SnapUp, []uint8{60, 62, 64, 65, 67, 71}},
+		{0, ScaleMajor, SnapDown, []uint8{60, 60, 62, 65, 65, 69}},
+		{0, ScaleMajorPentatonic, SnapNearest, []uint8{60, 62, 64, 64, 67, 69}},
+		{0, ScaleMajorPentatonic, SnapUp, []uint8{60, 62, 64, 67, 67, 72}},
+		{9, ScaleHarmonicMinor, SnapNearest, []uint8{60, 62, 64, 65, 65, 71}},
+		{2, ScaleDorian, SnapNearestDown, []uint8{60, 60, 62, 65, 65, 69}},
+		{0, ScaleChromatic, SnapNearest, keys},
+		{0, NewScale(0, 4, 7), SnapNearest, []uint8{60, 60, 64, 64, 67, 72}},
+	}
+
+	for i, test := range tests {
+		s := scaleSMF(channel.Channel0, keys...)
+		res := ForceToScale(s, test.root, test.scale, test.policy)
+
+		if got := noteKeys(res); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("[%v] ForceToScale() keys = %v; want %v", i, got, test.expected)
+		}
+
+		// idempotence
+		if got, want := trackString(ForceToScale(res, test.root, test.scale, test.policy).Track(0)), trackString(res.Track(0)); got != want {
+			t.Errorf("[%v] second ForceToScale()\ngot:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+
+		// the given SMF is untouched
+		if got := noteKeys(s); !reflect.DeepEqual(got, keys) {
+			t.Errorf("[%v] given SMF has been modified: %v", i, got)
+		}
+	}
+
+	// drum channel
+	s := scaleSMF(channel.Channel9, keys...)
+
+	if got := noteKeys(ForceToScale(s, 0, ScaleMajor, SnapNearest)); !reflect.DeepEqual(got, keys) {
+		t.Errorf("ForceToScale() of drums = %v; want %v", got, keys)
+	}
+
+	if got, want := noteKeys(ForceToScale(s, 0, ScaleMajor, SnapNearest, ScaleDrumChannels())), tests[0].expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("ForceToScale() without drum channels = %v; want %v", got, want)
+	}
+
+	// detected drum channels
+	drums := func(*SMF) []uint8 { return []uint8{0} }
+
+	if got := noteKeys(ForceToScale(scaleSMF(channel.Channel0, keys...), 0, ScaleMajor, SnapNearest, DetectDrums(drums))); !reflect.DeepEqual(got, keys) {
+		t.Errorf("ForceToScale() of detected drums = %v; want %v", got, keys)
+	}
+
+	if got, want := noteKeys(ForceToScale(s, 0, ScaleMajor, SnapNearest, DetectDrums(drums))), tests[0].expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("ForceToScale() with other detected drums = %v; want %v", got, want)
+	}
+
+	if got := noteKeys(ForceToScale(s, 0, ScaleMajor, SnapNearest, DetectDrums(drums), ScaleDrumChannels(9))); !reflect.DeepEqual(got, keys) {
+		t.Errorf("ForceToScale() with explicit drum channels after detection = %v; want %v", got, keys)
+	}
+}
+
+func TestForceToScaleCollisions(t *testing.T) {
+	ch := channel.Channel0
+
+	chord := func(keys ...uint8) *SMF {
+		var tr Track
+
+		for _, k := range keys {
+			tr.Add(0, ch.NoteOn(k, 100))
+		}
+
+		for _, k := range keys {
+			tr.Add(960, ch.NoteOff(k))
+		}
+
+		s := New(smf.SMF0, smf.MetricTicks(480))
+		s.AddTrack(&tr)
+		return s
+	}
+
+	tests := []struct {
+		keys       []uint8
+		scale      Scale
+		policy     ScalePolicy
+		collisions CollisionPolicy
+		expected   []uint8
+	}{
+		// C# would collapse to the C of the chord, D is the next member
+		{[]uint8{60, 61, 64}, ScaleMajor, SnapNearestDown, AvoidUnisons, []uint8{60, 62, 64}},
+		{[]uint8{60, 61, 64}, ScaleMajor, SnapNearestDown, AllowUnisons, []uint8{60, 60, 64}},
+
+		// both C# and D# want to become D
+		{[]uint8{61, 63}, ScaleMajor, SnapDown, AvoidUnisons, []uint8{60, 62}},
+		{[]uint8{62, 63}, ScaleMajor, SnapDown, AvoidUnisons, []uint8{62, 64}},
+
+		// the only members within an octave are taken: the collision is unavoidable
+		{[]uint8{60, 61, 72}, NewScale(), SnapNearest, AvoidUnisons, []uint8{60, 60, 72}},
+	}
+
+	for i, test := range tests {
+		res := ForceToScale(chord(test.keys...), 0, test.scale, test.policy, ScaleCollisions(test.collisions))
+
+		if got := noteKeys(res); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("[%v] ForceToScale() keys = %v; want %v", i, got, test.expected)
+		}
+
+		if got, want := trackString(ForceToScale(res, 0, test.scale, test.policy, ScaleCollisions(test.collisions)).Track(0)), trackString(res.Track(0)); got != want {
+			t.Errorf("[%v] second ForceToScale()\ngot:\n%s\n\nwanted:\n%s\n\n", i, got, want)
+		}
+	}
+
+	// notes that don't sound at the same time don't collide
+	res := ForceToScale(scaleSMF(ch, 60, 61), 0, ScaleMajor, SnapNearestDown)
+
+	if got, want := noteKeys(res), []uint8{60, 60}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ForceToScale() keys = %v; want %v", got, want)
+	}
+}
+
+func TestForceToScaleKeySignatures(t *testing.T) {
+	s := scaleSMF(channel.Channel0, 68, 68, 60, 63)
+	tr := s.Track(0)
+	tr.Add(0, key.AMin())
+	tr.Add(960, key.EMaj())
+
+	tests := []struct {
+		options  []ScaleOption
+		expected []uint8
+	}{
+		// A minor: G# is between G and A, E major: C is between B and C#
+		{[]ScaleOption{ScaleFromKeySignatures()}, []uint8{69, 69, 61, 63}},
+		{nil, []uint8{69, 69, 60, 64}},
+	}
+
+	for i, test := range tests {
+		if got := noteKeys(ForceToScale(s, 0, ScaleMajor, SnapNearest, test.options...)); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("[%v] ForceToScale() keys = %v; want %v", i, got, test.expected)
+		}
+	}
+
+	if root, sc := KeyScale(key.EFlatMin()); root != 3 || sc != ScaleNaturalMinor {
+		t.Errorf("KeyScale(EFlatMin) = %v, %v; want 3, %v", root, sc, ScaleNaturalMinor)
+	}
+}
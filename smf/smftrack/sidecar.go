@@ -0,0 +1,291 @@
+package smftrack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+// Frozen is a serialized Index (see FreezeIndex and Thaw), e.g. to be stored in a sidecar file next to the SMF.
+//
+// The format starts with "gmIX", the version and the Fingerprint of the SMF, followed by the snapshot interval,
+// the number of events and the snapshots of the state.
+type Frozen []byte
+
+// ErrStale is returned by Thaw, if the Frozen index belongs to another SMF or to another version of the SMF
+var ErrStale = errors.New("frozen index is stale")
+
+const (
+	frozenMagic   = "gmIX"
+	frozenVersion = 1
+)
+
+// Fingerprint returns a SHA-256 hash of the format, the time format and the events (their ticks and raw bytes)
+// and ends of the tracks of the SMF. Tags and the preserved raw data are not part of the hash.
+func Fingerprint(s *SMF) [sha256.Size]byte {
+	h := sha256.New()
+	var buf []byte
+
+	buf = binary.AppendUvarint(buf, uint64(s.format.Type()))
+	buf = append(buf, s.timeFormat.String()...)
+	h.Write(buf)
+
+	for _, tr := range s.tracks {
+		buf = binary.AppendUvarint(buf[:0], uint64(len(tr.evs())))
+
+		for _, ev := range tr.evs() {
+			raw := ev.Message.Raw()
+			buf = binary.AppendUvarint(buf, ev.AbsTicks)
+			buf = binary.AppendUvarint(buf, uint64(len(raw)))
+			buf = append(buf, raw...)
+		}
+
+		buf = binary.AppendUvarint(buf, tr.end)
+		h.Write(buf)
+	}
+
+	var res [sha256.Size]byte
+	h.Sum(res[:0])
+	return res
+}
+
+// FreezeIndex builds the Index of the given SMF with snapshots every given ticks (see SnapshotInterval)
+// and serializes it. The events are not serialized, they are taken from the SMF by Thaw.
+func FreezeIndex(s *SMF, every uint64) Frozen {
+	idx := NewIndex(s, SnapshotInterval(every))
+	fp := Fingerprint(s)
+
+	var res = Frozen(frozenMagic)
+	res = append(res, frozenVersion)
+	res = append(res, fp[:]...)
+	res = binary.AppendUvarint(res, idx.interval)
+	res = binary.AppendUvarint(res, uint64(len(idx.events)))
+	res = binary.AppendUvarint(res, uint64(len(idx.snapshots)))
+
+	for _, snap := range idx.snapshots {
+		res = binary.AppendUvarint(res, snap.absTicks)
+		res = binary.AppendUvarint(res, uint64(snap.idx))
+		res = snap.state.appendBinary(res)
+	}
+
+	return res
+}
+
+// Thaw restores the Index of the given SMF from the Frozen index, without applying the events.
+// ErrStale is returned, if the Frozen index does not match the Fingerprint of the SMF, i.e. if the SMF has been
+// modified since the freezing. Then a new Index has to be built (see NewIndex and FreezeIndex).
+// An unknown version or corrupted data returns another error.
+func Thaw(s *SMF, f Frozen) (*Index, error) {
+	if !bytes.HasPrefix(f, []byte(frozenMagic)) || len(f) < len(frozenMagic)+1+sha256.Size {
+		return nil, fmt.Errorf("invalid frozen index: missing header")
+	}
+
+	rd := frozenReader{data: f[len(frozenMagic):]}
+
+	if v := rd.byte(); v != frozenVersion {
+		return nil, fmt.Errorf("invalid frozen index: unsupported version %v", v)
+	}
+
+	if fp := Fingerprint(s); !bytes.Equal(rd.bytes(sha256.Size), fp[:]) {
+		return nil, ErrStale
+	}
+
+	idx := &Index{events: s.Merged(), interval: rd.uvarint()}
+
+	if n := rd.uvarint(); n != uint64(len(idx.events)) {
+		return nil, ErrStale
+	}
+
+	if idx.interval == 0 {
+		return nil, fmt.Errorf("invalid frozen index: interval of 0 ticks")
+	}
+
+	n := rd.uvarint()
+
+	// a snapshot needs more than 16 bytes
+	if n == 0 || n > uint64(len(rd.data))/16 {
+		return nil, fmt.Errorf("invalid frozen index: invalid number of snapshots %v", n)
+	}
+
+	idx.snapshots = make([]snapshot, n)
+
+	for i := range idx.snapshots {
+		snap := &idx.snapshots[i]
+		snap.absTicks = rd.uvarint()
+		snap.idx = int(rd.uvarint())
+		rd.state(&snap.state)
+
+		if rd.err != nil {
+			return nil, fmt.Errorf("invalid frozen index: %v", rd.err)
+		}
+
+		if i == 0 && (snap.absTicks != 0 || snap.idx != 0) {
+			return nil, fmt.Errorf("invalid frozen index: first snapshot not at the start")
+		}
+
+		if snap.idx > len(idx.events) || (i > 0 && (snap.absTicks < idx.snapshots[i-1].absTicks || snap.idx < idx.snapshots[i-1].idx)) {
+			return nil, fmt.Errorf("invalid frozen index: snapshot %v out of order", i)
+		}
+	}
+
+	if len(rd.data) > 0 {
+		return nil, fmt.Errorf("invalid frozen index: %v bytes of trailing data", len(rd.data))
+	}
+
+	return idx, nil
+}
+
+// appendBinary appends the serialized state to b
+func (st *State) appendBinary(b []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(st.Tempo))
+	b = append(b, st.TimeSig.Numerator, st.TimeSig.Denominator, st.TimeSig.ClocksPerClick, st.TimeSig.DemiSemiQuaverPerQuarter)
+
+	if st.Key == nil {
+		b = append(b, 0)
+	} else {
+		var flags byte = 1
+
+		if st.Key.IsMajor {
+			flags |= 2
+		}
+
+		if st.Key.IsFlat {
+			flags |= 4
+		}
+
+		b = append(b, flags, st.Key.Key, st.Key.Num)
+	}
+
+	for i := range st.Channels {
+		c := &st.Channels[i]
+		b = append(b, byte(c.Program), byte(c.Aftertouch))
+		b = binary.BigEndian.AppendUint16(b, uint16(c.Pitchbend))
+
+		var set byte
+
+		for _, v := range c.Controllers {
+			if v >= 0 {
+				set++
+			}
+		}
+
+		b = append(b, set)
+
+		for cc, v := range c.Controllers {
+			if v >= 0 {
+				b = append(b, byte(cc), byte(v))
+			}
+		}
+	}
+
+	var active []byte
+
+	for ch := uint8(0); ch < 16; ch++ {
+		for key := uint8(0); key < 128; key++ {
+			if n := st.Notes.Count(ch, key); n > 0 {
+				active = append(active, ch, key, n)
+			}
+		}
+	}
+
+	b = binary.AppendUvarint(b, uint64(len(active)/3))
+	return append(b, active...)
+}
+
+// frozenReader reads the data of a Frozen index. The first error is kept, further reads return zero values.
+type frozenReader struct {
+	data []byte
+	err  error
+}
+
+func (r *frozenReader) bytes(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+
+	if len(r.data) < n {
+		r.err = fmt.Errorf("unexpected end of data")
+		r.data = nil
+		return make([]byte, n)
+	}
+
+	res := r.data[:n]
+	r.data = r.data[n:]
+	return res
+}
+
+func (r *frozenReader) byte() byte {
+	return r.bytes(1)[0]
+}
+
+func (r *frozenReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.data)
+
+	if n <= 0 {
+		r.err = fmt.Errorf("invalid varint")
+		r.data = nil
+		return 0
+	}
+
+	r.data = r.data[n:]
+	return v
+}
+
+// state reads a state that has been written by State.appendBinary
+func (r *frozenReader) state(st *State) {
+	st.Tempo = meta.Tempo(r.uvarint())
+	tsig := r.bytes(4)
+	st.TimeSig = meta.TimeSig{Numerator: tsig[0], Denominator: tsig[1], ClocksPerClick: tsig[2], DemiSemiQuaverPerQuarter: tsig[3]}
+
+	if flags := r.byte(); flags&1 != 0 {
+		k := r.bytes(2)
+		st.Key = &meta.Key{Key: k[0], Num: k[1], IsMajor: flags&2 != 0, IsFlat: flags&4 != 0}
+	}
+
+	for i := range st.Channels {
+		c := &st.Channels[i]
+		c.Program = int8(r.byte())
+		c.Aftertouch = int8(r.byte())
+		c.Pitchbend = int16(binary.BigEndian.Uint16(r.bytes(2)))
+
+		for cc := range c.Controllers {
+			c.Controllers[cc] = -1
+		}
+
+		for n := r.byte(); n > 0; n-- {
+			cc := r.bytes(2)
+
+			if cc[0] > 127 {
+				r.err = fmt.Errorf("invalid controller %v", cc[0])
+				return
+			}
+
+			c.Controllers[cc[0]] = int8(cc[1])
+		}
+	}
+
+	n := r.uvarint()
+
+	if n > uint64(len(r.data))/3 {
+		r.err = fmt.Errorf("invalid number of notes %v", n)
+		return
+	}
+
+	for ; n > 0; n-- {
+		note := r.bytes(3)
+		ch := channel.Channel(note[0] & 0x0F)
+
+		for i := note[2]; i > 0; i-- {
+			st.Notes.Track(ch.NoteOn(note[1]&0x7F, 1))
+		}
+	}
+}
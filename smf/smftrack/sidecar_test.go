@@ -0,0 +1,75 @@
+package smftrack
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+func TestFreezeThaw(t *testing.T) {
+	s := indexSMF()
+
+	for _, interval := range []uint64{1, 480, 100000} {
+		idx, err := Thaw(s, FreezeIndex(s, interval))
+
+		if err != nil {
+			t.Fatalf("[%v] Thaw: %v", interval, err)
+		}
+
+		want := NewIndex(s, SnapshotInterval(interval))
+
+		if !reflect.DeepEqual(idx, want) {
+			t.Errorf("[%v] thawed index differs from NewIndex", interval)
+		}
+
+		for tick := uint64(0); tick < 4000; tick += 60 {
+			if got, want := idx.StateAt(tick), StateAt(s, tick); !reflect.DeepEqual(got, want) {
+				t.Errorf("[%v] StateAt(%v) = %+v; want %+v", interval, tick, got, want)
+			}
+		}
+	}
+}
+
+func TestThawStale(t *testing.T) {
+	s := indexSMF()
+	f := FreezeIndex(s, 480)
+
+	fp := Fingerprint(s)
+	s.Tracks()[1].Add(3000, channel.Channel2.ControlChange(7, 10))
+
+	if Fingerprint(s) == fp {
+		t.Errorf("Fingerprint has not changed after adding an event")
+	}
+
+	if _, err := Thaw(s, f); !errors.Is(err, ErrStale) {
+		t.Errorf("Thaw of a modified SMF returned %v; want ErrStale", err)
+	}
+
+	if _, err := Thaw(indexSMF(), f); err != nil {
+		t.Errorf("Thaw of an equal SMF returned %v", err)
+	}
+}
+
+func TestThawErrors(t *testing.T) {
+	s := indexSMF()
+	f := FreezeIndex(s, 480)
+
+	tests := []struct {
+		descr  string
+		frozen Frozen
+	}{
+		{"empty", nil},
+		{"no magic", append(Frozen("gmIY"), f[4:]...)},
+		{"unknown version", append(append(Frozen("gmIX"), 2), f[5:]...)},
+		{"truncated", f[:len(f)-3]},
+		{"trailing data", append(append(Frozen{}, f...), 0)},
+	}
+
+	for _, test := range tests {
+		if _, err := Thaw(s, test.frozen); err == nil || errors.Is(err, ErrStale) {
+			t.Errorf("[%s] Thaw returned %v; want an error other than ErrStale", test.descr, err)
+		}
+	}
+}
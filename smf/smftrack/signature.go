@@ -0,0 +1,207 @@
+package smftrack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/internal/vlq"
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+/*
+A Signature is stored as a sequencer specific meta message (FF 7F) at tick 0 of the first track, in the same
+way as a TrackMeta:
+
+	FF 7F <len> 7D 67 6D 53 47 <version> [<field>]...
+
+7D is the manufacturer ID that is reserved for non-commercial use, followed by "gmSG" and the version of the
+format (currently 01). Each field consists of a tag byte, the length of the field data as variable length
+quantity and the field data:
+
+	4E ('N') name:        <name>
+	56 ('V') version:     <version>
+	54 ('T') created:     <time in RFC 3339 format>
+	48 ('H') fingerprint: 20 <SHA-256 hash>
+
+Fields with unknown tags are skipped, so that newer versions may add fields.
+*/
+
+// signaturePrefix is the beginning of the sequencer specific data of a Signature
+var signaturePrefix = []byte{0x7D, 'g', 'm', 'S', 'G'}
+
+const signatureVersion = 0x01
+
+// field tags of the Signature data
+const (
+	signatureName        = 'N'
+	signatureVersionTag  = 'V'
+	signatureCreated     = 'T'
+	signatureFingerprint = 'H'
+)
+
+// Signature identifies the tool that has produced a SMF (see Sign)
+type Signature struct {
+	// Name and Version are the name and version of the tool
+	Name    string
+	Version string
+
+	// Created is the time of the signing, in seconds
+	Created time.Time
+
+	// Fingerprint is the Fingerprint of the SMF without its signature at the time of the signing
+	Fingerprint [sha256.Size]byte
+}
+
+// String returns the name and version of the tool and the time of the signing
+func (s Signature) String() string {
+	return fmt.Sprintf("%s %s (%s)", s.Name, s.Version, s.Created.Format(time.RFC3339))
+}
+
+// encode returns the sequencer specific data of the Signature
+func (s Signature) encode() []byte {
+	var bf bytes.Buffer
+	bf.Write(signaturePrefix)
+	bf.WriteByte(signatureVersion)
+
+	field := func(tag byte, data []byte) {
+		bf.WriteByte(tag)
+		bf.Write(vlq.Encode(uint32(len(data))))
+		bf.Write(data)
+	}
+
+	field(signatureName, []byte(s.Name))
+	field(signatureVersionTag, []byte(s.Version))
+	field(signatureCreated, []byte(s.Created.Format(time.RFC3339)))
+	field(signatureFingerprint, s.Fingerprint[:])
+
+	return bf.Bytes()
+}
+
+// decodeSignature decodes the sequencer specific data of a Signature
+func decodeSignature(data []byte) (s Signature, err error) {
+	if !bytes.HasPrefix(data, signaturePrefix) || len(data) < len(signaturePrefix)+1 {
+		return s, fmt.Errorf("not a signature")
+	}
+
+	rd := bytes.NewReader(data[len(signaturePrefix)+1:])
+	var hasFingerprint bool
+
+	for rd.Len() > 0 {
+		tag, _ := rd.ReadByte()
+		length, err := midilib.ReadVarLength(rd)
+
+		if err != nil {
+			return s, err
+		}
+
+		if length > uint32(rd.Len()) {
+			return s, fmt.Errorf("field %q: length %v exceeds the remaining %v bytes", tag, length, rd.Len())
+		}
+
+		fd := make([]byte, length)
+
+		if _, err := io.ReadFull(rd, fd); err != nil {
+			return s, fmt.Errorf("field %q: %v", tag, err)
+		}
+
+		switch tag {
+		case signatureName:
+			s.Name = string(fd)
+		case signatureVersionTag:
+			s.Version = string(fd)
+		case signatureCreated:
+			if s.Created, err = time.Parse(time.RFC3339, string(fd)); err != nil {
+				return s, fmt.Errorf("invalid creation time: %v", err)
+			}
+		case signatureFingerprint:
+			if len(fd) != sha256.Size {
+				return s, fmt.Errorf("invalid fingerprint of %v bytes", len(fd))
+			}
+			copy(s.Fingerprint[:], fd)
+			hasFingerprint = true
+		}
+	}
+
+	if !hasFingerprint {
+		return s, fmt.Errorf("missing fingerprint")
+	}
+
+	return s, nil
+}
+
+// unsignedFingerprint returns the Fingerprint of the SMF without the sequencer specific messages of signatures,
+// i.e. the Fingerprint of the SMF before it has been signed
+func unsignedFingerprint(s *SMF) [sha256.Size]byte {
+	unsigned := New(s.format, s.timeFormat)
+
+	for _, tr := range s.tracks {
+		var evts = make([]Event, 0, len(tr.evs()))
+
+		for _, ev := range tr.evs() {
+			if sd, is := ev.Message.(meta.SequencerData); is && bytes.HasPrefix(sd.Data(), signaturePrefix) {
+				continue
+			}
+			evts = append(evts, ev)
+		}
+
+		unsigned.tracks = append(unsigned.tracks, &Track{events: evts, end: tr.end})
+	}
+
+	return Fingerprint(unsigned)
+}
+
+// Sign returns a copy of the given SMF that is signed by the tool of the given name and version, so that it can be
+// identified later (see ReadSignature). The given SMF is not modified.
+//
+// The Signature is written as sequencer specific message at tick 0 of the first track, after the leading meta messages.
+// It contains the Fingerprint of the SMF without the signature, so that later modifications can be detected.
+// A previous signature is replaced. An error is returned, if the SMF has no tracks.
+func Sign(s *SMF, name, version string) (*SMF, error) {
+	if len(s.tracks) == 0 {
+		return nil, fmt.Errorf("can't sign SMF without tracks")
+	}
+
+	res := s.clone()
+
+	// the previous signature is not part of the fingerprint
+	sig := Signature{
+		Name:        name,
+		Version:     version,
+		Created:     time.Now().UTC().Truncate(time.Second),
+		Fingerprint: unsignedFingerprint(res),
+	}
+
+	if err := res.tracks[0].setSequencerData(signaturePrefix, sig.encode()); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ReadSignature returns the Signature of the given SMF (see Sign) and true, if the SMF has been signed and not
+// been modified since then, i.e. if the Fingerprint of the Signature matches the SMF without the signature.
+// If the SMF has been modified, the Signature is returned with false. If there is no valid Signature in the first
+// track, the zero value is returned with false.
+func ReadSignature(s *SMF) (Signature, bool) {
+	if len(s.tracks) == 0 {
+		return Signature{}, false
+	}
+
+	data := s.tracks[0].sequencerData(signaturePrefix)
+
+	if data == nil {
+		return Signature{}, false
+	}
+
+	sig, err := decodeSignature(data)
+
+	if err != nil {
+		return Signature{}, false
+	}
+
+	return sig, sig.Fingerprint == unsignedFingerprint(s)
+}
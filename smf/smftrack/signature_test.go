@@ -0,0 +1,132 @@
+package smftrack
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestSignature(t *testing.T) {
+	var tr Track
+	tr.Add(0, meta.Track("piano"), channel.Channel0.NoteOn(60, 100))
+	tr.Add(480, channel.Channel0.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	if sig, ok := ReadSignature(s); ok || sig != (Signature{}) {
+		t.Errorf("ReadSignature() of unsigned SMF = %v, %v; want zero value, false", sig, ok)
+	}
+
+	before := time.Now().Add(-time.Second)
+	signed, err := Sign(s, "arranger", "1.2.0")
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	expected := `0 meta.Track: "piano"
+0 meta.SequencerData len 79
+0 channel.NoteOn channel 1 key 60 velocity 100
+480 channel.NoteOff channel 1 key 60
+480 end
+`
+
+	if got := trackString(signed.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	if got, want := len(s.Track(0).Events()), 3; got != want {
+		t.Errorf("source has been modified: %v events; want %v", got, want)
+	}
+
+	// sign -> write -> read -> verify
+	var bf bytes.Buffer
+
+	if err := signed.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	read, err := Read(&bf)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	sig, ok := ReadSignature(read)
+
+	if !ok {
+		t.Errorf("ReadSignature() after round trip = %v, false; want true", sig)
+	}
+
+	if sig.Name != "arranger" || sig.Version != "1.2.0" || sig.Created.Before(before) || sig.Created.After(time.Now()) {
+		t.Errorf("ReadSignature() = %v; want arranger 1.2.0, created now", sig)
+	}
+
+	if got, want := sig.Fingerprint, Fingerprint(s); got != want {
+		t.Errorf("fingerprint = %X; want fingerprint of the unsigned SMF %X", got, want)
+	}
+
+	// sign -> modify -> verify fails
+	modified := read.clone()
+	modified.Track(0).Add(240, channel.Channel0.ControlChange(7, 100))
+
+	if sig, ok := ReadSignature(modified); ok || sig.Name != "arranger" {
+		t.Errorf("ReadSignature() of modified SMF = %v, %v; want arranger, false", sig, ok)
+	}
+
+	// double signing replaces the signature
+	resigned, err := Sign(modified, "mixer", "0.9")
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var signatures int
+
+	for _, ev := range resigned.Track(0).Events() {
+		if sd, is := ev.Message.(meta.SequencerData); is && bytes.HasPrefix(sd.Data(), signaturePrefix) {
+			signatures++
+		}
+	}
+
+	if signatures != 1 {
+		t.Errorf("%v signatures after double signing; want 1", signatures)
+	}
+
+	if sig, ok := ReadSignature(resigned); !ok || sig.Name != "mixer" || sig.Version != "0.9" {
+		t.Errorf("ReadSignature() after double signing = %v, %v; want mixer 0.9, true", sig, ok)
+	}
+
+	// signing the signed SMF again results in the same fingerprint
+	again, _ := Sign(resigned, "mixer", "0.9")
+
+	if a, b := readFingerprint(t, again), readFingerprint(t, resigned); a != b {
+		t.Errorf("fingerprint of double signed SMF = %X; want %X", a, b)
+	}
+
+	if _, err := Sign(New(smf.SMF1, smf.MetricTicks(480)), "x", "1"); err == nil {
+		t.Errorf("Sign() of SMF without tracks must return an error")
+	}
+}
+
+func TestDecodeSignatureLength(t *testing.T) {
+	// a length beyond the data is rejected before allocating
+	if _, err := decodeSignature([]byte{0x7D, 'g', 'm', 'S', 'G', 0x01, 'N', 0xFF, 0xFF, 0xFF, 0x7F}); err == nil {
+		t.Errorf("expected error for a length beyond the data")
+	}
+}
+
+func readFingerprint(t *testing.T, s *SMF) [32]byte {
+	sig, ok := ReadSignature(s)
+
+	if !ok {
+		t.Fatalf("ReadSignature() = %v, false; want true", sig)
+	}
+
+	return sig.Fingerprint
+}
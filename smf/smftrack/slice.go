@@ -0,0 +1,99 @@
+package smftrack
+
+import (
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// Slice returns a copy of the region from (inclusive) to (exclusive) of the given SMF.
+// The region is moved to the start: an event at tick from is at tick 0 in the result.
+// The given SMF is not modified.
+//
+// The state at from (tempo, time signature, key, programs, controllers, pitch bend and aftertouch) is
+// chased and reestablished at the start of each track. Notes that start before from are left out,
+// notes that last beyond to are ended at the end of the slice.
+// The messages that reestablish the state and the note off messages at the end of the slice have no tag.
+func Slice(s *SMF, from, to uint64) *SMF {
+	res := New(s.format, s.timeFormat)
+	res.inheritProvenance(s)
+	res.trackPolicy = s.trackPolicy
+
+	for _, tr := range s.tracks {
+		res.tracks = append(res.tracks, tr.slice(from, to))
+	}
+
+	return res
+}
+
+func (t *Track) slice(from, to uint64) *Track {
+	var res Track
+
+	if to < from {
+		to = from
+	}
+
+	end := t.end
+	if end > to {
+		end = to
+	}
+	if end < from {
+		end = from
+	}
+
+	res.end = end - from
+
+	// the note on and note off events of notes that are included, by index
+	var ons = map[int]bool{}
+	var offs = map[int]bool{}
+	var included []Note
+	var evts []Event
+
+	for _, n := range t.Notes() {
+		if n.AbsTicks < from || n.AbsTicks >= to {
+			continue
+		}
+
+		included = append(included, n)
+		ons[n.on] = true
+
+		if n.off >= 0 && n.End() < to {
+			offs[n.off] = true
+		}
+	}
+
+	var c chaser
+
+	for i, ev := range t.evs() {
+		switch ev.Message.(type) {
+		case channel.NoteOn, channel.NoteOff, channel.NoteOffVelocity:
+			if ons[i] || offs[i] {
+				evts = append(evts, Event{AbsTicks: ev.AbsTicks - from, Message: ev.Message, Tag: ev.Tag})
+			}
+			continue
+		}
+
+		if ev.AbsTicks < from {
+			c.add(ev.Message)
+			continue
+		}
+
+		if ev.AbsTicks < to {
+			evts = append(evts, Event{AbsTicks: ev.AbsTicks - from, Message: ev.Message, Tag: ev.Tag})
+		}
+	}
+
+	// end the notes that last beyond the slice
+	for _, n := range included {
+		if n.off < 0 || !offs[n.off] {
+			evts = append(evts, Event{AbsTicks: res.end, Message: channel.Channel(n.Channel).NoteOff(n.Key)})
+		}
+	}
+
+	var state []Event
+
+	for _, msg := range c.messages() {
+		state = append(state, Event{Message: msg})
+	}
+
+	res.SetEvents(append(state, evts...))
+	return &res
+}
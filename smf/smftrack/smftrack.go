@@ -0,0 +1,299 @@
+package smftrack
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// SMF is a Standard MIDI File that is kept in memory.
+//
+// The functions of this package that transform a SMF (e.g. Slice or Legato) never modify the given SMF, but return
+// a copy. So a SMF may be shared across goroutines, as long as it is not modified by the methods of the SMF and its
+// tracks. To enforce this, the SMF can be frozen before it is shared (see Freeze).
+type SMF struct {
+	format     smf.Format
+	timeFormat smf.TimeFormat
+	tracks     []*Track
+
+	// preserved is only set, if the SMF was read with the smfreader.Preserve option
+	preserved *preserved
+
+	// warnings are only set, if the SMF was read with a policy that warns (see smfreader.Policy)
+	warnings []smfreader.Warning
+
+	// trackPolicy defines, what happens if a track is added to a SMF format 0 (see SetTrackPolicy)
+	trackPolicy TrackPolicy
+
+	// provenance are the sources of the events by their tags (see RecordProvenance). It is never modified.
+	provenance map[uint64]Source
+
+	// frozen prevents modifications (see Freeze)
+	frozen bool
+}
+
+// preserved is the raw data of a SMF that is kept beside the raw data of the tracks
+type preserved struct {
+	header  smf.Header
+	raw     []byte
+	trailer []byte
+}
+
+// New returns a new SMF of the given format and timeformat without any tracks.
+// If timeformat is nil, smf.MetricTicks(960) will be used.
+func New(format smf.Format, timeformat smf.TimeFormat) *SMF {
+	if timeformat == nil {
+		timeformat = smf.MetricTicks(960)
+	}
+	return &SMF{format: format, timeFormat: timeformat}
+}
+
+// Format returns the SMF format
+func (s *SMF) Format() smf.Format {
+	return s.format
+}
+
+// TimeFormat returns the time format
+func (s *SMF) TimeFormat() smf.TimeFormat {
+	return s.timeFormat
+}
+
+// Header returns the header that corresponds to the current state of the SMF
+func (s *SMF) Header() smf.Header {
+	return smf.Header{Format: s.format, NumTracks: s.NumTracks(), TimeFormat: s.timeFormat}
+}
+
+// NumTracks returns the number of tracks
+func (s *SMF) NumTracks() uint16 {
+	return uint16(len(s.tracks))
+}
+
+// Track returns the track with the given number (starting with 0).
+// It panics, if the track does not exist.
+func (s *SMF) Track(no int) *Track {
+	return s.tracks[no]
+}
+
+// clone returns a deep copy of the SMF
+func (s *SMF) clone() *SMF {
+	res := New(s.format, s.timeFormat)
+	res.preserved = s.preserved
+	res.provenance = s.provenance
+	res.trackPolicy = s.trackPolicy
+
+	for _, tr := range s.tracks {
+		res.tracks = append(res.tracks, tr.clone())
+	}
+
+	return res
+}
+
+// Warnings returns the warnings of reading the SMF, if it was read with a policy that warns (see smfreader.Policy).
+func (s *SMF) Warnings() []smfreader.Warning {
+	return s.warnings
+}
+
+// Tracks returns all tracks
+func (s *SMF) Tracks() []*Track {
+	res := make([]*Track, len(s.tracks))
+	copy(res, s.tracks)
+	return res
+}
+
+// AddTrack adds the given track at the end of the tracks.
+// If the SMF has format 0 and already a track, it is converted to format 1 before (see ToFormat1), unless the
+// track policy is RejectTracks (see SetTrackPolicy), which returns ErrTrackCount. The conversion changes the track
+// list: the conductor messages are moved out of the existing track into a new first track, so the existing track
+// becomes the second track and the given track the third one. The existing *Track stays the track of the
+// other messages.
+// ErrFrozen is returned, if the SMF is frozen.
+func (s *SMF) AddTrack(t *Track) error {
+	if s.frozen {
+		return ErrFrozen
+	}
+
+	if s.format == smf.SMF0 && len(s.tracks) > 0 {
+		if s.trackPolicy == RejectTracks {
+			return fmt.Errorf("can't add track %v: %w", len(s.tracks), ErrTrackCount)
+		}
+
+		if len(s.tracks) > 1 {
+			return fmt.Errorf("can't convert to SMF format 1: %w", ErrTrackCount)
+		}
+
+		conductor, err := s.tracks[0].splitConductor()
+
+		if err != nil {
+			return err
+		}
+
+		s.format, s.tracks = smf.SMF1, []*Track{conductor, s.tracks[0]}
+	}
+
+	s.tracks = append(s.tracks, t)
+	return nil
+}
+
+// ReadFile reads the SMF file
+func ReadFile(file string, options ...smfreader.Option) (*SMF, error) {
+	f, err := os.Open(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return Read(f, options...)
+}
+
+// Read reads the SMF data from src
+func Read(src io.Reader, options ...smfreader.Option) (*SMF, error) {
+	return ReadFrom(smfreader.New(src, options...))
+}
+
+// ReadFrom reads all tracks from the given smf.Reader.
+// If the reader has been created with the smfreader.RetainPositions option,
+// the positions of the events are kept (see Track.Position).
+// If the reader has been created with the smfreader.Preserve option, the raw data is kept,
+// so that Write reproduces the SMF byte by byte, as long as it has not been modified.
+// If the reader has been created with a policy that warns (see smfreader.Policy), the warnings are kept (see Warnings).
+func ReadFrom(rd smf.Reader) (*SMF, error) {
+	err := rd.ReadHeader()
+
+	if err != nil {
+		return nil, err
+	}
+
+	h := rd.Header()
+	s := New(h.Format, h.TimeFormat)
+
+	var (
+		msg   midi.Message
+		track *Track
+		abs   uint64
+	)
+
+	for {
+		msg, err = rd.Read()
+
+		if err != nil {
+			break
+		}
+
+		if no := int(rd.Track()); no >= len(s.tracks) {
+			track = &Track{}
+			s.tracks = append(s.tracks, track)
+			abs = 0
+		}
+
+		abs += uint64(rd.Delta())
+
+		if p := smfreader.PreservedOf(rd); p != nil {
+			if s.preserved == nil {
+				s.preserved = &preserved{header: h, raw: p.Header}
+			}
+
+			if p.Prefix != nil {
+				track.prefix = p.Prefix
+			}
+
+			track.raw = append(track.raw, p.Event)
+
+			if p.Trailer != nil {
+				s.preserved.trailer = p.Trailer
+			}
+		}
+
+		if msg == meta.EndOfTrack {
+			track.end = abs
+			continue
+		}
+
+		track.events = append(track.evs(), Event{AbsTicks: abs, Message: msg})
+		track.end = abs
+
+		if pos := smfreader.PositionOf(rd); pos != nil {
+			track.positions = append(track.positions, *pos)
+		}
+	}
+
+	// a missing end of track at the end of the last track is tolerated
+	if err != smf.ErrFinished && err != io.EOF {
+		return nil, err
+	}
+
+	s.warnings = smfreader.WarningsOf(rd)
+
+	// unless the policy fails on truncated data, missing tracks are tolerated
+	if len(s.tracks) != int(h.NumTracks) && smfreader.PolicyOf(rd).TruncatedData == smf.Fail {
+		return nil, smfreader.ErrMissing
+	}
+
+	return s, nil
+}
+
+// WriteFile writes the SMF to the given file.
+// The options Format, NumTracks and TimeFormat are overwritten by the properties of the SMF.
+func (s *SMF) WriteFile(file string, options ...smfwriter.Option) error {
+	return smfwriter.WriteFile(file, func(wr smf.Writer) {
+		s.writeTracks(wr)
+	}, s.writerOptions(options)...)
+}
+
+// Write writes the SMF to dest.
+// The options Format, NumTracks and TimeFormat are overwritten by the properties of the SMF.
+//
+// If no options are given, the encoded tracks are cached until they are modified, so that writing a copy of
+// a SMF where only some tracks have changed (e.g. by a transforming function) only encodes the changed tracks.
+// Tracks that have been read by ReadLazy and are not modified are written without being decoded and encoded.
+// If the SMF was read with the smfreader.Preserve option, the preserved raw data is written for the header and
+// the tracks that have not been modified (see VerifyRoundTrip).
+func (s *SMF) Write(dest io.Writer, options ...smfwriter.Option) error {
+	if len(options) == 0 {
+		return s.writeChunks(dest)
+	}
+	return s.writeTracks(smfwriter.New(dest, s.writerOptions(options)...))
+}
+
+func (s *SMF) writerOptions(options []smfwriter.Option) []smfwriter.Option {
+	return append(options,
+		smfwriter.Format(s.format),
+		smfwriter.NumTracks(s.NumTracks()),
+		smfwriter.TimeFormat(s.timeFormat),
+	)
+}
+
+func (s *SMF) writeTracks(wr smf.Writer) error {
+	if err := s.checkTrackCount(); err != nil {
+		return err
+	}
+
+	for _, t := range s.tracks {
+		err := t.writeTo(wr)
+		if err != nil && err != smf.ErrFinished {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkTrackCount returns an error, if the SMF has no tracks or is a SMF format 0 with more than one track,
+// so that the written header never contradicts the tracks
+func (s *SMF) checkTrackCount() error {
+	switch {
+	case len(s.tracks) == 0:
+		return fmt.Errorf("SMF has no tracks")
+	case s.format == smf.SMF0 && len(s.tracks) > 1:
+		return fmt.Errorf("can't write %v tracks: %w", len(s.tracks), ErrTrackCount)
+	}
+
+	return nil
+}
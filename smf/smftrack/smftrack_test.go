@@ -0,0 +1,141 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/internal/examples"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+)
+
+func TestNewDefaultTimeFormat(t *testing.T) {
+	if got, want := New(smf.SMF0, nil).TimeFormat(), smf.TimeFormat(smf.MetricTicks(960)); got != want {
+		t.Errorf("TimeFormat() = %v; want %v", got, want)
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	s, err := Read(bytes.NewReader(examples.SpecSMF1))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := s.Header().String(), "<Format: SMF1 (multitrack), NumTracks: 4, TimeFormat: 96 MetricTicks>"; got != want {
+		t.Errorf("Header() = %#v; want %#v", got, want)
+	}
+
+	var bf bytes.Buffer
+	err = s.Write(&bf)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := fmt.Sprintf("% X", bf.Bytes()), fmt.Sprintf("% X", examples.SpecSMF1); got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	var tr Track
+	tr.Add(10, channel.Channel0.NoteOn(60, 100))
+	tr.Add(0, channel.Channel0.ProgramChange(3))
+	tr.Add(10, channel.Channel0.NoteOn(64, 100))
+	tr.Add(20, channel.Channel0.NoteOff(60), channel.Channel0.NoteOff(64))
+
+	var bf bytes.Buffer
+
+	for _, ev := range tr.Events() {
+		fmt.Fprintf(&bf, "%v %s\n", ev.AbsTicks, ev.Message)
+	}
+
+	expected := `0 channel.ProgramChange channel 1 program 3
+10 channel.NoteOn channel 1 key 60 velocity 100
+10 channel.NoteOn channel 1 key 64 velocity 100
+20 channel.NoteOff channel 1 key 60
+20 channel.NoteOff channel 1 key 64
+`
+
+	if got, want := bf.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if got, want := tr.End(), uint64(20); got != want {
+		t.Errorf("End() = %v; want %v", got, want)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	src := examples.SpecSMF0
+	s, err := Read(bytes.NewReader(src), smfreader.RetainPositions())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	tr := s.Track(0)
+
+	// the raw bytes of each event (delta time + event) as found in the file
+	expected := []string{
+		"00 FF 58 04 04 02 18 08",
+		"00 FF 51 03 07 A1 20",
+		"00 C0 05",
+		"00 C1 2E",
+		"00 C2 46",
+		"00 92 30 60",
+		"00 3C 60", // running status
+		"60 91 43 40",
+		"60 90 4C 20",
+		"81 40 82 30 40",
+		"00 3C 40", // running status
+		"00 81 43 40",
+		"00 80 4C 40",
+	}
+
+	if got, want := tr.Len(), len(expected); got != want {
+		t.Fatalf("Len() = %v; want %v", got, want)
+	}
+
+	for i, exp := range expected {
+		pos := tr.Position(i)
+
+		if pos == nil {
+			t.Fatalf("Position(%v) = nil", i)
+		}
+
+		if got, want := fmt.Sprintf("% X", src[pos.Offset:pos.Offset+pos.Length]), exp; got != want {
+			t.Errorf("bytes at Position(%v) [%s] = %#v; want %#v", i, tr.Event(i).Message, got, want)
+		}
+	}
+
+	// read only operations keep the positions
+	_ = tr.Events()
+	_ = s.Write(&bytes.Buffer{})
+
+	if tr.Position(0) == nil {
+		t.Errorf("Position(0) must not be nil after read only operations")
+	}
+
+	// modification invalidates the positions
+	tr.Add(0, channel.Channel0.ProgramChange(2))
+
+	if got := tr.Position(0); got != nil {
+		t.Errorf("Position(0) = %v; want nil after modification", got)
+	}
+}
+
+func TestNoPositions(t *testing.T) {
+	s, err := Read(bytes.NewReader(examples.SpecSMF0))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got := s.Track(0).Position(0); got != nil {
+		t.Errorf("Position(0) = %v; want nil without RetainPositions option", got)
+	}
+}
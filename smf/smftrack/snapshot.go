@@ -0,0 +1,240 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+// snapshotMarker is the beginning of the text of the marker that precedes the messages of a snapshot.
+// It is followed by the number of messages.
+const snapshotMarker = "gomidi snapshot "
+
+// powerOnValue returns the value of the given controller after power on (General MIDI)
+func powerOnValue(controller uint8) uint8 {
+	switch controller {
+	case 7:
+		return 100
+	case 10:
+		return 64
+	case 11:
+		return 127
+	}
+	return 0
+}
+
+// snapshot returns the channel messages that reestablish the state of the channels, as far as it differs from the
+// state after power on: for each channel the bank select and the program, if one of them is not 0, the other
+// controllers, the pitch bend and the aftertouch.
+func (c *chaser) snapshot() (msgs []midi.Message) {
+	for ch := 0; ch < 16; ch++ {
+		ctrls := &c.controllers[ch]
+		patch := c.program[ch] != nil && c.program[ch].Program() != 0
+
+		for _, cc := range []uint8{0, 32} {
+			if ctrls[cc] != nil && ctrls[cc].Value() != 0 {
+				patch = true
+			}
+		}
+
+		if patch {
+			for _, cc := range []uint8{0, 32} {
+				if ctrls[cc] != nil {
+					msgs = append(msgs, *ctrls[cc])
+				}
+			}
+
+			if c.program[ch] != nil {
+				msgs = append(msgs, *c.program[ch])
+			}
+		}
+
+		for cc, v := range ctrls {
+			if v != nil && cc != 0 && cc != 32 && v.Value() != powerOnValue(uint8(cc)) {
+				msgs = append(msgs, *v)
+			}
+		}
+
+		if c.pitchbend[ch] != nil && c.pitchbend[ch].Value() != 0 {
+			msgs = append(msgs, *c.pitchbend[ch])
+		}
+
+		if c.aftertouch[ch] != nil && c.aftertouch[ch].Pressure() != 0 {
+			msgs = append(msgs, *c.aftertouch[ch])
+		}
+	}
+
+	return
+}
+
+// SnapshotReport describes the snapshots that InjectSnapshots has inserted
+type SnapshotReport struct {
+	// Snapshots is the number of inserted snapshots (a snapshot is inserted for each track and boundary)
+	Snapshots int
+
+	// Messages is the number of inserted messages, without the markers
+	Messages int
+
+	// SizeIncrease is the increase of the size of the written SMF in bytes
+	SizeIncrease int
+}
+
+// InjectSnapshots returns a copy of the given SMF with snapshots of the channel state every given number of bars,
+// so that players that start in the middle of the file (e.g. after seeking in a stream) get the programs,
+// controllers and pitch bends right. The given SMF is not modified.
+//
+// The snapshots are inserted at the start of every given bars (but not at tick 0), before the other events at the
+// tick. Each track gets the state that has been established by its own messages: a marker, followed by the bank
+// selects and programs, the other controllers (see Slice), the pitch bends and aftertouch of the channels, as far as
+// they differ from the state after power on (General MIDI). Tracks without such state get no snapshot, and the
+// tracks are not extended by snapshots after their end.
+// The markers allow RemoveSnapshots to remove the snapshots again.
+//
+// Since the values after power on are not repeated, the player must be reset before it starts in the middle of the
+// file. An error is returned, if the SMF can't be written.
+func InjectSnapshots(s *SMF, everyBars uint64) (*SMF, SnapshotReport, error) {
+	var rep SnapshotReport
+	res := s.clone()
+
+	if everyBars == 0 {
+		return res, rep, nil
+	}
+
+	cache := map[int]*MeterMap{}
+
+	for no, tr := range res.tracks {
+		tr.injectSnapshots(boundaries(res.trackMeterMap(no, cache), everyBars, tr.end), &rep)
+	}
+
+	before, err := encodedSize(s)
+
+	if err != nil {
+		return nil, rep, err
+	}
+
+	after, err := encodedSize(res)
+
+	if err != nil {
+		return nil, rep, err
+	}
+
+	rep.SizeIncrease = after - before
+	return res, rep, nil
+}
+
+// boundaries returns the ticks of the starts of every given bars up to the given end (exclusive), without tick 0
+func boundaries(m *MeterMap, everyBars, end uint64) (res []uint64) {
+	for bar := everyBars; ; bar += everyBars {
+		tick := m.BarStart(bar)
+
+		if tick >= end || (len(res) > 0 && tick <= res[len(res)-1]) || tick == 0 {
+			return
+		}
+
+		res = append(res, tick)
+	}
+}
+
+// injectSnapshots inserts the snapshots at the given ticks (see InjectSnapshots)
+func (t *Track) injectSnapshots(boundaries []uint64, rep *SnapshotReport) {
+	var c chaser
+	var evts = make([]Event, 0, len(t.evs()))
+	var i int
+
+	for _, b := range boundaries {
+		for ; i < len(t.evs()) && t.evs()[i].AbsTicks < b; i++ {
+			c.add(t.evs()[i].Message)
+			evts = append(evts, t.evs()[i])
+		}
+
+		msgs := c.snapshot()
+
+		if len(msgs) == 0 {
+			continue
+		}
+
+		evts = append(evts, Event{AbsTicks: b, Message: meta.Marker(snapshotMarker + strconv.Itoa(len(msgs)))})
+
+		for _, msg := range msgs {
+			evts = append(evts, Event{AbsTicks: b, Message: msg})
+		}
+
+		rep.Snapshots++
+		rep.Messages += len(msgs)
+	}
+
+	t.SetEvents(append(evts, t.evs()[i:]...))
+}
+
+// RemoveSnapshots returns a copy of the given SMF without the snapshots that have been inserted by InjectSnapshots.
+// The given SMF is not modified.
+func RemoveSnapshots(s *SMF) *SMF {
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		var evts = make([]Event, 0, len(tr.evs()))
+
+		for i := 0; i < len(tr.evs()); i++ {
+			ev := tr.evs()[i]
+
+			if n, is := snapshotLen(ev.Message); is && tr.isSnapshot(i, n) {
+				i += n
+				continue
+			}
+
+			evts = append(evts, ev)
+		}
+
+		tr.SetEvents(evts)
+	}
+
+	return res
+}
+
+// snapshotLen returns the number of messages of the snapshot, if the given message is the marker of a snapshot
+func snapshotLen(msg midi.Message) (int, bool) {
+	m, is := msg.(meta.Marker)
+
+	if !is || !strings.HasPrefix(m.Text(), snapshotMarker) {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(strings.TrimPrefix(m.Text(), snapshotMarker))
+	return n, err == nil && n > 0
+}
+
+// isSnapshot returns true, if the marker at the given index is followed by the given number of channel messages
+// at the same tick that are part of a snapshot
+func (t *Track) isSnapshot(marker, n int) bool {
+	if marker+n >= len(t.evs()) {
+		return false
+	}
+
+	for _, ev := range t.evs()[marker+1 : marker+n+1] {
+		if ev.AbsTicks != t.evs()[marker].AbsTicks || !isStateMessage(ev.Message) {
+			return false
+		}
+
+		if _, is := ev.Message.(channel.Message); !is {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodedSize returns the size of the written SMF in bytes
+func encodedSize(s *SMF) (int, error) {
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		return 0, fmt.Errorf("can't write SMF: %w", err)
+	}
+
+	return bf.Len(), nil
+}
@@ -0,0 +1,142 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// synth is the state of a General MIDI synth
+type synth struct {
+	programs    [16]uint8
+	pitchbends  [16]int16
+	controllers [16][128]uint8
+}
+
+func newSynth() *synth {
+	var sy synth
+
+	for ch := range sy.controllers {
+		for cc := range sy.controllers[ch] {
+			sy.controllers[ch][cc] = powerOnValue(uint8(cc))
+		}
+	}
+
+	return &sy
+}
+
+// play applies the messages of the given SMF from (inclusive) to (inclusive) to the synth
+func (sy *synth) play(s *SMF, from, to uint64) *synth {
+	for ev := range s.All() {
+		if ev.AbsTicks < from || ev.AbsTicks > to {
+			continue
+		}
+
+		switch v := ev.Message.(type) {
+		case channel.ProgramChange:
+			sy.programs[v.Channel()] = v.Program()
+		case channel.Pitchbend:
+			sy.pitchbends[v.Channel()] = v.Value()
+		case channel.ControlChange:
+			sy.controllers[v.Channel()][v.Controller()] = v.Value()
+		}
+	}
+
+	return sy
+}
+
+func TestInjectSnapshots(t *testing.T) {
+	var conductor, piano, bass Track
+	conductor.Add(0, meta.BPM(100), meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8})
+	conductor.Add(1536, meta.TimeSig{Numerator: 3, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8})
+
+	p := channel.Channel0
+	piano.Add(0, p.ControlChange(0, 1), p.ProgramChange(5), p.NoteOn(60, 100))
+	piano.Add(100, p.ControlChange(7, 80))
+	piano.Add(600, p.NoteOff(60))
+	piano.Add(768, p.NoteOn(62, 100), p.Pitchbend(500))
+	piano.Add(1000, p.Pitchbend(0), p.NoteOff(62))
+	piano.Add(1400, p.ControlChange(7, 100), p.ControlChange(64, 127), p.NoteOn(64, 100))
+	piano.Add(2000, p.NoteOff(64), p.ControlChange(64, 0))
+	piano.Add(2200, p.NoteOn(65, 100))
+	piano.Add(2300, p.NoteOff(65))
+
+	// the bass only has values of the state after power on
+	b := channel.Channel1
+	bass.Add(0, b.ControlChange(10, 64), b.NoteOn(40, 100))
+	bass.Add(2300, b.NoteOff(40))
+
+	s := New(smf.SMF1, smf.MetricTicks(96))
+	s.AddTrack(&conductor)
+	s.AddTrack(&piano)
+	s.AddTrack(&bass)
+
+	res, rep, err := InjectSnapshots(s, 2)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// bars 2 and 4 are in 4/4, bar 6 is in 3/4
+	expected := `0 channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 1
+0 channel.ProgramChange channel 1 program 5
+0 channel.NoteOn channel 1 key 60 velocity 100
+100 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 80
+600 channel.NoteOff channel 1 key 60
+768 meta.Marker: "gomidi snapshot 3"
+768 channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 1
+768 channel.ProgramChange channel 1 program 5
+768 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 80
+768 channel.NoteOn channel 1 key 62 velocity 100
+768 channel.Pitchbend channel 1 value 500 absValue 0
+1000 channel.Pitchbend channel 1 value 0 absValue 0
+1000 channel.NoteOff channel 1 key 62
+1400 channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+1400 channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 127 (on)
+1400 channel.NoteOn channel 1 key 64 velocity 100
+1536 meta.Marker: "gomidi snapshot 3"
+1536 channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 1
+1536 channel.ProgramChange channel 1 program 5
+1536 channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 127 (on)
+2000 channel.NoteOff channel 1 key 64
+2000 channel.ControlChange channel 1 controller 64 ("Hold Pedal (on/off)") value 0 (off)
+2112 meta.Marker: "gomidi snapshot 2"
+2112 channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 1
+2112 channel.ProgramChange channel 1 program 5
+2200 channel.NoteOn channel 1 key 65 velocity 100
+2300 channel.NoteOff channel 1 key 65
+2300 end
+`
+
+	if got := trackString(res.Track(1)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	if rep.Snapshots != 3 || rep.Messages != 8 || rep.SizeIncrease < 8*3 {
+		t.Errorf("report = %+v; want 3 snapshots with 8 messages", rep)
+	}
+
+	// starting a reset synth at a boundary of the snapshotted SMF results in the same state as playing from the start
+	for _, boundary := range []uint64{768, 1536, 2112} {
+		for _, to := range []uint64{boundary, 2300} {
+			if got, want := newSynth().play(res, boundary, to), newSynth().play(s, 0, to); *got != *want {
+				t.Errorf("state of synth starting at %v differs at %v from the state of the synth starting at 0", boundary, to)
+			}
+		}
+	}
+
+	// but not without the snapshots
+	if got, want := newSynth().play(s, 768, 768), newSynth().play(s, 0, 768); *got == *want {
+		t.Errorf("state of synth starting at 768 without snapshot is already correct")
+	}
+
+	removed := RemoveSnapshots(res)
+
+	for no := range s.Tracks() {
+		if got, want := trackString(removed.Track(no)), trackString(s.Track(no)); got != want {
+			t.Errorf("track %v after RemoveSnapshots:\ngot:\n%s\n\nwanted:\n%s\n\n", no, got, want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package smftrack
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// isConductorMessage returns true for the meta messages that belong to the conductor track:
+// tempo, time signature, key, markers, cue points and the SMPTE offset.
+func isConductorMessage(msg midi.Message) bool {
+	switch msg.(type) {
+	case meta.Tempo, meta.TimeSig, meta.Key, meta.Marker, meta.Cuepoint, meta.SMPTE:
+		return true
+	}
+	return false
+}
+
+// noteChannels returns the channels that have notes within the track
+func (t *Track) noteChannels() (channels [16]bool) {
+	for _, ev := range t.evs() {
+		if on, is := ev.Message.(channel.NoteOn); is && on.Channel() < 16 {
+			channels[on.Channel()] = true
+		}
+	}
+	return
+}
+
+// ExportStems returns a standalone SMF for each part of the given SMF, mapped by the name of the part.
+// The given SMF is not modified.
+//
+// For SMF format 1 and 2, each track with notes is a part. It is named by the track name (see Track.Name)
+// or "Track n", if the track has no name. For SMF format 0, each channel with notes is a part that is named
+// "Channel n" (or "<track name> channel n"), where n is the channel from 1 to 16. Duplicate names get a suffix
+// " (2)", " (3)" etc.
+//
+// Each stem is an SMF format 1 with two tracks: the conductor track, containing the tempo, time signature, key,
+// marker, cue point and SMPTE offset messages of all tracks, and the track of the part. Programs, controllers,
+// pitch bend and aftertouch of the channels of the part that are set within other tracks without notes on
+// these channels (shared tracks), are taken into the track of the part.
+// For SMF format 2, the tracks are independent and each stem is an SMF format 0 that contains just the track.
+// The events keep their tags, the track names that are added for the channels of SMF format 0 have none.
+func ExportStems(s *SMF) map[string]*SMF {
+	var stems = map[string]*SMF{}
+
+	add := func(name string, stem *SMF) {
+		unique := name
+		for i := 2; stems[unique] != nil; i++ {
+			unique = fmt.Sprintf("%s (%v)", name, i)
+		}
+		stems[unique] = stem
+	}
+
+	if s.format == smf.SMF2 {
+		for no, tr := range s.tracks {
+			if !tr.hasNotes() {
+				continue
+			}
+			stem := New(smf.SMF0, s.timeFormat)
+			stem.inheritProvenance(s)
+			stem.tracks = append(stem.tracks, tr.clone())
+			add(trackName(tr, no), stem)
+		}
+		return stems
+	}
+
+	conductor := s.conductor()
+
+	if s.format == smf.SMF0 && len(s.tracks) == 1 {
+		tr := s.tracks[0]
+		chs := tr.noteChannels()
+
+		for ch := uint8(0); ch < 16; ch++ {
+			if !chs[ch] {
+				continue
+			}
+
+			name := fmt.Sprintf("Channel %v", ch+1)
+
+			if n := tr.Name(); n != "" {
+				name = fmt.Sprintf("%s channel %v", n, ch+1)
+			}
+
+			var part Track
+			part.end = tr.end
+			var evts = []Event{{Message: meta.Track(name)}}
+
+			for _, ev := range tr.evs() {
+				if cm, is := ev.Message.(channel.Message); is && cm.Channel() == ch {
+					evts = append(evts, ev)
+				}
+			}
+
+			part.SetEvents(evts)
+			add(name, s.stem(conductor, &part))
+		}
+
+		return stems
+	}
+
+	for no, tr := range s.tracks {
+		chs := tr.noteChannels()
+
+		if !tr.hasNotes() {
+			continue
+		}
+
+		var part Track
+		part.end = tr.end
+		var evts []Event
+
+		// the state of the channels of the part that is set in shared tracks.
+		// It comes first, so that it is established before the notes at the same tick.
+		for other, otr := range s.tracks {
+			if other == no {
+				continue
+			}
+
+			otherChs := otr.noteChannels()
+
+			for _, ev := range otr.evs() {
+				cm, is := ev.Message.(channel.Message)
+				if is && isStateMessage(cm) && cm.Channel() < 16 && chs[cm.Channel()] && !otherChs[cm.Channel()] {
+					evts = append(evts, ev)
+				}
+			}
+		}
+
+		for _, ev := range tr.evs() {
+			if !isConductorMessage(ev.Message) {
+				evts = append(evts, ev)
+			}
+		}
+
+		part.SetEvents(evts)
+		add(trackName(tr, no), s.stem(conductor, &part))
+	}
+
+	return stems
+}
+
+// stem returns an SMF format 1 with the given conductor and part track
+func (s *SMF) stem(conductor, part *Track) *SMF {
+	stem := New(smf.SMF1, s.timeFormat)
+	stem.inheritProvenance(s)
+	stem.tracks = append(stem.tracks, conductor.clone(), part)
+	return stem
+}
+
+// conductor returns a track with the conductor messages of all tracks
+func (s *SMF) conductor() *Track {
+	var tr Track
+	var evts []Event
+
+	for _, ev := range s.Merged() {
+		if isConductorMessage(ev.Message) {
+			evts = append(evts, ev.Event)
+		}
+	}
+
+	for _, t := range s.tracks {
+		if t.end > tr.end {
+			tr.end = t.end
+		}
+	}
+
+	tr.SetEvents(evts)
+	return &tr
+}
+
+func (t *Track) hasNotes() bool {
+	for _, ev := range t.evs() {
+		if _, is := ev.Message.(channel.NoteOn); is {
+			return true
+		}
+	}
+	return false
+}
+
+func trackName(tr *Track, no int) string {
+	if name := tr.Name(); name != "" {
+		return name
+	}
+	return fmt.Sprintf("Track %v", no)
+}
+
+// ExportMinusOne returns a copy of the given SMF without the channel messages and sysex messages of the given track,
+// i.e. the backing track for the part of the track. The meta messages of the track are kept.
+// The given SMF is not modified. An error is returned, if the track does not exist.
+//
+// For SMF format 0 use ExportStems to get the parts of the channels.
+func ExportMinusOne(s *SMF, excludeTrack int) (*SMF, error) {
+	if excludeTrack < 0 || excludeTrack >= len(s.tracks) {
+		return nil, fmt.Errorf("track %v does not exist", excludeTrack)
+	}
+
+	res := s.clone()
+	tr := res.tracks[excludeTrack]
+	var evts []Event
+
+	for _, ev := range tr.evs() {
+		if _, is := ev.Message.(meta.Message); is {
+			evts = append(evts, ev)
+		}
+	}
+
+	tr.SetEvents(evts)
+	return res, nil
+}
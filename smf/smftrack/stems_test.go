@@ -0,0 +1,171 @@
+package smftrack
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func band() *SMF {
+	var conductor, bass, keys Track
+	ch1, ch2 := channel.Channel1, channel.Channel2
+
+	// the program of the bass lives on the conductor track
+	conductor.Add(0, meta.BPM(100), meta.TimeSig{Numerator: 3, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8}, ch1.ProgramChange(33))
+	conductor.Add(96, meta.Marker("verse"), ch1.ControlChange(7, 90))
+	conductor.SetEnd(384)
+
+	bass.Add(0, meta.Track("Bass"), ch1.NoteOn(40, 100))
+	bass.Add(96, ch1.NoteOff(40))
+	bass.Add(192, ch1.NoteOn(43, 100))
+	bass.Add(288, ch1.NoteOff(43))
+
+	keys.Add(0, ch2.ProgramChange(4), ch2.NoteOn(60, 80))
+	keys.Add(384, ch2.NoteOff(60))
+
+	s := New(smf.SMF1, smf.MetricTicks(96))
+	s.AddTrack(&conductor)
+	s.AddTrack(&bass)
+	s.AddTrack(&keys)
+	return s
+}
+
+func TestExportStems(t *testing.T) {
+	stems := ExportStems(band())
+
+	var names []string
+
+	for name := range stems {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	if got, want := strings.Join(names, ","), "Bass,Track 2"; got != want {
+		t.Fatalf("names = %#v; want %#v", got, want)
+	}
+
+	bass := stems["Bass"]
+
+	if got, want := bass.NumTracks(), uint16(2); got != want {
+		t.Fatalf("NumTracks() = %v; want %v", got, want)
+	}
+
+	expectedConductor := `0 meta.Tempo BPM: 100.00
+0 meta.TimeSig 3/4 clocksperclick 24 dsqpq 8
+96 meta.Marker: "verse"
+384 end
+`
+
+	if got, want := trackString(bass.Track(0)), expectedConductor; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	expectedBass := `0 channel.ProgramChange channel 2 program 33
+0 meta.Track: "Bass"
+0 channel.NoteOn channel 2 key 40 velocity 100
+96 channel.ControlChange channel 2 controller 7 ("Volume (MSB)") value 90
+96 channel.NoteOff channel 2 key 40
+192 channel.NoteOn channel 2 key 43 velocity 100
+288 channel.NoteOff channel 2 key 43
+288 end
+`
+
+	if got, want := trackString(bass.Track(1)), expectedBass; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	expectedKeys := `0 channel.ProgramChange channel 3 program 4
+0 channel.NoteOn channel 3 key 60 velocity 80
+384 channel.NoteOff channel 3 key 60
+384 end
+`
+
+	if got, want := trackString(stems["Track 2"].Track(1)), expectedKeys; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestExportStemsFormat0(t *testing.T) {
+	var tr Track
+	tr.Add(0, meta.BPM(100), channel.Channel1.NoteOn(40, 100), channel.Channel2.NoteOn(60, 100))
+	tr.Add(96, channel.Channel1.NoteOff(40), channel.Channel2.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	stems := ExportStems(s)
+
+	expected := `0 meta.Track: "Channel 3"
+0 channel.NoteOn channel 3 key 60 velocity 100
+96 channel.NoteOff channel 3 key 60
+96 end
+`
+
+	if got, want := trackString(stems["Channel 3"].Track(1)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if got, want := len(stems), 2; got != want {
+		t.Errorf("len(stems) = %v; want %v", got, want)
+	}
+}
+
+func TestExportMinusOne(t *testing.T) {
+	s := band()
+	res, err := ExportMinusOne(s, 1)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := trackString(res.Track(1)), "0 meta.Track: \"Bass\"\n288 end\n"; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if got, want := s.Track(1).Len(), 5; got != want {
+		t.Errorf("source has been modified: Len() = %v; want %v", got, want)
+	}
+
+	if _, err := ExportMinusOne(s, 3); err == nil {
+		t.Errorf("expected error for missing track")
+	}
+}
+
+func TestSlice(t *testing.T) {
+	res := Slice(band(), 150, 250)
+
+	expected := `0 meta.Tempo BPM: 100.00
+0 meta.TimeSig 3/4 clocksperclick 24 dsqpq 8
+0 channel.ProgramChange channel 2 program 33
+0 channel.ControlChange channel 2 controller 7 ("Volume (MSB)") value 90
+100 end
+`
+
+	if got, want := trackString(res.Track(0)), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	// the note that lasts beyond the slice is ended
+	expectedBass := `42 channel.NoteOn channel 2 key 43 velocity 100
+100 channel.NoteOff channel 2 key 43
+100 end
+`
+
+	if got, want := trackString(res.Track(1)), expectedBass; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	// the note that started before the slice is left out
+	expectedKeys := `0 channel.ProgramChange channel 3 program 4
+100 end
+`
+
+	if got, want := trackString(res.Track(2)), expectedKeys; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
@@ -0,0 +1,255 @@
+package smftrack
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// taggedSMF returns a SMF of format 1 where each event has a tag: the events of the conductor track are tagged
+// 1, 2, ... and the events of the part are tagged 11, 12, ...
+func taggedSMF() *SMF {
+	var conductor Track
+	conductor.Add(0, meta.Track("conductor"), meta.BPM(120), meta.TimeSig{Numerator: 4, Denominator: 4, ClocksPerClick: 24, DemiSemiQuaverPerQuarter: 8})
+	conductor.Add(960, meta.BPM(100))
+	conductor.SetEnd(1920)
+
+	var part Track
+	ch := channel.Channel0
+	part.Add(0, ch.ProgramChange(5), ch.NoteOn(60, 100))
+	part.Add(240, ch.ControlChange(7, 80))
+	part.Add(480, ch.NoteOff(60), ch.NoteOn(64, 90), ch.PolyAftertouch(64, 50))
+	part.Add(960, ch.NoteOff(64))
+	part.Add(1440, ch.NoteOn(67, 80))
+	part.Add(1680, ch.NoteOff(67))
+	part.SetEnd(1920)
+
+	for i := range conductor.Len() {
+		conductor.SetTag(i, uint64(i+1))
+	}
+
+	for i := range part.Len() {
+		part.SetTag(i, uint64(i+11))
+	}
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&part)
+	return s
+}
+
+// tagsOf returns the tags of the tagged events of all tracks in order
+func tagsOf(s *SMF) (tags []uint64) {
+	for _, tr := range s.Tracks() {
+		for _, ev := range tr.Events() {
+			if ev.Tag != 0 {
+				tags = append(tags, ev.Tag)
+			}
+		}
+	}
+	return
+}
+
+// messagesByTag returns the messages of the tagged events of the given SMF by their tag
+func messagesByTag(s *SMF) map[uint64]midi.Message {
+	var m = map[uint64]midi.Message{}
+
+	for _, tr := range s.Tracks() {
+		for _, ev := range tr.Events() {
+			if ev.Tag != 0 {
+				m[ev.Tag] = ev.Message
+			}
+		}
+	}
+
+	return m
+}
+
+func TestTags(t *testing.T) {
+	var (
+		all      = []uint64{1, 2, 3, 4, 11, 12, 13, 14, 15, 16, 17, 18, 19}
+		original = messagesByTag(taggedSMF())
+	)
+
+	tests := []struct {
+		name      string
+		transform func(*SMF) *SMF
+		expected  []uint64
+		// converted is true, if the tagged messages are of another type than the original ones
+		converted bool
+	}{
+		{"RemapChannels", func(s *SMF) *SMF { return RemapChannels(s, map[uint8]uint8{0: 3}) }, all, false},
+		{"RemapDrums", func(s *SMF) *SMF {
+			res, _ := RemapDrums(s, channel.DrumMap{60: 36, 64: 38, 67: 42}, 0)
+			return res
+		}, all, false},
+		{"Humanize", func(s *SMF) *SMF { return Humanize(s, 10, 10, 1) }, all, false},
+		{"Legato", func(s *SMF) *SMF { return Legato(s, 0) }, all, false},
+		{"TrimSilence", TrimSilence, all, false},
+		// the note messages that split the note at 480 have no tag
+		{"InsertSilence", func(s *SMF) *SMF { return InsertSilence(s, 600, 240, SplitNotes()) }, all, false},
+		// the events within the region are removed, the chased tempo has no tag
+		{"RemoveRegion", func(s *SMF) *SMF { return RemoveRegion(s, 720, 1200) },
+			[]uint64{1, 2, 3, 11, 12, 13, 14, 15, 16, 17, 18, 19}, false},
+		// the chased state at 480 and the note off of the note that lasts to the end of the slice have no tag
+		{"Slice", func(s *SMF) *SMF { return Slice(s, 480, 960) }, []uint64{15, 16}, false},
+		{"NudgeTrackTicks", func(s *SMF) *SMF {
+			res, _ := NudgeTrackTicks(s, 1, 120)
+			return res
+		}, all, false},
+		{"MoveFirstTrackMetas", MoveFirstTrackMetas, all, false},
+		{"PolyToChannelPressure", func(s *SMF) *SMF { return PolyToChannelPressure(s, channel.ReduceMax) }, all, true},
+		{"Resample", func(s *SMF) *SMF {
+			res, _ := Resample(s, 960)
+			return res
+		}, all, false},
+		{"Concat", func(s *SMF) *SMF {
+			res, _ := Concat(s, New(smf.SMF1, smf.MetricTicks(480)))
+			return res
+		}, all, false},
+		// the new tempo messages have no tag
+		{"Retime", func(s *SMF) *SMF {
+			res, _ := Retime(s, 90, 0)
+			return res
+		}, []uint64{1, 3, 11, 12, 13, 14, 15, 16, 17, 18, 19}, false},
+		// the segments in between have no tag
+		{"Retrigger", func(s *SMF) *SMF { return Retrigger(s, 240, 0) }, all, false},
+		{"ForceToScale", func(s *SMF) *SMF { return ForceToScale(s, 0, NewScale(0, 2, 4, 5, 7, 9, 11), SnapNearest) }, all, false},
+		{"ThinControllers", func(s *SMF) *SMF { return ThinControllers(s, 10, 1) }, all, false},
+		{"ScaleVelocity", func(s *SMF) *SMF {
+			res, _, _ := ScaleVelocity(s, 0.5, 0)
+			return res
+		}, all, false},
+		{"CompressVelocity", func(s *SMF) *SMF {
+			res, _, _ := CompressVelocity(s, 80, 2, 0)
+			return res
+		}, all, false},
+		{"EditNotes", func(s *SMF) *SMF {
+			res, _ := EditNotes(s, nil, func(n Note) Note { n.Key++; n.AbsTicks += 10; return n })
+			return res
+		}, all, false},
+		{"VelocityRamp", func(s *SMF) *SMF {
+			res, _ := VelocityRamp(s, 0, 1920, 1, 0.5, Exponential, RampExpression())
+			return res
+		}, all, false},
+		// the tempo message at 960 is replaced by the ramp
+		{"TempoRamp", func(s *SMF) *SMF {
+			res, _ := TempoRamp(s, 480, 960, 120, 100, 240, LinearBPM)
+			return res
+		}, []uint64{1, 2, 3, 11, 12, 13, 14, 15, 16, 17, 18, 19}, false},
+		// the conductor messages are recreated from the Timeline
+		{"ApplyConductor", func(s *SMF) *SMF {
+			res, _ := ApplyConductor(s, Conductor(s))
+			return res
+		}, []uint64{1, 11, 12, 13, 14, 15, 16, 17, 18, 19}, false},
+		{"Mixdown", func(s *SMF) *SMF {
+			res, _, _ := Mixdown(s, New(smf.SMF1, smf.MetricTicks(480)))
+			return res
+		}, []uint64{1, 11, 12, 13, 14, 15, 16, 17, 18, 19}, false},
+		{"Strip", Strip, all, false},
+		{"Detach", func(s *SMF) *SMF { return s.Detach() }, all, false},
+		// the pasted copies keep their tags
+		{"PasteRegion", func(s *SMF) *SMF {
+			res, _ := PasteRegion(s, CopyRegion(s, 1440, 1920), 1920, Insert)
+			return res
+		}, []uint64{1, 2, 3, 4, 11, 12, 13, 14, 15, 16, 17, 18, 18, 19, 19}, false},
+		{"ExportMinusOne", func(s *SMF) *SMF {
+			res, _ := ExportMinusOne(s, 1)
+			return res
+		}, []uint64{1, 2, 3, 4}, false},
+		{"ExportStems", func(s *SMF) *SMF { return ExportStems(s)["Track 1"] }, []uint64{2, 3, 4, 11, 12, 13, 14, 15, 16, 17, 18, 19}, false},
+	}
+
+	for _, test := range tests {
+		s := taggedSMF()
+		res := test.transform(s)
+
+		if res == nil {
+			t.Errorf("[%s] no result", test.name)
+			continue
+		}
+
+		if got := tagsOf(res); !reflect.DeepEqual(sorted(got), test.expected) {
+			t.Errorf("[%s] tags = %v; want %v", test.name, sorted(got), test.expected)
+		}
+
+		if got, want := tagsOf(s), all; !reflect.DeepEqual(got, want) {
+			t.Errorf("[%s] tags of the given SMF = %v; want %v", test.name, got, want)
+		}
+
+		if test.converted {
+			continue
+		}
+
+		for tag, msg := range messagesByTag(res) {
+			if got, want := reflect.TypeOf(msg), reflect.TypeOf(original[tag]); got != want {
+				t.Errorf("[%s] message of tag %v is %s; want %s", test.name, tag, got, want)
+			}
+		}
+	}
+}
+
+func TestTagsNotWritten(t *testing.T) {
+	tagged, untagged := taggedSMF(), taggedSMF()
+
+	for _, tr := range untagged.Tracks() {
+		for i := range tr.Len() {
+			tr.SetTag(i, 0)
+		}
+	}
+
+	var a, b bytes.Buffer
+
+	if err := tagged.Write(&a); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if err := untagged.Write(&b); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Errorf("written tagged SMF differs:\n% X\n\nwanted:\n% X\n\n", a.Bytes(), b.Bytes())
+	}
+
+	res, err := ReadBytes(a.Bytes())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got := tagsOf(res); got != nil {
+		t.Errorf("tags of read SMF = %v; want none", got)
+	}
+}
+
+func TestSetTag(t *testing.T) {
+	s := taggedSMF()
+
+	if got := s.Track(1).Tag(2); got != 13 {
+		t.Errorf("Tag(2) = %v; want 13", got)
+	}
+
+	if err := s.Track(1).SetTag(9, 1); err == nil {
+		t.Errorf("SetTag(9) must return an error")
+	}
+
+	s.Freeze()
+
+	if err := s.Track(1).SetTag(2, 1); err != ErrFrozen {
+		t.Errorf("SetTag() on frozen track = %v; want %v", err, ErrFrozen)
+	}
+}
+
+// sorted returns a sorted copy of the given tags
+func sorted(tags []uint64) []uint64 {
+	res := append([]uint64{}, tags...)
+	slices.Sort(res)
+	return res
+}
@@ -0,0 +1,145 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// Curve is the shape of a ramp (see TempoRamp and VelocityRamp)
+type Curve int
+
+const (
+	// LinearBPM changes the tempo linearly in beats per minute, respectively any other value linearly
+	LinearBPM Curve = iota
+
+	// LinearMicroseconds changes the tempo linearly in microseconds per quarter note, i.e. the duration of the
+	// quarter notes changes linearly. Compared to LinearBPM, the tempo changes slower at the start of an
+	// accelerando and faster at the start of a ritardando. For other values, the reciprocal changes linearly.
+	LinearMicroseconds
+
+	// Exponential changes the tempo by the same factor per tick. Since the reciprocal of an exponential curve
+	// is exponential too, it is the same in beats per minute and in microseconds per quarter note.
+	Exponential
+)
+
+// value returns the value at the position x (0 to 1) of a ramp from the value from to the value to.
+// For LinearMicroseconds and Exponential, the values must be positive.
+func (c Curve) value(from, to, x float64) float64 {
+	switch c {
+	case LinearMicroseconds:
+		return 1 / (1/from + (1/to-1/from)*x)
+	case Exponential:
+		return from * math.Pow(to/from, x)
+	default:
+		return from + (to-from)*x
+	}
+}
+
+// microseconds returns the tempo in microseconds per quarter note at the position x (0 to 1) of a ramp
+// from the tempo from to the tempo to (in beats per minute).
+func (c Curve) microseconds(from, to, x float64) float64 {
+	return 60000000 / c.value(from, to, x)
+}
+
+// mean returns the mean tempo in microseconds per quarter note between the positions x0 and x1 of a ramp.
+// It is integrated with Simpson's rule, so that the duration of the ramp is kept.
+func (c Curve) mean(from, to, x0, x1 float64) float64 {
+	const n = 8
+	h := (x1 - x0) / n
+	sum := c.microseconds(from, to, x0) + c.microseconds(from, to, x1)
+
+	for i := 1; i < n; i++ {
+		w := 2.0
+		if i%2 == 1 {
+			w = 4
+		}
+		sum += w * c.microseconds(from, to, x0+float64(i)*h)
+	}
+
+	return sum / (3 * n)
+}
+
+// TempoRamp returns a copy of the given SMF with a gradual tempo change (accelerando or ritardando) from fromBPM at
+// fromTick to toBPM at toTick. The given SMF is not modified.
+//
+// The ramp consists of a tempo message every step ticks, followed by a tempo message of exactly toBPM at toTick.
+// The tempo of each step is the mean tempo of the curve within the step, so that the duration of the ramp matches
+// the duration of the continuous curve. Any existing tempo messages from fromTick to toTick (inclusive) are removed.
+// The ramp is added to the first track and its tempo messages have no tag.
+//
+// An error is returned for SMF2, for time formats other than smf.MetricTicks and for invalid arguments.
+func TempoRamp(s *SMF, fromTick, toTick uint64, fromBPM, toBPM float64, step uint32, curve Curve) (*SMF, error) {
+	if s.format == smf.SMF2 {
+		return nil, fmt.Errorf("tempo ramps are not supported: %w", ErrNoConductor)
+	}
+
+	if _, ok := s.timeFormat.(smf.MetricTicks); !ok {
+		return nil, fmt.Errorf("tempo ramps are not supported for time format %v", s.timeFormat)
+	}
+
+	if len(s.tracks) == 0 {
+		return nil, fmt.Errorf("SMF has no tracks")
+	}
+
+	if toTick <= fromTick {
+		return nil, fmt.Errorf("invalid tempo ramp from tick %v to tick %v", fromTick, toTick)
+	}
+
+	if fromBPM <= 0 || toBPM <= 0 {
+		return nil, fmt.Errorf("invalid tempo ramp from %v BPM to %v BPM", fromBPM, toBPM)
+	}
+
+	if step == 0 {
+		return nil, fmt.Errorf("invalid tempo ramp step 0")
+	}
+
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		tr.removeTempos(fromTick, toTick)
+	}
+
+	var (
+		conductor = res.tracks[0]
+		length    = float64(toTick - fromTick)
+		last      meta.Tempo
+	)
+
+	for a := fromTick; a < toTick; a += uint64(step) {
+		b := a + uint64(step)
+		if b > toTick {
+			b = toTick
+		}
+
+		x0, x1 := float64(a-fromTick)/length, float64(b-fromTick)/length
+		tempo := meta.Tempo(math.Round(curve.mean(fromBPM, toBPM, x0, x1)))
+
+		if tempo != last {
+			conductor.Add(a, tempo)
+			last = tempo
+		}
+	}
+
+	conductor.Add(toTick, meta.FractionalBPM(toBPM))
+	return res, nil
+}
+
+// removeTempos removes the tempo messages from the tick from to the tick to (inclusive).
+// The track is not modified, if there are none.
+func (t *Track) removeTempos(from, to uint64) {
+	var evts []Event
+
+	for _, ev := range t.evs() {
+		if _, is := ev.Message.(meta.Tempo); is && ev.AbsTicks >= from && ev.AbsTicks <= to {
+			continue
+		}
+		evts = append(evts, ev)
+	}
+
+	if len(evts) != len(t.evs()) {
+		t.SetEvents(evts)
+	}
+}
@@ -0,0 +1,126 @@
+package smftrack
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// rampDuration returns the duration from the tick from to the tick to, based on the tempo messages of the first track
+func rampDuration(s *SMF, from, to uint64) time.Duration {
+	tpq := float64(s.TimeFormat().(smf.MetricTicks).Ticks4th())
+	tempo, pos := float64(500000), from
+	var d float64
+
+	for _, ev := range s.Track(0).Events() {
+		t, is := ev.Message.(meta.Tempo)
+
+		if !is || ev.AbsTicks > to {
+			continue
+		}
+
+		if ev.AbsTicks > pos {
+			d += float64(ev.AbsTicks-pos) / tpq * tempo
+			pos = ev.AbsTicks
+		}
+
+		tempo = float64(t)
+	}
+
+	d += float64(to-pos) / tpq * tempo
+	return time.Duration(d * float64(time.Microsecond))
+}
+
+func TestTempoRamp(t *testing.T) {
+	const quarters = 16
+
+	seconds := func(f float64) time.Duration { return time.Duration(f * float64(time.Second)) }
+
+	tests := []struct {
+		from, to float64
+		curve    Curve
+		expected time.Duration
+	}{
+		// closed-form integrals of the duration of a quarter note (60/bpm seconds) over the ramp
+		{60, 120, LinearBPM, seconds(quarters * 60 / 60.0 * math.Log(2))},
+		{120, 60, LinearBPM, seconds(quarters * 60 / 60.0 * math.Log(2))},
+		{60, 120, LinearMicroseconds, seconds(quarters * (1.0 + 0.5) / 2)},
+		{60, 120, Exponential, seconds(quarters * 60 * (1/60.0 - 1/120.0) / math.Log(2))},
+		{140, 70, Exponential, seconds(quarters * 60 * (1/70.0 - 1/140.0) / math.Log(2))},
+		{100, 100, LinearBPM, seconds(quarters * 0.6)},
+	}
+
+	for i, test := range tests {
+		var tr Track
+		tr.Add(0, meta.BPM(90), channel.Channel0.NoteOn(60, 100))
+		tr.Add(960, meta.BPM(200))
+		tr.Add(1000+quarters*960, meta.BPM(80))
+		tr.SetEnd(2 * quarters * 960)
+
+		s := New(smf.SMF0, smf.MetricTicks(960))
+		s.AddTrack(&tr)
+
+		res, err := TempoRamp(s, 1000, 1000+quarters*960, test.from, test.to, 120, test.curve)
+
+		if err != nil {
+			t.Fatalf("[%v] Error: %v", i, err)
+		}
+
+		got := rampDuration(res, 1000, 1000+quarters*960)
+
+		if diff := got - test.expected; diff > time.Millisecond || diff < -time.Millisecond {
+			t.Errorf("[%v] duration = %v; want %v", i, got, test.expected)
+		}
+
+		evts := res.Track(0).Events()
+
+		// the tempo at tick 960 is kept, the tempo at the end of the ramp is replaced
+		if got, want := evts[2], (Event{AbsTicks: 960, Message: meta.BPM(200)}); got != want {
+			t.Errorf("[%v] first event = %v; want %v", i, got, want)
+		}
+
+		if got, want := evts[len(evts)-1], (Event{AbsTicks: 1000 + quarters*960, Message: meta.FractionalBPM(test.to)}); got != want {
+			t.Errorf("[%v] last event = %v; want %v", i, got, want)
+		}
+
+		// the original is untouched
+		if got, want := s.Track(0).Len(), 4; got != want {
+			t.Errorf("[%v] Len() = %v; want %v", i, got, want)
+		}
+	}
+}
+
+func TestTempoRampErrors(t *testing.T) {
+	var tr Track
+	tr.Add(0, meta.BPM(120))
+
+	s := New(smf.SMF0, smf.MetricTicks(960))
+	s.AddTrack(&tr)
+
+	tc := New(smf.SMF0, smf.SMPTE25(40))
+	tc.AddTrack(&tr)
+
+	tests := []struct {
+		s              *SMF
+		from, to       uint64
+		fromBPM, toBPM float64
+		step           uint32
+	}{
+		{s, 960, 960, 60, 120, 10},
+		{s, 0, 960, 0, 120, 10},
+		{s, 0, 960, 60, -1, 10},
+		{s, 0, 960, 60, 120, 0},
+		{tc, 0, 960, 60, 120, 10},
+		{New(smf.SMF0, nil), 0, 960, 60, 120, 10},
+	}
+
+	for i, test := range tests {
+		if _, err := TempoRamp(test.s, test.from, test.to, test.fromBPM, test.toBPM, test.step, LinearBPM); err == nil {
+			t.Errorf("[%v] expected error", i)
+		}
+	}
+}
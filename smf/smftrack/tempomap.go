@@ -0,0 +1,142 @@
+package smftrack
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// TempoChange is a change of the tempo within a TempoMap
+type TempoChange struct {
+	AbsTicks uint64
+	Tempo    meta.Tempo
+
+	// Time is the time of the change since the start
+	Time time.Duration
+}
+
+// TempoMap maps ticks to time and vice versa, based on the tempo messages of a SMF.
+// Until the first tempo message, the tempo is 120 BPM.
+// For time code based time formats (smf.TimeCode), the tempo messages are irrelevant, since the duration of a tick is fixed.
+type TempoMap struct {
+	timeFormat smf.TimeFormat
+	changes    []TempoChange
+}
+
+// NewTempoMap returns the TempoMap of the tempo messages of the given tracks (see Timeline.TempoMap).
+// Tempo messages at the same tick are applied in the order of the tracks, so that the last one wins.
+func NewTempoMap(timeFormat smf.TimeFormat, tracks ...*Track) *TempoMap {
+	return newTimeline(timeFormat, tracks...).TempoMap()
+}
+
+// newTempoMap returns the TempoMap of the given tempo changes that are sorted by their ticks
+func newTempoMap(timeFormat smf.TimeFormat, tempos []TempoMark) *TempoMap {
+	m := &TempoMap{timeFormat: timeFormat}
+	m.changes = []TempoChange{{Tempo: meta.BPM(120)}}
+
+	for _, t := range tempos {
+		if t.Tempo == 0 {
+			continue
+		}
+
+		last := &m.changes[len(m.changes)-1]
+
+		if t.AbsTicks == last.AbsTicks {
+			last.Tempo = t.Tempo
+			continue
+		}
+
+		c := TempoChange{AbsTicks: t.AbsTicks, Tempo: t.Tempo}
+		c.Time = last.Time + m.duration(c.AbsTicks-last.AbsTicks, last.Tempo)
+		m.changes = append(m.changes, c)
+	}
+
+	return m
+}
+
+// TempoMap returns the TempoMap of the SMF, based on the tempo messages of all tracks.
+// Since the tracks of SMF2 are independent, the TempoMap of a single track should be used for SMF2 (see NewTempoMap).
+func (s *SMF) TempoMap() *TempoMap {
+	return NewTempoMap(s.timeFormat, s.tracks...)
+}
+
+// Changes returns the changes of the tempo. The first change is always at tick 0.
+func (m *TempoMap) Changes() []TempoChange {
+	res := make([]TempoChange, len(m.changes))
+	copy(res, m.changes)
+	return res
+}
+
+// TempoAt returns the tempo at the given tick
+func (m *TempoMap) TempoAt(absTicks uint64) meta.Tempo {
+	return m.changes[m.changeAtTick(absTicks)].Tempo
+}
+
+// Time returns the time of the given tick since the start
+func (m *TempoMap) Time(absTicks uint64) time.Duration {
+	c := m.changes[m.changeAtTick(absTicks)]
+	return c.Time + m.duration(absTicks-c.AbsTicks, c.Tempo)
+}
+
+// Ticks returns the tick that is nearest to the given time since the start. Negative times return 0.
+func (m *TempoMap) Ticks(d time.Duration) uint64 {
+	if d <= 0 {
+		return 0
+	}
+
+	i := sort.Search(len(m.changes), func(i int) bool {
+		return m.changes[i].Time > d
+	}) - 1
+
+	c := m.changes[i]
+	return c.AbsTicks + m.ticks(d-c.Time, c.Tempo)
+}
+
+// changeAtTick returns the index of the change that is valid at the given tick
+func (m *TempoMap) changeAtTick(absTicks uint64) int {
+	return sort.Search(len(m.changes), func(i int) bool {
+		return m.changes[i].AbsTicks > absTicks
+	}) - 1
+}
+
+// perTick returns the duration of a tick as fraction num/den of nanoseconds
+func (m *TempoMap) perTick(tempo meta.Tempo) (num, den uint64) {
+	switch tf := m.timeFormat.(type) {
+	case smf.TimeCode:
+		if tf.FramesPerSecond == 29 {
+			// 30 drop frame is 29.97 frames per second
+			return 1001 * uint64(time.Second), 30000 * uint64(tf.SubFrames)
+		}
+		return uint64(time.Second), uint64(tf.FramesPerSecond) * uint64(tf.SubFrames)
+	default:
+		tpq := uint64(smf.MetricTicks(0).Ticks4th())
+		if mt, ok := tf.(smf.MetricTicks); ok {
+			tpq = uint64(mt.Ticks4th())
+		}
+		return uint64(tempo) * uint64(time.Microsecond), tpq
+	}
+}
+
+// duration returns the duration of the given ticks at the given tempo
+func (m *TempoMap) duration(ticks uint64, tempo meta.Tempo) time.Duration {
+	num, den := m.perTick(tempo)
+	if den == 0 {
+		return 0
+	}
+	d := scaleTicks(ticks, num, den)
+	if d > 1<<63-1 {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration(d)
+}
+
+// ticks returns the number of ticks of the given duration at the given tempo
+func (m *TempoMap) ticks(d time.Duration, tempo meta.Tempo) uint64 {
+	num, den := m.perTick(tempo)
+	if num == 0 {
+		return 0
+	}
+	return scaleTicks(uint64(d), den, num)
+}
@@ -0,0 +1,68 @@
+package smftrack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestTempoMap(t *testing.T) {
+	var conductor, other Track
+	conductor.Add(1920, meta.BPM(60))
+	conductor.Add(3840, meta.BPM(240))
+	other.Add(3840, meta.BPM(120))
+
+	tm := NewTempoMap(smf.MetricTicks(960), &conductor, &other)
+
+	tests := []struct {
+		ticks uint64
+		time  time.Duration
+		tempo meta.Tempo
+	}{
+		{0, 0, meta.BPM(120)},
+		{960, 500 * time.Millisecond, meta.BPM(120)},
+		{1920, time.Second, meta.BPM(60)},
+		{2400, 1500 * time.Millisecond, meta.BPM(60)},
+		{3840, 3 * time.Second, meta.BPM(120)},
+		{4800, 3500 * time.Millisecond, meta.BPM(120)},
+	}
+
+	for _, test := range tests {
+		if got, want := tm.Time(test.ticks), test.time; got != want {
+			t.Errorf("Time(%v) = %v; want %v", test.ticks, got, want)
+		}
+
+		if got, want := tm.Ticks(test.time), test.ticks; got != want {
+			t.Errorf("Ticks(%v) = %v; want %v", test.time, got, want)
+		}
+
+		if got, want := tm.TempoAt(test.ticks), test.tempo; got != want {
+			t.Errorf("TempoAt(%v) = %v; want %v", test.ticks, got, want)
+		}
+	}
+
+	if got, want := tm.Ticks(-time.Second), uint64(0); got != want {
+		t.Errorf("Ticks(-1s) = %v; want %v", got, want)
+	}
+
+	if got, want := len(tm.Changes()), 3; got != want {
+		t.Errorf("len(Changes()) = %v; want %v", got, want)
+	}
+}
+
+func TestTempoMapTimeCode(t *testing.T) {
+	var tr Track
+	tr.Add(0, meta.BPM(60))
+
+	tm := NewTempoMap(smf.SMPTE25(40), &tr)
+
+	if got, want := tm.Time(1000), time.Second; got != want {
+		t.Errorf("Time(1000) = %v; want %v", got, want)
+	}
+
+	if got, want := tm.Ticks(20*time.Millisecond), uint64(20); got != want {
+		t.Errorf("Ticks(20ms) = %v; want %v", got, want)
+	}
+}
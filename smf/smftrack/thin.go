@@ -0,0 +1,266 @@
+package smftrack
+
+import (
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/internal/controllers"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// ThinControllers returns a copy of the given SMF with less control change, pitch bend, aftertouch and
+// polyphonic aftertouch messages. The given SMF is not modified.
+//
+// A message is dropped, if the time since the last kept message of the same controller (on the same channel)
+// is below minIntervalTicks and the change of the value is below minDelta. The value of pitch bend messages
+// is compared in units of 128 (the resolution of the other messages).
+// The final value of a ramp (i.e. a message that is not followed by a message of the same controller within
+// minIntervalTicks) and messages at the tick of a note on message on the same channel are always kept.
+func ThinControllers(s *SMF, minIntervalTicks uint32, minDelta uint8) *SMF {
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		tr.thinControllers(uint64(minIntervalTicks), int(minDelta))
+	}
+
+	return res
+}
+
+func (t *Track) thinControllers(interval uint64, delta int) {
+	type kept struct {
+		tick  uint64
+		value int
+	}
+
+	var last = map[[4]uint8]kept{}
+
+	// the ticks of the note ons by channel
+	var noteOns = map[[2]uint64]bool{}
+
+	for _, ev := range t.evs() {
+		if on, is := ev.Message.(channel.NoteOn); is && on.Velocity() > 0 {
+			noteOns[[2]uint64{uint64(on.Channel()), ev.AbsTicks}] = true
+		}
+	}
+
+	// the index of the next message of the same stream for each message
+	var next = make([]int, len(t.evs()))
+	var lastIdx = map[[4]uint8]int{}
+
+	for i := len(t.evs()) - 1; i >= 0; i-- {
+		next[i] = -1
+		stream, _, ok := controllers.Stream(t.evs()[i].Message)
+		if !ok {
+			continue
+		}
+		if j, has := lastIdx[stream]; has {
+			next[i] = j
+		}
+		lastIdx[stream] = i
+	}
+
+	var evts []Event
+
+	for i, ev := range t.evs() {
+		stream, value, ok := controllers.Stream(ev.Message)
+
+		if !ok {
+			evts = append(evts, ev)
+			continue
+		}
+
+		prev, hasPrev := last[stream]
+		diff := value - prev.value
+		if diff < 0 {
+			diff = -diff
+		}
+
+		drop := hasPrev && ev.AbsTicks-prev.tick < interval && diff < delta
+
+		switch {
+		case !drop:
+		case next[i] < 0 || t.evs()[next[i]].AbsTicks-ev.AbsTicks >= interval:
+			// end of a ramp
+			drop = false
+		case noteOns[[2]uint64{uint64(stream[1]), ev.AbsTicks}]:
+			drop = false
+		}
+
+		if drop {
+			continue
+		}
+
+		last[stream] = kept{tick: ev.AbsTicks, value: value}
+		evts = append(evts, ev)
+	}
+
+	t.SetEvents(evts)
+}
+
+// pressureStream returns the stream an aftertouch or polyphonic aftertouch message belongs to: its channel and key,
+// 128 for aftertouch messages.
+func pressureStream(msg midi.Message) (stream [2]uint8, pressure uint8, ok bool) {
+	switch v := msg.(type) {
+	case channel.Aftertouch:
+		return [2]uint8{v.Channel(), 128}, v.Pressure(), true
+	case channel.PolyAftertouch:
+		return [2]uint8{v.Channel(), v.Key()}, v.Pressure(), true
+	}
+	return
+}
+
+// releasedKey returns the channel and key of a note off message (or note on message with velocity 0)
+func releasedKey(msg midi.Message) (ch, key uint8, ok bool) {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		return v.Channel(), v.Key(), v.Velocity() == 0
+	case channel.NoteOff:
+		return v.Channel(), v.Key(), true
+	case channel.NoteOffVelocity:
+		return v.Channel(), v.Key(), true
+	}
+	return
+}
+
+// ThinPressure returns a copy of the given SMF with less aftertouch and polyphonic aftertouch messages.
+// The given SMF is not modified.
+//
+// As with ThinControllers, a pressure message is dropped, if the time since the last kept message of the same channel
+// (respectively key for polyphonic aftertouch) is below minIntervalTicks and the change of the pressure is below minDelta,
+// unless it is the final value of a ramp. The first pressure message after a note on message on the channel
+// (respectively of the key) is always kept.
+//
+// If gateToNotes is true, pressure messages are dropped while no note is sounding on the channel (respectively while the
+// key is not sounding), and a pressure of 0 is added before the note off message that ends the last sounding note,
+// if the last kept pressure is not 0.
+func ThinPressure(s *SMF, minIntervalTicks uint32, minDelta uint8, gateToNotes bool) *SMF {
+	res := s.clone()
+
+	for _, tr := range res.tracks {
+		tr.thinPressure(uint64(minIntervalTicks), int(minDelta), gateToNotes)
+	}
+
+	return res
+}
+
+func (t *Track) thinPressure(interval uint64, delta int, gate bool) {
+	type state struct {
+		tick  uint64
+		value uint8
+
+		// kept is true, if a message has been kept since the last release, fresh if no message has been kept since
+		// the last note on
+		kept, fresh bool
+	}
+
+	var streams = map[[2]uint8]*state{}
+
+	get := func(stream [2]uint8) *state {
+		st := streams[stream]
+		if st == nil {
+			st = &state{fresh: true}
+			streams[stream] = st
+		}
+		return st
+	}
+
+	// the index of the next message of the same stream for each message
+	var next = make([]int, len(t.evs()))
+	var lastIdx = map[[2]uint8]int{}
+
+	for i := len(t.evs()) - 1; i >= 0; i-- {
+		next[i] = -1
+		stream, _, ok := pressureStream(t.evs()[i].Message)
+		if !ok {
+			continue
+		}
+		if j, has := lastIdx[stream]; has {
+			next[i] = j
+		}
+		lastIdx[stream] = i
+	}
+
+	var notes channel.NoteTracker
+	var evts []Event
+
+	// changed is true, if a message has been dropped or added
+	var changed bool
+
+	for i, ev := range t.evs() {
+		if on, is := ev.Message.(channel.NoteOn); is && on.Velocity() > 0 {
+			notes.Track(on)
+			get([2]uint8{on.Channel(), 128}).fresh = true
+			get([2]uint8{on.Channel(), on.Key()}).fresh = true
+			evts = append(evts, ev)
+			continue
+		}
+
+		if ch, key, is := releasedKey(ev.Message); is {
+			notes.Track(ev.Message.(channel.Message))
+
+			if gate {
+				released := []struct {
+					stream [2]uint8
+					zero   midi.Message
+					ended  bool
+				}{
+					{[2]uint8{ch, key}, channel.Channel(ch).PolyAftertouch(key, 0), !notes.IsActive(ch, key)},
+					{[2]uint8{ch, 128}, channel.Channel(ch).Aftertouch(0), len(notes.Active(ch)) == 0},
+				}
+
+				for _, r := range released {
+					st := streams[r.stream]
+
+					if !r.ended || st == nil {
+						continue
+					}
+
+					if st.kept && st.value > 0 {
+						evts = append(evts, Event{AbsTicks: ev.AbsTicks, Message: r.zero})
+						changed = true
+					}
+
+					delete(streams, r.stream)
+				}
+			}
+
+			evts = append(evts, ev)
+			continue
+		}
+
+		stream, value, ok := pressureStream(ev.Message)
+
+		if !ok {
+			evts = append(evts, ev)
+			continue
+		}
+
+		if gate && ((stream[1] == 128 && len(notes.Active(stream[0])) == 0) || (stream[1] < 128 && !notes.IsActive(stream[0], stream[1]))) {
+			changed = true
+			continue
+		}
+
+		st := get(stream)
+		diff := int(value) - int(st.value)
+		if diff < 0 {
+			diff = -diff
+		}
+
+		drop := st.kept && !st.fresh && ev.AbsTicks-st.tick < interval && diff < delta
+
+		if drop && (next[i] < 0 || t.evs()[next[i]].AbsTicks-ev.AbsTicks >= interval) {
+			// end of a ramp
+			drop = false
+		}
+
+		if drop {
+			changed = true
+			continue
+		}
+
+		st.tick, st.value, st.kept, st.fresh = ev.AbsTicks, value, true, false
+		evts = append(evts, ev)
+	}
+
+	if changed {
+		t.SetEvents(evts)
+	}
+}
@@ -0,0 +1,185 @@
+package smftrack
+
+import (
+	"io"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestThinControllers(t *testing.T) {
+	var tr Track
+	ch := channel.Channel0
+
+	// dense ramps: pitch bend from 0 to 8100 and volume from 0 to 99 every 2 ticks
+	for i := 0; i < 100; i++ {
+		tr.Add(uint64(i*2), ch.Pitchbend(int16(i*81+81)), ch.ControlChange(7, uint8(i)))
+	}
+
+	tr.Add(51, ch.NoteOn(60, 100), ch.ControlChange(7, 26))
+	// second ramp after a pause
+	tr.Add(500, ch.ControlChange(7, 50))
+	tr.Add(501, ch.ControlChange(7, 51))
+	tr.Add(502, ch.ControlChange(7, 52))
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	res := ThinControllers(s, 10, 8)
+	evts := res.Track(0).Events()
+
+	if before, after := len(tr.Events()), len(evts); after*3 > before {
+		t.Errorf("ThinControllers reduced %v events to %v; want less than a third", before, after)
+	}
+
+	var pbs []int16
+	var vols = map[uint64]uint8{}
+
+	for _, ev := range evts {
+		switch v := ev.Message.(type) {
+		case channel.Pitchbend:
+			pbs = append(pbs, v.Value())
+		case channel.ControlChange:
+			vols[ev.AbsTicks] = v.Value()
+		}
+	}
+
+	if got, want := pbs[0], int16(81); got != want {
+		t.Errorf("first pitch bend = %v; want %v", got, want)
+	}
+
+	if got, want := pbs[len(pbs)-1], int16(8100); got != want {
+		t.Errorf("last pitch bend = %v; want %v", got, want)
+	}
+
+	// the final values of the ramps and the value at the note on must be there
+	for tick, value := range map[uint64]uint8{198: 99, 51: 26, 500: 50, 502: 52} {
+		if got, want := vols[tick], value; got != want {
+			t.Errorf("volume at %v = %v; want %v", tick, got, want)
+		}
+	}
+
+	if _, has := vols[501]; has {
+		t.Errorf("volume at 501 should have been dropped")
+	}
+
+	// the source is untouched
+	if got, want := len(s.Track(0).Events()), 205; got != want {
+		t.Errorf("source has been modified: %v events; want %v", got, want)
+	}
+}
+
+func TestThinPressure(t *testing.T) {
+	var tr Track
+	ch := channel.Channel0
+
+	// pressure in silence
+	tr.Add(0, ch.Aftertouch(20), ch.PolyAftertouch(60, 20))
+	tr.Add(50, ch.Aftertouch(30))
+
+	// dense pressure ramps while the note sounds, polyphonic aftertouch of a key that does not sound
+	tr.Add(100, ch.NoteOn(60, 100))
+	for i := 0; i < 50; i++ {
+		tick := uint64(100 + i*2)
+		tr.Add(tick, ch.Aftertouch(uint8(10+i)), ch.PolyAftertouch(60, uint8(10+i)), ch.PolyAftertouch(64, 50))
+	}
+	tr.Add(200, ch.NoteOff(60))
+
+	// pressure in silence after the release
+	tr.Add(210, ch.Aftertouch(5), ch.PolyAftertouch(60, 5))
+
+	s := New(smf.SMF0, smf.MetricTicks(96))
+	s.AddTrack(&tr)
+
+	pressures := func(res *SMF) (at, poly map[uint64]uint8, other int) {
+		at, poly = map[uint64]uint8{}, map[uint64]uint8{}
+
+		for _, ev := range res.Track(0).Events() {
+			switch v := ev.Message.(type) {
+			case channel.Aftertouch:
+				at[ev.AbsTicks] = v.Pressure()
+			case channel.PolyAftertouch:
+				if v.Key() != 60 {
+					other++
+					continue
+				}
+				poly[ev.AbsTicks] = v.Pressure()
+			}
+		}
+
+		return
+	}
+
+	at, poly, other := pressures(ThinPressure(s, 10, 8, true))
+
+	for _, m := range []map[uint64]uint8{at, poly} {
+		if len(m) > 15 {
+			t.Errorf("ThinPressure kept %v of 50 pressure messages; want less than 15", len(m))
+		}
+
+		for tick := range m {
+			if tick < 100 || tick > 200 {
+				t.Errorf("pressure at %v in silence", tick)
+			}
+		}
+
+		// the first pressure after the note on, the final value of the ramp and the zero at the release
+		for tick, value := range map[uint64]uint8{100: 10, 198: 59, 200: 0} {
+			if got, has := m[tick]; !has || got != value {
+				t.Errorf("pressure at %v = %v (%v); want %v", tick, got, has, value)
+			}
+		}
+	}
+
+	if other != 0 {
+		t.Errorf("%v polyphonic aftertouch messages of a key that does not sound", other)
+	}
+
+	// without the gate, the pressure in silence is kept and no zero is added
+	at, poly, other = pressures(ThinPressure(s, 10, 8, false))
+
+	for tick, value := range map[uint64]uint8{0: 20, 100: 10, 198: 59, 210: 5} {
+		if got := at[tick]; got != value {
+			t.Errorf("ungated aftertouch at %v = %v; want %v", tick, got, value)
+		}
+	}
+
+	if _, has := poly[200]; has {
+		t.Errorf("ungated polyphonic aftertouch at the release")
+	}
+
+	if other == 0 {
+		t.Errorf("ungated polyphonic aftertouch of a key that does not sound has been dropped")
+	}
+}
+
+func TestThinPressureUnchanged(t *testing.T) {
+	var notes, pressure Track
+	notes.Add(0, channel.Channel0.NoteOn(60, 100))
+	notes.Add(100, channel.Channel0.NoteOff(60))
+	pressure.Add(0, channel.Channel1.NoteOn(60, 100))
+
+	for tick := uint64(0); tick < 50; tick++ {
+		pressure.Add(tick, channel.Channel1.Aftertouch(uint8(tick)))
+	}
+
+	pressure.Add(100, channel.Channel1.NoteOff(60))
+
+	s := New(smf.SMF1, smf.MetricTicks(96))
+	s.AddTrack(&notes)
+	s.AddTrack(&pressure)
+
+	if err := s.Write(io.Discard); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// only the track with dropped messages is modified, so that the other one keeps its cached chunk
+	res := ThinPressure(s, 10, 8, true)
+
+	for no, shared := range []bool{true, false} {
+		if got := res.Track(no).encoded.Load() == s.Track(no).encoded.Load(); got != shared {
+			t.Errorf("track %v shares the encoded data: %v; want %v", no, got, shared)
+		}
+	}
+}
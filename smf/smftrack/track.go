@@ -0,0 +1,417 @@
+package smftrack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfreader"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+// Event is a MIDI message at a certain position within a track
+type Event struct {
+	// AbsTicks is the distance from the beginning of the track in ticks
+	AbsTicks uint64
+
+	// Message is the MIDI message. It is never meta.EndOfTrack.
+	Message midi.Message
+
+	// Tag is an opaque ID that an application may attach to the event, e.g. to associate the state of an editor
+	// with it. 0 means no tag. Tags are never written.
+	//
+	// The transforming functions keep the tags of the events they move or modify, also when copying them,
+	// while the events they synthesize have no tag. The functions document which events they synthesize.
+	Tag uint64
+}
+
+// Track is a track of a SMF file.
+// The events of a track are sorted by their absolute ticks. Events at the same tick keep their order.
+// The end of track message is not part of the events, it is written at the tick that is returned by End.
+type Track struct {
+	events []Event
+	end    uint64
+
+	// positions is only set, if the track was read with the smfreader.RetainPositions option
+	// and has not been modified since.
+	positions []smfreader.Position
+
+	// raw is only set, if the track was read with the smfreader.Preserve option and has not been modified since.
+	// It contains the raw data of the events, followed by the raw end of track.
+	raw [][]byte
+
+	// prefix is the raw data of the unknown chunks before the track (only with the smfreader.Preserve option)
+	prefix []byte
+
+	// encoded is the track chunk, as it is written without options. It is cached by the first write and
+	// shared with the copies of the track, until the track is modified (see chunk).
+	encoded atomic.Pointer[[]byte]
+
+	// lazy is the data of the track chunk, as long as the events of a track that was read by ReadLazy have not
+	// been decoded (see evs). It is shared with the copies of the track.
+	lazy     atomic.Pointer[[]byte]
+	decoding sync.Mutex
+
+	// frozen prevents modifications (see Freeze)
+	frozen bool
+}
+
+// evs returns the events of the track, decoding them first, if the track was read by ReadLazy.
+// It is safe for concurrent use.
+func (t *Track) evs() []Event {
+	if t.lazy.Load() != nil {
+		t.decode()
+	}
+	return t.events
+}
+
+// decode decodes the events of a track that was read by ReadLazy
+func (t *Track) decode() {
+	t.decoding.Lock()
+	defer t.decoding.Unlock()
+
+	if data := t.lazy.Load(); data != nil {
+		t.events = decodeChunk(*data)
+		t.lazy.Store(nil)
+	}
+}
+
+// Len returns the number of events within the track (without the end of track)
+func (t *Track) Len() int {
+	return len(t.evs())
+}
+
+// Event returns the event at index i
+func (t *Track) Event(i int) Event {
+	return t.evs()[i]
+}
+
+// Tick returns the absolute ticks of the event at index i
+func (t *Track) Tick(i int) uint64 {
+	return t.evs()[i].AbsTicks
+}
+
+// Message returns the message of the event at index i
+func (t *Track) Message(i int) midi.Message {
+	return t.evs()[i].Message
+}
+
+// Tag returns the tag of the event at index i
+func (t *Track) Tag(i int) uint64 {
+	return t.evs()[i].Tag
+}
+
+// SetTag sets the tag of the event at index i. Since tags are not written, the track is not considered to be
+// modified, e.g. the raw data of the smfreader.Preserve option is kept.
+// ErrFrozen is returned, if the track is frozen.
+func (t *Track) SetTag(i int, tag uint64) error {
+	if t.frozen {
+		return ErrFrozen
+	}
+
+	if i < 0 || i >= len(t.evs()) {
+		return fmt.Errorf("index %v out of range [0,%v)", i, len(t.evs()))
+	}
+
+	t.evs()[i].Tag = tag
+	return nil
+}
+
+// Events returns a copy of the events of the track
+func (t *Track) Events() []Event {
+	evts := make([]Event, len(t.evs()))
+	copy(evts, t.evs())
+	return evts
+}
+
+// End returns the position of the end of track message in ticks. The end of track is never before the last
+// event, so that the events after the last note (e.g. controller tails) are kept, even when a transformation has
+// set the end before them.
+func (t *Track) End() uint64 {
+	// the end of a track that has not been decoded yet is never before its last event
+	if t.lazy.Load() != nil {
+		return t.end
+	}
+
+	if n := len(t.evs()); n > 0 && t.evs()[n-1].AbsTicks > t.end {
+		return t.evs()[n-1].AbsTicks
+	}
+
+	return t.end
+}
+
+// Name returns the text of the first track name message (meta.Track) of the track, or an empty string,
+// if there is none.
+func (t *Track) Name() string {
+	for _, ev := range t.evs() {
+		if name, is := ev.Message.(meta.Track); is {
+			return name.Text()
+		}
+	}
+	return ""
+}
+
+// Position returns the position of the event at index i within the SMF data the track has been read from.
+// It returns nil, if the track was not read with the smfreader.RetainPositions option, or if it has been
+// modified since.
+func (t *Track) Position(i int) *smfreader.Position {
+	if t.positions == nil {
+		return nil
+	}
+	pos := t.positions[i]
+	return &pos
+}
+
+// Add adds the given messages at the given tick. They are placed after any existing events at the same tick.
+// The end of track is moved to absTicks, if it was before.
+// meta.EndOfTrack messages are not added, but also move the end of track.
+// ErrFrozen is returned, if the track is frozen.
+func (t *Track) Add(absTicks uint64, msgs ...midi.Message) error {
+	if t.frozen {
+		return ErrFrozen
+	}
+
+	t.modified()
+
+	i := sort.Search(len(t.evs()), func(i int) bool {
+		return t.evs()[i].AbsTicks > absTicks
+	})
+
+	var evts []Event
+
+	for _, msg := range msgs {
+		if msg == meta.EndOfTrack {
+			continue
+		}
+		evts = append(evts, Event{AbsTicks: absTicks, Message: msg})
+	}
+
+	t.events = append(t.evs()[:i], append(evts, t.evs()[i:]...)...)
+
+	if absTicks > t.end {
+		t.end = absTicks
+	}
+
+	return nil
+}
+
+// SetEvents replaces the events of the track by the given events.
+// The events are sorted by their absolute ticks while keeping the order of events at the same tick.
+// Any meta.EndOfTrack is removed. The end of track is moved to the last event, if it was before.
+// ErrFrozen is returned, if the track is frozen.
+func (t *Track) SetEvents(events []Event) error {
+	if t.frozen {
+		return ErrFrozen
+	}
+
+	t.modified()
+	t.events = make([]Event, 0, len(events))
+
+	for _, ev := range events {
+		if ev.Message == meta.EndOfTrack {
+			continue
+		}
+		t.events = append(t.evs(), ev)
+	}
+
+	sort.SliceStable(t.evs(), func(a, b int) bool {
+		return t.evs()[a].AbsTicks < t.evs()[b].AbsTicks
+	})
+
+	if n := len(t.evs()); n > 0 && t.evs()[n-1].AbsTicks > t.end {
+		t.end = t.evs()[n-1].AbsTicks
+	}
+
+	return nil
+}
+
+// Insert inserts the given event at index i, moving the event at index i and the following events back.
+// The ticks of the event must be between the ticks of the events before and after the index, so that the events
+// stay sorted. The end of track is moved to the ticks of the event, if it was before.
+// ErrFrozen is returned, if the track is frozen.
+func (t *Track) Insert(i int, ev Event) error {
+	if t.frozen {
+		return ErrFrozen
+	}
+
+	if i < 0 || i > len(t.evs()) {
+		return fmt.Errorf("index %v out of range [0,%v]", i, len(t.evs()))
+	}
+
+	if ev.Message == meta.EndOfTrack {
+		return fmt.Errorf("can't insert end of track, use SetEnd")
+	}
+
+	if (i > 0 && t.evs()[i-1].AbsTicks > ev.AbsTicks) || (i < len(t.evs()) && t.evs()[i].AbsTicks < ev.AbsTicks) {
+		return fmt.Errorf("can't insert event at tick %v at index %v: events must be sorted by their ticks", ev.AbsTicks, i)
+	}
+
+	t.modified()
+	t.events = append(t.evs(), Event{})
+	copy(t.evs()[i+1:], t.evs()[i:])
+	t.evs()[i] = ev
+
+	if ev.AbsTicks > t.end {
+		t.end = ev.AbsTicks
+	}
+
+	return nil
+}
+
+// Delete removes the event at index i. The end of track is not moved.
+// ErrFrozen is returned, if the track is frozen.
+func (t *Track) Delete(i int) error {
+	if t.frozen {
+		return ErrFrozen
+	}
+
+	if i < 0 || i >= len(t.evs()) {
+		return fmt.Errorf("index %v out of range [0,%v)", i, len(t.evs()))
+	}
+
+	t.modified()
+	t.events = append(t.evs()[:i], t.evs()[i+1:]...)
+	return nil
+}
+
+// SetEnd sets the position of the end of track message in ticks.
+// If there are events after the given ticks, the end of track is set to the last event.
+// ErrFrozen is returned, if the track is frozen.
+func (t *Track) SetEnd(absTicks uint64) error {
+	if t.frozen {
+		return ErrFrozen
+	}
+
+	t.modified()
+
+	if n := len(t.evs()); n > 0 && t.evs()[n-1].AbsTicks > absTicks {
+		absTicks = t.evs()[n-1].AbsTicks
+	}
+
+	t.end = absTicks
+	return nil
+}
+
+// clone returns a deep copy of the track that is not frozen. The raw and encoded data is shared, since it is
+// never modified.
+func (t *Track) clone() *Track {
+	res := &Track{end: t.end, raw: t.raw, prefix: t.prefix}
+	res.encoded.Store(t.encoded.Load())
+
+	// the copy of a track that has not been decoded yet is decoded on its own
+	if data := t.lazy.Load(); data != nil {
+		res.lazy.Store(data)
+	} else {
+		res.events = t.Events()
+	}
+
+	if t.positions != nil {
+		res.positions = make([]smfreader.Position, len(t.positions))
+		copy(res.positions, t.positions)
+	}
+
+	return res
+}
+
+// modified must be called before any modification of the track
+func (t *Track) modified() {
+	t.evs()
+	t.positions = nil
+	t.raw = nil
+	t.encoded.Store(nil)
+}
+
+// chunk returns the track chunk (without prefix), as it is written without options: the raw data of an
+// unmodified track that has been read with the smfreader.Preserve option, otherwise the encoded events.
+// The result is cached until the track is modified, so unmodified tracks are encoded only once, even when they
+// are shared by the copies of the transforming functions. It is safe for concurrent use.
+// The chunk of a track that was read by ReadLazy is cached from the beginning, so it is written without being
+// decoded, as long as it is not modified.
+func (t *Track) chunk() ([]byte, error) {
+	if c := t.encoded.Load(); c != nil {
+		return *c, nil
+	}
+
+	var bf bytes.Buffer
+
+	if t.raw != nil {
+		var length uint32
+
+		for _, raw := range t.raw {
+			length += uint32(len(raw))
+		}
+
+		bf.Grow(8 + int(length))
+		bf.WriteString("MTrk")
+		binary.Write(&bf, binary.BigEndian, length)
+
+		for _, raw := range t.raw {
+			bf.Write(raw)
+		}
+	} else {
+		if err := t.writeTo(smfwriter.New(&bf, smfwriter.NumTracks(1))); err != nil && err != smf.ErrFinished {
+			return nil, err
+		}
+
+		// skip the header chunk
+		bf.Next(14)
+	}
+
+	c := bf.Bytes()
+	t.encoded.Store(&c)
+	return c, nil
+}
+
+func (t *Track) writeTo(wr smf.Writer) error {
+	var last uint64
+
+	for _, ev := range t.evs() {
+		if err := setDelta(wr, ev.AbsTicks-last, ev.Message); err != nil {
+			return err
+		}
+
+		last = ev.AbsTicks
+
+		err := wr.Write(ev.Message)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := setDelta(wr, t.End()-last, meta.EndOfTrack); err != nil {
+		return err
+	}
+
+	return wr.Write(meta.EndOfTrack)
+}
+
+// setDelta sets the given delta time before the given message. Delta times that don't fit into 32 bits are split
+// by empty text messages, if the writer splits delta times (see smfwriter.SplitDeltas); otherwise an error that
+// wraps smfwriter.ErrDeltaOverflow is returned.
+func setDelta(wr smf.Writer, delta uint64, msg midi.Message) error {
+	if delta > math.MaxUint32 {
+		if !smfwriter.SplitsDeltas(wr) {
+			return fmt.Errorf("delta time of %v ticks before %s: %w", delta, msg, smfwriter.ErrDeltaOverflow)
+		}
+
+		for delta > math.MaxUint32 {
+			wr.SetDelta(smfwriter.MaxDelta)
+
+			if err := wr.Write(meta.Text("")); err != nil {
+				return err
+			}
+
+			delta -= smfwriter.MaxDelta
+		}
+	}
+
+	wr.SetDelta(uint32(delta))
+	return nil
+}
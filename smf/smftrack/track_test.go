@@ -0,0 +1,231 @@
+package smftrack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smfwriter"
+)
+
+func TestInsertDelete(t *testing.T) {
+	var tr Track
+	tr.Add(0, channel.Channel0.NoteOn(60, 100))
+	tr.Add(20, channel.Channel0.NoteOff(60))
+
+	tests := []struct {
+		i   int
+		ev  Event
+		err bool
+	}{
+		{1, Event{AbsTicks: 10, Message: channel.Channel0.NoteOn(64, 100)}, false},
+		{0, Event{AbsTicks: 0, Message: channel.Channel0.ProgramChange(3)}, false},
+		{4, Event{AbsTicks: 30, Message: channel.Channel0.NoteOff(64)}, false},
+		{1, Event{AbsTicks: 5, Message: channel.Channel0.NoteOff(64)}, true},
+		{4, Event{AbsTicks: 10, Message: channel.Channel0.NoteOff(64)}, true},
+		{6, Event{AbsTicks: 40, Message: channel.Channel0.NoteOff(64)}, true},
+		{-1, Event{AbsTicks: 0, Message: channel.Channel0.NoteOff(64)}, true},
+		{5, Event{AbsTicks: 40, Message: meta.EndOfTrack}, true},
+	}
+
+	for i, test := range tests {
+		if err := tr.Insert(test.i, test.ev); (err != nil) != test.err {
+			t.Errorf("[%v] Insert(%v, %v) error = %v; want error: %v", i, test.i, test.ev, err, test.err)
+		}
+	}
+
+	if err := tr.Delete(3); err != nil {
+		t.Errorf("Delete(3) error = %v", err)
+	}
+
+	for _, i := range []int{-1, 4} {
+		if err := tr.Delete(i); err == nil {
+			t.Errorf("Delete(%v): expected an error", i)
+		}
+	}
+
+	expected := `0 channel.ProgramChange channel 1 program 3
+0 channel.NoteOn channel 1 key 60 velocity 100
+10 channel.NoteOn channel 1 key 64 velocity 100
+30 channel.NoteOff channel 1 key 64
+30 end
+`
+
+	if got := trackString(&tr); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	if got, want := fmt.Sprintf("%v %v", tr.Tick(2), tr.Message(2)), "10 channel.NoteOn channel 1 key 64 velocity 100"; got != want {
+		t.Errorf("Tick(2), Message(2) = %q; want %q", got, want)
+	}
+
+	tr.Freeze()
+
+	if err := tr.Insert(0, Event{Message: channel.Channel0.ProgramChange(3)}); err != ErrFrozen {
+		t.Errorf("Insert() error = %v; want %v", err, ErrFrozen)
+	}
+
+	if err := tr.Delete(0); err != ErrFrozen {
+		t.Errorf("Delete() error = %v; want %v", err, ErrFrozen)
+	}
+}
+
+// multiTrackFile returns a SMF1 with the given number of tracks of the given number of notes each
+func multiTrackFile(tracks, notes int) *SMF {
+	s := New(smf.SMF1, smf.MetricTicks(960))
+
+	for no := 0; no < tracks; no++ {
+		var tr Track
+		ch := channel.Channel(uint8(no % 16))
+		tr.Add(0, meta.Track(fmt.Sprintf("track %v", no)), ch.ProgramChange(uint8(no)))
+
+		for i := 0; i < notes; i++ {
+			key := uint8(36 + (i*7+no)%48)
+			tr.Add(uint64(i*240), ch.NoteOn(key, uint8(1+i%127)), ch.ControlChange(1, uint8(i%128)))
+			tr.Add(uint64(i*240+200), ch.NoteOff(key))
+		}
+
+		s.AddTrack(&tr)
+	}
+
+	return s
+}
+
+// writeUncached writes the SMF by encoding all tracks
+func writeUncached(s *SMF) []byte {
+	var bf bytes.Buffer
+	s.writeTracks(smfwriter.New(&bf, s.writerOptions(nil)...))
+	return bf.Bytes()
+}
+
+func TestWriteCache(t *testing.T) {
+	s := multiTrackFile(4, 20)
+
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := bf.Bytes(), writeUncached(s); !bytes.Equal(got, want) {
+		t.Errorf("got:\n% X\n\nwanted:\n% X\n\n", got, want)
+	}
+
+	// the copy shares the encoded tracks, until they are modified
+	res := s.clone()
+	res.Track(2).Delete(5)
+	res.Track(3).SetEnd(100000)
+
+	for no, shared := range []bool{true, true, false, false} {
+		if got := res.Track(no).encoded.Load() == s.Track(no).encoded.Load(); got != shared {
+			t.Errorf("track %v shares the encoded data: %v; want %v", no, got, shared)
+		}
+	}
+
+	bf.Reset()
+
+	if err := res.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := bf.Bytes(), writeUncached(res); !bytes.Equal(got, want) {
+		t.Errorf("got:\n% X\n\nwanted:\n% X\n\n", got, want)
+	}
+
+	// the original is written as before
+	bf.Reset()
+	s.Write(&bf)
+
+	if got, want := bf.Bytes(), writeUncached(s); !bytes.Equal(got, want) {
+		t.Errorf("got:\n% X\n\nwanted:\n% X\n\n", got, want)
+	}
+
+	// the written data is read back
+	back, err := Read(bytes.NewReader(bf.Bytes()))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := trackString(back.Track(2)), trackString(s.Track(2)); got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+// BenchmarkWriteChanged writes a copy of a SMF of 16 tracks with 2000 notes each, where one track has been changed.
+func BenchmarkWriteChanged(b *testing.B) {
+	s := multiTrackFile(16, 2000)
+
+	var bf bytes.Buffer
+	s.Write(&bf)
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			res := s.clone()
+			res.Track(5).Delete(10)
+			bf.Reset()
+
+			if err := res.Write(&bf); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	})
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			res := s.clone()
+			res.Track(5).Delete(10)
+			bf.Reset()
+
+			if err := res.writeTracks(smfwriter.New(&bf, res.writerOptions(nil)...)); err != nil {
+				b.Fatalf("Error: %v", err)
+			}
+		}
+	})
+}
+
+func TestWriteDeltaOverflow(t *testing.T) {
+	var tr Track
+	tr.Add(0, channel.Channel0.NoteOn(60, 100))
+	tr.Add(1<<33, channel.Channel0.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(960))
+	s.AddTrack(&tr)
+
+	var bf bytes.Buffer
+
+	for _, options := range [][]smfwriter.Option{nil, {smfwriter.NoRunningStatus()}} {
+		if err := s.Write(&bf, options...); !errors.Is(err, smfwriter.ErrDeltaOverflow) {
+			t.Errorf("Write(%v options) error = %v; want %v", len(options), err, smfwriter.ErrDeltaOverflow)
+		}
+	}
+
+	bf.Reset()
+
+	if err := s.Write(&bf, smfwriter.SplitDeltas()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res, err := Read(bytes.NewReader(bf.Bytes()))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// 8589934592 = 32 * 0x0FFFFFFF + 32
+	if got, want := res.Track(0).Len(), 34; got != want {
+		t.Errorf("Len() = %v; want %v", got, want)
+	}
+
+	if got, want := fmt.Sprintf("%v %v", res.Track(0).Tick(33), res.Track(0).Message(33)), "8589934592 channel.NoteOff channel 1 key 60"; got != want {
+		t.Errorf("last event = %q; want %q", got, want)
+	}
+}
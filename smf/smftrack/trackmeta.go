@@ -0,0 +1,265 @@
+package smftrack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gomidi/midi/internal/midilib"
+	"github.com/gomidi/midi/internal/vlq"
+	"github.com/gomidi/midi/midimessage/meta"
+)
+
+/*
+TrackMeta is stored as a sequencer specific meta message (FF 7F) at tick 0 of the track:
+
+	FF 7F <len> 7D 67 6D 54 4D <version> [<field>]...
+
+7D is the manufacturer ID that is reserved for non-commercial use, followed by the signature "gmTM" and
+the version of the format (currently 01). Each field consists of a tag byte, the length of the field data
+as variable length quantity and the field data:
+
+	43 ('C') color:   03 <red> <green> <blue>
+	46 ('F') flags:   01 <bits> (bit 0: mute, bit 1: solo)
+	56 ('V') value:   <len> <key> 00 <value> (the key must not contain 00)
+
+Fields with unknown tags are skipped, so that newer versions may add fields.
+*/
+
+// trackMetaPrefix is the beginning of the sequencer specific data of a TrackMeta
+var trackMetaPrefix = []byte{0x7D, 'g', 'm', 'T', 'M'}
+
+const trackMetaVersion = 0x01
+
+// field tags of the TrackMeta data
+const (
+	trackMetaColor = 'C'
+	trackMetaFlags = 'F'
+	trackMetaValue = 'V'
+)
+
+// Color is a RGB color
+type Color struct {
+	R, G, B uint8
+}
+
+// TrackMeta are properties of a track that DAWs usually keep in proprietary formats (see Track.Meta).
+// The zero value has no properties.
+type TrackMeta struct {
+	// Color is the color of the track, nil if there is none
+	Color *Color
+
+	Mute bool
+	Solo bool
+
+	// Values are arbitrary key value pairs. The keys must not contain null bytes.
+	Values map[string]string
+}
+
+// isZero returns true, if the TrackMeta has no properties
+func (m TrackMeta) isZero() bool {
+	return m.Color == nil && !m.Mute && !m.Solo && len(m.Values) == 0
+}
+
+// isTrackMeta returns true, if the given sequencer specific data is a TrackMeta
+func isTrackMeta(data []byte) bool {
+	return bytes.HasPrefix(data, trackMetaPrefix)
+}
+
+// encode returns the sequencer specific data of the TrackMeta
+func (m TrackMeta) encode() []byte {
+	var bf bytes.Buffer
+	bf.Write(trackMetaPrefix)
+	bf.WriteByte(trackMetaVersion)
+
+	field := func(tag byte, data []byte) {
+		bf.WriteByte(tag)
+		bf.Write(vlq.Encode(uint32(len(data))))
+		bf.Write(data)
+	}
+
+	if m.Color != nil {
+		field(trackMetaColor, []byte{m.Color.R, m.Color.G, m.Color.B})
+	}
+
+	if m.Mute || m.Solo {
+		var bits byte
+
+		if m.Mute {
+			bits |= 1
+		}
+
+		if m.Solo {
+			bits |= 2
+		}
+
+		field(trackMetaFlags, []byte{bits})
+	}
+
+	var keys []string
+
+	for k := range m.Values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		field(trackMetaValue, []byte(k+"\x00"+m.Values[k]))
+	}
+
+	return bf.Bytes()
+}
+
+// decodeTrackMeta decodes the sequencer specific data of a TrackMeta
+func decodeTrackMeta(data []byte) (m TrackMeta, err error) {
+	if !isTrackMeta(data) || len(data) < len(trackMetaPrefix)+1 {
+		return m, fmt.Errorf("not a track meta")
+	}
+
+	rd := bytes.NewReader(data[len(trackMetaPrefix)+1:])
+
+	for rd.Len() > 0 {
+		tag, _ := rd.ReadByte()
+		length, err := midilib.ReadVarLength(rd)
+
+		if err != nil {
+			return m, err
+		}
+
+		if length > uint32(rd.Len()) {
+			return m, fmt.Errorf("field %q: length %v exceeds the remaining %v bytes", tag, length, rd.Len())
+		}
+
+		fd := make([]byte, length)
+
+		if _, err := io.ReadFull(rd, fd); err != nil {
+			return m, fmt.Errorf("field %q: %v", tag, err)
+		}
+
+		switch tag {
+		case trackMetaColor:
+			if len(fd) == 3 {
+				m.Color = &Color{R: fd[0], G: fd[1], B: fd[2]}
+			}
+		case trackMetaFlags:
+			if len(fd) > 0 {
+				m.Mute = fd[0]&1 != 0
+				m.Solo = fd[0]&2 != 0
+			}
+		case trackMetaValue:
+			if i := bytes.IndexByte(fd, 0); i >= 0 {
+				if m.Values == nil {
+					m.Values = map[string]string{}
+				}
+				m.Values[string(fd[:i])] = string(fd[i+1:])
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// Meta returns the TrackMeta of the track. If the track has none, the zero value is returned.
+// The TrackMeta is read from the first sequencer specific message of the track that is a TrackMeta;
+// damaged fields are ignored.
+func (t *Track) Meta() TrackMeta {
+	data := t.sequencerData(trackMetaPrefix)
+
+	if data == nil {
+		return TrackMeta{}
+	}
+
+	m, _ := decodeTrackMeta(data)
+	return m
+}
+
+// SetMeta replaces the TrackMeta of the track by the given one. It is written as sequencer specific message
+// at tick 0, after the leading meta messages (e.g. the track name). Setting the zero value removes the TrackMeta.
+// Other sequencer specific messages are not touched.
+// An error is returned, if a key of the values contains a null byte. ErrFrozen is returned, if the track is frozen.
+func (t *Track) SetMeta(m TrackMeta) error {
+	if t.frozen {
+		return ErrFrozen
+	}
+
+	for k := range m.Values {
+		if strings.IndexByte(k, 0) >= 0 {
+			return fmt.Errorf("invalid track meta key %q: must not contain null bytes", k)
+		}
+	}
+
+	var data []byte
+
+	if !m.isZero() {
+		data = m.encode()
+	}
+
+	return t.setSequencerData(trackMetaPrefix, data)
+}
+
+// sequencerData returns the data of the first sequencer specific message of the track that starts with the
+// given prefix, nil if there is none
+func (t *Track) sequencerData(prefix []byte) []byte {
+	for _, ev := range t.evs() {
+		if sd, is := ev.Message.(meta.SequencerData); is && bytes.HasPrefix(sd.Data(), prefix) {
+			return sd.Data()
+		}
+	}
+
+	return nil
+}
+
+// setSequencerData replaces the sequencer specific messages that start with the given prefix by a single
+// message with the given data at tick 0, after the leading meta messages. nil data removes the messages.
+func (t *Track) setSequencerData(prefix, data []byte) error {
+	var evts = make([]Event, 0, len(t.evs())+1)
+	var pos = -1
+
+	for _, ev := range t.evs() {
+		if sd, is := ev.Message.(meta.SequencerData); is && bytes.HasPrefix(sd.Data(), prefix) {
+			continue
+		}
+
+		if _, is := ev.Message.(meta.Message); pos < 0 && (ev.AbsTicks > 0 || !is) {
+			pos = len(evts)
+		}
+
+		evts = append(evts, ev)
+	}
+
+	if pos < 0 {
+		pos = len(evts)
+	}
+
+	if data != nil {
+		evts = append(evts[:pos], append([]Event{{Message: meta.SequencerData(data)}}, evts[pos:]...)...)
+	}
+
+	return t.SetEvents(evts)
+}
+
+// TrackMeta returns the TrackMeta of the track with the given number (see Track.Meta).
+// An error is returned, if the track does not exist.
+func (s *SMF) TrackMeta(no int) (TrackMeta, error) {
+	if no < 0 || no >= len(s.tracks) {
+		return TrackMeta{}, fmt.Errorf("track %v does not exist", no)
+	}
+
+	return s.tracks[no].Meta(), nil
+}
+
+// SetTrackMeta replaces the TrackMeta of the track with the given number (see Track.SetMeta)
+func (s *SMF) SetTrackMeta(no int, m TrackMeta) error {
+	if no < 0 || no >= len(s.tracks) {
+		return fmt.Errorf("track %v does not exist", no)
+	}
+
+	if s.frozen {
+		return ErrFrozen
+	}
+
+	return s.tracks[no].SetMeta(m)
+}
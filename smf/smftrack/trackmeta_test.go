@@ -0,0 +1,153 @@
+package smftrack
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestTrackMeta(t *testing.T) {
+	var tr Track
+	tr.Add(0, meta.Track("piano"), meta.SequencerData{0x41, 0x01, 0x02}, channel.Channel0.NoteOn(60, 100))
+	tr.Add(480, channel.Channel0.NoteOff(60))
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	if got, err := s.TrackMeta(0); err != nil || !reflect.DeepEqual(got, TrackMeta{}) {
+		t.Errorf("TrackMeta() = %+v; want zero value", got)
+	}
+
+	m := TrackMeta{
+		Color:  &Color{R: 0xFF, G: 0x80, B: 0x00},
+		Solo:   true,
+		Values: map[string]string{"icon": "keys", "group": "strings"},
+	}
+
+	if err := s.SetTrackMeta(0, m); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the foreign sequencer specific message is untouched
+	expected := `0 meta.Track: "piano"
+0 meta.SequencerData len 3
+0 meta.SequencerData len 40
+0 channel.NoteOn channel 1 key 60 velocity 100
+480 channel.NoteOff channel 1 key 60
+480 end
+`
+
+	if got := trackString(s.Track(0)); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+
+	data := s.Track(0).Event(2).Message.(meta.SequencerData).Data()
+	expectedData := []byte{0x7D, 'g', 'm', 'T', 'M', 0x01,
+		'C', 0x03, 0xFF, 0x80, 0x00,
+		'F', 0x01, 0x02,
+		'V', 0x0D, 'g', 'r', 'o', 'u', 'p', 0x00, 's', 't', 'r', 'i', 'n', 'g', 's',
+		'V', 0x09, 'i', 'c', 'o', 'n', 0x00, 'k', 'e', 'y', 's',
+	}
+
+	if !bytes.Equal(data, expectedData) {
+		t.Errorf("data = % X; want % X", data, expectedData)
+	}
+
+	// round trip
+	var bf bytes.Buffer
+
+	if err := s.Write(&bf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	read, err := Read(&bf)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, _ := read.TrackMeta(0); !reflect.DeepEqual(got, m) {
+		t.Errorf("TrackMeta() after round trip = %+v; want %+v", got, m)
+	}
+
+	if got, want := read.Track(0).Event(1).Message, (meta.SequencerData{0x41, 0x01, 0x02}); !reflect.DeepEqual(got, want) {
+		t.Errorf("foreign message = %v; want %v", got, want)
+	}
+
+	// replacing keeps a single TrackMeta
+	if err := read.SetTrackMeta(0, TrackMeta{Mute: true}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, _ := read.TrackMeta(0); !reflect.DeepEqual(got, TrackMeta{Mute: true}) {
+		t.Errorf("TrackMeta() = %+v; want Mute", got)
+	}
+
+	if got, want := read.Track(0).Len(), 5; got != want {
+		t.Errorf("Len() = %v; want %v", got, want)
+	}
+
+	// the zero value removes the TrackMeta
+	if err := read.SetTrackMeta(0, TrackMeta{}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := read.Track(0).Len(), 4; got != want {
+		t.Errorf("Len() = %v; want %v", got, want)
+	}
+}
+
+func TestTrackMetaDecode(t *testing.T) {
+	// unknown and damaged fields are skipped
+	var tr Track
+	tr.Add(0, meta.SequencerData{0x7D, 'g', 'm', 'T', 'M', 0x02,
+		'X', 0x02, 0x01, 0x02,
+		'C', 0x02, 0x01, 0x02,
+		'V', 0x03, 'a', 0x00, 'b',
+		'V', 0x01, 'c',
+	})
+
+	if got, want := tr.Meta(), (TrackMeta{Values: map[string]string{"a": "b"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Meta() = %+v; want %+v", got, want)
+	}
+
+	// a truncated field ends the decoding
+	tr = Track{}
+	tr.Add(0, meta.SequencerData{0x7D, 'g', 'm', 'T', 'M', 0x01, 'F', 0x01, 0x01, 'C', 0x03, 0x01})
+
+	if got, want := tr.Meta(), (TrackMeta{Mute: true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Meta() = %+v; want %+v", got, want)
+	}
+
+	// a length beyond the data is rejected before allocating
+	if _, err := decodeTrackMeta([]byte{0x7D, 'g', 'm', 'T', 'M', 0x01, 'V', 0xFF, 0xFF, 0xFF, 0x7F}); err == nil {
+		t.Errorf("expected error for a length beyond the data")
+	}
+}
+
+func TestTrackMetaErrors(t *testing.T) {
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&Track{})
+
+	if err := s.SetTrackMeta(1, TrackMeta{Mute: true}); err == nil {
+		t.Errorf("expected error for missing track")
+	}
+
+	if _, err := s.TrackMeta(1); err == nil {
+		t.Errorf("expected error for missing track")
+	}
+
+	if err := s.SetTrackMeta(0, TrackMeta{Values: map[string]string{"a\x00b": "c"}}); err == nil {
+		t.Errorf("expected error for invalid key")
+	}
+
+	s.Freeze()
+
+	if err := s.SetTrackMeta(0, TrackMeta{Mute: true}); err != ErrFrozen {
+		t.Errorf("SetTrackMeta() error = %v; want %v", err, ErrFrozen)
+	}
+}
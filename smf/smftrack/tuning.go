@@ -0,0 +1,88 @@
+package smftrack
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gomidi/midi/midimessage/channel/tuning"
+	"github.com/gomidi/midi/smf"
+)
+
+// ApplyTuning returns a copy of the given SMF where the notes sound in the given Tuning on receivers with a pitch
+// bend range of bendRange semitones: each note is played by the nearest key and detuned by the pitch bend of its
+// channel (see tuning.Retuner). The given SMF is not modified.
+//
+// By default, the notes of each used channel (except channel 9) are distributed to the channel itself and an equal
+// share of the unused channels (except channel 9). The given options (e.g. tuning.Pool) override the defaults.
+// The events of all tracks are converted in the order of Merged, so that the tracks share the channels, except for
+// SMF format 2, whose tracks are converted on their own. The messages stay in the track of the message they replace;
+// they keep its tag, if they have the same type, the added messages have none.
+// An error is returned, if bendRange is not positive.
+func ApplyTuning(s *SMF, t tuning.Tuning, bendRange float64, options ...tuning.Option) (*SMF, error) {
+	if bendRange <= 0 {
+		return nil, fmt.Errorf("invalid pitch bend range %v", bendRange)
+	}
+
+	used, _ := s.channelUsage()
+	var sources, spare []uint8
+
+	for ch := uint8(0); ch < 16; ch++ {
+		switch {
+		case ch == 9:
+		case used[ch]:
+			sources = append(sources, ch)
+		default:
+			spare = append(spare, ch)
+		}
+	}
+
+	var opts []tuning.Option
+
+	for i, src := range sources {
+		pool := []uint8{src}
+
+		for j := i; j < len(spare); j += len(sources) {
+			pool = append(pool, spare[j])
+		}
+
+		opts = append(opts, tuning.Pool(src, pool...))
+	}
+
+	opts = append(opts, options...)
+	res := s.clone()
+	evts := make([][]Event, len(s.tracks))
+
+	convert := func(r *tuning.Retuner, no int, ev Event) {
+		for _, msg := range r.Convert(ev.Message) {
+			tag := ev.Tag
+
+			if reflect.TypeOf(msg) != reflect.TypeOf(ev.Message) {
+				tag = 0
+			}
+
+			evts[no] = append(evts[no], Event{AbsTicks: ev.AbsTicks, Message: msg, Tag: tag})
+		}
+	}
+
+	if s.format == smf.SMF2 {
+		for no, tr := range s.tracks {
+			r := tuning.NewRetuner(t, bendRange, opts...)
+
+			for _, ev := range tr.evs() {
+				convert(r, no, ev)
+			}
+		}
+	} else {
+		r := tuning.NewRetuner(t, bendRange, opts...)
+
+		for ev := range s.All() {
+			convert(r, ev.Track, ev.Event)
+		}
+	}
+
+	for no, tr := range res.tracks {
+		tr.SetEvents(evts[no])
+	}
+
+	return res, nil
+}
@@ -0,0 +1,88 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/channel/tuning"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestApplyTuning(t *testing.T) {
+	var strings, drums Track
+	ch := channel.Channel0
+	chords := [][]uint8{{60, 62, 65, 68, 71, 74}, {61, 64, 66, 69, 72, 75}, {55, 58, 60, 63, 66, 70}}
+
+	strings.Add(0, ch.ProgramChange(48))
+
+	for i, chord := range chords {
+		tick := uint64(i * 480)
+
+		for _, key := range chord {
+			strings.Add(tick, ch.NoteOn(key, 100))
+			strings.Add(tick+480, ch.NoteOff(key))
+		}
+	}
+
+	drums.Add(0, channel.Channel9.NoteOn(36, 100))
+	drums.Add(480, channel.Channel9.NoteOff(36))
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&strings)
+	s.AddTrack(&drums)
+
+	t19 := tuning.EqualTemperament(19).Tuning(60)
+	res, err := ApplyTuning(s, t19, 2)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// play the result and check the pitch of each note
+	var bends [16]int16
+	var programs = map[uint8]uint8{}
+	var pitches []string
+
+	for ev := range res.All() {
+		switch v := ev.Message.(type) {
+		case channel.ProgramChange:
+			programs[v.Channel()] = v.Program()
+		case channel.Pitchbend:
+			bends[v.Channel()] = v.Value()
+		case channel.NoteOn:
+			if programs[v.Channel()] != 48 && v.Channel() != 9 {
+				t.Errorf("channel %v has no program", v.Channel())
+			}
+			pitch := float64(v.Key())*100 + float64(bends[v.Channel()])*200/8192
+			pitches = append(pitches, fmt.Sprintf("%.0f", math.Round(pitch)))
+		}
+	}
+
+	var expected []string
+
+	for _, chord := range chords {
+		for _, key := range chord {
+			expected = append(expected, fmt.Sprintf("%.0f", math.Round(t19.Pitch(key))))
+		}
+
+		if len(expected) == 6 {
+			// the drums
+			expected = append(expected, "3600")
+		}
+	}
+
+	if got, want := fmt.Sprint(pitches), fmt.Sprint(expected); got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	// the drum track is untouched
+	if got, want := trackString(res.Track(1)), trackString(s.Track(1)); got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+
+	if _, err := ApplyTuning(s, t19, 0); err == nil {
+		t.Errorf("ApplyTuning with a pitch bend range of 0 must return an error")
+	}
+}
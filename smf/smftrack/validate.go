@@ -0,0 +1,300 @@
+package smftrack
+
+import (
+	"fmt"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+// rules of Validate
+const (
+	// RuleMetaPlacement is violated by Time Signature, Key Signature, SMPTE Offset, Marker and Cue Point
+	// messages that are not in the first track of a SMF1 (see MoveFirstTrackMetas)
+	RuleMetaPlacement = "meta-placement"
+
+	// RuleTrackCount is violated by a SMF0 that has not exactly one track
+	RuleTrackCount = "track-count"
+
+	// RuleValueRange is violated by a tempo of 0, a time signature with a numerator of 0 or a denominator that is
+	// not a power of 2 and a key signature with more than 7 accidentals
+	RuleValueRange = "value-range"
+
+	// RuleUndefinedMessage is violated by meta messages of an undefined type (see meta.Undefined)
+	RuleUndefinedMessage = "undefined-message"
+
+	// RuleTrackEnds is violated by the tracks of a SMF1 with channel messages that end much earlier than the
+	// longest of them (more than a bar of 4/4 and more than a tenth of its length), which often truncates the
+	// playback of sequencers that loop or export single tracks (see SetTrackLength). It is no violation of the
+	// SMF specification, so its problems are of the category smf.Suspicious and always reported as warnings.
+	RuleTrackEnds = "track-ends"
+)
+
+// Problem is a violation of the SMF specification or suspicious data that is found by Validate
+type Problem struct {
+	// Rule is the violated rule, e.g. RuleMetaPlacement
+	Rule string
+
+	// Category is the category of the rule
+	Category smf.Category
+
+	// Track is the number of the track (starting with 0)
+	Track int
+
+	// Event is the index of the event within the track
+	Event int
+
+	AbsTicks uint64
+	Message  midi.Message
+
+	// Description describes the problem
+	Description string
+
+	// Position is the position of AbsTicks, if Validate has been called with the BarPositions option
+	Position *Position
+}
+
+// String represents the problem as a string, showing its position instead of the ticks, if it is set
+func (p Problem) String() string {
+	if p.Position != nil {
+		return fmt.Sprintf("track %v at %s: %s (%s)", p.Track, p.Position, p.Description, p.Rule)
+	}
+	return fmt.Sprintf("track %v at tick %v: %s (%s)", p.Track, p.AbsTicks, p.Description, p.Rule)
+}
+
+type validateConfig struct {
+	bars      bool
+	positions []PositionOption
+}
+
+// ValidateOption is an option for Validate and ValidatePolicy
+type ValidateOption func(*validateConfig)
+
+// BarPositions sets the Position of the problems, so that they show the bar and beat instead of the ticks.
+// The positions are based on the MeterMap of the SMF, respectively of the track for SMF2, and the given options.
+func BarPositions(options ...PositionOption) ValidateOption {
+	return func(c *validateConfig) {
+		c.bars = true
+		c.positions = options
+	}
+}
+
+// Error implements the error interface
+func (p Problem) Error() string {
+	return p.String()
+}
+
+// validateRules are checked by Validate in this order
+var validateRules = []struct {
+	category smf.Category
+	check    func(s *SMF) []Problem
+}{
+	{smf.StructuralErrors, validateTrackCount},
+	{smf.PlacementViolations, validateMetaPlacement},
+	{smf.Suspicious, validateTrackEnds},
+	{smf.RangeViolations, validateValueRange},
+	{smf.UnknownData, validateUndefinedMessages},
+}
+
+// Validate checks the given SMF against the rules of the SMF specification and for suspicious data (see RuleTrackEnds)
+// and returns the problems that were found, ordered by rule, track and event.
+func Validate(s *SMF, options ...ValidateOption) (problems []Problem) {
+	var c validateConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	var meters = map[int]*MeterMap{}
+
+	for _, rule := range validateRules {
+		for _, p := range rule.check(s) {
+			p.Category = rule.category
+
+			if c.bars {
+				pos := s.trackMeterMap(p.Track, meters).Position(p.AbsTicks, c.positions...)
+				p.Position = &pos
+			}
+
+			problems = append(problems, p)
+		}
+	}
+	return
+}
+
+// trackMeterMap returns the MeterMap for the events of the given track: the MeterMap of the SMF, respectively of
+// the track for SMF2. The MeterMaps are cached in the given map.
+func (s *SMF) trackMeterMap(track int, cache map[int]*MeterMap) *MeterMap {
+	if s.format != smf.SMF2 || track < 0 || track >= len(s.tracks) {
+		track = -1
+	}
+
+	if m, has := cache[track]; has {
+		return m
+	}
+
+	m := s.MeterMap()
+
+	if track >= 0 {
+		m = NewMeterMap(s.timeFormat, s.tracks[track])
+	}
+
+	cache[track] = m
+	return m
+}
+
+// ValidatePolicy checks the given SMF like Validate and handles the problems by their category according to the
+// given policy: the problems of the categories that fail are returned as errs, the problems of the categories that
+// warn as warnings, while the problems of the ignored categories are dropped. Suspicious data is always returned
+// as warnings.
+func ValidatePolicy(s *SMF, p smf.Policy, options ...ValidateOption) (errs, warnings []Problem) {
+	for _, problem := range Validate(s, options...) {
+		switch p.Action(problem.Category) {
+		case smf.Fail:
+			errs = append(errs, problem)
+		case smf.Warn:
+			warnings = append(warnings, problem)
+		}
+	}
+	return
+}
+
+func validateTrackCount(s *SMF) []Problem {
+	if s.format != smf.SMF0 || len(s.tracks) == 1 {
+		return nil
+	}
+
+	return []Problem{{
+		Rule:        RuleTrackCount,
+		Description: fmt.Sprintf("SMF0 with %v tracks", len(s.tracks)),
+	}}
+}
+
+func validateValueRange(s *SMF) (problems []Problem) {
+	for no, tr := range s.tracks {
+		for i, ev := range tr.evs() {
+			var desc string
+
+			switch msg := ev.Message.(type) {
+			case meta.Tempo:
+				if msg == 0 {
+					desc = "tempo of 0 microseconds per quarter note"
+				}
+			case meta.TimeSig:
+				if msg.Numerator == 0 || msg.Denominator == 0 || msg.Denominator&(msg.Denominator-1) != 0 {
+					desc = fmt.Sprintf("time signature of %v/%v", msg.Numerator, msg.Denominator)
+				}
+			case meta.Key:
+				if msg.Num > 7 {
+					desc = fmt.Sprintf("key signature with %v accidentals", msg.Num)
+				}
+			}
+
+			if desc != "" {
+				problems = append(problems, Problem{
+					Rule:        RuleValueRange,
+					Track:       no,
+					Event:       i,
+					AbsTicks:    ev.AbsTicks,
+					Message:     ev.Message,
+					Description: desc,
+				})
+			}
+		}
+	}
+
+	return
+}
+
+func validateUndefinedMessages(s *SMF) (problems []Problem) {
+	for no, tr := range s.tracks {
+		for i, ev := range tr.evs() {
+			if u, is := ev.Message.(meta.Undefined); is {
+				problems = append(problems, Problem{
+					Rule:        RuleUndefinedMessage,
+					Track:       no,
+					Event:       i,
+					AbsTicks:    ev.AbsTicks,
+					Message:     ev.Message,
+					Description: fmt.Sprintf("meta message of undefined type %02X", byte(u.Typ)),
+				})
+			}
+		}
+	}
+
+	return
+}
+
+func validateMetaPlacement(s *SMF) (problems []Problem) {
+	if s.format != smf.SMF1 {
+		return nil
+	}
+
+	for no, tr := range s.tracks {
+		if no == 0 {
+			continue
+		}
+
+		for i, ev := range tr.evs() {
+			if isFirstTrackOnly(ev.Message) {
+				problems = append(problems, Problem{
+					Rule:        RuleMetaPlacement,
+					Track:       no,
+					Event:       i,
+					AbsTicks:    ev.AbsTicks,
+					Message:     ev.Message,
+					Description: fmt.Sprintf("%s is only allowed in the first track", ev.Message),
+				})
+			}
+		}
+	}
+
+	return
+}
+
+func validateTrackEnds(s *SMF) (problems []Problem) {
+	if s.format != smf.SMF1 {
+		return nil
+	}
+
+	var tracks []int
+	var longest uint64
+
+	for no, tr := range s.tracks {
+		for _, ev := range tr.evs() {
+			if _, is := ev.Message.(channel.Message); is {
+				tracks = append(tracks, no)
+
+				if tr.End() > longest {
+					longest = tr.End()
+				}
+				break
+			}
+		}
+	}
+
+	tolerance := longest / 10
+
+	if tpq, is := s.timeFormat.(smf.MetricTicks); is && uint64(tpq.Ticks4th())*4 > tolerance {
+		tolerance = uint64(tpq.Ticks4th()) * 4
+	}
+
+	for _, no := range tracks {
+		tr := s.tracks[no]
+
+		if end := tr.End(); longest-end > tolerance {
+			problems = append(problems, Problem{
+				Rule:        RuleTrackEnds,
+				Track:       no,
+				Event:       len(tr.evs()),
+				AbsTicks:    end,
+				Message:     meta.EndOfTrack,
+				Description: fmt.Sprintf("track ends %v ticks before the longest track at tick %v", longest-end, longest),
+			})
+		}
+	}
+
+	return
+}
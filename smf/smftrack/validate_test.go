@@ -0,0 +1,227 @@
+package smftrack
+
+import (
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+)
+
+func TestValidate(t *testing.T) {
+	// a SMF0 with two tracks, a tempo of 0, a time signature of 3/3 and an undefined meta message
+	var tr0, tr1 Track
+	tr0.Add(0, meta.Tempo(0), meta.TimeSig{Numerator: 3, Denominator: 3}, channel.Channel0.NoteOn(60, 100))
+	tr0.Add(480, channel.Channel0.NoteOff(60), meta.Undefined{Typ: 0x60, Data: []byte{0x05}})
+	tr1.Add(0, meta.Key{Key: 0, Num: 9, IsMajor: true})
+
+	// AddTrack would convert it to SMF1, as if it had been read tolerantly
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.tracks = []*Track{&tr0, &tr1}
+
+	expected := []string{
+		`track 0 at tick 0: SMF0 with 2 tracks (track-count)`,
+		`track 0 at tick 0: tempo of 0 microseconds per quarter note (value-range)`,
+		`track 0 at tick 0: time signature of 3/3 (value-range)`,
+		`track 1 at tick 0: key signature with 9 accidentals (value-range)`,
+		`track 0 at tick 480: meta message of undefined type 60 (undefined-message)`,
+	}
+
+	problems := Validate(s)
+
+	if got, want := len(problems), len(expected); got != want {
+		t.Fatalf("len(Validate()) = %v; want %v: %v", got, want, problems)
+	}
+
+	for i, p := range problems {
+		if got, want := p.String(), expected[i]; got != want {
+			t.Errorf("Validate()[%v] = %q; want %q", i, got, want)
+		}
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	var tr0, tr1 Track
+	tr0.Add(0, meta.Tempo(0), meta.Undefined{Typ: 0x60})
+	tr1.Add(0, meta.Marker("verse"))
+
+	// a SMF1 with a problem of each category but structural errors
+	s1 := New(smf.SMF1, smf.MetricTicks(480))
+	s1.AddTrack(&tr0)
+	s1.AddTrack(&tr1)
+
+	// a SMF0 with a structural error only
+	s0 := New(smf.SMF0, smf.MetricTicks(480))
+	s0.tracks = []*Track{{}, {}}
+
+	tests := []struct {
+		s        *SMF
+		category smf.Category
+		rule     string
+	}{
+		{s0, smf.StructuralErrors, RuleTrackCount},
+		{s1, smf.PlacementViolations, RuleMetaPlacement},
+		{s1, smf.RangeViolations, RuleValueRange},
+		{s1, smf.UnknownData, RuleUndefinedMessage},
+	}
+
+	for _, test := range tests {
+		for _, a := range []smf.Action{smf.Fail, smf.Warn, smf.Ignore} {
+			// all other categories are ignored
+			p := smf.Policy{StructuralErrors: smf.Ignore, PlacementViolations: smf.Ignore, RangeViolations: smf.Ignore, UnknownData: smf.Ignore}
+
+			switch test.category {
+			case smf.StructuralErrors:
+				p.StructuralErrors = a
+			case smf.PlacementViolations:
+				p.PlacementViolations = a
+			case smf.RangeViolations:
+				p.RangeViolations = a
+			case smf.UnknownData:
+				p.UnknownData = a
+			}
+
+			errs, warnings := ValidatePolicy(test.s, p)
+
+			var got []Problem
+
+			switch a {
+			case smf.Fail:
+				got = errs
+				if len(warnings) != 0 {
+					t.Errorf("[%s %s] warnings = %v; want none", a, test.category, warnings)
+				}
+			case smf.Warn:
+				got = warnings
+				if len(errs) != 0 {
+					t.Errorf("[%s %s] errs = %v; want none", a, test.category, errs)
+				}
+			case smf.Ignore:
+				if len(errs)+len(warnings) != 0 {
+					t.Errorf("[%s %s] problems = %v, %v; want none", a, test.category, errs, warnings)
+				}
+				continue
+			}
+
+			if len(got) != 1 || got[0].Rule != test.rule || got[0].Category != test.category {
+				t.Errorf("[%s %s] problems = %v; want one of rule %s", a, test.category, got, test.rule)
+			}
+		}
+	}
+}
+
+func TestValidateTrackEnds(t *testing.T) {
+	var conductor, melody, bass, pad Track
+	conductor.Add(0, meta.BPM(120))
+	melody.Add(0, channel.Channel0.NoteOn(60, 100))
+	melody.Add(7680, channel.Channel0.NoteOff(60))
+	// an automation tail after the last note
+	bass.Add(0, channel.Channel1.NoteOn(40, 100))
+	bass.Add(5760, channel.Channel1.NoteOff(40))
+	bass.Add(7200, channel.Channel1.ControlChange(7, 0))
+	pad.Add(0, channel.Channel2.NoteOn(48, 100))
+	pad.Add(1920, channel.Channel2.NoteOff(48))
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&melody)
+	s.AddTrack(&bass)
+	s.AddTrack(&pad)
+
+	// the conductor track without channel messages and the bass track within a bar are not reported
+	expected := []string{
+		`track 3 at tick 1920: track ends 5760 ticks before the longest track at tick 7680 (track-ends)`,
+	}
+
+	problems := Validate(s)
+
+	if got, want := len(problems), len(expected); got != want {
+		t.Fatalf("len(Validate()) = %v; want %v: %v", got, want, problems)
+	}
+
+	for i, p := range problems {
+		if got, want := p.String(), expected[i]; got != want {
+			t.Errorf("Validate()[%v] = %q; want %q", i, got, want)
+		}
+
+		if p.Category != smf.Suspicious || p.Message != meta.EndOfTrack || p.Event != 2 {
+			t.Errorf("Validate()[%v] = %#v; want suspicious end of track", i, p)
+		}
+	}
+
+	// the early end is reported as a warning under every shipped policy
+	for _, policy := range []smf.Policy{smf.Strict, smf.Default, smf.Permissive} {
+		errs, warnings := ValidatePolicy(s, policy)
+
+		if len(errs) != 0 || len(warnings) != len(expected) {
+			t.Errorf("ValidatePolicy(%+v) = %v, %v; want %v warnings", policy, errs, warnings, len(expected))
+		}
+	}
+
+	res, err := SetTrackLength(s, 3, 7680)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if problems := Validate(res); len(problems) != 0 {
+		t.Errorf("Validate() after SetTrackLength = %v; want none", problems)
+	}
+}
+
+func TestValidateBarPositions(t *testing.T) {
+	var conductor, melody Track
+	conductor.Add(0, timeSig(4, 4))
+	conductor.Add(3840, timeSig(3, 4))
+	conductor.Add(6720, timeSig(7, 8))
+	conductor.Add(9960, meta.Tempo(0))
+	melody.Add(5280, meta.Key{Key: 0, Num: 9, IsMajor: true})
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&melody)
+
+	tests := []struct {
+		options  []ValidateOption
+		expected []string
+	}{
+		{
+			nil,
+			[]string{
+				`track 1 at tick 5280: meta.Key: C maj. is only allowed in the first track (meta-placement)`,
+				`track 0 at tick 9960: tempo of 0 microseconds per quarter note (value-range)`,
+				`track 1 at tick 5280: key signature with 9 accidentals (value-range)`,
+			},
+		},
+		{
+			[]ValidateOption{BarPositions()},
+			[]string{
+				`track 1 at bar 4 beat 1.00: meta.Key: C maj. is only allowed in the first track (meta-placement)`,
+				`track 0 at bar 6 beat 7.50: tempo of 0 microseconds per quarter note (value-range)`,
+				`track 1 at bar 4 beat 1.00: key signature with 9 accidentals (value-range)`,
+			},
+		},
+		{
+			[]ValidateOption{BarPositions(FirstBar(0))},
+			[]string{
+				`track 1 at bar 3 beat 1.00: meta.Key: C maj. is only allowed in the first track (meta-placement)`,
+				`track 0 at bar 5 beat 7.50: tempo of 0 microseconds per quarter note (value-range)`,
+				`track 1 at bar 3 beat 1.00: key signature with 9 accidentals (value-range)`,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		problems := Validate(s, test.options...)
+
+		if got, want := len(problems), len(test.expected); got != want {
+			t.Fatalf("[%v] len(Validate()) = %v; want %v: %v", i, got, want, problems)
+		}
+
+		for j, p := range problems {
+			if got, want := p.String(), test.expected[j]; got != want {
+				t.Errorf("[%v] Validate()[%v] = %q; want %q", i, j, got, want)
+			}
+		}
+	}
+}
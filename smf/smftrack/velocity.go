@@ -0,0 +1,367 @@
+package smftrack
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// VelocityStats are statistics of the velocities of notes
+type VelocityStats struct {
+	// Notes is the number of notes
+	Notes int
+
+	Min  uint8
+	Max  uint8
+	Mean float64
+}
+
+// add adds the given velocity to the statistics
+func (st *VelocityStats) add(velocity uint8) {
+	if st.Notes == 0 || velocity < st.Min {
+		st.Min = velocity
+	}
+
+	if velocity > st.Max {
+		st.Max = velocity
+	}
+
+	st.Mean += (float64(velocity) - st.Mean) / float64(st.Notes+1)
+	st.Notes++
+}
+
+type velocityConfig struct {
+	expand   bool
+	knee     float64
+	tracks   map[int]bool
+	channels map[uint8]bool
+}
+
+// VelocityOption is an option for CompressVelocity and ScaleVelocity
+type VelocityOption func(*velocityConfig)
+
+// VelocityExpand lets CompressVelocity work as a (downward) expander: the distance of the velocities below the
+// threshold to the threshold is multiplied by the ratio, while the velocities above the threshold are kept.
+func VelocityExpand() VelocityOption {
+	return func(c *velocityConfig) {
+		c.expand = true
+	}
+}
+
+// VelocitySoftKnee sets the width of the soft knee of CompressVelocity: within the given width around the threshold
+// the ratio changes smoothly (quadratic interpolation). Default is 0 (hard knee).
+func VelocitySoftKnee(width float64) VelocityOption {
+	return func(c *velocityConfig) {
+		c.knee = width
+	}
+}
+
+// VelocityTracks restricts the changes to the notes of the given tracks. Default is all tracks.
+func VelocityTracks(tracks ...int) VelocityOption {
+	return func(c *velocityConfig) {
+		c.tracks = map[int]bool{}
+		for _, tr := range tracks {
+			c.tracks[tr] = true
+		}
+	}
+}
+
+// VelocityChannels restricts the changes to the notes of the given channels. Default is all channels.
+func VelocityChannels(channels ...uint8) VelocityOption {
+	return func(c *velocityConfig) {
+		c.channels = map[uint8]bool{}
+		for _, ch := range channels {
+			c.channels[ch] = true
+		}
+	}
+}
+
+// CompressVelocity returns a copy of the given SMF where the velocities of the notes are compressed like the level
+// of an audio signal: the distance of the velocities above the threshold to the threshold is divided by the ratio.
+// Then makeup is added to all velocities. See VelocityExpand for the expander mode and VelocitySoftKnee for a soft knee.
+// A ratio below 1 is treated as 1. The resulting velocities are rounded and kept between 1 and 127.
+// The given SMF is not modified.
+//
+// before and after are the statistics of the velocities of the changed notes (see VelocityTracks and VelocityChannels).
+func CompressVelocity(s *SMF, threshold, ratio float64, makeup int8, options ...VelocityOption) (res *SMF, before, after VelocityStats) {
+	var c velocityConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	if ratio < 1 {
+		ratio = 1
+	}
+
+	return c.apply(s, func(v float64) float64 {
+		return c.dynamics(v, threshold, ratio) + float64(makeup)
+	})
+}
+
+// dynamics returns the compressed or expanded velocity (without makeup)
+func (c velocityConfig) dynamics(v, threshold, ratio float64) float64 {
+	// slope is the slope of the curve beyond the threshold (above for compression, below for expansion)
+	slope := 1 / ratio
+
+	if c.expand {
+		slope = ratio
+	}
+
+	half := c.knee / 2
+
+	switch {
+	case c.knee > 0 && math.Abs(v-threshold) <= half:
+		if c.expand {
+			d := v - threshold - half
+			return v + (1-slope)*d*d/(2*c.knee)
+		}
+		d := v - threshold + half
+		return v + (slope-1)*d*d/(2*c.knee)
+	case c.expand && v < threshold, !c.expand && v > threshold:
+		return threshold + (v-threshold)*slope
+	default:
+		return v
+	}
+}
+
+// ScaleVelocity returns a copy of the given SMF where the velocities of the notes are multiplied by factor and
+// offset is added. The resulting velocities are rounded and kept between 1 and 127. The given SMF is not modified.
+//
+// before and after are the statistics of the velocities of the changed notes (see VelocityTracks and VelocityChannels).
+func ScaleVelocity(s *SMF, factor float64, offset int8, options ...VelocityOption) (res *SMF, before, after VelocityStats) {
+	var c velocityConfig
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	return c.apply(s, func(v float64) float64 {
+		return v*factor + float64(offset)
+	})
+}
+
+// apply returns a copy of the given SMF where the given function is applied to the velocities of the selected notes
+func (c velocityConfig) apply(s *SMF, fn func(float64) float64) (res *SMF, before, after VelocityStats) {
+	where := func(n Note) bool {
+		return (c.tracks == nil || c.tracks[n.Track]) && (c.channels == nil || c.channels[n.Channel])
+	}
+
+	// can't fail, since the velocities are clamped
+	res, _ = EditNotes(s, where, func(n Note) Note {
+		before.add(n.Velocity)
+		n.Velocity = clampVelocity(fn(float64(n.Velocity)))
+		after.add(n.Velocity)
+		return n
+	})
+
+	return res, before, after
+}
+
+type rampConfig struct {
+	drums
+	expression bool
+}
+
+// RampOption is an option for VelocityRamp (see also DetectDrums)
+type RampOption interface {
+	applyRamp(*rampConfig)
+}
+
+type rampOption func(*rampConfig)
+
+func (o rampOption) applyRamp(c *rampConfig) {
+	o(c)
+}
+
+// RampDrumChannels sets the channels that are skipped. Default is channel 9 (channel 10 in GM).
+func RampDrumChannels(channels ...uint8) RampOption {
+	return rampOption(func(c *rampConfig) {
+		c.drumChannels = channelSet(channels)
+		c.detectDrums = nil
+	})
+}
+
+// RampExpression lets VelocityRamp also change the sustained notes: on each channel with a note that starts
+// before fromTick and still sounds at fromTick, the expression (controller 11) is ramped instead of the velocities.
+// See VelocityRamp for the details.
+func RampExpression() RampOption {
+	return rampOption(func(c *rampConfig) {
+		c.expression = true
+	})
+}
+
+// VelocityRamp returns a copy of the given SMF with a gradual change of the velocities (crescendo or decrescendo):
+// the velocities of the notes that start from fromTick to toTick (inclusive) are multiplied by a scale that changes
+// from fromScale at fromTick to toScale at toTick, following the curve. LinearBPM changes the scale linearly and
+// Exponential by the same factor per tick, while LinearMicroseconds changes the reciprocal of the scale linearly.
+// The scale of a note is the scale at its note on message. The resulting velocities are rounded and kept between
+// 1 and 127. Notes on drum channels are not changed (see RampDrumChannels). The given SMF is not modified.
+//
+// With RampExpression, the expression (controller 11) of each channel with a note that sounds across fromTick is
+// ramped from fromTick to toTick, since the velocity of these notes can't be changed anymore. The ramp scales the
+// expression at fromTick (127 if there is none) and is written to the track of the first of these notes, with a
+// message for each tick where the value changes. Existing expression messages of the channel from fromTick to toTick
+// (exclusive) are removed and the expression they leave is restored at toTick. The velocities of the notes of these
+// channels that start from fromTick to toTick (exclusive) are not changed, since the expression covers them.
+// The expression messages have no tag.
+//
+// An error is returned for invalid arguments, e.g. negative scales or a scale of 0 for a curve other than LinearBPM.
+func VelocityRamp(s *SMF, fromTick, toTick uint64, fromScale, toScale float64, curve Curve, options ...RampOption) (*SMF, error) {
+	c := rampConfig{
+		drums: drums{drumChannels: map[uint8]bool{9: true}},
+	}
+
+	for _, opt := range options {
+		opt.applyRamp(&c)
+	}
+
+	c.resolveDrums(s)
+
+	if toTick <= fromTick {
+		return nil, fmt.Errorf("invalid velocity ramp from tick %v to tick %v", fromTick, toTick)
+	}
+
+	if fromScale < 0 || toScale < 0 || (curve != LinearBPM && (fromScale == 0 || toScale == 0)) {
+		return nil, fmt.Errorf("invalid velocity ramp from scale %v to scale %v", fromScale, toScale)
+	}
+
+	scale := func(tick uint64) float64 {
+		return curve.value(fromScale, toScale, float64(tick-fromTick)/float64(toTick-fromTick))
+	}
+
+	res := s.clone()
+
+	// the channels with an expression ramp and the number of the track it is written to
+	ramped := map[uint8]int{}
+
+	if c.expression {
+		for n := range res.AllNotes() {
+			if _, has := ramped[n.Channel]; has || c.drumChannels[n.Channel] {
+				continue
+			}
+
+			if n.AbsTicks < fromTick && n.End() > fromTick {
+				ramped[n.Channel] = n.Track
+			}
+		}
+	}
+
+	for _, tr := range res.tracks {
+		notes := tr.Notes()
+
+		for i := range notes {
+			n := &notes[i]
+
+			if n.AbsTicks < fromTick || n.AbsTicks > toTick || c.drumChannels[n.Channel] {
+				continue
+			}
+
+			if _, has := ramped[n.Channel]; has && n.AbsTicks < toTick {
+				continue
+			}
+
+			n.Velocity = clampVelocity(float64(n.Velocity) * scale(n.AbsTicks))
+		}
+
+		// can't fail, since the notes are from the track
+		tr.SetNotes(notes)
+	}
+
+	for ch, no := range ramped {
+		res.rampExpression(ch, no, fromTick, toTick, scale)
+	}
+
+	return res, nil
+}
+
+// rampExpression writes an expression ramp of the given channel to the track no
+func (s *SMF) rampExpression(ch uint8, no int, from, to uint64, scale func(uint64) float64) {
+	var (
+		// the expression at from and the tick of its message
+		start     uint8 = 127
+		startTick uint64
+		// the expression that is left at to, if there are expression messages within the ramp
+		end    uint8
+		hasEnd bool
+		ramp   []Event
+	)
+
+	for _, tr := range s.tracks {
+		var kept []Event
+
+		for _, ev := range tr.evs() {
+			cc, ok := ev.Message.(channel.ControlChange)
+
+			switch {
+			case !ok || cc.Channel() != ch || cc.Controller() != 11 || ev.AbsTicks >= to:
+				kept = append(kept, ev)
+			case ev.AbsTicks < from:
+				if ev.AbsTicks >= startTick {
+					start, startTick = cc.Value(), ev.AbsTicks
+				}
+				kept = append(kept, ev)
+			default:
+				// removed, but the expression at from is the start of the ramp
+				if ev.AbsTicks == from {
+					start, startTick = cc.Value(), ev.AbsTicks
+				}
+				end, hasEnd = cc.Value(), true
+			}
+		}
+
+		if len(kept) != len(tr.evs()) {
+			tr.SetEvents(kept)
+		}
+	}
+
+	if !hasEnd {
+		end = start
+	}
+
+	value := func(tick uint64) uint8 {
+		v := int(math.Round(float64(start) * scale(tick)))
+		return uint8(min(max(v, 0), 127))
+	}
+
+	for tick := from; tick < to; {
+		v := value(tick)
+		ramp = append(ramp, Event{AbsTicks: tick, Message: channel.Channel(ch).ControlChange(11, v)})
+
+		// the curves are monotonic, so the next tick where the value changes can be searched
+		lo, hi := tick+1, to
+
+		for lo < hi {
+			mid := lo + (hi-lo)/2
+
+			if value(mid) != v {
+				hi = mid
+			} else {
+				lo = mid + 1
+			}
+		}
+
+		tick = lo
+	}
+
+	ramp = append(ramp, Event{AbsTicks: to, Message: channel.Channel(ch).ControlChange(11, end)})
+
+	// the expression messages come before the other events at the same tick, so that the notes sound with them
+	tr := s.tracks[no]
+	tr.SetEvents(append(ramp, tr.evs()...))
+}
+
+// clampVelocity returns the given velocity rounded and kept between 1 and 127
+func clampVelocity(v float64) uint8 {
+	v = math.Round(v)
+
+	switch {
+	case v < 1:
+		return 1
+	case v > 127:
+		return 127
+	default:
+		return uint8(v)
+	}
+}
@@ -0,0 +1,317 @@
+package smftrack
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+)
+
+// velocitySMF returns a SMF with a note for each given velocity on channel 0 of the first track
+// and a note of velocity 80 on channel 1 of the second track
+func velocitySMF(velocities ...uint8) *SMF {
+	var tr0, tr1 Track
+
+	for i, v := range velocities {
+		tr0.Add(uint64(i*480), channel.Channel0.NoteOn(60, v))
+		tr0.Add(uint64(i*480+240), channel.Channel0.NoteOff(60))
+	}
+
+	tr1.Add(0, channel.Channel1.NoteOn(60, 80))
+	tr1.Add(240, channel.Channel1.NoteOff(60))
+
+	s := New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&tr0)
+	s.AddTrack(&tr1)
+	return s
+}
+
+// velocities returns the velocities of the notes of the given track
+func velocities(tr *Track) []uint8 {
+	var res []uint8
+
+	for _, n := range tr.Notes() {
+		res = append(res, n.Velocity)
+	}
+
+	return res
+}
+
+var velocityInput = []uint8{1, 32, 48, 63, 64, 65, 100, 127}
+
+func TestCompressVelocity(t *testing.T) {
+	tests := []struct {
+		threshold float64
+		ratio     float64
+		makeup    int8
+		options   []VelocityOption
+		expected  []uint8
+	}{
+		{64, 2, 0, nil, []uint8{1, 32, 48, 63, 64, 65, 82, 96}},
+		{64, 2, 10, nil, []uint8{11, 42, 58, 73, 74, 75, 92, 106}},
+		{64, 4, 0, nil, []uint8{1, 32, 48, 63, 64, 64, 73, 80}},
+		{64, 0.5, 0, nil, velocityInput},
+		{0, 127, -10, nil, []uint8{1, 1, 1, 1, 1, 1, 1, 1}},
+		{64, 2, 0, []VelocityOption{VelocitySoftKnee(20)}, []uint8{1, 32, 48, 62, 63, 63, 82, 96}},
+		{64, 2, 0, []VelocityOption{VelocityExpand()}, []uint8{1, 1, 32, 62, 64, 65, 100, 127}},
+		{64, 2, 0, []VelocityOption{VelocityExpand(), VelocitySoftKnee(20)}, []uint8{1, 1, 32, 60, 62, 63, 100, 127}},
+		{64, 2, 0, []VelocityOption{VelocityChannels(1)}, velocityInput},
+	}
+
+	for i, test := range tests {
+		s := velocitySMF(velocityInput...)
+		res, _, _ := CompressVelocity(s, test.threshold, test.ratio, test.makeup, test.options...)
+
+		if got := velocities(res.Track(0)); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("[%v] CompressVelocity() = %v; want %v", i, got, test.expected)
+		}
+
+		if got := velocities(s.Track(0)); !reflect.DeepEqual(got, velocityInput) {
+			t.Errorf("[%v] given SMF has been modified: %v", i, got)
+		}
+	}
+}
+
+func TestScaleVelocity(t *testing.T) {
+	tests := []struct {
+		factor   float64
+		offset   int8
+		expected []uint8
+	}{
+		{1, 0, velocityInput},
+		{0.5, 10, []uint8{11, 26, 34, 42, 42, 43, 60, 74}},
+		{2, -1, []uint8{1, 63, 95, 125, 127, 127, 127, 127}},
+		{0, 0, []uint8{1, 1, 1, 1, 1, 1, 1, 1}},
+	}
+
+	for i, test := range tests {
+		res, _, _ := ScaleVelocity(velocitySMF(velocityInput...), test.factor, test.offset)
+
+		if got := velocities(res.Track(0)); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("[%v] ScaleVelocity() = %v; want %v", i, got, test.expected)
+		}
+	}
+}
+
+func TestVelocityStats(t *testing.T) {
+	s := velocitySMF(velocityInput...)
+
+	// all notes
+	_, before, after := ScaleVelocity(s, 0.5, 0)
+
+	if want := (VelocityStats{Notes: 9, Min: 1, Max: 127, Mean: 580.0 / 9}); before != want {
+		t.Errorf("before = %+v; want %+v", before, want)
+	}
+
+	// 1, 16, 24, 32 (31.5), 32, 33 (32.5), 50, 64 (63.5), 40
+	want := VelocityStats{Notes: 9, Min: 1, Max: 64, Mean: 292.0 / 9}
+
+	if math.Abs(after.Mean-want.Mean) < 1e-9 {
+		after.Mean = want.Mean
+	}
+
+	if after != want {
+		t.Errorf("after = %+v; want %+v", after, want)
+	}
+
+	// selection of the second track
+	res, before, after := CompressVelocity(s, 64, 2, 0, VelocityTracks(1))
+
+	if want := (VelocityStats{Notes: 1, Min: 80, Max: 80, Mean: 80}); before != want {
+		t.Errorf("before = %+v; want %+v", before, want)
+	}
+
+	if want := (VelocityStats{Notes: 1, Min: 72, Max: 72, Mean: 72}); after != want {
+		t.Errorf("after = %+v; want %+v", after, want)
+	}
+
+	if got := velocities(res.Track(0)); !reflect.DeepEqual(got, velocityInput) {
+		t.Errorf("track 0 = %v; want %v", got, velocityInput)
+	}
+
+	// no notes selected
+	if _, before, after := ScaleVelocity(s, 2, 0, VelocityChannels(5)); before != (VelocityStats{}) || after != (VelocityStats{}) {
+		t.Errorf("stats = %+v, %+v; want zero values", before, after)
+	}
+}
+
+func TestVelocityRamp(t *testing.T) {
+	tests := []struct {
+		curve    Curve
+		expected []uint8
+	}{
+		{LinearBPM, []uint8{50, 63, 75, 88, 100}},
+		{Exponential, []uint8{50, 59, 71, 84, 100}},
+		{LinearMicroseconds, []uint8{50, 57, 67, 80, 100}},
+	}
+
+	for _, test := range tests {
+		s := velocitySMF(100, 100, 100, 100, 100, 100)
+
+		var drums Track
+		drums.Add(960, channel.Channel9.NoteOn(36, 100))
+		drums.Add(1080, channel.Channel9.NoteOff(36))
+		s.AddTrack(&drums)
+
+		res, err := VelocityRamp(s, 0, 1920, 0.5, 1, test.curve)
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		// the note at 2400 is after the ramp
+		if got, want := velocities(res.Track(0)), append(test.expected, 100); !reflect.DeepEqual(got, want) {
+			t.Errorf("[%v] velocities = %v; want %v", test.curve, got, want)
+		}
+
+		if got, want := velocities(res.Track(1)), []uint8{40}; !reflect.DeepEqual(got, want) {
+			t.Errorf("[%v] velocities of track 1 = %v; want %v", test.curve, got, want)
+		}
+
+		if got, want := velocities(res.Track(2)), []uint8{100}; !reflect.DeepEqual(got, want) {
+			t.Errorf("[%v] velocities of the drums = %v; want %v", test.curve, got, want)
+		}
+
+		if got, want := velocities(s.Track(0)), []uint8{100, 100, 100, 100, 100, 100}; !reflect.DeepEqual(got, want) {
+			t.Errorf("[%v] VelocityRamp modified the given SMF: %v", test.curve, got)
+		}
+	}
+
+	// drums are changed, if asked for, the velocities are kept between 1 and 127
+	s := velocitySMF(100, 100)
+	res, err := VelocityRamp(s, 0, 480, 0, 2, LinearBPM, RampDrumChannels())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if got, want := velocities(res.Track(0)), []uint8{1, 127}; !reflect.DeepEqual(got, want) {
+		t.Errorf("velocities = %v; want %v", got, want)
+	}
+
+	invalid := []struct {
+		from, to  uint64
+		fromScale float64
+		toScale   float64
+		curve     Curve
+	}{
+		{480, 480, 1, 1, LinearBPM},
+		{0, 480, -1, 1, LinearBPM},
+		{0, 480, 0, 1, Exponential},
+		{0, 480, 1, 0, LinearMicroseconds},
+	}
+
+	for _, test := range invalid {
+		if _, err := VelocityRamp(s, test.from, test.to, test.fromScale, test.toScale, test.curve); err == nil {
+			t.Errorf("VelocityRamp(%v, %v, %v, %v, %v) must return an error", test.from, test.to, test.fromScale, test.toScale, test.curve)
+		}
+	}
+}
+
+func TestVelocityRampExpression(t *testing.T) {
+	var tr Track
+	ch := channel.Channel2
+	tr.Add(0, ch.ControlChange(11, 100), ch.NoteOn(48, 100))
+	tr.Add(600, ch.NoteOn(60, 100))
+	tr.Add(700, ch.ControlChange(11, 90), ch.NoteOff(60))
+	tr.Add(960, ch.NoteOn(62, 100))
+	tr.Add(1200, ch.NoteOff(62))
+	tr.Add(1920, ch.NoteOff(48))
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	res, err := VelocityRamp(s, 480, 960, 1, 0.5, LinearBPM, RampExpression())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the note at 600 sounds with the expression, the note at 960 is scaled
+	if got, want := velocities(res.Track(0)), []uint8{100, 100, 50}; !reflect.DeepEqual(got, want) {
+		t.Errorf("velocities = %v; want %v", got, want)
+	}
+
+	var expression []Event
+
+	for _, ev := range res.Track(0).Events() {
+		if cc, ok := ev.Message.(channel.ControlChange); ok && cc.Controller() == 11 {
+			expression = append(expression, ev)
+		}
+	}
+
+	// 100 at 0, 100 down to 50 from 480 on and 90 at 960
+	if got, want := len(expression), 53; got != want {
+		t.Fatalf("expression messages = %v; want %v", got, want)
+	}
+
+	expected := []Event{
+		{AbsTicks: 0, Message: ch.ControlChange(11, 100)},
+		{AbsTicks: 480, Message: ch.ControlChange(11, 100)},
+		{AbsTicks: 485, Message: ch.ControlChange(11, 99)},
+		{AbsTicks: 956, Message: ch.ControlChange(11, 50)},
+		{AbsTicks: 960, Message: ch.ControlChange(11, 90)},
+	}
+
+	got := []Event{expression[0], expression[1], expression[2], expression[51], expression[52]}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expression = %v; want %v", got, expected)
+	}
+
+	// the restored expression comes before the note at 960
+	for _, ev := range res.Track(0).Events() {
+		if ev.AbsTicks == 960 {
+			if ev.Message != ch.ControlChange(11, 90) {
+				t.Errorf("first event at 960 = %v; want the expression", ev.Message)
+			}
+			break
+		}
+	}
+}
+
+func TestVelocityRampExpressionStart(t *testing.T) {
+	var tr Track
+	ch := channel.Channel2
+	tr.Add(0, ch.NoteOn(48, 100))
+	// the expression at the start of the ramp is scaled
+	tr.Add(480, ch.ControlChange(11, 80))
+	tr.Add(1<<40, ch.NoteOff(48))
+
+	s := New(smf.SMF0, smf.MetricTicks(480))
+	s.AddTrack(&tr)
+
+	// a huge range is ramped by the changes of the value, not tick by tick
+	res, err := VelocityRamp(s, 480, 1<<40, 1, 0.5, LinearBPM, RampExpression())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var expression []Event
+
+	for _, ev := range res.Track(0).Events() {
+		if cc, ok := ev.Message.(channel.ControlChange); ok && cc.Controller() == 11 {
+			expression = append(expression, ev)
+		}
+	}
+
+	// 80 down to 40 and 80 restored at the end
+	if got, want := len(expression), 42; got != want {
+		t.Fatalf("expression messages = %v; want %v", got, want)
+	}
+
+	if got, want := expression[0], (Event{AbsTicks: 480, Message: ch.ControlChange(11, 80)}); got != want {
+		t.Errorf("first expression = %v; want %v", got, want)
+	}
+
+	if got, want := expression[40].Message, ch.ControlChange(11, 40); got != want {
+		t.Errorf("last ramped expression = %v; want %v", got, want)
+	}
+
+	if got, want := expression[41], (Event{AbsTicks: 1 << 40, Message: ch.ControlChange(11, 80)}); got != want {
+		t.Errorf("restored expression = %v; want %v", got, want)
+	}
+}
@@ -50,10 +50,10 @@ func Example() {
 	}
 
 	// Output:
-	// 0 channel.Pitchbend channel 2 value 5000 absValue 13192
-	// 2 channel.NoteOn channel 2 key 65 velocity 90
+	// 0 channel.Pitchbend channel 3 value 5000 absValue 13192
+	// 2 channel.NoteOn channel 3 key 65 velocity 90
 	// NoteOn at channel 2: key 65 velocity: 90
-	// 4 channel.NoteOff channel 2 key 65
+	// 4 channel.NoteOff channel 3 key 65
 	// NoteOff at channel 2: key 65
 	// 0 meta.EndOfTrack
 
@@ -1,6 +1,8 @@
 package smfwriter
 
 import (
+	"bytes"
+
 	"github.com/gomidi/midi/smf"
 )
 
@@ -24,6 +26,11 @@ type Option func(*writer)
 
 // NoRunningStatus forces the writer to always write the status byte.
 // Without passing this option, running status will be used if possible (saving some bytes).
+//
+// Running status is only used for messages with the same status byte as the message before. Therefore
+// channel.NoteOffVelocity messages are always written as "real" noteoff messages (type 8) with their release
+// velocity, with or without this option. They are never turned into noteon messages with velocity 0;
+// write channel.NoteOff messages instead, if that is wanted (dropping the release velocity).
 func NoRunningStatus() Option {
 	return func(w *writer) {
 		w.noRunningStatus = true
@@ -63,3 +70,94 @@ func Format(f smf.Format) Option {
 		w.header.Format = f
 	}
 }
+
+// StrictMetaPlacement lets the writer reject Time Signature, Key Signature, SMPTE Offset, Marker and Cue Point
+// messages that are written to any but the first track of a format 1 SMF, since the SMF specification
+// only allows them in the first track. The error is returned by Write (see smftrack.MoveFirstTrackMetas for a way
+// to relocate them).
+// Without passing this option, the messages are written as they come.
+func StrictMetaPlacement() Option {
+	return func(w *writer) {
+		w.strictMetaPlacement = true
+	}
+}
+
+// SplitDeltas lets the writer split delta times that exceed MaxDelta (about 268 million ticks, which can't be
+// encoded) by writing empty text messages (meta.Text("")) every MaxDelta ticks, that carry the time. Readers will
+// see these additional messages.
+// Without passing this option, Write returns an error that wraps ErrDeltaOverflow for such delta times.
+func SplitDeltas() Option {
+	return func(w *writer) {
+		w.splitDeltas = true
+	}
+}
+
+// SplitsDeltas returns true, if wr is a writer of this package with the SplitDeltas option.
+func SplitsDeltas(wr smf.Writer) bool {
+	w, ok := wr.(*writer)
+	return ok && w.splitDeltas
+}
+
+// Order lets the writer reorder the messages at the same tick (i.e. the messages after a message with a delta time
+// of 0) according to the given policy, e.g. smf.Safe. The messages of a tick are buffered until the next message
+// with a delta time or the end of the track. Without passing this option, or with smf.PreserveInput,
+// the messages are written in the order in which they come.
+func Order(p smf.OrderPolicy) Option {
+	return func(w *writer) {
+		w.order = p
+	}
+}
+
+// Unsafe lets the writer write invalid messages, e.g. for writing corrupt files for tests.
+// Without passing this option, Write validates each message (see midi.Validate) and returns the error of an
+// invalid message, which blocks the writer like any other error.
+func Unsafe() Option {
+	return func(w *writer) {
+		w.unsafe = true
+	}
+}
+
+// Streaming lets the writer write the messages of a track to the output while they come, instead of keeping the
+// track in memory until its end, e.g. for writing huge tracks. The track chunk is written with a length of 0 and
+// its length is patched, when the track ends. Therefore the output must be an io.WriteSeeker (e.g. an *os.File),
+// otherwise the first Write returns an error. The messages are written in blocks of 64 KB.
+func Streaming() Option {
+	return func(w *writer) {
+		w.streaming = true
+	}
+}
+
+// Checkpoints lets the writer write a checkpoint (see Checkpoint) after every given number of messages, so that a
+// crash of the recording process loses at most the messages since the last checkpoint. It implies the Streaming
+// option. A number of 0 only enables the calls of Checkpoint, e.g. for checkpoints in regular time intervals.
+//
+// After a checkpoint the output is a complete SMF up to the current track: the track chunk ends with a provisional
+// end of track message and its length includes it. Tracks after the current one are missing, therefore a SMF1 or
+// SMF2 with more tracks can only be read with a policy that does not fail on structural errors.
+// Between the checkpoints the messages are written over the provisional end of track, while the length of the
+// chunk is that of the last checkpoint, so the end of the data is that of a truncated SMF. smftrack.Recover reads
+// the messages of both states.
+func Checkpoints(messages int) Option {
+	return func(w *writer) {
+		w.streaming = true
+		w.checkpointEvery = messages
+	}
+}
+
+// signature is the data of the sequencer specific meta message that is written by the Signature option:
+// the manufacturer ID 7D (non-commercial) followed by "gomidi"
+var signature = []byte{0x7D, 'g', 'o', 'm', 'i', 'd', 'i'}
+
+// Signature lets the writer write a sequencer specific meta message with the signature of this package at the
+// start of the first track, so that the SMF can be recognized as written by it (see IsSignature).
+func Signature() Option {
+	return func(w *writer) {
+		w.signature = true
+	}
+}
+
+// IsSignature returns true, if data is the data of the sequencer specific meta message that is written by the
+// Signature option.
+func IsSignature(data []byte) bool {
+	return bytes.Equal(data, signature)
+}
@@ -2,7 +2,11 @@ package smfwriter
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -23,17 +27,17 @@ SMF0
 TimeFormat: 96 QuarterNoteTicks
 Track 0@0 meta.TimeSignature 4/4
 Track 0@0 meta.Tempo BPM: 120.00
-Track 0@0 channel.ProgramChange channel 0 program 5
-Track 0@0 channel.ProgramChange channel 1 program 46
-Track 0@0 channel.ProgramChange channel 2 program 70
-Track 0@0 channel.NoteOn channel 2 pitch 48 vel 96
-Track 0@0 channel.NoteOn channel 2 pitch 60 vel 96
-Track 0@96 channel.NoteOn channel 1 pitch 67 vel 64
-Track 0@96 channel.NoteOn channel 0 pitch 76 vel 32
-Track 0@192 channel.NoteOff channel 2 pitch 48
-Track 0@0 channel.NoteOff channel 2 pitch 60
-Track 0@0 channel.NoteOff channel 1 pitch 67
-Track 0@0 channel.NoteOff channel 0 pitch 76
+Track 0@0 channel.ProgramChange channel 1 program 5
+Track 0@0 channel.ProgramChange channel 2 program 46
+Track 0@0 channel.ProgramChange channel 3 program 70
+Track 0@0 channel.NoteOn channel 3 pitch 48 vel 96
+Track 0@0 channel.NoteOn channel 3 pitch 60 vel 96
+Track 0@96 channel.NoteOn channel 2 pitch 67 vel 64
+Track 0@96 channel.NoteOn channel 1 pitch 76 vel 32
+Track 0@192 channel.NoteOff channel 3 pitch 48
+Track 0@0 channel.NoteOff channel 3 pitch 60
+Track 0@0 channel.NoteOff channel 2 pitch 67
+Track 0@0 channel.NoteOff channel 1 pitch 76
 Track 0@0 meta.endOfTrack
 */
 
@@ -87,19 +91,19 @@ TimeFormat: 96 QuarterNoteTicks
 Track 0@0 meta.TimeSignature 4/4
 Track 0@0 meta.Tempo BPM: 120.00
 Track 0@384 meta.endOfTrack
-Track 1@0 channel.ProgramChange channel 0 program 5
-Track 1@192 channel.NoteOn channel 0 pitch 76 vel 32
-Track 1@192 channel.NoteOff channel 0 pitch 76
+Track 1@0 channel.ProgramChange channel 1 program 5
+Track 1@192 channel.NoteOn channel 1 pitch 76 vel 32
+Track 1@192 channel.NoteOff channel 1 pitch 76
 Track 1@0 meta.endOfTrack
-Track 2@0 channel.ProgramChange channel 1 program 46
-Track 2@96 channel.NoteOn channel 1 pitch 67 vel 64
-Track 2@288 channel.NoteOff channel 1 pitch 67
+Track 2@0 channel.ProgramChange channel 2 program 46
+Track 2@96 channel.NoteOn channel 2 pitch 67 vel 64
+Track 2@288 channel.NoteOff channel 2 pitch 67
 Track 2@0 meta.endOfTrack
-Track 3@0 channel.ProgramChange channel 2 program 70
-Track 3@0 channel.NoteOn channel 2 pitch 48 vel 96
-Track 3@0 channel.NoteOn channel 2 pitch 60 vel 96
-Track 3@384 channel.NoteOff channel 2 pitch 48
-Track 3@0 channel.NoteOff channel 2 pitch 60
+Track 3@0 channel.ProgramChange channel 3 program 70
+Track 3@0 channel.NoteOn channel 3 pitch 48 vel 96
+Track 3@0 channel.NoteOn channel 3 pitch 60 vel 96
+Track 3@384 channel.NoteOff channel 3 pitch 48
+Track 3@0 channel.NoteOff channel 3 pitch 60
 Track 3@0 meta.endOfTrack
 */
 
@@ -162,7 +166,8 @@ func TestWriteSMF1(t *testing.T) {
 func TestWriteSysEx(t *testing.T) {
 	var bf bytes.Buffer
 
-	wr := New(&bf)
+	// the data contains a status byte on purpose
+	wr := New(&bf, Unsafe())
 	wr.SetDelta(0)
 	wr.Write(channel.Channel2.NoteOn(65, 90))
 	wr.SetDelta(10)
@@ -256,3 +261,431 @@ func TestNoRunningStatus(t *testing.T) {
 		t.Errorf("got:\n%#v\nwanted:\n%#v\n\n", got, want)
 	}
 }
+
+func TestSignature(t *testing.T) {
+
+	var bf bytes.Buffer
+
+	wr := New(&bf, Signature())
+
+	err := wr.WriteHeader()
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the delta of the first message is kept
+	wr.SetDelta(2)
+	wr.Write(channel.Channel0.NoteOn(50, 33))
+	wr.Write(meta.EndOfTrack)
+
+	expected := "4D 54 68 64 00 00 00 06 00 00 00 01 03 C0 4D 54 72 6B 00 00 00 13 00 FF 7F 07 7D 67 6F 6D 69 64 69 02 90 32 21 00 FF 2F 00"
+
+	if got, want := fmt.Sprintf("% X", bf.Bytes()), expected; got != want {
+		t.Errorf("got:\n%#v\nwanted:\n%#v\n\n", got, want)
+	}
+
+	if !IsSignature([]byte{0x7D, 'g', 'o', 'm', 'i', 'd', 'i'}) {
+		t.Errorf("IsSignature() = false; want true")
+	}
+}
+
+func TestValidation(t *testing.T) {
+
+	var bf bytes.Buffer
+
+	wr := New(&bf)
+
+	if err := wr.WriteHeader(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if err := wr.Write(meta.Undefined{Typ: meta.TypeEndOfTrack}); !errors.Is(err, midi.ErrInvalidMessage) {
+		t.Errorf("Write() = %v; want %v", err, midi.ErrInvalidMessage)
+	}
+
+	// the writer is blocked
+	if err := wr.Write(meta.EndOfTrack); !errors.Is(err, midi.ErrInvalidMessage) {
+		t.Errorf("Write() = %v; want %v", err, midi.ErrInvalidMessage)
+	}
+
+	bf.Reset()
+	wr = New(&bf, Unsafe())
+	wr.Write(channel.Channel(16).NoteOn(50, 33))
+	wr.Write(meta.EndOfTrack)
+
+	// the channel is truncated to 4 bits by the message
+	expected := "4D 54 68 64 00 00 00 06 00 00 00 01 03 C0 4D 54 72 6B 00 00 00 08 00 90 32 21 00 FF 2F 00"
+
+	if got, want := fmt.Sprintf("% X", bf.Bytes()), expected; got != want {
+		t.Errorf("got:\n%#v\nwanted:\n%#v\n\n", got, want)
+	}
+}
+
+func TestStrictMetaPlacement(t *testing.T) {
+	var bf bytes.Buffer
+
+	wr := New(&bf, NumTracks(2), StrictMetaPlacement())
+	wr.Write(meta.Marker("intro"))
+
+	if err := wr.Write(meta.EndOfTrack); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if err := wr.Write(meta.Track("bass")); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	err := wr.Write(meta.Marker("verse"))
+
+	if err == nil {
+		t.Fatalf("expected error for marker in the second track")
+	}
+
+	if got, want := err.Error(), `meta.Marker: "verse" is only allowed in the first track of a SMF1 file, but written to track 1`; got != want {
+		t.Errorf("Write() error = %q; want %q", got, want)
+	}
+
+	// SMF2 tracks are independent
+	bf.Reset()
+	wr = New(&bf, NumTracks(2), Format(smf.SMF2), StrictMetaPlacement())
+	wr.Write(meta.EndOfTrack)
+
+	if err := wr.Write(meta.Marker("verse")); err != nil {
+		t.Errorf("Error: %v", err)
+	}
+}
+
+func TestDeltaOverflow(t *testing.T) {
+	var bf bytes.Buffer
+
+	// the maximum is written as 4 bytes
+	wr := New(&bf, TimeFormat(smf.MetricTicks(96)))
+	wr.SetDelta(MaxDelta)
+	wr.Write(channel.Channel0.NoteOn(60, 100))
+
+	if err := wr.Write(meta.EndOfTrack); err != smf.ErrFinished {
+		t.Fatalf("Write() error = %v; want %v", err, smf.ErrFinished)
+	}
+
+	if got, want := fmt.Sprintf("% X", bf.Bytes()[14:]), "4D 54 72 6B 00 00 00 0B FF FF FF 7F 90 3C 64 00 FF 2F 00"; got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s\n\n", got, want)
+	}
+
+	// above the maximum the writing fails
+	bf.Reset()
+	wr = New(&bf, TimeFormat(smf.MetricTicks(96)))
+	wr.SetDelta(MaxDelta + 1)
+	err := wr.Write(channel.Channel0.NoteOn(60, 100))
+
+	if !errors.Is(err, ErrDeltaOverflow) {
+		t.Fatalf("Write() error = %v; want %v", err, ErrDeltaOverflow)
+	}
+
+	if got, want := err.Error(), "delta time of 268435456 ticks before channel.NoteOn channel 1 key 60 velocity 100 in track 0: delta time exceeds the maximum of 0x0FFFFFFF ticks"; got != want {
+		t.Errorf("Write() error = %q; want %q", got, want)
+	}
+
+	if err := wr.Write(meta.EndOfTrack); err == nil {
+		t.Errorf("expected the writer to be blocked")
+	}
+
+	if SplitsDeltas(wr) {
+		t.Errorf("SplitsDeltas() = true; want false")
+	}
+
+	// unless the delta time is split
+	bf.Reset()
+	wr = New(&bf, TimeFormat(smf.MetricTicks(96)), SplitDeltas())
+	wr.Write(channel.Channel0.NoteOn(60, 100))
+	wr.SetDelta(2*MaxDelta + 5)
+	wr.Write(channel.Channel0.NoteOn(64, 100))
+
+	if err := wr.Write(meta.EndOfTrack); err != smf.ErrFinished {
+		t.Fatalf("Write() error = %v; want %v", err, smf.ErrFinished)
+	}
+
+	if !SplitsDeltas(wr) {
+		t.Errorf("SplitsDeltas() = false; want true")
+	}
+
+	// the running status is not used after the text messages
+	if got, want := fmt.Sprintf("% X", bf.Bytes()[22:]), "00 90 3C 64 FF FF FF 7F FF 01 00 FF FF FF 7F FF 01 00 05 90 40 64 00 FF 2F 00"; got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s\n\n", got, want)
+	}
+
+	rd := smfreader.New(bytes.NewReader(bf.Bytes()))
+	var abs uint64
+	var msgs []string
+
+	for {
+		msg, err := rd.Read()
+
+		if err != nil {
+			break
+		}
+
+		abs += uint64(rd.Delta())
+		msgs = append(msgs, fmt.Sprintf("%v %s", abs, msg))
+	}
+
+	expected := []string{
+		"0 channel.NoteOn channel 1 key 60 velocity 100",
+		"268435455 meta.Text: \"\"",
+		"536870910 meta.Text: \"\"",
+		"536870915 channel.NoteOn channel 1 key 64 velocity 100",
+		"536870915 meta.EndOfTrack",
+	}
+
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("got:\n%v\nwanted:\n%v\n\n", msgs, expected)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	ch := channel.Channel0
+
+	// write writes a tick where messages of all classes collide
+	write := func(options ...Option) []byte {
+		var bf bytes.Buffer
+		wr := New(&bf, append(options, TimeFormat(smf.MetricTicks(96)))...)
+		wr.Write(ch.NoteOn(48, 100))
+		wr.Write(ch.NoteOn(64, 100))
+		wr.Write(ch.NoteOn(67, 100))
+		wr.SetDelta(96)
+		wr.Write(ch.NoteOn(60, 100))
+		wr.Write(ch.NoteOff(48))
+		wr.Write(ch.ControlChange(7, 100))
+		wr.Write(ch.Pitchbend(100))
+		wr.Write(ch.ProgramChange(5))
+		wr.Write(ch.ControlChange(0, 1))
+		wr.Write(ch.ControlChange(32, 2))
+		wr.Write(sysex.SysEx{0x41})
+		wr.Write(meta.BPM(100))
+		// a note without duration and retriggered notes, with the note off before and after the note on
+		wr.Write(ch.NoteOn(62, 100))
+		wr.Write(ch.NoteOff(62))
+		wr.Write(ch.NoteOff(64))
+		wr.Write(ch.NoteOn(64, 100))
+		wr.Write(ch.NoteOn(67, 100))
+		wr.Write(ch.NoteOff(67))
+		wr.SetDelta(96)
+		wr.Write(ch.NoteOff(60))
+		wr.Write(ch.NoteOff(64))
+		wr.Write(ch.NoteOff(67))
+		wr.Write(meta.EndOfTrack)
+		return bf.Bytes()
+	}
+
+	// read returns the messages of the second tick
+	read := func(data []byte) string {
+		rd := smfreader.New(bytes.NewReader(data))
+		var abs uint32
+		var bf bytes.Buffer
+
+		for {
+			msg, err := rd.Read()
+
+			if err != nil {
+				break
+			}
+
+			abs += rd.Delta()
+
+			if abs == 96 {
+				fmt.Fprintf(&bf, "%s\n", msg)
+			}
+		}
+
+		return bf.String()
+	}
+
+	tests := []struct {
+		policy   smf.OrderPolicy
+		expected string
+	}{
+		{
+			smf.Safe,
+			`meta.Tempo BPM: 100.00
+sysex.SysEx len: 1
+channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 1
+channel.ControlChange channel 1 controller 32 ("Bank Select (LSB)") value 2
+channel.ProgramChange channel 1 program 5
+channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+channel.Pitchbend channel 1 value 100 absValue 8292
+channel.NoteOff channel 1 key 48
+channel.NoteOff channel 1 key 64
+channel.NoteOn channel 1 key 64 velocity 100
+channel.NoteOff channel 1 key 67
+channel.NoteOn channel 1 key 67 velocity 100
+channel.NoteOn channel 1 key 60 velocity 100
+channel.NoteOn channel 1 key 62 velocity 100
+channel.NoteOff channel 1 key 62
+`,
+		},
+		{
+			smf.LegatoFriendly,
+			`meta.Tempo BPM: 100.00
+sysex.SysEx len: 1
+channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 1
+channel.ControlChange channel 1 controller 32 ("Bank Select (LSB)") value 2
+channel.ProgramChange channel 1 program 5
+channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+channel.Pitchbend channel 1 value 100 absValue 8292
+channel.NoteOn channel 1 key 60 velocity 100
+channel.NoteOn channel 1 key 62 velocity 100
+channel.NoteOff channel 1 key 62
+channel.NoteOff channel 1 key 48
+channel.NoteOff channel 1 key 64
+channel.NoteOn channel 1 key 64 velocity 100
+channel.NoteOff channel 1 key 67
+channel.NoteOn channel 1 key 67 velocity 100
+`,
+		},
+		{
+			smf.PreserveInput,
+			`channel.NoteOn channel 1 key 60 velocity 100
+channel.NoteOff channel 1 key 48
+channel.ControlChange channel 1 controller 7 ("Volume (MSB)") value 100
+channel.Pitchbend channel 1 value 100 absValue 8292
+channel.ProgramChange channel 1 program 5
+channel.ControlChange channel 1 controller 0 ("Bank Select (MSB)") value 1
+channel.ControlChange channel 1 controller 32 ("Bank Select (LSB)") value 2
+sysex.SysEx len: 1
+meta.Tempo BPM: 100.00
+channel.NoteOn channel 1 key 62 velocity 100
+channel.NoteOff channel 1 key 62
+channel.NoteOff channel 1 key 64
+channel.NoteOn channel 1 key 64 velocity 100
+channel.NoteOn channel 1 key 67 velocity 100
+channel.NoteOff channel 1 key 67
+`,
+		},
+	}
+
+	for i, test := range tests {
+		if got, want := read(write(Order(test.policy))), test.expected; got != want {
+			t.Errorf("[%v] got:\n%s\nwanted:\n%s\n\n", i, got, want)
+		}
+	}
+
+	if got, want := write(Order(smf.PreserveInput)), write(); !bytes.Equal(got, want) {
+		t.Errorf("PreserveInput got:\n% X\nwanted:\n% X\n\n", got, want)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	// write writes two tracks, the first one is larger than a block of the streaming
+	write := func(dest io.Writer, options ...Option) error {
+		wr := New(dest, append(options, NumTracks(2), TimeFormat(smf.MetricTicks(96)))...)
+
+		for i := 0; i < 20000; i++ {
+			wr.SetDelta(10)
+			wr.Write(channel.Channel1.NoteOn(uint8(i%128), 100))
+			wr.Write(sysex.SysEx{0x7D, byte(i % 128)})
+		}
+
+		wr.Write(meta.EndOfTrack)
+		wr.SetDelta(20)
+		wr.Write(channel.Channel2.NoteOff(60))
+		return wr.Write(meta.EndOfTrack)
+	}
+
+	var expected bytes.Buffer
+
+	if err := write(&expected); err != smf.ErrFinished {
+		t.Fatalf("Error: %v", err)
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "streamed.mid"))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	defer f.Close()
+
+	if err := write(f, Streaming()); err != smf.ErrFinished {
+		t.Fatalf("Error: %v", err)
+	}
+
+	got, err := os.ReadFile(f.Name())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if !bytes.Equal(got, expected.Bytes()) {
+		t.Errorf("streamed file (%v bytes) differs from the file written in memory (%v bytes)", len(got), expected.Len())
+	}
+
+	// the output must be seekable
+	if err := write(&bytes.Buffer{}, Streaming()); err == nil || err.Error() != "streaming needs an io.WriteSeeker as output, got *bytes.Buffer" {
+		t.Errorf("Write() to *bytes.Buffer = %v; want error", err)
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	// write writes two tracks with a checkpoint after every 3 messages
+	write := func(dest io.Writer, options ...Option) error {
+		wr := New(dest, append(options, NumTracks(2), TimeFormat(smf.MetricTicks(96)))...)
+
+		for i := 0; i < 10; i++ {
+			wr.SetDelta(10)
+			wr.Write(channel.Channel1.NoteOn(uint8(i), 100))
+		}
+
+		wr.Write(meta.EndOfTrack)
+
+		if len(options) > 0 {
+			if err := Checkpoint(wr); err != nil {
+				return err
+			}
+		}
+
+		wr.SetDelta(20)
+		wr.Write(channel.Channel2.NoteOff(60))
+		return wr.Write(meta.EndOfTrack)
+	}
+
+	var expected bytes.Buffer
+
+	if err := write(&expected); err != smf.ErrFinished {
+		t.Fatalf("Error: %v", err)
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "checkpoints.mid"))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	defer f.Close()
+
+	if err := write(f, Checkpoints(3)); err != smf.ErrFinished {
+		t.Fatalf("Error: %v", err)
+	}
+
+	got, err := os.ReadFile(f.Name())
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// the provisional ends of track have been overwritten
+	if !bytes.Equal(got, expected.Bytes()) {
+		t.Errorf("got:\n% X\n\nwanted:\n% X\n\n", got, expected.Bytes())
+	}
+
+	if err := Checkpoint(New(&bytes.Buffer{})); err == nil {
+		t.Errorf("Checkpoint() without Checkpoints option returned no error")
+	}
+
+	f.Seek(0, io.SeekStart)
+	wr := New(f, Streaming())
+	wr.Write(meta.EndOfTrack)
+
+	if err := Checkpoint(wr); err != smf.ErrFinished {
+		t.Errorf("Checkpoint() after the last track = %v; want %v", err, smf.ErrFinished)
+	}
+}
@@ -2,8 +2,10 @@ package smfwriter
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 
 	"github.com/gomidi/midi/internal/runningstatus"
@@ -13,10 +15,19 @@ import (
 
 	"github.com/gomidi/midi"
 
+	"github.com/gomidi/midi/midimessage/channel"
 	"github.com/gomidi/midi/midimessage/meta"
 	"github.com/gomidi/midi/smf"
 )
 
+// MaxDelta is the largest delta time in ticks that can be written: the SMF specification limits the variable
+// length quantities to 4 bytes.
+const MaxDelta = 0x0FFFFFFF
+
+// ErrDeltaOverflow is wrapped by the error that Write returns for a delta time that exceeds MaxDelta, unless the
+// SplitDeltas option is given.
+var ErrDeltaOverflow = errors.New("delta time exceeds the maximum of 0x0FFFFFFF ticks")
+
 // WriteFile creates file, calls callback with a writer and closes file
 //
 // WriteFile makes sure that the data of the last track is written by sending
@@ -99,8 +110,44 @@ type writer struct {
 	noRunningStatus bool
 	error           error
 	runningWriter   runningstatus.SMFWriter
+
+	strictMetaPlacement bool
+	splitDeltas         bool
+
+	// unsafe disables the validation of the messages (see Unsafe)
+	unsafe bool
+
+	// signature is set by the Signature option, signed is true, when the signature has been written
+	signature bool
+	signed    bool
+
+	// order is the ordering of the messages at the same tick (see Order),
+	// group are the buffered messages of the current tick and groupDelta the delta time of the first one.
+	// sounding are the notes of the current track that are sounding before the current tick.
+	order      smf.OrderPolicy
+	group      []midi.Message
+	groupDelta uint32
+	sounding   channel.NoteTracker
+
+	// streaming is set by the Streaming option: the messages of the current track are buffered in stream and
+	// written to the output in blocks. trackStart is the offset of the current track chunk within the output
+	// (-1 before the chunk header has been written) and trackLen the number of bytes written to its body.
+	streaming  bool
+	stream     []byte
+	trackStart int64
+	trackLen   int64
+
+	// checkpointEvery is set by the Checkpoints option, sinceCheckpoint counts the messages since the last
+	// checkpoint and provisional is true, while a provisional end of track follows the written body of the track
+	// (see Checkpoint)
+	checkpointEvery int
+	sinceCheckpoint int
+	provisional     bool
 }
 
+// streamBlock is the size of the blocks that are written by the Streaming option
+const streamBlock = 64 * 1024
+
 func (w *writer) Close() error {
 	if cl, is := w.output.(io.WriteCloser); is {
 		return cl.Close()
@@ -128,6 +175,7 @@ func newWriter(output io.Writer, opts ...Option) *writer {
 	// setup
 	wr := &writer{}
 	wr.output = output
+	wr.trackStart = -1
 	wr.track.SetType([4]byte{byte('M'), byte('T'), byte('r'), byte('k')})
 
 	// defaults
@@ -144,6 +192,10 @@ func newWriter(output io.Writer, opts ...Option) *writer {
 		wr.runningWriter = runningstatus.NewSMFWriter()
 	}
 
+	if _, is := output.(io.WriteSeeker); wr.streaming && !is {
+		wr.error = fmt.Errorf("streaming needs an io.WriteSeeker as output, got %T", output)
+	}
+
 	// if midiformat is undefined (see above), i.e. not set via options
 	// set the default, which is format 0 for one track and format 1 for multitracks
 	// if wr.header.MidiFormat == format(10) {
@@ -187,7 +239,44 @@ func (w *writer) Write(m midi.Message) (err error) {
 		return w.error
 	}
 
+	if w.strictMetaPlacement && w.header.Format == smf.SMF1 && w.tracksProcessed > 0 {
+		if mm, is := m.(meta.Message); is && meta.FirstTrackOnly(mm) {
+			w.error = fmt.Errorf("%s is only allowed in the first track of a SMF1 file, but written to track %v", m, w.tracksProcessed)
+			return w.error
+		}
+	}
+
+	if !w.unsafe {
+		if err := midi.Validate(m); err != nil {
+			w.error = fmt.Errorf("can't write message to track %v: %w", w.tracksProcessed, err)
+			return w.error
+		}
+	}
+
+	if w.signature && !w.signed {
+		w.addMessage(0, meta.SequencerData(signature))
+		w.signed = true
+	}
+
+	if w.deltatime > 0 {
+		w.flushGroup()
+	}
+
+	if w.deltatime > MaxDelta {
+		if !w.splitDeltas {
+			w.error = fmt.Errorf("delta time of %v ticks before %s in track %v: %w", w.deltatime, m, w.tracksProcessed, ErrDeltaOverflow)
+			return w.error
+		}
+
+		for w.deltatime > MaxDelta {
+			w.addMessage(MaxDelta, meta.Text(""))
+			w.deltatime -= MaxDelta
+		}
+	}
+
 	if m == meta.EndOfTrack {
+		w.flushGroup()
+		w.sounding.Reset()
 		w.addMessage(w.deltatime, m)
 		err = w.writeTrackTo(w.output)
 		if err != nil {
@@ -195,10 +284,201 @@ func (w *writer) Write(m midi.Message) (err error) {
 		}
 		return
 	}
+
+	if len(w.order.Classes) > 0 {
+		if len(w.group) == 0 {
+			w.groupDelta = w.deltatime
+		}
+		w.group = append(w.group, m)
+		return
+	}
+
 	w.addMessage(w.deltatime, m)
+
+	if w.streaming && len(w.stream) >= streamBlock {
+		w.error = w.flushStream()
+		err = w.error
+	}
+
+	if w.error == nil && w.checkpointEvery > 0 {
+		w.sinceCheckpoint++
+
+		if w.sinceCheckpoint >= w.checkpointEvery {
+			w.error = w.checkpoint()
+			err = w.error
+		}
+	}
+
 	return
 }
 
+// Checkpoint writes the messages that have been written to wr so far to its output, followed by a provisional end
+// of track message, and patches the length of the track chunk, so that the output is a complete SMF up to this
+// point, if the current track is the last one (see Checkpoints). The next message overwrites the provisional end
+// of track.
+// If the output has a Sync method (e.g. *os.File), it is called, so that the data survives a crash of the
+// system.
+//
+// Messages at the current tick that are buffered by the Order option are not written before the next tick.
+// An error is returned, if wr is not a writer of this package with the Streaming or Checkpoints option, or if
+// writing fails, which blocks the writer like any other error.
+func Checkpoint(wr smf.Writer) error {
+	w, is := wr.(*writer)
+
+	if !is || !w.streaming {
+		return fmt.Errorf("checkpoints need a writer with the Streaming or Checkpoints option")
+	}
+
+	if w.error != nil {
+		return w.error
+	}
+
+	if !w.headerWritten {
+		if err := w.WriteHeader(); err != nil {
+			return err
+		}
+	}
+
+	if w.header.NumTracks == w.tracksProcessed {
+		return smf.ErrFinished
+	}
+
+	w.error = w.checkpoint()
+	return w.error
+}
+
+// provisionalEndOfTrack is the end of track message that is written by a checkpoint
+var provisionalEndOfTrack = []byte{0x00, 0xFF, 0x2F, 0x00}
+
+// checkpoint writes the buffered messages, the provisional end of track and the length of the track chunk that
+// includes it
+func (w *writer) checkpoint() error {
+	w.sinceCheckpoint = 0
+
+	if err := w.flushStream(); err != nil {
+		return err
+	}
+
+	out := w.output.(io.WriteSeeker)
+
+	if _, err := out.Write(provisionalEndOfTrack); err != nil {
+		return fmt.Errorf("could not write checkpoint of track %v: %v", w.tracksProcessed+1, err)
+	}
+
+	w.provisional = true
+
+	if err := w.patchLength(w.trackLen + int64(len(provisionalEndOfTrack))); err != nil {
+		return err
+	}
+
+	if sy, is := w.output.(interface{ Sync() error }); is {
+		if err := sy.Sync(); err != nil {
+			return fmt.Errorf("could not sync checkpoint of track %v: %v", w.tracksProcessed+1, err)
+		}
+	}
+
+	return nil
+}
+
+// flushStream writes the buffered messages of the Streaming option, preceded by the header of the track chunk
+// (with a length of 0), if it has not been written yet
+func (w *writer) flushStream() error {
+	out := w.output.(io.WriteSeeker)
+
+	if w.trackStart < 0 {
+		pos, err := out.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return fmt.Errorf("could not write track %v: %v", w.tracksProcessed+1, err)
+		}
+
+		if _, err := out.Write([]byte{'M', 'T', 'r', 'k', 0, 0, 0, 0}); err != nil {
+			return fmt.Errorf("could not write track %v: %v", w.tracksProcessed+1, err)
+		}
+
+		w.trackStart, w.trackLen = pos, 0
+	}
+
+	// the next message overwrites the provisional end of track of the last checkpoint
+	if w.provisional {
+		if _, err := out.Seek(w.trackStart+8+w.trackLen, io.SeekStart); err != nil {
+			return fmt.Errorf("could not write track %v: %v", w.tracksProcessed+1, err)
+		}
+
+		w.provisional = false
+	}
+
+	if _, err := out.Write(w.stream); err != nil {
+		return fmt.Errorf("could not write track %v: %v", w.tracksProcessed+1, err)
+	}
+
+	w.trackLen += int64(len(w.stream))
+	w.stream = w.stream[:0]
+	return nil
+}
+
+// finishStream writes the rest of the track of the Streaming option and patches the length of its chunk
+func (w *writer) finishStream() error {
+	if err := w.flushStream(); err != nil {
+		return err
+	}
+
+	if err := w.patchLength(w.trackLen); err != nil {
+		return err
+	}
+
+	w.trackStart = -1
+	w.sinceCheckpoint = 0
+	return nil
+}
+
+// patchLength writes the given length to the header of the current track chunk and seeks to the end of its
+// written body
+func (w *writer) patchLength(length int64) error {
+	if length > math.MaxUint32 {
+		return fmt.Errorf("could not write track %v: length of %v bytes exceeds the maximum chunk length", w.tracksProcessed+1, length)
+	}
+
+	out := w.output.(io.WriteSeeker)
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(length))
+
+	if _, err := out.Seek(w.trackStart+4, io.SeekStart); err != nil {
+		return fmt.Errorf("could not patch the length of track %v: %v", w.tracksProcessed+1, err)
+	}
+
+	if _, err := out.Write(b[:]); err != nil {
+		return fmt.Errorf("could not patch the length of track %v: %v", w.tracksProcessed+1, err)
+	}
+
+	if _, err := out.Seek(w.trackStart+8+length, io.SeekStart); err != nil {
+		return fmt.Errorf("could not patch the length of track %v: %v", w.tracksProcessed+1, err)
+	}
+
+	return nil
+}
+
+// flushGroup writes the buffered messages of the current tick in the order of the policy (see Order)
+func (w *writer) flushGroup() {
+	if len(w.group) == 0 {
+		return
+	}
+
+	w.order.SortSounding(w.group, &w.sounding)
+	delta := w.groupDelta
+
+	for _, m := range w.group {
+		if cm, is := m.(channel.Message); is {
+			w.sounding.Track(cm)
+		}
+
+		w.addMessage(delta, m)
+		delta = 0
+	}
+
+	w.group = w.group[:0]
+}
+
 /*
 
 					| time type            | bit 15 | bits 14 thru 8        | bits 7 thru 0   |
@@ -273,7 +553,11 @@ func (w *writer) writeHeader(wr io.Writer) error {
 
 // <Track Chunk> = <chunk type><length><MTrk event>+
 func (w *writer) writeTrackTo(wr io.Writer) (err error) {
-	_, err = w.track.WriteTo(wr)
+	if w.streaming {
+		err = w.finishStream()
+	} else {
+		_, err = w.track.WriteTo(wr)
+	}
 
 	if err != nil {
 		return fmt.Errorf("could not write track %v: %v", w.tracksProcessed+1, err)
@@ -296,6 +580,11 @@ func (w *writer) writeTrackTo(wr io.Writer) (err error) {
 }
 
 func (w *writer) appendToChunk(deltaTime uint32, b []byte) {
+	if w.streaming {
+		w.stream = append(append(w.stream, vlq.Encode(deltaTime)...), b...)
+		return
+	}
+
 	w.track.Write(append(vlq.Encode(deltaTime), b...))
 	//t.track.data = append(t.track.data, append(vlq.Encode(deltaTime), b...)...)
 }
@@ -0,0 +1,29 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package stepinput provides the step entry of notes known from notation programs and step recording of sequencers.
+
+A Session is bound to a track of a SMF. It has a cursor and a step size. Each entered note lasts one step and
+moves the cursor on by a step:
+
+	session, err := stepinput.New(song, 1, smftrack.Eighth, stepinput.Channel(2))
+
+	if err != nil {
+		panic(err)
+	}
+
+	session.NoteEntered(60, 100) // C4 eighth
+	session.NoteEntered(62, 100) // D4 eighth
+	session.SetStep(smftrack.Quarter)
+	session.Rest()               // quarter rest
+	session.ChordNote(64, 100)   // E4 and G4 quarters
+	session.ChordNote(67, 100)
+	session.Advance()
+	session.Tie()                // E4 and G4 last two quarters
+	session.Undo()               // E4 and G4 are quarters again
+
+The track is modified in place.
+*/
+package stepinput
@@ -0,0 +1,330 @@
+package stepinput
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// Option is an option for New
+type Option func(*Session)
+
+// Channel sets the MIDI channel of the entered notes. Default is channel 0.
+func Channel(ch uint8) Option {
+	return func(s *Session) {
+		s.channel = ch
+	}
+}
+
+// Session enters notes step by step into a track of a SMF (see New)
+type Session struct {
+	track   *smftrack.Track
+	channel uint8
+	tpq     uint64
+
+	cursor    uint64
+	step      smftrack.Duration
+	stepTicks uint64
+
+	// entry are the notes of the last entry, i.e. of the last note or chord, that Tie extends; pending is true
+	// while notes may be added to the chord at the cursor
+	entry   []entered
+	pending bool
+
+	undo []state
+}
+
+// entered is an entered note and the tick of its end
+type entered struct {
+	key uint8
+	end uint64
+}
+
+// state is the state before an edit that Undo restores: the changes of the events are reverted in reverse order
+type state struct {
+	changes []change
+	end     uint64
+	cursor  uint64
+	entry   []entered
+	pending bool
+}
+
+// change is an event that has been inserted at or deleted from an index of the track
+type change struct {
+	index   int
+	event   smftrack.Event
+	deleted bool
+}
+
+// New returns a Session that enters notes into the track of the given number of the given SMF, starting at tick 0
+// with the given step size. The SMF must have a metric time format.
+func New(s *smftrack.SMF, track int, step smftrack.Duration, options ...Option) (*Session, error) {
+	mt, isMetric := s.TimeFormat().(smf.MetricTicks)
+
+	if !isMetric || mt == 0 {
+		return nil, fmt.Errorf("only metric timeformat supported, sorry")
+	}
+
+	if track < 0 || track >= int(s.NumTracks()) {
+		return nil, fmt.Errorf("track %v out of range [0,%v)", track, s.NumTracks())
+	}
+
+	sess := &Session{
+		track: s.Track(track),
+		tpq:   uint64(mt.Ticks4th()),
+	}
+
+	for _, opt := range options {
+		opt(sess)
+	}
+
+	if sess.channel > 15 {
+		return nil, fmt.Errorf("invalid channel %v", sess.channel)
+	}
+
+	if err := sess.SetStep(step); err != nil {
+		return nil, err
+	}
+
+	return sess, nil
+}
+
+// Cursor returns the tick at which the next note is entered
+func (s *Session) Cursor() uint64 {
+	return s.cursor
+}
+
+// SetCursor moves the cursor to the given tick. It ends the last entry, so that Tie has nothing to extend.
+func (s *Session) SetCursor(tick uint64) {
+	s.cursor = tick
+	s.entry, s.pending = nil, false
+}
+
+// Step returns the step size
+func (s *Session) Step() smftrack.Duration {
+	return s.step
+}
+
+// SetStep sets the step size, i.e. the length of the entered notes and the distance the cursor moves on, e.g.
+// smftrack.Dotted(smftrack.Eighth). It returns an error, if the step is shorter than a tick.
+func (s *Session) SetStep(d smftrack.Duration) error {
+	ticks := math.Round(float64(d) * 4 * float64(s.tpq))
+
+	if ticks < 1 {
+		return fmt.Errorf("step %v is shorter than a tick", d)
+	}
+
+	s.step, s.stepTicks = d, uint64(ticks)
+	return nil
+}
+
+// NoteEntered inserts a note of the step size with the given key and velocity at the cursor and moves the
+// cursor on by a step. It adds the note to the chord at the cursor, if ChordNote has been called before.
+func (s *Session) NoteEntered(key, velocity uint8) error {
+	if err := s.ChordNote(key, velocity); err != nil {
+		return err
+	}
+
+	// the note and the advance are undone together
+	s.advance()
+	return nil
+}
+
+// ChordNote inserts a note of the step size with the given key and velocity at the cursor without moving the
+// cursor, so that further notes of a chord can be entered. Advance moves the cursor on.
+func (s *Session) ChordNote(key, velocity uint8) error {
+	if key > 127 {
+		return fmt.Errorf("invalid key %v", key)
+	}
+
+	if velocity == 0 || velocity > 127 {
+		return fmt.Errorf("invalid velocity %v", velocity)
+	}
+
+	s.save()
+	ch := channel.Channel(s.channel)
+	end := s.cursor + s.stepTicks
+
+	if err := s.insertNoteOff(end, ch.NoteOff(key)); err != nil {
+		s.restore()
+		return err
+	}
+
+	// after any existing events at the cursor
+	i := sort.Search(s.track.Len(), func(i int) bool { return s.track.Tick(i) > s.cursor })
+
+	if err := s.insert(i, smftrack.Event{AbsTicks: s.cursor, Message: ch.NoteOn(key, velocity)}); err != nil {
+		s.restore()
+		return err
+	}
+
+	if !s.pending {
+		s.entry = nil
+	}
+
+	s.entry = append(s.entry, entered{key: key, end: end})
+	s.pending = true
+	return nil
+}
+
+// Advance moves the cursor on by a step, e.g. after the notes of a chord have been entered with ChordNote
+func (s *Session) Advance() {
+	s.save()
+	s.advance()
+}
+
+func (s *Session) advance() {
+	s.cursor += s.stepTicks
+	s.pending = false
+}
+
+// Rest moves the cursor on by a step without entering a note. It ends the last entry, so that Tie has nothing to
+// extend.
+func (s *Session) Rest() {
+	s.save()
+	s.advance()
+	s.entry = nil
+}
+
+// Tie extends the notes of the last entry (the last note or chord) by a step and moves the cursor to their end.
+// It returns an error, if there is nothing to extend, i.e. after a Rest, SetCursor or if nothing has been entered.
+func (s *Session) Tie() error {
+	if len(s.entry) == 0 {
+		return fmt.Errorf("nothing to tie")
+	}
+
+	s.save()
+	entry := make([]entered, len(s.entry))
+	var cursor uint64
+
+	for i, n := range s.entry {
+		// the note off of the note at its end
+		j := sort.Search(s.track.Len(), func(i int) bool { return s.track.Tick(i) >= n.end })
+		var off channel.NoteOff
+
+		for ; j < s.track.Len() && s.track.Tick(j) == n.end; j++ {
+			var is bool
+			if off, is = s.track.Event(j).Message.(channel.NoteOff); is && off.Channel() == s.channel && off.Key() == n.key {
+				break
+			}
+		}
+
+		if j == s.track.Len() || s.track.Tick(j) != n.end {
+			s.restore()
+			return fmt.Errorf("note off of key %v at tick %v not found", n.key, n.end)
+		}
+
+		if err := s.delete(j); err != nil {
+			s.restore()
+			return err
+		}
+
+		entry[i] = entered{key: n.key, end: n.end + s.stepTicks}
+
+		if err := s.insertNoteOff(entry[i].end, off); err != nil {
+			s.restore()
+			return err
+		}
+
+		if entry[i].end > cursor {
+			cursor = entry[i].end
+		}
+	}
+
+	s.entry, s.pending = entry, false
+	s.cursor = cursor
+	return nil
+}
+
+// insertNoteOff inserts the given note off after the note offs, but before any other events at the given tick, so
+// that it does not end a note of the same key that starts at that tick
+func (s *Session) insertNoteOff(tick uint64, off channel.NoteOff) error {
+	i := sort.Search(s.track.Len(), func(i int) bool { return s.track.Tick(i) >= tick })
+
+	for ; i < s.track.Len() && s.track.Tick(i) == tick; i++ {
+		if _, is := s.track.Event(i).Message.(channel.NoteOff); !is {
+			break
+		}
+	}
+
+	return s.insert(i, smftrack.Event{AbsTicks: tick, Message: off})
+}
+
+// insert inserts the given event at index i and records the change for Undo
+func (s *Session) insert(i int, ev smftrack.Event) error {
+	if err := s.track.Insert(i, ev); err != nil {
+		return err
+	}
+
+	st := &s.undo[len(s.undo)-1]
+	st.changes = append(st.changes, change{index: i, event: ev})
+	return nil
+}
+
+// delete deletes the event at index i and records the change for Undo
+func (s *Session) delete(i int) error {
+	ev := s.track.Event(i)
+
+	if err := s.track.Delete(i); err != nil {
+		return err
+	}
+
+	st := &s.undo[len(s.undo)-1]
+	st.changes = append(st.changes, change{index: i, event: ev, deleted: true})
+	return nil
+}
+
+// Undo reverts the last NoteEntered, ChordNote, Advance, Rest or Tie, including the move of the cursor.
+// It returns an error, if there is nothing to undo or if the track can't be changed (e.g. smftrack.ErrFrozen).
+func (s *Session) Undo() error {
+	if len(s.undo) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+
+	return s.restore()
+}
+
+// save pushes the current state to the undo stack. The events are not copied, the edit records its changes
+// (see insert and delete).
+func (s *Session) save() {
+	s.undo = append(s.undo, state{
+		end:     s.track.End(),
+		cursor:  s.cursor,
+		entry:   append([]entered(nil), s.entry...),
+		pending: s.pending,
+	})
+}
+
+// restore pops the last state from the undo stack and restores it by reverting its changes in reverse order
+func (s *Session) restore() error {
+	st := s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+
+	for i := len(st.changes) - 1; i >= 0; i-- {
+		c := st.changes[i]
+		var err error
+
+		if c.deleted {
+			err = s.track.Insert(c.index, c.event)
+		} else {
+			err = s.track.Delete(c.index)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(st.changes) > 0 {
+		if err := s.track.SetEnd(st.end); err != nil {
+			return err
+		}
+	}
+
+	s.cursor, s.entry, s.pending = st.cursor, st.entry, st.pending
+	return nil
+}
@@ -0,0 +1,174 @@
+package stepinput
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/smf"
+	"github.com/gomidi/midi/smf/smftrack"
+)
+
+// smfString returns the events of the given SMF and the written bytes
+func smfString(t *testing.T, s *smftrack.SMF) (string, []byte) {
+	var bf bytes.Buffer
+
+	for i := 0; i < int(s.NumTracks()); i++ {
+		for _, ev := range s.Track(i).Events() {
+			fmt.Fprintf(&bf, "%v %v %s\n", i, ev.AbsTicks, ev.Message)
+		}
+
+		fmt.Fprintf(&bf, "%v %v end\n", i, s.Track(i).End())
+	}
+
+	var data bytes.Buffer
+
+	if err := s.Write(&data); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	return bf.String(), data.Bytes()
+}
+
+// song returns a SMF with a conductor track and a piano track with the given notes
+func song(notes ...smftrack.Event) *smftrack.SMF {
+	var conductor, piano smftrack.Track
+	conductor.Add(0, meta.BPM(100), meta.TimeSig{Numerator: 4, Denominator: 4})
+	piano.Add(0, meta.Track("piano"), channel.Channel2.ProgramChange(0))
+
+	for _, n := range notes {
+		piano.Add(n.AbsTicks, n.Message)
+	}
+
+	s := smftrack.New(smf.SMF1, smf.MetricTicks(480))
+	s.AddTrack(&conductor)
+	s.AddTrack(&piano)
+	return s
+}
+
+func TestSession(t *testing.T) {
+	s := song()
+	sess, err := New(s, 1, smftrack.Eighth, Channel(2))
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	steps := []struct {
+		action string
+		step   func() error
+		cursor uint64
+	}{
+		{"C4", func() error { return sess.NoteEntered(60, 100) }, 240},
+		{"C4", func() error { return sess.NoteEntered(60, 90) }, 480},
+		{"quarter", func() error { return sess.SetStep(smftrack.Quarter) }, 480},
+		{"rest", func() error { sess.Rest(); return nil }, 960},
+		{"E4", func() error { return sess.ChordNote(64, 100) }, 960},
+		{"G4", func() error { return sess.ChordNote(67, 100) }, 960},
+		{"advance", func() error { sess.Advance(); return nil }, 1440},
+		{"tie", sess.Tie, 1920},
+		{"C5", func() error { return sess.NoteEntered(72, 80) }, 2400},
+		{"undo", sess.Undo, 1920},
+		{"dotted eighth", func() error { return sess.SetStep(smftrack.Dotted(smftrack.Eighth)) }, 1920},
+		{"B4", func() error { return sess.NoteEntered(71, 100) }, 2280},
+		{"tie", sess.Tie, 2640},
+		{"tie", sess.Tie, 3000},
+		{"undo", sess.Undo, 2640},
+		{"undo", sess.Undo, 2280},
+	}
+
+	for _, st := range steps {
+		if err := st.step(); err != nil {
+			t.Fatalf("%s: Error: %v", st.action, err)
+		}
+
+		if got, want := sess.Cursor(), st.cursor; got != want {
+			t.Errorf("cursor after %s = %v; want %v", st.action, got, want)
+		}
+	}
+
+	ch := channel.Channel2
+	expected := song(
+		smftrack.Event{AbsTicks: 0, Message: ch.NoteOn(60, 100)},
+		smftrack.Event{AbsTicks: 240, Message: ch.NoteOff(60)},
+		smftrack.Event{AbsTicks: 240, Message: ch.NoteOn(60, 90)},
+		smftrack.Event{AbsTicks: 480, Message: ch.NoteOff(60)},
+		smftrack.Event{AbsTicks: 960, Message: ch.NoteOn(64, 100)},
+		smftrack.Event{AbsTicks: 960, Message: ch.NoteOn(67, 100)},
+		smftrack.Event{AbsTicks: 1920, Message: ch.NoteOff(64)},
+		smftrack.Event{AbsTicks: 1920, Message: ch.NoteOff(67)},
+		smftrack.Event{AbsTicks: 1920, Message: ch.NoteOn(71, 100)},
+		smftrack.Event{AbsTicks: 2280, Message: ch.NoteOff(71)},
+	)
+
+	gotString, gotData := smfString(t, s)
+	wantString, wantData := smfString(t, expected)
+
+	if gotString != wantString || !bytes.Equal(gotData, wantData) {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", gotString, wantString)
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	if _, err := New(smftrack.New(smf.SMF0, smf.SMPTE25(40)), 0, smftrack.Quarter); err == nil {
+		t.Errorf("New() with time code returned no error")
+	}
+
+	if _, err := New(song(), 2, smftrack.Quarter); err == nil {
+		t.Errorf("New() of track 2 of 2 tracks returned no error")
+	}
+
+	if _, err := New(song(), 1, smftrack.Quarter, Channel(16)); err == nil {
+		t.Errorf("New() with channel 16 returned no error")
+	}
+
+	if _, err := New(song(), 1, smftrack.Duration(1.0/4096)); err == nil {
+		t.Errorf("New() with a step shorter than a tick returned no error")
+	}
+
+	sess, _ := New(song(), 1, smftrack.Quarter)
+
+	if err := sess.Undo(); err == nil {
+		t.Errorf("Undo() without edits returned no error")
+	}
+
+	if err := sess.Tie(); err == nil {
+		t.Errorf("Tie() without notes returned no error")
+	}
+
+	if err := sess.NoteEntered(60, 0); err == nil {
+		t.Errorf("NoteEntered() with velocity 0 returned no error")
+	}
+
+	if err := sess.NoteEntered(128, 100); err == nil {
+		t.Errorf("NoteEntered() of key 128 returned no error")
+	}
+
+	sess.NoteEntered(60, 100)
+	sess.Rest()
+
+	if err := sess.Tie(); err == nil {
+		t.Errorf("Tie() after Rest() returned no error")
+	}
+
+	// the rest is undone, so the note can be tied again
+	sess.Undo()
+
+	if err := sess.Tie(); err != nil || sess.Cursor() != 960 {
+		t.Errorf("Tie() after undoing Rest() = %v, cursor %v; want no error, cursor 960", err, sess.Cursor())
+	}
+	// the errors of a frozen track are returned and the edit is not recorded
+	frozen := song()
+	frozen.Freeze()
+	sess, _ = New(frozen, 1, smftrack.Quarter)
+
+	if err := sess.NoteEntered(60, 100); err != smftrack.ErrFrozen {
+		t.Errorf("NoteEntered() of frozen track = %v; want %v", err, smftrack.ErrFrozen)
+	}
+
+	if err := sess.Undo(); err == nil || sess.Cursor() != 0 {
+		t.Errorf("Undo() after failed edit = %v, cursor %v; want error, cursor 0", err, sess.Cursor())
+	}
+}
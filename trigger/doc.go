@@ -0,0 +1,35 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package trigger recognizes phrases in live MIDI streams, e.g. to start an action of an installation, when someone
+plays three ascending notes C, E and G.
+
+A Pattern is a sequence of Steps. Each Step matches a message by its type, channel and ranges of the key
+(respectively controller) and the velocity (respectively value), and may limit the time since the message of the
+step before. Patterns can be built with the Step constructors
+
+	p := trigger.Sequence(
+		trigger.NoteOn().Key(60),
+		trigger.NoteOn().Key(64).Within(time.Second),
+		trigger.NoteOn().Key(67).Velocity(80, 127).Within(time.Second),
+	)
+
+or parsed from their compact string form (see ParsePattern):
+
+	p, err := trigger.ParsePattern("noteon C4, noteon E4 within=1s, noteon G4 vel=80..127 within=1s")
+
+A Matcher is fed with the incoming messages and their arrival times and calls its callback with the messages of
+each match:
+
+	m := trigger.NewMatcher(p, func(matched []trigger.Event) {
+		fmt.Println("C major!")
+	})
+
+	m.Feed(time.Since(start), msg)
+
+The Matcher follows several candidate matches at once, so that phrases may overlap and interleave with other
+messages (see NewMatcher).
+*/
+package trigger
@@ -0,0 +1,181 @@
+package trigger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gomidi/midi"
+)
+
+// Event is a message with its arrival time, as passed to Matcher.Feed
+type Event struct {
+	Time    time.Duration
+	Message midi.Message
+}
+
+// candidate is a match in progress: the events that matched the first steps
+type candidate struct {
+	events []Event
+}
+
+// Option is an option for a Matcher
+type Option func(*Matcher)
+
+// ResetOnMismatch drops a candidate match, as soon as a message does not match its next step. Messages whose type
+// does not occur in the pattern (e.g. the note off messages of a pattern of note on messages) are still skipped.
+// Without it, a candidate skips all messages that do not match its next step, until it times out.
+func ResetOnMismatch() Option {
+	return func(m *Matcher) {
+		m.reset = true
+	}
+}
+
+// NoOverlap drops all candidate matches after each match, so that a message is part of a single match only.
+func NoOverlap() Option {
+	return func(m *Matcher) {
+		m.noOverlap = true
+	}
+}
+
+// MaxCandidates limits the number of candidate matches. If a new candidate would exceed the limit, the oldest one
+// is dropped. Default is 64.
+func MaxCandidates(n int) Option {
+	return func(m *Matcher) {
+		m.max = n
+	}
+}
+
+// Matcher recognizes a Pattern in the messages that it is fed with and calls its callback for each match.
+// A Matcher is safe for concurrent use.
+type Matcher struct {
+	pattern   Pattern
+	fn        func(matched []Event)
+	reset     bool
+	noOverlap bool
+	max       int
+
+	mx         sync.Mutex
+	candidates []*candidate
+}
+
+// NewMatcher returns a Matcher for the given Pattern that calls fn with the events of each match.
+//
+// Each message that matches the first step starts a new candidate match. A message that matches the next step of
+// candidates advances the oldest of them that wait for the same step, so that interleaved phrases are matched
+// separately, first come first served. Since a message may advance candidates at different steps and start a new one
+// at the same time, matches may overlap (see NoOverlap). A candidate is dropped, when the time limit of its next step
+// (see Step.Within) has passed, or by a mismatch with the ResetOnMismatch option.
+func NewMatcher(p Pattern, fn func(matched []Event), options ...Option) *Matcher {
+	m := &Matcher{pattern: p, fn: fn, max: 64}
+
+	for _, opt := range options {
+		opt(m)
+	}
+
+	return m
+}
+
+// Compile parses the given pattern (see ParsePattern) and returns a Matcher for it (see NewMatcher)
+func Compile(pattern string, fn func(matched []Event), options ...Option) (*Matcher, error) {
+	p, err := ParsePattern(pattern)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMatcher(p, fn, options...), nil
+}
+
+// Pattern returns the Pattern of the Matcher
+func (m *Matcher) Pattern() Pattern {
+	return m.pattern
+}
+
+// Feed passes the given message that arrived at the given time to the Matcher. The times must not decrease.
+// The callback is called for each match that is completed by the message, after the state of the Matcher has
+// been updated, so it may call the methods of the Matcher.
+func (m *Matcher) Feed(at time.Duration, msg midi.Message) {
+	if len(m.pattern) == 0 {
+		return
+	}
+
+	m.mx.Lock()
+	in := parse(msg)
+	ev := Event{Time: at, Message: msg}
+
+	// advanced marks the steps whose oldest waiting candidate has been advanced by the message
+	var advanced = map[int]bool{}
+	var matches [][]Event
+	var kept []*candidate
+
+	for _, c := range m.candidates {
+		next := len(c.events)
+		step := m.pattern[next]
+
+		if step.within > 0 && at-c.events[next-1].Time > step.within {
+			continue
+		}
+
+		if !advanced[next] && step.matches(msg, in) {
+			advanced[next] = true
+			c.events = append(c.events, ev)
+
+			if len(c.events) == len(m.pattern) {
+				matches = append(matches, c.events)
+				continue
+			}
+		} else if m.reset && m.relevant(in) && !step.matches(msg, in) {
+			continue
+		}
+
+		kept = append(kept, c)
+	}
+
+	if m.pattern[0].matches(msg, in) {
+		if len(m.pattern) == 1 {
+			matches = append(matches, []Event{ev})
+		} else {
+			kept = append(kept, &candidate{events: []Event{ev}})
+		}
+	}
+
+	if m.max > 0 && len(kept) > m.max {
+		kept = kept[len(kept)-m.max:]
+	}
+
+	if m.noOverlap && len(matches) > 0 {
+		kept = nil
+		matches = matches[:1]
+	}
+
+	m.candidates = kept
+	m.mx.Unlock()
+
+	for _, matched := range matches {
+		m.fn(matched)
+	}
+}
+
+// relevant returns true, if the type of the message occurs in the pattern
+func (m *Matcher) relevant(in input) bool {
+	for _, s := range m.pattern {
+		if s.typ == anyType || s.typ == in.typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset drops all candidate matches
+func (m *Matcher) Reset() {
+	m.mx.Lock()
+	m.candidates = nil
+	m.mx.Unlock()
+}
+
+// Candidates returns the number of candidate matches in progress
+func (m *Matcher) Candidates() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return len(m.candidates)
+}
@@ -0,0 +1,185 @@
+package trigger
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// timed is a message that arrives at a time in milliseconds
+type timed struct {
+	ms  int
+	msg midi.Message
+}
+
+// feed feeds the messages to a Matcher of the given pattern and returns the matches, one per line, as the times
+// and keys (respectively the messages) of the matched messages
+func feed(t *testing.T, pattern string, msgs []timed, options ...Option) string {
+	var bf bytes.Buffer
+
+	m, err := Compile(pattern, func(matched []Event) {
+		for i, ev := range matched {
+			if i > 0 {
+				bf.WriteString(" ")
+			}
+
+			if on, is := ev.Message.(channel.NoteOn); is {
+				fmt.Fprintf(&bf, "%v:%s", ev.Time.Milliseconds(), channel.NoteName(on.Key()))
+			} else {
+				fmt.Fprintf(&bf, "%v:%s", ev.Time.Milliseconds(), ev.Message)
+			}
+		}
+		bf.WriteString("\n")
+	}, options...)
+
+	if err != nil {
+		t.Fatalf("Compile(%q) = %v", pattern, err)
+	}
+
+	for _, tm := range msgs {
+		m.Feed(time.Duration(tm.ms)*time.Millisecond, tm.msg)
+	}
+
+	return bf.String()
+}
+
+func TestMatcher(t *testing.T) {
+	ch := channel.Channel0
+	on := func(ms int, key uint8) timed { return timed{ms, ch.NoteOn(key, 100)} }
+	off := func(ms int, key uint8) timed { return timed{ms, ch.NoteOff(key)} }
+	ceg := "noteon C4, noteon E4 within=1s, noteon G4 within=1s"
+
+	tests := []struct {
+		descr   string
+		pattern string
+		msgs    []timed
+		options []Option
+		golden  string
+	}{
+		{
+			"ascending C-E-G with note offs between",
+			ceg,
+			[]timed{on(0, 60), off(200, 60), on(400, 64), off(600, 64), on(900, 67)},
+			nil,
+			"0:C4 400:E4 900:G4\n",
+		},
+		{
+			"too slow",
+			ceg,
+			[]timed{on(0, 60), on(1001, 64), on(1500, 67)},
+			nil,
+			"",
+		},
+		{
+			"other notes between are skipped",
+			ceg,
+			[]timed{on(0, 60), on(100, 62), on(200, 64), on(300, 65), on(400, 67)},
+			nil,
+			"0:C4 200:E4 400:G4\n",
+		},
+		{
+			"a mismatch resets the candidate",
+			ceg,
+			[]timed{on(0, 60), on(100, 62), on(200, 64), on(300, 67), on(400, 60), off(450, 60), on(500, 64), on(600, 67)},
+			[]Option{ResetOnMismatch()},
+			"400:C4 500:E4 600:G4\n",
+		},
+		{
+			"overlapping matches",
+			"noteon C4, noteon C4 within=1s, noteon C4 within=1s",
+			[]timed{on(0, 60), on(100, 60), on(200, 60), on(300, 60), on(400, 60)},
+			nil,
+			"0:C4 100:C4 200:C4\n100:C4 200:C4 300:C4\n200:C4 300:C4 400:C4\n",
+		},
+		{
+			"no overlapping matches",
+			"noteon C4, noteon C4 within=1s, noteon C4 within=1s",
+			[]timed{on(0, 60), on(100, 60), on(200, 60), on(300, 60), on(400, 60), on(500, 60)},
+			[]Option{NoOverlap()},
+			"0:C4 100:C4 200:C4\n300:C4 400:C4 500:C4\n",
+		},
+		{
+			"interleaved phrases are matched separately",
+			ceg,
+			[]timed{on(0, 60), on(50, 60), on(100, 64), on(150, 64), on(200, 67), on(250, 67)},
+			nil,
+			"0:C4 100:E4 200:G4\n50:C4 150:E4 250:G4\n",
+		},
+		{
+			"an interleaved phrase that times out leaves the other one",
+			ceg,
+			[]timed{on(0, 60), on(900, 60), on(1200, 64), on(1300, 67)},
+			nil,
+			"900:C4 1200:E4 1300:G4\n",
+		},
+		{
+			"the oldest candidate is dropped beyond the limit",
+			ceg,
+			[]timed{on(0, 60), on(10, 60), on(20, 60), on(100, 64), on(110, 64), on(120, 64), on(200, 67), on(210, 67), on(220, 67)},
+			[]Option{MaxCandidates(2)},
+			"10:C4 100:E4 200:G4\n20:C4 110:E4 210:G4\n",
+		},
+		{
+			"ranges and channels",
+			"noteon C4..B4 vel=90..127 ch=1, cc 64 value=64..127 within=500ms",
+			[]timed{
+				{0, ch.NoteOn(60, 80)},
+				{10, channel.Channel1.NoteOn(61, 100)},
+				{20, ch.NoteOn(72, 100)},
+				{30, ch.NoteOn(71, 100)},
+				{40, ch.ControlChange(64, 10)},
+				{50, ch.ControlChange(64, 127)},
+			},
+			nil,
+			"30:B4 50:channel.ControlChange channel 1 controller 64 (\"Hold Pedal (on/off)\") value 127 (on)\n",
+		},
+		{
+			"single step",
+			"noteon C4",
+			[]timed{on(0, 60), on(10, 62), on(20, 60)},
+			nil,
+			"0:C4\n20:C4\n",
+		},
+	}
+
+	for _, test := range tests {
+		if got, want := feed(t, test.pattern, test.msgs, test.options...), test.golden; got != want {
+			t.Errorf("%s:\ngot:\n%s\n\nwanted:\n%s\n\n", test.descr, got, want)
+		}
+	}
+}
+
+func TestMatcherReset(t *testing.T) {
+	ch := channel.Channel0
+	var matches int
+	var m *Matcher
+
+	// the callback may use the Matcher
+	m = NewMatcher(Sequence(NoteOn().Key(60), NoteOn().Key(64)), func([]Event) {
+		matches++
+		m.Reset()
+	})
+
+	m.Feed(0, ch.NoteOn(60, 100))
+	m.Feed(10, ch.NoteOn(60, 100))
+
+	if got, want := m.Candidates(), 2; got != want {
+		t.Errorf("Candidates() = %v; want %v", got, want)
+	}
+
+	m.Feed(20, ch.NoteOn(64, 100))
+
+	if got, want := m.Candidates(), 0; got != want {
+		t.Errorf("Candidates() after match = %v; want %v", got, want)
+	}
+
+	m.Feed(30, ch.NoteOn(64, 100))
+
+	if got, want := matches, 1; got != want {
+		t.Errorf("matches = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,420 @@
+package trigger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+// msgType is the type of message that a Step matches, as used in the string form of a Pattern
+type msgType string
+
+const (
+	noteOnType         msgType = "noteon"
+	noteOffType        msgType = "noteoff"
+	controlChangeType  msgType = "cc"
+	programChangeType  msgType = "program"
+	pitchbendType      msgType = "pitchbend"
+	aftertouchType     msgType = "aftertouch"
+	polyAftertouchType msgType = "polyaftertouch"
+
+	// anyType matches all messages
+	anyType msgType = "any"
+)
+
+// hasKey returns true, if the messages of the type have a key
+func (t msgType) hasKey() bool {
+	return t == noteOnType || t == noteOffType || t == polyAftertouchType
+}
+
+// hasNumber returns true, if the messages of the type have a key or controller
+func (t msgType) hasNumber() bool {
+	return t.hasKey() || t == controlChangeType
+}
+
+// valid returns true, if the type is one of the defined types
+func (t msgType) valid() bool {
+	switch t {
+	case noteOnType, noteOffType, controlChangeType, programChangeType, pitchbendType, aftertouchType, polyAftertouchType, anyType:
+		return true
+	}
+	return false
+}
+
+// Range is a range of values from Min to Max (both inclusive)
+type Range struct {
+	Min, Max uint8
+}
+
+// all is the range of all values
+var all = Range{0, 127}
+
+func (r Range) contains(v uint8) bool {
+	return v >= r.Min && v <= r.Max
+}
+
+// format returns the range in the string form of a pattern, with note names for keys
+func (r Range) format(keys bool) string {
+	f := func(v uint8) string { return strconv.Itoa(int(v)) }
+
+	if keys {
+		f = channel.NoteName
+	}
+
+	if r.Min == r.Max {
+		return f(r.Min)
+	}
+
+	return f(r.Min) + ".." + f(r.Max)
+}
+
+// Step matches a message of a Pattern. The Steps are created by the constructors of the types (e.g. NoteOn) and
+// refined by their methods, which return a modified copy.
+type Step struct {
+	typ msgType
+
+	// channel is the channel (0-15), -1 matches all channels
+	channel int
+
+	// numbers is the range of the keys or controllers, values the range of the velocity, controller value,
+	// program, pressure or pitch bend (scaled to 0-127)
+	numbers, values Range
+
+	// within is the maximal time since the message of the step before, 0 for no limit
+	within time.Duration
+
+	where func(midi.Message) bool
+}
+
+func newStep(typ msgType) Step {
+	return Step{typ: typ, channel: -1, numbers: all, values: all}
+}
+
+// NoteOn returns a Step that matches note on messages with a velocity above 0
+func NoteOn() Step { return newStep(noteOnType) }
+
+// NoteOff returns a Step that matches note off messages, including note on messages with a velocity of 0
+func NoteOff() Step { return newStep(noteOffType) }
+
+// ControlChange returns a Step that matches control change messages
+func ControlChange() Step { return newStep(controlChangeType) }
+
+// ProgramChange returns a Step that matches program change messages. Their program is the value of the Step.
+func ProgramChange() Step { return newStep(programChangeType) }
+
+// Pitchbend returns a Step that matches pitch bend messages. Their value is scaled to 0-127 (64 is the center).
+func Pitchbend() Step { return newStep(pitchbendType) }
+
+// Aftertouch returns a Step that matches aftertouch messages. Their pressure is the value of the Step.
+func Aftertouch() Step { return newStep(aftertouchType) }
+
+// PolyAftertouch returns a Step that matches polyphonic aftertouch messages. Their pressure is the value of the Step.
+func PolyAftertouch() Step { return newStep(polyAftertouchType) }
+
+// AnyMessage returns a Step that matches all messages, e.g. to be refined by Where
+func AnyMessage() Step { return newStep(anyType) }
+
+// Key limits the Step to the given key
+func (s Step) Key(key uint8) Step {
+	return s.Keys(key, key)
+}
+
+// Keys limits the Step to the keys from min to max (both inclusive)
+func (s Step) Keys(min, max uint8) Step {
+	s.numbers = Range{min, max}
+	return s
+}
+
+// Controller limits a Step of control change messages to the given controller
+func (s Step) Controller(controller uint8) Step {
+	return s.Keys(controller, controller)
+}
+
+// Velocity limits the Step to the velocities from min to max (both inclusive)
+func (s Step) Velocity(min, max uint8) Step {
+	return s.Values(min, max)
+}
+
+// Values limits the Step to the values from min to max (both inclusive): the velocity, controller value, program,
+// pressure or pitch bend (scaled to 0-127)
+func (s Step) Values(min, max uint8) Step {
+	s.values = Range{min, max}
+	return s
+}
+
+// Channel limits the Step to the given channel (0-15)
+func (s Step) Channel(ch uint8) Step {
+	s.channel = int(ch)
+	return s
+}
+
+// Within limits the time from the message of the step before to the message of the Step. It has no effect on
+// the first Step of a Pattern.
+func (s Step) Within(d time.Duration) Step {
+	s.within = d
+	return s
+}
+
+// Where adds a predicate that the message must fulfill. Predicates are not part of the string form of a Pattern.
+func (s Step) Where(fn func(midi.Message) bool) Step {
+	s.where = fn
+	return s
+}
+
+// input is a message, broken down into its parts
+type input struct {
+	typ     msgType
+	channel int
+
+	// number is the controller or key
+	number uint8
+	value  uint8
+}
+
+// parse returns the parts of the given message. Messages that are no channel messages match the steps of any message only.
+func parse(msg midi.Message) input {
+	switch v := msg.(type) {
+	case channel.NoteOn:
+		if v.Velocity() == 0 {
+			return input{noteOffType, int(v.Channel()), v.Key(), 0}
+		}
+		return input{noteOnType, int(v.Channel()), v.Key(), v.Velocity()}
+	case channel.NoteOff:
+		return input{noteOffType, int(v.Channel()), v.Key(), 0}
+	case channel.NoteOffVelocity:
+		return input{noteOffType, int(v.Channel()), v.Key(), v.Velocity()}
+	case channel.ControlChange:
+		return input{controlChangeType, int(v.Channel()), v.Controller(), v.Value()}
+	case channel.ProgramChange:
+		return input{programChangeType, int(v.Channel()), 0, v.Program()}
+	case channel.Pitchbend:
+		return input{pitchbendType, int(v.Channel()), 0, uint8((int(v.Value()) + 8192) >> 7)}
+	case channel.Aftertouch:
+		return input{aftertouchType, int(v.Channel()), 0, v.Pressure()}
+	case channel.PolyAftertouch:
+		return input{polyAftertouchType, int(v.Channel()), v.Key(), v.Pressure()}
+	}
+	return input{typ: anyType, channel: -1}
+}
+
+// matches returns true, if the Step matches the given message with the given parts
+func (s Step) matches(msg midi.Message, in input) bool {
+	if s.typ != anyType {
+		switch {
+		case s.typ != in.typ:
+			return false
+		case s.channel >= 0 && s.channel != in.channel:
+			return false
+		case s.typ.hasNumber() && !s.numbers.contains(in.number):
+			return false
+		case !s.values.contains(in.value):
+			return false
+		}
+	}
+
+	return s.where == nil || s.where(msg)
+}
+
+// String returns the Step in the string form of a Pattern (see ParsePattern)
+func (s Step) String() string {
+	var parts = []string{string(s.typ)}
+
+	if s.typ.hasNumber() && s.numbers != all {
+		parts = append(parts, s.numbers.format(s.typ.hasKey()))
+	}
+
+	if s.values != all && s.typ != anyType {
+		field := "value"
+
+		if s.typ == noteOnType || s.typ == noteOffType {
+			field = "vel"
+		}
+
+		parts = append(parts, field+"="+s.values.format(false))
+	}
+
+	if s.channel >= 0 && s.typ != anyType {
+		parts = append(parts, fmt.Sprintf("ch=%v", s.channel+1))
+	}
+
+	if s.within > 0 {
+		parts = append(parts, "within="+s.within.String())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Pattern is a sequence of Steps that are matched by successive messages, which may be interleaved with other
+// messages (see NewMatcher)
+type Pattern []Step
+
+// Sequence returns the Pattern of the given Steps
+func Sequence(steps ...Step) Pattern {
+	return Pattern(steps)
+}
+
+// String returns the string form of the Pattern (see ParsePattern)
+func (p Pattern) String() string {
+	var steps = make([]string, len(p))
+
+	for i, s := range p {
+		steps[i] = s.String()
+	}
+
+	return strings.Join(steps, ", ")
+}
+
+// ParsePattern parses the string form of a Pattern: the Steps separated by commas. A Step has the form
+//
+//	type [main] {field=value}
+//
+// The type is one of
+//
+//	noteon noteoff cc program pitchbend aftertouch polyaftertouch any
+//
+// The main value is the key of note and polyphonic aftertouch messages, the controller of control change messages
+// and the value of the other types. The fields are
+//
+//	key     key of note and polyphonic aftertouch messages
+//	cc      controller of control change messages
+//	vel     velocity of note messages
+//	value   value of the other messages (controller value, program, pressure, pitch bend scaled to 0-127)
+//	ch      channel, counted from 1 as in the message strings
+//	within  maximal time since the message of the step before, e.g. 1s or 500ms
+//
+// Keys may be given as note names (e.g. C4 for 60, see channel.ParseNoteNumber). Keys, controllers and values may
+// be a single value or a range of the form a..b that includes both ends. Example:
+//
+//	noteon C4, noteon E4 within=1s, noteon G4 vel=80..127 within=1s
+func ParsePattern(text string) (Pattern, error) {
+	var p Pattern
+
+	for i, st := range strings.Split(text, ",") {
+		s, err := parseStep(strings.Fields(st))
+
+		if err != nil {
+			return nil, fmt.Errorf("step %v: %v", i, err)
+		}
+
+		p = append(p, s)
+	}
+
+	return p, nil
+}
+
+// parseStep parses the fields of a step
+func parseStep(fields []string) (s Step, err error) {
+	if len(fields) == 0 {
+		return s, fmt.Errorf("missing type")
+	}
+
+	typ := msgType(strings.ToLower(fields[0]))
+
+	if !typ.valid() {
+		return s, fmt.Errorf("unknown type %q", fields[0])
+	}
+
+	s = newStep(typ)
+	fields = fields[1:]
+
+	if len(fields) > 0 && !strings.Contains(fields[0], "=") {
+		main := "value"
+
+		switch {
+		case typ == anyType:
+			return s, fmt.Errorf("unexpected %q", fields[0])
+		case typ.hasKey():
+			main = "key"
+		case typ == controlChangeType:
+			main = "cc"
+		}
+
+		fields[0] = main + "=" + fields[0]
+	}
+
+	for _, f := range fields {
+		name, value, _ := strings.Cut(f, "=")
+
+		if err := s.set(name, value); err != nil {
+			return s, err
+		}
+	}
+
+	return s, nil
+}
+
+// set sets the field of the given name of a step to the given value
+func (s *Step) set(name, value string) (err error) {
+	switch {
+	case name == "key" && s.typ.hasKey():
+		s.numbers, err = parseRange(value, true)
+	case name == "cc" && s.typ == controlChangeType:
+		s.numbers, err = parseRange(value, false)
+	case name == "vel" && (s.typ == noteOnType || s.typ == noteOffType), name == "value" && s.typ != anyType && s.typ != noteOnType && s.typ != noteOffType:
+		s.values, err = parseRange(value, false)
+	case name == "ch" && s.typ != anyType:
+		ch, perr := strconv.ParseUint(value, 10, 8)
+
+		if perr != nil || ch < 1 || ch > 16 {
+			return fmt.Errorf("invalid channel %q", value)
+		}
+
+		s.channel = int(ch) - 1
+	case name == "within":
+		d, perr := time.ParseDuration(value)
+
+		if perr != nil || d < 0 {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+
+		s.within = d
+	default:
+		return fmt.Errorf("invalid field %q for %s", name, s.typ)
+	}
+
+	return err
+}
+
+// parseRange parses a value or a range of the form a..b, with note names, if keys is true
+func parseRange(text string, keys bool) (r Range, err error) {
+	from, to, isRange := strings.Cut(text, "..")
+
+	if r.Min, err = parseValue(from, keys); err != nil {
+		return r, err
+	}
+
+	r.Max = r.Min
+
+	if isRange {
+		if r.Max, err = parseValue(to, keys); err != nil {
+			return r, err
+		}
+	}
+
+	if r.Min > r.Max {
+		return r, fmt.Errorf("invalid range %q", text)
+	}
+
+	return r, nil
+}
+
+// parseValue parses a value of 0-127, or a note name, if keys is true
+func parseValue(text string, keys bool) (uint8, error) {
+	v, err := strconv.ParseUint(text, 10, 8)
+
+	if err == nil && v <= 127 {
+		return uint8(v), nil
+	}
+
+	if keys {
+		if key, err := channel.ParseNoteNumber(text); err == nil {
+			return key, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid value %q", text)
+}
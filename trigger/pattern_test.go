@@ -0,0 +1,127 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/realtime"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"noteon C4, noteon E4 within=1s, noteon G4 vel=80..127 within=1s", "noteon C4, noteon E4 within=1s, noteon G4 vel=80..127 within=1s"},
+		{"NoteOn 60 ch=10", "noteon C4 ch=10"},
+		{"noteon key=C4..B4, noteoff key=60", "noteon C4..B4, noteoff C4"},
+		{"noteon C#4..Db5", "noteon C#4..C#5"},
+		{"cc 64 value=64..127", "cc 64 value=64..127"},
+		{"cc cc=1 ch=1 within=500ms", "cc 1 ch=1 within=500ms"},
+		{"program 5, pitchbend 64..127, aftertouch value=100", "program value=5, pitchbend value=64..127, aftertouch value=100"},
+		{"polyaftertouch A0 value=10..20", "polyaftertouch A0 value=10..20"},
+		{"any, any within=2s", "any, any within=2s"},
+		{"noteon", "noteon"},
+	}
+
+	for _, test := range tests {
+		p, err := ParsePattern(test.text)
+
+		if err != nil {
+			t.Errorf("ParsePattern(%q) = %v", test.text, err)
+			continue
+		}
+
+		if got, want := p.String(), test.expected; got != want {
+			t.Errorf("ParsePattern(%q) = %q; want %q", test.text, got, want)
+		}
+
+		// the string form parses to the same pattern
+		again, err := ParsePattern(p.String())
+
+		if err != nil || again.String() != p.String() {
+			t.Errorf("ParsePattern(%q) = %v, %v; want %q", p.String(), again, err, p.String())
+		}
+	}
+}
+
+func TestParsePatternErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"noteon C4,",
+		"chord C4",
+		"noteon H4",
+		"noteon 128",
+		"noteon G4..C4",
+		"noteon cc=1",
+		"cc vel=10",
+		"noteon value=10",
+		"noteon ch=0",
+		"noteon ch=17",
+		"noteon within=1",
+		"noteon within=-1s",
+		"any 60",
+		"any ch=1",
+		"noteon C4 C5",
+	}
+
+	for _, text := range tests {
+		if p, err := ParsePattern(text); err == nil {
+			t.Errorf("ParsePattern(%q) = %q; want error", text, p)
+		}
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	p := Sequence(
+		NoteOn().Key(60),
+		NoteOn().Keys(64, 65).Within(time.Second).Channel(9),
+		NoteOn().Key(67).Velocity(80, 127).Within(1500*time.Millisecond),
+		ControlChange().Controller(64).Values(64, 127),
+		AnyMessage().Where(func(msg midi.Message) bool { return msg == realtime.Start }),
+	)
+
+	expected := "noteon C4, noteon E4..F4 ch=10 within=1s, noteon G4 vel=80..127 within=1.5s, cc 64 value=64..127, any"
+
+	if got, want := p.String(), expected; got != want {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, want)
+	}
+}
+
+func TestStepMatches(t *testing.T) {
+	ch := channel.Channel2
+
+	tests := []struct {
+		step     Step
+		msg      midi.Message
+		expected bool
+	}{
+		{NoteOn().Key(60), ch.NoteOn(60, 100), true},
+		{NoteOn().Key(60), ch.NoteOn(61, 100), false},
+		{NoteOn().Key(60), ch.NoteOn(60, 0), false},
+		{NoteOff().Key(60), ch.NoteOn(60, 0), true},
+		{NoteOff().Key(60), ch.NoteOffVelocity(60, 30), true},
+		{NoteOn().Velocity(80, 127), ch.NoteOn(60, 79), false},
+		{NoteOn().Velocity(80, 127), ch.NoteOn(60, 80), true},
+		{NoteOn().Channel(2), ch.NoteOn(60, 80), true},
+		{NoteOn().Channel(3), ch.NoteOn(60, 80), false},
+		{ControlChange().Controller(7), ch.ControlChange(7, 0), true},
+		{ControlChange().Controller(7), ch.ControlChange(8, 0), false},
+		{ProgramChange().Values(5, 5), ch.ProgramChange(5), true},
+		{Pitchbend().Values(64, 127), ch.Pitchbend(0), true},
+		{Pitchbend().Values(64, 127), ch.Pitchbend(-1), false},
+		{PolyAftertouch().Key(60).Values(10, 20), ch.PolyAftertouch(60, 15), true},
+		{Aftertouch(), ch.PolyAftertouch(60, 15), false},
+		{NoteOn(), realtime.Start, false},
+		{AnyMessage(), realtime.Start, true},
+		{AnyMessage().Where(func(msg midi.Message) bool { return msg == realtime.Stop }), realtime.Start, false},
+	}
+
+	for _, test := range tests {
+		if got := test.step.matches(test.msg, parse(test.msg)); got != test.expected {
+			t.Errorf("%s matches %s = %v; want %v", test.step, test.msg, got, test.expected)
+		}
+	}
+}
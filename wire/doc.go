@@ -0,0 +1,21 @@
+// Copyright (c) 2017 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package wire provides a framing format for streams of MIDI messages outside of SMF, e.g. for chaining small tools
+with Unix pipes.
+
+Each message is written as a frame of the Magic byte, the delta time to the previous message in microseconds and
+the length of the raw bytes of the message (both as unsigned varints, see encoding/binary), followed by the
+raw bytes of the message. Since the Magic byte is an undefined status byte, a reader can resynchronize
+after corrupted data by searching for the next Magic byte.
+
+Cat, Filter and Play are the building blocks of a pipeline like
+
+	midicat in | transpose +2 | midicat out
+
+where Cat frames the messages of a MIDI port with their timing, Filter transforms the messages and Play writes
+the messages to a MIDI port in time.
+*/
+package wire
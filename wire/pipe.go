@@ -0,0 +1,117 @@
+package wire
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midiio"
+)
+
+// Cat reads the messages from src, e.g. a midireader of a MIDI port, and writes them as frames to dst.
+// The delta times are measured with the given clock (SystemClock, if nil), the first one from the call of Cat.
+// Cat returns nil, when src returns io.EOF.
+func Cat(dst io.Writer, src midi.Reader, clock midiio.Clock) error {
+	if clock == nil {
+		clock = midiio.SystemClock
+	}
+
+	last := clock.Now()
+
+	for {
+		msg, err := src.Read()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		now := clock.Now()
+
+		if err := Write(dst, now.Sub(last), msg); err != nil {
+			return err
+		}
+
+		last = now
+	}
+}
+
+// Filter reads the frames from src, passes their messages to fn and writes the returned messages as frames to dst.
+// The first returned message gets the delta time of the read message, the others a delta time of 0.
+// If fn returns no message, the delta time is added to the next written message, so that the timing is kept.
+//
+// Corrupted frames are skipped. Filter returns nil, when src ends.
+func Filter(dst io.Writer, src io.Reader, fn func(midi.Message) []midi.Message) error {
+	rd := bufio.NewReader(src)
+	var delta time.Duration
+
+	for {
+		d, msg, err := Read(rd)
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if errors.Is(err, ErrCorrupt) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		delta += d
+
+		for _, m := range fn(msg) {
+			if err := Write(dst, delta, m); err != nil {
+				return err
+			}
+			delta = 0
+		}
+	}
+}
+
+// Play reads the frames from src and writes their messages to dst, e.g. a midiwriter of a MIDI port, in time.
+// The times of the messages are measured with the given clock (SystemClock, if nil) from the call of Play
+// and don't drift by the time that is needed for reading and writing.
+//
+// Corrupted frames are skipped. Play returns nil, when src ends.
+func Play(dst midi.Writer, src io.Reader, clock midiio.Clock) error {
+	if clock == nil {
+		clock = midiio.SystemClock
+	}
+
+	rd := bufio.NewReader(src)
+	at := clock.Now()
+
+	for {
+		delta, msg, err := Read(rd)
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if errors.Is(err, ErrCorrupt) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		at = at.Add(delta)
+
+		if d := at.Sub(clock.Now()); d > 0 {
+			clock.Sleep(d)
+		}
+
+		if err := dst.Write(msg); err != nil {
+			return err
+		}
+	}
+}
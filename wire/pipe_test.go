@@ -0,0 +1,135 @@
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+// timedSource returns the messages as a midi.Reader, each after advancing the clock by its delay
+type timedSource struct {
+	clock  *fakeClock
+	delays []time.Duration
+	msgs   []midi.Message
+}
+
+func (s *timedSource) Read() (midi.Message, error) {
+	if len(s.msgs) == 0 {
+		return nil, io.EOF
+	}
+
+	msg := s.msgs[0]
+	s.clock.Sleep(s.delays[0])
+	s.msgs, s.delays = s.msgs[1:], s.delays[1:]
+	return msg, nil
+}
+
+// timedSink records the messages together with the time of writing
+type timedSink struct {
+	clock *fakeClock
+	start time.Time
+	bf    bytes.Buffer
+}
+
+func (s *timedSink) Write(msg midi.Message) error {
+	fmt.Fprintf(&s.bf, "%v %s\n", s.clock.now.Sub(s.start), msg)
+	return nil
+}
+
+// transpose returns a transform for Filter that transposes notes
+func transpose(semitones int) func(midi.Message) []midi.Message {
+	return func(msg midi.Message) []midi.Message {
+		switch v := msg.(type) {
+		case channel.NoteOn:
+			return []midi.Message{channel.Channel(v.Channel()).NoteOn(uint8(int(v.Key())+semitones), v.Velocity())}
+		case channel.NoteOffVelocity:
+			return []midi.Message{channel.Channel(v.Channel()).NoteOff(uint8(int(v.Key()) + semitones))}
+		case channel.NoteOff:
+			return []midi.Message{channel.Channel(v.Channel()).NoteOff(uint8(int(v.Key()) + semitones))}
+		}
+		return []midi.Message{msg}
+	}
+}
+
+// dropControllers is a transform for Filter that drops control change messages
+func dropControllers(msg midi.Message) []midi.Message {
+	if _, is := msg.(channel.ControlChange); is {
+		return nil
+	}
+	return []midi.Message{msg}
+}
+
+// octaves is a transform for Filter that adds the upper octave to notes
+func octaves(msg midi.Message) []midi.Message {
+	return append([]midi.Message{msg}, transpose(12)(msg)...)
+}
+
+// TestPipeline wires Cat, three Filters and Play through in-memory pipes
+func TestPipeline(t *testing.T) {
+	in := &fakeClock{now: time.Unix(0, 0)}
+	ch := channel.Channel0
+
+	src := &timedSource{
+		clock:  in,
+		delays: []time.Duration{10 * time.Millisecond, 0, 250 * time.Millisecond, 250 * time.Millisecond},
+		msgs:   []midi.Message{ch.NoteOn(60, 100), ch.ControlChange(7, 90), ch.ControlChange(7, 80), ch.NoteOff(60)},
+	}
+
+	filters := []func(midi.Message) []midi.Message{transpose(2), dropControllers, octaves}
+	errs := make(chan error, len(filters)+1)
+
+	r, w := io.Pipe()
+
+	go func() {
+		err := Cat(w, src, in)
+		w.Close()
+		errs <- err
+	}()
+
+	for _, fn := range filters {
+		next, w := io.Pipe()
+
+		go func(src io.Reader, fn func(midi.Message) []midi.Message) {
+			err := Filter(w, src, fn)
+			w.Close()
+			errs <- err
+		}(r, fn)
+
+		r = next
+	}
+
+	out := &fakeClock{now: time.Unix(100, 0)}
+	sink := &timedSink{clock: out, start: out.now}
+
+	if err := Play(sink, r, out); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	for range len(filters) + 1 {
+		if err := <-errs; err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+
+	expected := `10ms channel.NoteOn channel 1 key 62 velocity 100
+10ms channel.NoteOn channel 1 key 74 velocity 100
+510ms channel.NoteOff channel 1 key 62
+510ms channel.NoteOff channel 1 key 74
+`
+
+	if got := sink.bf.String(); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}
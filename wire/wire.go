@@ -0,0 +1,152 @@
+package wire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage"
+	"github.com/gomidi/midi/midimessage/status"
+)
+
+const (
+	// Magic starts each frame. It is the undefined status byte 0xFD.
+	Magic = 0xFD
+
+	// MaxLen is the maximal length of the raw bytes of a message
+	MaxLen = 1 << 20
+)
+
+// ErrCorrupt is returned by Read for a frame that is corrupted. The next Read resynchronizes with the stream.
+var ErrCorrupt = errors.New("corrupt frame")
+
+// Write writes the given message as a single frame with the given delta time to the previous message.
+// The delta time is written in microseconds, the rest is truncated.
+func Write(w io.Writer, delta time.Duration, msg midi.Message) error {
+	if delta < 0 {
+		return fmt.Errorf("can't write message %s: negative delta %v", msg, delta)
+	}
+
+	raw := msg.Raw()
+
+	if len(raw) == 0 || len(raw) > MaxLen {
+		return fmt.Errorf("can't write message %s: invalid length %v", msg, len(raw))
+	}
+
+	frame := make([]byte, 1, 1+2*binary.MaxVarintLen64+len(raw))
+	frame[0] = Magic
+	frame = binary.AppendUvarint(frame, uint64(delta/time.Microsecond))
+	frame = binary.AppendUvarint(frame, uint64(len(raw)))
+	frame = append(frame, raw...)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+// Read reads the next frame and returns its delta time and message.
+//
+// The bytes before the next Magic byte are skipped. If the frame after the Magic byte is corrupted, an error
+// wrapping ErrCorrupt is returned and the next Read searches for the next Magic byte.
+// A frame that is cut off by the end of the stream returns io.ErrUnexpectedEOF.
+func Read(r *bufio.Reader) (delta time.Duration, msg midi.Message, err error) {
+	for {
+		b, err := r.ReadByte()
+
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if b == Magic {
+			break
+		}
+	}
+
+	// the header is peeked byte by byte, so that a corrupted frame is skipped by its Magic byte only and
+	// a short frame is returned as soon as it is complete
+	header := &peeker{r: r}
+
+	micros, err := binary.ReadUvarint(header)
+
+	if err != nil {
+		return 0, nil, header.error("invalid delta")
+	}
+
+	if micros > math.MaxInt64/uint64(time.Microsecond) {
+		return 0, nil, fmt.Errorf("%w: invalid delta", ErrCorrupt)
+	}
+
+	length, err := binary.ReadUvarint(header)
+
+	if err != nil {
+		return 0, nil, header.error("invalid length")
+	}
+
+	if length == 0 || length > MaxLen {
+		return 0, nil, fmt.Errorf("%w: invalid length", ErrCorrupt)
+	}
+
+	st, err := header.ReadByte()
+
+	if err != nil {
+		return 0, nil, header.error("missing status byte")
+	}
+
+	if !status.IsStatus(st) || st == Magic {
+		return 0, nil, fmt.Errorf("%w: missing status byte", ErrCorrupt)
+	}
+
+	r.Discard(header.n - 1)
+	raw := make([]byte, length)
+
+	if _, err := io.ReadFull(r, raw); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+
+	msg, err = midimessage.UnmarshalMessage(raw)
+
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	return time.Duration(micros) * time.Microsecond, msg, nil
+}
+
+// peeker is an io.ByteReader that peeks the bytes of a bufio.Reader without consuming them
+type peeker struct {
+	r   *bufio.Reader
+	n   int
+	err error
+}
+
+func (p *peeker) ReadByte() (byte, error) {
+	b, err := p.r.Peek(p.n + 1)
+
+	if len(b) <= p.n {
+		p.err = err
+		return 0, err
+	}
+
+	p.n++
+	return b[p.n-1], nil
+}
+
+// error returns the error for a header that could not be read: the end of the stream is unexpected,
+// errors of the stream are returned as they are and anything else (an overflowing varint) is corrupt.
+func (p *peeker) error(what string) error {
+	switch p.err {
+	case nil:
+		return fmt.Errorf("%w: %s", ErrCorrupt, what)
+	case io.EOF:
+		return io.ErrUnexpectedEOF
+	default:
+		return p.err
+	}
+}
@@ -0,0 +1,162 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gomidi/midi"
+	"github.com/gomidi/midi/midimessage/channel"
+	"github.com/gomidi/midi/midimessage/meta"
+	"github.com/gomidi/midi/midimessage/realtime"
+	"github.com/gomidi/midi/midimessage/sysex"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		delta    time.Duration
+		msg      midi.Message
+		expected string
+	}{
+		{0, channel.Channel2.NoteOn(60, 100), "FD 00 03 92 3C 64"},
+		{1500 * time.Microsecond, realtime.TimingClock, "FD DC 0B 01 F8"},
+		{time.Second, sysex.SysEx{0x41, 0x10}, "FD C0 84 3D 04 F0 41 10 F7"},
+	}
+
+	for _, test := range tests {
+		var bf bytes.Buffer
+
+		if err := Write(&bf, test.delta, test.msg); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		if got := fmt.Sprintf("% X", bf.Bytes()); got != test.expected {
+			t.Errorf("Write(%v, %s) = %s; want %s", test.delta, test.msg, got, test.expected)
+		}
+	}
+
+	if err := Write(io.Discard, -time.Second, realtime.Start); err == nil {
+		t.Errorf("Write with a negative delta must return an error")
+	}
+}
+
+func TestRead(t *testing.T) {
+	msgs := []midi.Message{
+		channel.Channel0.NoteOn(60, 100),
+		realtime.TimingClock,
+		sysex.SysEx{0x41, 0x10, 0x42},
+		meta.Text("meta messages are fine too"),
+		channel.Channel0.NoteOff(60),
+	}
+
+	var bf bytes.Buffer
+
+	for i, msg := range msgs {
+		if err := Write(&bf, time.Duration(i)*time.Millisecond, msg); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+
+	rd := bufio.NewReader(&bf)
+
+	for i, want := range msgs {
+		delta, msg, err := Read(rd)
+
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+
+		if delta != time.Duration(i)*time.Millisecond || msg.String() != want.String() {
+			t.Errorf("Read() = %v, %s; want %v, %s", delta, msg, time.Duration(i)*time.Millisecond, want)
+		}
+	}
+
+	if _, _, err := Read(rd); err != io.EOF {
+		t.Errorf("Read() = %v; want io.EOF", err)
+	}
+}
+
+func TestReadPipe(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	go Write(pw, time.Millisecond, channel.Channel0.NoteOn(60, 100))
+
+	type result struct {
+		delta time.Duration
+		msg   midi.Message
+		err   error
+	}
+
+	done := make(chan result)
+
+	go func() {
+		delta, msg, err := Read(bufio.NewReader(pr))
+		done <- result{delta, msg, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			t.Fatalf("Error: %v", res.err)
+		}
+
+		if got, want := fmt.Sprintf("%v %s", res.delta, res.msg), "1ms channel.NoteOn channel 1 key 60 velocity 100"; got != want {
+			t.Errorf("Read() = %s; want %s", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the frame is not delivered while the pipe is open")
+	}
+}
+
+func TestReadResync(t *testing.T) {
+	var bf bytes.Buffer
+
+	Write(&bf, time.Millisecond, channel.Channel0.NoteOn(60, 100))
+	// garbage before a frame
+	bf.Write([]byte{0x12, 0x90, 0x40})
+	Write(&bf, 2*time.Millisecond, channel.Channel0.NoteOn(62, 100))
+	// a frame with a corrupted length, that swallows the next frame
+	bf.Write([]byte{Magic, 0x00, 0x05, 0x90, 0x40})
+	Write(&bf, 3*time.Millisecond, channel.Channel0.NoteOn(64, 100))
+	// a frame whose length is corrupted to 0
+	bf.Write([]byte{Magic, 0x00, 0x00})
+	Write(&bf, 4*time.Millisecond, channel.Channel0.NoteOn(65, 100))
+	// a frame that is cut off
+	bf.Write([]byte{Magic, 0x00, 0x03, 0x90})
+
+	rd := bufio.NewReader(&bf)
+	var res bytes.Buffer
+
+	for {
+		delta, msg, err := Read(rd)
+
+		if errors.Is(err, ErrCorrupt) {
+			fmt.Fprintf(&res, "corrupt\n")
+			continue
+		}
+
+		if err != nil {
+			fmt.Fprintf(&res, "%v\n", err)
+			break
+		}
+
+		fmt.Fprintf(&res, "%v %s\n", delta, msg)
+	}
+
+	expected := `1ms channel.NoteOn channel 1 key 60 velocity 100
+2ms channel.NoteOn channel 1 key 62 velocity 100
+corrupt
+corrupt
+4ms channel.NoteOn channel 1 key 65 velocity 100
+unexpected EOF
+`
+
+	if got := res.String(); got != expected {
+		t.Errorf("got:\n%s\n\nwanted:\n%s\n\n", got, expected)
+	}
+}